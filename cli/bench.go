@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+	"github.com/griffincancode/polyglot.js/runtimes/javascript"
+	"github.com/griffincancode/polyglot.js/runtimes/lua"
+	"github.com/griffincancode/polyglot.js/runtimes/python"
+	"github.com/griffincancode/polyglot.js/runtimes/ruby"
+)
+
+// BenchSnippet is a piece of code timed by polyglot bench
+type BenchSnippet struct {
+	Name string
+	Code string
+}
+
+// benchSnippets are representative workloads for each benchmarkable
+// language. Code is self-contained, since consecutive runs may land on
+// different workers.
+var benchSnippets = map[string][]BenchSnippet{
+	"python": {
+		{"arithmetic", "sum(i * i for i in range(1000))"},
+		{"string ops", "'-'.join(str(i) for i in range(100)).upper()"},
+		{"function calls", "def fib(n):\n    return n if n < 2 else fib(n - 1) + fib(n - 2)\nfib(15)"},
+		{"data structures", "len({i: [i] * 3 for i in range(200)})"},
+	},
+	"javascript": {
+		{"arithmetic", "(() => { let s = 0; for (let i = 0; i < 1000; i++) s += i * i; return s })()"},
+		{"string ops", "Array.from({length: 100}, (_, i) => String(i)).join('-').toUpperCase()"},
+		{"function calls", "(() => { const fib = n => n < 2 ? n : fib(n - 1) + fib(n - 2); return fib(15) })()"},
+		{"data structures", "(() => { const m = new Map(); for (let i = 0; i < 200; i++) m.set(i, [i, i, i]); return m.size })()"},
+	},
+	"lua": {
+		{"arithmetic", "local s = 0 for i = 1, 1000 do s = s + i * i end return s"},
+		{"string ops", "local t = {} for i = 1, 100 do t[#t + 1] = tostring(i) end return string.upper(table.concat(t, '-'))"},
+		{"function calls", "local function fib(n) if n < 2 then return n end return fib(n - 1) + fib(n - 2) end return fib(15)"},
+		{"data structures", "local t = {} for i = 1, 200 do t[i] = {i, i, i} end return #t"},
+	},
+	"ruby": {
+		{"arithmetic", "(0...1000).sum { |i| i * i }"},
+		{"string ops", "(0...100).map(&:to_s).join('-').upcase"},
+		{"function calls", "fib = ->(n) { n < 2 ? n : fib.(n - 1) + fib.(n - 2) }; fib.(15)"},
+		{"data structures", "(0...200).to_h { |i| [i, [i] * 3] }.size"},
+	},
+}
+
+// benchRuntimes creates the runtime for each benchmarkable language
+var benchRuntimes = map[string]func() core.Runtime{
+	"python":     func() core.Runtime { return python.NewRuntime() },
+	"javascript": func() core.Runtime { return javascript.NewRuntime() },
+	"lua":        func() core.Runtime { return lua.NewRuntime() },
+	"ruby":       func() core.Runtime { return ruby.NewRuntime() },
+}
+
+// BenchOptions configures a benchmark run
+type BenchOptions struct {
+	// Iterations is the number of times each snippet runs
+	Iterations int
+
+	// Concurrency is the number of executions in flight at once
+	Concurrency int
+}
+
+// BenchStats summarizes the timings of one snippet
+type BenchStats struct {
+	Name   string
+	Count  int
+	Errors int
+	Min    time.Duration
+	Mean   time.Duration
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+
+	// Throughput is successful executions per second of wall time
+	Throughput float64
+
+	// Err is the first execution error, if any
+	Err error
+}
+
+// computeBenchStats aggregates successful execution timings measured over
+// wall time
+func computeBenchStats(name string, timings []time.Duration, errors int, wall time.Duration) BenchStats {
+	summary := core.SummarizeTimings(timings)
+	stats := BenchStats{
+		Name:   name,
+		Count:  summary.Iterations,
+		Errors: errors,
+		Min:    summary.Min,
+		Mean:   summary.Mean,
+		P50:    summary.P50,
+		P90:    summary.P90,
+		P99:    summary.P99,
+		Max:    summary.Max,
+	}
+	if len(timings) > 0 && wall > 0 {
+		stats.Throughput = float64(len(timings)) / wall.Seconds()
+	}
+	return stats
+}
+
+// runBench times each snippet through rt
+func runBench(ctx context.Context, rt core.Runtime, snippets []BenchSnippet, opts BenchOptions) []BenchStats {
+	results := make([]BenchStats, 0, len(snippets))
+	for _, snippet := range snippets {
+		results = append(results, benchSnippet(ctx, rt, snippet, opts))
+	}
+	return results
+}
+
+func benchSnippet(ctx context.Context, rt core.Runtime, snippet BenchSnippet, opts BenchOptions) BenchStats {
+	var (
+		mu       sync.Mutex
+		timings  = make([]time.Duration, 0, opts.Iterations)
+		errors   int
+		firstErr error
+		next     int
+		wg       sync.WaitGroup
+	)
+
+	start := time.Now()
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if next >= opts.Iterations {
+					mu.Unlock()
+					return
+				}
+				next++
+				mu.Unlock()
+
+				began := time.Now()
+				_, err := rt.Execute(ctx, snippet.Code)
+				elapsed := time.Since(began)
+
+				mu.Lock()
+				if err != nil {
+					errors++
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					timings = append(timings, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := computeBenchStats(snippet.Name, timings, errors, time.Since(start))
+	stats.Err = firstErr
+	return stats
+}
+
+// printBenchStats writes results as a table
+func printBenchStats(out io.Writer, results []BenchStats) {
+	fmt.Fprintf(out, "%-16s %10s %10s %10s %10s %10s %7s\n", "snippet", "ops/s", "p50", "p90", "p99", "max", "errors")
+	for _, r := range results {
+		fmt.Fprintf(out, "%-16s %10.1f %10s %10s %10s %10s %7d\n",
+			r.Name, r.Throughput, formatBenchDuration(r.P50), formatBenchDuration(r.P90),
+			formatBenchDuration(r.P99), formatBenchDuration(r.Max), r.Errors)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(out, "%s: %v\n", r.Name, r.Err)
+		}
+	}
+}
+
+func formatBenchDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.1fµs", float64(d)/float64(time.Microsecond))
+	}
+}
+
+// benchLanguage normalizes a language name and checks it can be
+// benchmarked
+func benchLanguage(lang string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(lang))
+	if alias, ok := languageAliases[name]; ok {
+		name = alias
+	}
+	if _, ok := benchSnippets[name]; !ok {
+		supported := make([]string, 0, len(benchSnippets))
+		for l := range benchSnippets {
+			supported = append(supported, l)
+		}
+		sort.Strings(supported)
+		return "", fmt.Errorf("no benchmarks for %s (supported: %s)", lang, strings.Join(supported, ", "))
+	}
+	return name, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+func TestComputeBenchStats(t *testing.T) {
+	// 1ms..100ms, shuffled
+	timings := make([]time.Duration, 0, 100)
+	for i := 0; i < 100; i++ {
+		timings = append(timings, time.Duration((i*37)%100+1)*time.Millisecond)
+	}
+
+	stats := computeBenchStats("synthetic", timings, 2, 2*time.Second)
+
+	checks := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"min", stats.Min, time.Millisecond},
+		{"max", stats.Max, 100 * time.Millisecond},
+		{"mean", stats.Mean, 50500 * time.Microsecond},
+		{"p50", stats.P50, 50 * time.Millisecond},
+		{"p90", stats.P90, 90 * time.Millisecond},
+		{"p99", stats.P99, 99 * time.Millisecond},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if stats.Count != 100 || stats.Errors != 2 {
+		t.Errorf("count %d errors %d, want 100 and 2", stats.Count, stats.Errors)
+	}
+	if stats.Throughput != 50 {
+		t.Errorf("throughput = %v, want 50", stats.Throughput)
+	}
+	if timings[0] != time.Millisecond {
+		t.Error("computeBenchStats reordered the caller's timings")
+	}
+}
+
+func TestComputeBenchStatsSmallSamples(t *testing.T) {
+	stats := computeBenchStats("one", []time.Duration{7 * time.Millisecond}, 0, time.Second)
+	if stats.P50 != 7*time.Millisecond || stats.P99 != 7*time.Millisecond {
+		t.Errorf("single sample percentiles = %v/%v, want 7ms", stats.P50, stats.P99)
+	}
+
+	stats = computeBenchStats("none", nil, 3, time.Second)
+	if stats.Count != 0 || stats.P99 != 0 || stats.Throughput != 0 {
+		t.Errorf("empty timings gave %+v", stats)
+	}
+}
+
+// countingRuntime fails every third execution
+type countingRuntime struct {
+	calls int64
+}
+
+func (r *countingRuntime) Initialize(ctx context.Context, config core.RuntimeConfig) error {
+	return nil
+}
+
+func (r *countingRuntime) Execute(ctx context.Context, code string, args ...interface{}) (interface{}, error) {
+	if atomic.AddInt64(&r.calls, 1)%3 == 0 {
+		return nil, errors.New("boom")
+	}
+	return nil, nil
+}
+
+func (r *countingRuntime) Call(ctx context.Context, fn string, args ...interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *countingRuntime) Shutdown(ctx context.Context) error { return nil }
+func (r *countingRuntime) Name() string                       { return "counting" }
+func (r *countingRuntime) Version() string                    { return "1.0" }
+
+func TestRunBench(t *testing.T) {
+	rt := &countingRuntime{}
+	snippets := []BenchSnippet{{"a", "a"}, {"b", "b"}}
+
+	results := runBench(context.Background(), rt, snippets, BenchOptions{Iterations: 30, Concurrency: 4})
+
+	if rt.calls != 60 {
+		t.Errorf("executions = %d, want 60", rt.calls)
+	}
+	if len(results) != 2 || results[0].Name != "a" || results[1].Name != "b" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	for _, r := range results {
+		if r.Count+r.Errors != 30 || r.Errors != 10 || r.Err == nil {
+			t.Errorf("%s: count %d errors %d err %v, want 20, 10 and an error", r.Name, r.Count, r.Errors, r.Err)
+		}
+	}
+}
+
+func TestBenchLanguage(t *testing.T) {
+	if lang, err := benchLanguage("JS"); err != nil || lang != "javascript" {
+		t.Errorf("benchLanguage(JS) = %q, %v", lang, err)
+	}
+	if _, err := benchLanguage("cobol"); err == nil {
+		t.Error("expected error for a language without benchmarks")
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// projectConfigFile is the project configuration written by polyglot init
+const projectConfigFile = "polyglot.config.json"
+
+// BuildSettings describes how to build a project with the Go toolchain
+type BuildSettings struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Languages []string `json:"languages"`
+	Runtimes  map[string]struct {
+		Enabled bool `json:"enabled"`
+	} `json:"runtimes"`
+	Build struct {
+		OutputPath string         `json:"outputPath"`
+		Optimize   bool           `json:"optimize"`
+		Matrix     []MatrixTarget `json:"matrix"`
+	} `json:"build"`
+	Signing struct {
+		Certificate   string `json:"certificate"`
+		Notarize      bool   `json:"notarize"`
+		NotaryProfile string `json:"notaryProfile"`
+	} `json:"signing"`
+
+	// Platform and Arch select a cross-compilation target
+	Platform string `json:"-"`
+	Arch     string `json:"-"`
+
+	// Stub forces stub runtimes and the stub webview backend
+	Stub bool `json:"-"`
+}
+
+// runtimeTags maps language names to the build tag enabling their runtime
+var runtimeTags = map[string]string{
+	"python":     "runtime_python",
+	"javascript": "runtime_javascript",
+	"go":         "runtime_go",
+	"rust":       "runtime_rust",
+	"cpp":        "runtime_cpp",
+	"java":       "runtime_java",
+	"ruby":       "runtime_ruby",
+	"php":        "runtime_php",
+	"lua":        "runtime_lua",
+	"wasm":       "runtime_wasm",
+	"zig":        "runtime_zig",
+}
+
+// languageAliases normalizes alternate language spellings
+var languageAliases = map[string]string{
+	"js":          "javascript",
+	"golang":      "go",
+	"c++":         "cpp",
+	"webassembly": "wasm",
+}
+
+// RuntimeBuildTags maps languages to sorted, de-duplicated runtime build
+// tags. Unrecognized languages are returned separately.
+func RuntimeBuildTags(languages []string) (tags []string, unknown []string) {
+	seen := make(map[string]bool)
+	for _, lang := range languages {
+		name := strings.ToLower(strings.TrimSpace(lang))
+		if alias, ok := languageAliases[name]; ok {
+			name = alias
+		}
+
+		tag, ok := runtimeTags[name]
+		if !ok {
+			unknown = append(unknown, lang)
+			continue
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	sort.Strings(tags)
+	return tags, unknown
+}
+
+// EnabledLanguages returns the languages whose runtimes are enabled. A
+// language without a runtimes entry is treated as enabled.
+func (b *BuildSettings) EnabledLanguages() []string {
+	enabled := make([]string, 0, len(b.Languages))
+	for _, lang := range b.Languages {
+		if rt, ok := b.Runtimes[lang]; ok && !rt.Enabled {
+			continue
+		}
+		enabled = append(enabled, lang)
+	}
+	return enabled
+}
+
+// LoadBuildSettings reads build settings from a project configuration file
+func LoadBuildSettings(path string) (*BuildSettings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var settings BuildSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if settings.Name == "" {
+		return nil, fmt.Errorf("%s: project name is required", path)
+	}
+	if settings.Build.OutputPath == "" {
+		settings.Build.OutputPath = "./dist"
+	}
+	if err := validateMatrix(settings.Build.Matrix); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if _, unknown := RuntimeBuildTags(settings.Languages); len(unknown) > 0 {
+		fmt.Printf("⚠️  No runtime build tag for: %s\n", strings.Join(unknown, ", "))
+	}
+
+	return &settings, nil
+}
+
+// Tags returns the Go build tags for the project's enabled runtimes, or
+// only the stub tag when stubs are forced
+func (b *BuildSettings) Tags() []string {
+	if b.Stub {
+		return []string{"stub"}
+	}
+
+	tags, _ := RuntimeBuildTags(b.EnabledLanguages())
+	return tags
+}
+
+// LDFlags returns linker flags stamping version information
+func (b *BuildSettings) LDFlags() string {
+	flags := []string{fmt.Sprintf("-X main.version=%s", b.Version)}
+	if b.Build.Optimize {
+		flags = append(flags, "-s", "-w")
+	}
+	return strings.Join(flags, " ")
+}
+
+// Binary returns the output path of the built executable
+func (b *BuildSettings) Binary() string {
+	name := b.Name
+	if b.Platform != "" && b.Arch != "" {
+		name = fmt.Sprintf("%s-%s-%s", b.Name, b.Platform, b.Arch)
+	}
+	if b.Platform == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(b.Build.OutputPath, name)
+}
+
+// GoArgs builds the go command arguments for build, run, or test
+func (b *BuildSettings) GoArgs(action string) []string {
+	args := []string{action}
+
+	if tags := b.Tags(); len(tags) > 0 {
+		args = append(args, "-tags", strings.Join(tags, ","))
+	}
+
+	switch action {
+	case "build":
+		args = append(args, "-ldflags", b.LDFlags(), "-o", b.Binary(), "./src/backend")
+	case "run":
+		args = append(args, "-ldflags", b.LDFlags(), "./src/backend")
+	case "test":
+		args = append(args, "./...")
+	}
+
+	return args
+}
+
+// Env returns the environment for the go command
+func (b *BuildSettings) Env() []string {
+	env := os.Environ()
+	if b.Platform != "" {
+		env = append(env, "GOOS="+b.Platform)
+	}
+	if b.Arch != "" {
+		env = append(env, "GOARCH="+b.Arch)
+	}
+	return env
+}
+
+// Command creates the go command for an action
+func (b *BuildSettings) Command(action string) *exec.Cmd {
+	cmd := exec.Command("go", b.GoArgs(action)...)
+	cmd.Env = b.Env()
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// preferMake reports whether make was requested and is usable
+func preferMake(args []string) bool {
+	if !contains(args, "--make") {
+		return false
+	}
+	if _, err := exec.LookPath("make"); err != nil {
+		fmt.Println("⚠️  make not found, using the Go toolchain directly")
+		return false
+	}
+	if _, err := os.Stat("Makefile"); err != nil {
+		fmt.Println("⚠️  Makefile not found, using the Go toolchain directly")
+		return false
+	}
+	return true
+}
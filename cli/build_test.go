@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadBuildSettings(t *testing.T) {
+	settings, err := LoadBuildSettings(filepath.Join("testdata", "golden", "webapp", "polyglot.config.json.golden"))
+	if err != nil {
+		t.Fatalf("LoadBuildSettings failed: %v", err)
+	}
+
+	if settings.Name != "goldenapp" || settings.Version != "1.2.3" {
+		t.Errorf("Unexpected name/version: %s %s", settings.Name, settings.Version)
+	}
+	if settings.Build.OutputPath != "./dist" {
+		t.Errorf("Expected output path ./dist, got %s", settings.Build.OutputPath)
+	}
+
+	path := filepath.Join(t.TempDir(), projectConfigFile)
+	os.WriteFile(path, []byte(`{"version": "1.0.0"}`), 0644)
+	if _, err := LoadBuildSettings(path); err == nil {
+		t.Error("Expected error for config without a name")
+	}
+}
+
+func TestGoArgs(t *testing.T) {
+	settings := &BuildSettings{
+		Name:      "myapp",
+		Version:   "0.2.0",
+		Languages: []string{"python", "javascript"},
+	}
+	settings.Build.OutputPath = "dist"
+
+	tests := []struct {
+		action string
+		want   []string
+	}{
+		{"build", []string{"build", "-tags", "runtime_javascript,runtime_python", "-ldflags", "-X main.version=0.2.0", "-o", filepath.Join("dist", "myapp"), "./src/backend"}},
+		{"run", []string{"run", "-tags", "runtime_javascript,runtime_python", "-ldflags", "-X main.version=0.2.0", "./src/backend"}},
+		{"test", []string{"test", "-tags", "runtime_javascript,runtime_python", "./..."}},
+	}
+
+	for _, tt := range tests {
+		if got := settings.GoArgs(tt.action); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GoArgs(%s) = %v, want %v", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestGoArgsCrossCompile(t *testing.T) {
+	settings := &BuildSettings{Name: "myapp", Version: "1.0.0", Platform: "windows", Arch: "amd64"}
+	settings.Build.OutputPath = "dist"
+	settings.Build.Optimize = true
+
+	args := settings.GoArgs("build")
+	if contains(args, "-tags") {
+		t.Errorf("Expected no tags without languages, got %v", args)
+	}
+	if !contains(args, filepath.Join("dist", "myapp-windows-amd64.exe")) {
+		t.Errorf("Expected platform binary name, got %v", args)
+	}
+	if !contains(args, "-X main.version=1.0.0 -s -w") {
+		t.Errorf("Expected optimized ldflags, got %v", args)
+	}
+
+	env := strings.Join(settings.Env(), " ")
+	if !strings.Contains(env, "GOOS=windows") || !strings.Contains(env, "GOARCH=amd64") {
+		t.Error("Expected GOOS/GOARCH in environment")
+	}
+}
+
+func TestRuntimeBuildTags(t *testing.T) {
+	tests := []struct {
+		languages []string
+		want      string
+		unknown   []string
+	}{
+		{[]string{"python"}, "runtime_python", nil},
+		{[]string{"python", "javascript"}, "runtime_javascript,runtime_python", nil},
+		{[]string{"rust", "Go", "zig", "wasm"}, "runtime_go,runtime_rust,runtime_wasm,runtime_zig", nil},
+		{[]string{"js", "javascript", "c++"}, "runtime_cpp,runtime_javascript", nil},
+		{[]string{"python", "cobol"}, "runtime_python", []string{"cobol"}},
+		{nil, "", nil},
+	}
+
+	for _, tt := range tests {
+		tags, unknown := RuntimeBuildTags(tt.languages)
+		if got := strings.Join(tags, ","); got != tt.want {
+			t.Errorf("RuntimeBuildTags(%v) = %q, want %q", tt.languages, got, tt.want)
+		}
+		if !reflect.DeepEqual(unknown, tt.unknown) {
+			t.Errorf("RuntimeBuildTags(%v) unknown = %v, want %v", tt.languages, unknown, tt.unknown)
+		}
+	}
+}
+
+func TestBuildTagsFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), projectConfigFile)
+	config := `{
+  "name": "tagged",
+  "version": "1.0.0",
+  "languages": ["python", "ruby", "lua"],
+  "runtimes": {
+    "python": {"enabled": true},
+    "ruby": {"enabled": false}
+  }
+}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadBuildSettings(path)
+	if err != nil {
+		t.Fatalf("LoadBuildSettings failed: %v", err)
+	}
+
+	// Disabled runtimes are excluded; languages without an entry are kept
+	if got := strings.Join(settings.Tags(), ","); got != "runtime_lua,runtime_python" {
+		t.Errorf("Expected runtime_lua,runtime_python, got %q", got)
+	}
+
+	settings.Stub = true
+	args := settings.GoArgs("build")
+	if got := strings.Join(args[:3], " "); got != "build -tags stub" {
+		t.Errorf("Expected stub tags, got %v", args)
+	}
+}
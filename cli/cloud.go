@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/griffincancode/polyglot.js/cloud"
+)
+
+// errNoCloudService is returned by --cloud and --matrix builds when no
+// cloud build service is configured. The in-memory builder in package
+// cloud only returns placeholder bytes for tests, so it is never used in
+// its place.
+var errNoCloudService = errors.New("no cloud build service configured")
+
+// cloudService connects to the cloud build service, returning an
+// authenticated client. The CLI ships without one; distributions that
+// provide a build service set it.
+var cloudService func(ctx context.Context) (cloud.Client, error)
+
+// cloudClient returns a client for the configured cloud build service
+func cloudClient(ctx context.Context) (cloud.Client, error) {
+	if cloudService == nil {
+		return nil, fmt.Errorf("%w; build locally without --cloud or --matrix", errNoCloudService)
+	}
+	return cloudService(ctx)
+}
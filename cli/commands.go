@@ -1,26 +1,39 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+	"github.com/griffincancode/polyglot.js/signing"
 )
 
 const version = "0.1.0"
 
 func handleInit(args []string) {
-	fmt.Println()
-	fmt.Println("╔═══════════════════════════════════════════════════════╗")
-	fmt.Println("║                                                       ║")
-	fmt.Println("║       POLYGLOT PROJECT INITIALIZATION WIZARD          ║")
-	fmt.Println("║                                                       ║")
-	fmt.Println("╚═══════════════════════════════════════════════════════╝")
-	fmt.Println()
+	core.Banner(os.Stdout, core.QuietFromEnv(), "", "POLYGLOT PROJECT INITIALIZATION WIZARD", "")
 
 	var config *ProjectConfig
 	var err error
 
+	// Extract a custom template directory, if provided
+	templateDir, args := extractFlag(args, "--template-dir")
+	if templateDir != "" {
+		if info, err := os.Stat(templateDir); err != nil || !info.IsDir() {
+			fmt.Printf("❌ Error: template directory '%s' not found\n", templateDir)
+			os.Exit(1)
+		}
+	}
+
 	// Check if non-interactive mode
 	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
 		// Quick mode: just provide name
@@ -53,6 +66,8 @@ func handleInit(args []string) {
 		}
 	}
 
+	config.TemplateDir = templateDir
+
 	// Check if project already exists
 	if _, err := os.Stat(config.Name); !os.IsNotExist(err) {
 		fmt.Printf("\n❌ Error: Directory '%s' already exists!\n", config.Name)
@@ -163,13 +178,7 @@ func handleInit(args []string) {
 	os.Chdir(originalDir)
 
 	// Success!
-	fmt.Println()
-	fmt.Println("╔═══════════════════════════════════════════════════════╗")
-	fmt.Println("║                                                       ║")
-	fmt.Println("║              ✨ PROJECT CREATED SUCCESSFULLY! ✨       ║")
-	fmt.Println("║                                                       ║")
-	fmt.Println("╚═══════════════════════════════════════════════════════╝")
-	fmt.Println()
+	core.Banner(os.Stdout, core.QuietFromEnv(), "", "✨ PROJECT CREATED SUCCESSFULLY! ✨", "")
 	fmt.Println("🎉 Your Polyglot project is ready!")
 	fmt.Println()
 	fmt.Println("📚 Next steps:")
@@ -201,41 +210,68 @@ func handleInit(args []string) {
 	fmt.Println()
 }
 
+// extractFlag removes "--name value" from args and returns the value
+func extractFlag(args []string, name string) (string, []string) {
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			value := args[i+1]
+			rest := append([]string{}, args[:i]...)
+			return value, append(rest, args[i+2:]...)
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			rest := append([]string{}, args[:i]...)
+			return strings.TrimPrefix(arg, name+"="), append(rest, args[i+1:]...)
+		}
+	}
+	return "", args
+}
+
 func handleBuild(args []string) {
 	fmt.Println("🔨 Building application...")
 
 	// Check if we're in a project directory
-	if _, err := os.Stat("polyglot.config.json"); os.IsNotExist(err) {
-		fmt.Println("❌ Error: Not a Polyglot project directory")
-		fmt.Println("   Run this command from your project root, or initialize a new project with 'polyglot init'")
-		os.Exit(1)
+	settings := loadProjectSettings()
+	settings.Stub = contains(args, "--stub")
+
+	if contains(args, "--matrix") {
+		handleMatrixBuild(settings)
+		return
 	}
 
 	// Parse arguments for platform and arch
-	platform := ""
-	arch := ""
 	for i, arg := range args {
 		if arg == "--platform" && i+1 < len(args) {
-			platform = args[i+1]
+			settings.Platform = args[i+1]
 		}
 		if arg == "--arch" && i+1 < len(args) {
-			arch = args[i+1]
+			settings.Arch = args[i+1]
 		}
 	}
 
 	// Build command
 	var cmd *exec.Cmd
-	if platform != "" && arch != "" {
-		fmt.Printf("Building for %s/%s...\n", platform, arch)
-		cmd = exec.Command("make", fmt.Sprintf("build-%s", platform))
-		cmd.Env = append(os.Environ(), fmt.Sprintf("GOARCH=%s", arch))
+	if preferMake(args) {
+		if settings.Platform != "" && settings.Arch != "" {
+			fmt.Printf("Building for %s/%s...\n", settings.Platform, settings.Arch)
+			cmd = exec.Command("make", fmt.Sprintf("build-%s", settings.Platform))
+			cmd.Env = append(os.Environ(), fmt.Sprintf("GOARCH=%s", settings.Arch))
+		} else {
+			cmd = exec.Command("make", "build")
+		}
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
 	} else {
-		cmd = exec.Command("make", "build")
+		if settings.Platform != "" && settings.Arch != "" {
+			fmt.Printf("Building for %s/%s...\n", settings.Platform, settings.Arch)
+		}
+		if err := os.MkdirAll(settings.Build.OutputPath, 0755); err != nil {
+			fmt.Printf("❌ Failed to create output directory: %v\n", err)
+			os.Exit(1)
+		}
+		cmd = settings.Command("build")
+		printTags(settings)
 	}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
 	if err := cmd.Run(); err != nil {
 		fmt.Printf("❌ Build failed: %v\n", err)
 		os.Exit(1)
@@ -244,14 +280,94 @@ func handleBuild(args []string) {
 	fmt.Println("✅ Build complete!")
 }
 
+// handleMatrixBuild builds every target in build.matrix with the cloud
+// build service
+func handleMatrixBuild(settings *BuildSettings) {
+	ctx := context.Background()
+
+	client, err := cloudClient(ctx)
+	if err != nil {
+		fmt.Printf("❌ Cloud build unavailable: %v\n", err)
+		os.Exit(1)
+	}
+
+	source, err := archiveSource(".")
+	if err != nil {
+		fmt.Printf("❌ Failed to archive source: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := runMatrix(ctx, client, settings, source); err != nil {
+		fmt.Printf("❌ Build failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Build complete!")
+}
+
+func handlePackage(args []string) {
+	fmt.Println("📦 Packaging application...")
+
+	settings := loadProjectSettings()
+	settings.Stub = contains(args, "--stub")
+	for i, arg := range args {
+		if arg == "--platform" && i+1 < len(args) {
+			settings.Platform = args[i+1]
+		}
+		if arg == "--arch" && i+1 < len(args) {
+			settings.Arch = args[i+1]
+		}
+	}
+
+	packager := &Packager{
+		Settings: settings,
+		Dir:      ".",
+		Builder:  localBuilder{},
+		Bundler:  platformBundler{},
+		Logf: func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		},
+	}
+
+	if contains(args, "--cloud") {
+		client, err := cloudClient(context.Background())
+		if err != nil {
+			fmt.Printf("❌ Cloud build unavailable: %v\n", err)
+			os.Exit(1)
+		}
+		packager.Builder = cloudBuilder{builder: client.Builder(), dir: "."}
+	}
+
+	platform, _ := packager.target()
+	if path := settings.Signing.Certificate; path != "" && !contains(args, "--no-sign") {
+		cert, err := loadCertificate(path, platform)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		packager.Signer = signing.NewSigner()
+		packager.Certificate = cert
+	}
+
+	if settings.Signing.Notarize && platform == "darwin" {
+		packager.Notarizer = notarytool{profile: settings.Signing.NotaryProfile}
+	}
+
+	artifact, err := packager.Run(context.Background())
+	if err != nil {
+		fmt.Printf("❌ Packaging failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Package ready: %s\n", artifact)
+}
+
 func handleDev(args []string) {
 	fmt.Println("🔧 Starting development mode...")
 
 	// Check if we're in a project directory
-	if _, err := os.Stat("polyglot.config.json"); os.IsNotExist(err) {
-		fmt.Println("❌ Error: Not a Polyglot project directory")
-		os.Exit(1)
-	}
+	settings := loadProjectSettings()
+	settings.Stub = contains(args, "--stub")
 
 	// Check for HMR feature
 	if _, err := os.Stat("src/backend/main.go"); os.IsNotExist(err) {
@@ -263,47 +379,272 @@ func handleDev(args []string) {
 	fmt.Println("Press Ctrl+C to stop")
 	fmt.Println()
 
-	cmd := exec.Command("make", "dev")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	session := NewDevSession(time.Now())
 
-	if err := cmd.Run(); err != nil {
-		// If make dev fails, try direct go run
-		fmt.Println("Falling back to direct execution...")
-		cmd = exec.Command("go", "run", "src/backend/main.go")
+	if preferMake(args) {
+		cmd := exec.CommandContext(ctx, "make", "dev")
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
+		if err := cmd.Run(); err != nil && ctx.Err() == nil {
+			session.Record(DevEvent{Kind: DevAppError, At: time.Now(), Err: err})
 			fmt.Printf("❌ Failed to start: %v\n", err)
-			os.Exit(1)
 		}
+	} else if err := runDevLoop(ctx, settings, session); err != nil {
+		fmt.Printf("❌ Failed to start: %v\n", err)
+		os.Exit(1)
 	}
+
+	fmt.Println()
+	printDevSummary(os.Stdout, session.Summary(time.Now()))
 }
 
 func handleTest(args []string) {
 	fmt.Println("🧪 Running tests...")
 
-	if _, err := os.Stat("polyglot.config.json"); os.IsNotExist(err) {
-		fmt.Println("❌ Error: Not a Polyglot project directory")
+	settings := loadProjectSettings()
+	settings.Stub = contains(args, "--stub")
+
+	var cmd *exec.Cmd
+	if preferMake(args) {
+		cmd = exec.Command("make", "test")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	} else {
+		cmd = settings.Command("test")
+	}
+
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("❌ Tests failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	cmd := exec.Command("make", "test")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	fmt.Println("✅ All tests passed!")
+}
 
-	if err := cmd.Run(); err != nil {
-		// Fallback to go test
-		cmd = exec.Command("go", "test", "./...")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("❌ Tests failed: %v\n", err)
+func handleBench(args []string) {
+	opts := BenchOptions{Iterations: 100, Concurrency: 1}
+	iterations, args := extractFlag(args, "--iterations")
+	concurrency, args := extractFlag(args, "--concurrency")
+	for _, flag := range []struct {
+		name  string
+		value string
+		dest  *int
+	}{
+		{"--iterations", iterations, &opts.Iterations},
+		{"--concurrency", concurrency, &opts.Concurrency},
+	} {
+		if flag.value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(flag.value)
+		if err != nil || n <= 0 {
+			fmt.Printf("❌ %s must be a positive integer\n", flag.name)
 			os.Exit(1)
 		}
+		*flag.dest = n
 	}
 
-	fmt.Println("✅ All tests passed!")
+	if len(args) != 1 {
+		fmt.Println("Usage: polyglot bench <lang> [--iterations N] [--concurrency N]")
+		os.Exit(1)
+	}
+	lang, err := benchLanguage(args[0])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	rt := benchRuntimes[lang]()
+	if core.IsStub(rt) {
+		fmt.Printf("❌ This polyglot binary was built without the %s runtime; rebuild it with -tags %s\n", lang, runtimeTags[lang])
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	config := core.RuntimeConfig{
+		Name:           lang,
+		Enabled:        true,
+		MaxConcurrency: opts.Concurrency,
+		Timeout:        30 * time.Second,
+	}
+	if err := rt.Initialize(ctx, config); err != nil {
+		fmt.Printf("❌ Failed to initialize %s: %v\n", lang, err)
+		os.Exit(1)
+	}
+	defer rt.Shutdown(ctx)
+
+	fmt.Printf("⏱️  Benchmarking %s %s (%d iterations, concurrency %d)\n\n", lang, rt.Version(), opts.Iterations, opts.Concurrency)
+	printBenchStats(os.Stdout, runBench(ctx, rt, benchSnippets[lang], opts))
+}
+
+func handleTypes(args []string) {
+	out, args := extractFlag(args, "--out")
+	if out == "" {
+		out = "polyglot.d.ts"
+	}
+	if len(args) != 1 {
+		fmt.Println("Usage: polyglot types <manifest.json> [--out polyglot.d.ts]")
+		os.Exit(1)
+	}
+
+	manifests, err := loadManifest(args[0])
+	if err != nil {
+		fmt.Printf("❌ Failed to read manifest: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(out, []byte(generateTypeDefinitions(manifests)), 0644); err != nil {
+		fmt.Printf("❌ Failed to write %s: %v\n", out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Wrote types for %d bridge functions to %s\n", len(manifests), out)
+}
+
+func handleAPI(args []string) {
+	out, args := extractFlag(args, "--out")
+	title, args := extractFlag(args, "--title")
+	version, args := extractFlag(args, "--version")
+	if out == "" {
+		out = "polyglot-api.json"
+	}
+	if title == "" {
+		title = "Polyglot bridge"
+	}
+	if version == "" {
+		version = "1.0.0"
+	}
+	if len(args) != 1 {
+		fmt.Println("Usage: polyglot api <manifest.json> [--out polyglot-api.json] [--title name] [--version 1.0.0]")
+		os.Exit(1)
+	}
+
+	manifests, err := loadManifest(args[0])
+	if err != nil {
+		fmt.Printf("❌ Failed to read manifest: %v\n", err)
+		os.Exit(1)
+	}
+	spec, err := generateAPISpec(manifests, core.APIInfo{Title: title, Version: version})
+	if err != nil {
+		fmt.Printf("❌ Failed to encode API spec: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(out, spec, 0644); err != nil {
+		fmt.Printf("❌ Failed to write %s: %v\n", out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Wrote the API spec of %d bridge functions to %s\n", len(manifests), out)
+}
+
+func handleScaffold(args []string) {
+	argSpec, args := extractFlag(args, "--args")
+	returns, args := extractFlag(args, "--returns")
+	mainPath, args := extractFlag(args, "--main")
+	jsPath, args := extractFlag(args, "--js")
+	if mainPath == "" {
+		mainPath = scaffoldMainFile
+	}
+	if jsPath == "" {
+		jsPath = scaffoldJSFile
+	}
+	if len(args) != 2 || args[0] != "function" {
+		fmt.Println("Usage: polyglot scaffold function <name> [--args name:type,...] [--returns type] [--main src/backend/main.go] [--js src/frontend/scripts/main.js]")
+		os.Exit(1)
+	}
+
+	fn, err := newScaffoldFunction(args[1], argSpec, returns)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	jsWritten, err := scaffoldFiles(fn, mainPath, jsPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to scaffold %s: %v\n", fn.Name, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Registered %s in %s\n", fn.Name, mainPath)
+	if jsWritten {
+		fmt.Printf("✅ Added %s() to %s\n", fn.JSName(), jsPath)
+	} else {
+		fmt.Printf("⚠️  %s not found, skipped the frontend stub\n", jsPath)
+	}
+}
+
+func handleCache(args []string) {
+	dir, args := extractFlag(args, "--dir")
+	if dir == "" {
+		dir = core.DefaultCompileCacheDir
+	}
+	if len(args) < 1 || len(args) > 2 || args[0] != "clean" {
+		fmt.Println("Usage: polyglot cache clean [runtime] [--dir .polyglot/cache]")
+		os.Exit(1)
+	}
+	if len(args) == 2 {
+		dir = filepath.Join(dir, args[1])
+	}
+
+	files, bytes, err := core.CleanCompileCache(dir)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Removed %d cached artifacts (%.1f MB) from %s\n", files, float64(bytes)/(1<<20), dir)
+}
+
+// printTags reports which runtime build tags are in effect
+func printTags(settings *BuildSettings) {
+	if settings.Stub {
+		fmt.Println("🧩 Using stub runtimes (--stub)")
+		return
+	}
+	if tags := settings.Tags(); len(tags) > 0 {
+		fmt.Printf("🏷️  Build tags: %s\n", strings.Join(tags, ","))
+	}
+}
+
+// loadProjectSettings reads the project config or exits with guidance
+func loadProjectSettings() *BuildSettings {
+	if _, err := os.Stat(projectConfigFile); os.IsNotExist(err) {
+		fmt.Println("❌ Error: Not a Polyglot project directory")
+		fmt.Println("   Run this command from your project root, or initialize a new project with 'polyglot init'")
+		os.Exit(1)
+	}
+
+	settings, err := LoadBuildSettings(projectConfigFile)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	return settings
+}
+
+func handleInfo(args []string) {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "--json") {
+		fmt.Println("Usage: polyglot info [--json]")
+		os.Exit(1)
+	}
+	if _, err := os.Stat(projectConfigFile); os.IsNotExist(err) {
+		fmt.Println("❌ Error: Not a Polyglot project directory")
+		fmt.Println("   Run this command from your project root, or initialize a new project with 'polyglot init'")
+		os.Exit(1)
+	}
+
+	info, err := loadProjectInfo(".", toolchainVersion)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if contains(args, "--json") {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	printProjectInfo(os.Stdout, info)
 }
 
 func handleVersion(args []string) {
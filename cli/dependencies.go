@@ -2,20 +2,19 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 )
 
 // DependencyManager handles dependency detection and installation
 type DependencyManager struct {
 	config *ProjectConfig
+	fs     ProjectFS
 }
 
 // NewDependencyManager creates a new dependency manager
 func NewDependencyManager(config *ProjectConfig) *DependencyManager {
-	return &DependencyManager{config: config}
+	return &DependencyManager{config: config, fs: dirFS{root: config.Name}}
 }
 
 // DetectAndGuide detects installed dependencies and provides guidance
@@ -249,8 +248,7 @@ func (d *DependencyManager) generatePythonRequirements() error {
 # numpy>=1.24.0
 `
 
-	path := filepath.Join(d.config.Name, "requirements.txt")
-	return os.WriteFile(path, []byte(content), 0644)
+	return d.fs.WriteFile("requirements.txt", []byte(content), 0644)
 }
 
 func (d *DependencyManager) generatePackageJSON() error {
@@ -279,8 +277,7 @@ func (d *DependencyManager) generatePackageJSON() error {
 		scripts,
 	)
 
-	path := filepath.Join(d.config.Name, "package.json")
-	return os.WriteFile(path, []byte(content), 0644)
+	return d.fs.WriteFile("package.json", []byte(content), 0644)
 }
 
 func (d *DependencyManager) generateCargoToml() error {
@@ -295,6 +292,5 @@ edition = "2021"
 		d.config.Version,
 	)
 
-	path := filepath.Join(d.config.Name, "src", "rust", "Cargo.toml")
-	return os.WriteFile(path, []byte(content), 0644)
+	return d.fs.WriteFile("src/rust/Cargo.toml", []byte(content), 0644)
 }
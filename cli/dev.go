@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// devDebounce groups the bursts of events editors produce on save
+	devDebounce = 300 * time.Millisecond
+
+	// devStopGrace is how long the app gets to exit after an interrupt
+	devStopGrace = 3 * time.Second
+
+	// devSummaryErrors caps the errors listed in the session summary
+	devSummaryErrors = 10
+)
+
+// DevEventKind classifies what happened during a dev session
+type DevEventKind string
+
+const (
+	DevBuildOK     DevEventKind = "build"
+	DevBuildFailed DevEventKind = "build-failed"
+	DevAppError    DevEventKind = "app-error"
+)
+
+// DevEvent is one build or app failure in a dev session
+type DevEvent struct {
+	Kind DevEventKind
+	At   time.Time
+	Err  error
+}
+
+// DevSession records the events of a polyglot dev run
+type DevSession struct {
+	mu      sync.Mutex
+	started time.Time
+	events  []DevEvent
+}
+
+// NewDevSession starts recording a session that began at started
+func NewDevSession(started time.Time) *DevSession {
+	return &DevSession{started: started}
+}
+
+// Record adds an event to the session
+func (s *DevSession) Record(event DevEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+// DevSummary aggregates a dev session for the report printed on exit
+type DevSummary struct {
+	Uptime time.Duration
+
+	// Builds counts every build; Rebuilds those after the first
+	Builds       int
+	Rebuilds     int
+	FailedBuilds int
+
+	// LastBuild is the kind of the latest build event, empty when nothing
+	// was built
+	LastBuild   DevEventKind
+	LastBuildAt time.Time
+
+	// Errors lists build and app failures, oldest first
+	Errors []string
+}
+
+// Summary aggregates the session's events as of now
+func (s *DevSession) Summary(now time.Time) DevSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := DevSummary{Uptime: now.Sub(s.started), Errors: []string{}}
+	for _, e := range s.events {
+		switch e.Kind {
+		case DevBuildOK, DevBuildFailed:
+			summary.Builds++
+			summary.LastBuild = e.Kind
+			summary.LastBuildAt = e.At
+			if e.Kind == DevBuildFailed {
+				summary.FailedBuilds++
+			}
+		}
+		if e.Err != nil {
+			label := "build failed"
+			if e.Kind == DevAppError {
+				label = "app error"
+			}
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s %s: %v", e.At.Format("15:04:05"), label, e.Err))
+		}
+	}
+	if summary.Builds > 0 {
+		summary.Rebuilds = summary.Builds - 1
+	}
+	return summary
+}
+
+// printDevSummary writes the session report shown when polyglot dev exits
+func printDevSummary(out io.Writer, s DevSummary) {
+	fmt.Fprintln(out, "📋 Dev session summary")
+	fmt.Fprintf(out, "   Uptime:     %s\n", s.Uptime.Round(time.Second))
+	fmt.Fprintf(out, "   Rebuilds:   %d (%d failed)\n", s.Rebuilds, s.FailedBuilds)
+	switch s.LastBuild {
+	case DevBuildOK:
+		fmt.Fprintf(out, "   Last build: ✅ succeeded at %s\n", s.LastBuildAt.Format("15:04:05"))
+	case DevBuildFailed:
+		fmt.Fprintf(out, "   Last build: ❌ failed at %s\n", s.LastBuildAt.Format("15:04:05"))
+	default:
+		fmt.Fprintln(out, "   Last build: none")
+	}
+	fmt.Fprintf(out, "   Errors:     %d\n", len(s.Errors))
+
+	errs := s.Errors
+	if len(errs) > devSummaryErrors {
+		fmt.Fprintf(out, "     … %d earlier\n", len(errs)-devSummaryErrors)
+		errs = errs[len(errs)-devSummaryErrors:]
+	}
+	for _, e := range errs {
+		fmt.Fprintf(out, "     %s\n", e)
+	}
+}
+
+// devApp is a running build of the app
+type devApp struct {
+	cmd    *exec.Cmd
+	exited chan error
+}
+
+// runDevLoop builds and runs the app, rebuilding and restarting it when
+// files under src change, until ctx is done
+func runDevLoop(ctx context.Context, settings *BuildSettings, session *DevSession) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watchTree(watcher, "src"); err != nil {
+		return err
+	}
+
+	app := buildAndStart(settings, session)
+	var debounce <-chan time.Time
+
+	for {
+		var exited chan error
+		if app != nil {
+			exited = app.exited
+		}
+
+		select {
+		case <-ctx.Done():
+			app.stop()
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() && event.Has(fsnotify.Create) {
+				watchTree(watcher, event.Name)
+			}
+			if isDevSource(event.Name) {
+				debounce = time.After(devDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("⚠️  Watch error: %v\n", err)
+
+		case <-debounce:
+			debounce = nil
+			fmt.Println("\n🔄 Change detected, rebuilding...")
+			app.stop()
+			app = buildAndStart(settings, session)
+
+		case err := <-exited:
+			app = nil
+			if err != nil {
+				session.Record(DevEvent{Kind: DevAppError, At: time.Now(), Err: err})
+				fmt.Printf("❌ App exited: %v\n", err)
+			} else {
+				fmt.Println("ℹ️  App exited")
+			}
+			fmt.Println("   Waiting for changes...")
+		}
+	}
+}
+
+// buildAndStart builds the app and starts it, returning nil when either
+// step fails
+func buildAndStart(settings *BuildSettings, session *DevSession) *devApp {
+	printTags(settings)
+	if err := settings.Command("build").Run(); err != nil {
+		session.Record(DevEvent{Kind: DevBuildFailed, At: time.Now(), Err: err})
+		fmt.Printf("❌ Build failed: %v\n", err)
+		fmt.Println("   Waiting for changes...")
+		return nil
+	}
+	session.Record(DevEvent{Kind: DevBuildOK, At: time.Now()})
+
+	binary, err := filepath.Abs(settings.Binary())
+	if err != nil {
+		binary = settings.Binary()
+	}
+	cmd := exec.Command(binary)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err := cmd.Start(); err != nil {
+		session.Record(DevEvent{Kind: DevAppError, At: time.Now(), Err: err})
+		fmt.Printf("❌ Failed to start: %v\n", err)
+		return nil
+	}
+
+	app := &devApp{cmd: cmd, exited: make(chan error, 1)}
+	go func() { app.exited <- cmd.Wait() }()
+	return app
+}
+
+// stop interrupts the app and waits for it to exit, killing it after
+// devStopGrace. Stopping a nil app does nothing.
+func (a *devApp) stop() {
+	if a == nil {
+		return
+	}
+	if runtime.GOOS == "windows" {
+		a.cmd.Process.Kill()
+	} else {
+		a.cmd.Process.Signal(os.Interrupt)
+	}
+	select {
+	case <-a.exited:
+	case <-time.After(devStopGrace):
+		a.cmd.Process.Kill()
+		<-a.exited
+	}
+}
+
+// watchTree adds root and its subdirectories to watcher, skipping hidden
+// directories and installed dependencies
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if path != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// isDevSource reports whether a change to path should trigger a rebuild,
+// ignoring editor swap and backup files
+func isDevSource(path string) bool {
+	name := filepath.Base(path)
+	return !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, "~") &&
+		!strings.HasSuffix(name, ".swp") && !strings.HasSuffix(name, ".tmp")
+}
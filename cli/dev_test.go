@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDevSessionSummary(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	session := NewDevSession(start)
+
+	// Initial build, an edit that breaks the build, a fix, then a crash
+	events := []DevEvent{
+		{Kind: DevBuildOK, At: start.Add(2 * time.Second)},
+		{Kind: DevBuildFailed, At: start.Add(time.Minute), Err: errors.New("exit status 1")},
+		{Kind: DevBuildOK, At: start.Add(2 * time.Minute)},
+		{Kind: DevAppError, At: start.Add(3 * time.Minute), Err: errors.New("panic: nil map")},
+	}
+	for _, e := range events {
+		session.Record(e)
+	}
+
+	summary := session.Summary(start.Add(5 * time.Minute))
+	if summary.Uptime != 5*time.Minute {
+		t.Errorf("uptime = %v, want 5m", summary.Uptime)
+	}
+	if summary.Builds != 3 || summary.Rebuilds != 2 || summary.FailedBuilds != 1 {
+		t.Errorf("builds %d rebuilds %d failed %d, want 3, 2 and 1", summary.Builds, summary.Rebuilds, summary.FailedBuilds)
+	}
+	if summary.LastBuild != DevBuildOK || !summary.LastBuildAt.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("last build %s at %v, want a success at 09:02", summary.LastBuild, summary.LastBuildAt)
+	}
+	want := []string{"09:01:00 build failed: exit status 1", "09:03:00 app error: panic: nil map"}
+	if strings.Join(summary.Errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors = %q, want %q", summary.Errors, want)
+	}
+
+	var out bytes.Buffer
+	printDevSummary(&out, summary)
+	for _, line := range []string{
+		"Uptime:     5m0s",
+		"Rebuilds:   2 (1 failed)",
+		"Last build: ✅ succeeded at 09:02:00",
+		"Errors:     2",
+		"09:03:00 app error: panic: nil map",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("summary missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestDevSessionSummaryEmpty(t *testing.T) {
+	start := time.Now()
+	summary := NewDevSession(start).Summary(start)
+	if summary.Builds != 0 || summary.Rebuilds != 0 || summary.LastBuild != "" || len(summary.Errors) != 0 {
+		t.Errorf("unexpected summary for an empty session: %+v", summary)
+	}
+
+	var out bytes.Buffer
+	printDevSummary(&out, summary)
+	if !strings.Contains(out.String(), "Last build: none") {
+		t.Errorf("expected no last build:\n%s", out.String())
+	}
+}
+
+func TestDevSummaryCapsErrors(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	session := NewDevSession(start)
+	for i := 0; i < devSummaryErrors+3; i++ {
+		session.Record(DevEvent{Kind: DevBuildFailed, At: start.Add(time.Duration(i) * time.Second), Err: errors.New("exit status 1")})
+	}
+
+	summary := session.Summary(start.Add(time.Minute))
+	if summary.LastBuild != DevBuildFailed || len(summary.Errors) != devSummaryErrors+3 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	var out bytes.Buffer
+	printDevSummary(&out, summary)
+	if !strings.Contains(out.String(), "… 3 earlier") || strings.Contains(out.String(), "09:00:00 build failed") {
+		t.Errorf("expected only the latest errors:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Last build: ❌ failed at 09:00:12") {
+		t.Errorf("expected a failed last build:\n%s", out.String())
+	}
+}
+
+func TestIsDevSource(t *testing.T) {
+	for path, want := range map[string]bool{
+		"src/backend/main.go":         true,
+		"src/frontend/app.js":         true,
+		"src/backend/.main.go.swp":    false,
+		"src/backend/main.go~":        false,
+		"src/frontend/index.tmp":      false,
+		"src/frontend/.DS_Store":      false,
+		"src/python/compute.py":       true,
+		"src/backend/4913.swp":        false,
+		"src/frontend/styles/app.css": true,
+	} {
+		if got := isDevSource(path); got != want {
+			t.Errorf("isDevSource(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
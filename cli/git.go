@@ -4,17 +4,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 )
 
 // GitManager handles git initialization
 type GitManager struct {
 	projectPath string
+	fs          ProjectFS
 }
 
 // NewGitManager creates a new git manager
 func NewGitManager(projectPath string) *GitManager {
-	return &GitManager{projectPath: projectPath}
+	return &GitManager{projectPath: projectPath, fs: dirFS{root: projectPath}}
 }
 
 // Initialize initializes a git repository
@@ -156,6 +156,5 @@ hs_err_pid*
 		}
 	}
 
-	path := filepath.Join(g.projectPath, ".gitignore")
-	return os.WriteFile(path, []byte(content), 0644)
+	return g.fs.WriteFile(".gitignore", []byte(content), 0644)
 }
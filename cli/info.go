@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// toolchainCommands print the version of each language's toolchain.
+// Languages without one, such as wasm, run on a runtime built into the
+// binary.
+var toolchainCommands = map[string][]string{
+	"python":     {"python3", "--version"},
+	"javascript": {"node", "--version"},
+	"go":         {"go", "version"},
+	"rust":       {"rustc", "--version"},
+	"cpp":        {"g++", "--version"},
+	"java":       {"java", "-version"},
+	"ruby":       {"ruby", "--version"},
+	"php":        {"php", "--version"},
+	"lua":        {"lua", "-v"},
+	"zig":        {"zig", "version"},
+}
+
+// ProjectInfo summarizes a project for polyglot info
+type ProjectInfo struct {
+	Name        string           `json:"name"`
+	Version     string           `json:"version"`
+	Description string           `json:"description,omitempty"`
+	Languages   []LanguageInfo   `json:"languages"`
+	Features    []string         `json:"features"`
+	Webview     WebviewInfo      `json:"webview"`
+	Bridge      []BridgeFunction `json:"bridgeFunctions"`
+}
+
+// LanguageInfo describes one of the project's languages
+type LanguageInfo struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	// Toolchain is the detected toolchain version, "built in" for
+	// languages that need none and empty when it was not found
+	Toolchain string `json:"toolchain"`
+}
+
+// WebviewInfo holds the project's window settings
+type WebviewInfo struct {
+	Title     string `json:"title"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Resizable bool   `json:"resizable"`
+	DevTools  bool   `json:"devTools"`
+}
+
+// BridgeFunction is a function registered on the bridge in the project's
+// backend source
+type BridgeFunction struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// infoSettings are the parts of the project configuration info reports
+type infoSettings struct {
+	Name        string      `json:"name"`
+	Version     string      `json:"version"`
+	Description string      `json:"description"`
+	Languages   []string    `json:"languages"`
+	Features    []string    `json:"features"`
+	Webview     WebviewInfo `json:"webview"`
+	Runtimes    map[string]struct {
+		Enabled bool `json:"enabled"`
+	} `json:"runtimes"`
+}
+
+// loadProjectInfo reads the configuration of the project in dir and
+// parses its backend source for bridge functions. detect returns the
+// toolchain version of a language, or "" when it is not installed.
+func loadProjectInfo(dir string, detect func(lang string) string) (*ProjectInfo, error) {
+	path := filepath.Join(dir, projectConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var settings infoSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	info := &ProjectInfo{
+		Name:        settings.Name,
+		Version:     settings.Version,
+		Description: settings.Description,
+		Features:    settings.Features,
+		Webview:     settings.Webview,
+	}
+	for _, lang := range settings.Languages {
+		rt, configured := settings.Runtimes[lang]
+		info.Languages = append(info.Languages, LanguageInfo{
+			Name:      lang,
+			Enabled:   !configured || rt.Enabled,
+			Toolchain: detect(lang),
+		})
+	}
+
+	backend := filepath.Join(dir, filepath.Dir(filepath.FromSlash(scaffoldMainFile)))
+	info.Bridge, err = bridgeFunctions(dir, backend)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// toolchainVersion runs a language's toolchain to report its version,
+// returning the first line it prints or "" when it is not installed
+func toolchainVersion(lang string) string {
+	name := strings.ToLower(lang)
+	if alias, ok := languageAliases[name]; ok {
+		name = alias
+	}
+	command, ok := toolchainCommands[name]
+	if !ok {
+		if _, known := runtimeTags[name]; known {
+			return "built in"
+		}
+		return ""
+	}
+
+	// java -version prints to stderr
+	output, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(line)
+}
+
+// bridgeFunctions parses the Go files below dir, skipping tests, for
+// functions registered on a bridge created with NewBridge. File paths
+// are reported relative to root.
+func bridgeFunctions(root, dir string) ([]BridgeFunction, error) {
+	var functions []BridgeFunction
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		bridge, _ := findBridge(file)
+		if bridge == "" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			if name, ok := registeredName(n, bridge); ok && name != "" {
+				functions = append(functions, BridgeFunction{
+					Name: name,
+					File: filepath.ToSlash(rel),
+					Line: fset.Position(n.Pos()).Line,
+				})
+			}
+			return true
+		})
+		return nil
+	})
+	return functions, err
+}
+
+// printProjectInfo writes info as a readable summary
+func printProjectInfo(out io.Writer, info *ProjectInfo) {
+	fmt.Fprintf(out, "📦 %s %s\n", info.Name, info.Version)
+	if info.Description != "" {
+		fmt.Fprintf(out, "   %s\n", info.Description)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Languages:")
+	for _, lang := range info.Languages {
+		toolchain := lang.Toolchain
+		if toolchain == "" {
+			toolchain = "toolchain not found"
+		}
+		if !lang.Enabled {
+			toolchain += " (disabled)"
+		}
+		fmt.Fprintf(out, "  %-12s %s\n", lang.Name, toolchain)
+	}
+
+	fmt.Fprintln(out)
+	features := "none"
+	if len(info.Features) > 0 {
+		features = strings.Join(info.Features, ", ")
+	}
+	fmt.Fprintf(out, "Features: %s\n", features)
+
+	w := info.Webview
+	fmt.Fprintf(out, "Webview:  %q %dx%d, resizable %t, dev tools %t\n", w.Title, w.Width, w.Height, w.Resizable, w.DevTools)
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Bridge functions (%d):\n", len(info.Bridge))
+	for _, fn := range info.Bridge {
+		fmt.Fprintf(out, "  %-24s %s:%d\n", fn.Name, fn.File, fn.Line)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeToolchains reports fixed versions, with rust not installed
+func fakeToolchains(lang string) string {
+	return map[string]string{
+		"python":     "Python 3.12.1",
+		"javascript": "v20.11.0",
+	}[lang]
+}
+
+func TestProjectInfo(t *testing.T) {
+	project := generateIn(t, t.TempDir(), goldenConfig("webapp"))
+
+	// Disable a runtime and register a function in a second backend file
+	configPath := filepath.Join(project, projectConfigFile)
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = bytes.Replace(config, []byte(`"rust": {
+      "enabled": true`), []byte(`"rust": {
+      "enabled": false`), 1)
+	if err := os.WriteFile(configPath, config, 0644); err != nil {
+		t.Fatal(err)
+	}
+	notes := `package main
+
+import "github.com/griffincancode/polyglot.js/core"
+
+func registerNotes() {
+	api := core.NewBridge()
+	api.Register("notes.save", nil)
+	api.RegisterTyped("notes.list", nil)
+}
+`
+	if err := os.WriteFile(filepath.Join(project, "src", "backend", "notes.go"), []byte(notes), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ignored := "package main\n\nfunc init() { b := core.NewBridge(); b.Register(\"test.only\", nil) }\n"
+	if err := os.WriteFile(filepath.Join(project, "src", "backend", "notes_test.go"), []byte(ignored), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := loadProjectInfo(project, fakeToolchains)
+	if err != nil {
+		t.Fatalf("loadProjectInfo failed: %v", err)
+	}
+
+	if info.Name != "goldenapp" || info.Version != "1.2.3" || info.Description != "A golden test application" {
+		t.Errorf("project = %s %s %q", info.Name, info.Version, info.Description)
+	}
+	wantLanguages := []LanguageInfo{
+		{Name: "python", Enabled: true, Toolchain: "Python 3.12.1"},
+		{Name: "javascript", Enabled: true, Toolchain: "v20.11.0"},
+		{Name: "rust", Enabled: false},
+	}
+	if !reflect.DeepEqual(info.Languages, wantLanguages) {
+		t.Errorf("languages = %+v, want %+v", info.Languages, wantLanguages)
+	}
+	if got := strings.Join(info.Features, ","); got != "webview,hmr" {
+		t.Errorf("features = %s", got)
+	}
+	wantWebview := WebviewInfo{Title: "goldenapp", Width: 1024, Height: 768, Resizable: true}
+	if info.Webview != wantWebview {
+		t.Errorf("webview = %+v, want %+v", info.Webview, wantWebview)
+	}
+
+	var names []string
+	for _, fn := range info.Bridge {
+		names = append(names, fn.Name+"@"+fn.File)
+		if fn.Line <= 0 {
+			t.Errorf("%s has no line", fn.Name)
+		}
+	}
+	want := "greet@src/backend/main.go,getAppInfo@src/backend/main.go,notes.save@src/backend/notes.go,notes.list@src/backend/notes.go"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("bridge functions = %s, want %s", got, want)
+	}
+	if fn := info.Bridge[2]; fn.Line != 7 {
+		t.Errorf("notes.save line = %d, want 7", fn.Line)
+	}
+
+	var out bytes.Buffer
+	printProjectInfo(&out, info)
+	for _, line := range []string{
+		"📦 goldenapp 1.2.3",
+		"python       Python 3.12.1",
+		"rust         toolchain not found (disabled)",
+		"Features: webview, hmr",
+		`Webview:  "goldenapp" 1024x768, resizable true, dev tools false`,
+		"Bridge functions (4):",
+		"notes.list",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output missing %q:\n%s", line, out.String())
+		}
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ProjectInfo
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(&decoded, info) {
+		t.Errorf("JSON round trip = %+v, %v", decoded, err)
+	}
+}
+
+func TestProjectInfoWithoutBackend(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "bare", "version": "0.1.0", "languages": ["wasm"]}`
+	if err := os.WriteFile(filepath.Join(dir, projectConfigFile), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := loadProjectInfo(dir, toolchainVersion)
+	if err != nil {
+		t.Fatalf("loadProjectInfo failed: %v", err)
+	}
+	if len(info.Bridge) != 0 {
+		t.Errorf("bridge functions = %+v, want none", info.Bridge)
+	}
+	if got := info.Languages[0].Toolchain; got != "built in" {
+		t.Errorf("wasm toolchain = %q, want built in", got)
+	}
+
+	if _, err := loadProjectInfo(t.TempDir(), toolchainVersion); err == nil {
+		t.Error("expected an error without a project config")
+	}
+}
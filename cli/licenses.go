@@ -2,19 +2,18 @@ package main
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 )
 
 // LicenseManager handles license file generation
 type LicenseManager struct {
 	config *ProjectConfig
+	fs     ProjectFS
 }
 
 // NewLicenseManager creates a new license manager
 func NewLicenseManager(config *ProjectConfig) *LicenseManager {
-	return &LicenseManager{config: config}
+	return &LicenseManager{config: config, fs: dirFS{root: config.Name}}
 }
 
 // Generate creates the LICENSE file
@@ -42,8 +41,7 @@ func (l *LicenseManager) Generate() error {
 		return nil
 	}
 
-	path := filepath.Join(l.config.Name, "LICENSE")
-	return os.WriteFile(path, []byte(content), 0644)
+	return l.fs.WriteFile("LICENSE", []byte(content), 0644)
 }
 
 func (l *LicenseManager) generateMIT(year int, author string) string {
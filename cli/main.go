@@ -21,8 +21,22 @@ func main() {
 		handleBuild(args)
 	case "dev":
 		handleDev(args)
+	case "package":
+		handlePackage(args)
 	case "test":
 		handleTest(args)
+	case "bench":
+		handleBench(args)
+	case "types":
+		handleTypes(args)
+	case "api":
+		handleAPI(args)
+	case "scaffold":
+		handleScaffold(args)
+	case "cache":
+		handleCache(args)
+	case "info":
+		handleInfo(args)
 	case "version":
 		handleVersion(args)
 	default:
@@ -42,12 +56,30 @@ func printUsage() {
 	fmt.Println("  init     Initialize a new Polyglot project")
 	fmt.Println("  build    Build the application")
 	fmt.Println("  dev      Start development mode")
+	fmt.Println("  package  Build, sign and bundle a distributable")
 	fmt.Println("  test     Run tests")
+	fmt.Println("  bench    Benchmark a language runtime")
+	fmt.Println("  types    Generate TypeScript definitions from a bridge manifest")
+	fmt.Println("  api      Generate an OpenAPI spec from a bridge manifest")
+	fmt.Println("  scaffold Add a bridge function and its frontend stub")
+	fmt.Println("  cache    Manage the compiled-artifact cache")
+	fmt.Println("  info     Summarize the project: languages, toolchains, webview and bridge functions")
 	fmt.Println("  version  Show version information")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  polyglot init myapp")
+	fmt.Println("  polyglot init myapp --template-dir ./my-templates")
 	fmt.Println("  polyglot build --platform darwin --arch arm64")
 	fmt.Println("  polyglot dev --port 3000")
+	fmt.Println("  polyglot build --make   (use the project Makefile instead of go build)")
+	fmt.Println("  polyglot build --stub   (build with stub runtimes and webview)")
+	fmt.Println("  polyglot build --matrix (build every build.matrix target in the cloud)")
+	fmt.Println("  polyglot package --platform darwin --arch arm64")
+	fmt.Println("  polyglot bench python --iterations 500 --concurrency 4")
+	fmt.Println("  polyglot types manifest.json --out frontend/polyglot.d.ts")
+	fmt.Println("  polyglot api manifest.json --title \"My App\" --version 1.2.0")
+	fmt.Println("  polyglot scaffold function saveNote --args title:string,body:string --returns bool")
+	fmt.Println("  polyglot cache clean")
+	fmt.Println("  polyglot info --json")
 	fmt.Println()
 }
@@ -2,19 +2,18 @@ package main
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 )
 
 // MakefileGenerator generates Makefile for the project
 type MakefileGenerator struct {
 	config *ProjectConfig
+	fs     ProjectFS
 }
 
 // NewMakefileGenerator creates a new Makefile generator
 func NewMakefileGenerator(config *ProjectConfig) *MakefileGenerator {
-	return &MakefileGenerator{config: config}
+	return &MakefileGenerator{config: config, fs: dirFS{root: config.Name}}
 }
 
 // Generate creates the Makefile
@@ -131,8 +130,7 @@ help:
 		m.generateInstallTargets(),
 	)
 
-	path := filepath.Join(m.config.Name, "Makefile")
-	return os.WriteFile(path, []byte(content), 0644)
+	return m.fs.WriteFile("Makefile", []byte(content), 0644)
 }
 
 func (m *MakefileGenerator) generateDevTarget() string {
@@ -180,11 +178,13 @@ func (m *MakefileGenerator) generateInstallTargets() string {
 
 func (t *ProjectTemplate) generateGitignore() error {
 	gitManager := NewGitManager(t.config.Name)
+	gitManager.fs = t.fs
 	return gitManager.GenerateGitignore(t.config.Languages)
 }
 
 func (t *ProjectTemplate) generateLicense() error {
 	licenseManager := NewLicenseManager(t.config)
+	licenseManager.fs = t.fs
 	return licenseManager.Generate()
 }
 
@@ -200,16 +200,17 @@ require (
 		t.config.Name,
 	)
 
-	path := filepath.Join(t.config.Name, "go.mod")
-	return os.WriteFile(path, []byte(content), 0644)
+	return t.fs.WriteFile("go.mod", []byte(content), 0644)
 }
 
 func (t *ProjectTemplate) generatePackageFiles() error {
 	depManager := NewDependencyManager(t.config)
+	depManager.fs = t.fs
 	return depManager.GeneratePackageFiles()
 }
 
 func (t *ProjectTemplate) generateMakefile() error {
 	makefileGen := NewMakefileGenerator(t.config)
+	makefileGen.fs = t.fs
 	return makefileGen.Generate()
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/griffincancode/polyglot.js/cloud"
+)
+
+// defaultChannel is used by matrix entries without a channel
+const defaultChannel = "stable"
+
+// supportedTargets lists the architectures buildable for each platform
+var supportedTargets = map[string][]string{
+	"darwin":  {"amd64", "arm64"},
+	"linux":   {"amd64", "arm64", "arm", "386"},
+	"windows": {"amd64", "arm64", "386"},
+}
+
+// MatrixTarget is one entry of build.matrix in polyglot.config.json
+type MatrixTarget struct {
+	Platform string `json:"platform"`
+	Arch     string `json:"arch"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+// MatrixBuild is one cloud cross-compile covering a channel's targets
+type MatrixBuild struct {
+	Channel   string
+	Platforms []cloud.Platform
+}
+
+// validateMatrix checks matrix entries against the supported targets
+func validateMatrix(matrix []MatrixTarget) error {
+	seen := make(map[MatrixTarget]bool)
+	for i, target := range matrix {
+		if !isSupportedTarget(target.Platform, target.Arch) {
+			return fmt.Errorf("build.matrix[%d]: unsupported target %s/%s", i, target.Platform, target.Arch)
+		}
+		if target.Channel == "" {
+			target.Channel = defaultChannel
+		}
+		if seen[target] {
+			return fmt.Errorf("build.matrix[%d]: duplicate target %s/%s on channel %s", i, target.Platform, target.Arch, target.Channel)
+		}
+		seen[target] = true
+	}
+	return nil
+}
+
+func isSupportedTarget(platform, arch string) bool {
+	for _, supported := range supportedTargets[platform] {
+		if supported == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// MatrixBuilds groups the configured matrix into one cross-compile per
+// channel, in the order channels first appear
+func (b *BuildSettings) MatrixBuilds() ([]MatrixBuild, error) {
+	if len(b.Build.Matrix) == 0 {
+		return nil, fmt.Errorf("no build.matrix configured in %s", projectConfigFile)
+	}
+	if err := validateMatrix(b.Build.Matrix); err != nil {
+		return nil, err
+	}
+
+	var builds []MatrixBuild
+	index := make(map[string]int)
+	for _, target := range b.Build.Matrix {
+		channel := target.Channel
+		if channel == "" {
+			channel = defaultChannel
+		}
+		i, ok := index[channel]
+		if !ok {
+			i = len(builds)
+			index[channel] = i
+			builds = append(builds, MatrixBuild{Channel: channel})
+		}
+		builds[i].Platforms = append(builds[i].Platforms, cloud.Platform{
+			OS:         target.Platform,
+			Arch:       target.Arch,
+			CGOEnabled: !b.Stub,
+			Tags:       b.Tags(),
+		})
+	}
+	return builds, nil
+}
+
+// runMatrix cross-compiles every matrix build and writes the binaries to
+// the output path under a directory per channel. A channel with any failed
+// target writes no binaries and fails the matrix.
+func runMatrix(ctx context.Context, client cloud.Client, settings *BuildSettings, source []byte) error {
+	builds, err := settings.MatrixBuilds()
+	if err != nil {
+		return err
+	}
+
+	for _, build := range builds {
+		fmt.Printf("Building %d targets for channel %s...\n", len(build.Platforms), build.Channel)
+		results, err := client.CrossCompile(ctx, source, build.Platforms)
+		if err != nil {
+			return fmt.Errorf("channel %s: %w", build.Channel, err)
+		}
+
+		if err := checkResults(results); err != nil {
+			return fmt.Errorf("channel %s: %w", build.Channel, err)
+		}
+
+		for _, result := range results {
+			target := *settings
+			target.Platform = result.Platform.OS
+			target.Arch = result.Platform.Arch
+			target.Build.OutputPath = filepath.Join(settings.Build.OutputPath, build.Channel)
+
+			path := target.Binary()
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+			if err := os.WriteFile(path, result.Binary, 0755); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			fmt.Printf("  %s/%s -> %s\n", result.Platform.OS, result.Platform.Arch, path)
+		}
+	}
+	return nil
+}
+
+// checkResults fails if any target failed to build or returned a binary
+// that does not match its provenance
+func checkResults(results []*cloud.BuildResult) error {
+	var errs []error
+	for _, result := range results {
+		target := result.Platform.OS + "/" + result.Platform.Arch
+		switch {
+		case result.Error != "":
+			errs = append(errs, fmt.Errorf("%s: %s", target, result.Error))
+		case len(result.Binary) == 0:
+			errs = append(errs, fmt.Errorf("%s: build returned no binary", target))
+		case result.Provenance != nil:
+			if err := result.Provenance.Verify(result.Binary); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", target, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/griffincancode/polyglot.js/cloud"
+)
+
+const matrixConfig = `{
+  "name": "matrixapp",
+  "version": "2.0.0",
+  "languages": ["python"],
+  "build": {
+    "outputPath": "dist",
+    "matrix": [
+      {"platform": "linux", "arch": "amd64"},
+      {"platform": "darwin", "arch": "arm64", "channel": "beta"},
+      {"platform": "windows", "arch": "amd64", "channel": "stable"},
+      {"platform": "darwin", "arch": "arm64"}
+    ]
+  }
+}`
+
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), projectConfigFile)
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMatrixBuilds(t *testing.T) {
+	settings, err := LoadBuildSettings(writeConfig(t, matrixConfig))
+	if err != nil {
+		t.Fatalf("LoadBuildSettings failed: %v", err)
+	}
+
+	builds, err := settings.MatrixBuilds()
+	if err != nil {
+		t.Fatalf("MatrixBuilds failed: %v", err)
+	}
+
+	tags := []string{"runtime_python"}
+	want := []MatrixBuild{
+		{Channel: "stable", Platforms: []cloud.Platform{
+			{OS: "linux", Arch: "amd64", CGOEnabled: true, Tags: tags},
+			{OS: "windows", Arch: "amd64", CGOEnabled: true, Tags: tags},
+			{OS: "darwin", Arch: "arm64", CGOEnabled: true, Tags: tags},
+		}},
+		{Channel: "beta", Platforms: []cloud.Platform{
+			{OS: "darwin", Arch: "arm64", CGOEnabled: true, Tags: tags},
+		}},
+	}
+	if !reflect.DeepEqual(builds, want) {
+		t.Errorf("MatrixBuilds() = %+v, want %+v", builds, want)
+	}
+}
+
+func TestMatrixValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix string
+		want   string
+	}{
+		{"unsupported platform", `[{"platform": "plan9", "arch": "amd64"}]`, "unsupported target plan9/amd64"},
+		{"unsupported arch", `[{"platform": "darwin", "arch": "386"}]`, "unsupported target darwin/386"},
+		{"duplicate", `[{"platform": "linux", "arch": "arm64"}, {"platform": "linux", "arch": "arm64", "channel": "stable"}]`, "build.matrix[1]: duplicate target"},
+	}
+
+	for _, tt := range tests {
+		config := `{"name": "app", "build": {"matrix": ` + tt.matrix + `}}`
+		_, err := LoadBuildSettings(writeConfig(t, config))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	settings := &BuildSettings{Name: "app"}
+	if _, err := settings.MatrixBuilds(); err == nil {
+		t.Error("Expected error without a matrix")
+	}
+}
+
+func TestRunMatrix(t *testing.T) {
+	ctx := context.Background()
+	settings, err := LoadBuildSettings(writeConfig(t, matrixConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.Build.OutputPath = t.TempDir()
+
+	client := cloud.NewClient(cloud.NewMemoryBuilder(), cloud.NewMemoryStorage(), cloud.NewMemoryAuth())
+	if err := client.Authenticate(ctx, "test-api-key", "test-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runMatrix(ctx, client, settings, []byte("source")); err != nil {
+		t.Fatalf("runMatrix failed: %v", err)
+	}
+
+	for _, name := range []string{
+		"stable/matrixapp-linux-amd64",
+		"stable/matrixapp-windows-amd64.exe",
+		"stable/matrixapp-darwin-arm64",
+		"beta/matrixapp-darwin-arm64",
+	} {
+		if _, err := os.Stat(filepath.Join(settings.Build.OutputPath, name)); err != nil {
+			t.Errorf("Expected binary %s: %v", name, err)
+		}
+	}
+}
+
+// failingTargetClient reports one target's build as failed
+type failingTargetClient struct {
+	cloud.Client
+}
+
+func (c failingTargetClient) CrossCompile(ctx context.Context, source []byte, platforms []cloud.Platform) ([]*cloud.BuildResult, error) {
+	results := make([]*cloud.BuildResult, len(platforms))
+	for i, platform := range platforms {
+		results[i] = &cloud.BuildResult{Platform: platform, Binary: []byte("binary")}
+	}
+	results[0].Binary, results[0].Error = nil, "linker error"
+	return results, nil
+}
+
+func TestRunMatrixFailedTarget(t *testing.T) {
+	settings, err := LoadBuildSettings(writeConfig(t, matrixConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.Build.OutputPath = t.TempDir()
+
+	err = runMatrix(context.Background(), failingTargetClient{}, settings, []byte("source"))
+	if err == nil || !strings.Contains(err.Error(), "linker error") {
+		t.Fatalf("Expected the failed target to fail the matrix, got %v", err)
+	}
+	if entries, _ := os.ReadDir(settings.Build.OutputPath); len(entries) != 0 {
+		t.Errorf("Expected no binaries written for a failed channel, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/griffincancode/polyglot.js/cloud"
+	"github.com/griffincancode/polyglot.js/signing"
+)
+
+// frontendDir holds the assets bundled with packaged applications
+const frontendDir = "src/frontend"
+
+// ArtifactBuilder produces the application binary
+type ArtifactBuilder interface {
+	Build(ctx context.Context, settings *BuildSettings) ([]byte, error)
+}
+
+// BinarySigner signs a binary; *signing.DefaultSigner satisfies it
+type BinarySigner interface {
+	Sign(ctx context.Context, req *signing.SignRequest) (*signing.SignResult, error)
+}
+
+// Bundler writes a platform-appropriate artifact and returns its path
+type Bundler interface {
+	Bundle(ctx context.Context, bundle *Bundle) (string, error)
+}
+
+// Notarizer submits a macOS artifact for notarization
+type Notarizer interface {
+	Notarize(ctx context.Context, artifact string) error
+}
+
+// Bundle is the input to a Bundler
+type Bundle struct {
+	Name      string
+	Version   string
+	Platform  string
+	Arch      string
+	Binary    []byte
+	Assets    map[string][]byte
+	OutputDir string
+}
+
+// Packager runs the package pipeline: build, collect assets, sign,
+// bundle, and notarize. Signing and notarization are skipped when not
+// configured.
+type Packager struct {
+	Settings    *BuildSettings
+	Dir         string
+	Builder     ArtifactBuilder
+	Signer      BinarySigner
+	Certificate *signing.Certificate
+	Bundler     Bundler
+	Notarizer   Notarizer
+	Logf        func(format string, args ...interface{})
+}
+
+// Run executes the pipeline and returns the artifact path
+func (p *Packager) Run(ctx context.Context) (string, error) {
+	platform, arch := p.target()
+
+	binary, err := p.Builder.Build(ctx, p.Settings)
+	if err != nil {
+		return "", fmt.Errorf("build failed: %w", err)
+	}
+	p.logf("🔨 Built %s/%s binary (%d bytes)", platform, arch, len(binary))
+
+	assets, err := collectAssets(filepath.Join(p.Dir, frontendDir))
+	if err != nil {
+		return "", fmt.Errorf("asset collection failed: %w", err)
+	}
+	p.logf("📦 Collected %d frontend assets", len(assets))
+
+	if p.Signer != nil && p.Certificate != nil {
+		result, err := p.Signer.Sign(ctx, &signing.SignRequest{
+			Binary:      binary,
+			Platform:    platform,
+			Certificate: p.Certificate,
+			Timestamp:   true,
+		})
+		if err != nil {
+			return "", fmt.Errorf("signing failed: %w", err)
+		}
+		binary = result.SignedBinary
+		p.logf("🔏 Signed with %s", p.Certificate.ID)
+	} else {
+		p.logf("⚠️  No signing certificate configured, skipping signing")
+	}
+
+	artifact, err := p.Bundler.Bundle(ctx, &Bundle{
+		Name:      p.Settings.Name,
+		Version:   p.Settings.Version,
+		Platform:  platform,
+		Arch:      arch,
+		Binary:    binary,
+		Assets:    assets,
+		OutputDir: filepath.Join(p.Dir, p.Settings.Build.OutputPath),
+	})
+	if err != nil {
+		return "", fmt.Errorf("bundling failed: %w", err)
+	}
+	p.logf("🗂️  Bundled %s", artifact)
+
+	if platform == "darwin" && p.Notarizer != nil {
+		if err := p.Notarizer.Notarize(ctx, artifact); err != nil {
+			return "", fmt.Errorf("notarization failed: %w", err)
+		}
+		p.logf("✅ Notarized %s", artifact)
+	}
+
+	return artifact, nil
+}
+
+// target returns the platform and architecture being packaged
+func (p *Packager) target() (string, string) {
+	platform, arch := p.Settings.Platform, p.Settings.Arch
+	if platform == "" {
+		platform = goEnv("GOOS")
+	}
+	if arch == "" {
+		arch = goEnv("GOARCH")
+	}
+	return platform, arch
+}
+
+func (p *Packager) logf(format string, args ...interface{}) {
+	if p.Logf != nil {
+		p.Logf(format, args...)
+	}
+}
+
+// goEnv reads a value from the Go toolchain environment
+func goEnv(key string) string {
+	out, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// collectAssets reads every file under dir keyed by its slash-separated
+// relative path. A missing directory yields no assets.
+func collectAssets(dir string) (map[string][]byte, error) {
+	assets := make(map[string][]byte)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return assets, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		assets[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return assets, err
+}
+
+// localBuilder builds with the Go toolchain and reads the resulting binary
+type localBuilder struct{}
+
+func (localBuilder) Build(ctx context.Context, settings *BuildSettings) ([]byte, error) {
+	if err := os.MkdirAll(settings.Build.OutputPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := settings.Command("build").Run(); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(settings.Binary())
+}
+
+// cloudBuilder submits the project source to a cloud build service
+type cloudBuilder struct {
+	builder cloud.Builder
+	dir     string
+}
+
+func (b cloudBuilder) Build(ctx context.Context, settings *BuildSettings) ([]byte, error) {
+	source, err := archiveSource(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive source: %w", err)
+	}
+
+	result, err := b.builder.Build(ctx, &cloud.BuildRequest{
+		ProjectID: settings.Name,
+		Platform:  cloud.Platform{OS: settings.Platform, Arch: settings.Arch, CGOEnabled: !settings.Stub},
+		Source:    source,
+		Languages: settings.EnabledLanguages(),
+		Tags:      settings.Tags(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("cloud build %s: %s", result.ID, result.Error)
+	}
+	if result.Provenance != nil {
+		if err := result.Provenance.Verify(result.Binary); err != nil {
+			return nil, fmt.Errorf("cloud build %s: %w", result.ID, err)
+		}
+	}
+	return result.Binary, nil
+}
+
+// archiveSource packs the project sources as a gzipped tarball, leaving
+// out build output and version control data
+func archiveSource(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() {
+			if rel == "dist" || rel == ".git" || rel == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{Name: filepath.ToSlash(rel), Mode: 0644, Size: int64(len(data))}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// platformBundler writes .app/.dmg, .exe, or AppImage artifacts
+type platformBundler struct{}
+
+func (platformBundler) Bundle(ctx context.Context, b *Bundle) (string, error) {
+	base := fmt.Sprintf("%s-%s-%s", b.Name, b.Platform, b.Arch)
+	stage := filepath.Join(b.OutputDir, base)
+	if err := os.RemoveAll(stage); err != nil {
+		return "", err
+	}
+
+	switch b.Platform {
+	case "darwin":
+		return bundleDarwin(ctx, b, stage)
+	case "windows":
+		return stage, writeBundle(stage, b.Name+".exe", b, filepath.Join(stage, "frontend"))
+	default:
+		return bundleLinux(ctx, b, stage)
+	}
+}
+
+// writeBundle writes the binary and assets into a staging directory
+func writeBundle(dir, binaryName string, b *Bundle, assetDir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, binaryName), b.Binary, 0755); err != nil {
+		return err
+	}
+	for name, data := range b.Assets {
+		path := filepath.Join(assetDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bundleDarwin creates Name.app and, when hdiutil is available, a .dmg
+func bundleDarwin(ctx context.Context, b *Bundle, stage string) (string, error) {
+	app := filepath.Join(stage, b.Name+".app")
+	contents := filepath.Join(app, "Contents")
+	if err := writeBundle(filepath.Join(contents, "MacOS"), b.Name, b, filepath.Join(contents, "Resources", "frontend")); err != nil {
+		return "", err
+	}
+
+	plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>CFBundleName</key><string>%s</string>
+  <key>CFBundleExecutable</key><string>%s</string>
+  <key>CFBundleIdentifier</key><string>com.polyglot.%s</string>
+  <key>CFBundleShortVersionString</key><string>%s</string>
+  <key>CFBundlePackageType</key><string>APPL</string>
+</dict>
+</plist>
+`, b.Name, b.Name, b.Name, b.Version)
+	if err := os.WriteFile(filepath.Join(contents, "Info.plist"), []byte(plist), 0644); err != nil {
+		return "", err
+	}
+
+	if _, err := exec.LookPath("hdiutil"); err != nil {
+		return app, nil
+	}
+
+	dmg := stage + ".dmg"
+	os.Remove(dmg)
+	cmd := exec.CommandContext(ctx, "hdiutil", "create", "-volname", b.Name, "-srcfolder", app, "-ov", "-format", "UDZO", dmg)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("hdiutil failed: %v: %s", err, out)
+	}
+	return dmg, nil
+}
+
+// bundleLinux creates an AppDir and, when appimagetool is available, an
+// AppImage
+func bundleLinux(ctx context.Context, b *Bundle, stage string) (string, error) {
+	appDir := filepath.Join(stage, b.Name+".AppDir")
+	if err := writeBundle(filepath.Join(appDir, "usr", "bin"), b.Name, b, filepath.Join(appDir, "usr", "share", b.Name, "frontend")); err != nil {
+		return "", err
+	}
+
+	desktop := fmt.Sprintf("[Desktop Entry]\nType=Application\nName=%s\nExec=%s\nIcon=%s\nCategories=Utility;\n", b.Name, b.Name, b.Name)
+	if err := os.WriteFile(filepath.Join(appDir, b.Name+".desktop"), []byte(desktop), 0644); err != nil {
+		return "", err
+	}
+	appRun := fmt.Sprintf("#!/bin/sh\nHERE=\"$(dirname \"$(readlink -f \"$0\")\")\"\nexec \"$HERE/usr/bin/%s\" \"$@\"\n", b.Name)
+	if err := os.WriteFile(filepath.Join(appDir, "AppRun"), []byte(appRun), 0755); err != nil {
+		return "", err
+	}
+
+	if _, err := exec.LookPath("appimagetool"); err != nil {
+		return appDir, nil
+	}
+
+	image := stage + ".AppImage"
+	cmd := exec.CommandContext(ctx, "appimagetool", appDir, image)
+	cmd.Env = append(os.Environ(), "ARCH="+appImageArch(b.Arch))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("appimagetool failed: %v: %s", err, out)
+	}
+	return image, nil
+}
+
+// appImageArch maps Go architectures to AppImage names
+func appImageArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	default:
+		return arch
+	}
+}
+
+// notarytool notarizes with xcrun notarytool using a stored keychain profile
+type notarytool struct {
+	profile string
+}
+
+func (n notarytool) Notarize(ctx context.Context, artifact string) error {
+	// notarytool accepts disk images and zip archives, not bare bundles
+	submission := artifact
+	if strings.HasSuffix(artifact, ".app") {
+		submission = artifact + ".zip"
+		zip := exec.CommandContext(ctx, "ditto", "-c", "-k", "--keepParent", artifact, submission)
+		if out, err := zip.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to archive %s: %v: %s", artifact, err, out)
+		}
+		defer os.Remove(submission)
+	}
+
+	cmd := exec.CommandContext(ctx, "xcrun", "notarytool", "submit", submission, "--keychain-profile", n.profile, "--wait")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+
+	if strings.HasSuffix(artifact, ".dmg") || strings.HasSuffix(artifact, ".app") {
+		staple := exec.CommandContext(ctx, "xcrun", "stapler", "staple", artifact)
+		if out, err := staple.CombinedOutput(); err != nil {
+			return fmt.Errorf("stapling failed: %v: %s", err, out)
+		}
+	}
+	return nil
+}
+
+// loadCertificate reads the configured signing certificate
+func loadCertificate(path, platform string) (*signing.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	certType := map[string]string{"darwin": "apple", "windows": "windows"}[platform]
+	if certType == "" {
+		certType = "linux"
+	}
+	return &signing.Certificate{ID: filepath.Base(path), Type: certType, Data: data}, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/griffincancode/polyglot.js/signing"
+)
+
+type recordingStep struct {
+	steps *[]string
+	fail  string
+}
+
+func (r recordingStep) record(step string) error {
+	*r.steps = append(*r.steps, step)
+	if r.fail == step {
+		return errors.New(step + " exploded")
+	}
+	return nil
+}
+
+func (r recordingStep) Build(ctx context.Context, settings *BuildSettings) ([]byte, error) {
+	return []byte("binary"), r.record("build")
+}
+
+func (r recordingStep) Sign(ctx context.Context, req *signing.SignRequest) (*signing.SignResult, error) {
+	if err := r.record("sign"); err != nil {
+		return nil, err
+	}
+	return &signing.SignResult{SignedBinary: append([]byte("signed-"), req.Binary...)}, nil
+}
+
+func (r recordingStep) Bundle(ctx context.Context, bundle *Bundle) (string, error) {
+	if err := r.record("bundle"); err != nil {
+		return "", err
+	}
+	if string(bundle.Binary) != "signed-binary" && string(bundle.Binary) != "binary" {
+		return "", errors.New("unexpected binary")
+	}
+	return filepath.Join(bundle.OutputDir, bundle.Name+".dmg"), nil
+}
+
+func (r recordingStep) Notarize(ctx context.Context, artifact string) error {
+	return r.record("notarize")
+}
+
+func newTestPackager(t *testing.T, platform, fail string) (*Packager, *[]string) {
+	t.Helper()
+	steps := &[]string{}
+	step := recordingStep{steps: steps, fail: fail}
+
+	settings := &BuildSettings{Name: "myapp", Version: "1.0.0", Platform: platform, Arch: "arm64"}
+	settings.Build.OutputPath = "dist"
+
+	return &Packager{
+		Settings:    settings,
+		Dir:         t.TempDir(),
+		Builder:     step,
+		Signer:      step,
+		Certificate: &signing.Certificate{ID: "test-cert"},
+		Bundler:     step,
+		Notarizer:   step,
+	}, steps
+}
+
+func TestPackagePipelineOrder(t *testing.T) {
+	packager, steps := newTestPackager(t, "darwin", "")
+
+	artifact, err := packager.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.HasSuffix(artifact, "myapp.dmg") {
+		t.Errorf("Unexpected artifact %s", artifact)
+	}
+
+	want := []string{"build", "sign", "bundle", "notarize"}
+	if !reflect.DeepEqual(*steps, want) {
+		t.Errorf("Steps = %v, want %v", *steps, want)
+	}
+}
+
+func TestPackageSkipsOptionalSteps(t *testing.T) {
+	packager, steps := newTestPackager(t, "linux", "")
+	packager.Certificate = nil
+
+	if _, err := packager.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// No certificate skips signing; notarization only applies to darwin
+	want := []string{"build", "bundle"}
+	if !reflect.DeepEqual(*steps, want) {
+		t.Errorf("Steps = %v, want %v", *steps, want)
+	}
+}
+
+func TestPackageFailureStopsPipeline(t *testing.T) {
+	tests := []struct {
+		fail  string
+		err   string
+		steps []string
+	}{
+		{"build", "build failed", []string{"build"}},
+		{"sign", "signing failed", []string{"build", "sign"}},
+		{"bundle", "bundling failed", []string{"build", "sign", "bundle"}},
+		{"notarize", "notarization failed", []string{"build", "sign", "bundle", "notarize"}},
+	}
+
+	for _, tt := range tests {
+		packager, steps := newTestPackager(t, "darwin", tt.fail)
+
+		_, err := packager.Run(context.Background())
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.fail, tt.err, err)
+		}
+		if !reflect.DeepEqual(*steps, tt.steps) {
+			t.Errorf("%s: steps = %v, want %v", tt.fail, *steps, tt.steps)
+		}
+	}
+}
+
+func TestCollectAssets(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "css"), 0755)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>"), 0644)
+	os.WriteFile(filepath.Join(dir, "css", "app.css"), []byte("body{}"), 0644)
+
+	assets, err := collectAssets(dir)
+	if err != nil {
+		t.Fatalf("collectAssets failed: %v", err)
+	}
+	if len(assets) != 2 || string(assets["index.html"]) != "<html>" || string(assets["css/app.css"]) != "body{}" {
+		t.Errorf("Unexpected assets: %v", assets)
+	}
+
+	assets, err = collectAssets(filepath.Join(dir, "missing"))
+	if err != nil || len(assets) != 0 {
+		t.Errorf("Expected no assets for a missing directory, got %v, %v", assets, err)
+	}
+}
+
+func TestPlatformBundlerWindows(t *testing.T) {
+	out := t.TempDir()
+	artifact, err := platformBundler{}.Bundle(context.Background(), &Bundle{
+		Name:      "myapp",
+		Platform:  "windows",
+		Arch:      "amd64",
+		Binary:    []byte("exe"),
+		Assets:    map[string][]byte{"index.html": []byte("<html>")},
+		OutputDir: out,
+	})
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(artifact, "myapp.exe")); err != nil || string(data) != "exe" {
+		t.Errorf("Expected myapp.exe in %s: %v", artifact, err)
+	}
+	if _, err := os.Stat(filepath.Join(artifact, "frontend", "index.html")); err != nil {
+		t.Errorf("Expected bundled frontend: %v", err)
+	}
+}
+
+func TestCloudClientRequiresService(t *testing.T) {
+	if _, err := cloudClient(context.Background()); !errors.Is(err, errNoCloudService) {
+		t.Errorf("Expected errNoCloudService without a configured service, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// ProjectFS receives the files written by the generator. Paths are
+// slash-separated and relative to the project root.
+type ProjectFS interface {
+	MkdirAll(dir string) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+}
+
+// dirFS writes a project below a directory on disk
+type dirFS struct {
+	root string
+}
+
+func (d dirFS) MkdirAll(dir string) error {
+	return os.MkdirAll(filepath.Join(d.root, filepath.FromSlash(dir)), 0755)
+}
+
+func (d dirFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(filepath.Join(d.root, filepath.FromSlash(name)), data, perm)
+}
+
+// MemoryFS holds a generated project in memory
+type MemoryFS struct {
+	Files map[string][]byte
+	Dirs  map[string]bool
+}
+
+// NewMemoryFS creates an empty in-memory project
+func NewMemoryFS() *MemoryFS {
+	return &MemoryFS{Files: make(map[string][]byte), Dirs: make(map[string]bool)}
+}
+
+// MkdirAll records dir and its parents
+func (m *MemoryFS) MkdirAll(dir string) error {
+	for dir = path.Clean(dir); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		m.Dirs[dir] = true
+	}
+	return nil
+}
+
+// WriteFile stores a copy of data, which like os.WriteFile requires the
+// parent directory to exist
+func (m *MemoryFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	name = path.Clean(name)
+	if dir := path.Dir(name); dir != "." && !m.Dirs[dir] {
+		return fmt.Errorf("open %s: %w", name, os.ErrNotExist)
+	}
+	m.Files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// Paths returns the stored file paths in order
+func (m *MemoryFS) Paths() []string {
+	paths := make([]string, 0, len(m.Files))
+	for name := range m.Files {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// TemplateDiff compares freshly generated files with an existing project.
+// Paths are slash-separated and relative to the project root; files the
+// generator does not produce are ignored.
+type TemplateDiff struct {
+	// Added are generated files missing from the project
+	Added []string
+
+	// Changed are files whose contents differ from what is generated
+	Changed []string
+
+	// Unchanged are files identical to what is generated
+	Unchanged []string
+}
+
+// HasChanges reports whether updating the project would change any file
+func (d *TemplateDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Changed) > 0
+}
+
+// Diff generates the project in memory and compares each file with the
+// project in existingDir, so scaffolding from an older template can be
+// updated
+func (t *ProjectTemplate) Diff(existingDir string) (*TemplateDiff, error) {
+	generated := NewMemoryFS()
+	if err := t.GenerateTo(generated); err != nil {
+		return nil, err
+	}
+
+	diff := &TemplateDiff{}
+	for _, name := range generated.Paths() {
+		current, err := os.ReadFile(filepath.Join(existingDir, filepath.FromSlash(name)))
+		switch {
+		case os.IsNotExist(err):
+			diff.Added = append(diff.Added, name)
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		case bytes.Equal(current, generated.Files[name]):
+			diff.Unchanged = append(diff.Unchanged, name)
+		default:
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// Default files scaffold edits, relative to the project root
+var (
+	scaffoldMainFile = "src/backend/main.go"
+	scaffoldJSFile   = "src/frontend/scripts/main.js"
+)
+
+// scaffoldTypes maps the type names accepted by --args and --returns to Go
+// types; anything else must be a Go type expression
+var scaffoldTypes = map[string]string{
+	"any":     "interface{}",
+	"boolean": "bool",
+	"bytes":   "[]byte",
+	"float":   "float64",
+	"number":  "float64",
+}
+
+// bridgeNamePattern matches bridge function names the scaffold accepts:
+// identifiers, optionally dotted into groups
+var bridgeNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// scaffoldArg is one parameter of a scaffolded function
+type scaffoldArg struct {
+	Name string
+	Type string
+}
+
+// scaffoldFunction describes a bridge function to scaffold
+type scaffoldFunction struct {
+	// Name the function is registered under
+	Name string
+
+	// Args are the function's parameters
+	Args []scaffoldArg
+
+	// Returns is the Go result type
+	Returns string
+}
+
+// newScaffoldFunction validates a function name, an argument list of
+// comma-separated name:type pairs and a result type
+func newScaffoldFunction(name, args, returns string) (*scaffoldFunction, error) {
+	if !bridgeNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid function name %q", name)
+	}
+	if core.IsReservedBridgeName(name) {
+		return nil, fmt.Errorf("function name %q is reserved by the polyglot API", name)
+	}
+
+	fn := &scaffoldFunction{Name: name, Returns: "interface{}"}
+	if returns != "" {
+		goType, err := scaffoldType(returns)
+		if err != nil {
+			return nil, err
+		}
+		fn.Returns = goType
+	}
+
+	seen := make(map[string]bool)
+	for _, spec := range strings.Split(args, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		argName, argType, ok := strings.Cut(spec, ":")
+		argName = strings.TrimSpace(argName)
+		if !ok {
+			return nil, fmt.Errorf("argument %q must be name:type", spec)
+		}
+		if !token.IsIdentifier(argName) || argName == "ctx" || argName == "result" || argName == "err" {
+			return nil, fmt.Errorf("invalid argument name %q", argName)
+		}
+		if seen[argName] {
+			return nil, fmt.Errorf("duplicate argument %q", argName)
+		}
+		seen[argName] = true
+
+		goType, err := scaffoldType(strings.TrimSpace(argType))
+		if err != nil {
+			return nil, err
+		}
+		fn.Args = append(fn.Args, scaffoldArg{Name: argName, Type: goType})
+	}
+	return fn, nil
+}
+
+// scaffoldType resolves a type name to a Go type expression
+func scaffoldType(name string) (string, error) {
+	if goType, ok := scaffoldTypes[name]; ok {
+		return goType, nil
+	}
+	if _, err := parser.ParseExpr(name); err != nil || name == "" {
+		return "", fmt.Errorf("invalid type %q", name)
+	}
+	return name, nil
+}
+
+// JSName is the name of the frontend wrapper: the bridge name with dotted
+// groups joined in camel case
+func (f *scaffoldFunction) JSName() string {
+	parts := strings.Split(f.Name, ".")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// registration renders the RegisterTyped call for bridge, indented by
+// indent
+func (f *scaffoldFunction) registration(bridge, indent string) string {
+	params := []string{"ctx context.Context"}
+	for _, arg := range f.Args {
+		params = append(params, arg.Name+" "+arg.Type)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s// %s is called by %s() in the frontend\n", indent, f.Name, f.JSName())
+	fmt.Fprintf(&b, "%s%s.RegisterTyped(%s, func(%s) (result %s, err error) {\n", indent, bridge, strconv.Quote(f.Name), strings.Join(params, ", "), f.Returns)
+	fmt.Fprintf(&b, "%s\t// TODO: implement %s\n", indent, f.Name)
+	fmt.Fprintf(&b, "%s\treturn result, nil\n", indent)
+	fmt.Fprintf(&b, "%s})", indent)
+	return b.String()
+}
+
+// wrapper renders the frontend function calling the bridge function
+func (f *scaffoldFunction) wrapper(module bool) string {
+	names := make([]string, len(f.Args))
+	var b strings.Builder
+	b.WriteString("/**\n")
+	fmt.Fprintf(&b, " * Calls the %s bridge function\n", f.Name)
+	for i, arg := range f.Args {
+		names[i] = arg.Name
+		fmt.Fprintf(&b, " * @param {%s} %s\n", jsDocType(arg.Type, true), arg.Name)
+	}
+	fmt.Fprintf(&b, " * @returns {Promise<%s>}\n", jsDocType(f.Returns, false))
+	b.WriteString(" */\n")
+	if module {
+		b.WriteString("export ")
+	}
+	fmt.Fprintf(&b, "async function %s(%s) {\n", f.JSName(), strings.Join(names, ", "))
+	callArgs := append([]string{"'" + f.Name + "'"}, names...)
+	fmt.Fprintf(&b, "\treturn window.polyglot.call(%s);\n", strings.Join(callArgs, ", "))
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// jsDocType renders a Go type as the JSDoc type the bridge exchanges it
+// as, following polyglot types
+func jsDocType(goType string, arg bool) string {
+	expr, err := parser.ParseExpr(goType)
+	if err != nil {
+		return "any"
+	}
+	return jsDocExpr(expr, arg)
+}
+
+func jsDocExpr(expr ast.Expr, arg bool) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			return "string"
+		case "bool":
+			return "boolean"
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+			return "number"
+		}
+	case *ast.StarExpr:
+		return jsDocExpr(e.X, arg) + " | null"
+	case *ast.ArrayType:
+		if elem, ok := e.Elt.(*ast.Ident); ok && elem.Name == "byte" {
+			if arg {
+				return "ArrayBuffer | ArrayBufferView"
+			}
+			return "Uint8Array | string"
+		}
+		return "Array<" + jsDocExpr(e.Elt, arg) + ">"
+	case *ast.MapType:
+		return "Object<string, " + jsDocExpr(e.Value, arg) + ">"
+	}
+	return "any"
+}
+
+// scaffoldGo adds a registration of fn to Go source, after the last
+// function registered on the bridge created with NewBridge, or right after
+// its creation when none is
+func scaffoldGo(src []byte, fn *scaffoldFunction) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	bridge, anchor := findBridge(file)
+	if bridge == "" {
+		return nil, fmt.Errorf("no bridge created with NewBridge found")
+	}
+
+	var duplicate bool
+	ast.Inspect(file, func(n ast.Node) bool {
+		if name, ok := registeredName(n, bridge); ok && name == fn.Name {
+			duplicate = true
+		}
+		return !duplicate
+	})
+	if duplicate {
+		return nil, fmt.Errorf("function %s is already registered", fn.Name)
+	}
+
+	offset := fset.Position(anchor.End()).Offset
+	lineStart := bytes.LastIndexByte(src[:fset.Position(anchor.Pos()).Offset], '\n') + 1
+	indent := src[lineStart:fset.Position(anchor.Pos()).Offset]
+
+	var out bytes.Buffer
+	out.Write(src[:offset])
+	out.WriteString("\n\n")
+	out.WriteString(fn.registration(bridge, string(indent)))
+	out.Write(src[offset:])
+
+	result := out.Bytes()
+	if !importsPath(file, "context") {
+		result = addImport(result, fset, file, "context")
+	}
+	return format.Source(result)
+}
+
+// findBridge finds the variable assigned from NewBridge and the statement
+// to insert after: the last statement of that block registering a
+// function on it, or the assignment itself
+func findBridge(file *ast.File) (string, ast.Stmt) {
+	var bridge string
+	var anchor ast.Stmt
+	ast.Inspect(file, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok || bridge != "" {
+			return bridge == ""
+		}
+		for _, stmt := range block.List {
+			if bridge == "" {
+				if name := newBridgeAssignment(stmt); name != "" {
+					bridge, anchor = name, stmt
+				}
+				continue
+			}
+			if registers(stmt, bridge) {
+				anchor = stmt
+			}
+		}
+		return bridge == ""
+	})
+	return bridge, anchor
+}
+
+// newBridgeAssignment returns the variable stmt assigns NewBridge() to
+func newBridgeAssignment(stmt ast.Stmt) string {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return ""
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	var fnName string
+	switch f := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		fnName = f.Sel.Name
+	case *ast.Ident:
+		fnName = f.Name
+	}
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+	if fnName != "NewBridge" || !ok {
+		return ""
+	}
+	return ident.Name
+}
+
+// registers reports whether stmt registers a function on bridge
+func registers(stmt ast.Stmt, bridge string) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if _, ok := registeredName(n, bridge); ok {
+			found = true
+		}
+		_, isFunc := n.(*ast.FuncLit)
+		return !found && !isFunc
+	})
+	return found
+}
+
+// registeredName returns the name n registers on bridge, if it is a call
+// to one of its Register methods
+func registeredName(n ast.Node, bridge string) (string, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !strings.HasPrefix(sel.Sel.Name, "Register") {
+		return "", false
+	}
+	if recv, ok := sel.X.(*ast.Ident); !ok || recv.Name != bridge {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", true
+	}
+	name, err := strconv.Unquote(lit.Value)
+	return name, err == nil
+}
+
+// importsPath reports whether file imports path
+func importsPath(file *ast.File, path string) bool {
+	for _, spec := range file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil && p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// addImport adds an import of path to src, whose parse is file. Offsets
+// in file are unchanged by edits made after its imports.
+func addImport(src []byte, fset *token.FileSet, file *ast.File, path string) []byte {
+	var offset int
+	var text string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid() {
+			offset = fset.Position(gen.Lparen).Offset + 1
+			text = "\n\t" + strconv.Quote(path)
+			break
+		}
+	}
+	if text == "" {
+		offset = fset.Position(file.Name.End()).Offset
+		text = "\n\nimport " + strconv.Quote(path)
+	}
+
+	out := make([]byte, 0, len(src)+len(text))
+	out = append(out, src[:offset]...)
+	out = append(out, text...)
+	return append(out, src[offset:]...)
+}
+
+// jsModulePattern detects ES module syntax
+var jsModulePattern = regexp.MustCompile(`(?m)^\s*(import|export)\s`)
+
+// scaffoldJS appends a wrapper for fn to JavaScript source, which must
+// not already define a function of that name
+func scaffoldJS(src []byte, fn *scaffoldFunction) ([]byte, error) {
+	if err := checkJSBalanced(src); err != nil {
+		return nil, err
+	}
+	defined := regexp.MustCompile(`(?m)\b(function\s+|(const|let|var)\s+)` + regexp.QuoteMeta(fn.JSName()) + `\b`)
+	if defined.Match(src) {
+		return nil, fmt.Errorf("%s is already defined", fn.JSName())
+	}
+
+	var out bytes.Buffer
+	out.Write(bytes.TrimRight(src, " \t\r\n"))
+	if out.Len() > 0 {
+		out.WriteString("\n\n")
+	}
+	out.WriteString(fn.wrapper(jsModulePattern.Match(src)))
+	return out.Bytes(), nil
+}
+
+// checkJSBalanced checks that src ends at the top level, outside any
+// block, string or comment, so appended code is a top-level declaration.
+// Regular expression literals are not recognized.
+func checkJSBalanced(src []byte) error {
+	depth := 0
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '/':
+			if i+1 < len(src) && src[i+1] == '/' {
+				for i < len(src) && src[i] != '\n' {
+					i++
+				}
+			} else if i+1 < len(src) && src[i+1] == '*' {
+				end := bytes.Index(src[i+2:], []byte("*/"))
+				if end < 0 {
+					return fmt.Errorf("unterminated comment")
+				}
+				i += end + 3
+			}
+		case '"', '\'', '`':
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' {
+					i++
+				} else if src[i] == '\n' && c != '`' {
+					return fmt.Errorf("unterminated string")
+				}
+			}
+			if i >= len(src) {
+				return fmt.Errorf("unterminated string")
+			}
+		case '{', '(', '[':
+			depth++
+		case '}', ')', ']':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced %q", c)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unclosed block at end of file")
+	}
+	return nil
+}
+
+// scaffoldFiles adds fn to the Go file at mainPath and, if it exists, the
+// JavaScript file at jsPath. Neither file is written unless both edits
+// succeed.
+func scaffoldFiles(fn *scaffoldFunction, mainPath, jsPath string) (jsWritten bool, err error) {
+	goSrc, err := os.ReadFile(mainPath)
+	if err != nil {
+		return false, err
+	}
+	goOut, err := scaffoldGo(goSrc, fn)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", mainPath, err)
+	}
+
+	jsSrc, err := os.ReadFile(jsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	var jsOut []byte
+	if err == nil {
+		if jsOut, err = scaffoldJS(jsSrc, fn); err != nil {
+			return false, fmt.Errorf("%s: %w", jsPath, err)
+		}
+	}
+
+	if err := os.WriteFile(mainPath, goOut, 0644); err != nil {
+		return false, err
+	}
+	if jsOut == nil {
+		return false, nil
+	}
+	return true, os.WriteFile(jsPath, jsOut, 0644)
+}
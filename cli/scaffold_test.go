@@ -0,0 +1,160 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldFunction(t *testing.T) {
+	project := generateIn(t, t.TempDir(), goldenConfig("webapp"))
+	mainPath := filepath.Join(project, scaffoldMainFile)
+	jsPath := filepath.Join(project, scaffoldJSFile)
+
+	fn, err := newScaffoldFunction("notes.save", "title:string, count:int, tags:[]string", "bool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsWritten, err := scaffoldFiles(fn, mainPath, jsPath)
+	if err != nil {
+		t.Fatalf("scaffold failed: %v", err)
+	}
+	if !jsWritten {
+		t.Fatal("frontend stub was not written")
+	}
+
+	// The backend still parses and registers the function with its
+	// arguments after the template's own registrations
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, mainPath, nil, 0)
+	if err != nil {
+		t.Fatalf("scaffolded main.go does not parse: %v", err)
+	}
+	var order []string
+	var params string
+	ast.Inspect(file, func(n ast.Node) bool {
+		name, ok := registeredName(n, "bridge")
+		if !ok {
+			return true
+		}
+		order = append(order, name)
+		if name == "notes.save" {
+			call := n.(*ast.CallExpr)
+			if sel := call.Fun.(*ast.SelectorExpr); sel.Sel.Name != "RegisterTyped" {
+				t.Errorf("registered with %s, want RegisterTyped", sel.Sel.Name)
+			}
+			lit := call.Args[1].(*ast.FuncLit)
+			var names []string
+			for _, field := range lit.Type.Params.List {
+				names = append(names, field.Names[0].Name)
+			}
+			params = strings.Join(names, ",")
+		}
+		return true
+	})
+	if got := strings.Join(order, ","); got != "greet,getAppInfo,notes.save" {
+		t.Errorf("registrations = %s", got)
+	}
+	if params != "ctx,title,count,tags" {
+		t.Errorf("params = %s", params)
+	}
+	if !importsPath(file, "context") {
+		t.Error("context is not imported")
+	}
+
+	js := readFile(t, project, scaffoldJSFile)
+	for _, want := range []string{
+		"@param {Array<string>} tags",
+		"@returns {Promise<boolean>}",
+		"async function notesSave(title, count, tags) {",
+		"return window.polyglot.call('notes.save', title, count, tags);",
+	} {
+		if !strings.Contains(js, want) {
+			t.Errorf("main.js missing %q:\n%s", want, js)
+		}
+	}
+	if err := checkJSBalanced([]byte(js)); err != nil {
+		t.Errorf("scaffolded main.js is unbalanced: %v", err)
+	}
+
+	// Scaffolding the same function again fails without touching either file
+	before := readFile(t, project, scaffoldMainFile)
+	if _, err := scaffoldFiles(fn, mainPath, jsPath); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("duplicate scaffold error = %v", err)
+	}
+	if readFile(t, project, scaffoldMainFile) != before || readFile(t, project, scaffoldJSFile) != js {
+		t.Error("failed scaffold modified the project")
+	}
+}
+
+func TestScaffoldAddsContextImport(t *testing.T) {
+	src := "package main\n\nimport \"github.com/griffincancode/polyglot.js/core\"\n\nfunc main() {\n\tb := core.NewBridge()\n\t_ = b\n}\n"
+	fn, err := newScaffoldFunction("ping", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := scaffoldGo([]byte(src), fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\"context\"", "b := core.NewBridge()\n\n\t// ping is called by ping() in the frontend\n\tb.RegisterTyped(\"ping\", func(ctx context.Context) (result interface{}, err error) {"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestScaffoldModuleExport(t *testing.T) {
+	fn, err := newScaffoldFunction("load", "path:string", "bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := scaffoldJS([]byte("import { render } from './render.js';\n"), fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "export async function load(path) {") {
+		t.Errorf("module wrapper not exported:\n%s", out)
+	}
+	if !strings.Contains(string(out), "@returns {Promise<Uint8Array | string>}") {
+		t.Errorf("bytes result type not documented:\n%s", out)
+	}
+}
+
+func TestScaffoldRejects(t *testing.T) {
+	for _, tc := range []struct {
+		name, args, returns string
+	}{
+		{"call", "", ""},
+		{"1bad", "", ""},
+		{"ok", "title", ""},
+		{"ok", "ctx:string", ""},
+		{"ok", "a:int,a:int", ""},
+		{"ok", "a:not a type", ""},
+		{"ok", "", "map["},
+	} {
+		if _, err := newScaffoldFunction(tc.name, tc.args, tc.returns); err == nil {
+			t.Errorf("newScaffoldFunction(%q, %q, %q) succeeded", tc.name, tc.args, tc.returns)
+		}
+	}
+
+	fn, _ := newScaffoldFunction("save", "", "")
+	for _, src := range []string{
+		"function save() {}\n",
+		"document.addEventListener('x', () => {\n",
+		"const s = 'unterminated\n",
+	} {
+		if _, err := scaffoldJS([]byte(src), fn); err == nil {
+			t.Errorf("scaffoldJS accepted %q", src)
+		}
+	}
+	if _, err := scaffoldJS([]byte("const s = '{'; // }\n/* { */\nconst t = `${s}`;\n"), fn); err != nil {
+		t.Errorf("braces in strings and comments: %v", err)
+	}
+	if _, err := scaffoldGo([]byte("package main\n\nfunc main() {}\n"), fn); err == nil {
+		t.Error("scaffoldGo accepted a file without a bridge")
+	}
+}
@@ -1,15 +1,47 @@
 package main
 
 import (
+	"bytes"
+	"embed"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 )
 
+// builtinTemplates holds the default project file templates
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
 // ProjectTemplate handles project template generation
 type ProjectTemplate struct {
 	config *ProjectConfig
+	fs     ProjectFS
+}
+
+// templateData is the value passed to project file templates. It exposes
+// the project config directly plus pre-rendered language-dependent sections.
+type templateData struct {
+	*ProjectConfig
+
+	LanguagesJSON string
+	FeaturesJSON  string
+	LanguageList  string
+	FeatureList   string
+
+	RuntimesConfig       string
+	RuntimeImports       string
+	RuntimeConfigs       string
+	RuntimeRegistrations string
+	BridgeFunctions      string
+	AutoUpdate           bool
+
+	Prerequisites     string
+	LanguageSetup     string
+	BuildInstructions string
+	StructureExtras   string
 }
 
 // NewTemplate creates a new template generator
@@ -17,8 +49,16 @@ func NewTemplate(config *ProjectConfig) *ProjectTemplate {
 	return &ProjectTemplate{config: config}
 }
 
-// Generate creates the project structure and files
+// Generate creates the project structure and files in a directory named
+// after the project
 func (t *ProjectTemplate) Generate() error {
+	return t.GenerateTo(dirFS{root: t.config.Name})
+}
+
+// GenerateTo creates the project structure and files in fs
+func (t *ProjectTemplate) GenerateTo(fs ProjectFS) error {
+	t.fs = fs
+
 	// Create base directories
 	if err := t.createDirectories(); err != nil {
 		return fmt.Errorf("failed to create directories: %w", err)
@@ -74,21 +114,82 @@ func (t *ProjectTemplate) Generate() error {
 	return nil
 }
 
+// data builds the template data for this project
+func (t *ProjectTemplate) data() *templateData {
+	return &templateData{
+		ProjectConfig:        t.config,
+		LanguagesJSON:        t.formatStringArray(t.config.Languages),
+		FeaturesJSON:         t.formatStringArray(t.config.Features),
+		LanguageList:         strings.Join(t.config.Languages, ", "),
+		FeatureList:          strings.Join(t.config.Features, ", "),
+		RuntimesConfig:       t.generateRuntimesConfig(),
+		RuntimeImports:       t.generateRuntimeImports(),
+		RuntimeConfigs:       t.generateRuntimeConfigs(),
+		RuntimeRegistrations: t.generateRuntimeRegistrations(),
+		BridgeFunctions:      t.generateBridgeFunctions(),
+		AutoUpdate:           t.autoUpdate(),
+		Prerequisites:        t.generatePrerequisites(),
+		LanguageSetup:        t.generateLanguageSetup(),
+		BuildInstructions:    t.generateBuildInstructions(),
+		StructureExtras:      t.generateProjectStructureExtras(),
+	}
+}
+
+// loadTemplate reads a template, preferring the user's template directory
+// over the built-in templates
+func (t *ProjectTemplate) loadTemplate(name string) (string, error) {
+	if t.config.TemplateDir != "" {
+		content, err := os.ReadFile(filepath.Join(t.config.TemplateDir, name))
+		if err == nil {
+			return string(content), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+	}
+
+	content, err := builtinTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", fmt.Errorf("unknown template %s: %w", name, err)
+	}
+	return string(content), nil
+}
+
+// render executes a template and writes it to a path inside the project
+func (t *ProjectTemplate) render(name string, path ...string) error {
+	text, err := t.loadTemplate(name)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, t.data()); err != nil {
+		return fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	return t.fs.WriteFile(strings.Join(path, "/"), buf.Bytes(), 0644)
+}
+
 func (t *ProjectTemplate) createDirectories() error {
 	dirs := []string{
-		t.config.Name,
-		filepath.Join(t.config.Name, "src"),
-		filepath.Join(t.config.Name, "src", "backend"),
-		filepath.Join(t.config.Name, "dist"),
-		filepath.Join(t.config.Name, ".polyglot"),
+		".",
+		"src",
+		"src/backend",
+		"dist",
+		".polyglot",
 	}
 
 	if contains(t.config.Features, "webview") {
 		dirs = append(dirs,
-			filepath.Join(t.config.Name, "src", "frontend"),
-			filepath.Join(t.config.Name, "src", "frontend", "assets"),
-			filepath.Join(t.config.Name, "src", "frontend", "styles"),
-			filepath.Join(t.config.Name, "src", "frontend", "scripts"),
+			"src/frontend",
+			"src/frontend/assets",
+			"src/frontend/styles",
+			"src/frontend/scripts",
 		)
 	}
 
@@ -96,27 +197,27 @@ func (t *ProjectTemplate) createDirectories() error {
 	for _, lang := range t.config.Languages {
 		switch lang {
 		case "python":
-			dirs = append(dirs, filepath.Join(t.config.Name, "src", "python"))
+			dirs = append(dirs, "src/python")
 		case "javascript":
-			dirs = append(dirs, filepath.Join(t.config.Name, "src", "js"))
+			dirs = append(dirs, "src/js")
 		case "rust":
-			dirs = append(dirs, filepath.Join(t.config.Name, "src", "rust"))
+			dirs = append(dirs, "src/rust")
 		}
 	}
 
 	// Template-specific directories
 	switch t.config.Template {
 	case "cli":
-		dirs = append(dirs, filepath.Join(t.config.Name, "cmd"))
+		dirs = append(dirs, "cmd")
 	case "system":
 		dirs = append(dirs,
-			filepath.Join(t.config.Name, "services"),
-			filepath.Join(t.config.Name, "config"),
+			"services",
+			"config",
 		)
 	}
 
 	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
+		if err := t.fs.MkdirAll(dir); err != nil {
 			return err
 		}
 	}
@@ -125,55 +226,7 @@ func (t *ProjectTemplate) createDirectories() error {
 }
 
 func (t *ProjectTemplate) generateConfig() error {
-	content := fmt.Sprintf(`{
-  "name": "%s",
-  "version": "%s",
-  "description": "%s",
-  "author": "%s",
-  "license": "%s",
-  "template": "%s",
-  "languages": [%s],
-  "features": [%s],
-  "webview": {
-    "width": %d,
-    "height": %d,
-    "resizable": %t,
-    "devTools": %t,
-    "title": "%s"
-  },
-  "memory": {
-    "maxSharedMemory": 1073741824,
-    "enableZeroCopy": true,
-    "gcInterval": "5m"
-  },
-  "build": {
-    "outputPath": "./dist",
-    "optimize": true,
-    "compress": true,
-    "platforms": ["darwin", "linux", "windows"]
-  },
-  "runtimes": {
-%s
-  }
-}`,
-		t.config.Name,
-		t.config.Version,
-		t.config.Description,
-		t.config.Author,
-		t.config.License,
-		t.config.Template,
-		t.formatStringArray(t.config.Languages),
-		t.formatStringArray(t.config.Features),
-		t.config.WindowWidth,
-		t.config.WindowHeight,
-		t.config.WindowResizable,
-		t.config.DevTools,
-		t.config.Name,
-		t.generateRuntimesConfig(),
-	)
-
-	path := filepath.Join(t.config.Name, "polyglot.config.json")
-	return os.WriteFile(path, []byte(content), 0644)
+	return t.render("config.json.tmpl", "polyglot.config.json")
 }
 
 func (t *ProjectTemplate) generateRuntimesConfig() string {
@@ -206,334 +259,37 @@ func (t *ProjectTemplate) generateRuntimesConfig() string {
 }
 
 func (t *ProjectTemplate) generateMain() error {
-	var content string
+	var name string
 
 	switch t.config.Template {
 	case "webapp", "desktop":
-		content = t.generateWebAppMain()
+		name = "main_webapp.go.tmpl"
 	case "cli":
-		content = t.generateCLIMain()
+		name = "main_cli.go.tmpl"
 	case "system":
-		content = t.generateSystemMain()
+		name = "main_system.go.tmpl"
 	default:
-		content = t.generateMinimalMain()
+		name = "main_minimal.go.tmpl"
 	}
 
-	path := filepath.Join(t.config.Name, "src", "backend", "main.go")
-	return os.WriteFile(path, []byte(content), 0644)
-}
-
-func (t *ProjectTemplate) generateWebAppMain() string {
-	runtimeImports := t.generateRuntimeImports()
-	runtimeRegistrations := t.generateRuntimeRegistrations()
-	bridgeFunctions := t.generateBridgeFunctions()
-
-	return fmt.Sprintf(`package main
-
-import (
-	"context"
-	"log"
-	"time"
-	
-	"github.com/griffincancode/polyglot.js/core"
-	"github.com/griffincancode/polyglot.js/webview"
-%s
-)
-
-func main() {
-	// Load configuration from polyglot.config.json
-	config := core.DefaultConfig()
-	config.App.Name = "%s"
-	config.App.Version = "%s"
-	
-	// Configure runtimes
-%s
-	
-	// Create orchestrator
-	orch, err := core.NewOrchestrator(config)
-	if err != nil {
-		log.Fatalf("Failed to create orchestrator: %%v", err)
-	}
-	
-	// Register runtimes
-%s
-	
-	// Initialize
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	
-	if err := orch.Initialize(ctx); err != nil {
-		log.Fatalf("Failed to initialize: %%v", err)
-	}
-	defer orch.Shutdown(context.Background())
-	
-	// Create bridge for frontend-backend communication
-	bridge := core.NewBridge()
-	
-	// Register API functions
-%s
-	
-	// Configure webview
-	config.Webview.Title = "%s"
-	config.Webview.Width = %d
-	config.Webview.Height = %d
-	config.Webview.Resizable = %t
-	config.Webview.Debug = %t
-	
-	// Create and initialize webview
-	wv := webview.New(config.Webview, bridge)
-	if err := wv.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize webview: %%v", err)
-	}
-	
-	log.Println("Starting %s...")
-	
-	// Run application
-	if err := wv.Run(); err != nil {
-		log.Fatalf("Failed to run application: %%v", err)
-	}
-}
-`,
-		runtimeImports,
-		t.config.Name,
-		t.config.Version,
-		t.generateRuntimeConfigs(),
-		runtimeRegistrations,
-		bridgeFunctions,
-		t.config.Name,
-		t.config.WindowWidth,
-		t.config.WindowHeight,
-		t.config.WindowResizable,
-		t.config.DevTools,
-		t.config.Name,
-	)
-}
-
-func (t *ProjectTemplate) generateCLIMain() string {
-	return fmt.Sprintf(`package main
-
-import (
-	"context"
-	"flag"
-	"fmt"
-	"log"
-	"os"
-	"time"
-	
-	"github.com/griffincancode/polyglot.js/core"
-%s
-)
-
-var (
-	version = "%s"
-)
-
-func main() {
-	// Define CLI flags
-	versionFlag := flag.Bool("version", false, "Show version information")
-	helpFlag := flag.Bool("help", false, "Show help message")
-	flag.Parse()
-	
-	if *versionFlag {
-		fmt.Printf("%s v%%s\n", version)
-		os.Exit(0)
-	}
-	
-	if *helpFlag {
-		printHelp()
-		os.Exit(0)
-	}
-	
-	// Create configuration
-	config := core.DefaultConfig()
-	config.App.Name = "%s"
-	config.App.Version = "%s"
-	
-%s
-	
-	// Create orchestrator
-	orch, err := core.NewOrchestrator(config)
-	if err != nil {
-		log.Fatalf("Failed to create orchestrator: %%v", err)
-	}
-	
-%s
-	
-	// Initialize
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	
-	if err := orch.Initialize(ctx); err != nil {
-		log.Fatalf("Failed to initialize: %%v", err)
-	}
-	defer orch.Shutdown(context.Background())
-	
-	// Your CLI logic here
-	args := flag.Args()
-	if len(args) == 0 {
-		printHelp()
-		os.Exit(1)
-	}
-	
-	command := args[0]
-	switch command {
-	case "run":
-		handleRun(orch, args[1:])
-	default:
-		fmt.Printf("Unknown command: %%s\n", command)
-		printHelp()
-		os.Exit(1)
+	if err := t.render(name, "src", "backend", "main.go"); err != nil {
+		return err
 	}
-}
-
-func printHelp() {
-	fmt.Printf("%s - %s\n\n", "%s", "%s")
-	fmt.Println("Usage:")
-	fmt.Printf("  %s [command] [arguments]\n\n", "%s")
-	fmt.Println("Commands:")
-	fmt.Println("  run      Execute the main task")
-	fmt.Println()
-	fmt.Println("Flags:")
-	fmt.Println("  --version  Show version information")
-	fmt.Println("  --help     Show this help message")
-}
-
-func handleRun(orch *core.Orchestrator, args []string) {
-	fmt.Println("Running command...")
-	// Implement your logic here
-}
-`,
-		t.generateRuntimeImports(),
-		t.config.Version,
-		t.config.Name,
-		t.config.Version,
-		t.generateRuntimeConfigs(),
-		t.generateRuntimeRegistrations(),
-		t.config.Name,
-		t.config.Description,
-		t.config.Name,
-	)
-}
-
-func (t *ProjectTemplate) generateSystemMain() string {
-	return fmt.Sprintf(`package main
 
-import (
-	"context"
-	"log"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
-	
-	"github.com/griffincancode/polyglot.js/core"
-%s
-)
-
-func main() {
-	// Create configuration
-	config := core.DefaultConfig()
-	config.App.Name = "%s"
-	config.App.Version = "%s"
-	
-%s
-	
-	// Create orchestrator
-	orch, err := core.NewOrchestrator(config)
-	if err != nil {
-		log.Fatalf("Failed to create orchestrator: %%v", err)
+	if t.autoUpdate() {
+		return t.render("updater.go.tmpl", "src", "backend", "updater.go")
 	}
-	
-%s
-	
-	// Initialize
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	
-	if err := orch.Initialize(ctx); err != nil {
-		log.Fatalf("Failed to initialize: %%v", err)
-	}
-	defer orch.Shutdown(context.Background())
-	
-	log.Println("Starting %s system service...")
-	
-	// Setup signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	
-	// Main service loop
-	ticker := time.NewTicker(time.Second * 10)
-	defer ticker.Stop()
-	
-	for {
-		select {
-		case <-ticker.C:
-			// Periodic task execution
-			handleTick(orch)
-		case <-sigChan:
-			log.Println("Received shutdown signal, cleaning up...")
-			return
-		}
-	}
-}
-
-func handleTick(orch *core.Orchestrator) {
-	// Implement your periodic task here
-	log.Println("Tick...")
-}
-`,
-		t.generateRuntimeImports(),
-		t.config.Name,
-		t.config.Version,
-		t.generateRuntimeConfigs(),
-		t.generateRuntimeRegistrations(),
-		t.config.Name,
-	)
+	return nil
 }
 
-func (t *ProjectTemplate) generateMinimalMain() string {
-	return fmt.Sprintf(`package main
-
-import (
-	"context"
-	"log"
-	"time"
-	
-	"github.com/griffincancode/polyglot.js/core"
-%s
-)
-
-func main() {
-	config := core.DefaultConfig()
-	config.App.Name = "%s"
-	
-%s
-	
-	orch, err := core.NewOrchestrator(config)
-	if err != nil {
-		log.Fatalf("Failed to create orchestrator: %%v", err)
-	}
-	
-%s
-	
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	
-	if err := orch.Initialize(ctx); err != nil {
-		log.Fatalf("Failed to initialize: %%v", err)
+// autoUpdate reports whether update checking is scaffolded, which needs a
+// template with a frontend to offer updates from
+func (t *ProjectTemplate) autoUpdate() bool {
+	switch t.config.Template {
+	case "webapp", "desktop":
+		return contains(t.config.Features, "autoupdate")
 	}
-	defer orch.Shutdown(context.Background())
-	
-	log.Println("Application started successfully")
-	
-	// Your code here
-}
-`,
-		t.generateRuntimeImports(),
-		t.config.Name,
-		t.generateRuntimeConfigs(),
-		t.generateRuntimeRegistrations(),
-	)
+	return false
 }
 
 func (t *ProjectTemplate) generateRuntimeImports() string {
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// goldenFiles lists the generated files produced from templates
+var goldenFiles = []string{
+	"polyglot.config.json",
+	filepath.Join("src", "backend", "main.go"),
+	"README.md",
+}
+
+// frontendFiles are identical across templates and checked once
+var frontendFiles = []string{
+	filepath.Join("src", "frontend", "index.html"),
+	filepath.Join("src", "frontend", "styles", "main.css"),
+	filepath.Join("src", "frontend", "scripts", "main.js"),
+}
+
+func goldenConfig(template string) *ProjectConfig {
+	return &ProjectConfig{
+		Name:            "goldenapp",
+		Description:     "A golden test application",
+		Author:          "Polyglot Tests",
+		Version:         "1.2.3",
+		License:         "MIT",
+		Template:        template,
+		Languages:       []string{"python", "javascript", "rust"},
+		Features:        []string{"webview", "hmr"},
+		PythonVersion:   "3.12",
+		PackageManager:  "pnpm",
+		WindowWidth:     1024,
+		WindowHeight:    768,
+		WindowResizable: true,
+		DevTools:        false,
+	}
+}
+
+// generateIn runs the generator inside dir and returns the project path
+func generateIn(t *testing.T, dir string, config *ProjectConfig) string {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	if err := NewTemplate(config).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	return filepath.Join(dir, config.Name)
+}
+
+func TestGenerateMatchesGolden(t *testing.T) {
+	for _, template := range []string{"webapp", "cli", "system", "minimal"} {
+		t.Run(template, func(t *testing.T) {
+			project := generateIn(t, t.TempDir(), goldenConfig(template))
+			goldenDir := filepath.Join("testdata", "golden", template)
+
+			files := goldenFiles
+			if template == "webapp" {
+				files = append(files, frontendFiles...)
+			}
+
+			for _, file := range files {
+				got, err := os.ReadFile(filepath.Join(project, file))
+				if err != nil {
+					t.Fatalf("Missing generated file %s: %v", file, err)
+				}
+
+				goldenPath := filepath.Join(goldenDir, file+".golden")
+				if *updateGolden {
+					os.MkdirAll(filepath.Dir(goldenPath), 0755)
+					if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+
+				want, err := os.ReadFile(goldenPath)
+				if err != nil {
+					t.Fatalf("Missing golden file %s: %v", goldenPath, err)
+				}
+
+				if string(got) != string(want) {
+					t.Errorf("%s does not match golden output", file)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateWithTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "custom")
+	if err := os.MkdirAll(custom, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	override := "# {{.Name}} v{{.Version}}\n"
+	if err := os.WriteFile(filepath.Join(custom, "README.md.tmpl"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := goldenConfig("webapp")
+	config.TemplateDir = custom
+	project := generateIn(t, dir, config)
+
+	readme, err := os.ReadFile(filepath.Join(project, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(readme) != "# goldenapp v1.2.3\n" {
+		t.Errorf("Custom template not used, got %q", readme)
+	}
+
+	// Files without an override fall back to the built-in templates
+	want, err := os.ReadFile(filepath.Join("testdata", "golden", "webapp", "polyglot.config.json.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(project, "polyglot.config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Error("Built-in template not used for non-overridden file")
+	}
+}
+
+func TestExtractFlag(t *testing.T) {
+	value, rest := extractFlag([]string{"myapp", "--template-dir", "tpl"}, "--template-dir")
+	if value != "tpl" || len(rest) != 1 || rest[0] != "myapp" {
+		t.Errorf("Unexpected result: %q %v", value, rest)
+	}
+
+	value, rest = extractFlag([]string{"--template-dir=tpl", "myapp"}, "--template-dir")
+	if value != "tpl" || len(rest) != 1 || rest[0] != "myapp" {
+		t.Errorf("Unexpected result: %q %v", value, rest)
+	}
+}
+
+func TestGenerateAutoUpdate(t *testing.T) {
+	config := goldenConfig("desktop")
+	config.Features = append(config.Features, "autoupdate")
+	config.UpdateFeedURL = "https://updates.example.com/goldenapp.json"
+	config.UpdateChannel = "beta"
+	project := generateIn(t, t.TempDir(), config)
+
+	fset := token.NewFileSet()
+	updater, err := parser.ParseFile(fset, "updater.go", readFile(t, project, "src", "backend", "updater.go"), 0)
+	if err != nil {
+		t.Fatalf("Generated updater.go does not parse: %v", err)
+	}
+
+	// The feed settings are wired into constants used by main
+	consts := map[string]string{}
+	registered := map[string]bool{}
+	ast.Inspect(updater, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if len(n.Values) == 0 {
+				break
+			}
+			if lit, ok := n.Values[0].(*ast.BasicLit); ok {
+				consts[n.Names[0].Name], _ = strconv.Unquote(lit.Value)
+			}
+		case *ast.CallExpr:
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Register" {
+				if lit, ok := n.Args[0].(*ast.BasicLit); ok {
+					name, _ := strconv.Unquote(lit.Value)
+					registered[name] = true
+				}
+			}
+		}
+		return true
+	})
+	if consts["updateFeedURL"] != config.UpdateFeedURL || consts["updateChannel"] != "beta" {
+		t.Errorf("Unexpected feed settings: %v", consts)
+	}
+	if !registered["checkForUpdate"] || !registered["applyUpdate"] {
+		t.Errorf("Expected update bridge functions, got %v", registered)
+	}
+
+	main := readFile(t, project, "src", "backend", "main.go")
+	if _, err := parser.ParseFile(fset, "main.go", main, 0); err != nil {
+		t.Fatalf("Generated main.go does not parse: %v", err)
+	}
+	if !strings.Contains(main, "newAppUpdater(updateFeedURL, updateChannel, config.App.Version)") ||
+		!strings.Contains(main, "updater.register(bridge)") {
+		t.Error("main.go does not wire up the updater")
+	}
+
+	var settings struct {
+		Updates struct {
+			FeedURL string `json:"feedURL"`
+			Channel string `json:"channel"`
+		} `json:"updates"`
+	}
+	if err := json.Unmarshal([]byte(readFile(t, project, "polyglot.config.json")), &settings); err != nil {
+		t.Fatalf("Generated config is not valid JSON: %v", err)
+	}
+	if settings.Updates.FeedURL != config.UpdateFeedURL || settings.Updates.Channel != "beta" {
+		t.Errorf("Unexpected updates config: %+v", settings.Updates)
+	}
+
+	if !strings.Contains(readFile(t, project, "src", "frontend", "scripts", "main.js"), "call('checkForUpdate')") {
+		t.Error("Frontend does not check for updates")
+	}
+}
+
+func TestGenerateAutoUpdateRequiresFrontend(t *testing.T) {
+	config := goldenConfig("cli")
+	config.Features = append(config.Features, "autoupdate")
+	project := generateIn(t, t.TempDir(), config)
+
+	if _, err := os.Stat(filepath.Join(project, "src", "backend", "updater.go")); !os.IsNotExist(err) {
+		t.Error("Expected no updater for a template without a frontend")
+	}
+}
+
+// readFile returns a generated file's contents
+func readFile(t *testing.T, project string, path ...string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(append([]string{project}, path...)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTemplateDiff(t *testing.T) {
+	config := goldenConfig("webapp")
+	project := generateIn(t, t.TempDir(), config)
+
+	generated := NewMemoryFS()
+	if err := NewTemplate(config).GenerateTo(generated); err != nil {
+		t.Fatalf("GenerateTo failed: %v", err)
+	}
+
+	// A freshly generated project matches the template
+	diff, err := NewTemplate(config).Diff(project)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff.HasChanges() || len(diff.Unchanged) != len(generated.Files) {
+		t.Fatalf("Expected all %d files unchanged, got %+v", len(generated.Files), diff)
+	}
+
+	// Edit, delete, and add files as a project would over time
+	if err := os.WriteFile(filepath.Join(project, "README.md"), []byte("# Customized\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(project, "Makefile")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "src", "backend", "handlers.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err = NewTemplate(config).Diff(project)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if strings.Join(diff.Added, ",") != "Makefile" {
+		t.Errorf("Expected Makefile added, got %v", diff.Added)
+	}
+	if strings.Join(diff.Changed, ",") != "README.md" {
+		t.Errorf("Expected README.md changed, got %v", diff.Changed)
+	}
+	if len(diff.Unchanged) != len(generated.Files)-2 {
+		t.Errorf("Expected %d unchanged files, got %v", len(generated.Files)-2, diff.Unchanged)
+	}
+	for _, name := range diff.Unchanged {
+		if name == "src/backend/handlers.go" {
+			t.Error("Expected files outside the template to be ignored")
+		}
+	}
+}
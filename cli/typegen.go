@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// typesHeader starts every generated definitions file
+const typesHeader = "// Code generated by polyglot types. DO NOT EDIT.\n"
+
+// typesFooter types window.polyglot.call from the PolyglotFunctions
+// interface; other members of window.polyglot stay untyped
+const typesFooter = `export interface PolyglotBridge {
+  call<K extends keyof PolyglotFunctions>(name: K, ...args: Parameters<PolyglotFunctions[K]>): Promise<ReturnType<PolyglotFunctions[K]>>;
+  [member: string]: any;
+}
+
+declare global {
+  interface Window {
+    polyglot: PolyglotBridge;
+  }
+}
+`
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// loadManifest reads a bridge manifest written as JSON from
+// SimpleBridge.Manifest
+func loadManifest(path string) ([]core.FunctionManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifests []core.FunctionManifest
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return manifests, nil
+}
+
+// generateTypeDefinitions renders TypeScript definitions for calling the
+// functions in manifests through window.polyglot.call
+func generateTypeDefinitions(manifests []core.FunctionManifest) string {
+	var b strings.Builder
+	b.WriteString(typesHeader)
+	b.WriteString("\nexport interface PolyglotFunctions {\n")
+	for _, m := range manifests {
+		params := make([]string, len(m.Args))
+		for i, arg := range m.Args {
+			if m.Variadic && i == len(m.Args)-1 {
+				params[i] = fmt.Sprintf("...%s: Array<%s>", tsParam(m, i, "rest"), tsType(arg, true))
+			} else {
+				params[i] = fmt.Sprintf("%s: %s", tsParam(m, i, fmt.Sprintf("arg%d", i)), tsType(arg, true))
+			}
+		}
+		if m.Description != "" {
+			fmt.Fprintf(&b, "  /** %s */\n", strings.ReplaceAll(m.Description, "*/", "*\\/"))
+		}
+		fmt.Fprintf(&b, "  %s(%s): %s;\n", tsName(m.Name), strings.Join(params, ", "), tsType(m.Returns, false))
+	}
+	b.WriteString("}\n\n")
+	b.WriteString(typesFooter)
+	return b.String()
+}
+
+// tsParam names argument i of m as documented, or fallback when it is
+// undocumented or not an identifier
+func tsParam(m core.FunctionManifest, i int, fallback string) string {
+	if i < len(m.Params) && identifierPattern.MatchString(m.Params[i]) {
+		return m.Params[i]
+	}
+	return fallback
+}
+
+// generateAPISpec renders the OpenAPI spec of the functions in manifests
+func generateAPISpec(manifests []core.FunctionManifest, info core.APIInfo) ([]byte, error) {
+	spec, err := json.MarshalIndent(core.NewAPISpec(info, manifests), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(spec, '\n'), nil
+}
+
+// tsType renders t as a TypeScript type. Binary values are sent as
+// ArrayBuffers or typed arrays, and arrive as a Uint8Array in binary
+// frames or a base64 string otherwise.
+func tsType(t core.TypeManifest, arg bool) string {
+	var ts string
+	switch t.Kind {
+	case core.KindVoid:
+		ts = "void"
+	case core.KindString:
+		ts = "string"
+		if len(t.Enum) > 0 {
+			values := make([]string, len(t.Enum))
+			for i, v := range t.Enum {
+				values[i] = strconv.Quote(v)
+			}
+			ts = strings.Join(values, " | ")
+		}
+	case core.KindNumber:
+		ts = "number"
+	case core.KindBoolean:
+		ts = "boolean"
+	case core.KindBytes:
+		if arg {
+			ts = "ArrayBuffer | ArrayBufferView"
+		} else {
+			ts = "Uint8Array | string"
+		}
+	case core.KindArray:
+		ts = fmt.Sprintf("Array<%s>", tsElem(t.Elem, arg))
+	case core.KindMap:
+		ts = fmt.Sprintf("Record<string, %s>", tsElem(t.Elem, arg))
+	case core.KindObject:
+		fields := make([]string, len(t.Fields))
+		for i, f := range t.Fields {
+			optional := ""
+			if f.Optional {
+				optional = "?"
+			}
+			fields[i] = fmt.Sprintf("%s%s: %s", tsName(f.Name), optional, tsType(f.Type, arg))
+		}
+		if len(fields) == 0 {
+			ts = "{}"
+		} else {
+			ts = "{ " + strings.Join(fields, "; ") + " }"
+		}
+	default:
+		ts = "any"
+	}
+
+	if t.Nullable && ts != "any" {
+		ts += " | null"
+	}
+	return ts
+}
+
+func tsElem(elem *core.TypeManifest, arg bool) string {
+	if elem == nil {
+		return "any"
+	}
+	return tsType(*elem, arg)
+}
+
+// tsName quotes names that are not valid identifiers
+func tsName(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+type typegenTodo struct {
+	ID    int      `json:"id"`
+	Title string   `json:"title"`
+	Done  bool     `json:"done,omitempty"`
+	Tags  []string `json:"tags"`
+}
+
+type typegenPriority string
+
+func (typegenPriority) EnumValues() []string { return []string{"low", "high"} }
+
+func TestGenerateTypeDefinitions(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.RegisterTyped("addTodo", func(ctx context.Context, title string, tags []string) (*typegenTodo, error) {
+		return &typegenTodo{Title: title, Tags: tags}, nil
+	})
+	bridge.RegisterTyped("todos.count", func() int { return 0 })
+	bridge.RegisterTyped("upload", func(name string, data []byte) ([]byte, error) { return data, nil })
+	bridge.RegisterTyped("sum", func(values ...float64) float64 { return 0 })
+	bridge.RegisterTyped("settings", func() map[string]bool { return nil })
+	bridge.RegisterTyped("clear", func() {})
+	bridge.RegisterTyped("prioritize", func(id int, priority typegenPriority) {})
+	bridge.Register("legacy", func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil })
+
+	// Round trip through the JSON file the command reads
+	data, err := json.Marshal(bridge.Manifest())
+	if err != nil {
+		t.Fatalf("marshal manifest: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest: %v", err)
+	}
+
+	dts := generateTypeDefinitions(manifests)
+	for _, want := range []string{
+		"// Code generated by polyglot types. DO NOT EDIT.",
+		"  addTodo(arg0: string, arg1: Array<string>): { id: number; title: string; done?: boolean; tags: Array<string> } | null;",
+		"  clear(): void;",
+		"  legacy(...rest: Array<any>): any;",
+		`  prioritize(arg0: number, arg1: "low" | "high"): void;`,
+		"  settings(): Record<string, boolean>;",
+		"  sum(...rest: Array<number>): number;",
+		`  "todos.count"(): number;`,
+		"  upload(arg0: string, arg1: ArrayBuffer | ArrayBufferView): Uint8Array | string;",
+		"call<K extends keyof PolyglotFunctions>(name: K, ...args: Parameters<PolyglotFunctions[K]>): Promise<ReturnType<PolyglotFunctions[K]>>;",
+		"    polyglot: PolyglotBridge;",
+	} {
+		if !strings.Contains(dts, want) {
+			t.Errorf("definitions missing %q:\n%s", want, dts)
+		}
+	}
+}
+
+func TestLoadManifestInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+	if _, err := loadManifest(path); err == nil {
+		t.Error("expected error for invalid manifest")
+	}
+}
+
+func TestGenerateAPISpec(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.RegisterTyped("addTodo", func(title string, tags []string) typegenTodo { return typegenTodo{} })
+	bridge.Document("addTodo", "Adds a todo", "title", "tags")
+
+	spec, err := generateAPISpec(bridge.Manifest(), core.APIInfo{Title: "Todos", Version: "2.0.0"})
+	if err != nil {
+		t.Fatalf("generateAPISpec: %v", err)
+	}
+	for _, want := range []string{`"openapi": "3.1.0"`, `"title": "Todos"`, `"/addTodo"`, `"title": "tags"`, `"description": "Adds a todo"`} {
+		if !strings.Contains(string(spec), want) {
+			t.Errorf("spec missing %s:\n%s", want, spec)
+		}
+	}
+
+	dts := generateTypeDefinitions(bridge.Manifest())
+	if !strings.Contains(dts, "  /** Adds a todo */\n  addTodo(title: string, tags: Array<string>):") {
+		t.Errorf("expected documented names in definitions:\n%s", dts)
+	}
+}
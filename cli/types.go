@@ -24,6 +24,13 @@ type ProjectConfig struct {
 	WindowResizable bool
 	DevTools        bool
 
+	// Auto-update feed settings
+	UpdateFeedURL string
+	UpdateChannel string
+
 	// Git
 	GitInit bool
+
+	// TemplateDir overrides built-in templates with files of the same name
+	TemplateDir string
 }
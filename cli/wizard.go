@@ -81,6 +81,7 @@ func (w *ProjectWizard) Run() (*ProjectConfig, error) {
 	fmt.Println("  marketplace  - Plugin marketplace")
 	fmt.Println("  security     - Enhanced security sandbox")
 	fmt.Println("  signing      - Code signing")
+	fmt.Println("  autoupdate   - Check for and apply updates")
 	featuresInput := w.prompt("Features", "webview,hmr")
 	config.Features = w.parseFeatures(featuresInput)
 
@@ -116,6 +117,14 @@ func (w *ProjectWizard) Run() (*ProjectConfig, error) {
 		config.DevTools = w.promptBool("Enable DevTools?", true)
 	}
 
+	// Auto-update settings
+	if contains(config.Features, "autoupdate") {
+		fmt.Println()
+		fmt.Println("Auto-update configuration:")
+		config.UpdateFeedURL = w.prompt("Update feed URL", "https://example.com/"+config.Name+"/updates.json")
+		config.UpdateChannel = w.prompt("Update channel", "stable")
+	}
+
 	return config, nil
 }
 
@@ -228,6 +237,7 @@ func (w *ProjectWizard) parseFeatures(input string) []string {
 	validFeatures := map[string]bool{
 		"webview": true, "hmr": true, "cloud": true,
 		"marketplace": true, "security": true, "signing": true,
+		"autoupdate": true,
 	}
 
 	parts := strings.Split(input, ",")
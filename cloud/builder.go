@@ -30,17 +30,19 @@ func (b *MemoryBuilder) Build(ctx context.Context, req *BuildRequest) (*BuildRes
 	}
 
 	buildID := fmt.Sprintf("build-%d", time.Now().UnixNano())
+	binary := []byte(fmt.Sprintf("binary-%s-%s-%s", req.ProjectID, req.Platform.OS, req.Platform.Arch))
 
 	result := &BuildResult{
 		ID:          buildID,
 		RequestID:   req.ID,
 		Platform:    req.Platform,
-		Binary:      []byte(fmt.Sprintf("binary-%s-%s-%s", req.ProjectID, req.Platform.OS, req.Platform.Arch)),
+		Binary:      binary,
 		Artifacts:   make(map[string][]byte),
 		Logs:        fmt.Sprintf("Building for %s/%s\nBuild completed successfully", req.Platform.OS, req.Platform.Arch),
 		Status:      "completed",
 		Duration:    time.Second * 30,
 		BinarySize:  int64(len(req.Source) * 2),
+		Provenance:  NewProvenance(req, binary, nil),
 		CompletedAt: time.Now(),
 	}
 
@@ -4,6 +4,14 @@ import (
 	"context"
 	"fmt"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// Secret store keys read by AuthenticateFromStore
+const (
+	SecretAPIKey    = "cloud.api_key"
+	SecretSecretKey = "cloud.secret_key"
 )
 
 // DefaultClient implements the cloud services client
@@ -13,6 +21,9 @@ type DefaultClient struct {
 	auth    Auth
 	creds   *Credentials
 	mu      sync.RWMutex
+
+	maxBuilds int
+	pricing   BuildPricing
 }
 
 // NewClient creates a new cloud client
@@ -53,14 +64,48 @@ func (c *DefaultClient) Authenticate(ctx context.Context, apiKey, secretKey stri
 	return nil
 }
 
+// AuthenticateFromStore authenticates with the API and secret keys held in
+// store under SecretAPIKey and SecretSecretKey, keeping them out of config
+func (c *DefaultClient) AuthenticateFromStore(ctx context.Context, store core.SecretStore) error {
+	apiKey, err := store.Get(SecretAPIKey)
+	if err != nil {
+		return fmt.Errorf("failed to read API key: %w", err)
+	}
+	secretKey, err := store.Get(SecretSecretKey)
+	if err != nil {
+		return fmt.Errorf("failed to read secret key: %w", err)
+	}
+	return c.Authenticate(ctx, string(apiKey), string(secretKey))
+}
+
+// SetMaxConcurrentBuilds limits how many builds CrossCompile runs at once;
+// further builds are queued. Zero or less removes the limit.
+func (c *DefaultClient) SetMaxConcurrentBuilds(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxBuilds = n
+}
+
 // CrossCompile performs cross-platform compilation
 func (c *DefaultClient) CrossCompile(ctx context.Context, source []byte, platforms []Platform) ([]*BuildResult, error) {
+	return c.CrossCompileWithProgress(ctx, source, platforms, nil)
+}
+
+// CrossCompileWithProgress performs cross-platform compilation, reporting
+// each build's state changes on progress. Progress must be drained; it is
+// closed when all builds finish.
+func (c *DefaultClient) CrossCompileWithProgress(ctx context.Context, source []byte, platforms []Platform, progress chan<- *BuildProgress) ([]*BuildResult, error) {
+	if progress != nil {
+		defer close(progress)
+	}
+
 	c.mu.RLock()
 	if c.creds == nil {
 		c.mu.RUnlock()
 		return nil, fmt.Errorf("not authenticated")
 	}
 	creds := c.creds
+	maxBuilds := c.maxBuilds
 	c.mu.RUnlock()
 
 	// Validate credentials
@@ -68,17 +113,39 @@ func (c *DefaultClient) CrossCompile(ctx context.Context, source []byte, platfor
 		return nil, fmt.Errorf("invalid credentials: %w", err)
 	}
 
+	if maxBuilds <= 0 || maxBuilds > len(platforms) {
+		maxBuilds = len(platforms)
+	}
+	slots := make(chan struct{}, maxBuilds)
+	tracker := &buildTracker{total: len(platforms), progress: progress}
+
 	results := make([]*BuildResult, 0, len(platforms))
 	errChan := make(chan error, len(platforms))
 	resultChan := make(chan *BuildResult, len(platforms))
 
-	// Build for each platform in parallel
+	for _, platform := range platforms {
+		tracker.report(platform, BuildQueued, nil)
+	}
+
+	// Build for each platform in parallel, up to maxBuilds at a time
 	var wg sync.WaitGroup
 	for _, platform := range platforms {
 		wg.Add(1)
 		go func(p Platform) {
 			defer wg.Done()
 
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				err := fmt.Errorf("build for %s/%s not started: %w", p.OS, p.Arch, ctx.Err())
+				tracker.report(p, BuildFailed, err)
+				errChan <- err
+				return
+			}
+
+			tracker.report(p, BuildRunning, nil)
+
 			req := &BuildRequest{
 				ProjectID: creds.ProjectID,
 				Platform:  p,
@@ -87,10 +154,13 @@ func (c *DefaultClient) CrossCompile(ctx context.Context, source []byte, platfor
 
 			result, err := c.builder.Build(ctx, req)
 			if err != nil {
-				errChan <- fmt.Errorf("build for %s/%s failed: %w", p.OS, p.Arch, err)
+				err = fmt.Errorf("build for %s/%s failed: %w", p.OS, p.Arch, err)
+				tracker.report(p, BuildFailed, err)
+				errChan <- err
 				return
 			}
 
+			tracker.report(p, BuildCompleted, nil)
 			resultChan <- result
 		}(platform)
 	}
@@ -118,3 +188,34 @@ func (c *DefaultClient) CrossCompile(ctx context.Context, source []byte, platfor
 
 	return results, nil
 }
+
+// buildTracker counts finished builds and publishes progress
+type buildTracker struct {
+	mu       sync.Mutex
+	total    int
+	finished int
+	progress chan<- *BuildProgress
+}
+
+func (t *buildTracker) report(platform Platform, status BuildStatus, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if status == BuildCompleted || status == BuildFailed {
+		t.finished++
+	}
+	if t.progress == nil {
+		return
+	}
+
+	update := &BuildProgress{
+		Platform: platform,
+		Status:   status,
+		Finished: t.finished,
+		Total:    t.total,
+	}
+	if err != nil {
+		update.Error = err.Error()
+	}
+	t.progress <- update
+}
@@ -0,0 +1,180 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Heuristics used when no finished build of a platform is on record
+const (
+	estimateBaseDuration  = 20 * time.Second
+	estimatePerMegabyte   = 10 * time.Second
+	estimateCGOMultiplier = 1.5
+	estimateHistoryLimit  = 100
+)
+
+// Estimate bases
+const (
+	EstimateFromHistory   = "history"
+	EstimateFromHeuristic = "heuristic"
+)
+
+// BuildPricing is the price of remote build time
+type BuildPricing struct {
+	// PerMinute is the price of one minute of build time
+	PerMinute float64 `json:"per_minute"`
+
+	// Currency is an ISO 4217 code such as USD
+	Currency string `json:"currency"`
+}
+
+// PlatformEstimate is the expected cost of building for one platform
+type PlatformEstimate struct {
+	Platform Platform      `json:"platform"`
+	Duration time.Duration `json:"duration"`
+	Cost     float64       `json:"cost,omitempty"`
+
+	// Basis is EstimateFromHistory when the duration averages past builds
+	// of the platform, and EstimateFromHeuristic when it is derived from
+	// the source size
+	Basis string `json:"basis"`
+}
+
+// BuildEstimate is the expected cost of a cross-compilation
+type BuildEstimate struct {
+	Platforms []PlatformEstimate `json:"platforms"`
+
+	// Duration is the expected wall-clock time, with builds running up to
+	// the client's concurrency limit at once
+	Duration time.Duration `json:"duration"`
+
+	// BuildTime is the summed duration of every build, which is what
+	// pricing is charged on
+	BuildTime time.Duration `json:"build_time"`
+
+	// Cost and Currency are set when the client has pricing
+	Cost     float64 `json:"cost,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// SetPricing sets the price EstimateBuild uses for cost estimates. The
+// zero BuildPricing leaves costs out.
+func (c *DefaultClient) SetPricing(pricing BuildPricing) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pricing = pricing
+}
+
+// EstimateBuild estimates how long CrossCompile would take for source and
+// platforms, and what it would cost, without starting any builds.
+// Platforms with finished builds on record are estimated from their
+// average duration, and others from the source size.
+func (c *DefaultClient) EstimateBuild(ctx context.Context, source []byte, platforms []Platform) (BuildEstimate, error) {
+	if len(source) == 0 {
+		return BuildEstimate{}, fmt.Errorf("source is required")
+	}
+	if len(platforms) == 0 {
+		return BuildEstimate{}, fmt.Errorf("at least one platform is required")
+	}
+
+	c.mu.RLock()
+	pricing := c.pricing
+	maxBuilds := c.maxBuilds
+	projectID := ""
+	if c.creds != nil {
+		projectID = c.creds.ProjectID
+	}
+	c.mu.RUnlock()
+
+	history, err := c.builder.ListBuilds(ctx, projectID, estimateHistoryLimit)
+	if err != nil {
+		return BuildEstimate{}, fmt.Errorf("failed to list past builds: %w", err)
+	}
+	averages := averageDurations(history)
+
+	estimate := BuildEstimate{Platforms: make([]PlatformEstimate, 0, len(platforms))}
+	durations := make([]time.Duration, 0, len(platforms))
+	for _, p := range platforms {
+		pe := PlatformEstimate{Platform: p, Basis: EstimateFromHistory}
+		if avg, ok := averages[platformKey(p)]; ok {
+			pe.Duration = avg
+		} else {
+			pe.Duration = heuristicDuration(len(source), p)
+			pe.Basis = EstimateFromHeuristic
+		}
+		if pricing.PerMinute > 0 {
+			pe.Cost = pe.Duration.Minutes() * pricing.PerMinute
+		}
+
+		estimate.Platforms = append(estimate.Platforms, pe)
+		estimate.BuildTime += pe.Duration
+		durations = append(durations, pe.Duration)
+	}
+	if pricing.PerMinute > 0 {
+		estimate.Cost = estimate.BuildTime.Minutes() * pricing.PerMinute
+		estimate.Currency = pricing.Currency
+	}
+	estimate.Duration = scheduledDuration(durations, maxBuilds)
+	return estimate, nil
+}
+
+// heuristicDuration estimates a build from its source size
+func heuristicDuration(size int, p Platform) time.Duration {
+	d := estimateBaseDuration + time.Duration(float64(estimatePerMegabyte)*float64(size)/(1<<20))
+	if p.CGOEnabled {
+		d = time.Duration(float64(d) * estimateCGOMultiplier)
+	}
+	return d.Round(time.Second)
+}
+
+// averageDurations averages finished builds by platform
+func averageDurations(builds []*BuildResult) map[string]time.Duration {
+	totals := make(map[string]time.Duration)
+	counts := make(map[string]int)
+	for _, b := range builds {
+		if b.Status != "completed" || b.Duration <= 0 {
+			continue
+		}
+		key := platformKey(b.Platform)
+		totals[key] += b.Duration
+		counts[key]++
+	}
+
+	averages := make(map[string]time.Duration, len(totals))
+	for key, total := range totals {
+		averages[key] = total / time.Duration(counts[key])
+	}
+	return averages
+}
+
+// platformKey identifies builds of the same target
+func platformKey(p Platform) string {
+	return fmt.Sprintf("%s/%s/%s/%t", p.OS, p.Arch, p.Variant, p.CGOEnabled)
+}
+
+// scheduledDuration is the wall-clock time of builds started in order,
+// each as soon as one of maxBuilds slots frees up
+func scheduledDuration(durations []time.Duration, maxBuilds int) time.Duration {
+	if maxBuilds <= 0 || maxBuilds > len(durations) {
+		maxBuilds = len(durations)
+	}
+	slots := make([]time.Duration, maxBuilds)
+	for _, d := range durations {
+		next := 0
+		for i := range slots {
+			if slots[i] < slots[next] {
+				next = i
+			}
+		}
+		slots[next] += d
+	}
+
+	var longest time.Duration
+	for _, s := range slots {
+		if s > longest {
+			longest = s
+		}
+	}
+	return longest
+}
@@ -0,0 +1,156 @@
+package cloud
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Storage metadata keys holding build provenance
+const (
+	metaChecksum        = "checksum"
+	metaToolchainPrefix = "toolchain."
+	metaSBOM            = "sbom"
+)
+
+// Provenance describes how a build artifact was produced
+type Provenance struct {
+	// Checksum of the binary as "sha256:<hex>"
+	Checksum string `json:"checksum"`
+
+	// Toolchains maps tool names to the versions used
+	Toolchains map[string]string `json:"toolchains"`
+
+	// SBOM lists the runtimes and packages included, when requested
+	SBOM []SBOMEntry `json:"sbom,omitempty"`
+}
+
+// SBOMEntry is one component of a build
+type SBOMEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Type    string `json:"type"` // runtime or package
+}
+
+// Checksum returns the "sha256:<hex>" digest of data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// NewProvenance records the provenance of a binary built for req. The
+// Go toolchain is always included; toolchains adds or overrides versions.
+func NewProvenance(req *BuildRequest, binary []byte, toolchains map[string]string) *Provenance {
+	p := &Provenance{
+		Checksum:   Checksum(binary),
+		Toolchains: map[string]string{"go": runtime.Version()},
+	}
+	for name, version := range toolchains {
+		p.Toolchains[name] = version
+	}
+
+	if !req.SBOM {
+		return p
+	}
+
+	for _, lang := range req.Languages {
+		p.SBOM = append(p.SBOM, SBOMEntry{Name: lang, Version: p.Toolchains[lang], Type: "runtime"})
+	}
+	names := make([]string, 0, len(req.Dependencies))
+	for name := range req.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.SBOM = append(p.SBOM, SBOMEntry{Name: name, Version: req.Dependencies[name], Type: "package"})
+	}
+	return p
+}
+
+// Verify checks that binary matches the recorded checksum
+func (p *Provenance) Verify(binary []byte) error {
+	if got := Checksum(binary); got != p.Checksum {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", p.Checksum, got)
+	}
+	return nil
+}
+
+// Metadata flattens the provenance into storage metadata
+func (p *Provenance) Metadata() (map[string]string, error) {
+	metadata := map[string]string{metaChecksum: p.Checksum}
+	for name, version := range p.Toolchains {
+		metadata[metaToolchainPrefix+name] = version
+	}
+	if len(p.SBOM) > 0 {
+		sbom, err := json.Marshal(p.SBOM)
+		if err != nil {
+			return nil, err
+		}
+		metadata[metaSBOM] = string(sbom)
+	}
+	return metadata, nil
+}
+
+// provenanceFromMetadata rebuilds provenance stored with Metadata
+func provenanceFromMetadata(metadata map[string]string) (*Provenance, error) {
+	checksum, ok := metadata[metaChecksum]
+	if !ok {
+		return nil, fmt.Errorf("no provenance recorded")
+	}
+
+	p := &Provenance{Checksum: checksum, Toolchains: make(map[string]string)}
+	for key, value := range metadata {
+		if name := strings.TrimPrefix(key, metaToolchainPrefix); name != key {
+			p.Toolchains[name] = value
+		}
+	}
+	if sbom, ok := metadata[metaSBOM]; ok {
+		if err := json.Unmarshal([]byte(sbom), &p.SBOM); err != nil {
+			return nil, fmt.Errorf("invalid SBOM metadata: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// StoreArtifact stores a build's binary with its provenance as metadata
+func StoreArtifact(ctx context.Context, storage Storage, key string, result *BuildResult) error {
+	if result.Provenance == nil {
+		return fmt.Errorf("build %s has no provenance", result.ID)
+	}
+
+	metadata, err := result.Provenance.Metadata()
+	if err != nil {
+		return fmt.Errorf("failed to encode provenance: %w", err)
+	}
+	metadata["build_id"] = result.ID
+	metadata["platform"] = result.Platform.OS + "/" + result.Platform.Arch
+
+	return storage.Put(ctx, key, result.Binary, metadata)
+}
+
+// LoadArtifact retrieves a stored binary and its provenance, verifying the
+// binary against the recorded checksum
+func LoadArtifact(ctx context.Context, storage Storage, key string) ([]byte, *Provenance, error) {
+	obj, err := storage.GetMetadata(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	provenance, err := provenanceFromMetadata(obj.Metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("artifact %s: %w", key, err)
+	}
+
+	binary, err := storage.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := provenance.Verify(binary); err != nil {
+		return nil, nil, fmt.Errorf("artifact %s: %w", key, err)
+	}
+	return binary, provenance, nil
+}
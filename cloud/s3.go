@@ -0,0 +1,380 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentTypeKey in Put metadata sets the stored object's content type
+// instead of being kept as custom metadata
+const ContentTypeKey = "content-type"
+
+// defaultContentType is used when Put metadata has no content type
+const defaultContentType = "application/octet-stream"
+
+// s3MetaPrefix prefixes custom metadata headers
+const s3MetaPrefix = "x-amz-meta-"
+
+// S3Config configures an S3-compatible storage backend
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.us-east-1.amazonaws.com
+	// or http://localhost:9000 for MinIO
+	Endpoint string
+
+	// Region used for request signing (default us-east-1)
+	Region string
+
+	// Bucket holding the artifacts
+	Bucket string
+
+	// AccessKey and SecretKey sign requests
+	AccessKey string
+	SecretKey string
+
+	// Client performs requests (default http.DefaultClient)
+	Client *http.Client
+}
+
+// S3Storage stores artifacts in an S3-compatible bucket using path-style
+// requests signed with AWS Signature Version 4
+type S3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Storage creates a storage backend for an S3-compatible service
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("access key and secret key are required")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint: %q", config.Endpoint)
+	}
+
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3Storage{
+		config:   config,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// Put stores an artifact
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if data == nil {
+		return fmt.Errorf("data is required")
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", defaultContentType)
+	for name, value := range metadata {
+		if strings.EqualFold(name, ContentTypeKey) {
+			header.Set("Content-Type", value)
+			continue
+		}
+		header.Set(s3MetaPrefix+strings.ToLower(name), value)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, header, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get retrieves an artifact
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Delete removes an artifact
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3ListResult is the ListObjectsV2 response body
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List lists a page of artifacts matching a prefix. The cursor is the S3
+// continuation token. S3 listings carry no custom metadata, so requesting
+// it costs a HEAD request per object.
+func (s *S3Storage) List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if opts.Limit > 0 {
+		query.Set("max-keys", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("continuation-token", opts.Cursor)
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return ListResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var listing s3ListResult
+	if err := xml.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return ListResult{}, fmt.Errorf("invalid list response: %w", err)
+	}
+
+	result := ListResult{Objects: make([]*StorageObject, 0, len(listing.Contents))}
+	if listing.IsTruncated {
+		result.NextCursor = listing.NextContinuationToken
+	}
+
+	for _, item := range listing.Contents {
+		obj := &StorageObject{
+			Key:       item.Key,
+			Size:      item.Size,
+			Checksum:  strings.Trim(item.ETag, `"`),
+			CreatedAt: item.LastModified,
+		}
+		if opts.Metadata {
+			head, err := s.GetMetadata(ctx, item.Key)
+			if err != nil {
+				return ListResult{}, err
+			}
+			obj.ContentType = head.ContentType
+			obj.Metadata = head.Metadata
+		}
+		result.Objects = append(result.Objects, obj)
+	}
+	return result, nil
+}
+
+// GetMetadata retrieves artifact metadata
+func (s *S3Storage) GetMetadata(ctx context.Context, key string) (*StorageObject, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	obj := &StorageObject{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		Checksum:    strings.Trim(resp.Header.Get("ETag"), `"`),
+		Metadata:    make(map[string]string),
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.CreatedAt = modified
+	}
+	for name, values := range resp.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, s3MetaPrefix) && len(values) > 0 {
+			obj.Metadata[strings.TrimPrefix(name, s3MetaPrefix)] = values[0]
+		}
+	}
+	return obj, nil
+}
+
+// s3Error is an S3 error response body
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do sends a signed request for key (or the bucket when key is empty) and
+// returns the response when it succeeded
+func (s *S3Storage) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	target := *s.endpoint
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + s.config.Bucket
+	if key != "" {
+		target.Path += "/" + key
+	}
+	target.RawPath = s3EscapePath(target.Path)
+	target.RawQuery = s3Query(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage request failed: %w", err)
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		return nil, fmt.Errorf("object not found: %s", key)
+	}
+
+	var s3err s3Error
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if xml.Unmarshal(data, &s3err) == nil && s3err.Code != "" {
+		return nil, fmt.Errorf("storage %s %s failed: %s: %s", method, key, s3err.Code, s3err.Message)
+	}
+	return nil, fmt.Errorf("storage %s %s failed: %s", method, key, resp.Status)
+}
+
+// sign adds AWS Signature Version 4 headers to req
+func (s *S3Storage) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Sign the host, content type, and every x-amz-* header
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// s3EscapePath URI-encodes each path segment as S3 signing requires
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Query encodes query parameters sorted by key as S3 signing requires
+func s3Query(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything except unreserved characters
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -3,6 +3,7 @@ package cloud
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -35,11 +36,21 @@ func (s *MemoryStorage) Put(ctx context.Context, key string, data []byte, metada
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	contentType := defaultContentType
+	custom := make(map[string]string, len(metadata))
+	for name, value := range metadata {
+		if strings.EqualFold(name, ContentTypeKey) {
+			contentType = value
+			continue
+		}
+		custom[name] = value
+	}
+
 	obj := &StorageObject{
 		Key:         key,
 		Size:        int64(len(data)),
-		ContentType: "application/octet-stream",
-		Metadata:    metadata,
+		ContentType: contentType,
+		Metadata:    custom,
 		Checksum:    fmt.Sprintf("sha256-%d", len(data)),
 		CreatedAt:   time.Now(),
 	}
@@ -74,22 +85,36 @@ func (s *MemoryStorage) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
-// List lists artifacts matching a prefix
-func (s *MemoryStorage) List(ctx context.Context, prefix string, limit int) ([]*StorageObject, error) {
+// List lists a page of artifacts matching a prefix. The cursor is the last
+// key of the previous page.
+func (s *MemoryStorage) List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	results := make([]*StorageObject, 0)
-	for key, obj := range s.objects {
-		if strings.HasPrefix(key, prefix) {
-			results = append(results, obj)
-			if len(results) >= limit && limit > 0 {
-				break
-			}
+	keys := make([]string, 0)
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) && key > opts.Cursor {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var result ListResult
+	if opts.Limit > 0 && len(keys) > opts.Limit {
+		keys = keys[:opts.Limit]
+		result.NextCursor = keys[len(keys)-1]
+	}
+
+	result.Objects = make([]*StorageObject, 0, len(keys))
+	for _, key := range keys {
+		obj := *s.objects[key]
+		if !opts.Metadata {
+			obj.Metadata = nil
 		}
+		result.Objects = append(result.Objects, &obj)
 	}
 
-	return results, nil
+	return result, nil
 }
 
 // GetMetadata retrieves artifact metadata
@@ -26,6 +26,8 @@ type BuildRequest struct {
 	Languages    []string          `json:"languages"`
 	Tags         []string          `json:"tags"`
 	Optimization string            `json:"optimization"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	SBOM         bool              `json:"sbom"`
 	CreatedAt    time.Time         `json:"created_at"`
 }
 
@@ -41,9 +43,30 @@ type BuildResult struct {
 	Error       string            `json:"error,omitempty"`
 	Duration    time.Duration     `json:"duration"`
 	BinarySize  int64             `json:"binary_size"`
+	Provenance  *Provenance       `json:"provenance,omitempty"`
 	CompletedAt time.Time         `json:"completed_at"`
 }
 
+// BuildStatus is the state of a build during cross-compilation
+type BuildStatus string
+
+// Build states reported by CrossCompileWithProgress
+const (
+	BuildQueued    BuildStatus = "queued"
+	BuildRunning   BuildStatus = "running"
+	BuildCompleted BuildStatus = "completed"
+	BuildFailed    BuildStatus = "failed"
+)
+
+// BuildProgress reports a state change of one build in a cross-compilation
+type BuildProgress struct {
+	Platform Platform    `json:"platform"`
+	Status   BuildStatus `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Finished int         `json:"finished"`
+	Total    int         `json:"total"`
+}
+
 // Credentials represents authentication credentials
 type Credentials struct {
 	APIKey    string    `json:"api_key"`
@@ -63,6 +86,27 @@ type StorageObject struct {
 	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
 }
 
+// ListOptions controls a storage listing
+type ListOptions struct {
+	// Limit is the page size (zero lets the backend choose)
+	Limit int
+
+	// Cursor continues a listing from a previous ListResult.NextCursor
+	Cursor string
+
+	// Metadata includes each object's custom metadata, which some backends
+	// fetch with an extra request per object
+	Metadata bool
+}
+
+// ListResult is one page of a storage listing, ordered by key
+type ListResult struct {
+	Objects []*StorageObject `json:"objects"`
+
+	// NextCursor fetches the following page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 // Builder handles remote build orchestration
 type Builder interface {
 	// Build submits a build request
@@ -89,8 +133,8 @@ type Storage interface {
 	// Delete removes an artifact
 	Delete(ctx context.Context, key string) error
 
-	// List lists artifacts matching a prefix
-	List(ctx context.Context, prefix string, limit int) ([]*StorageObject, error)
+	// List lists a page of artifacts matching a prefix
+	List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error)
 
 	// GetMetadata retrieves artifact metadata
 	GetMetadata(ctx context.Context, key string) (*StorageObject, error)
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"path"
+)
+
+// FunctionAccess controls which bridge functions a caller, such as a
+// webview window, may invoke. Entries are function names or path.Match
+// patterns, so "tasks.*" covers every function of the tasks group.
+type FunctionAccess struct {
+	// Allow lists the callable functions. Empty allows every function.
+	Allow []string
+
+	// Deny lists functions that may not be called even when allowed
+	Deny []string
+}
+
+// Restricted reports whether the access limits any function
+func (a FunctionAccess) Restricted() bool {
+	return len(a.Allow) > 0 || len(a.Deny) > 0
+}
+
+// Allows reports whether name may be called
+func (a FunctionAccess) Allows(name string) bool {
+	if matchesAny(a.Deny, name) {
+		return false
+	}
+	return len(a.Allow) == 0 || matchesAny(a.Allow, name)
+}
+
+// matchesAny reports whether name equals or matches one of patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictBridge returns a view of bridge that only calls the functions
+// access allows; other calls fail with CodeForbidden before reaching a
+// handler. Functions lists only the allowed functions. When access is nil
+// or restricts nothing, bridge is returned as is.
+//
+// Allowing a group's "<group>.batch" function allows every operation of
+// the group through it.
+func RestrictBridge(bridge Bridge, access *FunctionAccess) Bridge {
+	if bridge == nil || access == nil || !access.Restricted() {
+		return bridge
+	}
+	return &restrictedBridge{Bridge: bridge, access: *access}
+}
+
+// restrictedBridge enforces a FunctionAccess in front of a bridge
+type restrictedBridge struct {
+	Bridge
+	access FunctionAccess
+}
+
+// Call invokes name if it is allowed
+func (b *restrictedBridge) Call(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	if !b.access.Allows(name) {
+		return nil, Errorf(CodeForbidden, "function %s is not allowed here", name).WithDetail("function", name)
+	}
+	return b.Bridge.Call(ctx, name, args...)
+}
+
+// Manifest describes the allowed functions of the underlying bridge, when
+// it can describe them
+func (b *restrictedBridge) Manifest() []FunctionManifest {
+	manifests := []FunctionManifest{}
+	for _, m := range ManifestOf(b.Bridge) {
+		if b.access.Allows(m.Name) {
+			manifests = append(manifests, m)
+		}
+	}
+	return manifests
+}
+
+// Functions lists the allowed functions of the underlying bridge, when
+// it can enumerate them
+func (b *restrictedBridge) Functions() []string {
+	lister, ok := b.Bridge.(interface{ Functions() []string })
+	if !ok {
+		return nil
+	}
+	names := []string{}
+	for _, name := range lister.Functions() {
+		if b.access.Allows(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
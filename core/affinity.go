@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// affinityKey carries a session affinity key through the execution context
+type affinityKey struct{}
+
+// WithAffinity returns a context whose executions are routed to the same
+// pooled worker for every call sharing the key. Runtimes whose state lives
+// per worker (such as Ruby globals) use it to keep a session coherent.
+func WithAffinity(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, affinityKey{}, key)
+}
+
+// Affinity returns the session affinity key of a context, if any
+func Affinity(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	key, ok := ctx.Value(affinityKey{}).(string)
+	return key, ok
+}
+
+// AffinitySlot maps an affinity key to a worker index in [0, size)
+func AffinitySlot(key string, size int) int {
+	if size <= 0 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(size))
+}
+
+// Session routes executions in one runtime through a single worker so
+// state set by one call is visible to the next
+type Session struct {
+	orch    *Orchestrator
+	runtime string
+	key     string
+}
+
+// Session returns an affinity session for a runtime. Sessions with the same
+// key share a worker.
+func (o *Orchestrator) Session(runtime string, key string) *Session {
+	return &Session{orch: o, runtime: runtime, key: key}
+}
+
+// Key returns the session affinity key
+func (s *Session) Key() string {
+	return s.key
+}
+
+// Execute runs code on the session's worker
+func (s *Session) Execute(ctx context.Context, code string, args ...interface{}) (interface{}, error) {
+	return s.orch.Execute(s.context(ctx), s.runtime, code, args...)
+}
+
+// ExecuteInfo runs code on the session's worker and reports execution info
+func (s *Session) ExecuteInfo(ctx context.Context, code string, args ...interface{}) (ExecResult, error) {
+	return s.orch.ExecuteInfo(s.context(ctx), s.runtime, code, args...)
+}
+
+// Call invokes a function on the session's worker
+func (s *Session) Call(ctx context.Context, fn string, args ...interface{}) (interface{}, error) {
+	return s.orch.Call(s.context(ctx), s.runtime, fn, args...)
+}
+
+func (s *Session) context(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return WithAffinity(ctx, s.key)
+}
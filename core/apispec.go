@@ -0,0 +1,221 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// APISpecPath is the well-known path apps serve APISpecHandler at
+const APISpecPath = "/.well-known/polyglot-api.json"
+
+// APISpecVersion is the OpenAPI version of the specs NewAPISpec builds
+const APISpecVersion = "3.1.0"
+
+// APIInfo identifies the app an APISpec describes
+type APIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// APISpec describes the functions of a bridge as an OpenAPI document, so
+// external tools can list them, document them and generate clients. Each
+// function is an operation at /<name> taking its arguments as a JSON array
+// in the request body, the way HTTP transports of the bridge receive them.
+type APISpec struct {
+	OpenAPI string             `json:"openapi"`
+	Info    APIInfo            `json:"info"`
+	Paths   map[string]APIPath `json:"paths"`
+}
+
+// APIPath holds the operation of one function
+type APIPath struct {
+	Post APIOperation `json:"post"`
+}
+
+// APIOperation describes calling one function
+type APIOperation struct {
+	OperationID string                 `json:"operationId"`
+	Description string                 `json:"description,omitempty"`
+	RequestBody APIBody                `json:"requestBody"`
+	Responses   map[string]APIResponse `json:"responses"`
+}
+
+// APIBody is the request body of an operation
+type APIBody struct {
+	Required bool                `json:"required"`
+	Content  map[string]APIMedia `json:"content"`
+}
+
+// APIResponse is one outcome of an operation
+type APIResponse struct {
+	Description string              `json:"description"`
+	Content     map[string]APIMedia `json:"content,omitempty"`
+}
+
+// APIMedia holds the schema of a JSON body
+type APIMedia struct {
+	Schema *JSONSchema `json:"schema"`
+}
+
+// JSONSchema is the part of JSON Schema an APISpec uses to describe bridge
+// values
+type JSONSchema struct {
+	// Type is a type name, or a list of them for nullable values. Values
+	// of any type leave it unset.
+	Type interface{} `json:"type,omitempty"`
+
+	Title           string   `json:"title,omitempty"`
+	Enum            []string `json:"enum,omitempty"`
+	ContentEncoding string   `json:"contentEncoding,omitempty"`
+
+	// PrefixItems are the schemas of leading array items, and Items of the
+	// rest
+	PrefixItems []*JSONSchema `json:"prefixItems,omitempty"`
+	Items       *JSONSchema   `json:"items,omitempty"`
+	MinItems    int           `json:"minItems,omitempty"`
+	MaxItems    *int          `json:"maxItems,omitempty"`
+
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+// NewAPISpec builds the API spec of the functions in manifests
+func NewAPISpec(info APIInfo, manifests []FunctionManifest) *APISpec {
+	spec := &APISpec{OpenAPI: APISpecVersion, Info: info, Paths: make(map[string]APIPath, len(manifests))}
+	for _, m := range manifests {
+		responses := map[string]APIResponse{
+			"200":     {Description: "The function's result"},
+			"default": {Description: "The function failed", Content: jsonContent(errorSchema())},
+		}
+		if m.Returns.Kind != KindVoid {
+			responses["200"] = APIResponse{Description: "The function's result", Content: jsonContent(SchemaOf(m.Returns))}
+		}
+		spec.Paths["/"+m.Name] = APIPath{Post: APIOperation{
+			OperationID: m.Name,
+			Description: m.Description,
+			RequestBody: APIBody{Required: true, Content: jsonContent(argsSchema(m))},
+			Responses:   responses,
+		}}
+	}
+	return spec
+}
+
+// ManifestOf describes the functions of bridge. Bridges that cannot
+// describe their functions but can list them report them as taking and
+// returning any values.
+func ManifestOf(bridge Bridge) []FunctionManifest {
+	if describer, ok := bridge.(interface{ Manifest() []FunctionManifest }); ok {
+		return describer.Manifest()
+	}
+	manifests := []FunctionManifest{}
+	if lister, ok := bridge.(interface{ Functions() []string }); ok {
+		names := lister.Functions()
+		sort.Strings(names)
+		for _, name := range names {
+			manifests = append(manifests, untypedManifest(name))
+		}
+	}
+	return manifests
+}
+
+// APISpecHandler serves the API spec of bridge as JSON, built on each
+// request so it lists functions registered since. Mount it at APISpecPath.
+func APISpecHandler(bridge Bridge, info APIInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(NewAPISpec(info, ManifestOf(bridge)))
+	})
+}
+
+// SchemaOf converts a TypeManifest to a JSON Schema
+func SchemaOf(t TypeManifest) *JSONSchema {
+	s := &JSONSchema{}
+	switch t.Kind {
+	case KindVoid:
+		s.Type = "null"
+	case KindString:
+		s.Type = "string"
+		s.Enum = t.Enum
+	case KindNumber:
+		s.Type = "number"
+	case KindBoolean:
+		s.Type = "boolean"
+	case KindBytes:
+		s.Type = "string"
+		s.ContentEncoding = "base64"
+	case KindArray:
+		s.Type = "array"
+		if t.Elem != nil {
+			s.Items = SchemaOf(*t.Elem)
+		}
+	case KindMap:
+		s.Type = "object"
+		if t.Elem != nil {
+			s.AdditionalProperties = SchemaOf(*t.Elem)
+		}
+	case KindObject:
+		s.Type = "object"
+		s.Properties = make(map[string]*JSONSchema, len(t.Fields))
+		for _, f := range t.Fields {
+			s.Properties[f.Name] = SchemaOf(f.Type)
+			if !f.Optional {
+				s.Required = append(s.Required, f.Name)
+			}
+		}
+	}
+	if name, ok := s.Type.(string); ok && t.Nullable {
+		s.Type = []string{name, "null"}
+	}
+	return s
+}
+
+// argsSchema describes the arguments array of a function, titling each
+// item with its documented parameter name
+func argsSchema(m FunctionManifest) *JSONSchema {
+	s := &JSONSchema{Type: "array", PrefixItems: []*JSONSchema{}}
+	fixed := len(m.Args)
+	if m.Variadic && fixed > 0 {
+		fixed--
+		s.Items = SchemaOf(m.Args[fixed])
+		if fixed < len(m.Params) {
+			s.Items.Title = m.Params[fixed]
+		}
+	}
+	for i := 0; i < fixed; i++ {
+		item := SchemaOf(m.Args[i])
+		if i < len(m.Params) {
+			item.Title = m.Params[i]
+		}
+		s.PrefixItems = append(s.PrefixItems, item)
+	}
+	s.MinItems = fixed
+	if !m.Variadic {
+		s.MaxItems = &fixed
+	}
+	return s
+}
+
+// errorSchema describes the ErrorInfo of a failed call
+func errorSchema() *JSONSchema {
+	return &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"code":    {Type: "string"},
+			"message": {Type: "string"},
+			"details": {Type: "object"},
+		},
+		Required: []string{"code", "message"},
+	}
+}
+
+func jsonContent(schema *JSONSchema) map[string]APIMedia {
+	return map[string]APIMedia{"application/json": {Schema: schema}}
+}
@@ -0,0 +1,213 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// arenaMaxRetained caps the buffer sizes kept for reuse, so one large call
+// does not pin its memory in the pool
+const arenaMaxRetained = 64 * 1024
+
+// ArgsDecoder is implemented by codecs that can decode an argument list
+// into a reused slice
+type ArgsDecoder interface {
+	// DecodeArgs decodes an array into dst[:0], growing it as needed. A
+	// null payload decodes to no arguments.
+	DecodeArgs(data []byte, dst []interface{}) ([]interface{}, error)
+}
+
+// AppendMarshaler is implemented by codecs that can encode into a reused
+// buffer
+type AppendMarshaler interface {
+	// MarshalAppend appends the encoding of v to dst
+	MarshalAppend(dst []byte, v interface{}) ([]byte, error)
+}
+
+// CallArena pools the argument slices and result buffers of bridge calls,
+// so high call rates do not allocate them for every call. Values decoded
+// into the arguments are fresh; only the containers are reused.
+type CallArena struct {
+	pool sync.Pool
+}
+
+// NewCallArena creates an empty arena
+func NewCallArena() *CallArena {
+	a := &CallArena{}
+	a.pool.New = func() interface{} { return &CallBuffers{arena: a} }
+	return a
+}
+
+// CallBuffers holds the buffers of one call. The slices returned by
+// DecodeArgs and Encode are reused after Release, so callers and bridge
+// functions must not keep them past it.
+type CallBuffers struct {
+	arena *CallArena
+	args  []interface{}
+	out   []byte
+}
+
+// Acquire returns buffers for one call, to be released when it completes
+func (a *CallArena) Acquire() *CallBuffers {
+	return a.pool.Get().(*CallBuffers)
+}
+
+// DecodeArgs decodes an argument array with codec into the pooled slice
+func (c *CallBuffers) DecodeArgs(codec Codec, data []byte) ([]interface{}, error) {
+	if decoder, ok := codec.(ArgsDecoder); ok {
+		args, err := decoder.DecodeArgs(data, c.args)
+		if args != nil {
+			c.args = args
+		}
+		return args, err
+	}
+
+	decoded, err := codec.Unmarshal(data)
+	if err != nil || decoded == nil {
+		return nil, err
+	}
+	list, ok := decoded.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected array, got %T", decoded)
+	}
+	return list, nil
+}
+
+// Encode encodes v with codec into the pooled buffer
+func (c *CallBuffers) Encode(codec Codec, v interface{}) ([]byte, error) {
+	if marshaler, ok := codec.(AppendMarshaler); ok {
+		out, err := marshaler.MarshalAppend(c.out[:0], v)
+		if err != nil {
+			return nil, err
+		}
+		c.out = out
+		return out, nil
+	}
+	return codec.Marshal(v)
+}
+
+// Release clears the buffers and returns them to the arena
+func (c *CallBuffers) Release() {
+	// Drop references so released arguments can be collected
+	clear(c.args[:cap(c.args)])
+	c.args = c.args[:0]
+	if cap(c.args) > arenaMaxRetained/16 {
+		c.args = nil
+	}
+	if cap(c.out) > arenaMaxRetained {
+		c.out = nil
+	}
+	c.arena.pool.Put(c)
+}
+
+// DecodeArgs decodes a JSON array into dst
+func (c JSONCodec) DecodeArgs(data []byte, dst []interface{}) ([]interface{}, error) {
+	args := dst[:0]
+	if c.Numbers != NumbersInteger {
+		if err := json.Unmarshal(data, &args); err != nil {
+			return nil, err
+		}
+		return args, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&args); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("json: trailing data after value")
+	}
+	for i, arg := range args {
+		args[i] = integralNumbers(arg)
+	}
+	return args, nil
+}
+
+// MarshalAppend appends the JSON encoding of v to dst
+func (c JSONCodec) MarshalAppend(dst []byte, v interface{}) ([]byte, error) {
+	if c.Times == TimesMillis {
+		v = EncodeTimes(v, c.Times)
+	}
+	if c.Numbers == NumbersInteger {
+		v = safeIntegers(v)
+	}
+	buf := bytes.NewBuffer(dst)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode terminates the value with a newline Marshal does not add
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// DecodeArgs decodes a MessagePack array into dst
+func (c MsgpackCodec) DecodeArgs(data []byte, dst []interface{}) ([]interface{}, error) {
+	dec := c.decoder(data)
+	tag, err := dec.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	var n uint64
+	switch {
+	case tag[0] == 0xc0:
+		return nil, dec.end()
+	case tag[0]&0xf0 == 0x90:
+		n = uint64(tag[0] & 0x0f)
+	case tag[0] == 0xdc || tag[0] == 0xdd:
+		if n, err = dec.readUint(2 << (tag[0] - 0xdc)); err != nil {
+			return nil, err
+		}
+	default:
+		dec.pos = 0
+		v, err := dec.decode()
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("expected array, got %T", v)
+	}
+	if n > uint64(len(data)-dec.pos) {
+		return nil, fmt.Errorf("msgpack: array length %d exceeds data", n)
+	}
+	if err := dec.count(); err != nil {
+		return nil, err
+	}
+	if err := dec.enter(); err != nil {
+		return nil, err
+	}
+
+	args := dst[:0]
+	for i := uint64(0); i < n; i++ {
+		v, err := dec.decode()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	return args, dec.end()
+}
+
+// MarshalAppend appends the MessagePack encoding of v to dst
+func (c MsgpackCodec) MarshalAppend(dst []byte, v interface{}) ([]byte, error) {
+	if c.Times == TimesMillis {
+		v = EncodeTimes(v, c.Times)
+	}
+	if c.Numbers == NumbersInteger {
+		v = safeIntegers(v)
+	}
+	enc := &msgpackEncoder{buf: dst}
+	if err := enc.encode(v); err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+// end reports trailing bytes after a decoded value
+func (d *msgpackDecoder) end() error {
+	if d.pos != len(d.data) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(d.data)-d.pos)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// QuietEnv names the environment variable that, set to a true value such
+// as "1" or "true", suppresses decorative startup output
+const QuietEnv = "POLYGLOT_QUIET"
+
+// QuietFromEnv reports whether POLYGLOT_QUIET is set to a true value
+func QuietFromEnv() bool {
+	quiet, _ := strconv.ParseBool(os.Getenv(QuietEnv))
+	return quiet
+}
+
+// Banner writes lines to w inside a box, or nothing when quiet. Banners
+// are decoration for interactive use; anything a deployment needs to see
+// belongs in a Logger.
+func Banner(w io.Writer, quiet bool, lines ...string) {
+	if quiet || w == nil {
+		return
+	}
+
+	width := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > width {
+			width = n
+		}
+	}
+	width += 4
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "╔"+strings.Repeat("═", width)+"╗")
+	for _, line := range lines {
+		pad := width - utf8.RuneCountInString(line)
+		fmt.Fprintln(w, "║"+strings.Repeat(" ", pad/2)+line+strings.Repeat(" ", pad-pad/2)+"║")
+	}
+	fmt.Fprintln(w, "╚"+strings.Repeat("═", width)+"╝")
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// BatchOp is one call in a batch
+type BatchOp struct {
+	Method string        `json:"method"`
+	Args   []interface{} `json:"args"`
+}
+
+// HandlerGroup is a set of bridge handlers sharing state. Calls through the
+// group run one at a time, and Batch applies several calls atomically:
+// when one fails, the state is restored to a snapshot taken before the
+// batch.
+type HandlerGroup struct {
+	name     string
+	snapshot func() interface{}
+	restore  func(snapshot interface{})
+	handlers map[string]BridgeFunc
+	mu       sync.Mutex
+}
+
+// NewHandlerGroup creates a handler group. snapshot must copy the group's
+// state deeply enough that later mutations do not change the copy; restore
+// replaces the state with a copy returned by snapshot.
+func NewHandlerGroup(name string, snapshot func() interface{}, restore func(snapshot interface{})) *HandlerGroup {
+	return &HandlerGroup{
+		name:     name,
+		snapshot: snapshot,
+		restore:  restore,
+		handlers: make(map[string]BridgeFunc),
+	}
+}
+
+// Handle adds a handler to the group
+func (g *HandlerGroup) Handle(method string, fn BridgeFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.handlers[method] = fn
+}
+
+// Register registers each handler on the bridge under "<group>.<method>",
+// plus "<group>.batch" taking a list of {method, args} operations
+func (g *HandlerGroup) Register(bridge Bridge) error {
+	g.mu.Lock()
+	methods := make([]string, 0, len(g.handlers))
+	for method := range g.handlers {
+		methods = append(methods, method)
+	}
+	g.mu.Unlock()
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		method := method
+		err := bridge.Register(g.name+"."+method, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			return g.Call(ctx, method, args...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return bridge.Register(g.name+".batch", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, NewError(CodeInvalidArgument, "batch requires 1 argument (operations)")
+		}
+		ops, err := parseBatchOps(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return g.Batch(ctx, ops)
+	})
+}
+
+// Call invokes one handler of the group
+func (g *HandlerGroup) Call(ctx context.Context, method string, args ...interface{}) (interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.call(ctx, method, args)
+}
+
+// Batch runs ops in order and returns their results. If any op fails, the
+// group's state is rolled back and the error reports the failing op.
+func (g *HandlerGroup) Batch(ctx context.Context, ops []BatchOp) ([]interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	snapshot := g.snapshot()
+	results := make([]interface{}, 0, len(ops))
+	for i, op := range ops {
+		result, err := g.call(ctx, op.Method, op.Args)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			g.restore(snapshot)
+			return nil, Errorf(ErrorInfoFor(err).Code, "batch rolled back: operation %d (%s) failed: %w", i, op.Method, err).
+				WithDetail("index", i)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// call invokes a handler with the group lock held
+func (g *HandlerGroup) call(ctx context.Context, method string, args []interface{}) (interface{}, error) {
+	fn, ok := g.handlers[method]
+	if !ok {
+		return nil, Errorf(CodeNotFound, "function %s.%s not found", g.name, method)
+	}
+	return fn(ctx, args...)
+}
+
+// parseBatchOps converts decoded bridge arguments into batch operations
+func parseBatchOps(value interface{}) ([]BatchOp, error) {
+	if ops, ok := value.([]BatchOp); ok {
+		return ops, nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, Errorf(CodeInvalidArgument, "operations must be a list, got %T", value)
+	}
+
+	ops := make([]BatchOp, len(list))
+	for i, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, Errorf(CodeInvalidArgument, "operation %d must be an object", i)
+		}
+		method, ok := entry["method"].(string)
+		if !ok || method == "" {
+			return nil, Errorf(CodeInvalidArgument, "operation %d has no method", i)
+		}
+		ops[i].Method = method
+
+		switch args := entry["args"].(type) {
+		case nil:
+		case []interface{}:
+			ops[i].Args = args
+		default:
+			return nil, Errorf(CodeInvalidArgument, "operation %d args must be a list", i)
+		}
+	}
+	return ops, nil
+}
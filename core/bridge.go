@@ -2,70 +2,302 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// SimpleBridge implements a basic bridge for frontend-backend communication
+// SimpleBridge implements a basic bridge for frontend-backend communication.
+// Calls look handlers up in a copy-on-write dispatch table without taking
+// a lock, so registration never slows down call-heavy apps.
 type SimpleBridge struct {
-	functions map[string]BridgeFunc
-	mu        sync.RWMutex
+	functions  map[string]BridgeFunc
+	locks      map[string]*sync.Mutex
+	groups     map[string]*sync.Mutex
+	isolation  map[string]Isolation
+	manifests  map[string]FunctionManifest
+	docs       map[string]functionDoc
+	serialized bool
+	parent     Bridge
+	fallback   BridgeFallback
+	logger     Logger
+	mu         sync.RWMutex
+
+	// dispatch is the table Call reads, replaced whole on every change
+	dispatch atomic.Pointer[dispatchTable]
+}
+
+// dispatchTable is an immutable snapshot of what Call needs
+type dispatchTable struct {
+	handlers map[string]dispatchEntry
+	parent   Bridge
+	fallback BridgeFallback
+}
+
+// dispatchEntry is a handler and the lock its calls take, nil when they
+// run concurrently
+type dispatchEntry struct {
+	fn   BridgeFunc
+	lock *sync.Mutex
 }
 
 // NewBridge creates a new bridge instance
 func NewBridge() *SimpleBridge {
-	return &SimpleBridge{
+	b := &SimpleBridge{
 		functions: make(map[string]BridgeFunc),
+		locks:     make(map[string]*sync.Mutex),
+		groups:    make(map[string]*sync.Mutex),
+		isolation: make(map[string]Isolation),
+		manifests: make(map[string]FunctionManifest),
+		docs:      make(map[string]functionDoc),
+	}
+	b.publish()
+	return b
+}
+
+// publish replaces the dispatch table with the current registrations.
+// Callers must hold b.mu, except during construction.
+func (b *SimpleBridge) publish() {
+	handlers := make(map[string]dispatchEntry, len(b.functions))
+	for name, fn := range b.functions {
+		handlers[name] = dispatchEntry{fn: fn, lock: b.lockFor(name)}
 	}
+	b.dispatch.Store(&dispatchTable{
+		handlers: handlers,
+		parent:   b.parent,
+		fallback: b.fallback,
+	})
+}
+
+// SetSerialized controls whether calls to the same handler run one at a
+// time. Enable it when handlers mutate shared state without their own
+// synchronization. Functions given an Isolation keep it either way.
+func (b *SimpleBridge) SetSerialized(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.serialized = enabled
+	b.publish()
+}
+
+// Extend makes calls to names this bridge does not register fall through
+// to parent, so a window-specific bridge can share globally registered
+// functions and add its own. Registrations on this bridge take precedence
+// over the parent's on name collision.
+func (b *SimpleBridge) Extend(parent Bridge) error {
+	for p := parent; p != nil; {
+		if p == Bridge(b) {
+			return fmt.Errorf("bridge cannot extend itself")
+		}
+		sb, ok := p.(*SimpleBridge)
+		if !ok || sb == nil {
+			break
+		}
+		sb.mu.RLock()
+		p = sb.parent
+		sb.mu.RUnlock()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.parent = parent
+	b.publish()
+	return nil
+}
+
+// reservedBridgeNames are the public window.polyglot API names a function
+// could be mistaken for in frontend code. Functions are called by name
+// through window.polyglot.call, so the API's internal helpers are free to
+// use.
+var reservedBridgeNames = map[string]bool{
+	"call":         true,
+	"capabilities": true,
+	"on":           true,
+	"store":        true,
+}
+
+// IsReservedBridgeName reports whether name is reserved for the
+// window.polyglot API and so cannot be registered as a function
+func IsReservedBridgeName(name string) bool {
+	return reservedBridgeNames[name]
 }
 
-// Register adds a callable function to the bridge
+// Register adds a callable function to the bridge. Names reserved for the
+// window.polyglot API are rejected with CodeInvalidArgument.
 func (b *SimpleBridge) Register(name string, fn BridgeFunc) error {
+	return b.register(name, fn, nil)
+}
+
+// register adds fn with isolation, or the bridge's default when nil
+func (b *SimpleBridge) register(name string, fn BridgeFunc, isolation *Isolation) error {
+	if IsReservedBridgeName(name) {
+		return Errorf(CodeInvalidArgument, "function name %s is reserved by the polyglot API", name)
+	}
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	
+
 	if _, exists := b.functions[name]; exists {
 		return fmt.Errorf("function %s already registered", name)
 	}
-	
+
 	b.functions[name] = fn
+	b.locks[name] = &sync.Mutex{}
+	if isolation != nil {
+		b.isolation[name] = *isolation
+	}
+	b.publish()
 	return nil
 }
 
-// Unregister removes a callable function
+// RegisterWithTimeout adds a function that must finish within timeout,
+// independent of any runtime timeout it calls into. The handler's context
+// carries the deadline; once it passes, the call returns a CodeTimeout
+// error right away, even if the handler is still running.
+func (b *SimpleBridge) RegisterWithTimeout(name string, timeout time.Duration, fn BridgeFunc) error {
+	if timeout <= 0 {
+		return fmt.Errorf("function %s: timeout must be positive", name)
+	}
+	return b.Register(name, withTimeout(name, timeout, fn))
+}
+
+// withTimeout bounds fn to timeout, abandoning handlers that overrun it
+func withTimeout(name string, timeout time.Duration, fn BridgeFunc) BridgeFunc {
+	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		type outcome struct {
+			result interface{}
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := fn(callCtx, args...)
+			done <- outcome{result, err}
+		}()
+
+		select {
+		case o := <-done:
+			return o.result, o.err
+		case <-callCtx.Done():
+			// The caller's own cancellation is not a bridge timeout
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, Errorf(CodeTimeout, "function %s exceeded bridge timeout %v: %w", name, timeout, callCtx.Err()).
+				WithDetail("timeout", timeout.String())
+		}
+	}
+}
+
+// Unregister removes a callable function. Functions inherited from a
+// parent bridge are left in place.
 func (b *SimpleBridge) Unregister(name string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	
+
 	if _, exists := b.functions[name]; !exists {
 		return fmt.Errorf("function %s not found", name)
 	}
-	
+
 	delete(b.functions, name)
+	delete(b.locks, name)
+	delete(b.isolation, name)
+	delete(b.manifests, name)
+	delete(b.docs, name)
+	b.publish()
 	return nil
 }
 
-// Call invokes a registered function
+// ErrFunctionNotFound is returned by calls to functions that are not
+// registered. The error's "function" detail holds the name called.
+var ErrFunctionNotFound = NewError(CodeNotFound, "function not found")
+
+// functionNotFound reports a call to the unregistered function name
+func functionNotFound(name string) error {
+	err := Errorf(CodeNotFound, "function %s not found", name).WithDetail("function", name)
+	err.Err = ErrFunctionNotFound
+	return err
+}
+
+// isFunctionNotFound reports whether err is the failure of a call to the
+// unregistered function name, rather than of a call made by its handler
+func isFunctionNotFound(err error, name string) bool {
+	var typed *Error
+	return errors.Is(err, ErrFunctionNotFound) && errors.As(err, &typed) && typed.Details["function"] == name
+}
+
+// BridgeFallback handles calls to functions a bridge does not register
+type BridgeFallback func(ctx context.Context, name string, args ...interface{}) (interface{}, error)
+
+// SetFallbackHandler routes calls to unregistered functions to fn, for
+// proxying them elsewhere or dispatching on the name at call time. Calls
+// fall through to a parent bridge, and its fallback, before reaching fn.
+// Without a fallback, or with a nil fn, they fail with ErrFunctionNotFound.
+func (b *SimpleBridge) SetFallbackHandler(fn BridgeFallback) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fallback = fn
+	b.publish()
+}
+
+// Call invokes a registered function. Calls to unregistered functions go
+// to the parent bridge, then the fallback handler, and otherwise fail with
+// ErrFunctionNotFound.
 func (b *SimpleBridge) Call(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
-	b.mu.RLock()
-	fn, exists := b.functions[name]
-	b.mu.RUnlock()
-	
+	table := b.dispatch.Load()
+	entry, exists := table.handlers[name]
+
 	if !exists {
-		return nil, fmt.Errorf("function %s not found", name)
+		if table.parent != nil {
+			result, err := table.parent.Call(ctx, name, args...)
+			if table.fallback == nil || !isFunctionNotFound(err, name) {
+				return result, err
+			}
+		}
+		if table.fallback != nil {
+			return table.fallback(ctx, name, args...)
+		}
+		return nil, functionNotFound(name)
+	}
+
+	if entry.lock != nil {
+		entry.lock.Lock()
+		defer entry.lock.Unlock()
 	}
-	
-	return fn(ctx, args...)
+
+	return entry.fn(ctx, args...)
 }
 
-// Functions returns a list of registered function names
+// Functions returns a list of registered function names, including those
+// inherited from a parent bridge
 func (b *SimpleBridge) Functions() []string {
 	b.mu.RLock()
-	defer b.mu.RUnlock()
-	
 	names := make([]string, 0, len(b.functions))
 	for name := range b.functions {
 		names = append(names, name)
 	}
+	parent := b.parent
+	b.mu.RUnlock()
+
+	if lister, ok := parent.(interface{ Functions() []string }); ok {
+		for _, name := range lister.Functions() {
+			if !b.has(name) {
+				names = append(names, name)
+			}
+		}
+	}
 	return names
 }
+
+func (b *SimpleBridge) has(name string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, exists := b.functions[name]
+	return exists
+}
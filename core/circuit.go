@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests to a runtime whose circuit
+// breaker is open
+var ErrCircuitOpen = NewError(CodeUnavailable, "circuit open")
+
+// CircuitBreakerConfig stops sending requests to a runtime that keeps
+// failing, until a cooldown has passed
+type CircuitBreakerConfig struct {
+	// Threshold is the number of consecutive failures that opens the
+	// circuit (default 5)
+	Threshold int
+
+	// Cooldown is how long the circuit stays open before one request is
+	// let through to test recovery (default 30s)
+	Cooldown time.Duration
+}
+
+// withDefaults fills unset fields with their defaults
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.Threshold <= 0 {
+		c.Threshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	return c
+}
+
+// CircuitState is the state of a runtime's circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed lets requests through
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen rejects requests with ErrCircuitOpen
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen lets one request through to test recovery
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitChange describes a circuit breaker state transition, published
+// as a TopicCircuitChange event
+type CircuitChange struct {
+	Runtime string
+	From    CircuitState
+	To      CircuitState
+
+	// Failures is the number of consecutive failures seen
+	Failures int
+
+	// Err is the error that opened the circuit, if it opened
+	Err error
+}
+
+// circuitBreakers tracks a breaker per runtime
+type circuitBreakers struct {
+	config   CircuitBreakerConfig
+	events   *EventBus
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// circuitBreaker is one runtime's breaker. Guarded by circuitBreakers.mu.
+type circuitBreaker struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+
+	// probing is set while the half-open trial request runs
+	probing bool
+}
+
+func newCircuitBreakers(config CircuitBreakerConfig, events *EventBus) *circuitBreakers {
+	return &circuitBreakers{
+		config:   config.withDefaults(),
+		events:   events,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+func (c *circuitBreakers) get(runtime string) *circuitBreaker {
+	b, ok := c.breakers[runtime]
+	if !ok {
+		b = &circuitBreaker{state: CircuitClosed}
+		c.breakers[runtime] = b
+	}
+	return b
+}
+
+// allow reports whether a request to runtime may run, moving an open
+// circuit whose cooldown has passed to half-open
+func (c *circuitBreakers) allow(runtime string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.get(runtime)
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < c.config.Cooldown {
+			return false
+		}
+		c.transition(runtime, b, CircuitHalfOpen, nil)
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record updates runtime's breaker with the outcome of a request
+func (c *circuitBreakers) record(runtime string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.get(runtime)
+	if b.state == CircuitHalfOpen {
+		b.probing = false
+	}
+
+	if err == nil {
+		b.failures = 0
+		if b.state != CircuitClosed {
+			c.transition(runtime, b, CircuitClosed, nil)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.state == CircuitClosed && b.failures >= c.config.Threshold {
+		b.openedAt = time.Now()
+		c.transition(runtime, b, CircuitOpen, err)
+	}
+}
+
+// transition moves b to state and publishes the change
+func (c *circuitBreakers) transition(runtime string, b *circuitBreaker, state CircuitState, err error) {
+	change := CircuitChange{Runtime: runtime, From: b.state, To: state, Failures: b.failures, Err: err}
+	b.state = state
+	c.events.Publish(TopicCircuitChange, change)
+}
+
+// state returns runtime's circuit state
+func (c *circuitBreakers) state(runtime string) CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(runtime).state
+}
+
+// middleware rejects requests to runtimes with an open circuit and records
+// the outcome of the rest. Requests canceled by the caller are not counted.
+func (c *circuitBreakers) middleware(next Handler) Handler {
+	return func(ctx context.Context, req *Request) (interface{}, error) {
+		if !c.allow(req.Runtime) {
+			return nil, Errorf(CodeUnavailable, "runtime %s unavailable: %w", req.Runtime, ErrCircuitOpen)
+		}
+
+		result, err := next(ctx, req)
+		if errors.Is(err, context.Canceled) {
+			// Release a half-open trial without judging the runtime
+			c.mu.Lock()
+			c.get(req.Runtime).probing = false
+			c.mu.Unlock()
+			return result, err
+		}
+		c.record(req.Runtime, err)
+		return result, err
+	}
+}
+
+// CircuitState returns the state of runtime's circuit breaker. It is
+// always CircuitClosed when Config.CircuitBreaker is nil.
+func (o *Orchestrator) CircuitState(runtime string) CircuitState {
+	if o.breakers == nil {
+		return CircuitClosed
+	}
+	return o.breakers.state(runtime)
+}
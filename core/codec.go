@@ -0,0 +1,682 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Codec serializes bridge arguments and results
+type Codec interface {
+	// Name identifies the wire format
+	Name() string
+
+	// Marshal encodes a value
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes data into a generic value
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// Wire format names
+const (
+	FormatJSON    = "json"
+	FormatMsgpack = "msgpack"
+)
+
+// CodecFor returns the codec for a wire format name, defaulting to JSON
+func CodecFor(format string) Codec {
+	if format == FormatMsgpack {
+		return MsgpackCodec{}
+	}
+	return JSONCodec{}
+}
+
+// JSONCodec encodes values as JSON
+type JSONCodec struct {
+	// Numbers selects the number policy; the zero value behaves as float
+	Numbers NumberPolicy
+
+	// Times selects the time policy; the zero value behaves as rfc3339
+	Times TimePolicy
+}
+
+// Name returns the wire format name
+func (JSONCodec) Name() string { return FormatJSON }
+
+// Marshal encodes a value as JSON
+func (c JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	if c.Times == TimesMillis {
+		v = EncodeTimes(v, c.Times)
+	}
+	if c.Numbers == NumbersInteger {
+		v = safeIntegers(v)
+	}
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON into a generic value
+func (c JSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	if c.Numbers != NumbersInteger {
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("json: trailing data after value")
+	}
+	return integralNumbers(v), nil
+}
+
+// MsgpackCodec encodes values as MessagePack. Decoded numbers are float64
+// so handlers see the same types they would receive from JSON, unless the
+// integer number policy is selected.
+type MsgpackCodec struct {
+	// Numbers selects the number policy; the zero value behaves as float
+	Numbers NumberPolicy
+
+	// Times selects the time policy; the zero value behaves as rfc3339
+	Times TimePolicy
+
+	// MaxDepth and MaxTokens cap how deeply decoded data nests and how
+	// many values it holds, failing with CodeTooLarge past either. Zero
+	// leaves them unlimited.
+	MaxDepth  int
+	MaxTokens int
+}
+
+// Name returns the wire format name
+func (MsgpackCodec) Name() string { return FormatMsgpack }
+
+// Marshal encodes a value as MessagePack
+func (c MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	if c.Times == TimesMillis {
+		v = EncodeTimes(v, c.Times)
+	}
+	if c.Numbers == NumbersInteger {
+		v = safeIntegers(v)
+	}
+
+	enc := &msgpackEncoder{buf: make([]byte, 0, 64)}
+	if err := enc.encode(v); err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+// Unmarshal decodes MessagePack into a generic value
+func (c MsgpackCodec) Unmarshal(data []byte) (interface{}, error) {
+	dec := c.decoder(data)
+	v, err := dec.decode()
+	if err != nil {
+		return nil, err
+	}
+	if err := dec.end(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// decoder returns a decoder for data using the codec's number policy and
+// limits
+func (c MsgpackCodec) decoder(data []byte) *msgpackDecoder {
+	return &msgpackDecoder{
+		data:      data,
+		integers:  c.Numbers == NumbersInteger,
+		maxDepth:  c.MaxDepth,
+		maxTokens: c.MaxTokens,
+	}
+}
+
+// msgpackEncoder appends MessagePack data to a buffer
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) encode(v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if val {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case int:
+		e.encodeInt(int64(val))
+	case int8:
+		e.encodeInt(int64(val))
+	case int16:
+		e.encodeInt(int64(val))
+	case int32:
+		e.encodeInt(int64(val))
+	case int64:
+		e.encodeInt(val)
+	case uint:
+		e.encodeUint(uint64(val))
+	case uint8:
+		e.encodeUint(uint64(val))
+	case uint16:
+		e.encodeUint(uint64(val))
+	case uint32:
+		e.encodeUint(uint64(val))
+	case uint64:
+		e.encodeUint(val)
+	case float32:
+		e.buf = append(e.buf, 0xca)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(val))
+	case float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(val))
+	case string:
+		e.encodeString(val)
+	case []byte:
+		e.encodeBytes(val)
+	case []interface{}:
+		e.encodeArrayHeader(len(val))
+		for _, item := range val {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		e.encodeMapHeader(len(val))
+		for _, k := range keys {
+			e.encodeString(k)
+			if err := e.encode(val[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return e.encodeReflect(v)
+	}
+	return nil
+}
+
+// encodeReflect handles typed slices, maps and plain structs directly and
+// routes anything else through JSON so encoding matches the JSON path
+func (e *msgpackEncoder) encodeReflect(v interface{}) error {
+	if _, ok := v.(json.Marshaler); ok {
+		return e.encodeViaJSON(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Struct:
+		if fields, ok := msgpackFields(rv.Type()); ok {
+			return e.encodeStruct(rv, fields)
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		e.encodeArrayHeader(rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.encode(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			if rv.IsNil() {
+				e.buf = append(e.buf, 0xc0)
+				return nil
+			}
+			m := make(map[string]interface{}, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				m[iter.Key().String()] = iter.Value().Interface()
+			}
+			return e.encode(m)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encode(rv.Elem().Interface())
+	}
+
+	return e.encodeViaJSON(v)
+}
+
+// encodeViaJSON converts a value to its generic JSON form before encoding
+func (e *msgpackEncoder) encodeViaJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("msgpack: cannot encode %T: %w", v, err)
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("msgpack: cannot encode %T: %w", v, err)
+	}
+	return e.encode(generic)
+}
+
+// msgpackField describes an encodable struct field
+type msgpackField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// msgpackFields lists a struct's fields using JSON tag names. It reports
+// false for structs whose JSON form needs encoding/json itself, such as
+// those with embedded fields or string-tagged options.
+func msgpackFields(t reflect.Type) ([]msgpackField, bool) {
+	fields := make([]msgpackField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			return nil, false
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		omitEmpty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				switch opt {
+				case "omitempty":
+					omitEmpty = true
+				case "string":
+					return nil, false
+				}
+			}
+		}
+
+		fields = append(fields, msgpackField{index: i, name: name, omitEmpty: omitEmpty})
+	}
+	return fields, true
+}
+
+func (e *msgpackEncoder) encodeStruct(rv reflect.Value, fields []msgpackField) error {
+	present := fields[:0:0]
+	for _, f := range fields {
+		if f.omitEmpty && isEmptyValue(rv.Field(f.index)) {
+			continue
+		}
+		present = append(present, f)
+	}
+
+	e.encodeMapHeader(len(present))
+	for _, f := range present {
+		e.encodeString(f.name)
+		if err := e.encode(rv.Field(f.index).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEmptyValue mirrors encoding/json's omitempty rules
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+func (e *msgpackEncoder) encodeInt(n int64) {
+	if n >= 0 {
+		e.encodeUint(uint64(n))
+		return
+	}
+
+	switch {
+	case n >= -32:
+		e.buf = append(e.buf, byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(n))
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, n)
+	}
+}
+
+func (e *msgpackEncoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) encodeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xc5)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xc6)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+func (e *msgpackEncoder) encodeArrayHeader(n int) {
+	switch {
+	case n <= 15:
+		e.buf = append(e.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xdc)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdd)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) encodeMapHeader(n int) {
+	switch {
+	case n <= 15:
+		e.buf = append(e.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xde)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdf)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+// msgpackDecoder reads MessagePack data into generic values
+type msgpackDecoder struct {
+	data     []byte
+	pos      int
+	integers bool
+
+	// depth and tokens track the containers open and the values read,
+	// checked against maxDepth and maxTokens when they are positive
+	depth, tokens       int
+	maxDepth, maxTokens int
+}
+
+// count records a value, failing once more than maxTokens are read
+func (d *msgpackDecoder) count() error {
+	d.tokens++
+	if d.maxTokens > 0 && d.tokens > d.maxTokens {
+		return Errorf(CodeTooLarge, "message holds more than %d values", d.maxTokens).
+			WithDetail("limit", d.maxTokens)
+	}
+	return nil
+}
+
+// enter records an opened array or map, failing once they nest deeper
+// than maxDepth; the caller decrements depth when it closes
+func (d *msgpackDecoder) enter() error {
+	d.depth++
+	if d.maxDepth > 0 && d.depth > d.maxDepth {
+		return Errorf(CodeTooLarge, "message nests deeper than %d levels", d.maxDepth).
+			WithDetail("limit", d.maxDepth)
+	}
+	return nil
+}
+
+// signed returns a decoded integer according to the number policy
+func (d *msgpackDecoder) signed(n int64) interface{} {
+	if d.integers {
+		return n
+	}
+	return float64(n)
+}
+
+// unsigned returns a decoded unsigned integer according to the number policy
+func (d *msgpackDecoder) unsigned(n uint64) interface{} {
+	if !d.integers {
+		return float64(n)
+	}
+	if n > math.MaxInt64 {
+		return n
+	}
+	return int64(n)
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, fmt.Errorf("msgpack: unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *msgpackDecoder) decode() (interface{}, error) {
+	if err := d.count(); err != nil {
+		return nil, err
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	tag := b[0]
+
+	switch {
+	case tag <= 0x7f:
+		return d.signed(int64(tag)), nil
+	case tag >= 0xe0:
+		return d.signed(int64(int8(tag))), nil
+	case tag&0xe0 == 0xa0:
+		return d.decodeString(int(tag & 0x1f))
+	case tag&0xf0 == 0x90:
+		return d.decodeArray(int(tag & 0x0f))
+	case tag&0xf0 == 0x80:
+		return d.decodeMap(int(tag & 0x0f))
+	}
+
+	switch tag {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readUint(1 << (tag - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), raw...), nil
+	case 0xca:
+		n, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 0xcb:
+		n, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(n), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.readUint(1 << (tag - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return d.unsigned(n), nil
+	case 0xd0:
+		n, err := d.readUint(1)
+		return d.signed(int64(int8(n))), err
+	case 0xd1:
+		n, err := d.readUint(2)
+		return d.signed(int64(int16(n))), err
+	case 0xd2:
+		n, err := d.readUint(4)
+		return d.signed(int64(int32(n))), err
+	case 0xd3:
+		n, err := d.readUint(8)
+		return d.signed(int64(n)), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (tag - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (tag - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (tag - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n))
+	}
+
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", tag)
+}
+
+func (d *msgpackDecoder) decodeString(n int) (interface{}, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) decodeArray(n int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, fmt.Errorf("msgpack: array length %d exceeds data", n)
+	}
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	items := make([]interface{}, n)
+	for i := range items {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		items[i] = v
+	}
+	return items, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, fmt.Errorf("msgpack: map length %d exceeds data", n)
+	}
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { d.depth-- }()
+
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key must be a string, got %T", k)
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ComparisonVerdict says how a runtime's result compares with the
+// reference runtime's
+type ComparisonVerdict string
+
+const (
+	// VerdictMatch is a result equal to the reference. Numbers are equal
+	// by value, so 2 from one runtime matches 2.0 from another.
+	VerdictMatch ComparisonVerdict = "match"
+
+	// VerdictTypeMismatch is a result holding a value of another type
+	// than the reference somewhere, as a string where it has a number
+	VerdictTypeMismatch ComparisonVerdict = "type_mismatch"
+
+	// VerdictValueMismatch is a result of the reference's types holding
+	// other values, or lists and maps of other sizes or keys
+	VerdictValueMismatch ComparisonVerdict = "value_mismatch"
+
+	// VerdictFailed is a runtime whose execution failed or timed out
+	VerdictFailed ComparisonVerdict = "failed"
+)
+
+// ComparisonReport is the outcome of CompareMulti
+type ComparisonReport struct {
+	// Reference is the runtime the others are compared with
+	Reference string
+
+	// Results holds every runtime's result, the reference's included,
+	// sorted by runtime as ExecuteMulti returns them
+	Results []MultiResult
+
+	// Runtimes compares each other runtime with the reference, sorted by
+	// runtime. It is empty when Err is set.
+	Runtimes []RuntimeComparison
+
+	// Equivalent reports that every runtime matched the reference
+	Equivalent bool
+
+	// Err is why nothing could be compared: the reference had no code or
+	// its execution failed
+	Err error
+}
+
+// RuntimeComparison compares one runtime's result with the reference's
+type RuntimeComparison struct {
+	Runtime string
+	Verdict ComparisonVerdict
+
+	// Differences lists where the result departs from the reference,
+	// outermost first. Failed runtimes have none.
+	Differences []ValueDifference
+}
+
+// ValueDifference is one place where a result departs from the reference
+type ValueDifference struct {
+	// Path locates the value in the result: "$" is the whole result,
+	// "$[2]" an item of a list and "$.name" an entry of a map
+	Path string
+
+	// Kind is VerdictTypeMismatch or VerdictValueMismatch
+	Kind ComparisonVerdict
+
+	// Expected and Actual describe the reference's value and the
+	// runtime's without their entries. A map entry one side lacks is nil.
+	Expected *Display
+	Actual   *Display
+}
+
+// CompareMulti runs code in every runtime as ExecuteMulti does and
+// compares each result with the reference runtime's, to check that
+// implementations of the same logic in several languages agree. Results
+// are compared by value whichever runtime produced them, so lists, maps
+// and numbers from different languages can match.
+func (o *Orchestrator) CompareMulti(ctx context.Context, code map[string]string, reference string, args ...interface{}) ComparisonReport {
+	report := ComparisonReport{Reference: reference}
+	if _, ok := code[reference]; !ok {
+		report.Err = Errorf(CodeInvalidArgument, "no code for reference runtime %s", reference)
+		return report
+	}
+
+	report.Results = o.ExecuteMulti(ctx, code, args...)
+	var expected MultiResult
+	for _, result := range report.Results {
+		if result.Runtime == reference {
+			expected = result
+		}
+	}
+	if expected.Status != MultiOK {
+		report.Err = Errorf(CodeUnavailable, "reference runtime %s failed: %w", reference, expected.Err)
+		return report
+	}
+
+	report.Equivalent = true
+	for _, result := range report.Results {
+		if result.Runtime == reference {
+			continue
+		}
+		comparison := RuntimeComparison{Runtime: result.Runtime, Verdict: VerdictFailed}
+		if result.Status == MultiOK {
+			comparison.Differences = diffValues("$", reflect.ValueOf(expected.Value), reflect.ValueOf(result.Value), nil)
+			comparison.Verdict = verdictOf(comparison.Differences)
+		}
+		if comparison.Verdict != VerdictMatch {
+			report.Equivalent = false
+		}
+		report.Runtimes = append(report.Runtimes, comparison)
+	}
+	return report
+}
+
+// verdictOf classifies a result by its differences from the reference
+func verdictOf(differences []ValueDifference) ComparisonVerdict {
+	if len(differences) == 0 {
+		return VerdictMatch
+	}
+	for _, difference := range differences {
+		if difference.Kind == VerdictTypeMismatch {
+			return VerdictTypeMismatch
+		}
+	}
+	return VerdictValueMismatch
+}
+
+// diffValues appends the differences between two values at path to
+// differences. Values are typed as Describe types them.
+func diffValues(path string, want, got reflect.Value, differences []ValueDifference) []ValueDifference {
+	want, got = plainValue(want), plainValue(got)
+	mismatch := func(kind ComparisonVerdict) []ValueDifference {
+		return append(differences, ValueDifference{Path: path, Kind: kind, Expected: summarize(want), Actual: summarize(got)})
+	}
+	if valueType(want) != valueType(got) {
+		return mismatch(VerdictTypeMismatch)
+	}
+
+	switch valueType(want) {
+	case DisplayList:
+		if want.Len() != got.Len() {
+			differences = mismatch(VerdictValueMismatch)
+		}
+		for i := 0; i < want.Len() && i < got.Len(); i++ {
+			differences = diffValues(path+"["+strconv.Itoa(i)+"]", want.Index(i), got.Index(i), differences)
+		}
+		return differences
+	case DisplayMap:
+		wantEntries, gotEntries := entriesOf(want), entriesOf(got)
+		keys := make([]string, 0, len(wantEntries)+len(gotEntries))
+		for key := range wantEntries {
+			keys = append(keys, key)
+		}
+		for key := range gotEntries {
+			if _, ok := wantEntries[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			w, inWant := wantEntries[key]
+			g, inGot := gotEntries[key]
+			if !inWant || !inGot {
+				difference := ValueDifference{Path: path + "." + key, Kind: VerdictValueMismatch}
+				if inWant {
+					difference.Expected = summarize(plainValue(w))
+				}
+				if inGot {
+					difference.Actual = summarize(plainValue(g))
+				}
+				differences = append(differences, difference)
+				continue
+			}
+			differences = diffValues(path+"."+key, w, g, differences)
+		}
+		return differences
+	case DisplayBytes:
+		if !bytes.Equal(bytesOf(want), bytesOf(got)) {
+			return mismatch(VerdictValueMismatch)
+		}
+		return differences
+	case DisplayNumber:
+		a, b := Describe(want.Interface()).Text, Describe(got.Interface()).Text
+		if a != b && !sameNumber(a, b) {
+			return mismatch(VerdictValueMismatch)
+		}
+		return differences
+	case DisplayNull:
+		return differences
+	}
+	if Describe(want.Interface()).Text != Describe(got.Interface()).Text {
+		return mismatch(VerdictValueMismatch)
+	}
+	return differences
+}
+
+// plainValue unwraps interfaces and pointers, and replaces structs and
+// values with their own JSON encoding by the value they encode to, as
+// Describe does. Nil values become the zero Value.
+func plainValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if _, ok := v.Interface().(json.Marshaler); ok && v.Kind() == reflect.Ptr {
+			return jsonValue(v)
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return v
+	}
+	if _, ok := v.Interface().(json.Marshaler); ok || v.Kind() == reflect.Struct {
+		return jsonValue(v)
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return reflect.Value{}
+	}
+	return v
+}
+
+// jsonValue is the value v encodes to as JSON
+func jsonValue(v reflect.Value) reflect.Value {
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return reflect.ValueOf(fmt.Sprint(v.Interface()))
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return reflect.ValueOf(string(encoded))
+	}
+	return plainValue(reflect.ValueOf(generic))
+}
+
+// valueType is the Display type of a plain value
+func valueType(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return DisplayNull
+	case v.Kind() == reflect.Bool:
+		return DisplayBoolean
+	case isNumberKind(v.Kind()):
+		return DisplayNumber
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8:
+		return DisplayBytes
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		return DisplayList
+	case v.Kind() == reflect.Map:
+		return DisplayMap
+	}
+	return DisplayString
+}
+
+// summarize describes a plain value without its entries, enough to report
+// a difference
+func summarize(v reflect.Value) *Display {
+	switch kind := valueType(v); kind {
+	case DisplayNull:
+		return &Display{Type: DisplayNull, Text: "null"}
+	case DisplayList, DisplayMap:
+		d := describer{DisplayOptions{MaxDepth: 1}}.collection(kind, v.Len(), 0)
+		return &d
+	}
+	d := Describe(v.Interface())
+	return &d
+}
+
+// entriesOf indexes the entries of a map by key, formatted as Describe
+// formats them
+func entriesOf(v reflect.Value) map[string]reflect.Value {
+	entries := make(map[string]reflect.Value, v.Len())
+	for _, key := range v.MapKeys() {
+		entries[fmt.Sprint(key.Interface())] = v.MapIndex(key)
+	}
+	return entries
+}
+
+// bytesOf returns the bytes of a byte slice or array
+func bytesOf(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b
+}
+
+// sameNumber reports whether two formatted numbers have the same value,
+// as an integer and a float can
+func sameNumber(a, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x == y
+}
@@ -0,0 +1,527 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultCompileCacheSize is how many artifacts a CompileCache keeps
+const DefaultCompileCacheSize = 64
+
+// DefaultCompileCacheDir is where persistent compile caches keep their
+// artifacts, relative to the app's working directory, with a subdirectory
+// per runtime
+const DefaultCompileCacheDir = ".polyglot/cache"
+
+// DefaultCompileCacheBytes caps the disk space of a persistent compile
+// cache
+const DefaultCompileCacheBytes int64 = 512 << 20
+
+// buildPrefix names artifacts still being built
+const buildPrefix = "build-"
+
+// CompileCache keeps the binaries compiled runtimes build from snippets,
+// so running the same program again skips the compiler. Programs are keyed
+// by SourceKey, so edits to comments and whitespace reuse the artifact.
+// It is safe for concurrent use by a runtime's workers.
+//
+// A persistent cache, opened with OpenCompileCache, keeps its artifacts on
+// disk when closed, so later runs and other processes using the same
+// directory start warm.
+type CompileCache struct {
+	dir        string
+	size       int
+	maxBytes   int64
+	bytes      int64
+	persistent bool
+	mu         sync.Mutex
+	entries    map[string]*compileEntry
+	hits       uint64
+	misses     uint64
+}
+
+type compileEntry struct {
+	path     string
+	used     time.Time
+	size     int64
+	users    int
+	obsolete bool
+}
+
+// CompileCacheStats counts cache lookups
+type CompileCacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// NewCompileCache creates a cache storing up to size artifacts in a new
+// temporary directory. Zero or less uses DefaultCompileCacheSize.
+func NewCompileCache(runtime string, size int) (*CompileCache, error) {
+	if size <= 0 {
+		size = DefaultCompileCacheSize
+	}
+	dir, err := os.MkdirTemp("", fmt.Sprintf("polyglot-%s-cache-*", runtime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compile cache: %w", err)
+	}
+	return &CompileCache{dir: dir, size: size, entries: make(map[string]*compileEntry)}, nil
+}
+
+// OpenCompileCache opens the persistent cache for runtime under dir,
+// creating it if needed and keeping the artifacts of earlier runs. It holds
+// up to size artifacts and maxBytes bytes, evicting the least recently used
+// beyond either; zero or less uses DefaultCompileCacheSize and
+// DefaultCompileCacheBytes.
+func OpenCompileCache(dir, runtime string, size int, maxBytes int64) (*CompileCache, error) {
+	if size <= 0 {
+		size = DefaultCompileCacheSize
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultCompileCacheBytes
+	}
+	dir = filepath.Join(dir, runtime)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create compile cache: %w", err)
+	}
+
+	c := &CompileCache{
+		dir:        dir,
+		size:       size,
+		maxBytes:   maxBytes,
+		persistent: true,
+		entries:    make(map[string]*compileEntry),
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compile cache: %w", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), buildPrefix) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		c.add(file.Name(), info)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	return c, nil
+}
+
+// CompileCacheFor creates the compile cache for a compiled runtime, or
+// returns nil when config disables it with Options["compile_cache"] set to
+// false. Options["compile_cache_size"] sets how many artifacts it keeps.
+//
+// Options["compile_cache_dir"] makes the cache persistent under that
+// directory, and Options["compile_cache_persist"] set to true does so under
+// DefaultCompileCacheDir. Options["compile_cache_max_bytes"] caps the disk
+// space of a persistent cache.
+func CompileCacheFor(runtime string, config RuntimeConfig) (*CompileCache, error) {
+	if enabled, ok := config.Options["compile_cache"].(bool); ok && !enabled {
+		return nil, nil
+	}
+	size, _ := config.Options["compile_cache_size"].(int)
+
+	dir, _ := config.Options["compile_cache_dir"].(string)
+	if persist, _ := config.Options["compile_cache_persist"].(bool); persist && dir == "" {
+		dir = DefaultCompileCacheDir
+	}
+	if dir == "" {
+		return NewCompileCache(runtime, size)
+	}
+
+	var maxBytes int64
+	switch v := config.Options["compile_cache_max_bytes"].(type) {
+	case int:
+		maxBytes = int64(v)
+	case int64:
+		maxBytes = v
+	case float64:
+		maxBytes = int64(v)
+	}
+	return OpenCompileCache(dir, runtime, size, maxBytes)
+}
+
+// add records an artifact found on disk
+func (c *CompileCache) add(key string, info os.FileInfo) *compileEntry {
+	entry := &compileEntry{path: filepath.Join(c.dir, key), used: info.ModTime(), size: info.Size()}
+	c.entries[key] = entry
+	c.bytes += entry.size
+	return entry
+}
+
+// Acquire returns the artifact for key, calling build to produce it at
+// path when it is not cached. Artifacts are only cached when build
+// succeeds. release must be called once the artifact is no longer used,
+// which keeps it from being evicted while it runs.
+func (c *CompileCache) Acquire(key string, build func(path string) error) (path string, release func(), err error) {
+	c.mu.Lock()
+	if entry, ok := c.lookup(key); ok {
+		c.hits++
+		entry.users++
+		c.touch(entry)
+		c.mu.Unlock()
+		return entry.path, c.releaser(entry), nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	tmp, err := os.CreateTemp(c.dir, buildPrefix+"*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create artifact: %w", err)
+	}
+	tmp.Close()
+	if err := build(tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another worker may have built the same program meanwhile
+	if entry, ok := c.lookup(key); ok {
+		os.Remove(tmp.Name())
+		entry.users++
+		c.touch(entry)
+		return entry.path, c.releaser(entry), nil
+	}
+
+	path = filepath.Join(c.dir, key)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, fmt.Errorf("failed to cache artifact: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to cache artifact: %w", err)
+	}
+	entry := c.add(key, info)
+	entry.used = time.Now()
+	entry.users = 1
+	c.evict()
+	return entry.path, c.releaser(entry), nil
+}
+
+// lookup finds the artifact for key. A persistent cache also picks up
+// artifacts other processes built, and forgets those they removed.
+// Callers must hold c.mu.
+func (c *CompileCache) lookup(key string) (*compileEntry, bool) {
+	entry, ok := c.entries[key]
+	if !c.persistent {
+		return entry, ok
+	}
+
+	info, err := os.Stat(filepath.Join(c.dir, key))
+	switch {
+	case ok && err != nil && entry.users == 0:
+		delete(c.entries, key)
+		c.bytes -= entry.size
+		return nil, false
+	case !ok && err == nil:
+		return c.add(key, info), true
+	}
+	return entry, ok
+}
+
+// touch marks entry used now, on disk too for a persistent cache so the
+// next run evicts in the same order. Callers must hold c.mu.
+func (c *CompileCache) touch(entry *compileEntry) {
+	entry.used = time.Now()
+	if c.persistent {
+		os.Chtimes(entry.path, entry.used, entry.used)
+	}
+}
+
+func (c *CompileCache) releaser(entry *compileEntry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			entry.users--
+			if entry.obsolete && entry.users == 0 {
+				os.Remove(entry.path)
+			}
+		})
+	}
+}
+
+// evict drops the least recently used artifacts over the size limits,
+// keeping at least the newest. Artifacts in use are removed from disk once
+// released. Callers must hold c.mu.
+func (c *CompileCache) evict() {
+	for len(c.entries) > c.size || (c.maxBytes > 0 && c.bytes > c.maxBytes && len(c.entries) > 1) {
+		var oldest string
+		for key, entry := range c.entries {
+			if oldest == "" || entry.used.Before(c.entries[oldest].used) {
+				oldest = key
+			}
+		}
+		entry := c.entries[oldest]
+		delete(c.entries, oldest)
+		c.bytes -= entry.size
+		entry.obsolete = true
+		if entry.users == 0 {
+			os.Remove(entry.path)
+		}
+	}
+}
+
+// Stats reports cache hits and misses
+func (c *CompileCache) Stats() CompileCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CompileCacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries), Bytes: c.bytes}
+}
+
+// Dir returns the directory holding the cache's artifacts
+func (c *CompileCache) Dir() string {
+	return c.dir
+}
+
+// Close removes the cache directory and every artifact in it, unless the
+// cache is persistent
+func (c *CompileCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*compileEntry)
+	c.bytes = 0
+	if c.persistent {
+		return nil
+	}
+	return os.RemoveAll(c.dir)
+}
+
+// CleanCompileCache deletes the persistent compile caches under dir,
+// reporting how many artifacts and bytes were removed
+func CleanCompileCache(dir string) (files int, bytes int64, err error) {
+	walkErr := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				files++
+				bytes += info.Size()
+			}
+		}
+		return nil
+	})
+	if os.IsNotExist(walkErr) {
+		return 0, 0, nil
+	}
+	if walkErr != nil {
+		return 0, 0, fmt.Errorf("failed to read compile cache: %w", walkErr)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return 0, 0, fmt.Errorf("failed to clean compile cache: %w", err)
+	}
+	return files, bytes, nil
+}
+
+// toolchains caches ToolchainVersion results by command line
+var toolchains sync.Map
+
+// ToolchainVersion returns the output of running a compiler with args,
+// such as "rustc --version", for keying artifacts of a persistent
+// CompileCache to the toolchain that built them. It is computed once per
+// process and is empty if the command fails.
+func ToolchainVersion(path string, args ...string) string {
+	key := strings.Join(append([]string{path}, args...), "\x00")
+	if version, ok := toolchains.Load(key); ok {
+		return version.(string)
+	}
+	output, err := exec.Command(path, args...).CombinedOutput()
+	version := ""
+	if err == nil {
+		version = strings.TrimSpace(string(output))
+	}
+	toolchains.Store(key, version)
+	return version
+}
+
+// SourceKey identifies a C-family program (Rust, C++, Zig) for
+// CompileCache. Comments are dropped and runs of spaces are collapsed, so
+// programs differing only in those share a key; string and character
+// literals are kept exactly. Every line is kept, so line numbers in
+// compiler messages and panics hold for all programs sharing a binary,
+// though columns may not. Sources the normalizer cannot
+// read with certainty, such as raw string literals, are keyed on their
+// exact text, as are those that embed their own line numbers. flags holds
+// whatever else affects the artifact, such as the compiler and its
+// arguments.
+func SourceKey(source string, flags ...string) string {
+	h := sha256.New()
+	for _, flag := range flags {
+		h.Write([]byte(flag))
+		h.Write([]byte{0})
+	}
+	if normalized, ok := normalizeSource(source); ok && !hasPositionMacros(source) {
+		h.Write([]byte("normalized\x00"))
+		h.Write([]byte(normalized))
+	} else {
+		h.Write([]byte("exact\x00"))
+		h.Write([]byte(source))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// positionMacros expand to where they appear in the source
+var positionMacros = []string{"__LINE__", "__COUNTER__", "line!", "column!", "@src("}
+
+func hasPositionMacros(source string) bool {
+	for _, macro := range positionMacros {
+		if strings.Contains(source, macro) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSource strips comments and redundant spaces from C-family
+// source. Newlines are kept, with comments left as empty lines, so every
+// program sharing a binary has the same line numbers in panics and
+// backtraces. It reports false for syntax it does not understand.
+func normalizeSource(src string) (string, bool) {
+	var out strings.Builder
+	space, lineStart := false, true
+	emit := func(s string) {
+		if space && !lineStart {
+			out.WriteByte(' ')
+		}
+		space, lineStart = false, false
+		out.WriteString(s)
+	}
+
+	for i := 0; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case ch == '\n':
+			out.WriteByte('\n')
+			space, lineStart = false, true
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\f' || ch == '\v':
+			space = true
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			// A trailing backslash continues a C++ comment onto the next line
+			if strings.HasSuffix(strings.TrimRight(src[i:i+end], "\r"), `\`) {
+				return "", false
+			}
+			i += end - 1
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return "", false
+			}
+			// Nested comments differ between languages
+			comment := src[i+2 : i+2+end]
+			if strings.Contains(comment, "/*") {
+				return "", false
+			}
+			if lines := strings.Count(comment, "\n"); lines > 0 {
+				out.WriteString(strings.Repeat("\n", lines))
+				lineStart = true
+			}
+			space = true
+			i += end + 3
+		case ch == '"':
+			end, ok := literalEnd(src, i, '"', len(src))
+			if !ok {
+				return "", false
+			}
+			emit(src[i : end+1])
+			i = end
+		case ch == '\'':
+			// Quotes that do not open a character literal mark Rust
+			// lifetimes or C++ digit separators
+			if end, ok := charLiteralEnd(src, i); ok {
+				emit(src[i : end+1])
+				i = end
+			} else {
+				emit("'")
+			}
+		case isRawStringPrefix(src, i):
+			return "", false
+		default:
+			emit(string(ch))
+		}
+	}
+	return out.String(), true
+}
+
+// literalEnd finds the quote closing the literal opened at start, looking
+// at most limit bytes ahead
+func literalEnd(src string, start int, quote byte, limit int) (int, bool) {
+	for i := start + 1; i < len(src) && i-start <= limit; i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// charLiteralEnd finds the end of a character literal opened at start:
+// one character, or an escape sequence, followed by a quote
+func charLiteralEnd(src string, start int) (int, bool) {
+	if start+1 >= len(src) {
+		return 0, false
+	}
+	if src[start+1] == '\\' {
+		end, ok := literalEnd(src, start, '\'', 12)
+		if !ok || strings.ContainsAny(src[start:end], "\n\"") {
+			return 0, false
+		}
+		return end, true
+	}
+	_, size := utf8.DecodeRuneInString(src[start+1:])
+	end := start + 1 + size
+	if src[start+1] == '\n' || end >= len(src) || src[end] != '\'' {
+		return 0, false
+	}
+	return end, true
+}
+
+// isRawStringPrefix reports whether src[i:] starts a raw string literal,
+// such as Rust's r#"..."# or C++'s R"(...)", or a Zig multiline string
+func isRawStringPrefix(src string, i int) bool {
+	if strings.HasPrefix(src[i:], `\\`) {
+		return true
+	}
+	if i > 0 && (isIdentByte(src[i-1])) {
+		return false
+	}
+	rest := strings.TrimLeft(src[i:], "bcuUL8")
+	if len(rest) == len(src[i:]) && src[i] != 'r' && src[i] != 'R' {
+		return false
+	}
+	if len(rest) == 0 || (rest[0] != 'r' && rest[0] != 'R') {
+		return false
+	}
+	rest = strings.TrimLeft(rest[1:], "#")
+	return strings.HasPrefix(rest, `"`)
+}
+
+func isIdentByte(ch byte) bool {
+	return ch == '_' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
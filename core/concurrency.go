@@ -0,0 +1,54 @@
+package core
+
+import "fmt"
+
+// ConcurrencyModel describes how a runtime's workers can run at once
+type ConcurrencyModel string
+
+const (
+	// ConcurrencyParallel runtimes run every worker in parallel, in its
+	// own process or thread, such as JVM threads and compiled binaries
+	ConcurrencyParallel ConcurrencyModel = "parallel"
+
+	// ConcurrencyPerState runtimes give each worker its own interpreter
+	// state, sharing nothing, so workers run in parallel in-process
+	ConcurrencyPerState ConcurrencyModel = "per_state"
+
+	// ConcurrencyGlobalLock runtimes share one interpreter lock between
+	// workers, such as CPython's GIL or Ruby's GVL: workers overlap while
+	// waiting on I/O, but CPU-bound code runs one worker at a time
+	ConcurrencyGlobalLock ConcurrencyModel = "global_lock"
+)
+
+// RuntimeCapabilities describes what a runtime can do, as declared by the
+// runtime itself
+type RuntimeCapabilities struct {
+	// Concurrency is how the runtime's workers can run at once
+	Concurrency ConcurrencyModel
+}
+
+// CapabilityReporter is implemented by runtimes that declare their
+// capabilities
+type CapabilityReporter interface {
+	Capabilities() RuntimeCapabilities
+}
+
+// CapabilitiesOf returns the capabilities rt declares, and false for
+// runtimes that declare none
+func CapabilitiesOf(rt Runtime) (RuntimeCapabilities, bool) {
+	reporter, ok := rt.(CapabilityReporter)
+	if !ok {
+		return RuntimeCapabilities{}, false
+	}
+	return reporter.Capabilities(), true
+}
+
+// concurrencyWarning explains why cfg's MaxConcurrency will not give the
+// parallelism it asks for under model, or returns "" when it will
+func concurrencyWarning(name string, model ConcurrencyModel, cfg *RuntimeConfig) string {
+	if model == ConcurrencyGlobalLock && cfg.MaxConcurrency > 1 && !cfg.SingleThreaded {
+		return fmt.Sprintf("%s workers share a global interpreter lock: MaxConcurrency %d overlaps I/O "+
+			"but will not run CPU-bound code in parallel", name, cfg.MaxConcurrency)
+	}
+	return ""
+}
@@ -21,8 +21,54 @@ type Config struct {
 
 	// Build configures compilation
 	Build BuildConfig
+
+	// AdaptiveTimeout derives default Call timeouts from each function's
+	// observed latency. Nil disables adaptive timeouts.
+	AdaptiveTimeout *AdaptiveTimeoutConfig
+
+	// CircuitBreaker rejects requests to a runtime after repeated
+	// failures, until a cooldown passes. Nil disables circuit breaking.
+	CircuitBreaker *CircuitBreakerConfig
+
+	// MaxFileDescriptors caps the file descriptors subprocess runtimes
+	// hold open for the programs they run. Starting a program that would
+	// exceed it fails with ErrDescriptorLimit. Zero counts descriptors,
+	// reported by Orchestrator.Descriptors, without a cap.
+	MaxFileDescriptors int
+
+	// InitConcurrency is how many runtimes Initialize starts at once.
+	// Zero uses DefaultInitConcurrency; 1 initializes them one at a time,
+	// for runtimes that cannot start alongside others.
+	InitConcurrency int
+
+	// StrictRuntimes makes initialization fail with ErrStubRuntime for
+	// any enabled runtime that is a stub because its build tag is absent,
+	// even one with a fallback, so deployments cannot ship without it
+	StrictRuntimes bool
+
+	// Quiet suppresses decorative output such as banners, for embedding
+	// the framework where stdout is captured. DefaultConfig sets it from
+	// POLYGLOT_QUIET.
+	Quiet bool
+
+	// Logger receives structured startup events, such as each runtime
+	// initializing. Nil discards them.
+	Logger Logger
+
+	// TraceArgs keeps each request's arguments in its trace, masked by
+	// the Redaction rules
+	TraceArgs bool
+
+	// Redaction masks sensitive arguments, such as passwords and tokens,
+	// in traces and in Request.RedactedArgs. Every rule that applies to a
+	// request is applied.
+	Redaction []RedactionRule
 }
 
+// DefaultInitConcurrency is the number of runtimes initialized at once
+// when Config.InitConcurrency is zero
+const DefaultInitConcurrency = 4
+
 // AppConfig holds application metadata
 type AppConfig struct {
 	// Name of the application
@@ -51,6 +97,10 @@ type MemoryConfig struct {
 
 	// GCInterval for memory cleanup
 	GCInterval time.Duration
+
+	// Trace publishes a TopicMemoryRegion event and logs a debug message
+	// for every region allocate, write, read and free
+	Trace bool
 }
 
 // WebviewConfig configures the frontend webview
@@ -72,8 +122,145 @@ type WebviewConfig struct {
 
 	// URL to load
 	URL string
+
+	// Transparent makes the window background transparent
+	Transparent bool
+
+	// Frameless removes the native title bar and borders
+	Frameless bool
+
+	// AlwaysOnTop keeps the window above other windows
+	AlwaysOnTop bool
+
+	// Fullscreen starts the window in fullscreen mode
+	Fullscreen bool
+
+	// Zoom is the initial page zoom factor, clamped to 0.25-5. Zero means
+	// 1, unzoomed.
+	Zoom float64
+
+	// RememberState saves the zoom level whenever it changes and restores
+	// it on the next launch, overriding Zoom
+	RememberState bool
+
+	// StateFile is where RememberState keeps the window's state. Empty
+	// uses window-state.json in a directory named after Title under the
+	// user's config directory.
+	StateFile string
+
+	// UserAgent overrides the default browser user agent
+	UserAgent string
+
+	// Serialization selects the bridge wire format ("json" or "msgpack").
+	// MessagePack falls back to JSON when the frontend lacks support. With
+	// Binary set to "transfer" it is posted as raw bytes, like frames.
+	Serialization string
+
+	// Numbers selects the bridge number policy ("float" or "integer").
+	// With "integer", integers stay integral instead of becoming float64.
+	Numbers string
+
+	// Times selects how time.Time values travel ("rfc3339" or "millis").
+	// With "millis", times become milliseconds since the Unix epoch.
+	Times string
+
+	// Nil selects what nil results become in JavaScript ("null" or
+	// "undefined"), including nils inside arrays and objects
+	Nil string
+
+	// Binary selects how calls carrying ArrayBuffers, typed arrays or
+	// []byte, and MessagePack calls, travel: "base64" (the default) sends
+	// them over the string binding; "transfer" posts them as raw bytes to
+	// an asset server serving the page, falling back to base64 elsewhere.
+	Binary string
+
+	// CaptureConsole forwards JavaScript console output to the Go logger
+	CaptureConsole bool
+
+	// MaxMessageBytes caps the size of bridge call arguments. Zero uses
+	// DefaultMaxMessageBytes; a negative value disables the limit.
+	MaxMessageBytes int
+
+	// MaxMessageDepth and MaxMessageTokens cap how deeply bridge
+	// arguments nest and how many values, map keys included, they hold.
+	// JSON payloads are rejected before they are decoded and MessagePack
+	// payloads as soon as decoding passes a limit.
+	// Zero uses DefaultMaxMessageDepth and DefaultMaxMessageTokens; a
+	// negative value disables the limit.
+	MaxMessageDepth  int
+	MaxMessageTokens int
+
+	// Arena reuses the argument slices and result buffers of bridge calls
+	// to cut allocations at high call rates. Bridge functions must not
+	// keep their args slice after returning.
+	Arena bool
+
+	// Retry configures automatic retries of window.polyglot.call for
+	// retriable error codes. Use window.polyglot.callOnce for calls that
+	// are not idempotent. Nil disables retries.
+	Retry *RetryPolicy
+
+	// Access restricts the bridge functions the window's page may call,
+	// for windows showing less trusted content. Nil allows every function.
+	Access *FunctionAccess
+
+	// BrowserFallback opens the page in the user's default browser when the
+	// native webview cannot be created, as on headless systems. The bridge
+	// is served over loopback HTTP; window controls are unavailable.
+	BrowserFallback bool
+
+	// HandlerPool runs bridge calls on up to this many goroutines instead
+	// of the webview's message loop, so a slow call blocks neither the
+	// window nor the calls made after it. Zero runs each call on the
+	// message loop, one at a time.
+	HandlerPool int
+
+	// HandlerOrder selects which pooled calls keep the order they were
+	// made in: "none" (the default) runs calls as soon as the pool has
+	// room, "function" runs calls to the same function one at a time in
+	// order, and "all" runs every call one at a time in order, still off
+	// the message loop
+	HandlerOrder string
+
+	// MaxConcurrentCalls bounds the bridge calls running at once, so a
+	// runaway page cannot exhaust the backend. Zero is unlimited.
+	MaxConcurrentCalls int
+
+	// CallOverflow selects what happens to calls made while
+	// MaxConcurrentCalls are running: "queue" (the default) waits for one
+	// to finish, and "reject" fails them at once with RESOURCE_EXHAUSTED
+	CallOverflow string
 }
 
+// DefaultMaxMessageBytes is the bridge argument size limit when unset
+const DefaultMaxMessageBytes = 4 * 1024 * 1024
+
+// DefaultMaxMessageDepth and DefaultMaxMessageTokens are the bridge
+// argument nesting and value limits when unset
+const (
+	DefaultMaxMessageDepth  = 128
+	DefaultMaxMessageTokens = 1000000
+)
+
+// Binary transports for WebviewConfig.Binary
+const (
+	BinaryBase64   = "base64"
+	BinaryTransfer = "transfer"
+)
+
+// Handler orders for WebviewConfig.HandlerOrder
+const (
+	HandlerOrderNone     = "none"
+	HandlerOrderFunction = "function"
+	HandlerOrderAll      = "all"
+)
+
+// Overflow policies for WebviewConfig.CallOverflow
+const (
+	CallOverflowQueue  = "queue"
+	CallOverflowReject = "reject"
+)
+
 // DefaultConfig returns a sensible default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -95,12 +282,17 @@ func DefaultConfig() *Config {
 			Resizable: true,
 			Debug:     false,
 			URL:       "http://localhost:3000",
+
+			MaxMessageBytes:  DefaultMaxMessageBytes,
+			MaxMessageDepth:  DefaultMaxMessageDepth,
+			MaxMessageTokens: DefaultMaxMessageTokens,
 		},
 		Build: BuildConfig{
 			OutputPath: "./dist",
 			Optimize:   true,
 			Compress:   false,
 		},
+		Quiet: QuietFromEnv(),
 	}
 }
 
@@ -118,6 +310,44 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("webview dimensions must be positive")
 	}
 
+	if c.Webview.Zoom < 0 {
+		return fmt.Errorf("webview zoom must not be negative")
+	}
+
+	if c.Webview.HandlerPool < 0 {
+		return fmt.Errorf("webview handler pool must not be negative")
+	}
+
+	switch c.Webview.HandlerOrder {
+	case "", HandlerOrderNone, HandlerOrderFunction, HandlerOrderAll:
+	default:
+		return fmt.Errorf("unknown webview handler order %q", c.Webview.HandlerOrder)
+	}
+
+	if c.Webview.MaxConcurrentCalls < 0 {
+		return fmt.Errorf("webview max concurrent calls must not be negative")
+	}
+
+	switch c.Webview.CallOverflow {
+	case "", CallOverflowQueue, CallOverflowReject:
+	default:
+		return fmt.Errorf("unknown webview call overflow policy %q", c.Webview.CallOverflow)
+	}
+
+	if c.InitConcurrency < 0 {
+		return fmt.Errorf("init concurrency must not be negative")
+	}
+
+	if c.MaxFileDescriptors < 0 {
+		return fmt.Errorf("max file descriptors must not be negative")
+	}
+
+	for i, rule := range c.Redaction {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("redaction rule %d: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// ErrDescriptorLimit is returned when starting a subprocess would take the
+// file descriptors held for runtime subprocesses past their cap
+var ErrDescriptorLimit = NewError(CodeResourceExhausted, "file descriptor limit reached")
+
+// DescriptorTracker counts the file descriptors this process holds open
+// for the programs subprocess runtimes run, and optionally caps them, so
+// apps running many programs at once fail with a clear error instead of
+// running out of descriptors for everything else
+type DescriptorTracker struct {
+	limit     int
+	open      int
+	peak      int
+	refused   uint64
+	byRuntime map[string]int
+	mu        sync.Mutex
+}
+
+// DescriptorStats reports descriptor usage
+type DescriptorStats struct {
+	// Open is the number of descriptors held for running subprocesses,
+	// in total and per runtime, and Peak the most held at once
+	Open      int            `json:"open"`
+	Peak      int            `json:"peak"`
+	ByRuntime map[string]int `json:"by_runtime"`
+
+	// Limit is the cap on Open, or zero when uncapped
+	Limit int `json:"limit"`
+
+	// Refused counts subprocesses not started because of the cap
+	Refused uint64 `json:"refused"`
+
+	// Process is the number of descriptors open in the whole process and
+	// ProcessLimit its soft RLIMIT_NOFILE. Reported on Linux only.
+	Process      int    `json:"process,omitempty"`
+	ProcessLimit uint64 `json:"process_limit,omitempty"`
+}
+
+// NewDescriptorTracker creates a tracker capping descriptors at limit.
+// Zero or less counts them without a cap.
+func NewDescriptorTracker(limit int) *DescriptorTracker {
+	if limit < 0 {
+		limit = 0
+	}
+	return &DescriptorTracker{limit: limit, byRuntime: make(map[string]int)}
+}
+
+// Acquire reserves n descriptors for a subprocess of runtime, failing with
+// ErrDescriptorLimit if that would exceed the cap. Call release once the
+// subprocess has exited.
+func (t *DescriptorTracker) Acquire(runtime string, n int) (release func(), err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limit > 0 && t.open+n > t.limit {
+		t.refused++
+		return nil, Errorf(CodeResourceExhausted, "%w: starting a %s process needs %d descriptors, %d of %d in use",
+			ErrDescriptorLimit, runtime, n, t.open, t.limit).
+			WithDetail("runtime", runtime).
+			WithDetail("limit", t.limit)
+	}
+
+	t.open += n
+	t.byRuntime[runtime] += n
+	if t.open > t.peak {
+		t.peak = t.open
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.open -= n
+			if t.byRuntime[runtime] -= n; t.byRuntime[runtime] <= 0 {
+				delete(t.byRuntime, runtime)
+			}
+		})
+	}, nil
+}
+
+// Stats returns the current descriptor usage
+func (t *DescriptorTracker) Stats() DescriptorStats {
+	t.mu.Lock()
+	stats := DescriptorStats{
+		Open:      t.open,
+		Peak:      t.peak,
+		ByRuntime: make(map[string]int, len(t.byRuntime)),
+		Limit:     t.limit,
+		Refused:   t.refused,
+	}
+	for runtime, n := range t.byRuntime {
+		stats.ByRuntime[runtime] = n
+	}
+	t.mu.Unlock()
+
+	stats.Process, stats.ProcessLimit = processDescriptors()
+	return stats
+}
+
+// descriptorsFor estimates the descriptors starting cmd opens in this
+// process: both ends of a pipe for each standard stream that is not a
+// file, and /dev/null for each that is unset
+func descriptorsFor(cmd *exec.Cmd) int {
+	n := 0
+	for _, stream := range []interface{}{cmd.Stdin, cmd.Stdout, cmd.Stderr} {
+		switch stream.(type) {
+		case *os.File:
+		case nil:
+			n++
+		default:
+			n += 2
+		}
+	}
+	return n
+}
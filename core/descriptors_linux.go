@@ -0,0 +1,23 @@
+//go:build linux
+// +build linux
+
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+// processDescriptors counts the descriptors open in this process and
+// returns its soft RLIMIT_NOFILE
+func processDescriptors() (open int, limit uint64) {
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		// Reading the directory holds one descriptor of its own
+		open = len(entries) - 1
+	}
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err == nil {
+		limit = rlimit.Cur
+	}
+	return open, limit
+}
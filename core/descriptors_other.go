@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package core
+
+// processDescriptors reports nothing; process-wide descriptor counts are
+// read on Linux only
+func processDescriptors() (open int, limit uint64) {
+	return 0, 0
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// languageExtensions maps file extensions to runtime names
+var languageExtensions = map[string]string{
+	".py":   "python",
+	".pyw":  "python",
+	".js":   "javascript",
+	".mjs":  "javascript",
+	".cjs":  "javascript",
+	".go":   "go",
+	".php":  "php",
+	".rb":   "ruby",
+	".lua":  "lua",
+	".zig":  "zig",
+	".wasm": "wasm",
+	".wat":  "wasm",
+	".rs":   "rust",
+	".java": "java",
+	".cpp":  "cpp",
+	".cc":   "cpp",
+	".cxx":  "cpp",
+	".hpp":  "cpp",
+	".hh":   "cpp",
+	".h":    "cpp",
+}
+
+// shebangInterpreters maps interpreter name prefixes on a #! line to
+// runtime names
+var shebangInterpreters = []struct {
+	prefix  string
+	runtime string
+}{
+	{"python", "python"},
+	{"node", "javascript"},
+	{"ruby", "ruby"},
+	{"lua", "lua"},
+	{"php", "php"},
+}
+
+// languageSignal is a pattern suggesting a language, with its weight
+type languageSignal struct {
+	pattern *regexp.Regexp
+	weight  int
+}
+
+func signal(pattern string, weight int) languageSignal {
+	return languageSignal{regexp.MustCompile(`(?m)` + pattern), weight}
+}
+
+// languageSignals holds the content heuristics of each language. Strong
+// signals are unique to the language; weak ones are shared by a few.
+var languageSignals = map[string][]languageSignal{
+	"python": {
+		signal(`^\s*def \w+\(.*\)\s*(->.*)?:\s*$`, 3),
+		signal(`^\s*from [\w.]+ import `, 3),
+		signal(`^\s*(if|elif|for|while|with|class) .*:\s*$`, 2),
+		signal(`^\s*import [\w.]+\s*$`, 1),
+		signal(`\bprint\(`, 1),
+		signal(`\bNone\b|\bTrue\b|\bFalse\b`, 1),
+	},
+	"ruby": {
+		signal(`^\s*def \w+[!?]?(\(.*\))?\s*$`, 2),
+		signal(`^\s*require(_relative)? ['"]`, 3),
+		signal(`\bdo \|[\w, ]+\|`, 3),
+		signal(`^\s*puts `, 2),
+		signal(`^\s*end\s*$`, 1),
+		signal(`\.each\b|\.map\b`, 1),
+	},
+	"lua": {
+		signal(`^\s*local \w+`, 2),
+		signal(`^\s*(local )?function [\w.:]+\(.*\)\s*$`, 2),
+		signal(`\bthen\s*$`, 2),
+		signal(`~=`, 3),
+		signal(`^\s*--[^-]`, 1),
+		signal(`^\s*end\s*$`, 1),
+	},
+	"javascript": {
+		signal(`\bconsole\.log\(`, 3),
+		signal(`^\s*(const|let|var) \w+\s*=`, 2),
+		signal(`=>`, 1),
+		signal(`\bfunction\s*\w*\s*\(.*\)\s*\{`, 2),
+		signal(`\brequire\(|\bexport (default|const|function)\b`, 2),
+		signal(`===|!==`, 2),
+	},
+	"go": {
+		signal(`^package \w+\s*$`, 3),
+		signal(`^func (\(\w+ \*?\w+\) )?\w+\(`, 3),
+		signal(`:=`, 1),
+		signal(`\bfmt\.\w+\(`, 2),
+	},
+	"rust": {
+		signal(`^\s*(pub )?fn \w+`, 2),
+		signal(`\blet mut\b`, 3),
+		signal(`\w+!\(`, 2),
+		signal(`^\s*use \w+(::\w+)+`, 3),
+		signal(`^\s*impl\b`, 3),
+	},
+	"java": {
+		signal(`\bpublic (static )?(final )?class\b`, 3),
+		signal(`\bSystem\.out\.print`, 3),
+		signal(`\bpublic static void main\(`, 3),
+		signal(`^\s*import java\.`, 3),
+	},
+	"cpp": {
+		signal(`^\s*#include\s*[<"]`, 3),
+		signal(`\bstd::`, 3),
+		signal(`\bint main\(`, 2),
+		signal(`^\s*template\s*<`, 3),
+	},
+	"zig": {
+		signal(`@import\("std"\)`, 3),
+		signal(`^\s*pub fn \w+\(.*\)\s*!?\w*\s*\{`, 2),
+		signal(`\bcomptime\b`, 3),
+	},
+	"php": {
+		signal(`\$\w+\s*=`, 2),
+		signal(`^\s*echo `, 2),
+		signal(`->\w+\(`, 1),
+	},
+}
+
+// wasmMagic starts every binary WebAssembly module
+var wasmMagic = []byte("\x00asm")
+
+// DetectLanguage picks the runtime for a source file from its extension,
+// falling back to its #! line and content when the extension is missing
+// or unknown. It returns a CodeInvalidArgument error when no language
+// matches or several match equally well; the error's "candidates" detail
+// lists the tied runtimes.
+func DetectLanguage(filename string, content []byte) (string, error) {
+	if runtime, ok := languageExtensions[strings.ToLower(filepath.Ext(filename))]; ok {
+		return runtime, nil
+	}
+
+	if bytes.HasPrefix(content, wasmMagic) {
+		return "wasm", nil
+	}
+	if runtime, ok := shebangLanguage(content); ok {
+		return runtime, nil
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<?php")) {
+		return "php", nil
+	}
+
+	scores := make(map[string]int)
+	best := 0
+	for runtime, signals := range languageSignals {
+		for _, s := range signals {
+			if s.pattern.Match(content) {
+				scores[runtime] += s.weight
+			}
+		}
+		if scores[runtime] > best {
+			best = scores[runtime]
+		}
+	}
+	if best == 0 {
+		return "", Errorf(CodeInvalidArgument, "cannot detect the language of %s", displayName(filename))
+	}
+
+	var candidates []string
+	for runtime, score := range scores {
+		if score == best {
+			candidates = append(candidates, runtime)
+		}
+	}
+	if len(candidates) > 1 {
+		sort.Strings(candidates)
+		return "", Errorf(CodeInvalidArgument, "language of %s is ambiguous: could be %s",
+			displayName(filename), strings.Join(candidates, " or ")).
+			WithDetail("candidates", candidates)
+	}
+	return candidates[0], nil
+}
+
+// shebangLanguage reads the interpreter from a #! line, including
+// "#!/usr/bin/env python3" forms
+func shebangLanguage(content []byte) (string, bool) {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return "", false
+	}
+	line, _, _ := bytes.Cut(content[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	for _, s := range shebangInterpreters {
+		if strings.HasPrefix(interpreter, s.prefix) {
+			return s.runtime, true
+		}
+	}
+	return "", false
+}
+
+func displayName(filename string) string {
+	if filename == "" {
+		return "input"
+	}
+	return filename
+}
@@ -0,0 +1,35 @@
+package core
+
+import "time"
+
+// DefaultDeterministicTime is the time clocks report in deterministic mode
+// when the config does not set one
+var DefaultDeterministicTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Determinism is what a runtime fixes in deterministic mode
+type Determinism struct {
+	// Seed for random number generators
+	Seed int64
+
+	// Time every clock reports
+	Time time.Time
+}
+
+// DeterminismFor returns the determinism a runtime applies, and false when
+// config does not enable deterministic mode
+func DeterminismFor(config RuntimeConfig) (Determinism, bool) {
+	if !config.Deterministic {
+		return Determinism{}, false
+	}
+	d := Determinism{Seed: config.DeterministicSeed, Time: config.DeterministicTime}
+	if d.Time.IsZero() {
+		d.Time = DefaultDeterministicTime
+	}
+	return d, true
+}
+
+// UnixSeconds returns the time in seconds since the Unix epoch, with a
+// fractional part
+func (d Determinism) UnixSeconds() float64 {
+	return float64(d.Time.UnixNano()) / 1e9
+}
@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Limits on how much of a value Describe expands. Collections past them
+// are summarized and marked Truncated.
+const (
+	DisplayMaxDepth   = 8
+	DisplayMaxEntries = 100
+)
+
+// Display types, reported in Display.Type
+const (
+	DisplayNull    = "null"
+	DisplayBoolean = "boolean"
+	DisplayNumber  = "number"
+	DisplayString  = "string"
+	DisplayBytes   = "bytes"
+	DisplayList    = "list"
+	DisplayMap     = "map"
+)
+
+// Display describes a result for presentation, the same way whichever
+// runtime produced it, so one frontend viewer can render any result: a
+// primitive as its text, a list or map as a collapsible tree of entries.
+type Display struct {
+	// Type is one of the Display* types
+	Type string `json:"type"`
+
+	// Text is the value formatted on one line. Lists and maps are
+	// summarized, as in "list (3 items)".
+	Text string `json:"text"`
+
+	// Length is the number of items of a list or map, or of bytes
+	Length int `json:"length,omitempty"`
+
+	// Entries are the items of a list, keyed by index, or of a map, sorted
+	// by key
+	Entries []DisplayEntry `json:"entries,omitempty"`
+
+	// Truncated reports that entries were left out for exceeding
+	// DisplayMaxEntries or DisplayMaxDepth
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DisplayEntry is one item of a list or map
+type DisplayEntry struct {
+	Key   string  `json:"key"`
+	Value Display `json:"value"`
+}
+
+// Displayed is a result together with its Display
+type Displayed struct {
+	Value   interface{} `json:"value"`
+	Display Display     `json:"display"`
+
+	// Ref identifies the full value of a truncated result held back in a
+	// ResultCache, in which case Value is nil
+	Ref string `json:"ref,omitempty"`
+}
+
+// DisplayOptions bounds the Display of results, so large results do not
+// freeze the page rendering them
+type DisplayOptions struct {
+	// MaxEntries caps the items shown of each list or map. Zero uses
+	// DisplayMaxEntries.
+	MaxEntries int
+
+	// MaxDepth caps how deep collections are expanded. Zero uses
+	// DisplayMaxDepth.
+	MaxDepth int
+
+	// Results holds back the full value of results whose Display is
+	// truncated: they are sent with a Ref instead, and the frontend
+	// fetches the value on request through Results.Fetch. Nil always
+	// sends the full value.
+	Results *ResultCache
+}
+
+// WithDisplay wraps a bridge function so its results reach the frontend
+// as {value, display} objects, with display built by Describe:
+//
+//	bridge.Register("pythonStatistics", core.WithDisplay(pythonStatistics))
+func WithDisplay(fn BridgeFunc) BridgeFunc {
+	return WithDisplayOptions(fn, DisplayOptions{})
+}
+
+// WithDisplayOptions is WithDisplay with bounds on the display. With
+// opts.Results set, truncated results arrive as {value: null, display,
+// ref} and the page loads the full value only when asked:
+//
+//	results := core.NewResultCache(0)
+//	bridge.Register("fullResult", results.Fetch)
+//	bridge.Register("processList", core.WithDisplayOptions(processList, core.DisplayOptions{MaxEntries: 100, Results: results}))
+func WithDisplayOptions(fn BridgeFunc, opts DisplayOptions) BridgeFunc {
+	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		result, err := fn(ctx, args...)
+		if err != nil {
+			return nil, err
+		}
+		display := DescribeWith(result, opts)
+		if display.Truncated && opts.Results != nil {
+			return Displayed{Display: display, Ref: opts.Results.Put(result)}, nil
+		}
+		return Displayed{Value: result, Display: display}, nil
+	}
+}
+
+// Describe builds the Display of a value. Structs and other values with
+// their own JSON encoding are described as they would be serialized.
+func Describe(value interface{}) Display {
+	return DescribeWith(value, DisplayOptions{})
+}
+
+// DescribeWith is Describe within the bounds of opts. Collections with
+// entries left out say so in their Text, as in "list (10000 items,
+// showing 100)".
+func DescribeWith(value interface{}, opts DisplayOptions) Display {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DisplayMaxEntries
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DisplayMaxDepth
+	}
+	return describer{opts}.describe(reflect.ValueOf(value), 0)
+}
+
+// describer builds Displays within the bounds of its options
+type describer struct {
+	DisplayOptions
+}
+
+func (b describer) describe(v reflect.Value, depth int) Display {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return Display{Type: DisplayNull, Text: "null"}
+		}
+		if _, ok := v.Interface().(json.Marshaler); ok && v.Kind() == reflect.Ptr {
+			return b.describeJSON(v, depth)
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return Display{Type: DisplayNull, Text: "null"}
+	}
+	if v.CanInterface() {
+		if _, ok := v.Interface().(json.Marshaler); ok {
+			return b.describeJSON(v, depth)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return Display{Type: DisplayBoolean, Text: strconv.FormatBool(v.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Display{Type: DisplayNumber, Text: strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Display{Type: DisplayNumber, Text: strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return Display{Type: DisplayNumber, Text: strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())}
+	case reflect.String:
+		return Display{Type: DisplayString, Text: v.String(), Length: len([]rune(v.String()))}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return Display{Type: DisplayBytes, Text: fmt.Sprintf("%d bytes", v.Len()), Length: v.Len()}
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return Display{Type: DisplayNull, Text: "null"}
+		}
+		d := b.collection(DisplayList, v.Len(), depth)
+		for i := 0; i < v.Len() && !d.Truncated; i++ {
+			if len(d.Entries) == b.MaxEntries {
+				b.showing(&d)
+				break
+			}
+			d.Entries = append(d.Entries, DisplayEntry{Key: strconv.Itoa(i), Value: b.describe(v.Index(i), depth+1)})
+		}
+		return d
+	case reflect.Map:
+		if v.IsNil() {
+			return Display{Type: DisplayNull, Text: "null"}
+		}
+		d := b.collection(DisplayMap, v.Len(), depth)
+		if d.Truncated {
+			return d
+		}
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for _, key := range v.MapKeys() {
+			name := fmt.Sprint(key.Interface())
+			keys = append(keys, name)
+			values[name] = v.MapIndex(key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if len(d.Entries) == b.MaxEntries {
+				b.showing(&d)
+				break
+			}
+			d.Entries = append(d.Entries, DisplayEntry{Key: key, Value: b.describe(values[key], depth+1)})
+		}
+		return d
+	case reflect.Struct:
+		return b.describeJSON(v, depth)
+	}
+	return Display{Type: DisplayString, Text: fmt.Sprint(v.Interface())}
+}
+
+// collection starts the Display of a list or map of n items, truncated
+// when it is nested too deep to expand
+func (b describer) collection(kind string, n, depth int) Display {
+	unit := "items"
+	if n == 1 {
+		unit = "item"
+	}
+	d := Display{Type: kind, Text: fmt.Sprintf("%s (%d %s)", kind, n, unit), Length: n}
+	if depth >= b.MaxDepth && n > 0 {
+		d.Truncated = true
+	}
+	return d
+}
+
+// showing marks a collection whose entries stopped at MaxEntries
+func (b describer) showing(d *Display) {
+	d.Truncated = true
+	d.Text = strings.TrimSuffix(d.Text, ")") + fmt.Sprintf(", showing %d)", len(d.Entries))
+}
+
+// describeJSON describes a value by its JSON encoding
+func (b describer) describeJSON(v reflect.Value, depth int) Display {
+	if !v.CanInterface() {
+		return Display{Type: DisplayString, Text: v.Type().String()}
+	}
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return Display{Type: DisplayString, Text: fmt.Sprint(v.Interface())}
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return Display{Type: DisplayString, Text: string(encoded)}
+	}
+	return b.describe(reflect.ValueOf(generic), depth)
+}
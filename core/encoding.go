@@ -0,0 +1,42 @@
+package core
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// OutputEncoding selects how subprocess runtimes handle output that is not
+// valid UTF-8, such as binary data or text in a legacy locale encoding
+type OutputEncoding string
+
+const (
+	// OutputReplace replaces invalid byte sequences with U+FFFD, so results
+	// are always valid strings. It is the default.
+	OutputReplace OutputEncoding = "replace"
+
+	// OutputRaw returns invalid output byte for byte as RawOutput
+	OutputRaw OutputEncoding = "raw"
+)
+
+// RawOutput is subprocess output that is not valid UTF-8, returned
+// unmodified under OutputRaw. Its type tells callers the result is bytes
+// rather than text.
+type RawOutput []byte
+
+// String returns the output with invalid sequences replaced by U+FFFD
+func (r RawOutput) String() string {
+	return strings.ToValidUTF8(string(r), "�")
+}
+
+// DecodeOutput validates subprocess output. Valid UTF-8 is returned as
+// text. Invalid output is returned as raw under OutputRaw, and otherwise
+// as text with invalid sequences replaced.
+func DecodeOutput(output []byte, encoding OutputEncoding) (text string, raw RawOutput) {
+	if utf8.Valid(output) {
+		return string(output), nil
+	}
+	if encoding == OutputRaw {
+		return "", RawOutput(append([]byte(nil), output...))
+	}
+	return strings.ToValidUTF8(string(output), "�"), nil
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Enumerated is implemented by string types limited to a fixed set of
+// values, such as a task status. Arguments of RegisterTyped functions are
+// checked against it, including struct fields, slice elements and map
+// values, and Manifest lists the values for frontend tooling. The method
+// must have a value receiver.
+//
+//	type Status string
+//
+//	func (Status) EnumValues() []string { return []string{"todo", "doing", "done"} }
+type Enumerated interface {
+	EnumValues() []string
+}
+
+var enumeratedType = reflect.TypeOf((*Enumerated)(nil)).Elem()
+
+// Enum validates string arguments of functions added with Register, which
+// cannot declare Enumerated parameter types
+type Enum struct {
+	name   string
+	values []string
+}
+
+// NewEnum creates an enum named name, as it appears in errors
+func NewEnum(name string, values ...string) *Enum {
+	return &Enum{name: name, values: append([]string(nil), values...)}
+}
+
+// Values returns the allowed values in declaration order
+func (e *Enum) Values() []string {
+	return append([]string(nil), e.values...)
+}
+
+// Contains reports whether value is allowed
+func (e *Enum) Contains(value string) bool {
+	return containsString(e.values, value)
+}
+
+// Parse returns arg as an allowed value, or a CodeInvalidArgument error
+// whose details name the field, the rejected value and the allowed values
+func (e *Enum) Parse(arg interface{}) (string, error) {
+	value, ok := arg.(string)
+	if !ok {
+		return "", Errorf(CodeInvalidArgument, "%s must be a string, got %T", e.name, arg).
+			WithDetail("field", e.name).
+			WithDetail("allowed", e.Values())
+	}
+	if !e.Contains(value) {
+		return "", enumError(e.name, value, e.values)
+	}
+	return value, nil
+}
+
+func enumError(field, value string, allowed []string) *Error {
+	quoted := make([]string, len(allowed))
+	for i, v := range allowed {
+		quoted[i] = strconv.Quote(v)
+	}
+	subject := "value"
+	if field != "" {
+		subject = field
+	}
+	err := Errorf(CodeInvalidArgument, "invalid %s %q: must be one of %s", subject, value, strings.Join(quoted, ", "))
+	if field != "" {
+		err.WithDetail("field", field)
+	}
+	return err.
+		WithDetail("value", value).
+		WithDetail("allowed", append([]string(nil), allowed...))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// enumValues returns the values of an Enumerated string type
+func enumValues(t reflect.Type) ([]string, bool) {
+	if t.Kind() != reflect.String || !t.Implements(enumeratedType) {
+		return nil, false
+	}
+	return reflect.Zero(t).Interface().(Enumerated).EnumValues(), true
+}
+
+// enumTypes caches whether a type holds Enumerated values anywhere
+var enumTypes sync.Map
+
+// hasEnums reports whether values of t can hold Enumerated values, so
+// arguments without any skip validation
+func hasEnums(t reflect.Type) bool {
+	if cached, ok := enumTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	found := findEnums(t, make(map[reflect.Type]bool))
+	enumTypes.Store(t, found)
+	return found
+}
+
+// findEnums searches t for Enumerated types. seen holds the types being
+// searched, so recursive types end the search.
+func findEnums(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.String:
+		return t.Implements(enumeratedType)
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return findEnums(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() && findEnums(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateEnums checks every Enumerated value in v. field names v in
+// errors; struct fields are named as encoding/json names them. Empty
+// strings are allowed in omitempty fields, where they mean unset.
+func validateEnums(v reflect.Value, field string) error {
+	if !v.IsValid() || !hasEnums(v.Type()) {
+		return nil
+	}
+
+	if allowed, ok := enumValues(v.Type()); ok {
+		if !containsString(allowed, v.String()) {
+			return enumError(field, v.String(), allowed)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return validateEnums(v.Elem(), field)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateEnums(v.Index(i), fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateEnums(iter.Value(), fmt.Sprintf("%s[%v]", field, iter.Key())); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			tag := f.Tag.Get("json")
+			if !f.IsExported() || tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = f.Name
+			}
+			fv := v.Field(i)
+			if fv.Kind() == reflect.String && fv.Len() == 0 && strings.Contains(","+opts+",", ",omitempty,") {
+				continue
+			}
+			if f.Anonymous && tag == "" {
+				name = field
+			} else if field != "" {
+				name = field + "." + name
+			}
+			if err := validateEnums(fv, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
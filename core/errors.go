@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies errors returned across the bridge
+type ErrorCode string
+
+const (
+	CodeInternal          ErrorCode = "INTERNAL"
+	CodeNotFound          ErrorCode = "NOT_FOUND"
+	CodeInvalidArgument   ErrorCode = "INVALID_ARGUMENT"
+	CodeUnauthorized      ErrorCode = "UNAUTHORIZED"
+	CodeForbidden         ErrorCode = "FORBIDDEN"
+	CodeTimeout           ErrorCode = "TIMEOUT"
+	CodeCanceled          ErrorCode = "CANCELED"
+	CodeUnavailable       ErrorCode = "UNAVAILABLE"
+	CodePolicyViolation   ErrorCode = "POLICY_VIOLATION"
+	CodeTooLarge          ErrorCode = "TOO_LARGE"
+	CodeResourceExhausted ErrorCode = "RESOURCE_EXHAUSTED"
+	CodeScriptError       ErrorCode = "SCRIPT_ERROR"
+)
+
+// Error is a typed error carrying a code the frontend can branch on
+type Error struct {
+	// Code classifies the error
+	Code ErrorCode
+
+	// Message is a human readable description
+	Message string
+
+	// Details holds optional structured context
+	Details map[string]interface{}
+
+	// Err is the underlying cause, if any
+	Err error
+}
+
+// NewError creates a typed error
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Errorf creates a typed error with a formatted message. A %w verb sets the
+// underlying cause.
+func Errorf(code ErrorCode, format string, args ...interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// WithDetail adds a detail entry and returns the error
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorInfo is the structured form of an error sent to the frontend
+type ErrorInfo struct {
+	Code    ErrorCode              `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// ErrorInfoFor derives the structured form of any error. Untyped errors
+// are reported as INTERNAL.
+func ErrorInfoFor(err error) ErrorInfo {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return ErrorInfo{Code: typed.Code, Message: err.Error(), Details: typed.Details}
+	}
+
+	var script *ScriptError
+	if errors.As(err, &script) {
+		details := map[string]interface{}{
+			"runtime":   script.Runtime,
+			"type":      script.Type,
+			"message":   script.Message,
+			"traceback": script.Traceback,
+		}
+		if script.Frame != nil {
+			details["frame"] = *script.Frame
+		}
+		return ErrorInfo{Code: CodeScriptError, Message: script.Summary(), Details: details}
+	}
+
+	var policy *PolicyViolationError
+	if errors.As(err, &policy) {
+		return ErrorInfo{
+			Code:    CodePolicyViolation,
+			Message: err.Error(),
+			Details: map[string]interface{}{
+				"runtime": policy.Runtime,
+				"rule":    policy.Rule,
+			},
+		}
+	}
+
+	var exceeded *ResourceExceededError
+	if errors.As(err, &exceeded) {
+		return ErrorInfo{
+			Code:    CodeResourceExhausted,
+			Message: err.Error(),
+			Details: map[string]interface{}{
+				"runtime":  exceeded.Runtime,
+				"resource": exceeded.Resource,
+			},
+		}
+	}
+
+	var crashed *RuntimeCrashedError
+	if errors.As(err, &crashed) {
+		code := CodeInternal
+		if crashed.OutOfMemory {
+			code = CodeResourceExhausted
+		}
+		details := map[string]interface{}{
+			"runtime":   crashed.Runtime,
+			"exit_code": crashed.ExitCode,
+		}
+		if crashed.Signal != 0 {
+			details["signal"] = crashed.Signal.String()
+		}
+		return ErrorInfo{Code: code, Message: err.Error(), Details: details}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorInfo{Code: CodeTimeout, Message: err.Error()}
+	case errors.Is(err, context.Canceled):
+		return ErrorInfo{Code: CodeCanceled, Message: err.Error()}
+	}
+
+	return ErrorInfo{Code: CodeInternal, Message: err.Error()}
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event topics published by the orchestrator
+const (
+	TopicExecutionStuck = "execution.stuck"
+	TopicSlowCall       = "call.slow"
+	TopicCircuitChange  = "circuit.change"
+	TopicPoolScale      = "pool.scale"
+	TopicMemoryRegion   = "memory.region"
+)
+
+// Event is a notification published on the event bus
+type Event struct {
+	// Topic identifies the kind of event
+	Topic string
+
+	// Timestamp of publication
+	Timestamp time.Time
+
+	// Data carries the event payload
+	Data interface{}
+}
+
+// EventOverflow selects what a subscription does with an event published
+// while its buffer is full
+type EventOverflow string
+
+const (
+	// EventDropNewest drops the new event, keeping those already
+	// buffered. This is the default.
+	EventDropNewest EventOverflow = "drop_newest"
+
+	// EventDropOldest evicts the oldest buffered event to make room, so
+	// a slow subscriber sees the most recent events
+	EventDropOldest EventOverflow = "drop_oldest"
+
+	// EventBlock makes Publish wait for room, up to the subscription's
+	// BlockTimeout, so no event is lost to a subscriber that keeps up
+	// eventually. It stalls publishers while the subscriber is behind.
+	EventBlock EventOverflow = "block"
+)
+
+// SubscribeOptions configures a subscription's buffer
+type SubscribeOptions struct {
+	// Buffer is how many undelivered events the subscription holds.
+	// Values below 1 use 1.
+	Buffer int
+
+	// Overflow selects what happens to events published while the buffer
+	// is full; empty uses EventDropNewest
+	Overflow EventOverflow
+
+	// BlockTimeout bounds how long Publish waits for room under
+	// EventBlock before dropping the event. Zero waits until there is
+	// room or the subscription is canceled.
+	BlockTimeout time.Duration
+}
+
+// Subscription receives the events published on a topic
+type Subscription struct {
+	// C delivers the events. It is closed by Cancel.
+	C <-chan Event
+
+	ch      chan Event
+	options SubscribeOptions
+	dropped int64
+	done    chan struct{}
+	closed  bool
+	remove  func()
+	cancel  sync.Once
+	mu      sync.Mutex
+}
+
+// Cancel ends the subscription and closes C. It is safe to call more
+// than once.
+func (s *Subscription) Cancel() {
+	s.cancel.Do(func() {
+		s.remove()
+		s.close()
+	})
+}
+
+// Dropped returns the number of events this subscription lost to a full
+// buffer, including those evicted under EventDropOldest
+func (s *Subscription) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// deliver sends event to the subscription following its overflow policy,
+// reporting whether an event was dropped
+func (s *Subscription) deliver(event Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+
+	select {
+	case s.ch <- event:
+		return false
+	default:
+	}
+
+	switch s.options.Overflow {
+	case EventDropOldest:
+		// The subscriber may have drained the buffer meanwhile, in which
+		// case nothing is evicted. Publishers hold s.mu, so the send
+		// cannot block either way.
+		evicted := false
+		select {
+		case <-s.ch:
+			evicted = true
+		default:
+		}
+		s.ch <- event
+		if !evicted {
+			return false
+		}
+	case EventBlock:
+		var timeout <-chan time.Time
+		if s.options.BlockTimeout > 0 {
+			timer := time.NewTimer(s.options.BlockTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case s.ch <- event:
+			return false
+		case <-s.done:
+			return false
+		case <-timeout:
+		}
+	}
+	atomic.AddInt64(&s.dropped, 1)
+	return true
+}
+
+// close ends the subscription, waking a Publish blocked on it
+func (s *Subscription) close() {
+	close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
+
+// EventBus delivers events to topic subscribers. By default publishing
+// never blocks: events for a subscriber whose buffer is full are dropped.
+// SubscribeWith selects another overflow policy per subscription.
+type EventBus struct {
+	mu      sync.RWMutex
+	subs    map[string]map[int]*Subscription
+	nextID  int
+	dropped int64
+}
+
+// NewEventBus creates an empty event bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subs: make(map[string]map[int]*Subscription),
+	}
+}
+
+// Subscribe returns a channel receiving events for a topic and a function
+// that cancels the subscription and closes the channel. Events published
+// while the buffer is full are dropped.
+func (b *EventBus) Subscribe(topic string, buffer int) (<-chan Event, func()) {
+	sub := b.SubscribeWith(topic, SubscribeOptions{Buffer: buffer})
+	return sub.C, sub.Cancel
+}
+
+// SubscribeWith subscribes to a topic with a buffer size and overflow
+// policy
+func (b *EventBus) SubscribeWith(topic string, options SubscribeOptions) *Subscription {
+	if options.Buffer < 1 {
+		options.Buffer = 1
+	}
+	if options.Overflow == "" {
+		options.Overflow = EventDropNewest
+	}
+
+	ch := make(chan Event, options.Buffer)
+	sub := &Subscription{C: ch, ch: ch, options: options, done: make(chan struct{})}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[int]*Subscription)
+	}
+	id := b.nextID
+	b.nextID++
+	b.subs[topic][id] = sub
+	sub.remove = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[topic], id)
+	}
+	return sub
+}
+
+// Publish sends an event to all subscribers of its topic. It waits only
+// for subscribers using EventBlock.
+func (b *EventBus) Publish(topic string, data interface{}) {
+	event := Event{Topic: topic, Timestamp: time.Now(), Data: data}
+
+	b.mu.RLock()
+	subs := make([]*Subscription, 0, len(b.subs[topic]))
+	for _, sub := range b.subs[topic] {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.deliver(event) {
+			atomic.AddInt64(&b.dropped, 1)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because a subscriber's
+// buffer was full
+func (b *EventBus) Dropped() int64 {
+	return atomic.LoadInt64(&b.dropped)
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// ExecResult describes an execution along with the runtime that handled it
+type ExecResult struct {
+	// Value returned by the execution
+	Value interface{}
+
+	// Runtime name that executed the code
+	Runtime string
+
+	// Version of the runtime
+	Version string
+
+	// WorkerID of the pooled worker, or -1 if the runtime does not report one
+	WorkerID int
+
+	// Duration of the execution
+	Duration time.Duration
+}
+
+// workerKey carries a worker recorder through the execution context
+type workerKey struct{}
+
+// withWorkerRecorder returns a context that records the reporting worker
+func withWorkerRecorder(ctx context.Context) (context.Context, *int64) {
+	id := int64(-1)
+	return context.WithValue(ctx, workerKey{}, &id), &id
+}
+
+// ReportWorker records which pooled worker is handling an execution.
+// Runtimes call it after acquiring a worker; it is a no-op unless the
+// caller requested execution info.
+func ReportWorker(ctx context.Context, id int) {
+	if ctx == nil {
+		return
+	}
+	if recorder, ok := ctx.Value(workerKey{}).(*int64); ok {
+		atomic.StoreInt64(recorder, int64(id))
+	}
+}
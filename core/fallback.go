@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrStubRuntime is returned when Config.StrictRuntimes is set and an
+// enabled runtime is a stub
+var ErrStubRuntime = NewError(CodeUnavailable, "runtime not enabled in build")
+
+// Stub is implemented by the placeholder runtimes compiled when a
+// runtime's build tag is absent
+type Stub interface {
+	Stubbed() bool
+}
+
+// IsStub reports whether rt is a placeholder for a runtime not enabled in
+// the build
+func IsStub(rt Runtime) bool {
+	stub, ok := rt.(Stub)
+	return ok && stub.Stubbed()
+}
+
+// FallbackFunc handles calls meant for a runtime that is not available
+type FallbackFunc func(ctx context.Context, fn string, args ...interface{}) (interface{}, error)
+
+// SetFallback routes Call for a runtime to fn when the runtime is stubbed
+// or not registered, so apps keep working in builds without it. Stubbed
+// runtimes with a fallback are skipped by Initialize. A nil fn removes the
+// fallback.
+func (o *Orchestrator) SetFallback(runtime string, fn FallbackFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if fn == nil {
+		delete(o.fallbacks, runtime)
+		return
+	}
+	o.fallbacks[runtime] = fn
+}
+
+// fallbackFor returns the fallback to use for a call to runtime, if any
+func (o *Orchestrator) fallbackFor(runtime string) (FallbackFunc, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	fn, ok := o.fallbacks[runtime]
+	if !ok {
+		return nil, false
+	}
+	if rt, exists := o.runtimes[runtime]; exists && !IsStub(rt) {
+		return nil, false
+	}
+	return fn, true
+}
+
+// RuntimeCode is one way to perform an operation in CallFallback: Code
+// executed in Runtime, or Func called directly when set, as a Go
+// implementation of last resort
+type RuntimeCode struct {
+	Runtime string
+	Code    string
+	Func    BridgeFunc
+}
+
+// name identifies rc in errors
+func (rc RuntimeCode) name() string {
+	if rc.Func != nil && rc.Runtime == "" {
+		return "go"
+	}
+	return rc.Runtime
+}
+
+// CallFallback performs an operation with the first of chain that
+// succeeds, passing args to each attempt. Stubbed and unregistered
+// runtimes are skipped without running, and failed attempts fall through
+// to the next entry. If every entry fails, the returned error carries each
+// attempt's error.
+func (o *Orchestrator) CallFallback(ctx context.Context, chain []RuntimeCode, args ...interface{}) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	errs := make([]error, 0, len(chain))
+	for _, rc := range chain {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		var result interface{}
+		var err error
+		if rc.Func != nil {
+			result, err = rc.Func(ctx, args...)
+		} else {
+			o.mu.RLock()
+			rt, exists := o.runtimes[rc.Runtime]
+			o.mu.RUnlock()
+
+			switch {
+			case !exists:
+				err = Errorf(CodeNotFound, "runtime %s not found", rc.Runtime)
+			case IsStub(rt):
+				err = Errorf(CodeUnavailable, "runtime %s not enabled in build", rc.Runtime)
+			default:
+				result, err = o.Execute(ctx, rc.Runtime, rc.Code, args...)
+			}
+		}
+		if err == nil {
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", rc.name(), err))
+	}
+
+	if len(errs) == 0 {
+		return nil, Errorf(CodeInvalidArgument, "fallback chain is empty")
+	}
+	return nil, Errorf(CodeUnavailable, "all %d fallback attempts failed: %w", len(errs), errors.Join(errs...))
+}
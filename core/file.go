@@ -0,0 +1,27 @@
+package core
+
+import "io"
+
+// FileResponse is returned by a bridge function to send a file to the
+// frontend. The webview streams Reader in chunks and exposes the result as
+// a blob URL that can be downloaded. Reader is closed when it implements
+// io.Closer.
+type FileResponse struct {
+	// Name is the suggested download filename
+	Name string
+
+	// MimeType describes the content (e.g. "text/csv")
+	MimeType string
+
+	// Reader supplies the file content
+	Reader io.Reader
+}
+
+// NewFileResponse creates a file response, defaulting the MIME type to
+// application/octet-stream
+func NewFileResponse(name, mimeType string, reader io.Reader) *FileResponse {
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return &FileResponse{Name: name, MimeType: mimeType, Reader: reader}
+}
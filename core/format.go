@@ -0,0 +1,72 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"go/format"
+	"os/exec"
+	"strings"
+)
+
+// Formatter is implemented by runtimes that format code themselves instead
+// of using the language's standard formatter
+type Formatter interface {
+	Format(ctx context.Context, code string) (string, error)
+}
+
+// formatterCommands run each language's idiomatic formatter over stdin,
+// writing the result to stdout. Go is formatted in-process.
+var formatterCommands = map[string][]string{
+	"python":     {"black", "--quiet", "-"},
+	"rust":       {"rustfmt", "--emit", "stdout", "--edition", "2021"},
+	"javascript": {"prettier", "--stdin-filepath", "code.js"},
+	"cpp":        {"clang-format", "--assume-filename=code.cpp"},
+	"java":       {"google-java-format", "-"},
+	"ruby":       {"rufo"},
+	"lua":        {"stylua", "-"},
+	"zig":        {"zig", "fmt", "--stdin"},
+}
+
+// Format formats code for a runtime. Runtimes implementing Formatter format
+// their own code; others use the standard formatter for their language.
+func Format(ctx context.Context, rt Runtime, code string) (string, error) {
+	if formatter, ok := rt.(Formatter); ok {
+		return formatter.Format(ctx, code)
+	}
+	return FormatCode(ctx, rt.Name(), code)
+}
+
+// FormatCode formats code with the standard formatter for a language. It
+// returns an UNAVAILABLE error when the formatter is not installed.
+func FormatCode(ctx context.Context, language, code string) (string, error) {
+	if language == "go" {
+		formatted, err := format.Source([]byte(code))
+		if err != nil {
+			return "", Errorf(CodeInvalidArgument, "format failed: %w", err)
+		}
+		return string(formatted), nil
+	}
+
+	command, ok := formatterCommands[language]
+	if !ok {
+		return "", Errorf(CodeUnavailable, "formatter unavailable: no formatter for %s", language)
+	}
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return "", Errorf(CodeUnavailable, "formatter unavailable: %s is not installed", command[0])
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, command[1:]...)
+	cmd.Stdin = strings.NewReader(code)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", Errorf(CodeCanceled, "format canceled: %w", ctx.Err())
+		}
+		return "", NewError(CodeInvalidArgument, "format failed: "+strings.TrimSpace(stderr.String())).
+			WithDetail("formatter", command[0])
+	}
+	return stdout.String(), nil
+}
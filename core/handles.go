@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// Handle is an opaque token standing for a Go object registered with
+// Handles. It is passed into runtime code as a string.
+type Handle string
+
+// Handles lets runtime code refer to Go objects that cannot be serialized,
+// such as database connections or open files. Go registers an object and
+// passes its Handle into the runtime; runtime code then calls back into Go
+// to invoke the object's exported methods. Executions see the handles set
+// on their context with WithHandles.
+type Handles struct {
+	mu      sync.RWMutex
+	next    uint64
+	objects map[Handle]interface{}
+}
+
+// NewHandles creates an empty handle registry
+func NewHandles() *Handles {
+	return &Handles{objects: make(map[Handle]interface{})}
+}
+
+// Register stores obj and returns the handle runtime code uses for it
+func (h *Handles) Register(obj interface{}) Handle {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.next++
+	handle := Handle(fmt.Sprintf("handle:%d", h.next))
+	h.objects[handle] = obj
+	return handle
+}
+
+// Lookup returns the object registered under handle
+func (h *Handles) Lookup(handle Handle) (interface{}, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	obj, ok := h.objects[handle]
+	if !ok {
+		return nil, Errorf(CodeNotFound, "handle %s not found", handle)
+	}
+	return obj, nil
+}
+
+// Release forgets handle so runtime code can no longer use it
+func (h *Handles) Release(handle Handle) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.objects[handle]; !ok {
+		return Errorf(CodeNotFound, "handle %s not found", handle)
+	}
+	delete(h.objects, handle)
+	return nil
+}
+
+// Invoke calls the exported method of the object behind handle. A leading
+// context.Context parameter receives ctx. Arguments arrive as runtime
+// values and are converted to the parameter types, so whole float64 values
+// may be passed for integer parameters. A trailing error result is
+// returned as the error; the first other result, if any, as the value. A
+// method that panics fails with CodeInternal.
+func (h *Handles) Invoke(ctx context.Context, handle Handle, method string, args ...interface{}) (result interface{}, err error) {
+	obj, err := h.Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := reflect.ValueOf(obj).MethodByName(method)
+	if !fn.IsValid() {
+		return nil, Errorf(CodeNotFound, "handle %s has no method %s", handle, method)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, Errorf(CodeInternal, "handle %s method %s panicked: %v", handle, method, r)
+		}
+	}()
+	return callFunc(ctx, method, fn, args)
+}
+
+// callFunc calls fn with args converted to its parameter types, passing
+// ctx to a leading context.Context parameter. A trailing error result is
+// returned as the error; the first other result, if any, as the value.
+func callFunc(ctx context.Context, name string, fn reflect.Value, args []interface{}) (interface{}, error) {
+	fnType := fn.Type()
+
+	in := make([]reflect.Value, 0, fnType.NumIn())
+	if fnType.NumIn() > 0 && fnType.In(0) == contextType {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+
+	params := fnType.NumIn() - len(in)
+	if fnType.IsVariadic() {
+		if len(args) < params-1 {
+			return nil, Errorf(CodeInvalidArgument, "%s requires at least %d arguments, got %d", name, params-1, len(args))
+		}
+	} else if len(args) != params {
+		return nil, Errorf(CodeInvalidArgument, "%s requires %d arguments, got %d", name, params, len(args))
+	}
+
+	for i, arg := range args {
+		index := len(in)
+		var paramType reflect.Type
+		if fnType.IsVariadic() && index >= fnType.NumIn()-1 {
+			paramType = fnType.In(fnType.NumIn() - 1).Elem()
+		} else {
+			paramType = fnType.In(index)
+		}
+
+		value, err := convertHandleArg(arg, paramType)
+		if err != nil {
+			return nil, Errorf(CodeInvalidArgument, "%s argument %d: %w", name, i, err)
+		}
+		if err := validateEnums(value, ""); err != nil {
+			wrapped := Errorf(CodeInvalidArgument, "%s argument %d: %w", name, i, err)
+			wrapped.Details = ErrorInfoFor(err).Details
+			return nil, wrapped
+		}
+		in = append(in, value)
+	}
+
+	out := fn.Call(in)
+	if n := len(out); n > 0 && fnType.Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out = out[:n-1]
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out[0].Interface(), nil
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// convertHandleArg converts a runtime value to a method parameter type
+func convertHandleArg(arg interface{}, t reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %s", t)
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	switch {
+	case isIntKind(t.Kind()) && v.Kind() == reflect.Float64:
+		f := v.Float()
+		if f != math.Trunc(f) || (f < 0 && t.Kind() >= reflect.Uint) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s", f, t)
+		}
+		return reflect.ValueOf(int64(f)).Convert(t), nil
+	case isNumberKind(t.Kind()) && isNumberKind(v.Kind()):
+		return v.Convert(t), nil
+	case t.Kind() == reflect.String && v.Kind() == reflect.String:
+		return v.Convert(t), nil
+	case isCompositeKind(t.Kind()) && isCompositeKind(v.Kind()):
+		// Decoded objects and arrays become structs, maps and slices the
+		// way encoding/json would build them
+		data, err := json.Marshal(arg)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot use %T as %s: %w", arg, t, err)
+		}
+		ptr := reflect.New(t)
+		if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot use %T as %s: %w", arg, t, err)
+		}
+		return ptr.Elem(), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %s", arg, t)
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Uint64
+}
+
+func isCompositeKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr:
+		return true
+	}
+	return false
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return isIntKind(k) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// handlesKey carries the registry set by WithHandles
+type handlesKey struct{}
+
+// WithHandles returns a context whose executions can invoke the objects
+// registered with handles
+func WithHandles(ctx context.Context, handles *Handles) context.Context {
+	return context.WithValue(ctx, handlesKey{}, handles)
+}
+
+// HandlesFrom returns the registry set by WithHandles, or nil
+func HandlesFrom(ctx context.Context) *Handles {
+	if ctx == nil {
+		return nil
+	}
+	handles, _ := ctx.Value(handlesKey{}).(*Handles)
+	return handles
+}
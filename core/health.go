@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SelfTestStatus describes the outcome of a runtime selftest
+type SelfTestStatus string
+
+const (
+	SelfTestNotRun  SelfTestStatus = "not-run"
+	SelfTestPassed  SelfTestStatus = "passed"
+	SelfTestFailed  SelfTestStatus = "failed"
+	SelfTestSkipped SelfTestStatus = "skipped"
+)
+
+// SelfTester is implemented by runtimes that provide their own selftest
+// instead of the default snippet evaluation
+type SelfTester interface {
+	SelfTest(ctx context.Context) error
+}
+
+// RuntimeHealth reports the state of a single runtime
+type RuntimeHealth struct {
+	// Runtime name
+	Runtime string
+
+	// Version reported by the runtime
+	Version string
+
+	// Initialized is true once Initialize (and any selftest) succeeded
+	Initialized bool
+
+	// Ready is true once the runtime is initialized and, for runtimes
+	// implementing ReadinessProber, reports itself ready
+	Ready bool
+
+	// Stubbed is true when the runtime is a placeholder for one not
+	// enabled in the build
+	Stubbed bool
+
+	// SelfTest is the result of the startup selftest
+	SelfTest SelfTestStatus
+
+	// Concurrency is the concurrency model the runtime declares, empty
+	// for runtimes that declare none
+	Concurrency ConcurrencyModel
+
+	// Error holds the initialization or selftest failure, if any
+	Error string
+
+	// CheckedAt is when the runtime was last initialized
+	CheckedAt time.Time
+}
+
+// selfTestSnippets evaluate 1+1 in each runtime's Execute convention
+var selfTestSnippets = map[string]string{
+	"python":     "1+1",
+	"javascript": "1+1",
+	"lua":        "return 1+1",
+	"ruby":       "1+1",
+	"php":        "echo 1+1;",
+}
+
+// selfTestExpected is the value every selftest snippet must produce
+const selfTestExpected = "2"
+
+// RunSelfTest evaluates a trivial expression in an initialized runtime and
+// checks the result. Runtimes without a known snippet are skipped.
+func RunSelfTest(ctx context.Context, rt Runtime) (SelfTestStatus, error) {
+	if tester, ok := rt.(SelfTester); ok {
+		if err := tester.SelfTest(ctx); err != nil {
+			return SelfTestFailed, err
+		}
+		return SelfTestPassed, nil
+	}
+
+	snippet, ok := selfTestSnippets[rt.Name()]
+	if !ok {
+		return SelfTestSkipped, nil
+	}
+
+	result, err := rt.Execute(ctx, snippet)
+	if err != nil {
+		return SelfTestFailed, fmt.Errorf("selftest %q failed: %w", snippet, err)
+	}
+
+	if got := strings.TrimSpace(fmt.Sprint(result)); got != selfTestExpected {
+		return SelfTestFailed, fmt.Errorf("selftest %q returned %q, expected %q", snippet, got, selfTestExpected)
+	}
+
+	return SelfTestPassed, nil
+}
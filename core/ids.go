@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync/atomic"
+)
+
+// IDGenerator issues monotonic IDs for entities created by bridge
+// handlers. An ID is never reissued, even after its entity is deleted. The
+// zero value starts at 1.
+type IDGenerator struct {
+	last int64
+}
+
+// Next returns a new ID
+func (g *IDGenerator) Next() int64 {
+	return atomic.AddInt64(&g.last, 1)
+}
+
+// Observe records an existing ID, such as one loaded from storage, so
+// later IDs are greater than it
+func (g *IDGenerator) Observe(id int64) {
+	for {
+		last := atomic.LoadInt64(&g.last)
+		if id <= last || atomic.CompareAndSwapInt64(&g.last, last, id) {
+			return
+		}
+	}
+}
+
+// Last returns the most recently issued or observed ID
+func (g *IDGenerator) Last() int64 {
+	return atomic.LoadInt64(&g.last)
+}
+
+// Register exposes the generator as a bridge function returning a new ID,
+// so the frontend and runtimes calling through the bridge share the sequence
+func (g *IDGenerator) Register(bridge Bridge, name string) error {
+	return bridge.Register(name, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return g.Next(), nil
+	})
+}
+
+// NewUUID returns a random (version 4) UUID, for IDs that must be unique
+// across processes
+func NewUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
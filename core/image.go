@@ -0,0 +1,62 @@
+package core
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// ImageKey tags a map a runtime returns as an image, for languages that
+// cannot return an Image: the entry under it holds the MIME type and the
+// "data" entry the bytes, or base64 text where the language has no bytes
+// type. Python's polyglot.image builds one.
+const ImageKey = "__polyglot_image__"
+
+// Image is a picture returned by a runtime or bridge function, such as a
+// chart, for the frontend to show inline. The webview sends Data with its
+// MIME type, as raw bytes on the binary frame path, and the page receives
+// a blob URL it can put in an <img>.
+type Image struct {
+	// MimeType describes the content (e.g. "image/png")
+	MimeType string
+
+	// Data holds the encoded image
+	Data []byte
+}
+
+// NewImage creates an image, detecting the MIME type from data when
+// mimeType is empty
+func NewImage(mimeType string, data []byte) *Image {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return &Image{MimeType: mimeType, Data: data}
+}
+
+// ImageFrom returns the image v holds: an Image, or a map tagged with
+// ImageKey as a runtime returns it. ok is false for anything else,
+// including tagged maps whose data is neither bytes nor base64 text.
+func ImageFrom(v interface{}) (image *Image, ok bool) {
+	switch img := v.(type) {
+	case *Image:
+		return img, img != nil
+	case Image:
+		return &img, true
+	case map[string]interface{}:
+		mimeType, tagged := img[ImageKey].(string)
+		if !tagged {
+			return nil, false
+		}
+		switch data := img["data"].(type) {
+		case []byte:
+			return NewImage(mimeType, data), true
+		case string:
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+			if err != nil {
+				return nil, false
+			}
+			return NewImage(mimeType, decoded), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RuntimeInitResult is the outcome of initializing a single runtime
+type RuntimeInitResult struct {
+	// Runtime name
+	Runtime string
+
+	// Err is nil when the runtime initialized successfully
+	Err error
+
+	// Duration is how long initialization and any selftest took
+	Duration time.Duration
+}
+
+// InitReport lists the per-runtime results of InitializeReport
+type InitReport struct {
+	// Results in runtime name order
+	Results []RuntimeInitResult
+}
+
+// Succeeded returns the names of runtimes that initialized
+func (r *InitReport) Succeeded() []string {
+	var names []string
+	for _, result := range r.Results {
+		if result.Err == nil {
+			names = append(names, result.Runtime)
+		}
+	}
+	return names
+}
+
+// Failed returns the names of runtimes that failed to initialize
+func (r *InitReport) Failed() []string {
+	var names []string
+	for _, result := range r.Results {
+		if result.Err != nil {
+			names = append(names, result.Runtime)
+		}
+	}
+	return names
+}
+
+// Degraded reports whether some runtimes failed while at least one
+// initialized, so the application can continue with reduced functionality
+func (r *InitReport) Degraded() bool {
+	failed := len(r.Failed())
+	return failed > 0 && failed < len(r.Results)
+}
+
+// Err combines all initialization failures, or returns nil if every
+// runtime initialized
+func (r *InitReport) Err() error {
+	var msgs []string
+	for _, result := range r.Results {
+		if result.Err != nil {
+			msgs = append(msgs, result.Err.Error())
+		}
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("initialization errors: %s", strings.Join(msgs, "; "))
+}
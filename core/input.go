@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// InputExecutor is implemented by subprocess runtimes that can feed a
+// program's standard input
+type InputExecutor interface {
+	// ExecuteWithInput runs code with stdin as its standard input, which
+	// is closed once stdin is exhausted so the program sees EOF
+	ExecuteWithInput(ctx context.Context, code string, stdin io.Reader) (interface{}, error)
+}
+
+// ExecuteWithInput runs code in a subprocess runtime with stdin piped to
+// the program, so existing scripts that read piped input run unchanged:
+//
+//	out, err := orch.ExecuteWithInput(ctx, "php", `echo strtoupper(stream_get_contents(STDIN));`, strings.NewReader("hello"))
+//
+// The program sees EOF once stdin is exhausted; a nil stdin reads as
+// empty. Runtimes that do not run a subprocess fail with CodeUnavailable.
+func (o *Orchestrator) ExecuteWithInput(ctx context.Context, runtime string, code string, stdin io.Reader) (interface{}, error) {
+	o.mu.RLock()
+	rt, exists := o.runtimes[runtime]
+	o.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("runtime %s not found", runtime)
+	}
+	executor, ok := rt.(InputExecutor)
+	if !ok {
+		return nil, Errorf(CodeUnavailable, "%s runtime does not support standard input", runtime)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := o.ensureInitialized(ctx, runtime); err != nil {
+		return nil, err
+	}
+
+	result, err := o.handle(ctx, &Request{Runtime: runtime, Code: code}, func(ctx context.Context, req *Request) (interface{}, error) {
+		code, err := o.transform(req.Runtime, req.Code)
+		if err != nil {
+			return nil, err
+		}
+		ctx, finish := o.watch(ctx, req.Runtime, code)
+		defer finish()
+		return executor.ExecuteWithInput(ctx, code, stdin)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return o.process(runtime, result)
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInterrupted is returned by executions stopped with Interrupt
+var ErrInterrupted = NewError(CodeCanceled, "execution interrupted")
+
+// InterruptGrace bounds how long a cancelled execution waits for its
+// interrupted code to stop, so the worker is idle when released
+const InterruptGrace = time.Second
+
+// AwaitResult waits for the result code running on a worker sends on
+// results. If ctx ends first, it calls interrupt and waits up to
+// InterruptGrace for the code to stop, so the worker is idle when
+// released, then returns ctx's error.
+func AwaitResult[T any](ctx context.Context, results <-chan T, interrupt func()) (T, error) {
+	select {
+	case res := <-results:
+		return res, nil
+	case <-ctx.Done():
+		interrupt()
+		select {
+		case <-results:
+		case <-time.After(InterruptGrace):
+		}
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Interrupter is implemented by runtimes that can stop a running
+// execution, rather than only abandoning it when its context is cancelled
+type Interrupter interface {
+	Interrupt(executionID uint64) error
+}
+
+// executionKey carries an execution ID through the context
+type executionKey struct{}
+
+var lastExecutionID uint64
+
+// WithExecutionID tags ctx with a new execution ID. Pass the context to
+// Execute or Call and the ID to Interrupt to stop the execution.
+func WithExecutionID(ctx context.Context) (context.Context, uint64) {
+	id := atomic.AddUint64(&lastExecutionID, 1)
+	return context.WithValue(ctx, executionKey{}, id), id
+}
+
+// ExecutionIDFrom returns the execution ID of ctx, or 0 if it has none
+func ExecutionIDFrom(ctx context.Context) uint64 {
+	if ctx == nil {
+		return 0
+	}
+	id, _ := ctx.Value(executionKey{}).(uint64)
+	return id
+}
+
+// Executions tracks the running executions of a runtime so they can be
+// interrupted by ID. The zero value is ready to use.
+type Executions struct {
+	mu      sync.Mutex
+	running map[uint64]func()
+}
+
+// Track registers interrupt for the execution in ctx until the returned
+// function is called. Executions without an ID are not tracked.
+func (e *Executions) Track(ctx context.Context, interrupt func()) func() {
+	id := ExecutionIDFrom(ctx)
+	if id == 0 {
+		return func() {}
+	}
+
+	e.mu.Lock()
+	if e.running == nil {
+		e.running = make(map[uint64]func())
+	}
+	e.running[id] = interrupt
+	e.mu.Unlock()
+
+	return func() {
+		e.mu.Lock()
+		delete(e.running, id)
+		e.mu.Unlock()
+	}
+}
+
+// Interrupt requests that a tracked execution stop
+func (e *Executions) Interrupt(id uint64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	interrupt, ok := e.running[id]
+	if !ok {
+		return Errorf(CodeNotFound, "execution %d is not running", id)
+	}
+	interrupt()
+	return nil
+}
+
+// Interrupt stops a running execution in a runtime
+func (o *Orchestrator) Interrupt(runtime string, executionID uint64) error {
+	o.mu.RLock()
+	rt, exists := o.runtimes[runtime]
+	o.mu.RUnlock()
+
+	if !exists {
+		return Errorf(CodeNotFound, "runtime %s not found", runtime)
+	}
+	interrupter, ok := rt.(Interrupter)
+	if !ok {
+		return Errorf(CodeUnavailable, "%s runtime does not support interrupts", runtime)
+	}
+	return interrupter.Interrupt(executionID)
+}
+
+// CancelAll cancels every execution and call running in a runtime, such as
+// before Reconfigure or an emergency stop. They return the context's
+// error; runtimes that can interrupt code also stop it on cancellation,
+// while others leave it to finish in the background.
+func (o *Orchestrator) CancelAll(runtime string) error {
+	o.mu.RLock()
+	_, exists := o.runtimes[runtime]
+	o.mu.RUnlock()
+
+	if !exists {
+		return Errorf(CodeNotFound, "runtime %s not found", runtime)
+	}
+	o.inflight.cancel(runtime)
+	return nil
+}
+
+// inflight holds the cancel functions of running executions by runtime
+type inflight struct {
+	mu      sync.Mutex
+	next    uint64
+	running map[string]map[uint64]context.CancelFunc
+}
+
+// track registers cancel for a runtime until the returned function is called
+func (f *inflight) track(runtime string, cancel context.CancelFunc) func() {
+	f.mu.Lock()
+	if f.running == nil {
+		f.running = make(map[string]map[uint64]context.CancelFunc)
+	}
+	if f.running[runtime] == nil {
+		f.running[runtime] = make(map[uint64]context.CancelFunc)
+	}
+	f.next++
+	id := f.next
+	f.running[runtime][id] = cancel
+	f.mu.Unlock()
+
+	return func() {
+		f.mu.Lock()
+		delete(f.running[runtime], id)
+		f.mu.Unlock()
+	}
+}
+
+// cancel cancels everything tracked for a runtime
+func (f *inflight) cancel(runtime string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, cancel := range f.running[runtime] {
+		cancel()
+	}
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FunctionInfo describes a user-defined callable in a runtime session
+type FunctionInfo struct {
+	// Name the function is called by
+	Name string `json:"name"`
+
+	// Arity is the number of parameters, or -1 when the function accepts a
+	// variable number of arguments or its arity is unknown
+	Arity int `json:"arity"`
+}
+
+// FunctionLister is implemented by runtimes that can list the callables
+// defined in their session
+type FunctionLister interface {
+	DefinedFunctions(ctx context.Context) ([]FunctionInfo, error)
+}
+
+// DefinedFunctions lists the user-defined callables in rt, sorted by name
+func DefinedFunctions(ctx context.Context, rt Runtime) ([]FunctionInfo, error) {
+	lister, ok := rt.(FunctionLister)
+	if !ok {
+		return nil, Errorf(CodeUnavailable, "%s runtime does not support function introspection", rt.Name())
+	}
+	return lister.DefinedFunctions(ctx)
+}
+
+// ParseFunctionListing parses the "name:arity" lines produced by runtime
+// introspection code into FunctionInfo sorted by name
+func ParseFunctionListing(listing interface{}) ([]FunctionInfo, error) {
+	text, ok := listing.(string)
+	if !ok && listing != nil {
+		return nil, fmt.Errorf("unexpected function listing of type %T", listing)
+	}
+
+	seen := make(map[string]bool)
+	functions := []FunctionInfo{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		sep := strings.LastIndex(line, ":")
+		if sep <= 0 {
+			return nil, fmt.Errorf("malformed function listing entry %q", line)
+		}
+		name := line[:sep]
+		arity, err := strconv.Atoi(line[sep+1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed arity for %s: %w", name, err)
+		}
+		if arity < 0 {
+			arity = -1
+		}
+		if !seen[name] {
+			seen[name] = true
+			functions = append(functions, FunctionInfo{Name: name, Arity: arity})
+		}
+	}
+
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions, nil
+}
+
+// ModuleLister is implemented by runtimes that can list the modules their
+// code can import
+type ModuleLister interface {
+	AvailableModules(ctx context.Context) ([]string, error)
+}
+
+// AvailableModules lists the modules code in rt can import, sorted, so
+// apps can check for optional dependencies before running code that needs
+// them
+func AvailableModules(ctx context.Context, rt Runtime) ([]string, error) {
+	lister, ok := rt.(ModuleLister)
+	if !ok {
+		return nil, Errorf(CodeUnavailable, "%s runtime does not support module introspection", rt.Name())
+	}
+	return lister.AvailableModules(ctx)
+}
+
+// ParseModuleListing parses the newline-separated module names produced
+// by runtime introspection code, sorted and without duplicates
+func ParseModuleListing(listing interface{}) ([]string, error) {
+	text, ok := listing.(string)
+	if !ok && listing != nil {
+		return nil, fmt.Errorf("unexpected module listing of type %T", listing)
+	}
+
+	seen := make(map[string]bool)
+	modules := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		name := strings.TrimSpace(line)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			modules = append(modules, name)
+		}
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// ScopeChanges reports what an execution added to a runtime session
+type ScopeChanges struct {
+	// Defined lists the names the execution defined, or bound to a value
+	// of another type, sorted by name
+	Defined []ScopedName `json:"defined"`
+
+	// Imported lists the modules the execution loaded, sorted
+	Imported []string `json:"imported"`
+}
+
+// ScopedName is a name defined in a runtime session
+type ScopedName struct {
+	Name string `json:"name"`
+
+	// Type is the language's name for the type of the value, such as
+	// "int" or "function" in Python
+	Type string `json:"type"`
+}
+
+// ChangeTracker is implemented by runtimes that can report what code
+// defines in their session
+type ChangeTracker interface {
+	ExecuteWithChanges(ctx context.Context, code string) (interface{}, ScopeChanges, error)
+}
+
+// ExecuteWithChanges runs code in rt like Execute and also reports the
+// names it defined and the modules it imported, for notebook-style UIs.
+// Changes are reported even when the code fails partway.
+func ExecuteWithChanges(ctx context.Context, rt Runtime, code string) (interface{}, ScopeChanges, error) {
+	tracker, ok := rt.(ChangeTracker)
+	if !ok {
+		return nil, ScopeChanges{}, Errorf(CodeUnavailable, "%s runtime does not support change tracking", rt.Name())
+	}
+	return tracker.ExecuteWithChanges(ctx, code)
+}
+
+// ParseScopeChanges compares the scope listings produced by runtime
+// introspection code before and after an execution. Listings hold a
+// "name\ttype" line for each name in scope and an "@module" line for each
+// loaded module; a name listed twice takes its last type.
+func ParseScopeChanges(before, after interface{}) (ScopeChanges, error) {
+	oldNames, oldModules, err := parseScopeListing(before)
+	if err != nil {
+		return ScopeChanges{}, err
+	}
+	names, modules, err := parseScopeListing(after)
+	if err != nil {
+		return ScopeChanges{}, err
+	}
+
+	changes := ScopeChanges{Defined: []ScopedName{}, Imported: []string{}}
+	for name, kind := range names {
+		if oldKind, ok := oldNames[name]; !ok || oldKind != kind {
+			changes.Defined = append(changes.Defined, ScopedName{Name: name, Type: kind})
+		}
+	}
+	for module := range modules {
+		if !oldModules[module] {
+			changes.Imported = append(changes.Imported, module)
+		}
+	}
+	sort.Slice(changes.Defined, func(i, j int) bool { return changes.Defined[i].Name < changes.Defined[j].Name })
+	sort.Strings(changes.Imported)
+	return changes, nil
+}
+
+// parseScopeListing parses a scope listing into the types of its names
+// and its set of modules
+func parseScopeListing(listing interface{}) (map[string]string, map[string]bool, error) {
+	text, ok := listing.(string)
+	if !ok && listing != nil {
+		return nil, nil, fmt.Errorf("unexpected scope listing of type %T", listing)
+	}
+
+	names := make(map[string]string)
+	modules := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "@"):
+			modules[line[1:]] = true
+		default:
+			sep := strings.IndexByte(line, '\t')
+			if sep <= 0 {
+				return nil, nil, fmt.Errorf("malformed scope listing entry %q", line)
+			}
+			names[line[:sep]] = line[sep+1:]
+		}
+	}
+	return names, modules, nil
+}
@@ -0,0 +1,130 @@
+package core
+
+import "sync"
+
+// IsolationLevel controls how concurrent calls to a bridge function are
+// dispatched
+type IsolationLevel string
+
+const (
+	// IsolationConcurrent lets calls run in parallel. Use it for pure
+	// functions and handlers that synchronize themselves.
+	IsolationConcurrent IsolationLevel = "concurrent"
+
+	// IsolationSerialized runs calls to the function one at a time
+	IsolationSerialized IsolationLevel = "serialized"
+
+	// IsolationSerializedGroup runs calls one at a time across every
+	// function in the same group, for handlers sharing state
+	IsolationSerializedGroup IsolationLevel = "serialized-group"
+)
+
+// Isolation is the isolation of a bridge function. Functions without one
+// are serialized when the bridge is (see SimpleBridge.SetSerialized) and
+// concurrent otherwise.
+type Isolation struct {
+	Level IsolationLevel
+
+	// Group names the lock shared by IsolationSerializedGroup functions
+	Group string
+}
+
+var (
+	// Concurrent lets calls to a function run in parallel
+	Concurrent = Isolation{Level: IsolationConcurrent}
+
+	// Serialized runs calls to a function one at a time
+	Serialized = Isolation{Level: IsolationSerialized}
+)
+
+// SerializedGroup runs calls one at a time across every function
+// registered with the same group
+func SerializedGroup(group string) Isolation {
+	return Isolation{Level: IsolationSerializedGroup, Group: group}
+}
+
+// validate checks the level is known and groups are named
+func (i Isolation) validate() error {
+	switch i.Level {
+	case IsolationConcurrent, IsolationSerialized:
+		return nil
+	case IsolationSerializedGroup:
+		if i.Group == "" {
+			return Errorf(CodeInvalidArgument, "serialized group isolation requires a group name")
+		}
+		return nil
+	}
+	return Errorf(CodeInvalidArgument, "unknown isolation level %q", i.Level)
+}
+
+// RegisterWithIsolation adds a function dispatched with isolation:
+//
+//	bridge.RegisterWithIsolation("addTask", core.SerializedGroup("tasks"), addTask)
+//	bridge.RegisterWithIsolation("fibonacci", core.Concurrent, fibonacci)
+func (b *SimpleBridge) RegisterWithIsolation(name string, isolation Isolation, fn BridgeFunc) error {
+	if err := isolation.validate(); err != nil {
+		return err
+	}
+	return b.register(name, fn, &isolation)
+}
+
+// SetIsolation changes the isolation of a function registered on this
+// bridge, such as one added with RegisterTyped. Calls already running
+// keep the isolation they started with.
+func (b *SimpleBridge) SetIsolation(name string, isolation Isolation) error {
+	if err := isolation.validate(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.functions[name]; !exists {
+		return Errorf(CodeNotFound, "function %s not found", name)
+	}
+	b.isolation[name] = isolation
+	b.publish()
+	return nil
+}
+
+// Isolation returns the isolation calls to name are dispatched with
+func (b *SimpleBridge) Isolation(name string) (Isolation, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if _, exists := b.functions[name]; !exists {
+		return Isolation{}, false
+	}
+	if isolation, ok := b.isolation[name]; ok {
+		return isolation, true
+	}
+	if b.serialized {
+		return Serialized, true
+	}
+	return Concurrent, true
+}
+
+// lockFor returns the lock calls to name take, or nil when they run
+// concurrently. Callers must hold b.mu.
+func (b *SimpleBridge) lockFor(name string) *sync.Mutex {
+	isolation, explicit := b.isolation[name]
+	if !explicit {
+		if !b.serialized {
+			return nil
+		}
+		isolation = Serialized
+	}
+
+	switch isolation.Level {
+	case IsolationSerialized:
+		return b.locks[name]
+	case IsolationSerializedGroup:
+		lock, ok := b.groups[isolation.Group]
+		if !ok {
+			lock = &sync.Mutex{}
+			b.groups[isolation.Group] = lock
+		}
+		return lock
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AdaptiveTimeoutConfig derives a default timeout for each function from
+// its observed latency, applied to calls whose context has no deadline
+type AdaptiveTimeoutConfig struct {
+	// Percentile of observed latency used as the baseline (default 0.99)
+	Percentile float64
+
+	// Factor multiplies the baseline to give the timeout (default 3)
+	Factor float64
+
+	// MinSamples is the number of successful calls observed before a
+	// timeout is derived (default 20)
+	MinSamples int
+
+	// Window is the number of recent calls kept per function (default 100)
+	Window int
+
+	// Min and Max bound the derived timeout (default 100ms and no maximum)
+	Min time.Duration
+	Max time.Duration
+}
+
+// withDefaults fills unset fields with their defaults
+func (c AdaptiveTimeoutConfig) withDefaults() AdaptiveTimeoutConfig {
+	if c.Percentile <= 0 || c.Percentile > 1 {
+		c.Percentile = 0.99
+	}
+	if c.Factor <= 0 {
+		c.Factor = 3
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = 20
+	}
+	if c.Window <= 0 {
+		c.Window = 100
+	}
+	if c.Window < c.MinSamples {
+		c.Window = c.MinSamples
+	}
+	if c.Min <= 0 {
+		c.Min = 100 * time.Millisecond
+	}
+	return c
+}
+
+// SlowCall describes a call that ran past its adaptive timeout
+type SlowCall struct {
+	// Runtime and Function identify the call
+	Runtime  string
+	Function string
+
+	// Duration is how long the call ran
+	Duration time.Duration
+
+	// Baseline is the learned latency percentile
+	Baseline time.Duration
+
+	// Timeout is the derived timeout that was exceeded
+	Timeout time.Duration
+
+	// Cancelled reports whether the call was cut off by the timeout
+	Cancelled bool
+}
+
+// latencyTracker keeps a window of recent call durations per function
+type latencyTracker struct {
+	config  AdaptiveTimeoutConfig
+	mu      sync.Mutex
+	windows map[string]*latencyWindow
+}
+
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+func newLatencyTracker(config AdaptiveTimeoutConfig) *latencyTracker {
+	return &latencyTracker{
+		config:  config.withDefaults(),
+		windows: make(map[string]*latencyWindow),
+	}
+}
+
+// observe records the duration of a successful call
+func (t *latencyTracker) observe(key string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.windows[key]
+	if !ok {
+		w = &latencyWindow{}
+		t.windows[key] = w
+	}
+	if len(w.samples) < t.config.Window {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+}
+
+// timeout returns the learned baseline and derived timeout for a function,
+// or false until enough calls have been observed
+func (t *latencyTracker) timeout(key string) (baseline, timeout time.Duration, ok bool) {
+	t.mu.Lock()
+	w, exists := t.windows[key]
+	if !exists || len(w.samples) < t.config.MinSamples {
+		t.mu.Unlock()
+		return 0, 0, false
+	}
+	sorted := append([]time.Duration(nil), w.samples...)
+	t.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(float64(len(sorted))*t.config.Percentile+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	baseline = sorted[index]
+
+	timeout = time.Duration(float64(baseline) * t.config.Factor)
+	if timeout < t.config.Min {
+		timeout = t.config.Min
+	}
+	if t.config.Max > 0 && timeout > t.config.Max {
+		timeout = t.config.Max
+	}
+	return baseline, timeout, true
+}
+
+// AdaptiveTimeout returns the timeout derived for a function from its
+// observed latency, or false when adaptive timeouts are disabled or too
+// few calls have been observed
+func (o *Orchestrator) AdaptiveTimeout(runtime, fn string) (time.Duration, bool) {
+	if o.latency == nil {
+		return 0, false
+	}
+	_, timeout, ok := o.latency.timeout(runtime + "." + fn)
+	return timeout, ok
+}
+
+// callAdaptive calls fn under its learned timeout when the caller set no
+// deadline, publishing a TopicSlowCall event for calls that exceed it
+func (o *Orchestrator) callAdaptive(ctx context.Context, rt Runtime, runtime, fn string, args ...interface{}) (interface{}, error) {
+	key := runtime + "." + fn
+	baseline, timeout, learned := o.latency.timeout(key)
+
+	applied := learned && !callerDeadline(ctx)
+	if applied {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := rt.Call(ctx, fn, args...)
+	elapsed := time.Since(start)
+
+	if !learned || elapsed < timeout {
+		if err == nil {
+			o.latency.observe(key, elapsed)
+		}
+		return result, err
+	}
+
+	cancelled := applied && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	o.events.Publish(TopicSlowCall, SlowCall{
+		Runtime:   runtime,
+		Function:  fn,
+		Duration:  elapsed,
+		Baseline:  baseline,
+		Timeout:   timeout,
+		Cancelled: cancelled,
+	})
+	if cancelled {
+		return nil, Errorf(CodeTimeout, "call %s exceeded adaptive timeout %v: %w", key, timeout, ctx.Err()).
+			WithDetail("baseline", baseline.String())
+	}
+	return result, err
+}
@@ -0,0 +1,94 @@
+package core
+
+import "context"
+
+// lazyInit is an initialization of a LazyInit runtime in progress
+type lazyInit struct {
+	done chan struct{}
+	err  error
+}
+
+// ensureInitialized initializes a LazyInit runtime on its first execution
+// or call. Concurrent first calls share one initialization and its error;
+// after a failure, the next call tries again.
+func (o *Orchestrator) ensureInitialized(ctx context.Context, name string) error {
+	cfg, running := o.lazyState(name)
+	if running || cfg == nil || !cfg.Enabled || !cfg.LazyInit {
+		return nil
+	}
+
+	o.lazyMu.Lock()
+	// An initialization may have finished since the check above
+	if _, running := o.lazyState(name); running {
+		o.lazyMu.Unlock()
+		return nil
+	}
+	pending, waiting := o.lazy[name]
+	if !waiting {
+		pending = &lazyInit{done: make(chan struct{})}
+		o.lazy[name] = pending
+	}
+	o.lazyMu.Unlock()
+
+	if waiting {
+		select {
+		case <-pending.done:
+			return pending.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// The runtime starts without holding o.mu, so other runtimes keep
+	// serving and Reconfigure or Shutdown are not held up behind it
+	o.mu.RLock()
+	start := o.prepareStart(name, cfg)
+	o.mu.RUnlock()
+	health, err := o.startRuntime(ctx, start)
+
+	o.mu.Lock()
+	if health.Initialized && !o.stillEnabled(name) {
+		o.mu.Unlock()
+		start.runtime.Shutdown(ctx)
+		err = Errorf(CodeUnavailable, "runtime %s was disabled while it started", name)
+	} else {
+		o.recordInit(name, start.config, health)
+		o.mu.Unlock()
+	}
+
+	o.lazyMu.Lock()
+	delete(o.lazy, name)
+	o.lazyMu.Unlock()
+	pending.err = err
+	close(pending.done)
+	return err
+}
+
+// stillEnabled reports whether the named runtime is enabled and the
+// orchestrator is not shutting down, as a runtime that finished starting
+// must be to take calls. Callers must hold o.mu.
+func (o *Orchestrator) stillEnabled(name string) bool {
+	select {
+	case <-o.shutdown:
+		return false
+	default:
+	}
+	cfg := o.config.Languages[name]
+	return cfg != nil && cfg.Enabled
+}
+
+// lazyPending reports whether a LazyInit runtime is starting
+func (o *Orchestrator) lazyPending(name string) bool {
+	o.lazyMu.Lock()
+	defer o.lazyMu.Unlock()
+	_, pending := o.lazy[name]
+	return pending
+}
+
+// lazyState returns the config of a runtime and whether it is running
+func (o *Orchestrator) lazyState(name string) (*RuntimeConfig, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	_, running := o.active[name]
+	return o.config.Languages[name], running
+}
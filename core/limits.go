@@ -0,0 +1,204 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ResourceLimits bounds the resources a subprocess may consume
+type ResourceLimits struct {
+	// Memory caps the data segment (RLIMIT_DATA) in bytes: memory the
+	// process allocates, but not address space it only reserves, as the
+	// JVM does for its heap (0 disables the limit)
+	Memory int64
+
+	// CPU caps CPU time (0 disables the limit)
+	CPU time.Duration
+
+	// Descriptors counts and caps the descriptors held for the process
+	// (nil disables tracking)
+	Descriptors *DescriptorTracker
+}
+
+// LimitsFor returns the resource limits configured for a runtime
+func LimitsFor(config RuntimeConfig) ResourceLimits {
+	return ResourceLimits{Memory: config.MemoryLimit, CPU: config.CPULimit, Descriptors: config.Descriptors}
+}
+
+// ResourceExceededError reports a subprocess killed for exceeding a limit
+type ResourceExceededError struct {
+	// Runtime that ran the process
+	Runtime string
+
+	// Resource that was exceeded (memory, cpu)
+	Resource string
+
+	// Limit describes the configured limit
+	Limit string
+}
+
+func (e *ResourceExceededError) Error() string {
+	return fmt.Sprintf("%s process exceeded %s limit of %s", e.Runtime, e.Resource, e.Limit)
+}
+
+// RuntimeCrashedError reports a subprocess that terminated abnormally:
+// killed by a signal, or exiting after failing to allocate memory. Script
+// errors that exit normally are not crashes.
+type RuntimeCrashedError struct {
+	// Runtime that ran the process
+	Runtime string
+
+	// Signal that killed the process, or 0 if it exited
+	Signal syscall.Signal
+
+	// ExitCode of the process, or -1 if it was killed by a signal
+	ExitCode int
+
+	// OutOfMemory reports the process ran out of memory, either saying so
+	// on stderr or being killed with SIGKILL while the cgroup's OOM kill
+	// count rose. Other SIGKILLs, such as manual kills, are plain crashes.
+	OutOfMemory bool
+
+	// Stdout and Stderr hold the last output the process wrote
+	Stdout string
+	Stderr string
+
+	// Err is the underlying exit error
+	Err error
+}
+
+func (e *RuntimeCrashedError) Error() string {
+	msg := fmt.Sprintf("%s process exited with code %d", e.Runtime, e.ExitCode)
+	if e.Signal != 0 {
+		msg = fmt.Sprintf("%s process killed by signal %d (%s)", e.Runtime, int(e.Signal), e.Signal)
+	}
+	if e.OutOfMemory {
+		msg += ": out of memory"
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Unwrap returns the underlying exit error
+func (e *RuntimeCrashedError) Unwrap() error {
+	return e.Err
+}
+
+// RunLimited runs cmd under limits, returning a *ResourceExceededError when
+// the process is killed for exceeding one and a *RuntimeCrashedError when
+// it otherwise terminates abnormally. Memory and CPU limits are enforced
+// on Linux only; elsewhere cmd runs unrestricted. Processes that would take
+// limits.Descriptors past its cap are not started and fail with
+// ErrDescriptorLimit.
+func RunLimited(cmd *exec.Cmd, runtime string, limits ResourceLimits) error {
+	stdout, stderr := &outputTail{}, &outputTail{}
+	cmd.Stdout = teeTail(cmd.Stdout, stdout)
+	cmd.Stderr = teeTail(cmd.Stderr, stderr)
+
+	if limits.Descriptors != nil {
+		release, err := limits.Descriptors.Acquire(runtime, descriptorsFor(cmd))
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	kills := oomKills()
+	var err error
+	if limits.Memory <= 0 && limits.CPU <= 0 {
+		err = cmd.Run()
+	} else {
+		err = runLimited(cmd, runtime, limits, stderr)
+	}
+	return crashError(runtime, err, stdout, stderr, kills)
+}
+
+// crashError converts an abnormal exit into a *RuntimeCrashedError and
+// returns any other error unchanged. kills is the OOM kill count read
+// before the process started.
+func crashError(runtime string, err error, stdout, stderr *outputTail, kills int64) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	signal, signaled := exitSignal(exitErr)
+	outOfMemory := reportsOutOfMemory(stderr.String()) ||
+		signal == syscall.SIGKILL && kills >= 0 && oomKills() > kills
+	if !signaled && !outOfMemory {
+		return err
+	}
+	return &RuntimeCrashedError{
+		Runtime:     runtime,
+		Signal:      signal,
+		ExitCode:    exitErr.ExitCode(),
+		OutOfMemory: outOfMemory,
+		Stdout:      stdout.String(),
+		Stderr:      stderr.String(),
+		Err:         err,
+	}
+}
+
+// outOfMemoryMarkers are stderr messages of runtimes failing to allocate
+var outOfMemoryMarkers = []string{
+	"memoryerror",
+	"out of memory",
+	"cannot allocate memory",
+	"bad_alloc",
+	"allowed memory size",
+	"memory allocation of",
+	"outofmemory",
+}
+
+// reportsOutOfMemory checks stderr for an allocation failure
+func reportsOutOfMemory(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, marker := range outOfMemoryMarkers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputTail keeps the last bytes a process writes for classifying and
+// reporting its exit
+type outputTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const outputTailSize = 4096
+
+func (t *outputTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > outputTailSize {
+		t.buf = t.buf[len(t.buf)-outputTailSize:]
+	}
+	return len(p), nil
+}
+
+func (t *outputTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// teeTail sends output to tail as well as w, if set
+func teeTail(w io.Writer, tail *outputTail) io.Writer {
+	if w == nil {
+		return tail
+	}
+	return io.MultiWriter(w, tail)
+}
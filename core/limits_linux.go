@@ -0,0 +1,140 @@
+//go:build linux
+// +build linux
+
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func runLimited(cmd *exec.Cmd, runtime string, limits ResourceLimits, tail *outputTail) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		return fmt.Errorf("cannot apply resource limits: %w", err)
+	}
+
+	// Apply rlimits in a shell that then execs the command, so they take
+	// effect before the process allocates anything. RLIMIT_DATA counts
+	// allocated memory rather than reserved address space, which runtimes
+	// such as the JVM reserve far beyond what they use.
+	script := ""
+	if limits.Memory > 0 {
+		script += fmt.Sprintf("ulimit -d %d && ", (limits.Memory+1023)/1024)
+	}
+	if limits.CPU > 0 {
+		// The soft limit raises SIGXCPU; the hard limit kills processes
+		// that ignore it
+		seconds := (limits.CPU + time.Second - 1) / time.Second
+		script += fmt.Sprintf("ulimit -S -t %d && ulimit -H -t %d && ", seconds, seconds+1)
+	}
+	script += `exec "$@"`
+
+	args := append([]string{"sh", "-c", script, "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = shell
+	cmd.Args = args
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	// Only the soft CPU limit raises SIGXCPU, and the kernel may send it
+	// before rusage accounts for the full limit. SIGKILL has other senders,
+	// so it counts as the hard limit only when the process used the CPU.
+	if sig, ok := exitSignal(exitErr); ok && limits.CPU > 0 {
+		state := exitErr.ProcessState
+		if sig == syscall.SIGXCPU || (sig == syscall.SIGKILL && state.UserTime()+state.SystemTime() >= limits.CPU*9/10) {
+			return &ResourceExceededError{Runtime: runtime, Resource: "cpu", Limit: limits.CPU.String()}
+		}
+	}
+	// A process at the data limit fails to allocate; only one saying so
+	// is reported as exceeding it, so other crashes keep their signal
+	if limits.Memory > 0 && reportsOutOfMemory(tail.String()) {
+		return &ResourceExceededError{Runtime: runtime, Resource: "memory", Limit: fmt.Sprintf("%d bytes", limits.Memory)}
+	}
+	return err
+}
+
+// exitSignal returns the signal that killed the process, if any
+func exitSignal(exitErr *exec.ExitError) (syscall.Signal, bool) {
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return status.Signal(), true
+}
+
+// oomKills returns the OOM kill count of this process's memory cgroup,
+// which subprocesses share, or -1 when it cannot be read. Under cgroup v2
+// it comes from memory.events, under v1 from memory.oom_control.
+func oomKills() int64 {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return -1
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		var mount, file string
+		switch {
+		case parts[0] == "0" && parts[1] == "":
+			mount, file = "/sys/fs/cgroup", "memory.events"
+		case hasController(parts[1], "memory"):
+			mount, file = "/sys/fs/cgroup/memory", "memory.oom_control"
+		default:
+			continue
+		}
+		// Inside a cgroup namespace the listed path may not be mounted;
+		// the mount root is then this process's cgroup
+		for _, dir := range []string{filepath.Join(mount, parts[2]), mount} {
+			if count, ok := readOOMKills(filepath.Join(dir, file)); ok {
+				return count
+			}
+		}
+	}
+	return -1
+}
+
+// hasController checks a cgroup v1 controller list
+func hasController(list, name string) bool {
+	for _, controller := range strings.Split(list, ",") {
+		if controller == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readOOMKills reads the oom_kill line of a cgroup events file
+func readOOMKills(path string) (int64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, err := strconv.ParseInt(fields[1], 10, 64)
+			return count, err == nil
+		}
+	}
+	return 0, false
+}
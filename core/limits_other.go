@@ -0,0 +1,25 @@
+//go:build !linux
+// +build !linux
+
+package core
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// runLimited runs cmd unrestricted; resource limits are enforced on Linux
+// only
+func runLimited(cmd *exec.Cmd, runtime string, limits ResourceLimits, tail *outputTail) error {
+	return cmd.Run()
+}
+
+// oomKills reports no count; OOM kills are detected on Linux only
+func oomKills() int64 {
+	return -1
+}
+
+// exitSignal reports no signal; signal exits are detected on Linux only
+func exitSignal(exitErr *exec.ExitError) (syscall.Signal, bool) {
+	return 0, false
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// LogLevel is the severity of a log message
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+// String returns the level name
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Logger receives diagnostic messages from the framework
+type Logger interface {
+	// Log records a message at the given level
+	Log(level LogLevel, msg string)
+}
+
+// StdLogger writes log messages through the standard library logger
+type StdLogger struct {
+	logger *log.Logger
+}
+
+// NewStdLogger creates a logger writing to out
+func NewStdLogger(out io.Writer) *StdLogger {
+	return &StdLogger{logger: log.New(out, "", log.LstdFlags)}
+}
+
+// DefaultLogger returns a logger writing to stderr
+func DefaultLogger() Logger {
+	return NewStdLogger(os.Stderr)
+}
+
+// Log records a message prefixed with its level
+func (l *StdLogger) Log(level LogLevel, msg string) {
+	l.logger.Printf("[%s] %s", level, msg)
+}
+
+// NopLogger discards every message
+type NopLogger struct{}
+
+// Log discards msg
+func (NopLogger) Log(level LogLevel, msg string) {}
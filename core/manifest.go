@@ -0,0 +1,284 @@
+package core
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Type kinds used in a TypeManifest
+const (
+	KindAny     = "any"
+	KindVoid    = "void"
+	KindString  = "string"
+	KindNumber  = "number"
+	KindBoolean = "boolean"
+	KindBytes   = "bytes"
+	KindArray   = "array"
+	KindMap     = "map"
+	KindObject  = "object"
+)
+
+// TypeManifest describes the shape of a bridge value as it crosses to the
+// frontend
+type TypeManifest struct {
+	// Kind is one of the Kind constants
+	Kind string `json:"kind"`
+
+	// Nullable is set for pointers, which arrive as null when nil
+	Nullable bool `json:"nullable,omitempty"`
+
+	// Elem is the element type of arrays and the value type of maps
+	Elem *TypeManifest `json:"elem,omitempty"`
+
+	// Fields are the properties of objects, named as encoding/json
+	// names them
+	Fields []FieldManifest `json:"fields,omitempty"`
+
+	// Enum lists the allowed values of Enumerated strings
+	Enum []string `json:"enum,omitempty"`
+}
+
+// FieldManifest describes one property of an object
+type FieldManifest struct {
+	Name     string       `json:"name"`
+	Type     TypeManifest `json:"type"`
+	Optional bool         `json:"optional,omitempty"`
+}
+
+// FunctionManifest describes a bridge function for frontend tooling
+type FunctionManifest struct {
+	Name string `json:"name"`
+
+	// Description and Params, which names the Args in order, are set with
+	// SimpleBridge.Document
+	Description string   `json:"description,omitempty"`
+	Params      []string `json:"params,omitempty"`
+
+	// Args are the parameter types, excluding a leading context. When
+	// Variadic is set the last one repeats.
+	Args     []TypeManifest `json:"args"`
+	Variadic bool           `json:"variadic,omitempty"`
+
+	// Returns is the result type, excluding a trailing error
+	Returns TypeManifest `json:"returns"`
+}
+
+// untypedManifest describes a function registered with Register, whose
+// arguments and result are not known
+func untypedManifest(name string) FunctionManifest {
+	return FunctionManifest{
+		Name:     name,
+		Args:     []TypeManifest{{Kind: KindAny}},
+		Variadic: true,
+		Returns:  TypeManifest{Kind: KindAny},
+	}
+}
+
+// RegisterTyped adds a Go function to the bridge, converting call
+// arguments to its parameter types as Handles.Invoke does. A leading
+// context.Context parameter receives the call context and a trailing error
+// result is returned as the error. Its signature is reported by Manifest.
+func (b *SimpleBridge) RegisterTyped(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fmt.Errorf("function %s: expected a func, got %T", name, fn)
+	}
+	manifest, err := funcManifest(name, v.Type())
+	if err != nil {
+		return err
+	}
+
+	err = b.Register(name, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return callFunc(ctx, name, v, args)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.manifests[name] = manifest
+	return nil
+}
+
+// Document describes a registered function for its manifest and the API
+// specs built from it. params names its arguments in order.
+func (b *SimpleBridge) Document(name, description string, params ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.functions[name]; !exists {
+		return Errorf(CodeNotFound, "function %s not found", name)
+	}
+	manifest, typed := b.manifests[name]
+	if typed && !manifest.Variadic && len(params) > len(manifest.Args) {
+		return Errorf(CodeInvalidArgument, "function %s takes %d arguments, got %d names", name, len(manifest.Args), len(params))
+	}
+	b.docs[name] = functionDoc{description: description, params: append([]string(nil), params...)}
+	return nil
+}
+
+// functionDoc is what Document records about a function
+type functionDoc struct {
+	description string
+	params      []string
+}
+
+// Manifest describes every function callable through the bridge, sorted by
+// name. Functions added with Register accept and return any values.
+func (b *SimpleBridge) Manifest() []FunctionManifest {
+	b.mu.RLock()
+	manifests := make([]FunctionManifest, 0, len(b.functions))
+	for name := range b.functions {
+		m, ok := b.manifests[name]
+		if !ok {
+			m = untypedManifest(name)
+		}
+		if doc, ok := b.docs[name]; ok {
+			m.Description, m.Params = doc.description, doc.params
+		}
+		manifests = append(manifests, m)
+	}
+	parent := b.parent
+	b.mu.RUnlock()
+
+	if describer, ok := parent.(interface{ Manifest() []FunctionManifest }); ok {
+		for _, m := range describer.Manifest() {
+			if !b.has(m.Name) {
+				manifests = append(manifests, m)
+			}
+		}
+	}
+
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Name < manifests[j].Name })
+	return manifests
+}
+
+// funcManifest describes a function type registered as name
+func funcManifest(name string, t reflect.Type) (FunctionManifest, error) {
+	manifest := FunctionManifest{Name: name, Args: []TypeManifest{}, Variadic: t.IsVariadic()}
+
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if i == 0 && in == contextType {
+			continue
+		}
+		if manifest.Variadic && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		manifest.Args = append(manifest.Args, typeManifest(in, nil))
+	}
+
+	out := t.NumOut()
+	if out > 0 && t.Out(out-1) == errorType {
+		out--
+	}
+	switch out {
+	case 0:
+		manifest.Returns = TypeManifest{Kind: KindVoid}
+	case 1:
+		manifest.Returns = typeManifest(t.Out(0), nil)
+	default:
+		return FunctionManifest{}, fmt.Errorf("function %s: at most one result besides error is supported", name)
+	}
+	return manifest, nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeManifest describes t as encoding/json renders it. seen holds the
+// struct types being described, so recursive types end in any.
+func typeManifest(t reflect.Type, seen map[reflect.Type]bool) TypeManifest {
+	if values, ok := enumValues(t); ok {
+		return TypeManifest{Kind: KindString, Enum: values}
+	}
+
+	switch {
+	case t.Implements(jsonMarshalerType):
+		return TypeManifest{Kind: KindAny}
+	case t.Implements(textMarshalerType):
+		return TypeManifest{Kind: KindString}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return TypeManifest{Kind: KindString}
+	case reflect.Bool:
+		return TypeManifest{Kind: KindBoolean}
+	case reflect.Ptr:
+		m := typeManifest(t.Elem(), seen)
+		m.Nullable = true
+		return m
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return TypeManifest{Kind: KindBytes}
+		}
+		elem := typeManifest(t.Elem(), seen)
+		return TypeManifest{Kind: KindArray, Elem: &elem}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String && !isIntKind(t.Key().Kind()) {
+			return TypeManifest{Kind: KindAny}
+		}
+		elem := typeManifest(t.Elem(), seen)
+		return TypeManifest{Kind: KindMap, Elem: &elem}
+	case reflect.Struct:
+		if seen[t] {
+			return TypeManifest{Kind: KindAny}
+		}
+		if seen == nil {
+			seen = make(map[reflect.Type]bool)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return TypeManifest{Kind: KindObject, Fields: fieldManifests(t, seen)}
+	}
+	if isNumberKind(t.Kind()) {
+		return TypeManifest{Kind: KindNumber}
+	}
+	return TypeManifest{Kind: KindAny}
+}
+
+// fieldManifests describes the exported fields of a struct, flattening
+// untagged embedded structs as encoding/json does
+func fieldManifests(t reflect.Type, seen map[reflect.Type]bool) []FieldManifest {
+	fields := []FieldManifest{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, fieldManifests(ft, seen)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, FieldManifest{
+			Name:     name,
+			Type:     typeManifest(f.Type, seen),
+			Optional: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
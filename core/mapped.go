@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// MappedExecutor is implemented by runtimes that can bind named inputs as
+// variables before running code and read named variables afterwards
+type MappedExecutor interface {
+	// ExecuteMapped sets each input as a variable, runs code and returns
+	// the value of each named output variable
+	ExecuteMapped(ctx context.Context, code string, inputs map[string]interface{}, outputs []string) (map[string]interface{}, error)
+}
+
+// variableName matches names every runtime accepts as a variable
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExecuteMapped runs code in a runtime with inputs bound as variables and
+// returns the named output variables, instead of the value of the code's
+// last expression:
+//
+//	out, err := orch.ExecuteMapped(ctx, "python", "z = x * y", map[string]interface{}{"x": 2, "y": 3}, []string{"z"})
+//
+// Outputs the code did not set are an error in runtimes that can tell
+// them apart from nil, such as Python; in Lua they are nil. Middleware
+// sees the outputs as a map[string]interface{}.
+func (o *Orchestrator) ExecuteMapped(ctx context.Context, runtime string, code string, inputs map[string]interface{}, outputNames []string) (map[string]interface{}, error) {
+	for name := range inputs {
+		if !variableName.MatchString(name) {
+			return nil, Errorf(CodeInvalidArgument, "invalid input variable name %q", name)
+		}
+	}
+	for _, name := range outputNames {
+		if !variableName.MatchString(name) {
+			return nil, Errorf(CodeInvalidArgument, "invalid output variable name %q", name)
+		}
+	}
+
+	o.mu.RLock()
+	rt, exists := o.runtimes[runtime]
+	o.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("runtime %s not found", runtime)
+	}
+	mapped, ok := rt.(MappedExecutor)
+	if !ok {
+		return nil, Errorf(CodeInvalidArgument, "runtime %s does not support mapped execution", runtime)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := o.ensureInitialized(ctx, runtime); err != nil {
+		return nil, err
+	}
+
+	result, err := o.handle(ctx, &Request{Runtime: runtime, Code: code}, func(ctx context.Context, req *Request) (interface{}, error) {
+		code, err := o.transform(req.Runtime, req.Code)
+		if err != nil {
+			return nil, err
+		}
+		ctx, finish := o.watch(ctx, req.Runtime, code)
+		defer finish()
+		outputs, err := mapped.ExecuteMapped(ctx, code, inputs, outputNames)
+		if err != nil {
+			return nil, err
+		}
+		return outputs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	outputs, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("middleware replaced outputs with %T", result)
+	}
+	return outputs, nil
+}
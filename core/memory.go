@@ -1,17 +1,23 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"unsafe"
 )
 
 // MemoryCoordinator manages shared memory across runtimes
 type MemoryCoordinator struct {
 	config  MemoryConfig
 	regions map[string]*MemoryRegion
+	signals map[string]chan struct{}
 	usage   int64
 	mu      sync.RWMutex
+
+	// tracer receives region lifecycle events, nil unless traced
+	tracer atomic.Pointer[memoryTracer]
 }
 
 // NewMemoryCoordinator creates a memory coordinator
@@ -19,12 +25,18 @@ func NewMemoryCoordinator(config MemoryConfig) *MemoryCoordinator {
 	return &MemoryCoordinator{
 		config:  config,
 		regions: make(map[string]*MemoryRegion),
+		signals: make(map[string]chan struct{}),
 		usage:   0,
 	}
 }
 
 // Allocate creates a new shared memory region
 func (m *MemoryCoordinator) Allocate(id string, size int, memType MemoryType) (*MemoryRegion, error) {
+	return m.allocate("", id, size, memType)
+}
+
+// allocate creates a region on behalf of runtime
+func (m *MemoryCoordinator) allocate(runtime, id string, size int, memType MemoryType) (*MemoryRegion, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -39,12 +51,14 @@ func (m *MemoryCoordinator) Allocate(id string, size int, memType MemoryType) (*
 	}
 
 	region := &MemoryRegion{
-		ID:   id,
-		Data: make([]byte, size),
-		Type: memType,
+		ID:          id,
+		Data:        alignedBytes(size),
+		Type:        memType,
+		coordinator: m,
 	}
 
 	m.regions[id] = region
+	m.trace(RegionEvent{Op: RegionAllocate, Region: id, Runtime: runtime, Size: size})
 	return region, nil
 }
 
@@ -63,6 +77,11 @@ func (m *MemoryCoordinator) Get(id string) (*MemoryRegion, error) {
 
 // Free releases a memory region
 func (m *MemoryCoordinator) Free(id string) error {
+	return m.free("", id)
+}
+
+// free releases a region on behalf of runtime
+func (m *MemoryCoordinator) free(runtime, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -77,6 +96,8 @@ func (m *MemoryCoordinator) Free(id string) error {
 
 	atomic.AddInt64(&m.usage, -int64(len(region.Data)))
 	delete(m.regions, id)
+	m.signal(id)
+	m.trace(RegionEvent{Op: RegionFree, Region: id, Runtime: runtime, Size: len(region.Data)})
 
 	return nil
 }
@@ -147,6 +168,57 @@ func (m *MemoryCoordinator) ReleaseWrite(id string) error {
 	return nil
 }
 
+// Notify wakes every Wait on a region, such as after writing new data to
+// it, so runtimes can hand data to each other without polling
+func (m *MemoryCoordinator) Notify(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.regions[id]; !exists {
+		return fmt.Errorf("region %s not found", id)
+	}
+
+	m.signal(id)
+	return nil
+}
+
+// Wait blocks until the next Notify on a region or until ctx is done.
+// Freeing the region wakes it with an error.
+func (m *MemoryCoordinator) Wait(ctx context.Context, id string) error {
+	m.mu.Lock()
+	if _, exists := m.regions[id]; !exists {
+		m.mu.Unlock()
+		return fmt.Errorf("region %s not found", id)
+	}
+	signal, ok := m.signals[id]
+	if !ok {
+		signal = make(chan struct{})
+		m.signals[id] = signal
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-signal:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, exists := m.regions[id]; !exists {
+		return fmt.Errorf("region %s was freed", id)
+	}
+	return nil
+}
+
+// signal wakes the waiters on a region. Callers hold m.mu.
+func (m *MemoryCoordinator) signal(id string) {
+	if signal, ok := m.signals[id]; ok {
+		close(signal)
+		delete(m.signals, id)
+	}
+}
+
 // Usage returns current memory usage in bytes
 func (m *MemoryCoordinator) Usage() int64 {
 	return atomic.LoadInt64(&m.usage)
@@ -164,3 +236,116 @@ func (m *MemoryCoordinator) Stats() map[string]interface{} {
 		"utilization": float64(m.Usage()) / float64(m.config.MaxSharedMemory),
 	}
 }
+
+// ErrOutOfBounds is returned by region accesses outside the region
+var ErrOutOfBounds = NewError(CodeInvalidArgument, "memory access out of bounds")
+
+// Write copies data into the region at offset, returning the bytes
+// written. Writes that do not fit fail with ErrOutOfBounds and write
+// nothing, rather than truncating as copy into Data would.
+func (r *MemoryRegion) Write(offset int, data []byte) (int, error) {
+	return r.write("", offset, data)
+}
+
+// write copies data into the region on behalf of runtime
+func (r *MemoryRegion) write(runtime string, offset int, data []byte) (int, error) {
+	if err := r.checkBounds("write", offset, len(data)); err != nil {
+		return 0, err
+	}
+	n := copy(r.Data[offset:], data)
+	r.coordinator.trace(RegionEvent{Op: RegionWrite, Region: r.ID, Runtime: runtime, Size: len(r.Data), Offset: offset, Length: n})
+	return n, nil
+}
+
+// Read returns a copy of length bytes of the region at offset. Reads past
+// the end fail with ErrOutOfBounds.
+func (r *MemoryRegion) Read(offset, length int) ([]byte, error) {
+	return r.read("", offset, length)
+}
+
+// read copies bytes out of the region on behalf of runtime
+func (r *MemoryRegion) read(runtime string, offset, length int) ([]byte, error) {
+	if err := r.checkBounds("read", offset, length); err != nil {
+		return nil, err
+	}
+	data := append([]byte(nil), r.Data[offset:offset+length]...)
+	r.coordinator.trace(RegionEvent{Op: RegionRead, Region: r.ID, Runtime: runtime, Size: len(r.Data), Offset: offset, Length: length})
+	return data, nil
+}
+
+// checkBounds fails unless [offset, offset+length) lies within the region
+func (r *MemoryRegion) checkBounds(op string, offset, length int) error {
+	if offset < 0 || length < 0 || offset > len(r.Data) || length > len(r.Data)-offset {
+		return Errorf(CodeInvalidArgument, "%w: %s of %d bytes at offset %d in region %s of %d bytes",
+			ErrOutOfBounds, op, length, offset, r.ID, len(r.Data))
+	}
+	return nil
+}
+
+// ErrUnaligned is returned by atomic accesses at offsets that are not
+// 8-byte aligned
+var ErrUnaligned = NewError(CodeInvalidArgument, "unaligned atomic access")
+
+// alignedBytes allocates size bytes starting on an 8-byte boundary, so
+// regions support atomic access at every multiple of 8
+func alignedBytes(size int) []byte {
+	if size <= 0 {
+		return make([]byte, size)
+	}
+	words := make([]uint64, (size+7)/8)
+	return unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+}
+
+// LoadInt64 atomically reads the int64 at offset. Atomic accessors use
+// the host byte order and are sequentially consistent with each other,
+// so runtimes and goroutines can coordinate through a region without
+// locks. Offsets must be multiples of 8.
+func (r *MemoryRegion) LoadInt64(offset int) (int64, error) {
+	addr, err := r.int64At(offset)
+	if err != nil {
+		return 0, err
+	}
+	return atomic.LoadInt64(addr), nil
+}
+
+// StoreInt64 atomically writes value at offset
+func (r *MemoryRegion) StoreInt64(offset int, value int64) error {
+	addr, err := r.int64At(offset)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt64(addr, value)
+	return nil
+}
+
+// AddInt64 atomically adds delta to the int64 at offset, returning the
+// new value
+func (r *MemoryRegion) AddInt64(offset int, delta int64) (int64, error) {
+	addr, err := r.int64At(offset)
+	if err != nil {
+		return 0, err
+	}
+	return atomic.AddInt64(addr, delta), nil
+}
+
+// CompareAndSwapInt64 atomically replaces the int64 at offset with new if
+// it holds old, reporting whether it did
+func (r *MemoryRegion) CompareAndSwapInt64(offset int, old, new int64) (bool, error) {
+	addr, err := r.int64At(offset)
+	if err != nil {
+		return false, err
+	}
+	return atomic.CompareAndSwapInt64(addr, old, new), nil
+}
+
+// int64At returns the address of the aligned int64 at offset
+func (r *MemoryRegion) int64At(offset int) (*int64, error) {
+	if err := r.checkBounds("atomic access", offset, 8); err != nil {
+		return nil, err
+	}
+	addr := unsafe.Pointer(&r.Data[offset])
+	if uintptr(addr)%8 != 0 {
+		return nil, Errorf(CodeInvalidArgument, "%w at offset %d in region %s", ErrUnaligned, offset, r.ID)
+	}
+	return (*int64)(addr), nil
+}
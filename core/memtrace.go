@@ -0,0 +1,134 @@
+package core
+
+import "fmt"
+
+// RegionOp is an operation on a shared memory region
+type RegionOp string
+
+// Traced region operations
+const (
+	RegionAllocate RegionOp = "allocate"
+	RegionWrite    RegionOp = "write"
+	RegionRead     RegionOp = "read"
+	RegionFree     RegionOp = "free"
+)
+
+// RegionEvent is the payload of TopicMemoryRegion events. Following the
+// events of one Region traces a buffer from allocation to free across the
+// runtimes that touch it.
+type RegionEvent struct {
+	Op     RegionOp
+	Region string
+
+	// Runtime performed the operation through its MemoryView; empty for
+	// operations made directly on the coordinator or region
+	Runtime string
+
+	// Size is the region's size in bytes
+	Size int
+
+	// Offset and Length locate the bytes a write or read touched
+	Offset int
+	Length int
+}
+
+// memoryTracer receives the events of a traced coordinator
+type memoryTracer struct {
+	events *EventBus
+	logger Logger
+}
+
+// Trace publishes a TopicMemoryRegion event on events and logs a debug
+// message to logger for every successful allocate, write, read and free,
+// so zero-copy flows can be followed across runtimes. Either may be nil;
+// with both nil tracing stops. MemoryConfig.Trace enables it on an
+// orchestrator's coordinator.
+func (m *MemoryCoordinator) Trace(events *EventBus, logger Logger) {
+	if events == nil && logger == nil {
+		m.tracer.Store(nil)
+		return
+	}
+	m.tracer.Store(&memoryTracer{events: events, logger: logger})
+}
+
+// trace reports event if the coordinator is traced
+func (m *MemoryCoordinator) trace(event RegionEvent) {
+	if m == nil {
+		return
+	}
+	tracer := m.tracer.Load()
+	if tracer == nil {
+		return
+	}
+	if tracer.events != nil {
+		tracer.events.Publish(TopicMemoryRegion, event)
+	}
+	if tracer.logger != nil {
+		tracer.logger.Log(LogDebug, event.String())
+	}
+}
+
+// String describes the event for logs
+func (e RegionEvent) String() string {
+	by := ""
+	if e.Runtime != "" {
+		by = " by " + e.Runtime
+	}
+	switch e.Op {
+	case RegionWrite, RegionRead:
+		return fmt.Sprintf("memory region %s: %s%s of %d bytes at offset %d", e.Region, e.Op, by, e.Length, e.Offset)
+	default:
+		return fmt.Sprintf("memory region %s: %s%s (%d bytes)", e.Region, e.Op, by, e.Size)
+	}
+}
+
+// MemoryView is one runtime's access to the memory coordinator. Traced
+// operations made through it are tagged with the runtime's name.
+type MemoryView struct {
+	coordinator *MemoryCoordinator
+	runtime     string
+}
+
+// As returns the view through which runtime accesses shared memory
+func (m *MemoryCoordinator) As(runtime string) *MemoryView {
+	return &MemoryView{coordinator: m, runtime: runtime}
+}
+
+// Runtime returns the name the view's operations are tagged with
+func (v *MemoryView) Runtime() string {
+	return v.runtime
+}
+
+// Allocate creates a new shared memory region
+func (v *MemoryView) Allocate(id string, size int, memType MemoryType) (*MemoryRegion, error) {
+	return v.coordinator.allocate(v.runtime, id, size, memType)
+}
+
+// Get retrieves a memory region by ID
+func (v *MemoryView) Get(id string) (*MemoryRegion, error) {
+	return v.coordinator.Get(id)
+}
+
+// Write copies data into a region at offset, as MemoryRegion.Write does
+func (v *MemoryView) Write(id string, offset int, data []byte) (int, error) {
+	region, err := v.coordinator.Get(id)
+	if err != nil {
+		return 0, err
+	}
+	return region.write(v.runtime, offset, data)
+}
+
+// Read returns a copy of length bytes of a region at offset, as
+// MemoryRegion.Read does
+func (v *MemoryView) Read(id string, offset, length int) ([]byte, error) {
+	region, err := v.coordinator.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return region.read(v.runtime, offset, length)
+}
+
+// Free releases a memory region
+func (v *MemoryView) Free(id string) error {
+	return v.coordinator.free(v.runtime, id)
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Request describes an Execute or Call passing through middleware
+type Request struct {
+	Runtime string
+
+	// Function is the function invoked by Call; empty for Execute
+	Function string
+
+	// Code is the code run by Execute; empty for Call
+	Code string
+
+	Args []interface{}
+
+	// redaction holds the rules RedactedArgs applies
+	redaction []RedactionRule
+
+	// adaptive reports that the call has a learned adaptive timeout, which
+	// takes the place of TimeoutMiddleware's default
+	adaptive bool
+}
+
+// Handler runs a request
+type Handler func(ctx context.Context, req *Request) (interface{}, error)
+
+// Middleware wraps the handling of every Execute and Call, for example to
+// adjust the context or inspect results
+type Middleware func(next Handler) Handler
+
+// Use adds middleware around every Execute and Call. Middleware added
+// first runs outermost.
+func (o *Orchestrator) Use(middleware ...Middleware) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.middleware = append(o.middleware, middleware...)
+}
+
+// handle runs req through the middleware, ending in h
+func (o *Orchestrator) handle(ctx context.Context, req *Request, h Handler) (interface{}, error) {
+	o.mu.RLock()
+	middleware := o.middleware
+	req.redaction = o.config.Redaction
+	traceArgs := o.config.TraceArgs
+	o.mu.RUnlock()
+
+	// The breaker sits inside middleware so retries see open circuits
+	if o.breakers != nil {
+		h = o.breakers.middleware(h)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return traceMiddleware(o.traces, traceArgs)(h)(ctx, req)
+}
+
+// noCallerDeadline marks a context whose deadline was set by
+// TimeoutMiddleware rather than the caller
+type noCallerDeadline struct{}
+
+// callerDeadline reports whether the caller, rather than TimeoutMiddleware,
+// gave ctx a deadline
+func callerDeadline(ctx context.Context) bool {
+	if ctx.Value(noCallerDeadline{}) != nil {
+		return false
+	}
+	_, ok := ctx.Deadline()
+	return ok
+}
+
+// TimeoutMiddleware gives requests without a deadline defaultTimeout and
+// caps every deadline, including ones set by the caller, at maxTimeout
+// from the start of the request. Zero disables either limit. Calls with a
+// learned AdaptiveTimeout use it instead of defaultTimeout, still capped at
+// maxTimeout.
+func TimeoutMiddleware(defaultTimeout, maxTimeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (interface{}, error) {
+			if !callerDeadline(ctx) {
+				ctx = context.WithValue(ctx, noCallerDeadline{}, true)
+				if defaultTimeout > 0 && !req.adaptive {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+					defer cancel()
+				}
+			}
+			if maxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, maxTimeout)
+				defer cancel()
+			}
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// MultiStatus describes how one runtime's part of ExecuteMulti ended
+type MultiStatus string
+
+const (
+	MultiOK       MultiStatus = "ok"
+	MultiFailed   MultiStatus = "error"
+	MultiTimedOut MultiStatus = "timeout"
+)
+
+// MultiResult is the outcome of running code in one runtime
+type MultiResult struct {
+	Runtime  string
+	Value    interface{}
+	Err      error
+	Status   MultiStatus
+	Duration time.Duration
+}
+
+// ExecuteMulti runs code, keyed by runtime name, in every runtime
+// concurrently and returns one result per runtime sorted by name. It
+// returns once every runtime finishes or ctx is done; runtimes still
+// running then are reported as timed out, while the results of those that
+// finished are kept.
+func (o *Orchestrator) ExecuteMulti(ctx context.Context, code map[string]string, args ...interface{}) []MultiResult {
+	results := make([]MultiResult, 0, len(code))
+	for result := range o.ExecuteMultiStream(ctx, code, args...) {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Runtime < results[j].Runtime })
+	return results
+}
+
+// ExecuteMultiStream is ExecuteMulti delivering each result as its runtime
+// finishes, so callers can take the fastest or update a dashboard live.
+// When ctx is done, the runtimes still running are sent as timed out and
+// the channel is closed.
+func (o *Orchestrator) ExecuteMultiStream(ctx context.Context, code map[string]string, args ...interface{}) <-chan MultiResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+	out := make(chan MultiResult, len(code))
+	// Buffered so executions finishing after the deadline do not block
+	done := make(chan MultiResult, len(code))
+
+	pending := make(map[string]bool, len(code))
+	for runtime, snippet := range code {
+		pending[runtime] = true
+		go func(runtime, snippet string) {
+			value, err := o.Execute(ctx, runtime, snippet, args...)
+			done <- multiResult(ctx, runtime, value, err, time.Since(start))
+		}(runtime, snippet)
+	}
+
+	go func() {
+		defer close(out)
+		for len(pending) > 0 {
+			select {
+			case result := <-done:
+				delete(pending, result.Runtime)
+				out <- result
+			case <-ctx.Done():
+				names := make([]string, 0, len(pending))
+				for runtime := range pending {
+					names = append(names, runtime)
+				}
+				sort.Strings(names)
+				for _, runtime := range names {
+					out <- MultiResult{
+						Runtime:  runtime,
+						Err:      Errorf(CodeTimeout, "runtime %s did not finish in time: %w", runtime, ctx.Err()),
+						Status:   MultiTimedOut,
+						Duration: time.Since(start),
+					}
+				}
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// multiResult classifies the outcome of one execution. Errors caused by
+// ctx ending count as timeouts.
+func multiResult(ctx context.Context, runtime string, value interface{}, err error, elapsed time.Duration) MultiResult {
+	result := MultiResult{Runtime: runtime, Value: value, Err: err, Status: MultiOK, Duration: elapsed}
+	switch {
+	case err == nil:
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		result.Status = MultiTimedOut
+		result.Err = Errorf(CodeTimeout, "runtime %s did not finish in time: %w", runtime, err)
+	default:
+		result.Status = MultiFailed
+	}
+	return result
+}
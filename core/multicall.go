@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// MultiCaller is implemented by runtimes whose functions can return
+// several values, such as Python tuples and Lua multiple returns
+type MultiCaller interface {
+	// CallMulti calls fn and returns each of its results. A function
+	// returning one value yields a one-element slice.
+	CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error)
+}
+
+// CallMulti calls fn in rt and returns all of its results. Runtimes without
+// multiple returns yield their single result.
+func CallMulti(ctx context.Context, rt Runtime, fn string, args ...interface{}) ([]interface{}, error) {
+	if caller, ok := rt.(MultiCaller); ok {
+		return caller.CallMulti(ctx, fn, args...)
+	}
+	result, err := rt.Call(ctx, fn, args...)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{result}, nil
+}
+
+// CallMulti is Call, returning every result of functions that return
+// several values. Middleware sees the results as a []interface{}.
+func (o *Orchestrator) CallMulti(ctx context.Context, runtime string, fn string, args ...interface{}) ([]interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	result, err := o.handle(ctx, &Request{Runtime: runtime, Function: fn, Args: args}, o.callMulti)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("middleware replaced results of %s with %T", fn, result)
+	}
+	return values, nil
+}
+
+// callMulti is the Handler that ends the middleware chain for CallMulti
+func (o *Orchestrator) callMulti(ctx context.Context, req *Request) (interface{}, error) {
+	if fallback, ok := o.fallbackFor(req.Runtime); ok {
+		result, err := fallback(ctx, req.Function, req.Args...)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{result}, nil
+	}
+
+	o.mu.RLock()
+	rt, exists := o.runtimes[req.Runtime]
+	o.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("runtime %s not found", req.Runtime)
+	}
+	if err := o.ensureInitialized(ctx, req.Runtime); err != nil {
+		return nil, err
+	}
+
+	ctx, finish := o.watch(ctx, req.Runtime, req.Function)
+	defer finish()
+
+	values, err := CallMulti(ctx, rt, req.Function, req.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
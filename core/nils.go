@@ -0,0 +1,46 @@
+package core
+
+import "reflect"
+
+// NilPolicy controls which empty value Go nil becomes in JavaScript, the
+// one language with two. In the other direction null and undefined both
+// become nil, as do Python None, Ruby nil, Lua nil and PHP null.
+type NilPolicy string
+
+const (
+	// NilNull converts nil to null. This is the default.
+	NilNull NilPolicy = "null"
+
+	// NilUndefined converts nil to undefined, so JavaScript default
+	// parameters and optional fields treat it as missing
+	NilUndefined NilPolicy = "undefined"
+)
+
+// ParseNilPolicy returns the policy for a config value, defaulting to null
+func ParseNilPolicy(name string) NilPolicy {
+	if NilPolicy(name) == NilUndefined {
+		return NilUndefined
+	}
+	return NilNull
+}
+
+// NilPolicyFor returns the nil policy set in a runtime's
+// Options["nil_policy"]
+func NilPolicyFor(config RuntimeConfig) NilPolicy {
+	name, _ := config.Options["nil_policy"].(string)
+	return ParseNilPolicy(name)
+}
+
+// IsNil reports whether v is nil, including typed nils such as a nil
+// slice, map or pointer held in an interface. Runtimes convert every nil
+// to the target language's nil rather than an empty list or object.
+func IsNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
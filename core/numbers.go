@@ -0,0 +1,113 @@
+package core
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// NumberPolicy controls how numbers are represented when crossing the bridge
+type NumberPolicy string
+
+const (
+	// NumbersFloat decodes every number as float64, matching JavaScript.
+	// This is the default.
+	NumbersFloat NumberPolicy = "float"
+
+	// NumbersInteger keeps integers integral: integral values decode as
+	// int64, and integers outside JavaScript's safe range are encoded as
+	// decimal strings so the frontend displays them without rounding.
+	NumbersInteger NumberPolicy = "integer"
+)
+
+// maxSafeInteger is the largest integer a JavaScript number represents exactly
+const maxSafeInteger = 1<<53 - 1
+
+// ParseNumberPolicy returns the policy for a config value, defaulting to float
+func ParseNumberPolicy(name string) NumberPolicy {
+	if NumberPolicy(name) == NumbersInteger {
+		return NumbersInteger
+	}
+	return NumbersFloat
+}
+
+// CodecWithPolicy returns the codec for a wire format using a number policy
+func CodecWithPolicy(format string, numbers NumberPolicy) Codec {
+	return CodecWithPolicies(format, numbers, TimesRFC3339)
+}
+
+// CodecWithPolicies returns the codec for a wire format using a number
+// and a time policy
+func CodecWithPolicies(format string, numbers NumberPolicy, times TimePolicy) Codec {
+	if format == FormatMsgpack {
+		return MsgpackCodec{Numbers: numbers, Times: times}
+	}
+	return JSONCodec{Numbers: numbers, Times: times}
+}
+
+// safeIntegers replaces integers JavaScript cannot represent exactly with
+// decimal strings, descending into generic slices and maps
+func safeIntegers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return safeInt64(int64(val))
+	case int64:
+		return safeInt64(val)
+	case uint:
+		return safeUint64(uint64(val))
+	case uint64:
+		return safeUint64(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = safeIntegers(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = safeIntegers(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func safeInt64(n int64) interface{} {
+	if n > maxSafeInteger || n < -maxSafeInteger {
+		return strconv.FormatInt(n, 10)
+	}
+	return n
+}
+
+func safeUint64(n uint64) interface{} {
+	if n > maxSafeInteger {
+		return strconv.FormatUint(n, 10)
+	}
+	return n
+}
+
+// integralNumbers converts json.Number values to int64 when integral and
+// float64 otherwise
+func integralNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		for i, item := range val {
+			val[i] = integralNumbers(item)
+		}
+		return val
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = integralNumbers(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
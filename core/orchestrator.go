@@ -3,17 +3,37 @@ package core
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Orchestrator coordinates all language runtimes
 type Orchestrator struct {
-	config   *Config
-	runtimes map[string]Runtime
-	memory   *MemoryCoordinator
-	bridge   Bridge
-	mu       sync.RWMutex
-	shutdown chan struct{}
+	config     *Config
+	runtimes   map[string]Runtime
+	memory     *MemoryCoordinator
+	fds        *DescriptorTracker
+	bridge     Bridge
+	policy     *InputPolicy
+	health     map[string]RuntimeHealth
+	active     map[string]RuntimeConfig
+	events     *EventBus
+	watchdog   *Watchdog
+	latency    *latencyTracker
+	breakers   *circuitBreakers
+	fallbacks  map[string]FallbackFunc
+	transforms map[string]TransformFunc
+	processors []ResultProcessor
+	middleware []Middleware
+	traces     *traceRing
+	inflight   *inflight
+	lazy       map[string]*lazyInit
+	lazyMu     sync.Mutex
+	initMu     sync.Mutex
+	mu         sync.RWMutex
+	shutdown   chan struct{}
 }
 
 // NewOrchestrator creates a new orchestrator instance
@@ -22,12 +42,31 @@ func NewOrchestrator(config *Config) (*Orchestrator, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	return &Orchestrator{
-		config:   config,
-		runtimes: make(map[string]Runtime),
-		memory:   NewMemoryCoordinator(config.Memory),
-		shutdown: make(chan struct{}),
-	}, nil
+	o := &Orchestrator{
+		config:     config,
+		runtimes:   make(map[string]Runtime),
+		health:     make(map[string]RuntimeHealth),
+		active:     make(map[string]RuntimeConfig),
+		fallbacks:  make(map[string]FallbackFunc),
+		transforms: make(map[string]TransformFunc),
+		events:     NewEventBus(),
+		traces:     newTraceRing(snapshotTraces),
+		inflight:   &inflight{},
+		lazy:       make(map[string]*lazyInit),
+		memory:     NewMemoryCoordinator(config.Memory),
+		fds:        NewDescriptorTracker(config.MaxFileDescriptors),
+		shutdown:   make(chan struct{}),
+	}
+	if config.AdaptiveTimeout != nil {
+		o.latency = newLatencyTracker(*config.AdaptiveTimeout)
+	}
+	if config.CircuitBreaker != nil {
+		o.breakers = newCircuitBreakers(*config.CircuitBreaker, o.events)
+	}
+	if config.Memory.Trace {
+		o.memory.Trace(o.events, config.Logger)
+	}
+	return o, nil
 }
 
 // RegisterRuntime adds a runtime to the orchestrator
@@ -44,53 +83,448 @@ func (o *Orchestrator) RegisterRuntime(runtime Runtime) error {
 	return nil
 }
 
-// Initialize starts all enabled runtimes
+// Initialize starts all enabled runtimes, up to InitConcurrency at once,
+// running a selftest for those configured with SelfTest. Once a runtime
+// fails, no further runtimes are started and the failure is returned; use
+// InitializeReport to continue past failures.
 func (o *Orchestrator) Initialize(ctx context.Context) error {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
+	for _, result := range o.initRuntimes(ctx, true) {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// InitializeReport starts every enabled runtime, up to InitConcurrency at
+// once, continuing past failures, and reports which runtimes are usable
+func (o *Orchestrator) InitializeReport(ctx context.Context) *InitReport {
+	return &InitReport{Results: o.initRuntimes(ctx, false)}
+}
 
+// enabledRuntimes returns the names of enabled runtimes in order, leaving
+// out LazyInit runtimes, which start on their first call. Callers must
+// hold o.mu.
+func (o *Orchestrator) enabledRuntimes() []string {
+	names := make([]string, 0, len(o.config.Languages))
 	for name, cfg := range o.config.Languages {
-		if !cfg.Enabled {
+		if cfg.Enabled && !cfg.LazyInit {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// initRuntimes starts the enabled runtimes concurrently, bounded by
+// InitConcurrency, and records their health. With stopOnError, runtimes
+// not yet started when one fails are skipped and left out of the results.
+//
+// The runtimes start without holding o.mu, as LazyInit runtimes do, so
+// runtimes already running keep serving and Health stays readable.
+// o.initMu keeps Reconfigure from starting the same runtimes meanwhile.
+func (o *Orchestrator) initRuntimes(ctx context.Context, stopOnError bool) []RuntimeInitResult {
+	o.initMu.Lock()
+	defer o.initMu.Unlock()
+
+	o.mu.RLock()
+	names := o.enabledRuntimes()
+	starts := make([]runtimeStart, len(names))
+	for i, name := range names {
+		starts[i] = o.prepareStart(name, o.config.Languages[name])
+	}
+	limit := o.config.InitConcurrency
+	o.mu.RUnlock()
+	if limit <= 0 {
+		limit = DefaultInitConcurrency
+	}
+
+	type outcome struct {
+		started  bool
+		health   RuntimeHealth
+		err      error
+		duration time.Duration
+	}
+	outcomes := make([]outcome, len(names))
+
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	slots := make(chan struct{}, limit)
+	for i := range names {
+		slots <- struct{}{}
+		if stopOnError && failed.Load() {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, start runtimeStart) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			began := time.Now()
+			health, err := o.startRuntime(ctx, start)
+			outcomes[i] = outcome{started: true, health: health, err: err, duration: time.Since(began)}
+			if err != nil {
+				failed.Store(true)
+			}
+		}(i, starts[i])
+	}
+	wg.Wait()
+
+	o.mu.Lock()
+	results := make([]RuntimeInitResult, 0, len(names))
+	var stopped []Runtime
+	for i, name := range names {
+		out := outcomes[i]
+		if !out.started {
 			continue
 		}
+		if out.health.Initialized && !o.stillEnabled(name) {
+			stopped = append(stopped, starts[i].runtime)
+			out.err = Errorf(CodeUnavailable, "runtime %s was disabled while it started", name)
+		} else {
+			o.recordInit(name, starts[i].config, out.health)
+		}
+		results = append(results, RuntimeInitResult{Runtime: name, Err: out.err, Duration: out.duration})
+	}
+	o.mu.Unlock()
+
+	for _, runtime := range stopped {
+		runtime.Shutdown(ctx)
+	}
+	return results
+}
+
+// initRuntime initializes and selftests one runtime, recording its health.
+// Callers must hold o.mu.
+func (o *Orchestrator) initRuntime(ctx context.Context, name string, cfg *RuntimeConfig) error {
+	health, err := o.startRuntime(ctx, o.prepareStart(name, cfg))
+	o.recordInit(name, cfg, health)
+	return err
+}
+
+// recordInit stores the health from startRuntime, marking initialized
+// runtimes active. Callers must hold o.mu.
+func (o *Orchestrator) recordInit(name string, cfg *RuntimeConfig, health RuntimeHealth) {
+	if health.Runtime == "" {
+		return
+	}
+	o.health[name] = health
+	if health.Initialized {
+		o.active[name] = snapshotConfig(cfg)
+		o.logf(LogInfo, "runtime %s initialized (version %s)", name, health.Version)
+	} else {
+		o.logf(LogWarn, "runtime %s not initialized: %s", name, health.Error)
+	}
+}
+
+// logf sends a startup event to the configured logger, if any
+func (o *Orchestrator) logf(level LogLevel, format string, args ...interface{}) {
+	logTo(o.config.Logger, level, format, args...)
+}
+
+// logTo sends a startup event to logger, if any
+func logTo(logger Logger, level LogLevel, format string, args ...interface{}) {
+	if logger != nil {
+		logger.Log(level, fmt.Sprintf(format, args...))
+	}
+}
+
+// runtimeStart is what starting a runtime reads from the orchestrator,
+// copied under o.mu so startRuntime can run without holding it
+type runtimeStart struct {
+	name     string
+	runtime  Runtime
+	config   *RuntimeConfig
+	strict   bool
+	fallback bool
+	logger   Logger
+}
+
+// prepareStart copies what startRuntime needs to start the named runtime
+// with cfg. Callers must hold o.mu.
+func (o *Orchestrator) prepareStart(name string, cfg *RuntimeConfig) runtimeStart {
+	config := *cfg
+	_, fallback := o.fallbacks[name]
+	return runtimeStart{
+		name:     name,
+		runtime:  o.runtimes[name],
+		config:   &config,
+		strict:   o.config.StrictRuntimes,
+		fallback: fallback,
+		logger:   o.config.Logger,
+	}
+}
+
+// startRuntime initializes and selftests one runtime without recording
+// anything or holding o.mu, so several can start at once and other
+// runtimes keep serving. It returns an empty health for runtimes that are
+// not registered.
+func (o *Orchestrator) startRuntime(ctx context.Context, start runtimeStart) (RuntimeHealth, error) {
+	name, runtime, cfg := start.name, start.runtime, start.config
+	if runtime == nil {
+		return RuntimeHealth{}, fmt.Errorf("runtime %s not registered", name)
+	}
+
+	health := RuntimeHealth{
+		Runtime:   name,
+		Stubbed:   IsStub(runtime),
+		SelfTest:  SelfTestNotRun,
+		CheckedAt: time.Now(),
+	}
+
+	if health.Stubbed && start.strict {
+		health.Error = "runtime not enabled in build; strict runtimes require it"
+		return health, Errorf(CodeUnavailable, "failed to initialize %s: %w; build with its runtime tag or disable it",
+			name, ErrStubRuntime).WithDetail("runtime", name)
+	}
+
+	if start.fallback && health.Stubbed {
+		health.Error = "runtime not enabled in build; calls use the registered fallback"
+		return health, nil
+	}
 
-		runtime, exists := o.runtimes[name]
-		if !exists {
-			return fmt.Errorf("runtime %s not registered", name)
+	if capabilities, ok := CapabilitiesOf(runtime); ok {
+		health.Concurrency = capabilities.Concurrency
+		if warning := concurrencyWarning(name, capabilities.Concurrency, cfg); warning != "" && !health.Stubbed {
+			logTo(start.logger, LogWarn, "%s", warning)
 		}
+	}
+
+	if err := runtime.Initialize(ctx, o.runtimeConfig(*cfg)); err != nil {
+		health.Error = err.Error()
+		return health, fmt.Errorf("failed to initialize %s: %w", name, err)
+	}
+	health.Version = runtime.Version()
+
+	if err := CheckVersion(name, health.Version, cfg.MinVersion, cfg.MaxVersion); err != nil {
+		health.Error = err.Error()
+		return health, fmt.Errorf("failed to initialize %s: %w", name, err)
+	}
 
-		if err := runtime.Initialize(ctx, *cfg); err != nil {
-			return fmt.Errorf("failed to initialize %s: %w", name, err)
+	if cfg.SelfTest {
+		status, err := RunSelfTest(ctx, runtime)
+		health.SelfTest = status
+		if err != nil {
+			health.Error = err.Error()
+			return health, fmt.Errorf("failed to initialize %s: %w", name, err)
 		}
 	}
 
-	return nil
+	health.Initialized = true
+	return health, nil
 }
 
-// Execute runs code in a specific runtime
+// runtimeConfig fills in the settings a runtime shares with the
+// orchestrator, its event bus and descriptor tracker, when cfg leaves
+// them unset
+func (o *Orchestrator) runtimeConfig(cfg RuntimeConfig) RuntimeConfig {
+	if cfg.Events == nil {
+		cfg.Events = o.events
+	}
+	if cfg.Descriptors == nil {
+		cfg.Descriptors = o.fds
+	}
+	return cfg
+}
+
+// Health returns the initialization and selftest status of each runtime
+// that has been initialized
+func (o *Orchestrator) Health() map[string]RuntimeHealth {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	health := make(map[string]RuntimeHealth, len(o.health))
+	for name, h := range o.health {
+		h.Ready = h.Initialized && isReady(o.runtimes[name])
+		health[name] = h
+	}
+	return health
+}
+
+// Execute runs code in a specific runtime, after the runtime's transform
+// if one is set
 func (o *Orchestrator) Execute(ctx context.Context, runtime string, code string, args ...interface{}) (interface{}, error) {
+	result, err := o.ExecuteInfo(ctx, runtime, code, args...)
+	return result.Value, err
+}
+
+// ExecuteInfo runs code in a specific runtime and reports which runtime
+// version and worker handled it
+func (o *Orchestrator) ExecuteInfo(ctx context.Context, runtime string, code string, args ...interface{}) (ExecResult, error) {
 	o.mu.RLock()
 	rt, exists := o.runtimes[runtime]
 	o.mu.RUnlock()
 
 	if !exists {
-		return nil, fmt.Errorf("runtime %s not found", runtime)
+		return ExecResult{WorkerID: -1}, fmt.Errorf("runtime %s not found", runtime)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := o.ensureInitialized(ctx, runtime); err != nil {
+		return ExecResult{WorkerID: -1}, err
+	}
+
+	// The recorder goes on ctx before the middleware runs, so a handler
+	// still running after a timeout returned only stores into it atomically
+	ctx, worker := withWorkerRecorder(ctx)
+	start := time.Now()
+	req := &Request{Runtime: runtime, Code: code, Args: args}
+	value, err := o.handle(ctx, req, func(ctx context.Context, req *Request) (interface{}, error) {
+		code, err := o.transform(req.Runtime, req.Code)
+		if err != nil {
+			return nil, err
+		}
+		ctx, finish := o.watch(ctx, req.Runtime, code)
+		defer finish()
+		return rt.Execute(ctx, code, req.Args...)
+	})
+
+	if err == nil {
+		value, err = o.process(runtime, value)
 	}
 
-	return rt.Execute(ctx, code, args...)
+	return ExecResult{
+		Value:    value,
+		Runtime:  rt.Name(),
+		Version:  rt.Version(),
+		WorkerID: int(atomic.LoadInt64(worker)),
+		Duration: time.Since(start),
+	}, err
 }
 
-// Call invokes a function in a specific runtime
+// SetInputPolicy configures the policy used by ExecuteSafe
+func (o *Orchestrator) SetInputPolicy(policy *InputPolicy) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.policy = policy
+}
+
+// ExecuteSafe runs code from an untrusted source after checking it against
+// the input policy, returning a *PolicyViolationError for rejected input.
+// DefaultInputPolicy is used when no policy has been set.
+func (o *Orchestrator) ExecuteSafe(ctx context.Context, runtime string, code string, args ...interface{}) (interface{}, error) {
+	o.mu.RLock()
+	policy := o.policy
+	o.mu.RUnlock()
+
+	if policy == nil {
+		policy = DefaultInputPolicy()
+	}
+
+	if err := policy.Check(runtime, code); err != nil {
+		return nil, err
+	}
+
+	return o.Execute(ctx, runtime, code, args...)
+}
+
+// Call invokes a function in a specific runtime. With AdaptiveTimeout
+// configured, calls without a deadline get one learned from the function's
+// latency. Calls to a stubbed runtime go to its fallback, if one is set.
 func (o *Orchestrator) Call(ctx context.Context, runtime string, fn string, args ...interface{}) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req := &Request{Runtime: runtime, Function: fn, Args: args}
+	_, req.adaptive = o.AdaptiveTimeout(runtime, fn)
+	result, err := o.handle(ctx, req, o.call)
+	if err != nil {
+		return result, err
+	}
+	return o.process(runtime, result)
+}
+
+// call is the Handler that ends the middleware chain for Call
+func (o *Orchestrator) call(ctx context.Context, req *Request) (interface{}, error) {
+	if fallback, ok := o.fallbackFor(req.Runtime); ok {
+		return fallback(ctx, req.Function, req.Args...)
+	}
+
 	o.mu.RLock()
-	rt, exists := o.runtimes[runtime]
+	rt, exists := o.runtimes[req.Runtime]
 	o.mu.RUnlock()
 
 	if !exists {
-		return nil, fmt.Errorf("runtime %s not found", runtime)
+		return nil, fmt.Errorf("runtime %s not found", req.Runtime)
 	}
+	if err := o.ensureInitialized(ctx, req.Runtime); err != nil {
+		return nil, err
+	}
+
+	ctx, finish := o.watch(ctx, req.Runtime, req.Function)
+	defer finish()
 
-	return rt.Call(ctx, fn, args...)
+	if o.latency != nil {
+		return o.callAdaptive(ctx, rt, req.Runtime, req.Function, req.Args...)
+	}
+	return rt.Call(ctx, req.Function, req.Args...)
+}
+
+// Events returns the orchestrator event bus
+func (o *Orchestrator) Events() *EventBus {
+	return o.events
+}
+
+// EnableWatchdog starts reporting executions that run longer than a
+// multiple of their runtime's Timeout as TopicExecutionStuck events
+func (o *Orchestrator) EnableWatchdog(config WatchdogConfig) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.watchdog != nil {
+		o.watchdog.close()
+	}
+	wd := newWatchdog(config, o.events)
+
+	interval := config.Interval
+	if interval <= 0 {
+		smallest := wd.threshold(0)
+		for _, cfg := range o.config.Languages {
+			if t := wd.threshold(cfg.Timeout); t < smallest {
+				smallest = t
+			}
+		}
+		interval = smallest / 4
+		if interval < 10*time.Millisecond {
+			interval = 10 * time.Millisecond
+		}
+	}
+
+	o.watchdog = wd
+	go wd.run(interval)
+}
+
+// watch registers an execution for CancelAll and with the watchdog,
+// returning a context either can cancel and a function to call when the
+// execution ends
+func (o *Orchestrator) watch(ctx context.Context, runtime string, code string) (context.Context, func()) {
+	o.mu.RLock()
+	wd := o.watchdog
+	var timeout time.Duration
+	if cfg, ok := o.config.Languages[runtime]; ok {
+		timeout = cfg.Timeout
+	}
+	o.mu.RUnlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	untrack := o.inflight.track(runtime, cancel)
+	if wd == nil {
+		return ctx, func() {
+			untrack()
+			cancel()
+		}
+	}
+
+	id := wd.track(runtime, code, timeout, cancel)
+	return ctx, func() {
+		wd.done(id)
+		untrack()
+		cancel()
+	}
 }
 
 // Memory returns the memory coordinator
@@ -98,6 +532,12 @@ func (o *Orchestrator) Memory() *MemoryCoordinator {
 	return o.memory
 }
 
+// Descriptors returns the tracker of file descriptors held for the
+// programs subprocess runtimes run, whose Stats report their usage
+func (o *Orchestrator) Descriptors() *DescriptorTracker {
+	return o.fds
+}
+
 // SetBridge configures the webview bridge
 func (o *Orchestrator) SetBridge(bridge Bridge) {
 	o.bridge = bridge
@@ -110,6 +550,10 @@ func (o *Orchestrator) Shutdown(ctx context.Context) error {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 
+	if o.watchdog != nil {
+		o.watchdog.close()
+	}
+
 	var errs []error
 	for name, runtime := range o.runtimes {
 		if err := runtime.Shutdown(ctx); err != nil {
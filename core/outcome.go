@@ -0,0 +1,89 @@
+package core
+
+import "fmt"
+
+// ErrorValueKey marks a result of runtime code as an expected failure, such
+// as a failed validation, rather than a value. The marked result is an
+// object whose ErrorValueKey entry is either a message or an object with
+// "code", "message" and optional "details":
+//
+//	return {"__polyglot_error__": {"code": "INVALID_ARGUMENT", "message": "email is invalid"}}
+const ErrorValueKey = "__polyglot_error__"
+
+// Outcome is the result of a computation that may fail in an expected way.
+// Bridge calls resolve with it instead of rejecting, so the frontend
+// handles expected failures as data and keeps rejections for unexpected
+// ones.
+type Outcome struct {
+	// OK reports whether the computation succeeded
+	OK bool `json:"ok"`
+
+	// Value is the result when OK
+	Value interface{} `json:"value,omitempty"`
+
+	// Error describes the expected failure when not OK
+	Error *ErrorInfo `json:"error,omitempty"`
+}
+
+// AsResultOrError turns the result of an execution into an Outcome.
+// Results marked with ErrorValueKey become failed Outcomes, with the code
+// defaulting to CodeInvalidArgument; other results become successful ones.
+// A non-nil err is an unexpected failure and is returned as is, as is a
+// malformed marker. It takes a runtime's return values directly:
+//
+//	bridge.Register("validateEmail", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+//		return core.AsResultOrError(py.Call(ctx, "validate_email", args...))
+//	})
+func AsResultOrError(value interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return Outcome{OK: true, Value: value}, nil
+	}
+	marker, ok := fields[ErrorValueKey]
+	if !ok {
+		return Outcome{OK: true, Value: value}, nil
+	}
+
+	info, err := errorValue(marker)
+	if err != nil {
+		return nil, err
+	}
+	return Outcome{Error: &info}, nil
+}
+
+// errorValue decodes the ErrorValueKey entry of a result
+func errorValue(marker interface{}) (ErrorInfo, error) {
+	info := ErrorInfo{Code: CodeInvalidArgument}
+	switch m := marker.(type) {
+	case string:
+		info.Message = m
+	case map[string]interface{}:
+		if code, ok := m["code"]; ok {
+			s, ok := code.(string)
+			if !ok || s == "" {
+				return ErrorInfo{}, Errorf(CodeInternal, "%s code must be a non-empty string, got %v", ErrorValueKey, code)
+			}
+			info.Code = ErrorCode(s)
+		}
+		if message, ok := m["message"]; ok {
+			info.Message = fmt.Sprint(message)
+		}
+		if details, ok := m["details"]; ok && details != nil {
+			d, ok := details.(map[string]interface{})
+			if !ok {
+				return ErrorInfo{}, Errorf(CodeInternal, "%s details must be an object, got %T", ErrorValueKey, details)
+			}
+			info.Details = d
+		}
+	default:
+		return ErrorInfo{}, Errorf(CodeInternal, "%s must be a message or an object, got %T", ErrorValueKey, marker)
+	}
+	if info.Message == "" {
+		info.Message = string(info.Code)
+	}
+	return info, nil
+}
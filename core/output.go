@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// outputKey carries the writers set by WithOutput
+type outputKey struct{}
+
+type outputWriters struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// WithOutput returns a context whose executions also send what their code
+// prints to stdout and stderr as it is produced. Runtimes may write from
+// the executing thread while holding interpreter locks, so the writers
+// must not block. Python and the subprocess runtimes (C++, Java, PHP,
+// Rust and Zig) stream output; other runtimes ignore it.
+func WithOutput(ctx context.Context, stdout, stderr io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, outputWriters{stdout: stdout, stderr: stderr})
+}
+
+// OutputFrom returns the writers set by WithOutput; either may be nil
+func OutputFrom(ctx context.Context) (stdout, stderr io.Writer) {
+	if ctx == nil {
+		return nil, nil
+	}
+	out, _ := ctx.Value(outputKey{}).(outputWriters)
+	return out.stdout, out.stderr
+}
+
+// OutputWriters returns a runtime's configured Stdout and Stderr, made
+// safe for concurrent use by its workers. Either may be nil. Call it once
+// per runtime so all workers share the lock.
+func OutputWriters(config RuntimeConfig) (stdout, stderr io.Writer) {
+	mu := &sync.Mutex{}
+	wrap := func(w io.Writer) io.Writer {
+		if w == nil {
+			return nil
+		}
+		return &lockedWriter{mu: mu, w: w}
+	}
+	return wrap(config.Stdout), wrap(config.Stderr)
+}
+
+// AddOutput returns a context whose executions also write to stdout and
+// stderr, besides any writers set on ctx with WithOutput
+func AddOutput(ctx context.Context, stdout, stderr io.Writer) context.Context {
+	if stdout == nil && stderr == nil {
+		return ctx
+	}
+	current, currentErr := OutputFrom(ctx)
+	return WithOutput(ctx, TeeWriter(current, stdout), TeeWriter(currentErr, stderr))
+}
+
+// TeeWriter writes to w and extra, either of which may be nil
+func TeeWriter(w, extra io.Writer) io.Writer {
+	switch {
+	case w == nil:
+		return extra
+	case extra == nil:
+		return w
+	}
+	return io.MultiWriter(w, extra)
+}
+
+// lockedWriter serializes writes shared by several workers
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,663 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned when acquiring from a closed WorkerPool
+var ErrPoolClosed = NewError(CodeUnavailable, "worker pool is closed")
+
+// ErrPoolExhausted is returned by TryAcquire when every worker is busy
+var ErrPoolExhausted = NewError(CodeResourceExhausted, "worker pool exhausted")
+
+// WorkerPoolOptions configures a WorkerPool
+type WorkerPoolOptions struct {
+	// Max is the most workers the pool runs
+	Max int
+
+	// Min workers are started up front and never retired
+	Min int
+
+	// IdleTimeout retires workers idle longer than this, down to Min, and
+	// starts workers on demand when callers queue. Zero starts all Max
+	// workers up front and keeps them.
+	IdleTimeout time.Duration
+
+	// Name identifies the pool in scaling events
+	Name string
+
+	// Events receives a TopicPoolScale event whenever workers are started
+	// on demand or retired. Nil disables them.
+	Events *EventBus
+}
+
+// WorkerPoolOptionsFor derives pool options for a runtime from its config.
+// size is the maximum, which runtimes default when MaxConcurrency is unset.
+func WorkerPoolOptionsFor(name string, config RuntimeConfig, size int) WorkerPoolOptions {
+	return WorkerPoolOptions{
+		Max:         size,
+		Min:         config.MinWorkers,
+		IdleTimeout: config.IdleTimeout,
+		Name:        name,
+		Events:      config.Events,
+	}
+}
+
+// PoolScaleEvent is the payload of TopicPoolScale events
+type PoolScaleEvent struct {
+	// Pool is the name from WorkerPoolOptions
+	Pool string
+
+	// Delta is the number of workers started, or retired when negative
+	Delta int
+
+	// Workers is the number of live workers after the change
+	Workers int
+
+	// Waiting is the number of callers queued for a worker
+	Waiting int
+}
+
+// slotState tracks what occupies a worker slot
+type slotState int
+
+const (
+	slotEmpty slotState = iota
+	slotStarting
+	slotIdle
+	slotBusy
+)
+
+type poolSlot[W comparable] struct {
+	worker    W
+	state     slotState
+	idleSince time.Time
+
+	// affine marks a worker that has served an affinity key, whose state
+	// belongs to the key's session
+	affine bool
+}
+
+// WorkerPool holds up to Max workers that callers take turns using.
+// Runtimes build their pools on it to share its queueing, affinity,
+// scaling and shutdown behavior, keeping only the setup of their own
+// workers.
+//
+// Callers waiting for a worker give up when their context ends, and are
+// served as workers are released. With an idle timeout, a caller that
+// finds every worker busy starts a new one if the pool is below Max, and
+// workers left idle are retired down to Min. Resize changes Max and Min
+// while the pool runs. Workers still in use when the pool is closed are
+// destroyed as they are released.
+type WorkerPool[W comparable] struct {
+	opts    WorkerPoolOptions
+	factory func(id int) (W, error)
+	destroy func(W)
+
+	slots    []poolSlot[W]
+	index    map[W]int
+	live     int
+	idle     int
+	starting int
+
+	waiting   int
+	acquired  uint64
+	waits     uint64
+	exhausted uint64
+
+	closed   bool
+	released chan struct{}
+	stop     chan struct{}
+	reaping  sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// NewWorkerPool creates a pool of size workers with factory, which
+// receives each worker's index. If one fails, those already created are
+// destroyed. destroy, which may be nil, tears a worker down when the pool
+// closes.
+func NewWorkerPool[W comparable](size int, factory func(id int) (W, error), destroy func(W)) (*WorkerPool[W], error) {
+	return NewWorkerPoolWith(WorkerPoolOptions{Max: size}, factory, destroy)
+}
+
+// NewWorkerPoolWith creates a pool configured by opts and starts its
+// initial workers, as NewWorkerPool does
+func NewWorkerPoolWith[W comparable](opts WorkerPoolOptions, factory func(id int) (W, error), destroy func(W)) (*WorkerPool[W], error) {
+	if opts.Max <= 0 {
+		return nil, fmt.Errorf("pool size must be positive")
+	}
+	if opts.Min < 0 || opts.Min > opts.Max {
+		return nil, fmt.Errorf("minimum workers must be between 0 and %d", opts.Max)
+	}
+
+	p := &WorkerPool[W]{
+		opts:     opts,
+		factory:  factory,
+		destroy:  destroy,
+		slots:    make([]poolSlot[W], opts.Max),
+		index:    make(map[W]int, opts.Max),
+		released: make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+
+	prewarm := opts.Max
+	if opts.IdleTimeout > 0 {
+		prewarm = opts.Min
+	}
+	for i := 0; i < prewarm; i++ {
+		worker, err := factory(i)
+		if err != nil {
+			for _, slot := range p.slots[:i] {
+				p.teardown(slot.worker)
+			}
+			return nil, fmt.Errorf("failed to initialize worker %d: %w", i, err)
+		}
+		p.slots[i] = poolSlot[W]{worker: worker, state: slotIdle, idleSince: time.Now()}
+		p.index[worker] = i
+	}
+	p.live = prewarm
+	p.idle = prewarm
+
+	if opts.IdleTimeout > 0 {
+		p.reaping.Add(1)
+		go p.reap()
+	}
+	return p, nil
+}
+
+// Acquire returns an idle worker, starting one if the pool scales and is
+// below Max, or waits for one to be released until ctx ends. With an
+// affinity key on ctx, it waits for the key's worker, so state a session
+// leaves in its worker is visible to its next call.
+func (p *WorkerPool[W]) Acquire(ctx context.Context) (W, error) {
+	var zero W
+	key, affine := Affinity(ctx)
+
+	p.mu.Lock()
+	counted := false
+	defer func() {
+		if counted {
+			p.waiting--
+		}
+		p.mu.Unlock()
+	}()
+
+	for {
+		if p.closed {
+			return zero, ErrPoolClosed
+		}
+
+		// The key's slot follows Max, which a Resize may have changed
+		// while the caller waited
+		slot := -1
+		if affine {
+			slot = AffinitySlot(key, p.opts.Max)
+		}
+
+		if i := p.free(slot); i >= 0 {
+			return p.take(i, affine), nil
+		}
+
+		if i := p.vacant(slot); i >= 0 {
+			return p.spawn(i, affine)
+		}
+
+		if !counted {
+			counted = true
+			p.waiting++
+			p.waits++
+		}
+		released := p.released
+		p.mu.Unlock()
+
+		select {
+		case <-released:
+			p.mu.Lock()
+		case <-ctx.Done():
+			p.mu.Lock()
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// TryAcquire returns an idle worker without waiting, failing with
+// ErrPoolExhausted when every worker is busy. A pool that scales starts a
+// worker instead when it is below Max.
+func (p *WorkerPool[W]) TryAcquire() (W, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var zero W
+	if p.closed {
+		return zero, ErrPoolClosed
+	}
+	if i := p.free(-1); i >= 0 {
+		return p.take(i, false), nil
+	}
+	if i := p.vacant(-1); i >= 0 {
+		return p.spawn(i, false)
+	}
+	p.exhausted++
+	return zero, ErrPoolExhausted
+}
+
+// free returns the index of an idle worker, or of slot when it is not
+// negative and idle, or -1. Callers must hold p.mu.
+func (p *WorkerPool[W]) free(slot int) int {
+	if slot >= 0 {
+		if p.slots[slot].state == slotIdle {
+			return slot
+		}
+		return -1
+	}
+	if p.idle == 0 {
+		return -1
+	}
+	for i := range p.slots {
+		if p.slots[i].state == slotIdle {
+			return i
+		}
+	}
+	return -1
+}
+
+// vacant returns the index of an empty slot a worker can be started in,
+// or of slot when it is not negative and empty, or -1. Only pools that
+// scale start workers after creation, except in the slot of an affinity
+// key, which its callers cannot do without, such as one a failed Replace
+// left empty. Callers must hold p.mu.
+func (p *WorkerPool[W]) vacant(slot int) int {
+	if slot >= 0 {
+		if p.slots[slot].state == slotEmpty {
+			return slot
+		}
+		return -1
+	}
+	if p.opts.IdleTimeout <= 0 {
+		return -1
+	}
+	if p.live+p.starting >= p.opts.Max {
+		return -1
+	}
+	for i := 0; i < p.opts.Max; i++ {
+		if p.slots[i].state == slotEmpty {
+			return i
+		}
+	}
+	return -1
+}
+
+// take marks a worker busy, and affine when an affinity key took it.
+// Callers must hold p.mu.
+func (p *WorkerPool[W]) take(i int, affine bool) W {
+	p.slots[i].state = slotBusy
+	p.slots[i].affine = p.slots[i].affine || affine
+	p.idle--
+	p.acquired++
+	return p.slots[i].worker
+}
+
+// spawn starts a worker in slot i for the caller, releasing p.mu while the
+// factory runs. Callers must hold p.mu.
+func (p *WorkerPool[W]) spawn(i int, affine bool) (W, error) {
+	var zero W
+	p.slots[i].state = slotStarting
+	p.starting++
+	p.mu.Unlock()
+
+	worker, err := p.factory(i)
+
+	p.mu.Lock()
+	p.starting--
+	if err != nil {
+		p.slots[i].state = slotEmpty
+		p.broadcast()
+		return zero, fmt.Errorf("failed to initialize worker %d: %w", i, err)
+	}
+	if p.closed {
+		p.slots[i].state = slotEmpty
+		p.mu.Unlock()
+		p.teardown(worker)
+		p.mu.Lock()
+		return zero, ErrPoolClosed
+	}
+
+	p.slots[i] = poolSlot[W]{worker: worker, state: slotBusy, affine: affine}
+	p.index[worker] = i
+	p.live++
+	p.acquired++
+	p.publish(1)
+	return worker, nil
+}
+
+// Release returns a worker to the pool, waking callers waiting for one.
+// Releasing a worker that is not in use does nothing.
+func (p *WorkerPool[W]) Release(worker W) {
+	p.mu.Lock()
+	i, ok := p.index[worker]
+	if !ok || p.slots[i].state != slotBusy {
+		p.mu.Unlock()
+		return
+	}
+
+	// Workers of a closed pool, or beyond its Max after a Resize, are
+	// destroyed rather than kept. Waiters are woken, since a pool that
+	// scales can start a worker in their place.
+	if p.closed || i >= p.opts.Max {
+		p.slots[i] = poolSlot[W]{}
+		delete(p.index, worker)
+		p.live--
+		if !p.closed {
+			p.trim()
+			p.publish(-1)
+			p.broadcast()
+		}
+		p.mu.Unlock()
+		p.teardown(worker)
+		return
+	}
+
+	p.slots[i].state = slotIdle
+	p.slots[i].idleSince = time.Now()
+	p.idle++
+	p.broadcast()
+	p.mu.Unlock()
+}
+
+// Replace drops a worker in use that cannot be reused, such as one whose
+// code outlived its interrupt, and starts a new worker in its slot for
+// the next caller. The dropped worker is not destroyed, so its owner can
+// dispose of it once it stops. If the new worker cannot be started the
+// slot is left empty.
+func (p *WorkerPool[W]) Replace(worker W) error {
+	p.mu.Lock()
+	i, ok := p.index[worker]
+	if !ok || p.slots[i].state != slotBusy {
+		p.mu.Unlock()
+		return fmt.Errorf("worker is not in use")
+	}
+	delete(p.index, worker)
+	p.live--
+	if p.closed || i >= p.opts.Max {
+		p.slots[i] = poolSlot[W]{}
+		if !p.closed {
+			p.trim()
+			p.publish(-1)
+			p.broadcast()
+		}
+		p.mu.Unlock()
+		return nil
+	}
+	p.slots[i] = poolSlot[W]{state: slotStarting}
+	p.starting++
+	p.mu.Unlock()
+
+	fresh, err := p.factory(i)
+
+	p.mu.Lock()
+	p.starting--
+	if err != nil {
+		p.slots[i].state = slotEmpty
+		p.publish(-1)
+		p.broadcast()
+		p.mu.Unlock()
+		return fmt.Errorf("failed to initialize worker %d: %w", i, err)
+	}
+	if p.closed {
+		p.slots[i].state = slotEmpty
+		p.mu.Unlock()
+		p.teardown(fresh)
+		return nil
+	}
+	p.slots[i] = poolSlot[W]{worker: fresh, state: slotIdle, idleSince: time.Now()}
+	p.index[fresh] = i
+	p.live++
+	p.idle++
+	p.broadcast()
+	p.mu.Unlock()
+	return nil
+}
+
+// Resize changes the pool's Max and Min workers without dropping work in
+// progress. Growing starts workers as NewWorkerPoolWith would, and
+// shrinking retires the workers beyond the new Max: idle ones at once and
+// busy ones as they are released. Affinity keys may map to different
+// workers afterwards.
+func (p *WorkerPool[W]) Resize(max, min int) error {
+	if max <= 0 {
+		return fmt.Errorf("pool size must be positive")
+	}
+	if min < 0 || min > max {
+		return fmt.Errorf("minimum workers must be between 0 and %d", max)
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.opts.Max, p.opts.Min = max, min
+
+	var excess []W
+	for i := max; i < len(p.slots); i++ {
+		if p.slots[i].state != slotIdle {
+			continue
+		}
+		excess = append(excess, p.slots[i].worker)
+		delete(p.index, p.slots[i].worker)
+		p.slots[i] = poolSlot[W]{}
+		p.live--
+		p.idle--
+	}
+	p.trim()
+	for len(p.slots) < max {
+		p.slots = append(p.slots, poolSlot[W]{})
+	}
+	if len(excess) > 0 {
+		p.publish(-len(excess))
+	}
+
+	// Start workers up to the new size, or up to Min if the pool scales,
+	// reserving their slots so callers do not start them too
+	target := max
+	if p.opts.IdleTimeout > 0 {
+		target = min
+	}
+	occupied := 0
+	for _, slot := range p.slots[:max] {
+		if slot.state != slotEmpty {
+			occupied++
+		}
+	}
+	var start []int
+	for i := 0; i < max && occupied < target; i++ {
+		if p.slots[i].state == slotEmpty {
+			p.slots[i].state = slotStarting
+			p.starting++
+			occupied++
+			start = append(start, i)
+		}
+	}
+	p.broadcast()
+	p.mu.Unlock()
+
+	for _, worker := range excess {
+		p.teardown(worker)
+	}
+
+	var startErr error
+	for _, i := range start {
+		worker, err := p.factory(i)
+
+		p.mu.Lock()
+		p.starting--
+		switch {
+		case err != nil:
+			p.slots[i].state = slotEmpty
+			if startErr == nil {
+				startErr = fmt.Errorf("failed to initialize worker %d: %w", i, err)
+			}
+		case p.closed || i >= p.opts.Max:
+			p.slots[i].state = slotEmpty
+			p.mu.Unlock()
+			p.teardown(worker)
+			p.mu.Lock()
+		default:
+			p.slots[i] = poolSlot[W]{worker: worker, state: slotIdle, idleSince: time.Now()}
+			p.index[worker] = i
+			p.live++
+			p.idle++
+			p.publish(1)
+		}
+		p.trim()
+		p.broadcast()
+		p.mu.Unlock()
+	}
+	return startErr
+}
+
+// trim drops empty slots beyond Max left by a Resize. Callers must hold
+// p.mu.
+func (p *WorkerPool[W]) trim() {
+	for n := len(p.slots); n > p.opts.Max && p.slots[n-1].state == slotEmpty; n-- {
+		p.slots = p.slots[:n-1]
+	}
+}
+
+// broadcast wakes every waiting caller. Callers must hold p.mu.
+func (p *WorkerPool[W]) broadcast() {
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// publish reports a change in live workers. Callers must hold p.mu.
+func (p *WorkerPool[W]) publish(delta int) {
+	if p.opts.Events == nil {
+		return
+	}
+	p.opts.Events.Publish(TopicPoolScale, PoolScaleEvent{
+		Pool:    p.opts.Name,
+		Delta:   delta,
+		Workers: p.live,
+		Waiting: p.waiting,
+	})
+}
+
+// reap periodically retires workers idle past the timeout
+func (p *WorkerPool[W]) reap() {
+	defer p.reaping.Done()
+
+	interval := p.opts.IdleTimeout / 2
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.retire(now)
+		}
+	}
+}
+
+// retire destroys workers idle past the timeout, down to Min. The
+// highest slots go first, since free hands out the lowest. Workers that
+// served an affinity key are kept, since their state belongs to its
+// session.
+func (p *WorkerPool[W]) retire(now time.Time) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	var expired []W
+	for i := len(p.slots) - 1; i >= 0 && p.live > p.opts.Min; i-- {
+		slot := p.slots[i]
+		if slot.state != slotIdle || slot.affine || now.Sub(slot.idleSince) < p.opts.IdleTimeout {
+			continue
+		}
+		expired = append(expired, slot.worker)
+		delete(p.index, slot.worker)
+		p.slots[i] = poolSlot[W]{}
+		p.live--
+		p.idle--
+	}
+	if len(expired) > 0 {
+		p.publish(-len(expired))
+	}
+	p.mu.Unlock()
+
+	for _, worker := range expired {
+		p.teardown(worker)
+	}
+}
+
+// Size returns the number of live workers
+func (p *WorkerPool[W]) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.live
+}
+
+// Stats reports the pool's workers and how callers have used them
+func (p *WorkerPool[W]) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return PoolStats{
+		Workers:   p.live,
+		Idle:      p.idle,
+		Waiting:   p.waiting,
+		Acquired:  p.acquired,
+		Waits:     p.waits,
+		Exhausted: p.exhausted,
+	}
+}
+
+// Close fails waiting and later acquires with ErrPoolClosed and destroys
+// idle workers; workers in use are destroyed when released. It waits for
+// the reaper to stop, so no retired worker is destroyed after it returns.
+// Closing again only waits for the reaper.
+func (p *WorkerPool[W]) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.reaping.Wait()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+
+	var idle []W
+	for i, slot := range p.slots {
+		if slot.state == slotIdle {
+			idle = append(idle, slot.worker)
+			delete(p.index, slot.worker)
+			p.slots[i] = poolSlot[W]{}
+		}
+	}
+	p.live -= len(idle)
+	p.idle = 0
+	p.broadcast()
+	p.mu.Unlock()
+
+	for _, worker := range idle {
+		p.teardown(worker)
+	}
+	p.reaping.Wait()
+}
+
+// teardown destroys a worker if the pool has a destroy function
+func (p *WorkerPool[W]) teardown(worker W) {
+	if p.destroy != nil {
+		p.destroy(worker)
+	}
+}
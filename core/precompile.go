@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Precompiler is implemented by compiled runtimes that can build snippets
+// into their compile cache without running them
+type Precompiler interface {
+	Precompile(ctx context.Context, snippets []string) error
+}
+
+// Precompile compiles snippets into a compiled runtime's artifact cache
+// without running them, such as at launch or while idle, so executing
+// them later skips the compiler. Snippets go through the runtime's
+// transform as Execute sends them. It stops at the first snippet that
+// fails to compile or when ctx ends.
+func (o *Orchestrator) Precompile(ctx context.Context, runtime string, snippets []string) error {
+	o.mu.RLock()
+	rt, exists := o.runtimes[runtime]
+	o.mu.RUnlock()
+
+	if !exists {
+		return Errorf(CodeNotFound, "runtime %s not found", runtime)
+	}
+	precompiler, ok := rt.(Precompiler)
+	if !ok {
+		return Errorf(CodeUnavailable, "%s runtime does not support precompiling", runtime)
+	}
+	if err := o.ensureInitialized(ctx, runtime); err != nil {
+		return err
+	}
+
+	transformed := make([]string, len(snippets))
+	for i, code := range snippets {
+		code, err := o.transform(runtime, code)
+		if err != nil {
+			return fmt.Errorf("snippet %d: %w", i, err)
+		}
+		transformed[i] = code
+	}
+	return precompiler.Precompile(ctx, transformed)
+}
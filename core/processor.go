@@ -0,0 +1,37 @@
+package core
+
+import "fmt"
+
+// ResultProcessor rewrites a result before Execute or Call returns it, for
+// example to convert timestamps, normalize numbers or attach metadata
+type ResultProcessor func(runtime string, result interface{}) (interface{}, error)
+
+// AddResultProcessor passes every successful Execute, ExecuteWithInput and
+// Call result through fn before it is returned. Processors run in the
+// order they were added, each receiving the previous one's result, after
+// middleware has seen the runtime's original result. An error from fn
+// fails the request and skips the remaining processors.
+func (o *Orchestrator) AddResultProcessor(fn ResultProcessor) {
+	if fn == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.processors = append(o.processors, fn)
+}
+
+// process runs result from runtime through the result processors
+func (o *Orchestrator) process(runtime string, result interface{}) (interface{}, error) {
+	o.mu.RLock()
+	processors := o.processors
+	o.mu.RUnlock()
+
+	for i, fn := range processors {
+		processed, err := fn(runtime, result)
+		if err != nil {
+			return nil, fmt.Errorf("result processor %d for %s failed: %w", i, runtime, err)
+		}
+		result = processed
+	}
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"math"
+)
+
+// Progress is a progress report from code running in a runtime
+type Progress struct {
+	// Runtime reporting the progress
+	Runtime string `json:"runtime"`
+
+	// Fraction of the work done, from 0 to 1
+	Fraction float64 `json:"fraction"`
+
+	// Message describes the current step
+	Message string `json:"message,omitempty"`
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context whose executions report progress to fn,
+// in the order the code reports it. Runtimes call fn on the executing
+// thread, so it should return quickly.
+func WithProgress(ctx context.Context, fn func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ProgressFrom returns the function set by WithProgress, or nil
+func ProgressFrom(ctx context.Context) func(Progress) {
+	if ctx == nil {
+		return nil
+	}
+	fn, _ := ctx.Value(progressKey{}).(func(Progress))
+	return fn
+}
+
+// ReportProgress sends a report to the function on ctx, if any, with the
+// fraction clamped to [0, 1]
+func ReportProgress(ctx context.Context, p Progress) {
+	fn := ProgressFrom(ctx)
+	if fn == nil {
+		return
+	}
+	switch {
+	case p.Fraction < 0 || math.IsNaN(p.Fraction):
+		p.Fraction = 0
+	case p.Fraction > 1:
+		p.Fraction = 1
+	}
+	fn(p)
+}
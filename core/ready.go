@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// readyPollInterval is how often WaitReady checks runtime health
+const readyPollInterval = 20 * time.Millisecond
+
+// ReadinessProber is implemented by runtimes that keep warming up after
+// Initialize returns, such as a JVM loading classes in the background.
+// Calls issued before Ready reports true may fail.
+type ReadinessProber interface {
+	Ready() bool
+}
+
+// isReady reports whether an initialized runtime can take calls
+func isReady(rt Runtime) bool {
+	if prober, ok := rt.(ReadinessProber); ok {
+		return prober.Ready()
+	}
+	return true
+}
+
+// WaitReady blocks until the named runtimes report Ready in Health, or
+// every enabled runtime when none are named. Each runtime is given its
+// configured Timeout, counted from the call, and ctx bounds the whole
+// wait. It fails fast for runtimes that failed to initialize. LazyInit
+// runtimes that have not started are skipped, since they start on their
+// first call.
+func (o *Orchestrator) WaitReady(ctx context.Context, runtimes ...string) error {
+	start := time.Now()
+
+	o.mu.RLock()
+	if len(runtimes) == 0 {
+		for name, cfg := range o.config.Languages {
+			if cfg.Enabled {
+				runtimes = append(runtimes, name)
+			}
+		}
+		sort.Strings(runtimes)
+	}
+	timeouts := make(map[string]time.Duration, len(runtimes))
+	lazy := make(map[string]bool)
+	for _, name := range runtimes {
+		if _, exists := o.runtimes[name]; !exists {
+			o.mu.RUnlock()
+			return Errorf(CodeNotFound, "runtime %s not registered", name)
+		}
+		if cfg, ok := o.config.Languages[name]; ok {
+			timeouts[name] = cfg.Timeout
+			_, started := o.health[name]
+			lazy[name] = cfg.LazyInit && !started
+		}
+	}
+	o.mu.RUnlock()
+
+	for _, name := range runtimes {
+		if lazy[name] && !o.lazyPending(name) {
+			continue
+		}
+		if err := o.waitRuntimeReady(ctx, name, start, timeouts[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitRuntimeReady polls the health of one runtime until it is ready, it
+// fails, or timeout after start passes. A zero timeout waits on ctx alone.
+func (o *Orchestrator) waitRuntimeReady(ctx context.Context, name string, start time.Time, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, start.Add(timeout))
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		health, ok := o.Health()[name]
+		if ok && health.Ready {
+			return nil
+		}
+		if ok && !health.Initialized && health.Error != "" {
+			return Errorf(CodeUnavailable, "runtime %s failed to initialize: %s", name, health.Error)
+		}
+
+		select {
+		case <-ctx.Done():
+			return Errorf(CodeTimeout, "runtime %s not ready after %v: %w", name, time.Since(start).Round(time.Millisecond), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
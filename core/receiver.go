@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"unicode"
+)
+
+// BridgeNamer is implemented by receivers passed to RegisterStruct that
+// choose their function names. BridgeNames maps method names to function
+// names; a method mapped to "-" is not registered.
+type BridgeNamer interface {
+	BridgeNames() map[string]string
+}
+
+var bridgeFuncType = reflect.TypeOf(BridgeFunc(nil))
+
+// RegisterStruct registers every exported method of receiver with the
+// BridgeFunc signature, named after the method with its leading capitals
+// lowered (GetUser as getUser, URLFor as urlFor) unless receiver is a
+// BridgeNamer. Pass a pointer so methods with pointer receivers are
+// included. Methods with other signatures are skipped with a warning.
+// Either every method is registered or, on error, none is.
+func (b *SimpleBridge) RegisterStruct(receiver interface{}) error {
+	v := reflect.ValueOf(receiver)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return NewError(CodeInvalidArgument, "cannot register methods of a nil receiver")
+	}
+
+	var names map[string]string
+	if namer, ok := receiver.(BridgeNamer); ok {
+		names = namer.BridgeNames()
+	}
+
+	t := v.Type()
+	var registered []string
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if method.Name == "BridgeNames" && names != nil {
+			continue
+		}
+		name, ok := names[method.Name]
+		if !ok {
+			name = bridgeName(method.Name)
+		}
+		if name == "-" {
+			continue
+		}
+
+		fn, ok := v.Method(i).Interface().(func(context.Context, ...interface{}) (interface{}, error))
+		if !ok {
+			b.warn(fmt.Sprintf("bridge: skipping %s.%s: %s does not match %s", t, method.Name, method.Type, bridgeFuncType))
+			continue
+		}
+		if err := b.Register(name, fn); err != nil {
+			for _, name := range registered {
+				b.Unregister(name)
+			}
+			return fmt.Errorf("%s.%s: %w", t, method.Name, err)
+		}
+		registered = append(registered, name)
+	}
+
+	if len(registered) == 0 {
+		return Errorf(CodeInvalidArgument, "%s has no methods with the bridge handler signature", t)
+	}
+	return nil
+}
+
+// bridgeName lowers the leading capitals of a method name, keeping the
+// last one of an initialism followed by a lowercase letter
+func bridgeName(method string) string {
+	runes := []rune(method)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// SetLogger sets where the bridge logs warnings, stderr by default
+func (b *SimpleBridge) SetLogger(logger Logger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.logger = logger
+}
+
+// warn logs a warning to the bridge's logger
+func (b *SimpleBridge) warn(msg string) {
+	b.mu.RLock()
+	logger := b.logger
+	b.mu.RUnlock()
+	if logger == nil {
+		logger = DefaultLogger()
+	}
+	logger.Log(LogWarn, msg)
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Reconfigurer is implemented by runtimes that can apply a changed
+// RuntimeConfig, such as MaxConcurrency, without restarting. Every
+// built-in runtime resizes its worker pool for a changed MaxConcurrency
+// or MinWorkers and rejects changes to its other settings.
+type Reconfigurer interface {
+	Reconfigure(ctx context.Context, config RuntimeConfig) error
+}
+
+// PoolSettingsOnly returns an error unless next differs from previous only
+// in MaxConcurrency and MinWorkers, the settings a runtime can apply to its
+// running pool with WorkerPool.Resize
+func PoolSettingsOnly(previous, next RuntimeConfig) error {
+	previous.MaxConcurrency = next.MaxConcurrency
+	previous.MinWorkers = next.MinWorkers
+	if settingsChanged(previous, next) {
+		return NewError(CodeInvalidArgument, "only MaxConcurrency and MinWorkers can change while running")
+	}
+	return nil
+}
+
+// Reconfigure applies the runtime settings in config to a running
+// orchestrator. Newly enabled runtimes are initialized, disabled ones shut
+// down, and runtimes with changed settings are reconfigured in place
+// without dropping in-flight work. Timeout changes need no runtime
+// support. A runtime that cannot apply its changes keeps its previous
+// settings and is reported in the returned error; the rest of config still
+// takes effect. Memory and AdaptiveTimeout settings are fixed at creation.
+func (o *Orchestrator) Reconfigure(ctx context.Context, config *Config) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	// Runtimes Initialize is starting are not active yet, so Reconfigure
+	// waits for them rather than starting them a second time
+	o.initMu.Lock()
+	defer o.initMu.Unlock()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	names := make([]string, 0, len(o.active)+len(config.Languages))
+	for name := range o.active {
+		names = append(names, name)
+	}
+	for name := range config.Languages {
+		if _, ok := o.active[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		previous, running := o.active[name]
+		next := config.Languages[name]
+		enabled := next != nil && next.Enabled
+
+		var err error
+		switch {
+		case enabled && !running && !next.LazyInit:
+			err = o.initRuntime(ctx, name, next)
+		case running && !enabled:
+			err = o.stopRuntime(ctx, name)
+		case running && settingsChanged(previous, *next):
+			err = o.reconfigureRuntime(ctx, name, *next)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	o.config = config
+
+	if len(errs) > 0 {
+		return fmt.Errorf("reconfigure errors: %v", errs)
+	}
+	return nil
+}
+
+// stopRuntime shuts down a runtime disabled by Reconfigure. Callers must
+// hold o.mu.
+func (o *Orchestrator) stopRuntime(ctx context.Context, name string) error {
+	if err := o.runtimes[name].Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", name, err)
+	}
+	delete(o.active, name)
+	delete(o.health, name)
+	return nil
+}
+
+// reconfigureRuntime applies changed settings to a running runtime.
+// Callers must hold o.mu.
+func (o *Orchestrator) reconfigureRuntime(ctx context.Context, name string, cfg RuntimeConfig) error {
+	reconfigurer, ok := o.runtimes[name].(Reconfigurer)
+	if !ok {
+		return Errorf(CodeUnavailable, "%s runtime cannot change settings while running", name)
+	}
+	if err := reconfigurer.Reconfigure(ctx, o.runtimeConfig(cfg)); err != nil {
+		return fmt.Errorf("failed to reconfigure %s: %w", name, err)
+	}
+	o.active[name] = snapshotConfig(&cfg)
+	return nil
+}
+
+// snapshotConfig copies cfg so later edits to a shared config, including
+// its Options, are seen as changes by Reconfigure
+func snapshotConfig(cfg *RuntimeConfig) RuntimeConfig {
+	snapshot := *cfg
+	if cfg.Options != nil {
+		snapshot.Options = make(map[string]interface{}, len(cfg.Options))
+		for key, value := range cfg.Options {
+			snapshot.Options[key] = value
+		}
+	}
+	return snapshot
+}
+
+// settingsChanged reports whether a runtime must apply a config change.
+// Timeouts are read by the orchestrator on each execution, and version
+// bounds, the selftest and LazyInit only matter at initialization.
+func settingsChanged(previous, next RuntimeConfig) bool {
+	previous.Timeout = next.Timeout
+	previous.MinVersion = next.MinVersion
+	previous.MaxVersion = next.MaxVersion
+	previous.SelfTest = next.SelfTest
+	previous.LazyInit = next.LazyInit
+	return !reflect.DeepEqual(previous, next)
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// RedactionRule masks sensitive call arguments wherever requests are
+// recorded: in traces and in Request.RedactedArgs, which middleware that
+// logs or keeps a history of calls should record instead of Args. The
+// runtime still receives the real values.
+type RedactionRule struct {
+	// Runtime and Function limit the rule to requests to one runtime or
+	// one function. Empty matches any; Execute requests have no function.
+	Runtime  string
+	Function string
+
+	// Args masks whole arguments by position, counting from 0
+	Args []int
+
+	// Keys masks the values of map entries with these names, matched
+	// regardless of case, at any depth in the arguments
+	Keys []string
+
+	// Pattern masks the parts of strings it matches, at any depth in the
+	// arguments
+	Pattern *regexp.Regexp
+}
+
+// matches reports whether the rule applies to req
+func (r RedactionRule) matches(req *Request) bool {
+	return (r.Runtime == "" || r.Runtime == req.Runtime) &&
+		(r.Function == "" || r.Function == req.Function)
+}
+
+// validate checks that the rule masks something
+func (r RedactionRule) validate() error {
+	if len(r.Args) == 0 && len(r.Keys) == 0 && r.Pattern == nil {
+		return fmt.Errorf("masks no arguments, keys or pattern")
+	}
+	for _, index := range r.Args {
+		if index < 0 {
+			return fmt.Errorf("argument index %d is negative", index)
+		}
+	}
+	return nil
+}
+
+// RedactedArgs returns a copy of Args with the Config.Redaction rules
+// that apply to the request masked by Redacted. Args is left untouched.
+func (r *Request) RedactedArgs() []interface{} {
+	if r.Args == nil {
+		return nil
+	}
+	args := make([]interface{}, len(r.Args))
+	copy(args, r.Args)
+	for _, rule := range r.redaction {
+		if !rule.matches(r) {
+			continue
+		}
+		for _, index := range rule.Args {
+			if index < len(args) {
+				args[index] = Redacted
+			}
+		}
+		if len(rule.Keys) > 0 || rule.Pattern != nil {
+			for i, arg := range args {
+				args[i] = rule.mask(reflect.ValueOf(arg))
+			}
+		}
+	}
+	return args
+}
+
+// mask copies v with the rule's keys and pattern masked. Maps and slices
+// that hold anything to mask are copied as map[string]interface{} and
+// []interface{}; other values are returned as they are.
+func (r RedactionRule) mask(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if r.Pattern != nil {
+			return r.Pattern.ReplaceAllString(v.String(), Redacted)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.IsNil() {
+			break
+		}
+		masked := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			name := key.String()
+			if r.sensitive(name) {
+				masked[name] = Redacted
+			} else {
+				masked[name] = r.mask(v.MapIndex(key))
+			}
+		}
+		return masked
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 || (v.Kind() == reflect.Slice && v.IsNil()) {
+			break
+		}
+		masked := make([]interface{}, v.Len())
+		for i := range masked {
+			masked[i] = r.mask(v.Index(i))
+		}
+		return masked
+	}
+	return v.Interface()
+}
+
+// sensitive reports whether the rule masks map entries named key
+func (r RedactionRule) sensitive(key string) bool {
+	for _, name := range r.Keys {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultResultCacheSize is the number of results a ResultCache keeps
+// when created with size 0
+const DefaultResultCacheSize = 32
+
+// ResultCache keeps the full values of results sent to the frontend only
+// as a truncated Display, until the frontend asks for them. It holds the
+// most recent results; older ones are forgotten.
+type ResultCache struct {
+	mu     sync.Mutex
+	size   int
+	next   uint64
+	order  []string
+	values map[string]interface{}
+}
+
+// NewResultCache creates a cache of the size most recent results. Zero
+// uses DefaultResultCacheSize.
+func NewResultCache(size int) *ResultCache {
+	if size <= 0 {
+		size = DefaultResultCacheSize
+	}
+	return &ResultCache{size: size, values: make(map[string]interface{})}
+}
+
+// Put stores value and returns the ref it is fetched by, forgetting the
+// oldest result when the cache is full
+func (c *ResultCache) Put(value interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.next++
+	ref := fmt.Sprintf("result:%d", c.next)
+	if len(c.order) == c.size {
+		delete(c.values, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.order = append(c.order, ref)
+	c.values[ref] = value
+	return ref
+}
+
+// Get returns the value stored under ref
+func (c *ResultCache) Get(ref string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.values[ref]
+	if !ok {
+		return nil, Errorf(CodeNotFound, "result %s not found", ref).WithDetail("ref", ref)
+	}
+	return value, nil
+}
+
+// Fetch is a bridge function returning the value stored under the ref
+// given as its argument
+func (c *ResultCache) Fetch(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, Errorf(CodeInvalidArgument, "fetch requires 1 argument, got %d", len(args))
+	}
+	ref, ok := args[0].(string)
+	if !ok {
+		return nil, Errorf(CodeInvalidArgument, "result ref must be a string, got %T", args[0])
+	}
+	return c.Get(ref)
+}
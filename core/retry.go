@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy retries idempotent calls that fail with a retriable error code
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; values below 2 disable
+	// retries
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, doubled for each
+	// following retry
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between retries (zero means no cap)
+	MaxBackoff time.Duration
+
+	// RetryOn lists retriable error codes, defaulting to UNAVAILABLE.
+	// TIMEOUT is left out of the default because a call that timed out
+	// may still have run.
+	RetryOn []ErrorCode
+}
+
+// defaultRetryCodes are retried when RetryOn is empty
+var defaultRetryCodes = []ErrorCode{CodeUnavailable}
+
+// Codes returns the retriable error codes
+func (p RetryPolicy) Codes() []ErrorCode {
+	if len(p.RetryOn) == 0 {
+		return defaultRetryCodes
+	}
+	return p.RetryOn
+}
+
+// Retriable reports whether an error should be retried
+func (p RetryPolicy) Retriable(err error) bool {
+	if err == nil {
+		return false
+	}
+	code := ErrorInfoFor(err).Code
+	for _, c := range p.Codes() {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the wait before retry number n (starting at 1)
+func (p RetryPolicy) Delay(n int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < n; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// Do calls fn until it succeeds, fails with a non-retriable error, the
+// attempts are exhausted, or ctx is done
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt < p.MaxAttempts && p.Retriable(err); attempt++ {
+		timer := time.NewTimer(p.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		err = fn()
+	}
+	return err
+}
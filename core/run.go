@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Run executes code and decodes the result into T. Results are converted
+// through their JSON form, so runtimes reporting numbers as int64 or
+// float64 decode alike and maps decode into structs by json tags.
+func Run[T any](ctx context.Context, orch *Orchestrator, runtime string, code string, args ...interface{}) (T, error) {
+	var out T
+
+	value, err := orch.Execute(ctx, runtime, code, args...)
+	if err != nil {
+		return out, err
+	}
+
+	if err := DecodeValue(value, &out); err != nil {
+		return out, fmt.Errorf("%s result: %w", runtime, err)
+	}
+	return out, nil
+}
+
+// DecodeValue converts a generic runtime value into the value pointed to
+// by out
+func DecodeValue(value interface{}, out interface{}) error {
+	if typed, ok := out.(*interface{}); ok {
+		*typed = value
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("cannot encode %T: %w", value, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("cannot decode %T into %T: %w", value, out, err)
+	}
+	return nil
+}
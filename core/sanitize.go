@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// InputPolicy screens code from untrusted sources before execution
+type InputPolicy struct {
+	// MaxLength limits source size in bytes (0 disables the check)
+	MaxLength int
+
+	// DeniedPatterns reject code matching any expression
+	DeniedPatterns []*regexp.Regexp
+
+	// RestrictBuiltins rejects references to dangerous runtime builtins
+	RestrictBuiltins bool
+}
+
+// PolicyViolationError reports code rejected by an InputPolicy
+type PolicyViolationError struct {
+	// Runtime the code was destined for
+	Runtime string
+
+	// Rule that was violated (max-length, denied-pattern, restricted-builtin)
+	Rule string
+
+	// Detail describes the offending input
+	Detail string
+}
+
+func (e *PolicyViolationError) Error() string {
+	return fmt.Sprintf("policy violation for %s (%s): %s", e.Runtime, e.Rule, e.Detail)
+}
+
+// restrictedBuiltins lists builtins that allow escaping an eval sandbox
+var restrictedBuiltins = map[string][]string{
+	"python": {
+		"__import__", "eval", "exec", "compile", "open", "input",
+		"globals", "locals", "vars", "getattr", "setattr", "delattr",
+		"breakpoint", "exit", "quit",
+	},
+	"javascript": {
+		"eval", "Function", "require", "process", "import",
+		"globalThis", "constructor",
+	},
+	"ruby": {
+		"eval", "instance_eval", "class_eval", "system", "exec",
+		"spawn", "send", "require", "load", "open", "binding",
+	},
+	"lua": {
+		"load", "loadstring", "dofile", "loadfile", "require",
+		"os", "io", "debug",
+	},
+	"php": {
+		"eval", "exec", "system", "shell_exec", "passthru",
+		"proc_open", "popen", "include", "require", "assert",
+	},
+}
+
+// builtinPatterns holds compiled matchers for restrictedBuiltins
+var builtinPatterns = compileBuiltinPatterns()
+
+func compileBuiltinPatterns() map[string][]*regexp.Regexp {
+	patterns := make(map[string][]*regexp.Regexp, len(restrictedBuiltins))
+	for runtime, names := range restrictedBuiltins {
+		for _, name := range names {
+			patterns[runtime] = append(patterns[runtime], regexp.MustCompile(`(^|[^\w$])`+regexp.QuoteMeta(name)+`($|[^\w$])`))
+		}
+	}
+	// Dunder attribute access reaches the same internals in Python
+	patterns["python"] = append(patterns["python"], regexp.MustCompile(`__\w+__`))
+	return patterns
+}
+
+// DefaultInputPolicy returns a policy suitable for user-facing eval features
+func DefaultInputPolicy() *InputPolicy {
+	return &InputPolicy{
+		MaxLength:        4096,
+		RestrictBuiltins: true,
+	}
+}
+
+// Check validates code against the policy
+func (p *InputPolicy) Check(runtime, code string) error {
+	if p.MaxLength > 0 && len(code) > p.MaxLength {
+		return &PolicyViolationError{
+			Runtime: runtime,
+			Rule:    "max-length",
+			Detail:  fmt.Sprintf("source is %d bytes, limit is %d", len(code), p.MaxLength),
+		}
+	}
+
+	for _, pattern := range p.DeniedPatterns {
+		if match := pattern.FindString(code); match != "" {
+			return &PolicyViolationError{
+				Runtime: runtime,
+				Rule:    "denied-pattern",
+				Detail:  fmt.Sprintf("matched %q", match),
+			}
+		}
+	}
+
+	if p.RestrictBuiltins {
+		for _, pattern := range builtinPatterns[runtime] {
+			if match := pattern.FindString(code); match != "" {
+				return &PolicyViolationError{
+					Runtime: runtime,
+					Rule:    "restricted-builtin",
+					Detail:  fmt.Sprintf("references %q", match),
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SecretStore holds secrets such as cloud API keys and signing private
+// keys, so they can be looked up by name instead of kept in config files.
+// Get fails with CodeNotFound for a missing secret.
+type SecretStore interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+}
+
+// MemorySecretStore is a SecretStore held in memory, for tests and for
+// secrets that should not outlive the process
+type MemorySecretStore struct {
+	mu      sync.RWMutex
+	secrets map[string][]byte
+}
+
+// NewMemorySecretStore creates an empty in-memory secret store
+func NewMemorySecretStore() *MemorySecretStore {
+	return &MemorySecretStore{secrets: make(map[string][]byte)}
+}
+
+// Get returns a copy of the secret stored under key
+func (s *MemorySecretStore) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.secrets[key]
+	if !ok {
+		return nil, secretNotFound(key)
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Set stores a copy of value under key
+func (s *MemorySecretStore) Set(key string, value []byte) error {
+	if err := validateSecretKey(key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete removes the secret stored under key
+func (s *MemorySecretStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.secrets[key]; !ok {
+		return secretNotFound(key)
+	}
+	delete(s.secrets, key)
+	return nil
+}
+
+// KeychainStore is a SecretStore backed by the OS credential store: the
+// macOS Keychain, Windows Credential Manager, or the Secret Service
+// (libsecret) on Linux. Keys are namespaced by service, typically the app
+// name.
+type KeychainStore struct {
+	service string
+}
+
+// NewKeychainStore creates a store for service in the OS credential store
+func NewKeychainStore(service string) (*KeychainStore, error) {
+	if err := validateSecretKey(service); err != nil {
+		return nil, fmt.Errorf("invalid service: %w", err)
+	}
+	return &KeychainStore{service: service}, nil
+}
+
+// Get reads the secret stored under key
+func (s *KeychainStore) Get(key string) ([]byte, error) {
+	if err := validateSecretKey(key); err != nil {
+		return nil, err
+	}
+	return keychainGet(s.service, key)
+}
+
+// Set stores value under key, replacing any existing secret
+func (s *KeychainStore) Set(key string, value []byte) error {
+	if err := validateSecretKey(key); err != nil {
+		return err
+	}
+	return keychainSet(s.service, key, value)
+}
+
+// Delete removes the secret stored under key
+func (s *KeychainStore) Delete(key string) error {
+	if err := validateSecretKey(key); err != nil {
+		return err
+	}
+	return keychainDelete(s.service, key)
+}
+
+// validateSecretKey rejects names that OS credential stores or their
+// command-line tools cannot represent
+func validateSecretKey(key string) error {
+	if key == "" {
+		return NewError(CodeInvalidArgument, "secret key is required")
+	}
+	if strings.ContainsAny(key, "\x00\r\n") {
+		return Errorf(CodeInvalidArgument, "invalid secret key %q", key)
+	}
+	return nil
+}
+
+func secretNotFound(key string) error {
+	return Errorf(CodeNotFound, "secret %s not found", key)
+}
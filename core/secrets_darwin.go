@@ -0,0 +1,80 @@
+//go:build darwin
+// +build darwin
+
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The Keychain is driven through the security tool. Secrets are stored
+// base64-encoded so binary keys survive, and written through security's
+// interactive mode on stdin so they never appear in a process listing.
+
+// errSecItemNotFound is the exit status of security for a missing item
+const errSecItemNotFound = 44
+
+func keychainGet(service, key string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("security", "find-generic-password", "-s", service, "-a", key, "-w")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, securityError("read", key, err, stderr.String())
+	}
+
+	value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(stdout.String()))
+	if err != nil {
+		return nil, Errorf(CodeInternal, "secret %s was not stored by polyglot: %w", key, err)
+	}
+	return value, nil
+}
+
+func keychainSet(service, key string, value []byte) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		securityQuote(service), securityQuote(key), base64.StdEncoding.EncodeToString(value))
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return securityError("store", key, err, stderr.String())
+	}
+	// Interactive mode exits 0 even when the command fails
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return Errorf(CodeUnavailable, "failed to store secret: %s", msg)
+	}
+	return nil
+}
+
+func keychainDelete(service, key string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "delete-generic-password", "-s", service, "-a", key)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return securityError("delete", key, err, stderr.String())
+	}
+	return nil
+}
+
+// securityQuote quotes an argument for security's interactive mode
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func securityError(op, key string, err error, stderr string) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return secretNotFound(key)
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return Errorf(CodeUnavailable, "failed to %s secret: %s", op, msg)
+	}
+	return Errorf(CodeUnavailable, "failed to %s secret: %w", op, err)
+}
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service (libsecret) is driven through secret-tool, which
+// reads the secret from stdin so it never appears in a process listing
+
+func keychainGet(service, key string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", key)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stdout.Len() == 0 && stderr.Len() == 0 {
+			return nil, secretNotFound(key)
+		}
+		return nil, secretToolError("read", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func keychainSet(service, key string, value []byte) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+key, "service", service, "account", key)
+	cmd.Stdin = bytes.NewReader(value)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return secretToolError("store", err, stderr.String())
+	}
+	return nil
+}
+
+func keychainDelete(service, key string) error {
+	if _, err := keychainGet(service, key); err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "clear", "service", service, "account", key)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return secretToolError("delete", err, stderr.String())
+	}
+	return nil
+}
+
+func secretToolError(op string, err error, stderr string) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return NewError(CodeUnavailable, "secret-tool not found; install libsecret-tools")
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return Errorf(CodeUnavailable, "failed to %s secret: %s", op, msg)
+	}
+	return Errorf(CodeUnavailable, "failed to %s secret: %w", op, err)
+}
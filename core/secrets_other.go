@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package core
+
+func keychainGet(service, key string) ([]byte, error) {
+	return nil, NewError(CodeUnavailable, "no OS credential store on this platform")
+}
+
+func keychainSet(service, key string, value []byte) error {
+	return NewError(CodeUnavailable, "no OS credential store on this platform")
+}
+
+func keychainDelete(service, key string) error {
+	return NewError(CodeUnavailable, "no OS credential store on this platform")
+}
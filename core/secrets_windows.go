@@ -0,0 +1,117 @@
+//go:build windows
+// +build windows
+
+package core
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Secrets are generic credentials in Windows Credential Manager, named
+// "service/key"
+
+var (
+	advapi32        = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+// credential mirrors the Win32 CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func credentialTarget(service, key string) (*uint16, error) {
+	target, err := syscall.UTF16PtrFromString(service + "/" + key)
+	if err != nil {
+		return nil, Errorf(CodeInvalidArgument, "invalid secret key %q", key)
+	}
+	return target, nil
+}
+
+func keychainGet(service, key string) ([]byte, error) {
+	target, err := credentialTarget(service, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var cred *credential
+	ok, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		return nil, credentialError("read", key, err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return []byte{}, nil
+	}
+	return append([]byte(nil), unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)...), nil
+}
+
+func keychainSet(service, key string, value []byte) error {
+	target, err := credentialTarget(service, key)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(key)
+	if err != nil {
+		return Errorf(CodeInvalidArgument, "invalid secret key %q", key)
+	}
+
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		UserName:           user,
+		Persist:            credPersistLocalMachine,
+		CredentialBlobSize: uint32(len(value)),
+	}
+	if len(value) > 0 {
+		cred.CredentialBlob = &value[0]
+	}
+
+	ok, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ok == 0 {
+		return credentialError("store", key, err)
+	}
+	return nil
+}
+
+func keychainDelete(service, key string) error {
+	target, err := credentialTarget(service, key)
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if ok == 0 {
+		return credentialError("delete", key, err)
+	}
+	return nil
+}
+
+func credentialError(op, key string, err error) error {
+	if err == errorNotFound {
+		return secretNotFound(key)
+	}
+	return Errorf(CodeUnavailable, "failed to %s secret: %w", op, err)
+}
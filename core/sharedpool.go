@@ -0,0 +1,203 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// SharedPool lets several orchestrators in one process use a single
+// runtime per language, so heavy runtimes such as the JVM and their worker
+// pools are not duplicated. Runtimes are reference counted: the first
+// orchestrator to initialize one starts it, and the last to shut down
+// stops it.
+//
+//	orch.RegisterRuntime(core.DefaultSharedPool.Share(java.NewRuntime()))
+//
+// The configuration of the first orchestrator to initialize a runtime is
+// used for as long as it is shared, and Reconfigure is not supported.
+type SharedPool struct {
+	mu      sync.Mutex
+	entries map[string]*sharedEntry
+}
+
+type sharedEntry struct {
+	rt   Runtime
+	refs int
+}
+
+// DefaultSharedPool is the process-global SharedPool
+var DefaultSharedPool = NewSharedPool()
+
+// NewSharedPool creates an empty SharedPool
+func NewSharedPool() *SharedPool {
+	return &SharedPool{entries: make(map[string]*sharedEntry)}
+}
+
+// Share returns a runtime to register in place of rt. Runtimes shared
+// under the same name use whichever one was started first; rt is used
+// only when no runtime of its name is running.
+func (p *SharedPool) Share(rt Runtime) Runtime {
+	return &sharedRuntime{pool: p, rt: rt}
+}
+
+// Refs reports how many orchestrators use the named runtime
+func (p *SharedPool) Refs(name string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, ok := p.entries[name]; ok {
+		return entry.refs
+	}
+	return 0
+}
+
+// acquire returns the running runtime for rt's name, starting rt when
+// there is none
+func (p *SharedPool) acquire(ctx context.Context, rt Runtime, config RuntimeConfig) (Runtime, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	name := rt.Name()
+	if entry, ok := p.entries[name]; ok {
+		entry.refs++
+		return entry.rt, nil
+	}
+	if err := rt.Initialize(ctx, config); err != nil {
+		return nil, err
+	}
+	p.entries[name] = &sharedEntry{rt: rt, refs: 1}
+	return rt, nil
+}
+
+// release drops a reference to the named runtime, shutting it down with
+// the last one
+func (p *SharedPool) release(ctx context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[name]
+	if !ok {
+		return nil
+	}
+	entry.refs--
+	if entry.refs > 0 {
+		return nil
+	}
+	delete(p.entries, name)
+	return entry.rt.Shutdown(ctx)
+}
+
+// sharedRuntime is one orchestrator's reference to a shared runtime
+type sharedRuntime struct {
+	pool *SharedPool
+
+	mu     sync.RWMutex
+	rt     Runtime
+	active bool
+}
+
+func (s *sharedRuntime) Initialize(ctx context.Context, config RuntimeConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active {
+		return nil
+	}
+	rt, err := s.pool.acquire(ctx, s.rt, config)
+	if err != nil {
+		return err
+	}
+	s.rt = rt
+	s.active = true
+	return nil
+}
+
+func (s *sharedRuntime) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active {
+		return nil
+	}
+	s.active = false
+	return s.pool.release(ctx, s.rt.Name())
+}
+
+// runtime returns the shared runtime, or an error once this reference
+// is shut down
+func (s *sharedRuntime) runtime() (Runtime, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.active {
+		return nil, Errorf(CodeUnavailable, "shared %s runtime is not initialized", s.rt.Name())
+	}
+	return s.rt, nil
+}
+
+func (s *sharedRuntime) Execute(ctx context.Context, code string, args ...interface{}) (interface{}, error) {
+	rt, err := s.runtime()
+	if err != nil {
+		return nil, err
+	}
+	return rt.Execute(ctx, code, args...)
+}
+
+func (s *sharedRuntime) Call(ctx context.Context, fn string, args ...interface{}) (interface{}, error) {
+	rt, err := s.runtime()
+	if err != nil {
+		return nil, err
+	}
+	return rt.Call(ctx, fn, args...)
+}
+
+func (s *sharedRuntime) CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error) {
+	rt, err := s.runtime()
+	if err != nil {
+		return nil, err
+	}
+	return CallMulti(ctx, rt, fn, args...)
+}
+
+func (s *sharedRuntime) DefinedFunctions(ctx context.Context) ([]FunctionInfo, error) {
+	rt, err := s.runtime()
+	if err != nil {
+		return nil, err
+	}
+	return DefinedFunctions(ctx, rt)
+}
+
+func (s *sharedRuntime) Interrupt(executionID uint64) error {
+	rt, err := s.runtime()
+	if err != nil {
+		return err
+	}
+	interrupter, ok := rt.(Interrupter)
+	if !ok {
+		return Errorf(CodeUnavailable, "%s runtime does not support interrupts", rt.Name())
+	}
+	return interrupter.Interrupt(executionID)
+}
+
+func (s *sharedRuntime) Ready() bool {
+	rt, err := s.runtime()
+	return err == nil && isReady(rt)
+}
+
+func (s *sharedRuntime) Stubbed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return IsStub(s.rt)
+}
+
+func (s *sharedRuntime) Name() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rt.Name()
+}
+
+func (s *sharedRuntime) Version() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rt.Version()
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// snapshotTraces is the number of recent requests kept for snapshots
+const snapshotTraces = 50
+
+// Redacted replaces sensitive values in snapshots
+const Redacted = "[REDACTED]"
+
+// sensitiveKey matches option and query parameter names whose values are
+// redacted from snapshots
+var sensitiveKey = regexp.MustCompile(`(?i)secret|token|passw|credential|api.?key|private|auth`)
+
+// PoolStats describes a runtime's worker pool
+type PoolStats struct {
+	// Workers is the number of live workers
+	Workers int `json:"workers"`
+
+	// Idle is the number of workers not running anything
+	Idle int `json:"idle"`
+
+	// Waiting is the number of callers waiting for a worker
+	Waiting int `json:"waiting,omitempty"`
+
+	// Acquired counts the workers handed out since the pool started
+	Acquired uint64 `json:"acquired,omitempty"`
+
+	// Waits counts acquires that found no idle worker and had to wait
+	Waits uint64 `json:"waits,omitempty"`
+
+	// Exhausted counts acquires rejected because every worker was busy
+	Exhausted uint64 `json:"exhausted,omitempty"`
+}
+
+// PoolStatser is implemented by runtimes that can report their worker pool
+type PoolStatser interface {
+	PoolStats() PoolStats
+}
+
+// Trace records one Execute or Call handled by the orchestrator. Code is
+// not kept, since it may contain user data, and arguments are kept only
+// with Config.TraceArgs, masked by the Config.Redaction rules.
+type Trace struct {
+	Runtime  string        `json:"runtime"`
+	Function string        `json:"function,omitempty"`
+	Args     []interface{} `json:"args,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// RuntimeSnapshot describes a registered runtime
+type RuntimeSnapshot struct {
+	Name    string         `json:"name"`
+	Version string         `json:"version"`
+	Stubbed bool           `json:"stubbed"`
+	Health  *RuntimeHealth `json:"health,omitempty"`
+	Pool    *PoolStats     `json:"pool,omitempty"`
+}
+
+// OrchestratorSnapshot captures an orchestrator's setup and recent
+// activity for debugging and bug reports. Secrets in the config are
+// redacted.
+type OrchestratorSnapshot struct {
+	TakenAt  time.Time `json:"takenAt"`
+	Platform string    `json:"platform"`
+	Go       string    `json:"go"`
+
+	Config   Config                 `json:"config"`
+	Runtimes []RuntimeSnapshot      `json:"runtimes"`
+	Memory   map[string]interface{} `json:"memory"`
+
+	// Traces are the most recent requests, oldest first
+	Traces []Trace `json:"traces"`
+
+	// EventsDropped counts events lost to full subscriber buffers
+	EventsDropped int64 `json:"eventsDropped"`
+}
+
+// Snapshot captures the orchestrator's config, registered runtimes with
+// their health and pool stats, memory stats, and recent requests
+func (o *Orchestrator) Snapshot() *OrchestratorSnapshot {
+	o.mu.RLock()
+	names := make([]string, 0, len(o.runtimes))
+	for name := range o.runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	runtimes := make([]RuntimeSnapshot, 0, len(names))
+	for _, name := range names {
+		rt := o.runtimes[name]
+		snapshot := RuntimeSnapshot{Name: name, Stubbed: IsStub(rt)}
+		if health, ok := o.health[name]; ok {
+			health.Ready = health.Initialized && isReady(rt)
+			snapshot.Health = &health
+			snapshot.Version = health.Version
+		}
+		if snapshot.Version == "" {
+			snapshot.Version = rt.Version()
+		}
+		if statser, ok := rt.(PoolStatser); ok && o.health[name].Initialized {
+			stats := statser.PoolStats()
+			snapshot.Pool = &stats
+		}
+		runtimes = append(runtimes, snapshot)
+	}
+	config := redactConfig(o.config)
+	o.mu.RUnlock()
+
+	return &OrchestratorSnapshot{
+		TakenAt:       time.Now(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		Go:            runtime.Version(),
+		Config:        config,
+		Runtimes:      runtimes,
+		Memory:        o.memory.Stats(),
+		Traces:        o.traces.recent(),
+		EventsDropped: o.events.Dropped(),
+	}
+}
+
+// WriteJSON writes the snapshot as indented JSON, ready to attach to a bug
+// report
+func (s *OrchestratorSnapshot) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// ReadSnapshot reads a snapshot written by WriteJSON
+func ReadSnapshot(r io.Reader) (*OrchestratorSnapshot, error) {
+	var snapshot OrchestratorSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// redactConfig copies config, replacing sensitive runtime options and URL
+// credentials with Redacted
+func redactConfig(config *Config) Config {
+	redacted := *config
+	redacted.Languages = make(map[string]*RuntimeConfig, len(config.Languages))
+	for name, cfg := range config.Languages {
+		copied := *cfg
+		if cfg.Options != nil {
+			copied.Options = make(map[string]interface{}, len(cfg.Options))
+			for key, value := range cfg.Options {
+				if sensitiveKey.MatchString(key) {
+					value = Redacted
+				}
+				copied.Options[key] = value
+			}
+		}
+		redacted.Languages[name] = &copied
+	}
+	redacted.Webview.URL = redactURL(config.Webview.URL)
+	return redacted
+}
+
+// redactURL hides the password and sensitive query parameters of a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if sensitiveKey.MatchString(key) {
+			query.Set(key, Redacted)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// traceRing keeps the most recent traces
+type traceRing struct {
+	mu     sync.Mutex
+	traces []Trace
+	next   int
+}
+
+func newTraceRing(size int) *traceRing {
+	return &traceRing{traces: make([]Trace, 0, size)}
+}
+
+func (r *traceRing) add(trace Trace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.traces) < cap(r.traces) {
+		r.traces = append(r.traces, trace)
+		return
+	}
+	r.traces[r.next] = trace
+	r.next = (r.next + 1) % len(r.traces)
+}
+
+// recent returns the traces oldest first
+func (r *traceRing) recent() []Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	traces := make([]Trace, 0, len(r.traces))
+	traces = append(traces, r.traces[r.next:]...)
+	return append(traces, r.traces[:r.next]...)
+}
+
+// traceMiddleware records each request in ring, with its redacted
+// arguments as the request arrived when withArgs is set
+func traceMiddleware(ring *traceRing, withArgs bool) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (interface{}, error) {
+			var args []interface{}
+			if withArgs {
+				args = req.RedactedArgs()
+			}
+			start := time.Now()
+			result, err := next(ctx, req)
+			trace := Trace{Runtime: req.Runtime, Function: req.Function, Args: args, Start: start, Duration: time.Since(start)}
+			if err != nil {
+				trace.Error = err.Error()
+			}
+			ring.add(trace)
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package core
+
+// ErrStackDepth is matched by errors of code that nested calls deeper than
+// RuntimeConfig.MaxStackDepth or the language's own limit
+var ErrStackDepth = NewError(CodeResourceExhausted, "maximum stack depth exceeded")
+
+// StackDepthError marks err as caused by exceeding the stack depth, so it
+// matches ErrStackDepth while keeping its message and what it wraps
+func StackDepthError(err error) error {
+	return &stackDepthError{err: err}
+}
+
+type stackDepthError struct {
+	err error
+}
+
+func (e *stackDepthError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackDepthError) Unwrap() []error {
+	return []error{e.err, ErrStackDepth}
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SafeCounter is a counter safe for concurrent use by bridge handlers
+type SafeCounter struct {
+	value int64
+}
+
+// Increment adds one and returns the new value
+func (c *SafeCounter) Increment() int64 {
+	return atomic.AddInt64(&c.value, 1)
+}
+
+// Add adds delta and returns the new value
+func (c *SafeCounter) Add(delta int64) int64 {
+	return atomic.AddInt64(&c.value, delta)
+}
+
+// Get returns the current value
+func (c *SafeCounter) Get() int64 {
+	return atomic.LoadInt64(&c.value)
+}
+
+// Set replaces the current value
+func (c *SafeCounter) Set(value int64) {
+	atomic.StoreInt64(&c.value, value)
+}
+
+// SafeMap is a string-keyed map safe for concurrent use
+type SafeMap struct {
+	data map[string]interface{}
+	mu   sync.RWMutex
+}
+
+// NewSafeMap creates an empty concurrent map
+func NewSafeMap() *SafeMap {
+	return &SafeMap{
+		data: make(map[string]interface{}),
+	}
+}
+
+// Get retrieves a value by key
+func (m *SafeMap) Get(key string) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, exists := m.data[key]
+	return value, exists
+}
+
+// Set stores a value by key
+func (m *SafeMap) Set(key string, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+}
+
+// Delete removes a key
+func (m *SafeMap) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+}
+
+// Update atomically replaces the value for a key using fn
+func (m *SafeMap) Update(key string, fn func(value interface{}, exists bool) interface{}) interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, exists := m.data[key]
+	updated := fn(value, exists)
+	m.data[key] = updated
+	return updated
+}
+
+// Len returns the number of entries
+func (m *SafeMap) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.data)
+}
+
+// Snapshot returns a copy of the map contents
+func (m *SafeMap) Snapshot() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]interface{}, len(m.data))
+	for k, v := range m.data {
+		result[k] = v
+	}
+	return result
+}
+
+// Restore replaces the map contents with a copy of snapshot
+func (m *SafeMap) Restore(snapshot map[string]interface{}) {
+	data := make(map[string]interface{}, len(snapshot))
+	for k, v := range snapshot {
+		data[k] = v
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = data
+}
+
+// SafeSlice is a slice safe for concurrent use
+type SafeSlice struct {
+	items []interface{}
+	mu    sync.RWMutex
+}
+
+// NewSafeSlice creates an empty concurrent slice
+func NewSafeSlice() *SafeSlice {
+	return &SafeSlice{}
+}
+
+// Append adds items to the end of the slice
+func (s *SafeSlice) Append(items ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, items...)
+}
+
+// Get returns the item at index
+func (s *SafeSlice) Get(index int) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if index < 0 || index >= len(s.items) {
+		return nil, false
+	}
+	return s.items[index], true
+}
+
+// RemoveFunc deletes the first item matching fn, reporting whether one was removed
+func (s *SafeSlice) RemoveFunc(fn func(item interface{}) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, item := range s.items {
+		if fn(item) {
+			last := len(s.items) - 1
+			copy(s.items[i:], s.items[i+1:])
+			// Clear the vacated slot so the removed item can be collected
+			s.items[last] = nil
+			s.items = s.items[:last]
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of items
+func (s *SafeSlice) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items)
+}
+
+// Snapshot returns a copy of the slice contents
+func (s *SafeSlice) Snapshot() []interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]interface{}, len(s.items))
+	copy(result, s.items)
+	return result
+}
+
+// Restore replaces the slice contents with a copy of snapshot
+func (s *SafeSlice) Restore(snapshot []interface{}) {
+	items := make([]interface{}, len(snapshot))
+	copy(items, snapshot)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = items
+}
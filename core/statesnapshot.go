@@ -0,0 +1,40 @@
+package core
+
+import "context"
+
+// StateSnapshotter is implemented by runtimes that can serialize the
+// functions and data their code has defined, so an environment prepared
+// once can be restored into other workers and runtimes instead of being
+// rebuilt by running its setup code again
+type StateSnapshotter interface {
+	// SnapshotState serializes the session of one worker
+	SnapshotState(ctx context.Context) ([]byte, error)
+
+	// RestoreState defines what snapshot holds on every worker, after
+	// their InitCode and before their next execution
+	RestoreState(ctx context.Context, snapshot []byte) error
+}
+
+// SnapshotState serializes the session of rt. Snapshots only restore into
+// the same kind of runtime, and may be tied to its version.
+func SnapshotState(ctx context.Context, rt Runtime) ([]byte, error) {
+	snapshotter, ok := rt.(StateSnapshotter)
+	if !ok {
+		return nil, Errorf(CodeUnavailable, "%s runtime does not support state snapshots", rt.Name())
+	}
+	return snapshotter.SnapshotState(ctx)
+}
+
+// RestoreState restores a snapshot taken by SnapshotState into rt.
+// Restoring runs code from the snapshot, so only restore snapshots from
+// trusted sources.
+func RestoreState(ctx context.Context, rt Runtime, snapshot []byte) error {
+	snapshotter, ok := rt.(StateSnapshotter)
+	if !ok {
+		return Errorf(CodeUnavailable, "%s runtime does not support state snapshots", rt.Name())
+	}
+	if len(snapshot) == 0 {
+		return Errorf(CodeInvalidArgument, "empty state snapshot")
+	}
+	return snapshotter.RestoreState(ctx, snapshot)
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// ResultStream is returned by a bridge function to send a large result to
+// the frontend in chunks. The webview serializes one chunk at a time as the
+// page asks for it, so memory stays bounded by the chunk size rather than
+// the size of the result. polyglot.call reassembles the chunks, and
+// polyglot.stream hands them to the page one by one.
+type ResultStream struct {
+	mu     sync.Mutex
+	next   func() (interface{}, error)
+	close  func() error
+	closed bool
+}
+
+// NewResultStream creates a stream calling next for each chunk until it
+// returns io.EOF. close, which may be nil, is called once when the stream
+// ends, fails or is abandoned by the page.
+func NewResultStream(next func() (interface{}, error), close func() error) *ResultStream {
+	return &ResultStream{next: next, close: close}
+}
+
+// StreamSlice creates a stream sending the elements of slice in chunks of
+// up to size elements. Each chunk is a []interface{}, so the page
+// reassembles the original array.
+func StreamSlice(slice interface{}, size int) *ResultStream {
+	value := reflect.ValueOf(slice)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return NewResultStream(func() (interface{}, error) {
+			return nil, Errorf(CodeInvalidArgument, "cannot stream %T as a slice", slice)
+		}, nil)
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	offset := 0
+	return NewResultStream(func() (interface{}, error) {
+		if offset >= value.Len() {
+			return nil, io.EOF
+		}
+		end := offset + size
+		if end > value.Len() {
+			end = value.Len()
+		}
+		chunk := make([]interface{}, 0, end-offset)
+		for ; offset < end; offset++ {
+			chunk = append(chunk, value.Index(offset).Interface())
+		}
+		return chunk, nil
+	}, nil)
+}
+
+// StreamChannel creates a stream sending each value received from ch as a
+// chunk until ch is closed. A value that is an error fails the stream.
+func StreamChannel(ch <-chan interface{}) *ResultStream {
+	return NewResultStream(func() (interface{}, error) {
+		value, ok := <-ch
+		if !ok {
+			return nil, io.EOF
+		}
+		if err, isErr := value.(error); isErr {
+			return nil, err
+		}
+		return value, nil
+	}, nil)
+}
+
+// Next returns the next chunk, or io.EOF once the stream is exhausted. The
+// stream is closed when it returns an error, including io.EOF.
+func (s *ResultStream) Next() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, io.EOF
+	}
+	chunk, err := s.safeNext()
+	if err != nil {
+		s.closeLocked()
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// safeNext calls next, turning a panic into an error
+func (s *ResultStream) safeNext() (chunk interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Errorf(CodeInternal, "result stream panicked: %v", r)
+		}
+	}()
+	if s.next == nil {
+		return nil, io.EOF
+	}
+	return s.next()
+}
+
+// Collect drains the stream and reassembles the result the way
+// polyglot.call does: when every chunk is a []interface{} they are
+// concatenated, otherwise the chunks are returned as a list
+func (s *ResultStream) Collect() (interface{}, error) {
+	var chunks []interface{}
+	concat := true
+	for {
+		chunk, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := chunk.([]interface{}); !ok {
+			concat = false
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if !concat {
+		return chunks, nil
+	}
+	items := []interface{}{}
+	for _, chunk := range chunks {
+		items = append(items, chunk.([]interface{})...)
+	}
+	return items, nil
+}
+
+// Close releases the stream. It is safe to call more than once.
+func (s *ResultStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeLocked()
+}
+
+func (s *ResultStream) closeLocked() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.close == nil {
+		return nil
+	}
+	if err := s.close(); err != nil {
+		return fmt.Errorf("failed to close result stream: %w", err)
+	}
+	return nil
+}
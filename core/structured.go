@@ -0,0 +1,41 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Markers around the JSON result a subprocess program prints when its
+// runtime has StructuredOutput set
+const (
+	ResultStart = "<<<POLYGLOT_RESULT>>>"
+	ResultEnd   = "<<<END_POLYGLOT_RESULT>>>"
+)
+
+// ParseStructuredOutput decodes the JSON a program printed between
+// ResultStart and ResultEnd, so whatever else it prints, such as logging,
+// stays out of the result. Numbers decode as int64 when integral and
+// float64 otherwise. If the program printed several results the last one
+// wins; found is false when it printed none.
+func ParseStructuredOutput(output []byte) (result interface{}, found bool, err error) {
+	start := bytes.LastIndex(output, []byte(ResultStart))
+	if start < 0 {
+		return nil, false, nil
+	}
+	data := output[start+len(ResultStart):]
+	end := bytes.Index(data, []byte(ResultEnd))
+	if end < 0 {
+		return nil, true, fmt.Errorf("structured result is missing its %s marker", ResultEnd)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data[:end]))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, true, fmt.Errorf("invalid structured result: %w", err)
+	}
+	if decoder.More() {
+		return nil, true, fmt.Errorf("invalid structured result: trailing data after value")
+	}
+	return integralNumbers(result), true, nil
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ThreadDispatcher runs functions one at a time, in the order they are
+// submitted, on a single goroutine locked to its own OS thread. Runtimes
+// use it for RuntimeConfig.SingleThreaded, so backends whose native state
+// must stay on one thread never see another.
+//
+// A nil dispatcher runs Do inline and Go on a new goroutine, so callers
+// can use one unconditionally. After Close, both behave as on a nil
+// dispatcher.
+type ThreadDispatcher struct {
+	mu     sync.Mutex
+	queue  []func()
+	wake   chan struct{}
+	closed bool
+}
+
+// NewThreadDispatcher starts a dispatcher and its thread
+func NewThreadDispatcher() *ThreadDispatcher {
+	d := &ThreadDispatcher{wake: make(chan struct{}, 1)}
+	go d.loop()
+	return d
+}
+
+// loop runs queued functions on the locked thread until the dispatcher
+// is closed and its queue drained
+func (d *ThreadDispatcher) loop() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	for {
+		d.mu.Lock()
+		queue, closed := d.queue, d.closed
+		d.queue = nil
+		d.mu.Unlock()
+
+		for _, fn := range queue {
+			fn()
+		}
+		if len(queue) == 0 {
+			if closed {
+				return
+			}
+			<-d.wake
+		}
+	}
+}
+
+// Go queues fn to run on the dispatcher's thread and returns without
+// waiting for it
+func (d *ThreadDispatcher) Go(fn func()) {
+	if !d.submit(fn) {
+		go fn()
+	}
+}
+
+// Do runs fn on the dispatcher's thread, after everything queued before
+// it, and returns its error. It must not be called from a function the
+// dispatcher is running, which would wait on itself.
+func (d *ThreadDispatcher) Do(fn func() error) error {
+	done := make(chan error, 1)
+	if !d.submit(func() { done <- fn() }) {
+		return fn()
+	}
+	return <-done
+}
+
+// submit queues fn, reporting false if the dispatcher is nil or closed
+func (d *ThreadDispatcher) submit(fn func()) bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return false
+	}
+	d.queue = append(d.queue, fn)
+	d.mu.Unlock()
+
+	d.signal()
+	return true
+}
+
+// signal wakes the loop if it is waiting
+func (d *ThreadDispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the dispatcher once the functions already queued have run,
+// releasing its thread. It is safe to call more than once.
+func (d *ThreadDispatcher) Close() {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.signal()
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// TimePolicy controls how time.Time values are represented when crossing
+// the bridge or passed into runtimes without a native time type
+type TimePolicy string
+
+const (
+	// TimesRFC3339 encodes times as RFC 3339 strings with nanosecond
+	// precision, as encoding/json does. This is the default.
+	TimesRFC3339 TimePolicy = "rfc3339"
+
+	// TimesMillis encodes times as integer milliseconds since the Unix
+	// epoch, the value JavaScript's Date.now and getTime return
+	TimesMillis TimePolicy = "millis"
+)
+
+// ParseTimePolicy returns the policy for a config value, defaulting to
+// RFC 3339
+func ParseTimePolicy(name string) TimePolicy {
+	if TimePolicy(name) == TimesMillis {
+		return TimesMillis
+	}
+	return TimesRFC3339
+}
+
+// TimePolicyFor returns the time policy set in a runtime's
+// Options["time_policy"]
+func TimePolicyFor(config RuntimeConfig) TimePolicy {
+	name, _ := config.Options["time_policy"].(string)
+	return ParseTimePolicy(name)
+}
+
+// EncodeTime converts t to its representation under policy
+func EncodeTime(t time.Time, policy TimePolicy) interface{} {
+	if policy == TimesMillis {
+		return t.UnixMilli()
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// EncodeTimes replaces time.Time values with their representation under
+// policy, descending into generic slices and maps. Times inside structs
+// keep their own MarshalJSON encoding.
+func EncodeTimes(v interface{}, policy TimePolicy) interface{} {
+	switch val := v.(type) {
+	case time.Time:
+		return EncodeTime(val, policy)
+	case *time.Time:
+		if val == nil {
+			return v
+		}
+		return EncodeTime(*val, policy)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = EncodeTimes(item, policy)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = EncodeTimes(item, policy)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// ParseTime converts a decoded bridge value back to a time.Time. It
+// accepts an RFC 3339 string or a number of milliseconds since the Unix
+// epoch, so handlers read times the same way under either policy.
+// Millisecond times are returned in UTC.
+func ParseTime(v interface{}) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, val)
+		if err != nil {
+			return time.Time{}, Errorf(CodeInvalidArgument, "invalid time %q: %v", val, err)
+		}
+		return t, nil
+	case int64:
+		return time.UnixMilli(val).UTC(), nil
+	case int:
+		return time.UnixMilli(int64(val)).UTC(), nil
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return time.Time{}, Errorf(CodeInvalidArgument, "invalid time %v", val)
+		}
+		return time.UnixMilli(int64(math.Round(val))).UTC(), nil
+	case json.Number:
+		ms, err := strconv.ParseInt(string(val), 10, 64)
+		if err != nil {
+			f, ferr := val.Float64()
+			if ferr != nil {
+				return time.Time{}, Errorf(CodeInvalidArgument, "invalid time %q: %v", val, err)
+			}
+			return ParseTime(f)
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	default:
+		return time.Time{}, Errorf(CodeInvalidArgument, "cannot convert %T to a time", v)
+	}
+}
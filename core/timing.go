@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Defaults for TimingOptions
+const (
+	DefaultTimingIterations = 100
+	DefaultTimingWarmup     = 5
+)
+
+// TimingOptions configures Orchestrator.TimeWith
+type TimingOptions struct {
+	// Iterations is the number of measured executions. Zero uses
+	// DefaultTimingIterations.
+	Iterations int
+
+	// Warmup is the number of executions run first and left out of the
+	// statistics, so one-time costs such as imports and compilation do not
+	// skew them. Zero uses DefaultTimingWarmup; a negative value disables
+	// warmup.
+	Warmup int
+}
+
+// withDefaults fills unset fields with their defaults
+func (o TimingOptions) withDefaults() TimingOptions {
+	if o.Iterations <= 0 {
+		o.Iterations = DefaultTimingIterations
+	}
+	if o.Warmup == 0 {
+		o.Warmup = DefaultTimingWarmup
+	}
+	if o.Warmup < 0 {
+		o.Warmup = 0
+	}
+	return o
+}
+
+// TimingResult summarizes the latency of repeated executions
+type TimingResult struct {
+	// Iterations is the number of measured executions
+	Iterations int `json:"iterations"`
+
+	Min  time.Duration `json:"min"`
+	Max  time.Duration `json:"max"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+
+	// Total is the sum of the measured executions
+	Total time.Duration `json:"total"`
+}
+
+// SummarizeTimings computes latency statistics over timings, using
+// nearest-rank percentiles. timings is left unchanged.
+func SummarizeTimings(timings []time.Duration) TimingResult {
+	result := TimingResult{Iterations: len(timings)}
+	if len(timings) == 0 {
+		return result
+	}
+
+	sorted := append([]time.Duration(nil), timings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, d := range sorted {
+		result.Total += d
+	}
+
+	result.Min = sorted[0]
+	result.Max = sorted[len(sorted)-1]
+	result.Mean = result.Total / time.Duration(len(sorted))
+	result.P50 = percentile(sorted, 50)
+	result.P90 = percentile(sorted, 90)
+	result.P99 = percentile(sorted, 99)
+	return result
+}
+
+// percentile returns the nearest-rank percentile p of sorted timings
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Time runs code in runtime repeatedly and reports its latency, with
+// DefaultTimingWarmup warmup runs and DefaultTimingIterations measured
+// runs. See TimeWith.
+func (o *Orchestrator) Time(ctx context.Context, runtime string, code string) (TimingResult, error) {
+	return o.TimeWith(ctx, runtime, code, TimingOptions{})
+}
+
+// TimeWith runs code in runtime opts.Warmup times, then opts.Iterations
+// times measuring each execution as Execute would run it, middleware and
+// transforms included. Runs are sequential, so the statistics reflect
+// latency rather than throughput. The first failed run stops timing and
+// its error is returned.
+func (o *Orchestrator) TimeWith(ctx context.Context, runtime string, code string, opts TimingOptions) (TimingResult, error) {
+	opts = opts.withDefaults()
+	for i := 0; i < opts.Warmup; i++ {
+		if _, err := o.ExecuteInfo(ctx, runtime, code); err != nil {
+			return TimingResult{}, fmt.Errorf("warmup run %d failed: %w", i+1, err)
+		}
+	}
+
+	timings := make([]time.Duration, 0, opts.Iterations)
+	for i := 0; i < opts.Iterations; i++ {
+		result, err := o.ExecuteInfo(ctx, runtime, code)
+		if err != nil {
+			return SummarizeTimings(timings), fmt.Errorf("run %d failed: %w", i+1, err)
+		}
+		timings = append(timings, result.Duration)
+	}
+	return SummarizeTimings(timings), nil
+}
@@ -0,0 +1,337 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenType classifies a syntax token
+type TokenType string
+
+const (
+	TokenKeyword    TokenType = "keyword"
+	TokenString     TokenType = "string"
+	TokenNumber     TokenType = "number"
+	TokenComment    TokenType = "comment"
+	TokenIdentifier TokenType = "identifier"
+	TokenOperator   TokenType = "operator"
+)
+
+// Token is a syntax token for highlighting. Whitespace is not tokenized.
+type Token struct {
+	Type  TokenType `json:"type"`
+	Value string    `json:"value"`
+
+	// Offset is the byte offset of the token in the source
+	Offset int `json:"offset"`
+
+	// Line and Column are 1-based; Column counts runes
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Tokenizer is implemented by runtimes that tokenize code themselves
+type Tokenizer interface {
+	Tokenize(code string) ([]Token, error)
+}
+
+// lexer describes the lexical conventions of a language
+type lexer struct {
+	keywords      []string
+	lineComments  []string
+	blockComments [][2]string
+	quotes        string
+	longStrings   [][2]string
+	identExtra    string
+	stringPrefix  []string
+}
+
+var cStyleComments = [][2]string{{"/*", "*/"}}
+
+// lexers holds the lexical conventions of each supported language
+var lexers = map[string]*lexer{
+	"python": {
+		keywords: []string{"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class",
+			"continue", "def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if",
+			"import", "in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try",
+			"while", "with", "yield"},
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		longStrings:  [][2]string{{`"""`, `"""`}, {`'''`, `'''`}},
+		stringPrefix: []string{"r", "b", "f", "u", "rb", "br", "fr", "rf"},
+	},
+	"lua": {
+		keywords: []string{"and", "break", "do", "else", "elseif", "end", "false", "for", "function", "goto",
+			"if", "in", "local", "nil", "not", "or", "repeat", "return", "then", "true", "until", "while"},
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"--[[", "]]"}},
+		quotes:        `"'`,
+		longStrings:   [][2]string{{"[[", "]]"}},
+	},
+	"javascript": {
+		keywords: []string{"async", "await", "break", "case", "catch", "class", "const", "continue", "debugger",
+			"default", "delete", "do", "else", "export", "extends", "false", "finally", "for", "function", "if",
+			"import", "in", "instanceof", "let", "new", "null", "of", "return", "super", "switch", "this",
+			"throw", "true", "try", "typeof", "undefined", "var", "void", "while", "with", "yield"},
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        "\"'`",
+		identExtra:    "$",
+	},
+	"go": {
+		keywords: []string{"break", "case", "chan", "const", "continue", "default", "defer", "else",
+			"fallthrough", "for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+			"return", "select", "struct", "switch", "type", "var", "true", "false", "nil"},
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        "\"'`",
+	},
+	"rust": {
+		keywords: []string{"as", "async", "await", "break", "const", "continue", "crate", "dyn", "else", "enum",
+			"extern", "false", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut",
+			"pub", "ref", "return", "self", "Self", "static", "struct", "super", "trait", "true", "type",
+			"unsafe", "use", "where", "while"},
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"`,
+	},
+	"cpp": {
+		keywords: []string{"auto", "bool", "break", "case", "catch", "char", "class", "const", "constexpr",
+			"continue", "default", "delete", "do", "double", "else", "enum", "explicit", "extern", "false",
+			"float", "for", "friend", "if", "inline", "int", "long", "namespace", "new", "nullptr", "operator",
+			"private", "protected", "public", "return", "short", "signed", "sizeof", "static", "struct",
+			"switch", "template", "this", "throw", "true", "try", "typedef", "typename", "union", "unsigned",
+			"using", "virtual", "void", "volatile", "while"},
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+	},
+	"java": {
+		keywords: []string{"abstract", "boolean", "break", "byte", "case", "catch", "char", "class", "continue",
+			"default", "do", "double", "else", "enum", "extends", "false", "final", "finally", "float", "for",
+			"if", "implements", "import", "instanceof", "int", "interface", "long", "new", "null", "package",
+			"private", "protected", "public", "return", "short", "static", "super", "switch", "synchronized",
+			"this", "throw", "throws", "true", "try", "var", "void", "volatile", "while"},
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+	},
+	"ruby": {
+		keywords: []string{"BEGIN", "END", "alias", "and", "begin", "break", "case", "class", "def",
+			"do", "else", "elsif", "end", "ensure", "false", "for", "if", "in", "module", "next", "nil", "not",
+			"or", "redo", "rescue", "retry", "return", "self", "super", "then", "true", "undef", "unless",
+			"until", "when", "while", "yield"},
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	},
+	"php": {
+		keywords: []string{"abstract", "array", "as", "break", "case", "catch", "class", "const", "continue",
+			"default", "do", "echo", "else", "elseif", "extends", "false", "final", "finally", "fn", "for",
+			"foreach", "function", "if", "implements", "interface", "namespace", "new", "null", "private",
+			"protected", "public", "return", "static", "switch", "throw", "true", "try", "use", "while"},
+		lineComments:  []string{"//", "#"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		identExtra:    "$",
+	},
+	"zig": {
+		keywords: []string{"align", "and", "break", "catch", "comptime", "const", "continue", "defer", "else",
+			"enum", "errdefer", "error", "export", "extern", "false", "fn", "for", "if", "inline", "null", "or",
+			"orelse", "pub", "return", "struct", "switch", "test", "true", "try", "undefined", "union", "unreachable",
+			"var", "while"},
+		lineComments: []string{"//"},
+		quotes:       `"'`,
+	},
+}
+
+// Tokenize returns syntax tokens for code in a runtime's language.
+// Runtimes implementing Tokenizer tokenize their own code.
+func Tokenize(rt Runtime, code string) ([]Token, error) {
+	if tokenizer, ok := rt.(Tokenizer); ok {
+		return tokenizer.Tokenize(code)
+	}
+	return TokenizeCode(rt.Name(), code)
+}
+
+// TokenizeCode returns syntax tokens for code in a language. Unterminated
+// strings and comments extend to the end of the code.
+func TokenizeCode(language, code string) ([]Token, error) {
+	lx, ok := lexers[language]
+	if !ok {
+		return nil, Errorf(CodeInvalidArgument, "no tokenizer for %s", language)
+	}
+	return lx.tokenize(code), nil
+}
+
+func (lx *lexer) tokenize(code string) []Token {
+	keywords := make(map[string]bool, len(lx.keywords))
+	for _, kw := range lx.keywords {
+		keywords[kw] = true
+	}
+
+	var tokens []Token
+	line, column := 1, 1
+	pos := 0
+
+	emit := func(typ TokenType, end int) {
+		value := code[pos:end]
+		tokens = append(tokens, Token{Type: typ, Value: value, Offset: pos, Line: line, Column: column})
+		for _, r := range value {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		pos = end
+	}
+
+	for pos < len(code) {
+		rest := code[pos:]
+		r, size := utf8.DecodeRuneInString(rest)
+
+		switch {
+		case unicode.IsSpace(r):
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+			pos += size
+
+		case delimitedLength(lx.blockComments, rest) > 0:
+			emit(TokenComment, pos+delimitedLength(lx.blockComments, rest))
+
+		case hasAnyPrefix(rest, lx.lineComments):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(TokenComment, pos+end)
+
+		case delimitedLength(lx.longStrings, rest) > 0:
+			emit(TokenString, pos+delimitedLength(lx.longStrings, rest))
+
+		case strings.ContainsRune(lx.quotes, r):
+			emit(TokenString, pos+quotedLength(rest))
+
+		case unicode.IsDigit(r) || r == '.' && len(rest) > 1 && isDigit(rest[1]):
+			emit(TokenNumber, pos+numberLength(rest))
+
+		case unicode.IsLetter(r) || r == '_' || strings.ContainsRune(lx.identExtra, r):
+			n := lx.identLength(rest)
+			word := rest[:n]
+			if lx.isStringPrefix(word) && n < len(rest) && strings.ContainsRune(lx.quotes, rune(rest[n])) {
+				if m := delimitedLength(lx.longStrings, rest[n:]); m > 0 {
+					emit(TokenString, pos+n+m)
+				} else {
+					emit(TokenString, pos+n+quotedLength(rest[n:]))
+				}
+				continue
+			}
+			if keywords[word] {
+				emit(TokenKeyword, pos+n)
+			} else {
+				emit(TokenIdentifier, pos+n)
+			}
+
+		default:
+			emit(TokenOperator, pos+size)
+		}
+	}
+
+	return tokens
+}
+
+// delimitedLength returns the length of a delimited span (long string or
+// block comment) starting s, or 0 when none starts there
+func delimitedLength(pairs [][2]string, s string) int {
+	for _, pair := range pairs {
+		if !strings.HasPrefix(s, pair[0]) {
+			continue
+		}
+		end := strings.Index(s[len(pair[0]):], pair[1])
+		if end < 0 {
+			return len(s)
+		}
+		return len(pair[0]) + end + len(pair[1])
+	}
+	return 0
+}
+
+func (lx *lexer) identLength(s string) int {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && !strings.ContainsRune(lx.identExtra, r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+func (lx *lexer) isStringPrefix(word string) bool {
+	for _, prefix := range lx.stringPrefix {
+		if strings.EqualFold(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// quotedLength returns the length of the quoted string starting s,
+// honoring backslash escapes. Single- and double-quoted strings end at a
+// newline when unterminated.
+func quotedLength(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i + 1
+		case s[i] == '\n' && quote != '`':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// numberLength returns the length of the numeric literal starting s,
+// including hex digits, separators, and signed exponents
+func numberLength(s string) int {
+	hex := strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")
+	n := 0
+	for n < len(s) {
+		c := s[n]
+		switch {
+		case c == '.' && n+1 < len(s) && s[n+1] == '.':
+			// Range or concatenation operator, e.g. 1..10
+			return n
+		case isDigit(c) || c == '.' || c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+			n++
+		case (c == '+' || c == '-') && !hex && n > 0 && (s[n-1] == 'e' || s[n-1] == 'E'):
+			n++
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
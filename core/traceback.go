@@ -0,0 +1,151 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TraceFrame is one frame of a script traceback
+type TraceFrame struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function,omitempty"`
+
+	// Source is the frame's line of code, when the traceback shows it
+	Source string `json:"source,omitempty"`
+}
+
+// ScriptError is an exception raised by script code, condensed for display
+// next to its full traceback. The frontend receives it as SCRIPT_ERROR with
+// the condensed message and type, frame and traceback details.
+type ScriptError struct {
+	// Runtime that raised the exception
+	Runtime string
+
+	// Type is the exception class, such as ValueError
+	Type string
+
+	// Message is the exception message without the traceback
+	Message string
+
+	// Frame is the innermost frame in user code, or nil when the
+	// traceback has none
+	Frame *TraceFrame
+
+	// Frames lists the traceback from the outermost call inward
+	Frames []TraceFrame
+
+	// Traceback is the full traceback text
+	Traceback string
+
+	// Err is the error the runtime returned
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Summary()
+}
+
+// Unwrap returns the runtime's error
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// Summary is a one-line description such as "ValueError: bad input (line 3)"
+func (e *ScriptError) Summary() string {
+	summary := e.Type
+	if e.Message != "" {
+		summary += ": " + e.Message
+	}
+	if e.Frame != nil && e.Frame.Line > 0 {
+		summary += " (line " + strconv.Itoa(e.Frame.Line) + ")"
+	}
+	return summary
+}
+
+var (
+	pythonFramePattern     = regexp.MustCompile(`^\s*File "(.+)", line (\d+)(?:, in (.+))?$`)
+	pythonCaretPattern     = regexp.MustCompile(`^\s*[\^~]+\s*$`)
+	pythonExceptionPattern = regexp.MustCompile(`^([A-Za-z_][\w.]*)(?:: (.*))?$`)
+)
+
+// pythonLibraryPaths mark frames outside user code
+var pythonLibraryPaths = []string{"/lib/python", `\lib\`, "site-packages", "dist-packages", "<frozen "}
+
+// ParsePythonTraceback condenses a Python traceback, as printed by the
+// interpreter, into a ScriptError. Of chained exceptions only the last is
+// kept. It reports false when text holds no exception line.
+func ParsePythonTraceback(text string) (*ScriptError, bool) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	var frames []TraceFrame
+	exception := -1
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "Traceback (most recent call last):"):
+			// A later traceback belongs to a chained exception
+			frames = nil
+			exception = -1
+		case pythonFramePattern.MatchString(line):
+			m := pythonFramePattern.FindStringSubmatch(line)
+			number, _ := strconv.Atoi(m[2])
+			frame := TraceFrame{File: m[1], Line: number, Function: m[3]}
+			if i+1 < len(lines) && isPythonSourceLine(lines[i+1]) {
+				frame.Source = strings.TrimSpace(lines[i+1])
+				i++
+			}
+			frames = append(frames, frame)
+			exception = -1
+		case exception < 0 && pythonExceptionPattern.MatchString(line):
+			exception = i
+		}
+	}
+	if exception < 0 {
+		return nil, false
+	}
+
+	m := pythonExceptionPattern.FindStringSubmatch(lines[exception])
+	message := m[2]
+	// Messages may span lines up to the next traceback section
+	for _, line := range lines[exception+1:] {
+		if line == "" || strings.HasPrefix(line, "Traceback (most recent call last):") {
+			break
+		}
+		message += "\n" + line
+	}
+
+	err := &ScriptError{
+		Runtime:   "python",
+		Type:      m[1],
+		Message:   strings.TrimSpace(message),
+		Frames:    frames,
+		Traceback: text,
+	}
+	for i := len(frames) - 1; i >= 0; i-- {
+		if isPythonUserFrame(frames[i]) {
+			err.Frame = &frames[i]
+			break
+		}
+	}
+	return err, true
+}
+
+// isPythonSourceLine reports whether line shows the code of the frame
+// before it
+func isPythonSourceLine(line string) bool {
+	return strings.HasPrefix(line, "    ") && !pythonFramePattern.MatchString(line) && !pythonCaretPattern.MatchString(line)
+}
+
+func isPythonUserFrame(frame TraceFrame) bool {
+	for _, path := range pythonLibraryPaths {
+		if strings.Contains(frame.File, path) {
+			return false
+		}
+	}
+	return true
+}
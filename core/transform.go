@@ -0,0 +1,36 @@
+package core
+
+// TransformFunc rewrites code before it is executed, for example to expand
+// macros, add instrumentation or prepend polyfills
+type TransformFunc func(code string) (string, error)
+
+// SetTransform makes Execute pass code for runtime through fn before the
+// runtime sees it. Middleware observe the original code. An error from fn
+// fails the execution with CodeInvalidArgument without running anything. A
+// nil fn removes the transform.
+func (o *Orchestrator) SetTransform(runtime string, fn TransformFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if fn == nil {
+		delete(o.transforms, runtime)
+		return
+	}
+	o.transforms[runtime] = fn
+}
+
+// transform applies the transform registered for runtime to code, if any
+func (o *Orchestrator) transform(runtime string, code string) (string, error) {
+	o.mu.RLock()
+	fn, ok := o.transforms[runtime]
+	o.mu.RUnlock()
+
+	if !ok {
+		return code, nil
+	}
+	transformed, err := fn(code)
+	if err != nil {
+		return "", Errorf(CodeInvalidArgument, "transform for %s failed: %w", runtime, err)
+	}
+	return transformed, nil
+}
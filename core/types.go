@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"io"
 	"time"
 )
 
@@ -34,6 +35,11 @@ type RuntimeConfig struct {
 	// Version constraint (e.g., "3.11", ">=1.70")
 	Version string
 
+	// MinVersion and MaxVersion bound the detected runtime version, checked
+	// during Initialize (e.g., "3.10" and "3.12"). Empty means unbounded.
+	MinVersion string
+	MaxVersion string
+
 	// Enabled determines if this runtime should be initialized
 	Enabled bool
 
@@ -45,6 +51,130 @@ type RuntimeConfig struct {
 
 	// Timeout for initialization
 	Timeout time.Duration
+
+	// IdleTimeout tears down pooled workers idle longer than this, re-spawning
+	// them on demand. Zero keeps all workers for the process lifetime.
+	IdleTimeout time.Duration
+
+	// MinWorkers is the number of workers kept when reclaiming idle ones
+	MinWorkers int
+
+	// Events receives TopicPoolScale events as pooled workers are started
+	// and retired. The orchestrator sets it to its event bus when unset.
+	Events *EventBus
+
+	// Descriptors tracks the file descriptors held for the programs
+	// subprocess runtimes run. The orchestrator sets it to its tracker,
+	// capped at Config.MaxFileDescriptors, when unset.
+	Descriptors *DescriptorTracker
+
+	// SelfTest evaluates a trivial expression after initialization and
+	// fails startup if the runtime returns the wrong result
+	SelfTest bool
+
+	// LazyInit leaves the runtime out of Orchestrator.Initialize and starts
+	// it on its first Execute or Call instead. Concurrent first calls wait
+	// for a single initialization; if it fails they all return its error
+	// and the next call tries again.
+	LazyInit bool
+
+	// MemoryLimit caps the data segment of subprocess runtimes in bytes,
+	// the heap and other writable memory they allocate, and CPULimit caps
+	// their CPU time. Enforced on Linux only; zero disables a limit.
+	MemoryLimit int64
+	CPULimit    time.Duration
+
+	// MaxStackDepth caps how deeply calls may nest, so runaway recursion
+	// fails with an error matching ErrStackDepth instead of crashing the
+	// process. Python applies it to the whole interpreter as its recursion
+	// limit and Lua to each worker. Zero keeps the language's own limit.
+	MaxStackDepth int
+
+	// OutputEncoding controls how subprocess runtimes return output that
+	// is not valid UTF-8. Empty means OutputReplace.
+	OutputEncoding OutputEncoding
+
+	// StructuredOutput makes subprocess runtimes return the JSON their
+	// program prints between ResultStart and ResultEnd, decoded, instead of
+	// everything it prints. Programs that print no result return their
+	// output as usual.
+	StructuredOutput bool
+
+	// ResetBetweenCalls clears a worker's interpreter scope after every
+	// Execute so globals and imports from one call are not visible to the
+	// next. Use it when serving untrusted code through reused workers; it
+	// costs a scope rebuild per call. Modules stay loaded: in Python they
+	// remain in sys.modules, and changes code makes to a module persist.
+	ResetBetweenCalls bool
+
+	// InitCode runs on each worker of a scripting runtime as it starts,
+	// before its first execution, to define helper functions or set up the
+	// environment every call relies on. Unlike code sent with each call it
+	// runs once per worker; with ResetBetweenCalls it runs again after
+	// each reset so its definitions remain. If it fails, so does starting
+	// the worker. Python and Lua support it.
+	InitCode string
+
+	// InitState is a snapshot from SnapshotState restored on each worker
+	// as it starts, after InitCode, and again after each reset, so workers
+	// start with a prepared environment without running the code that
+	// built it. Python and Lua support it.
+	InitState []byte
+
+	// ShutdownCode runs on each worker of a scripting runtime before it is
+	// torn down, to flush buffers, close resources or persist state its
+	// InitCode opened. It runs once per worker: at Shutdown for idle
+	// workers, and for busy ones when their execution finishes. Code still
+	// running when the Shutdown context ends is interrupted, and failures
+	// are ignored so teardown goes ahead. Python and Lua support it.
+	ShutdownCode string
+
+	// SingleThreaded runs all of a runtime's native work on one dedicated
+	// OS thread, one call at a time in the order calls arrive, for
+	// backends or extensions that break when used from several threads.
+	// It trades concurrency for correctness: MaxConcurrency is ignored.
+	// Python supports it.
+	SingleThreaded bool
+
+	// CaptureLastExpr makes Execute return the value of the code's final
+	// expression in every scripting runtime, so "2 + 2" yields 4 without an
+	// explicit return or echo. Runtimes that already behave this way
+	// ignore it.
+	CaptureLastExpr bool
+
+	// Stdout and Stderr receive what the runtime's code prints, for every
+	// execution, such as to tee it to a log file. Subprocess runtimes still
+	// return stdout as their result. Nil leaves output where it goes by
+	// default; runtimes that do not capture output ignore them.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Deterministic makes scripting runtimes reproducible for tests: before
+	// every execution their random number generators are seeded with
+	// DeterministicSeed and their clocks are frozen at DeterministicTime.
+	// Python's hash randomization is disabled too. PHP only seeds its
+	// generator; compiled runtimes ignore it.
+	Deterministic     bool
+	DeterministicSeed int64
+
+	// DeterministicTime is the time clocks report in deterministic mode.
+	// Zero means DefaultDeterministicTime.
+	DeterministicTime time.Time
+
+	// WASMInterpreterFuel runs WASM modules in the metered interpreter and
+	// limits the instructions a call may execute, so an untrusted module
+	// cannot loop forever; calls that run out fail with
+	// wasm.ErrFuelExhausted. Only the integer subset the interpreter
+	// supports loads: no imports, memory, globals, tables or floats. Zero
+	// disables the interpreter.
+	WASMInterpreterFuel uint64
+
+	// WASMVerifyModules re-hashes every module loaded into the WASM
+	// runtime before each Execute and Call, failing them with
+	// wasm.ErrModuleCorrupted if a module's bytes changed since it was
+	// loaded. Hashing costs time in proportion to the modules' size, so
+	// it is off by default.
+	WASMVerifyModules bool
 }
 
 // MemoryRegion represents shared memory accessible across runtimes
@@ -63,6 +193,9 @@ type MemoryRegion struct {
 
 	// Writers tracks active writers for sync
 	Writers int
+
+	// coordinator traces accesses, nil for regions made outside one
+	coordinator *MemoryCoordinator
 }
 
 // MemoryType describes the structure of shared memory
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// versionPattern finds the first dotted version number in a version string,
+// e.g. "3.11.4" in "3.11.4 (main, Jun 7 2023)" or "1.21" in "go1.21"
+var versionPattern = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseVersion extracts up to three numeric components from a version string
+func ParseVersion(s string) ([]int, error) {
+	match := versionPattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, fmt.Errorf("no version number in %q", s)
+	}
+
+	var parts []int
+	for _, part := range match[1:] {
+		if part == "" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
+
+// compareVersions compares a and b over the components present in b, so
+// "3.12.1" equals "3.12"
+func compareVersions(a, b []int) int {
+	for i, want := range b {
+		got := 0
+		if i < len(a) {
+			got = a[i]
+		}
+		if got != want {
+			if got < want {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// VersionError reports a runtime whose detected version is out of range
+type VersionError struct {
+	Runtime  string
+	Detected string
+	Min      string
+	Max      string
+}
+
+func (e *VersionError) Error() string {
+	if e.Min != "" && e.Max != "" {
+		return fmt.Sprintf("%s version %s is outside the supported range %s to %s", e.Runtime, e.Detected, e.Min, e.Max)
+	}
+	if e.Min != "" {
+		return fmt.Sprintf("%s version %s is below the minimum %s", e.Runtime, e.Detected, e.Min)
+	}
+	return fmt.Sprintf("%s version %s is above the maximum %s", e.Runtime, e.Detected, e.Max)
+}
+
+// CheckVersion verifies a detected version lies within [min, max]. Empty
+// bounds are not checked; max matches any patch release of its precision.
+func CheckVersion(runtime, detected, min, max string) error {
+	if min == "" && max == "" {
+		return nil
+	}
+
+	got, err := ParseVersion(detected)
+	if err != nil {
+		return fmt.Errorf("cannot check %s version: %w", runtime, err)
+	}
+
+	verr := &VersionError{Runtime: runtime, Detected: detected, Min: min, Max: max}
+
+	if min != "" {
+		want, err := ParseVersion(min)
+		if err != nil {
+			return fmt.Errorf("invalid minimum version for %s: %w", runtime, err)
+		}
+		if compareVersions(got, want) < 0 {
+			return verr
+		}
+	}
+
+	if max != "" {
+		want, err := ParseVersion(max)
+		if err != nil {
+			return fmt.Errorf("invalid maximum version for %s: %w", runtime, err)
+		}
+		if compareVersions(got, want) > 0 {
+			return verr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// WatchdogConfig configures detection of stuck executions
+type WatchdogConfig struct {
+	// Multiplier of the runtime's Timeout after which an execution is
+	// reported as stuck (default 3)
+	Multiplier float64
+
+	// DefaultTimeout is used for runtimes without a configured Timeout
+	// (default 30s)
+	DefaultTimeout time.Duration
+
+	// Interval between checks (default one quarter of the smallest
+	// threshold, at least 10ms)
+	Interval time.Duration
+
+	// ForceKill cancels the context of stuck executions so callers return
+	// instead of waiting forever
+	ForceKill bool
+}
+
+// StuckExecution describes an execution that exceeded its threshold
+type StuckExecution struct {
+	// ID identifies the execution
+	ID uint64
+
+	// Runtime running the code
+	Runtime string
+
+	// Code or function name being executed (truncated)
+	Code string
+
+	// Started is when the execution began
+	Started time.Time
+
+	// Elapsed is how long the execution has been running
+	Elapsed time.Duration
+
+	// Threshold that was exceeded
+	Threshold time.Duration
+
+	// Killed reports whether the execution context was cancelled
+	Killed bool
+
+	// Stack is a dump of all goroutines when the execution was flagged
+	Stack string
+}
+
+// maxStuckCode bounds the code snippet included in reports
+const maxStuckCode = 200
+
+// maxStuckStack bounds the goroutine dump included in reports
+const maxStuckStack = 64 * 1024
+
+type watchedExecution struct {
+	info      StuckExecution
+	cancel    context.CancelFunc
+	threshold time.Duration
+	reported  bool
+}
+
+// Watchdog reports executions running far past their expected timeout
+type Watchdog struct {
+	config  WatchdogConfig
+	events  *EventBus
+	mu      sync.Mutex
+	running map[uint64]*watchedExecution
+	nextID  uint64
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// newWatchdog creates a watchdog publishing to events
+func newWatchdog(config WatchdogConfig, events *EventBus) *Watchdog {
+	if config.Multiplier <= 0 {
+		config.Multiplier = 3
+	}
+	if config.DefaultTimeout <= 0 {
+		config.DefaultTimeout = 30 * time.Second
+	}
+
+	return &Watchdog{
+		config:  config,
+		events:  events,
+		running: make(map[uint64]*watchedExecution),
+		stop:    make(chan struct{}),
+	}
+}
+
+// threshold returns the stuck threshold for a runtime timeout
+func (w *Watchdog) threshold(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		timeout = w.config.DefaultTimeout
+	}
+	return time.Duration(float64(timeout) * w.config.Multiplier)
+}
+
+// track registers an execution and returns its id
+func (w *Watchdog) track(runtimeName, code string, timeout time.Duration, cancel context.CancelFunc) uint64 {
+	if len(code) > maxStuckCode {
+		code = code[:maxStuckCode] + "..."
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.nextID++
+	w.running[w.nextID] = &watchedExecution{
+		info: StuckExecution{
+			ID:      w.nextID,
+			Runtime: runtimeName,
+			Code:    code,
+			Started: time.Now(),
+		},
+		cancel:    cancel,
+		threshold: w.threshold(timeout),
+	}
+	return w.nextID
+}
+
+// done removes a finished execution
+func (w *Watchdog) done(id uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.running, id)
+}
+
+// run checks for stuck executions until stopped
+func (w *Watchdog) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			w.check(now)
+		}
+	}
+}
+
+// check reports each execution past its threshold once
+func (w *Watchdog) check(now time.Time) {
+	var stuck []StuckExecution
+
+	w.mu.Lock()
+	for _, exec := range w.running {
+		elapsed := now.Sub(exec.info.Started)
+		if exec.reported || elapsed < exec.threshold {
+			continue
+		}
+
+		exec.reported = true
+		exec.info.Elapsed = elapsed
+		exec.info.Threshold = exec.threshold
+		if w.config.ForceKill && exec.cancel != nil {
+			exec.cancel()
+			exec.info.Killed = true
+		}
+		stuck = append(stuck, exec.info)
+	}
+	w.mu.Unlock()
+
+	if len(stuck) == 0 {
+		return
+	}
+
+	stack := goroutineDump()
+	for _, info := range stuck {
+		info.Stack = stack
+		w.events.Publish(TopicExecutionStuck, info)
+	}
+}
+
+// close stops the watchdog
+func (w *Watchdog) close() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+// goroutineDump returns the stacks of all goroutines
+func goroutineDump() string {
+	buf := make([]byte, maxStuckStack)
+	n := runtime.Stack(buf, true)
+	return string(buf[:n])
+}
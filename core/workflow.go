@@ -0,0 +1,242 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// WorkflowState is the context shared by the steps of a workflow. Steps
+// running in parallel may read and write it concurrently.
+type WorkflowState struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+// NewWorkflowState creates a state holding a copy of values
+func NewWorkflowState(values map[string]interface{}) *WorkflowState {
+	state := &WorkflowState{values: make(map[string]interface{}, len(values))}
+	for key, value := range values {
+		state.values[key] = value
+	}
+	return state
+}
+
+// Get returns the value stored under key
+func (s *WorkflowState) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores value under key
+func (s *WorkflowState) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// Delete removes key
+func (s *WorkflowState) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// Values returns a copy of the state
+func (s *WorkflowState) Values() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]interface{}, len(s.values))
+	for key, value := range s.values {
+		values[key] = value
+	}
+	return values
+}
+
+// WorkflowFunc is the body of a workflow step
+type WorkflowFunc func(ctx context.Context, orch *Orchestrator, state *WorkflowState) error
+
+// WorkflowStep is a named unit of work in a workflow
+type WorkflowStep struct {
+	Name string
+	Run  WorkflowFunc
+}
+
+// ExecStep returns a step that executes code in runtime with the state
+// values named by inputs as arguments, and stores the result under output
+// unless output is empty
+func ExecStep(name, runtime, code string, inputs []string, output string) WorkflowStep {
+	return WorkflowStep{Name: name, Run: func(ctx context.Context, orch *Orchestrator, state *WorkflowState) error {
+		args := make([]interface{}, len(inputs))
+		for i, input := range inputs {
+			value, ok := state.Get(input)
+			if !ok {
+				return Errorf(CodeNotFound, "input %q is not set", input)
+			}
+			args[i] = value
+		}
+
+		result, err := orch.Execute(ctx, runtime, code, args...)
+		if err != nil {
+			return err
+		}
+		if output != "" {
+			state.Set(output, result)
+		}
+		return nil
+	}}
+}
+
+// Workflow threads shared state through a sequence of steps, typically
+// executions in different runtimes. Stages run in the order they are
+// added; the steps of a Parallel stage run concurrently and the next stage
+// starts once all of them finish.
+//
+//	out, err := core.NewWorkflow("report").
+//		Exec("load", "python", "load()", nil, "rows").
+//		Parallel(
+//			core.ExecStep("stats", "javascript", "stats", []string{"rows"}, "stats"),
+//			core.ExecStep("chart", "python", "chart", []string{"rows"}, "chart"),
+//		).
+//		Run(ctx, orch, nil)
+//
+// A Workflow is built once and may be run any number of times, including
+// concurrently.
+type Workflow struct {
+	name   string
+	stages [][]WorkflowStep
+}
+
+// NewWorkflow creates an empty workflow
+func NewWorkflow(name string) *Workflow {
+	return &Workflow{name: name}
+}
+
+// Name returns the workflow's name
+func (w *Workflow) Name() string {
+	return w.name
+}
+
+// Then appends a step that runs after the previous stage
+func (w *Workflow) Then(step WorkflowStep) *Workflow {
+	w.stages = append(w.stages, []WorkflowStep{step})
+	return w
+}
+
+// Step appends a step running fn
+func (w *Workflow) Step(name string, fn WorkflowFunc) *Workflow {
+	return w.Then(WorkflowStep{Name: name, Run: fn})
+}
+
+// Exec appends an ExecStep
+func (w *Workflow) Exec(name, runtime, code string, inputs []string, output string) *Workflow {
+	return w.Then(ExecStep(name, runtime, code, inputs, output))
+}
+
+// Parallel appends a stage running steps concurrently. When one fails,
+// the others' context is canceled.
+func (w *Workflow) Parallel(steps ...WorkflowStep) *Workflow {
+	if len(steps) > 0 {
+		w.stages = append(w.stages, steps)
+	}
+	return w
+}
+
+// Branch appends a step that runs then when cond holds for the state and
+// otherwise when it does not. Either workflow may be nil to do nothing.
+// Branches share the state of the workflow they belong to.
+func (w *Workflow) Branch(name string, cond func(state *WorkflowState) bool, then, otherwise *Workflow) *Workflow {
+	return w.Step(name, func(ctx context.Context, orch *Orchestrator, state *WorkflowState) error {
+		branch := otherwise
+		if cond(state) {
+			branch = then
+		}
+		if branch == nil {
+			return nil
+		}
+		return branch.run(ctx, orch, state)
+	})
+}
+
+// Run executes the workflow with a state holding initial and returns the
+// final state. When a step fails, the returned error is a *Error carrying
+// the step's name as the "step" detail, and the state is returned as the
+// failure left it.
+func (w *Workflow) Run(ctx context.Context, orch *Orchestrator, initial map[string]interface{}) (map[string]interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	state := NewWorkflowState(initial)
+	err := w.run(ctx, orch, state)
+	return state.Values(), err
+}
+
+// run executes the stages in order against state
+func (w *Workflow) run(ctx context.Context, orch *Orchestrator, state *WorkflowState) error {
+	for _, stage := range w.stages {
+		if err := ctx.Err(); err != nil {
+			return Errorf(ErrorInfoFor(err).Code, "workflow %s stopped: %w", w.name, err)
+		}
+
+		if len(stage) == 1 {
+			if err := w.runStep(ctx, orch, state, stage[0]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		stageCtx, cancel := context.WithCancel(ctx)
+		errs := make([]error, len(stage))
+		var wg sync.WaitGroup
+		for i, step := range stage {
+			wg.Add(1)
+			go func(i int, step WorkflowStep) {
+				defer wg.Done()
+				if errs[i] = w.runStep(stageCtx, orch, state, step); errs[i] != nil {
+					cancel()
+				}
+			}(i, step)
+		}
+		wg.Wait()
+		cancel()
+
+		// Report the step that failed first rather than siblings that
+		// failed because it canceled them
+		var canceled error
+		for _, err := range errs {
+			switch {
+			case err == nil:
+			case errors.Is(err, context.Canceled):
+				if canceled == nil {
+					canceled = err
+				}
+			default:
+				return err
+			}
+		}
+		if canceled != nil {
+			return canceled
+		}
+	}
+	return nil
+}
+
+// runStep runs one step, naming it in any error. Errors from steps of a
+// nested workflow already name their step and are returned as is.
+func (w *Workflow) runStep(ctx context.Context, orch *Orchestrator, state *WorkflowState, step WorkflowStep) error {
+	err := step.Run(ctx, orch, state)
+	if err == nil {
+		return nil
+	}
+
+	var typed *Error
+	if errors.As(err, &typed) {
+		if _, named := typed.Details["step"]; named {
+			return err
+		}
+	}
+	return Errorf(ErrorInfoFor(err).Code, "workflow %s: step %s failed: %w", w.name, step.Name, err).
+		WithDetail("step", step.Name)
+}
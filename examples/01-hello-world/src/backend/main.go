@@ -15,12 +15,12 @@ import (
 )
 
 func main() {
-	fmt.Println("🚀 Initializing Polyglot Hello World Example...")
-	fmt.Println()
-
-	// Create configuration
+	// Create configuration; POLYGLOT_QUIET sets config.Quiet
 	config := core.DefaultConfig()
 	config.App.Name = "hello-world"
+	config.Logger = core.DefaultLogger()
+
+	core.Banner(os.Stdout, config.Quiet, "🚀 Polyglot Hello World Example")
 
 	// Configure runtimes
 	config.Languages = map[string]*core.RuntimeConfig{
@@ -62,14 +62,22 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
 	defer cancel()
 
-	if err := orch.Initialize(ctx); err != nil {
+	report := orch.InitializeReport(ctx)
+	if err := report.Err(); err != nil {
 		fmt.Printf("⚠️  Initialization warning: %v\n", err)
+		if report.Degraded() {
+			fmt.Printf("   Continuing with: %v\n", report.Succeeded())
+		}
 		fmt.Println()
 		fmt.Println("Note: Runtimes are using stub implementations.")
 		fmt.Println("      This is normal when building without runtime tags.")
 		fmt.Println("      The example will demonstrate the architecture.")
 		fmt.Println()
 	} else {
+		// Runtimes may still be warming up after Initialize returns
+		if err := orch.WaitReady(ctx); err != nil {
+			log.Fatalf("❌ Runtimes did not become ready: %v", err)
+		}
 		fmt.Println("✓ System initialized with real runtimes")
 		fmt.Println()
 	}
@@ -237,11 +245,18 @@ func demonstrateMemorySharing(orch *core.Orchestrator) {
 
 	// Write data
 	testData := []byte("Hello from shared memory!")
-	copy(region.Data, testData)
+	if _, err := region.Write(0, testData); err != nil {
+		fmt.Printf("  ⚠️  Write failed: %v\n", err)
+		return
+	}
 	fmt.Printf("  ✓ Written data: %s\n", string(testData))
 
 	// Read data
-	readData := region.Data[:len(testData)]
+	readData, err := region.Read(0, len(testData))
+	if err != nil {
+		fmt.Printf("  ⚠️  Read failed: %v\n", err)
+		return
+	}
 	fmt.Printf("  ✓ Read data: %s\n", string(readData))
 
 	// Get region info
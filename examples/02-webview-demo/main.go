@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/rand"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/griffincancode/polyglot.js/core"
@@ -14,8 +15,10 @@ import (
 
 // DemoState holds application state
 type DemoState struct {
-	counter int
+	counter core.SafeCounter
 	todos   []Todo
+	ids     core.IDGenerator
+	mu      sync.Mutex
 }
 
 type Todo struct {
@@ -29,22 +32,21 @@ type Todo struct {
 func setupBridge() core.Bridge {
 	bridge := core.NewBridge()
 	state := &DemoState{
-		counter: 0,
 		todos: []Todo{
 			{ID: 1, Title: "Learn Polyglot", Completed: false, CreatedAt: time.Now()},
 			{ID: 2, Title: "Build awesome app", Completed: false, CreatedAt: time.Now()},
 		},
 	}
+	state.ids.Observe(2)
 
 	// Register increment function
 	bridge.Register("increment", func(ctx context.Context, args ...interface{}) (interface{}, error) {
-		state.counter++
-		return state.counter, nil
+		return state.counter.Increment(), nil
 	})
 
 	// Register getCounter function
 	bridge.Register("getCounter", func(ctx context.Context, args ...interface{}) (interface{}, error) {
-		return state.counter, nil
+		return state.counter.Get(), nil
 	})
 
 	// Register greet function
@@ -61,7 +63,9 @@ func setupBridge() core.Bridge {
 
 	// Register getTodos function
 	bridge.Register("getTodos", func(ctx context.Context, args ...interface{}) (interface{}, error) {
-		return state.todos, nil
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		return append([]Todo(nil), state.todos...), nil
 	})
 
 	// Register addTodo function
@@ -73,8 +77,10 @@ func setupBridge() core.Bridge {
 		if !ok {
 			return nil, fmt.Errorf("title must be a string")
 		}
+		state.mu.Lock()
+		defer state.mu.Unlock()
 		todo := Todo{
-			ID:        len(state.todos) + 1,
+			ID:        int(state.ids.Next()),
 			Title:     title,
 			Completed: false,
 			CreatedAt: time.Now(),
@@ -98,6 +104,8 @@ func setupBridge() core.Bridge {
 			return nil, fmt.Errorf("id must be a number")
 		}
 
+		state.mu.Lock()
+		defer state.mu.Unlock()
 		for i := range state.todos {
 			if state.todos[i].ID == id {
 				state.todos[i].Completed = !state.todos[i].Completed
@@ -122,6 +130,8 @@ func setupBridge() core.Bridge {
 			return nil, fmt.Errorf("id must be a number")
 		}
 
+		state.mu.Lock()
+		defer state.mu.Unlock()
 		for i := range state.todos {
 			if state.todos[i].ID == id {
 				state.todos = append(state.todos[:i], state.todos[i+1:]...)
@@ -648,5 +658,5 @@ func generateDemoHTML() string {
 </body>
 </html>
 `
-	return "data:text/html," + html
+	return webview.DataURL("text/html", []byte(html))
 }
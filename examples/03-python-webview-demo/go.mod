@@ -5,3 +5,8 @@ go 1.21
 replace github.com/griffincancode/polyglot.js => ../..
 
 require github.com/griffincancode/polyglot.js v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/fsnotify/fsnotify v1.7.0 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+)
@@ -15,8 +15,10 @@ import (
 // AppState holds application state
 type AppState struct {
 	pythonRuntime *pythonRuntime.Runtime
+	webview       *webview.Webview
 	counter       int
 	tasks         []Task
+	taskIDs       core.IDGenerator
 }
 
 type Task struct {
@@ -32,6 +34,9 @@ type Task struct {
 var (
 	appState  *AppState
 	startTime = time.Now()
+
+	taskStatuses   = core.NewEnum("status", "pending", "in-progress", "completed")
+	taskPriorities = core.NewEnum("priority", "low", "medium", "high")
 )
 
 func main() {
@@ -62,10 +67,12 @@ func main() {
 			},
 		},
 	}
+	appState.taskIDs.Observe(2)
 
 	// Initialize Python runtime
-	if err := initializePython(); err != nil {
-		log.Printf("Warning: Python runtime initialization failed: %v", err)
+	pythonErr := initializePython()
+	if pythonErr != nil {
+		log.Printf("Warning: Python runtime initialization failed: %v", pythonErr)
 		log.Println("Continuing without Python support...")
 	} else {
 		defer shutdownPython()
@@ -83,6 +90,7 @@ func main() {
 		Resizable: true,
 		Debug:     true,
 		URL:       generateDemoHTML(),
+		Numbers:   string(core.NumbersInteger), // integer arguments arrive as int64
 	}
 
 	// Create and initialize webview
@@ -95,21 +103,34 @@ func main() {
 		log.Fatalf("Failed to initialize webview: %v", err)
 	}
 	defer wv.Terminate()
+	appState.webview = wv
 
-	// Log startup
-	log.Println("╔════════════════════════════════════════════════════════════════╗")
-	log.Println("║  Polyglot Python + JS + Webview Demo                          ║")
-	log.Println("╚════════════════════════════════════════════════════════════════╝")
-	log.Println("Features:")
-	log.Println("  • Python runtime for backend calculations")
-	log.Println("  • JavaScript for interactive UI")
-	log.Println("  • Go for system operations and state management")
-	log.Println("  • Real-time data processing")
-	log.Println("")
-	log.Println("Controls:")
-	log.Println("  • Press F12 for developer tools")
-	log.Println("  • Close window to exit")
-	log.Println("")
+	// Tell the page whether Python works, so it can disable its demos
+	err := wv.ReportHealth(pythonHealth(pythonErr), webview.HealthOptions{
+		Notices: map[string]string{"python": "Python unavailable; calculator and Python demos disabled."},
+		Banner:  true,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to report runtime health: %v", err)
+	}
+
+	// Log startup; POLYGLOT_QUIET leaves just the one line
+	log.Println("Polyglot Python + JS + Webview Demo started")
+	if !core.QuietFromEnv() {
+		log.Println("╔════════════════════════════════════════════════════════════════╗")
+		log.Println("║  Polyglot Python + JS + Webview Demo                          ║")
+		log.Println("╚════════════════════════════════════════════════════════════════╝")
+		log.Println("Features:")
+		log.Println("  • Python runtime for backend calculations")
+		log.Println("  • JavaScript for interactive UI")
+		log.Println("  • Go for system operations and state management")
+		log.Println("  • Real-time data processing")
+		log.Println("")
+		log.Println("Controls:")
+		log.Println("  • Press F12 for developer tools")
+		log.Println("  • Close window to exit")
+		log.Println("")
+	}
 
 	// Run the webview (blocks until window is closed)
 	if err := wv.Run(); err != nil {
@@ -122,18 +143,55 @@ func main() {
 // initializePython initializes the Python runtime
 func initializePython() error {
 	appState.pythonRuntime = pythonRuntime.NewRuntime()
+	// Modules the demos use are imported once per worker
+	appState.pythonRuntime.Preimport([]string{"math", "statistics"})
 
 	config := core.RuntimeConfig{
 		Name:           "python",
 		Enabled:        true,
 		MaxConcurrency: 4,
 		Timeout:        30 * time.Second,
+		// The calculator runs user input; keep calls from seeing each
+		// other's variables
+		ResetBetweenCalls: true,
+		// Helpers are defined once per worker and survive the resets
+		InitCode: pythonHelpers,
 	}
 
 	ctx := context.Background()
 	return appState.pythonRuntime.Initialize(ctx, config)
 }
 
+// pythonHealth reports the outcome of initializePython
+func pythonHealth(err error) map[string]core.RuntimeHealth {
+	health := core.RuntimeHealth{
+		Runtime:   "python",
+		Stubbed:   core.IsStub(appState.pythonRuntime),
+		SelfTest:  core.SelfTestNotRun,
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		health.Error = err.Error()
+	} else {
+		health.Version = appState.pythonRuntime.Version()
+		health.Initialized = true
+		health.Ready = true
+	}
+	return map[string]core.RuntimeHealth{"python": health}
+}
+
+// pythonHelpers defines the functions the demos call
+const pythonHelpers = `
+def fibonacci(n):
+    if n <= 1:
+        return n
+    a, b = 0, 1
+    for i in range(2, n + 1):
+        a, b = b, a + b
+        print(f"F({i}) = {b}")
+    return b
+`
+
 // shutdownPython cleans up Python runtime
 func shutdownPython() {
 	if appState.pythonRuntime != nil {
@@ -149,18 +207,27 @@ func setupBridge() core.Bridge {
 	// Python calculation demos
 	bridge.Register("pythonCalculate", pythonCalculate)
 	bridge.Register("pythonFibonacci", pythonFibonacci)
-	bridge.Register("pythonStatistics", pythonStatistics)
-	bridge.Register("pythonTextAnalysis", pythonTextAnalysis)
-	bridge.Register("pythonDataTransform", pythonDataTransform)
 	bridge.Register("pythonMathOperations", pythonMathOperations)
-	bridge.Register("pythonListProcessing", pythonListProcessing)
 
-	// Task management functions
-	bridge.Register("getTasks", getTasks)
-	bridge.Register("addTask", addTask)
-	bridge.Register("updateTask", updateTask)
-	bridge.Register("deleteTask", deleteTask)
-	bridge.Register("filterTasks", filterTasks)
+	// Structured results carry display metadata for the result viewer
+	bridge.Register("pythonStatistics", core.WithDisplay(pythonStatistics))
+	bridge.Register("pythonTextAnalysis", core.WithDisplay(pythonTextAnalysis))
+	bridge.Register("pythonDataTransform", core.WithDisplay(pythonDataTransform))
+
+	// Long lists show their first entries; the page fetches the rest
+	// only when asked
+	results := core.NewResultCache(0)
+	bridge.Register("fullResult", results.Fetch)
+	bridge.Register("pythonListProcessing", core.WithDisplayOptions(pythonListProcessing, core.DisplayOptions{Results: results}))
+
+	// Task management functions share the task list, so calls to them
+	// run one at a time
+	tasks := core.SerializedGroup("tasks")
+	bridge.RegisterWithIsolation("getTasks", tasks, getTasks)
+	bridge.RegisterWithIsolation("addTask", tasks, addTask)
+	bridge.RegisterWithIsolation("updateTask", tasks, updateTask)
+	bridge.RegisterWithIsolation("deleteTask", tasks, deleteTask)
+	bridge.RegisterWithIsolation("filterTasks", tasks, filterTasks)
 
 	// System info functions
 	bridge.Register("getSystemInfo", getSystemInfo)
@@ -168,8 +235,9 @@ func setupBridge() core.Bridge {
 	bridge.Register("getUptime", getUptime)
 
 	// Counter demo
-	bridge.Register("increment", increment)
-	bridge.Register("getCounter", getCounter)
+	counter := core.SerializedGroup("counter")
+	bridge.RegisterWithIsolation("increment", counter, increment)
+	bridge.RegisterWithIsolation("getCounter", counter, getCounter)
 
 	return bridge
 }
@@ -190,6 +258,11 @@ func pythonCalculate(ctx context.Context, args ...interface{}) (interface{}, err
 		return nil, fmt.Errorf("expression must be a string")
 	}
 
+	// User input is untrusted - screen it before it reaches the interpreter
+	if err := core.DefaultInputPolicy().Check("python", expr); err != nil {
+		return nil, err
+	}
+
 	result, err := appState.pythonRuntime.Execute(ctx, expr)
 	if err != nil {
 		return nil, fmt.Errorf("calculation failed: %w", err)
@@ -207,29 +280,17 @@ func pythonFibonacci(ctx context.Context, args ...interface{}) (interface{}, err
 		return nil, fmt.Errorf("requires 1 argument (n)")
 	}
 
-	var n int
-	switch v := args[0].(type) {
-	case float64:
-		n = int(v)
-	case int:
-		n = v
-	default:
-		return nil, fmt.Errorf("n must be a number")
+	n64, ok := args[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("n must be an integer")
 	}
+	n := int(n64)
 
-	code := fmt.Sprintf(`
-def fibonacci(n):
-    if n <= 1:
-        return n
-    a, b = 0, 1
-    for _ in range(n - 1):
-        a, b = b, a + b
-    return b
-
-fibonacci(%d)
-`, n)
+	// Stream the printed progress to the page as runtimeLog events
+	ctx, stop := appState.webview.StreamLogs(ctx, "python")
+	defer stop()
 
-	result, err := appState.pythonRuntime.Execute(ctx, code)
+	result, err := appState.pythonRuntime.Execute(ctx, fmt.Sprintf("fibonacci(%d)", n))
 	if err != nil {
 		return nil, fmt.Errorf("fibonacci calculation failed: %w", err)
 	}
@@ -262,8 +323,6 @@ func pythonStatistics(ctx context.Context, args ...interface{}) (interface{}, er
 	numStr += "]"
 
 	code := fmt.Sprintf(`
-import statistics
-
 data = %s
 
 {
@@ -393,7 +452,7 @@ func pythonMathOperations(ctx context.Context, args ...interface{}) (interface{}
 	switch v := args[0].(type) {
 	case float64:
 		a = v
-	case int:
+	case int64:
 		a = float64(v)
 	default:
 		return nil, fmt.Errorf("a must be a number")
@@ -402,7 +461,7 @@ func pythonMathOperations(ctx context.Context, args ...interface{}) (interface{}
 	switch v := args[1].(type) {
 	case float64:
 		b = v
-	case int:
+	case int64:
 		b = float64(v)
 	default:
 		return nil, fmt.Errorf("b must be a number")
@@ -414,8 +473,6 @@ func pythonMathOperations(ctx context.Context, args ...interface{}) (interface{}
 	}
 
 	code := fmt.Sprintf(`
-import math
-
 a, b = %f, %f
 `, a, b)
 
@@ -459,44 +516,52 @@ func pythonListProcessing(ctx context.Context, args ...interface{}) (interface{}
 		return nil, fmt.Errorf("requires 1 argument (size)")
 	}
 
-	var size int
-	switch v := args[0].(type) {
-	case float64:
-		size = int(v)
-	case int:
-		size = v
-	default:
-		return nil, fmt.Errorf("size must be a number")
+	size64, ok := args[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("size must be an integer")
 	}
+	size := int(size64)
 
 	code := fmt.Sprintf(`
-# Generate list comprehensions and demonstrate Python's data processing
-size = %d
+# Generate list comprehensions and demonstrate Python's data processing,
+# reporting each step to the progress bar
+import polyglot
 
-result = {
-    'range': list(range(size)),
-    'squares': [x*x for x in range(size)],
-    'evens': [x for x in range(size) if x %% 2 == 0],
-    'odds': [x for x in range(size) if x %% 2 != 0],
-    'sum_squares': sum(x*x for x in range(size)),
-    'fibonacci': [
-        (lambda n: 
-            n if n <= 1 else 
-            sum((
-                (lambda f, i: f(f, i))(
-                    lambda f, i: i if i <= 1 else f(f, i-1) + f(f, i-2),
-                    n-j
-                )
-                for j in range(2)
-            ))
-        )(i) 
-        for i in range(min(size, 15))
-    ][:size]
-}
+size = %d
+result = {}
+
+polyglot.progress(0.0, 'range')
+result['range'] = list(range(size))
+polyglot.progress(0.2, 'squares')
+result['squares'] = [x*x for x in range(size)]
+polyglot.progress(0.4, 'evens and odds')
+result['evens'] = [x for x in range(size) if x %% 2 == 0]
+result['odds'] = [x for x in range(size) if x %% 2 != 0]
+polyglot.progress(0.6, 'sum of squares')
+result['sum_squares'] = sum(x*x for x in range(size))
+polyglot.progress(0.8, 'fibonacci')
+result['fibonacci'] = [
+    (lambda n: 
+        n if n <= 1 else 
+        sum((
+            (lambda f, i: f(f, i))(
+                lambda f, i: i if i <= 1 else f(f, i-1) + f(f, i-2),
+                n-j
+            )
+            for j in range(2)
+        ))
+    )(i) 
+    for i in range(min(size, 15))
+][:size]
+polyglot.progress(1.0, 'done')
 
 result
 `, size)
 
+	// Send the progress reports to the page as runtimeProgress events
+	ctx, stop := appState.webview.StreamProgress(ctx)
+	defer stop()
+
 	result, err := appState.pythonRuntime.Execute(ctx, code)
 	if err != nil {
 		return nil, fmt.Errorf("list processing failed: %w", err)
@@ -508,7 +573,8 @@ result
 // Task management functions
 
 func getTasks(ctx context.Context, args ...interface{}) (interface{}, error) {
-	return appState.tasks, nil
+	// Copy the list, since it is serialized after the tasks group is released
+	return append([]Task(nil), appState.tasks...), nil
 }
 
 func addTask(ctx context.Context, args ...interface{}) (interface{}, error) {
@@ -528,9 +594,11 @@ func addTask(ctx context.Context, args ...interface{}) (interface{}, error) {
 
 	priority := "medium"
 	if len(args) > 2 {
-		if p, ok := args[2].(string); ok {
-			priority = p
+		p, err := taskPriorities.Parse(args[2])
+		if err != nil {
+			return nil, err
 		}
+		priority = p
 	}
 
 	dueDate := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
@@ -541,7 +609,7 @@ func addTask(ctx context.Context, args ...interface{}) (interface{}, error) {
 	}
 
 	task := Task{
-		ID:          len(appState.tasks) + 1,
+		ID:          int(appState.taskIDs.Next()),
 		Title:       title,
 		Description: description,
 		Priority:    priority,
@@ -559,15 +627,11 @@ func updateTask(ctx context.Context, args ...interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("requires at least 2 arguments (id, field, value)")
 	}
 
-	var id int
-	switch v := args[0].(type) {
-	case float64:
-		id = int(v)
-	case int:
-		id = v
-	default:
-		return nil, fmt.Errorf("id must be a number")
+	id64, ok := args[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("id must be an integer")
 	}
+	id := int(id64)
 
 	for i := range appState.tasks {
 		if appState.tasks[i].ID == id {
@@ -577,13 +641,17 @@ func updateTask(ctx context.Context, args ...interface{}) (interface{}, error) {
 					value := args[2]
 					switch field {
 					case "status":
-						if v, ok := value.(string); ok {
-							appState.tasks[i].Status = v
+						v, err := taskStatuses.Parse(value)
+						if err != nil {
+							return nil, err
 						}
+						appState.tasks[i].Status = v
 					case "priority":
-						if v, ok := value.(string); ok {
-							appState.tasks[i].Priority = v
+						v, err := taskPriorities.Parse(value)
+						if err != nil {
+							return nil, err
 						}
+						appState.tasks[i].Priority = v
 					case "title":
 						if v, ok := value.(string); ok {
 							appState.tasks[i].Title = v
@@ -607,15 +675,11 @@ func deleteTask(ctx context.Context, args ...interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("requires 1 argument (id)")
 	}
 
-	var id int
-	switch v := args[0].(type) {
-	case float64:
-		id = int(v)
-	case int:
-		id = v
-	default:
-		return nil, fmt.Errorf("id must be a number")
+	id64, ok := args[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("id must be an integer")
 	}
+	id := int(id64)
 
 	for i := range appState.tasks {
 		if appState.tasks[i].ID == id {
@@ -704,7 +768,7 @@ func getCounter(ctx context.Context, args ...interface{}) (interface{}, error) {
 
 // generateDemoHTML creates the embedded HTML page
 func generateDemoHTML() string {
-	return "data:text/html," + `<!DOCTYPE html>
+	html := `<!DOCTYPE html>
 <html>
 <head>
     <meta charset="UTF-8">
@@ -859,6 +923,20 @@ func generateDemoHTML() string {
             color: #721c24;
         }
 
+        .result-box details > details,
+        .result-box details > div {
+            margin-left: 16px;
+        }
+
+        .result-box summary {
+            cursor: pointer;
+        }
+
+        .result-box .display-number,
+        .result-box .display-boolean {
+            color: #0b5394;
+        }
+
         .stats-grid {
             display: grid;
             grid-template-columns: repeat(auto-fit, minmax(150px, 1fr));
@@ -1054,7 +1132,7 @@ func generateDemoHTML() string {
 
         <div class="demo-grid">
             <!-- Python Calculator -->
-            <div class="demo-section">
+            <div class="demo-section" data-runtime="python">
                 <h2>Python Calculator</h2>
                 <p class="description">Execute Python expressions directly from JavaScript</p>
                 <input type="text" id="calcInput" placeholder="e.g., 2**10, math.sqrt(144)" style="width: 100%;">
@@ -1065,16 +1143,17 @@ func generateDemoHTML() string {
             </div>
 
             <!-- Fibonacci -->
-            <div class="demo-section">
+            <div class="demo-section" data-runtime="python">
                 <h2>Fibonacci Generator</h2>
                 <p class="description">Generate Fibonacci numbers using Python</p>
                 <input type="number" id="fibInput" value="10" min="1" max="50" style="width: 150px;">
                 <button onclick="fibonacci()">Generate</button>
                 <div id="fibResult" class="result-box" style="display:none;"></div>
+                <div id="fibLog" class="result-box" style="display:none;"></div>
             </div>
 
             <!-- Statistics -->
-            <div class="demo-section">
+            <div class="demo-section" data-runtime="python">
                 <h2>Statistical Analysis</h2>
                 <p class="description">Analyze number arrays with Python's statistics module</p>
                 <input type="text" id="statsInput" placeholder="e.g., 1,2,3,4,5" style="width: 100%;" value="12,15,18,20,22,25,28,30">
@@ -1084,7 +1163,7 @@ func generateDemoHTML() string {
             </div>
 
             <!-- Text Analysis -->
-            <div class="demo-section">
+            <div class="demo-section" data-runtime="python">
                 <h2>Text Analysis</h2>
                 <p class="description">Analyze text using Python string processing</p>
                 <textarea id="textInput" placeholder="Enter text to analyze...">The quick brown fox jumps over the lazy dog. This sentence contains every letter of the alphabet.</textarea>
@@ -1093,7 +1172,7 @@ func generateDemoHTML() string {
             </div>
 
             <!-- Data Transformation -->
-            <div class="demo-section">
+            <div class="demo-section" data-runtime="python">
                 <h2>Data Transformation</h2>
                 <p class="description">Transform arrays using Python list comprehensions</p>
                 <input type="text" id="transformInput" placeholder="e.g., 1,2,3,4,5" style="width: 200px;" value="1,2,3,4,5,6,7,8,9,10">
@@ -1108,11 +1187,12 @@ func generateDemoHTML() string {
             </div>
 
             <!-- List Processing -->
-            <div class="demo-section">
+            <div class="demo-section" data-runtime="python">
                 <h2>List Processing</h2>
                 <p class="description">Demonstrate Python's powerful list comprehensions</p>
                 <input type="number" id="listSize" value="10" min="1" max="20" style="width: 150px;">
                 <button onclick="processList()">Process</button>
+                <progress id="listProgress" max="1" value="0" style="display:none; width: 100%;"></progress>
                 <div id="listResult" class="result-box" style="display:none;"></div>
             </div>
 
@@ -1184,17 +1264,99 @@ func generateDemoHTML() string {
         window.addEventListener('DOMContentLoaded', async () => {
             console.log('Polyglot Python + JS + Webview Demo initialized');
             await loadTasks();
+            if (disableUnavailable()) return;
             showMessage('Application ready! Try the Python features above.', 'success', 'calcResult');
         });
 
+        // Disable the demos of runtimes the backend reports unavailable
+        function disableUnavailable() {
+            const health = window.polyglot.health;
+            if (!health) return false;
+            let disabled = false;
+            health.runtimes.forEach((status) => {
+                if (!status.notice) return;
+                document.querySelectorAll(` + "`[data-runtime=\"${status.runtime}\"]`" + `).forEach((section) => {
+                    section.querySelectorAll('button, input, textarea, select').forEach((el) => el.disabled = true);
+                    section.title = status.notice;
+                });
+                disabled = true;
+            });
+            return disabled;
+        }
+        window.polyglot.on('runtimeHealth', disableUnavailable);
+
+        // Python output streamed while it runs
+        window.polyglot.on('runtimeLog', (entry) => {
+            const log = document.getElementById('fibLog');
+            log.style.display = 'block';
+            if (entry.dropped) {
+                log.textContent += ` + "`... ${entry.dropped} lines skipped\n`" + `;
+            }
+            if (entry.stream) {
+                log.textContent += entry.line + '\n';
+            }
+        });
+
+        // Python progress reported while it runs
+        window.polyglot.on('runtimeProgress', (report) => {
+            const bar = document.getElementById('listProgress');
+            bar.style.display = 'block';
+            bar.value = report.fraction;
+            bar.title = report.message || '';
+        });
+
         // Utility functions
         function showResult(elementId, content, isError = false) {
             const element = document.getElementById(elementId);
             element.style.display = 'block';
             element.className = 'result-box ' + (isError ? 'error' : 'success');
+            if (content && content.display) {
+                element.textContent = '';
+                element.appendChild(renderDisplay(content.display));
+                if (content.ref) {
+                    element.appendChild(showAllButton(element, content.ref));
+                }
+                return;
+            }
             element.textContent = typeof content === 'object' ? JSON.stringify(content, null, 2) : content;
         }
 
+        // Render a result's display metadata as a collapsible tree
+        function renderDisplay(display, key) {
+            const label = key === undefined ? '' : key + ': ';
+            const more = display.truncated ? ' …' : '';
+            if (!display.entries || display.entries.length === 0) {
+                const line = document.createElement('div');
+                line.className = 'display-' + display.type;
+                const text = display.type === 'string' && key !== undefined ? JSON.stringify(display.text) : display.text;
+                line.textContent = label + text + more;
+                return line;
+            }
+
+            const node = document.createElement('details');
+            node.open = true;
+            const summary = document.createElement('summary');
+            summary.textContent = label + display.text + more;
+            node.appendChild(summary);
+            display.entries.forEach((entry) => node.appendChild(renderDisplay(entry.value, entry.key)));
+            return node;
+        }
+
+        // Load the full value of a truncated result on request
+        function showAllButton(element, ref) {
+            const button = document.createElement('button');
+            button.textContent = 'Show all';
+            button.onclick = async () => {
+                try {
+                    const value = await window.polyglot.call('fullResult', ref);
+                    element.textContent = JSON.stringify(value, null, 2);
+                } catch (error) {
+                    button.replaceWith('Error: ' + error.message);
+                }
+            };
+            return button;
+        }
+
         function showMessage(text, type, elementId) {
             showResult(elementId, text, type === 'error');
         }
@@ -1215,7 +1377,7 @@ func generateDemoHTML() string {
                 const result = await window.polyglot.call('pythonCalculate', expr);
                 showResult('calcResult', 'Result: ' + result);
             } catch (error) {
-                showMessage('Error: ' + error, 'error', 'calcResult');
+                showMessage('Error: ' + error.message, 'error', 'calcResult');
             }
         }
 
@@ -1226,11 +1388,12 @@ func generateDemoHTML() string {
                 return;
             }
 
+            document.getElementById('fibLog').textContent = '';
             try {
                 const result = await window.polyglot.call('pythonFibonacci', n);
                 showResult('fibResult', ` + "`Fibonacci(${n}) = ${result}`" + `);
             } catch (error) {
-                showMessage('Error: ' + error, 'error', 'fibResult');
+                showMessage('Error: ' + error.message, 'error', 'fibResult');
             }
         }
 
@@ -1247,7 +1410,7 @@ func generateDemoHTML() string {
                 const stats = await window.polyglot.call('pythonStatistics', numbers);
                 showResult('statsResult', stats);
             } catch (error) {
-                showMessage('Error: ' + error, 'error', 'statsResult');
+                showMessage('Error: ' + error.message, 'error', 'statsResult');
             }
         }
 
@@ -1268,7 +1431,7 @@ func generateDemoHTML() string {
                 const analysis = await window.polyglot.call('pythonTextAnalysis', text);
                 showResult('textResult', analysis);
             } catch (error) {
-                showMessage('Error: ' + error, 'error', 'textResult');
+                showMessage('Error: ' + error.message, 'error', 'textResult');
             }
         }
 
@@ -1284,9 +1447,9 @@ func generateDemoHTML() string {
 
             try {
                 const result = await window.polyglot.call('pythonDataTransform', numbers, operation);
-                showResult('transformResult', ` + "`Operation: ${operation}\\nResult: ${JSON.stringify(result)}`" + `);
+                showResult('transformResult', result);
             } catch (error) {
-                showMessage('Error: ' + error, 'error', 'transformResult');
+                showMessage('Error: ' + error.message, 'error', 'transformResult');
             }
         }
 
@@ -1301,7 +1464,7 @@ func generateDemoHTML() string {
                 const result = await window.polyglot.call('pythonListProcessing', size);
                 showResult('listResult', result);
             } catch (error) {
-                showMessage('Error: ' + error, 'error', 'listResult');
+                showMessage('Error: ' + error.message, 'error', 'listResult');
             }
         }
 
@@ -1478,4 +1641,5 @@ func generateDemoHTML() string {
     </script>
 </body>
 </html>`
+	return webview.DataURL("text/html", []byte(html))
 }
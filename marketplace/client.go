@@ -13,6 +13,10 @@ type DefaultClient struct {
 	validator Validator
 	mu        sync.RWMutex
 	installed map[string]*Package
+
+	// installDir receives the files of packages installed with
+	// InstallStream
+	installDir string
 }
 
 // NewClient creates a new marketplace client
@@ -40,6 +44,15 @@ func (c *DefaultClient) Validator() Validator {
 	return c.validator
 }
 
+// SetInstallDir sets the directory InstallStream unpacks packages into,
+// each under <dir>/<id>/<version>. Without one, streamed installs only
+// download and verify packages into the cache.
+func (c *DefaultClient) SetInstallDir(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.installDir = dir
+}
+
 // Install installs a package
 func (c *DefaultClient) Install(ctx context.Context, id, version string) error {
 	// Check if already installed
@@ -60,7 +73,7 @@ func (c *DefaultClient) Install(ctx context.Context, id, version string) error {
 		}
 
 		// Download package data (simplified - would fetch actual binary)
-		data = []byte(fmt.Sprintf("package-%s-%s", id, version))
+		data = placeholderData(id, version)
 
 		// Validate before installing
 		if err := c.validator.ValidatePackage(ctx, pkg, data); err != nil {
@@ -157,11 +170,9 @@ func (c *DefaultClient) InitFromTemplate(ctx context.Context, tmplID, targetDir
 		return fmt.Errorf("validate template: %w", err)
 	}
 
-	// Template expansion would happen here
-	// For now, just verify the template is valid
 	if len(tmpl.Files) == 0 {
 		return fmt.Errorf("template has no files")
 	}
 
-	return nil
+	return writeTemplate(tmpl, targetDir, vars)
 }
@@ -0,0 +1,279 @@
+package marketplace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// maxResponseSize bounds registry responses
+const maxResponseSize = 32 << 20
+
+// HTTPConfig configures a registry served over HTTP
+type HTTPConfig struct {
+	// BaseURL of the registry API, e.g. https://registry.example.com/v1
+	BaseURL string
+
+	// Client performs requests (default http.DefaultClient)
+	Client *http.Client
+
+	// Timeout bounds each attempt; zero leaves attempts unbounded
+	Timeout time.Duration
+
+	// Retry retries attempts that fail with UNAVAILABLE or TIMEOUT, unless
+	// RetryOn says otherwise. Registry operations are idempotent, so
+	// timeouts are retried too. The zero value makes a single attempt.
+	Retry core.RetryPolicy
+
+	// Deadline bounds an operation including its retries; zero means no
+	// deadline beyond the caller's context
+	Deadline time.Duration
+}
+
+// HTTPRegistry implements Registry against a REST API. Operations fail
+// with a *core.Error coded NOT_FOUND, UNAVAILABLE or TIMEOUT so callers
+// can tell a missing package from a flaky registry.
+type HTTPRegistry struct {
+	config HTTPConfig
+	base   *url.URL
+	client *http.Client
+}
+
+// NewHTTPRegistry creates a registry client for the API at config.BaseURL
+func NewHTTPRegistry(config HTTPConfig) (*HTTPRegistry, error) {
+	base, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid registry URL: %q", config.BaseURL)
+	}
+
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if len(config.Retry.RetryOn) == 0 {
+		config.Retry.RetryOn = []core.ErrorCode{core.CodeUnavailable, core.CodeTimeout}
+	}
+	return &HTTPRegistry{config: config, base: base, client: client}, nil
+}
+
+// Search searches for packages and templates
+func (r *HTTPRegistry) Search(ctx context.Context, query SearchQuery) (*SearchResult, error) {
+	params := url.Values{}
+	if query.Query != "" {
+		params.Set("q", query.Query)
+	}
+	if query.Author != "" {
+		params.Set("author", query.Author)
+	}
+	for _, lang := range query.Languages {
+		params.Add("language", lang)
+	}
+	for _, tag := range query.Tags {
+		params.Add("tag", tag)
+	}
+	if query.SortBy != "" {
+		params.Set("sort_by", query.SortBy)
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Offset > 0 {
+		params.Set("offset", strconv.Itoa(query.Offset))
+	}
+
+	var result SearchResult
+	if err := r.do(ctx, http.MethodGet, "search?"+params.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetPackage retrieves a specific package
+func (r *HTTPRegistry) GetPackage(ctx context.Context, id, version string) (*Package, error) {
+	var pkg Package
+	if err := r.do(ctx, http.MethodGet, packagePath(id, version), nil, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// GetTemplate retrieves a specific template
+func (r *HTTPRegistry) GetTemplate(ctx context.Context, id string) (*Template, error) {
+	var tmpl Template
+	if err := r.do(ctx, http.MethodGet, "templates/"+url.PathEscape(id), nil, &tmpl); err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// Publish publishes a new package
+func (r *HTTPRegistry) Publish(ctx context.Context, pkg *Package, data []byte) error {
+	body := struct {
+		Package *Package `json:"package"`
+		Data    []byte   `json:"data"`
+	}{pkg, data}
+	return r.do(ctx, http.MethodPut, packagePath(pkg.ID, pkg.Version), body, nil)
+}
+
+// Download opens the data of a package, served at
+// packages/<id>/<version>/data. It is a single attempt bounded only by
+// ctx, since a large package can outlast the per-attempt timeout.
+func (r *HTTPRegistry) Download(ctx context.Context, id, version string) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base.String()+"/"+packagePath(id, version)+"/data", nil)
+	if err != nil {
+		return nil, 0, core.Errorf(core.CodeInvalidArgument, "invalid request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, contextError(ctx, req.Method, req.URL.Path)
+		}
+		return nil, 0, core.Errorf(core.CodeUnavailable, "%s %s: %w", req.Method, req.URL.Path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, 0, statusError(req.Method, req.URL.Path, resp)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// PublishTemplate publishes a new template
+func (r *HTTPRegistry) PublishTemplate(ctx context.Context, tmpl *Template) error {
+	return r.do(ctx, http.MethodPut, "templates/"+url.PathEscape(tmpl.ID), tmpl, nil)
+}
+
+// UpdatePackage updates an existing package
+func (r *HTTPRegistry) UpdatePackage(ctx context.Context, pkg *Package) error {
+	return r.do(ctx, http.MethodPut, packagePath(pkg.ID, pkg.Version)+"/metadata", pkg, nil)
+}
+
+// DeletePackage removes a package
+func (r *HTTPRegistry) DeletePackage(ctx context.Context, id, version string) error {
+	return r.do(ctx, http.MethodDelete, packagePath(id, version), nil, nil)
+}
+
+func packagePath(id, version string) string {
+	return "packages/" + url.PathEscape(id) + "/" + url.PathEscape(version)
+}
+
+// do sends a request, retrying it per the retry policy, and decodes the
+// response into out when it is not nil. Every operation is idempotent, so
+// all of them are retried.
+func (r *HTTPRegistry) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return core.Errorf(core.CodeInvalidArgument, "encode request: %w", err)
+		}
+	}
+
+	if r.config.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Deadline)
+		defer cancel()
+	}
+
+	target := r.base.String() + "/" + path
+	err := r.config.Retry.Do(ctx, func() error {
+		return r.attempt(ctx, method, target, payload, out)
+	})
+	if err != nil && ctx.Err() != nil && !errors.As(err, new(*core.Error)) {
+		return contextError(ctx, method, path)
+	}
+	return err
+}
+
+// attempt sends a request once, bounded by the per-attempt timeout
+func (r *HTTPRegistry) attempt(ctx context.Context, method, target string, payload []byte, out interface{}) error {
+	attemptCtx := ctx
+	if r.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(attemptCtx, method, target, reader)
+	if err != nil {
+		return core.Errorf(core.CodeInvalidArgument, "invalid request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if attemptCtx.Err() != nil {
+			return contextError(attemptCtx, method, req.URL.Path)
+		}
+		return core.Errorf(core.CodeUnavailable, "%s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return statusError(method, req.URL.Path, resp)
+	}
+	if out == nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		if attemptCtx.Err() != nil {
+			return contextError(attemptCtx, method, req.URL.Path)
+		}
+		return core.Errorf(core.CodeUnavailable, "%s %s: read response: %w", method, req.URL.Path, err)
+	}
+	if len(data) > maxResponseSize {
+		return core.Errorf(core.CodeTooLarge, "%s %s: response exceeds %d bytes", method, req.URL.Path, maxResponseSize)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return core.Errorf(core.CodeInternal, "%s %s: decode response: %w", method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// contextError reports why ctx ended: its deadline passing is TIMEOUT,
+// anything else is CANCELED
+func contextError(ctx context.Context, method, path string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return core.Errorf(core.CodeTimeout, "%s %s: %w", method, path, ctx.Err())
+	}
+	return core.Errorf(core.CodeCanceled, "%s %s: %w", method, path, ctx.Err())
+}
+
+// statusError maps a failed response to a typed error
+func statusError(method, path string, resp *http.Response) error {
+	code := core.CodeInternal
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		code = core.CodeNotFound
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusGatewayTimeout:
+		code = core.CodeTimeout
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		code = core.CodeUnavailable
+	case resp.StatusCode == http.StatusUnauthorized:
+		code = core.CodeUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		code = core.CodeForbidden
+	case resp.StatusCode >= 400:
+		code = core.CodeInvalidArgument
+	}
+	return core.Errorf(code, "%s %s: registry returned %s", method, path, resp.Status)
+}
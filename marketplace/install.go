@@ -0,0 +1,308 @@
+package marketplace
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// installProgressBuffer is how many reports a streamed install queues for
+// a reader that falls behind
+const installProgressBuffer = 16
+
+// InstallStream installs a package in the background, reporting on the
+// returned channel as it downloads the package, verifies it and unpacks
+// it into the install directory. The last report is PhaseDone, or carries
+// the error that stopped the install; the channel is closed after it and
+// must be read until then.
+//
+// Canceling ctx aborts the install without leaving files behind. Updates
+// within a phase are dropped when the reader falls behind; the start of
+// each phase and the last report never are.
+func (c *DefaultClient) InstallStream(ctx context.Context, id, version string) (<-chan InstallProgress, error) {
+	pkg, err := c.registry.GetPackage(ctx, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("fetch package: %w", err)
+	}
+
+	c.mu.RLock()
+	dir := c.installDir
+	c.mu.RUnlock()
+
+	progress := make(chan InstallProgress, installProgressBuffer)
+	go func() {
+		defer close(progress)
+		report := &installReporter{ctx: ctx, progress: progress, phase: PhaseDownload, total: -1}
+		if err := c.installStream(ctx, pkg, dir, report); err != nil {
+			progress <- InstallProgress{Phase: report.phase, Total: report.total, Err: err}
+			return
+		}
+		progress <- InstallProgress{Phase: PhaseDone}
+	}()
+	return progress, nil
+}
+
+// installStream runs the phases of a streamed install
+func (c *DefaultClient) installStream(ctx context.Context, pkg *Package, dir string, report *installReporter) error {
+	data, err := c.downloadPackage(ctx, pkg, report)
+	if err != nil {
+		return err
+	}
+
+	if err := report.start(PhaseVerify, int64(len(data))); err != nil {
+		return err
+	}
+	if err := c.validator.ValidatePackage(ctx, pkg, data); err != nil {
+		return fmt.Errorf("validate package: %w", err)
+	}
+	if err := verifyPackageChecksum(data, pkg.Checksum); err != nil {
+		return err
+	}
+
+	if dir != "" {
+		if err := report.start(PhaseExtract, -1); err != nil {
+			return err
+		}
+		if err := extractPackage(ctx, pkg, data, dir, report); err != nil {
+			return err
+		}
+	}
+
+	if err := c.cache.Put(ctx, pkg.ID, pkg.Version, data); err != nil {
+		return fmt.Errorf("cache package: %w", err)
+	}
+
+	c.mu.Lock()
+	c.installed[pkg.ID] = pkg
+	c.mu.Unlock()
+	return nil
+}
+
+// downloadPackage returns the package data from the cache, or downloads it
+// from a registry that serves package data
+func (c *DefaultClient) downloadPackage(ctx context.Context, pkg *Package, report *installReporter) ([]byte, error) {
+	if data, err := c.cache.Get(ctx, pkg.ID, pkg.Version); err == nil && data != nil {
+		if err := report.start(PhaseDownload, int64(len(data))); err != nil {
+			return nil, err
+		}
+		report.update(int64(len(data)), "")
+		return data, nil
+	}
+
+	downloader, ok := c.registry.(PackageDownloader)
+	if !ok {
+		data := placeholderData(pkg.ID, pkg.Version)
+		if err := report.start(PhaseDownload, int64(len(data))); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	body, size, err := downloader.Download(ctx, pkg.ID, pkg.Version)
+	if err != nil {
+		return nil, fmt.Errorf("download package: %w", err)
+	}
+	defer body.Close()
+
+	if err := report.start(PhaseDownload, size); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	chunk := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, readErr := body.Read(chunk)
+		if n > 0 {
+			buf.Write(chunk[:n])
+			report.update(int64(buf.Len()), "")
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("download interrupted after %d bytes: %w", buf.Len(), readErr)
+		}
+	}
+	if size >= 0 && int64(buf.Len()) != size {
+		return nil, fmt.Errorf("download interrupted after %d of %d bytes", buf.Len(), size)
+	}
+	return buf.Bytes(), nil
+}
+
+// placeholderData stands in for the data of packages whose registry does
+// not serve it
+func placeholderData(id, version string) []byte {
+	return []byte(fmt.Sprintf("package-%s-%s", id, version))
+}
+
+// verifyPackageChecksum checks data against a "sha256:<hex>" checksum.
+// Checksums in other forms are left to the validator.
+func verifyPackageChecksum(data []byte, checksum string) error {
+	if !strings.HasPrefix(checksum, "sha256:") {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	computed := "sha256:" + hex.EncodeToString(sum[:])
+	if computed != strings.ToLower(checksum) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", checksum, computed)
+	}
+	return nil
+}
+
+// extractPackage unpacks data into <dir>/<id>/<version>. A gzipped tarball
+// is unpacked file by file; other data is written as a single file named
+// after the package. Files are unpacked into a staging directory that
+// replaces any earlier install only once complete, and is removed if the
+// install fails.
+func extractPackage(ctx context.Context, pkg *Package, data []byte, dir string, report *installReporter) error {
+	target, err := packageDir(dir, pkg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create install directory: %w", err)
+	}
+	stage, err := os.MkdirTemp(dir, ".install-*")
+	if err != nil {
+		return fmt.Errorf("create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stage)
+
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		err = extractTarball(ctx, data, stage, report)
+	} else {
+		err = os.WriteFile(filepath.Join(stage, pkg.ID), data, 0644)
+		report.update(int64(len(data)), pkg.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("create package directory: %w", err)
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("remove previous install: %w", err)
+	}
+	if err := os.Rename(stage, target); err != nil {
+		return fmt.Errorf("install package: %w", err)
+	}
+	return nil
+}
+
+// packageDir resolves where a package is installed under dir, rejecting
+// IDs and versions that are not a single path element
+func packageDir(dir string, pkg *Package) (string, error) {
+	for _, part := range []string{pkg.ID, pkg.Version} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return "", fmt.Errorf("invalid package location: %s@%s", pkg.ID, pkg.Version)
+		}
+	}
+	return filepath.Join(dir, pkg.ID, pkg.Version), nil
+}
+
+// extractTarball unpacks the regular files and directories of a gzipped
+// tarball under dir, rejecting entries that would escape it
+func extractTarball(ctx context.Context, data []byte, dir string, report *installReporter) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("open package archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	var extracted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read package archive: %w", err)
+		}
+
+		target, err := templatePath(dir, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			mode := os.FileMode(0644)
+			if header.Mode&0111 != 0 {
+				mode = 0755
+			}
+			if err := extractFile(tr, target, mode); err != nil {
+				return fmt.Errorf("extract %s: %w", header.Name, err)
+			}
+			extracted += header.Size
+			report.update(extracted, header.Name)
+		}
+	}
+}
+
+// extractFile writes the contents of r to path
+func extractFile(r io.Reader, path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// installReporter sends the progress of a streamed install
+type installReporter struct {
+	ctx      context.Context
+	progress chan<- InstallProgress
+	phase    InstallPhase
+	total    int64
+}
+
+// start enters phase, waiting for room to report it unless ctx ends
+func (r *installReporter) start(phase InstallPhase, total int64) error {
+	r.phase, r.total = phase, total
+	select {
+	case r.progress <- InstallProgress{Phase: phase, Total: total}:
+		return nil
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+}
+
+// update reports progress within the phase, unless the reader is behind
+func (r *installReporter) update(bytes int64, file string) {
+	select {
+	case r.progress <- InstallProgress{Phase: r.phase, Bytes: bytes, Total: r.total, File: file}:
+	default:
+	}
+}
@@ -1,8 +1,10 @@
 package marketplace
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 )
@@ -87,6 +89,18 @@ func (r *MemoryRegistry) GetTemplate(ctx context.Context, id string) (*Template,
 	return tmpl, nil
 }
 
+// Download opens the data a package was published with
+func (r *MemoryRegistry) Download(ctx context.Context, id, version string) (io.ReadCloser, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data, ok := r.data[fmt.Sprintf("%s-%s", id, version)]
+	if !ok {
+		return nil, 0, fmt.Errorf("package data not found: %s@%s", id, version)
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
 // Publish publishes a new package
 func (r *MemoryRegistry) Publish(ctx context.Context, pkg *Package, data []byte) error {
 	r.mu.Lock()
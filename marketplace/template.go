@@ -0,0 +1,96 @@
+package marketplace
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Binary reports whether the file is a binary asset: it has Data, or a
+// content type that is not text
+func (f TemplateFile) Binary() bool {
+	if len(f.Data) > 0 {
+		return true
+	}
+	if f.ContentType == "" {
+		return false
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(f.ContentType, ";", 2)[0])
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		mediaType == "application/javascript",
+		mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return false
+	}
+	return true
+}
+
+// Bytes returns the file's content: Data for binary files, otherwise
+// Content
+func (f TemplateFile) Bytes() []byte {
+	if len(f.Data) > 0 {
+		return f.Data
+	}
+	return []byte(f.Content)
+}
+
+// writeTemplate writes each file of tmpl under targetDir. Templated text
+// files are expanded with text/template using the template's Variables
+// overridden by vars; other files are written as is.
+func writeTemplate(tmpl *Template, targetDir string, vars map[string]string) error {
+	values := make(map[string]string, len(tmpl.Variables)+len(vars))
+	for k, v := range tmpl.Variables {
+		values[k] = v
+	}
+	for k, v := range vars {
+		values[k] = v
+	}
+
+	for _, file := range tmpl.Files {
+		path, err := templatePath(targetDir, file.Path)
+		if err != nil {
+			return err
+		}
+
+		content := file.Bytes()
+		if file.Templated && !file.Binary() {
+			t, err := template.New(file.Path).Option("missingkey=error").Parse(file.Content)
+			if err != nil {
+				return fmt.Errorf("parse %s: %w", file.Path, err)
+			}
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, values); err != nil {
+				return fmt.Errorf("expand %s: %w", file.Path, err)
+			}
+			content = buf.Bytes()
+		}
+
+		mode := os.FileMode(0644)
+		if file.Executable {
+			mode = 0755
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("create directory for %s: %w", file.Path, err)
+		}
+		if err := os.WriteFile(path, content, mode); err != nil {
+			return fmt.Errorf("write %s: %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// templatePath resolves a template file path under targetDir, rejecting
+// paths that would escape it
+func templatePath(targetDir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("template file path escapes target directory: %s", name)
+	}
+	return filepath.Join(targetDir, clean), nil
+}
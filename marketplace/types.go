@@ -2,6 +2,7 @@ package marketplace
 
 import (
 	"context"
+	"io"
 	"time"
 )
 
@@ -42,12 +43,16 @@ type Template struct {
 	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
-// TemplateFile represents a file in a template
+// TemplateFile represents a file in a template. Text files set Content;
+// binary assets such as icons and fonts set Data, which is base64 in JSON
+// and written byte-exact.
 type TemplateFile struct {
-	Path       string `json:"path"`
-	Content    string `json:"content"`
-	Executable bool   `json:"executable"`
-	Templated  bool   `json:"templated"`
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Data        []byte `json:"data,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Executable  bool   `json:"executable"`
+	Templated   bool   `json:"templated"`
 }
 
 // SearchQuery represents marketplace search parameters
@@ -93,6 +98,46 @@ type Registry interface {
 	DeletePackage(ctx context.Context, id, version string) error
 }
 
+// PackageDownloader is implemented by registries that serve package data,
+// which InstallStream downloads through it
+type PackageDownloader interface {
+	// Download opens the data of a package and returns its size, or -1
+	// when it is unknown
+	Download(ctx context.Context, id, version string) (io.ReadCloser, int64, error)
+}
+
+// InstallPhase is a stage of a streamed install
+type InstallPhase string
+
+const (
+	PhaseDownload InstallPhase = "download"
+	PhaseVerify   InstallPhase = "verify"
+	PhaseExtract  InstallPhase = "extract"
+	PhaseDone     InstallPhase = "done"
+)
+
+// InstallProgress reports a streamed install. Each phase is announced
+// once, with Bytes at zero, before any updates within it.
+type InstallProgress struct {
+	// Phase is the stage the install is in
+	Phase InstallPhase
+
+	// Bytes is how much the phase has processed: bytes downloaded, or
+	// bytes of files extracted
+	Bytes int64
+
+	// Total is the size of the package while downloading and verifying, or
+	// -1 when it is unknown
+	Total int64
+
+	// File is the file just extracted, during PhaseExtract
+	File string
+
+	// Err is set on the final report of a failed install, whose Phase is
+	// the one that failed
+	Err error
+}
+
 // Cache manages local package caching
 type Cache interface {
 	// Get retrieves a cached package
@@ -153,6 +198,9 @@ type Client interface {
 	// Install installs a package
 	Install(ctx context.Context, id, version string) error
 
+	// InstallStream installs a package, reporting its progress
+	InstallStream(ctx context.Context, id, version string) (<-chan InstallProgress, error)
+
 	// Uninstall removes a package
 	Uninstall(ctx context.Context, id string) error
 
@@ -57,6 +57,9 @@ func (v *DefaultValidator) ValidateTemplate(ctx context.Context, tmpl *Template)
 		if file.Path == "" {
 			return fmt.Errorf("template file path is required")
 		}
+		if file.Templated && file.Binary() {
+			return fmt.Errorf("binary template file cannot be templated: %s", file.Path)
+		}
 	}
 
 	return nil
@@ -4,22 +4,38 @@
 package cpp
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Pool manages C++ execution workers
 type Pool struct {
-	workers chan *Worker
-	size    int
-	mu      sync.Mutex
-	closed  bool
+	workers  *core.WorkerPool[*Worker]
+	opts     core.WorkerPoolOptions
+	size     int
+	mu       sync.Mutex
+	closed   bool
+	limits   core.ResourceLimits
+	encoding core.OutputEncoding
+	stdout   io.Writer
+	stderr   io.Writer
+	cache    *core.CompileCache
+
+	// structured returns the delimited JSON result programs print, for
+	// StructuredOutput
+	structured bool
 }
 
 // NewPool creates a worker pool
-func NewPool(size int) *Pool {
+func NewPool(size int, limits core.ResourceLimits, encoding core.OutputEncoding) *Pool {
 	return &Pool{
-		size: size,
+		size:     size,
+		limits:   limits,
+		encoding: encoding,
 	}
 }
 
@@ -32,32 +48,55 @@ func (p *Pool) Initialize() error {
 		return fmt.Errorf("pool is closed")
 	}
 
-	p.workers = make(chan *Worker, p.size)
-
-	for i := 0; i < p.size; i++ {
-		worker := NewWorker(i)
-		if err := worker.Initialize(); err != nil {
-			return fmt.Errorf("failed to initialize worker %d: %w", i, err)
-		}
-		p.workers <- worker
+	opts := p.opts
+	opts.Max = p.size
+	workers, err := core.NewWorkerPoolWith(opts, p.newWorker, (*Worker).Shutdown)
+	if err != nil {
+		return err
 	}
-
+	p.workers = workers
 	return nil
 }
 
-// Acquire gets a worker from the pool
-func (p *Pool) Acquire() *Worker {
-	return <-p.workers
+// newWorker creates and initializes a worker
+func (p *Pool) newWorker(id int) (*Worker, error) {
+	worker := NewWorker(id)
+	worker.limits = p.limits
+	worker.encoding = p.encoding
+	worker.stdout, worker.stderr = p.stdout, p.stderr
+	worker.structured = p.structured
+	worker.cache = p.cache
+	if err := worker.Initialize(); err != nil {
+		return nil, err
+	}
+	return worker, nil
+}
+
+// Acquire gets a worker from the pool, waiting until ctx ends
+func (p *Pool) Acquire(ctx context.Context) (*Worker, error) {
+	worker, err := p.workers.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire worker: %w", err)
+	}
+	return worker, nil
 }
 
 // Release returns a worker to the pool
 func (p *Pool) Release(worker *Worker) {
-	select {
-	case p.workers <- worker:
-	default:
-		// Pool is closed, just cleanup the worker
-		worker.Shutdown()
+	p.workers.Release(worker)
+}
+
+// Resize changes the pool's maximum and minimum workers
+func (p *Pool) Resize(size, min int) error {
+	return p.workers.Resize(size, min)
+}
+
+// Stats reports the pool's workers
+func (p *Pool) Stats() core.PoolStats {
+	if p.workers == nil {
+		return core.PoolStats{}
 	}
+	return p.workers.Stats()
 }
 
 // Close shuts down the pool
@@ -70,10 +109,30 @@ func (p *Pool) Close() {
 	}
 
 	p.closed = true
-	close(p.workers)
+	if p.workers != nil {
+		p.workers.Close()
+	}
+
+	if p.cache != nil {
+		p.cache.Close()
+	}
+}
 
-	// Drain and shutdown all workers
-	for worker := range p.workers {
-		worker.Shutdown()
+// SetCompileCache shares cache between the pool's workers. It must be
+// called before Initialize.
+func (p *Pool) SetCompileCache(cache *core.CompileCache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache = cache
+}
+
+// CompileCacheStats reports compile cache use, which is zero when the
+// cache is disabled
+func (p *Pool) CompileCacheStats() core.CompileCacheStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cache == nil {
+		return core.CompileCacheStats{}
 	}
+	return p.cache.Stats()
 }
@@ -6,6 +6,7 @@ package cpp
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/griffincancode/polyglot.js/core"
 )
@@ -28,6 +29,11 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 	return nil, fmt.Errorf("cpp runtime not enabled in build")
 }
 
+// ExecuteWithInput is not available
+func (r *Runtime) ExecuteWithInput(ctx context.Context, code string, stdin io.Reader) (interface{}, error) {
+	return nil, fmt.Errorf("cpp runtime not enabled in build")
+}
+
 // Call is not available
 func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (interface{}, error) {
 	return nil, fmt.Errorf("cpp runtime not enabled in build")
@@ -38,6 +44,11 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities declares the concurrency model: each execution runs its own compiled binary
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyParallel}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "cpp"
@@ -47,3 +58,18 @@ func (r *Runtime) Name() string {
 func (r *Runtime) Version() string {
 	return "disabled"
 }
+
+// Stubbed reports that this runtime is a placeholder
+func (r *Runtime) Stubbed() bool {
+	return true
+}
+
+// Precompile is not available
+func (r *Runtime) Precompile(ctx context.Context, snippets []string) error {
+	return fmt.Errorf("cpp runtime not enabled in build")
+}
+
+// CompileCacheStats reports how often compiled C++ binaries were reused
+func (r *Runtime) CompileCacheStats() core.CompileCacheStats {
+	return core.CompileCacheStats{}
+}
@@ -5,12 +5,16 @@ package cpp
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Worker represents a C++ execution context
@@ -20,6 +24,15 @@ type Worker struct {
 	shutdown bool
 	cppPath  string
 	tempDir  string
+	limits   core.ResourceLimits
+	encoding core.OutputEncoding
+	stdout   io.Writer
+	stderr   io.Writer
+	cache    *core.CompileCache
+
+	// structured returns the delimited JSON result programs print, for
+	// StructuredOutput
+	structured bool
 }
 
 // NewWorker creates a C++ worker
@@ -57,6 +70,13 @@ func (w *Worker) Initialize() error {
 
 // Execute runs C++ code
 func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error) {
+	return w.ExecuteWithInput(code, nil, nil, nil)
+}
+
+// ExecuteWithInput runs C++ code with stdin as the program's standard
+// input, which reads as empty when stdin is nil. What the program prints
+// is also written to stdout and stderr, either of which may be nil.
+func (w *Worker) ExecuteWithInput(code string, stdin io.Reader, stdout, stderr io.Writer) (interface{}, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -67,38 +87,27 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	// Prepare the code (wrap in a main function if needed)
 	fullCode := w.prepareCode(code)
 
-	// Write to a temporary C++ file
-	cppFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d.cpp", w.id))
-	if err := os.WriteFile(cppFile, []byte(fullCode), 0644); err != nil {
-		return nil, fmt.Errorf("failed to write C++ file: %w", err)
-	}
-	defer os.Remove(cppFile)
-
-	// Compile the C++ code
-	binaryFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d", w.id))
-	var compileStderr bytes.Buffer
-	compileCmd := exec.Command(w.cppPath, "-std=c++17", "-o", binaryFile, cppFile)
-	compileCmd.Stderr = &compileStderr
-
-	if err := compileCmd.Run(); err != nil {
-		errMsg := compileStderr.String()
-		if errMsg != "" {
-			return nil, fmt.Errorf("compilation failed: %s", errMsg)
-		}
-		return nil, fmt.Errorf("compilation failed: %w", err)
+	// Compile the C++ code, or reuse the binary of an equivalent program
+	binaryFile, release, err := w.build(fullCode)
+	if err != nil {
+		return nil, err
 	}
-
-	// Clean up compiled binary after execution
-	defer os.Remove(binaryFile)
+	defer release()
 
 	// Execute the compiled binary
-	var stdout, stderr bytes.Buffer
+	var out, errOut bytes.Buffer
 	runCmd := exec.Command(binaryFile)
-	runCmd.Stdout = &stdout
-	runCmd.Stderr = &stderr
-
-	if err := runCmd.Run(); err != nil {
-		errMsg := stderr.String()
+	runCmd.Stdin = stdin
+	runCmd.Stdout = core.TeeWriter(&out, core.TeeWriter(w.stdout, stdout))
+	runCmd.Stderr = core.TeeWriter(&errOut, core.TeeWriter(w.stderr, stderr))
+
+	if err := core.RunLimited(runCmd, "cpp", w.limits); err != nil {
+		var exceeded *core.ResourceExceededError
+		var crashed *core.RuntimeCrashedError
+		if errors.As(err, &exceeded) || errors.As(err, &crashed) {
+			return nil, err
+		}
+		errMsg := errOut.String()
 		if errMsg != "" {
 			return nil, fmt.Errorf("execution failed: %s", errMsg)
 		}
@@ -106,7 +115,15 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	}
 
 	// Extract result from output
-	output := stdout.String()
+	if w.structured {
+		if result, found, err := core.ParseStructuredOutput(out.Bytes()); found {
+			return result, err
+		}
+	}
+	output, raw := core.DecodeOutput(out.Bytes(), w.encoding)
+	if raw != nil {
+		return raw, nil
+	}
 	return extractResult(output), nil
 }
 
@@ -263,3 +280,63 @@ func extractResult(output string) interface{} {
 	// For now, return as string
 	return output
 }
+
+// Precompile builds code into the compile cache without running it
+func (w *Worker) Precompile(code string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return fmt.Errorf("worker is shutdown")
+	}
+	if w.cache == nil {
+		return core.NewError(core.CodeUnavailable, "compile cache is disabled")
+	}
+
+	_, release, err := w.build(w.prepareCode(code))
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+// build compiles fullCode, returning the binary and a func to call once it
+// has run. Binaries are shared through the compile cache when enabled.
+func (w *Worker) build(fullCode string) (string, func(), error) {
+	if w.cache == nil {
+		binaryFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d", w.id))
+		if err := w.compile(fullCode, binaryFile); err != nil {
+			return "", nil, err
+		}
+		return binaryFile, func() { os.Remove(binaryFile) }, nil
+	}
+	key := core.SourceKey(fullCode, w.cppPath, "-std=c++17", core.ToolchainVersion(w.cppPath, "--version"))
+	return w.cache.Acquire(key, func(path string) error {
+		return w.compile(fullCode, path)
+	})
+}
+
+// compile compiles fullCode to binaryFile
+func (w *Worker) compile(fullCode, binaryFile string) error {
+	// Write to a temporary C++ file
+	cppFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d.cpp", w.id))
+	if err := os.WriteFile(cppFile, []byte(fullCode), 0644); err != nil {
+		return fmt.Errorf("failed to write C++ file: %w", err)
+	}
+	defer os.Remove(cppFile)
+
+	// Compile the C++ code
+	var compileStderr bytes.Buffer
+	compileCmd := exec.Command(w.cppPath, "-std=c++17", "-o", binaryFile, cppFile)
+	compileCmd.Stderr = &compileStderr
+
+	if err := compileCmd.Run(); err != nil {
+		errMsg := compileStderr.String()
+		if errMsg != "" {
+			return fmt.Errorf("compilation failed: %s", errMsg)
+		}
+		return fmt.Errorf("compilation failed: %w", err)
+	}
+	return nil
+}
@@ -4,13 +4,17 @@
 package goruntime
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // InterpreterPool manages a pool of Go interpreters
 type InterpreterPool struct {
-	interpreters chan *Interpreter
+	interpreters *core.WorkerPool[*Interpreter]
+	opts         core.WorkerPoolOptions
 	size         int
 	mu           sync.Mutex
 	closed       bool
@@ -22,10 +26,7 @@ func NewInterpreterPool(size int) *InterpreterPool {
 		size = 4
 	}
 
-	return &InterpreterPool{
-		interpreters: make(chan *Interpreter, size),
-		size:         size,
-	}
+	return &InterpreterPool{size: size}
 }
 
 // Initialize creates the interpreter pool
@@ -37,36 +38,44 @@ func (p *InterpreterPool) Initialize() error {
 		return fmt.Errorf("pool is closed")
 	}
 
-	// Create interpreters
-	for i := 0; i < p.size; i++ {
-		interpreter, err := NewInterpreter()
-		if err != nil {
-			// Clean up any created interpreters
-			close(p.interpreters)
-			return fmt.Errorf("failed to create interpreter %d: %w", i, err)
-		}
-		p.interpreters <- interpreter
+	// Interpreters hold no resources, so the pool only drops them
+	opts := p.opts
+	opts.Max = p.size
+	interpreters, err := core.NewWorkerPoolWith(opts, func(int) (*Interpreter, error) {
+		return NewInterpreter()
+	}, nil)
+	if err != nil {
+		return err
 	}
-
+	p.interpreters = interpreters
 	return nil
 }
 
-// Acquire gets an interpreter from the pool
-func (p *InterpreterPool) Acquire() *Interpreter {
-	return <-p.interpreters
+// Acquire gets an interpreter from the pool, waiting until ctx ends
+func (p *InterpreterPool) Acquire(ctx context.Context) (*Interpreter, error) {
+	interpreter, err := p.interpreters.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire interpreter: %w", err)
+	}
+	return interpreter, nil
 }
 
 // Release returns an interpreter to the pool
 func (p *InterpreterPool) Release(interpreter *Interpreter) {
-	if p.closed {
-		return
-	}
+	p.interpreters.Release(interpreter)
+}
 
-	select {
-	case p.interpreters <- interpreter:
-	default:
-		// Pool is full or closed, discard the interpreter
+// Resize changes the pool's maximum and minimum interpreters
+func (p *InterpreterPool) Resize(size, min int) error {
+	return p.interpreters.Resize(size, min)
+}
+
+// Stats reports the pool's interpreters
+func (p *InterpreterPool) Stats() core.PoolStats {
+	if p.interpreters == nil {
+		return core.PoolStats{}
 	}
+	return p.interpreters.Stats()
 }
 
 // Close shuts down the pool
@@ -79,10 +88,7 @@ func (p *InterpreterPool) Close() {
 	}
 
 	p.closed = true
-	close(p.interpreters)
-
-	// Drain the channel
-	for range p.interpreters {
-		// Interpreters will be garbage collected
+	if p.interpreters != nil {
+		p.interpreters.Close()
 	}
 }
@@ -38,14 +38,11 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 
 	r.config = config
 
-	// Determine pool size
-	poolSize := config.MaxConcurrency
-	if poolSize <= 0 {
-		poolSize = 4
-	}
+	size := poolSize(config)
 
 	// Initialize the interpreter pool
-	r.pool = NewInterpreterPool(poolSize)
+	r.pool = NewInterpreterPool(size)
+	r.pool.opts = core.WorkerPoolOptionsFor("go", config, size)
 	if err := r.pool.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
@@ -62,7 +59,10 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 	}
 	r.mu.RUnlock()
 
-	interpreter := r.pool.Acquire()
+	interpreter, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.pool.Release(interpreter)
 
 	// Prepare code for execution
@@ -92,7 +92,10 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 	r.mu.RUnlock()
 
-	interpreter := r.pool.Acquire()
+	interpreter, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.pool.Release(interpreter)
 
 	// Call with context cancellation support
@@ -110,6 +113,34 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 }
 
+// poolSize returns the number of workers for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 4
+	}
+	return config.MaxConcurrency
+}
+
+// Reconfigure resizes the interpreter pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.pool.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
+	}
+	r.config = config
+	return nil
+}
+
 // Shutdown stops the runtime
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.mu.Lock()
@@ -128,6 +159,21 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// PoolStats reports the worker pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool == nil {
+		return core.PoolStats{}
+	}
+	return r.pool.Stats()
+}
+
+// Capabilities declares the concurrency model: each worker is its own interpreter
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyPerState}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "go"
@@ -38,6 +38,11 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities declares the concurrency model: each worker is its own interpreter
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyPerState}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "go"
@@ -47,3 +52,8 @@ func (r *Runtime) Name() string {
 func (r *Runtime) Version() string {
 	return "stub (not enabled)"
 }
+
+// Stubbed reports that this runtime is a placeholder
+func (r *Runtime) Stubbed() bool {
+	return true
+}
@@ -6,6 +6,7 @@ package java
 import (
 	"context"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 	"sync"
@@ -45,14 +46,10 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 
 	r.config = config
 
-	// Determine pool size
-	poolSize := config.MaxConcurrency
-	if poolSize <= 0 {
-		poolSize = 4
-	}
-
 	// Initialize the pool
-	r.pool = NewPool(poolSize)
+	r.pool = NewPool(core.WorkerPoolOptionsFor("java", config, poolSize(config)), core.LimitsFor(config), config.OutputEncoding)
+	r.pool.stdout, r.pool.stderr = core.OutputWriters(config)
+	r.pool.structured = config.StructuredOutput
 	if err := r.pool.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
@@ -71,6 +68,12 @@ func (r *Runtime) checkJavaAvailable() error {
 
 // Execute runs Java code
 func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{}) (interface{}, error) {
+	return r.ExecuteWithInput(ctx, code, nil)
+}
+
+// ExecuteWithInput runs Java code with stdin piped to the program, which
+// sees EOF once stdin is exhausted
+func (r *Runtime) ExecuteWithInput(ctx context.Context, code string, stdin io.Reader) (interface{}, error) {
 	r.mu.RLock()
 	if r.shutdown {
 		r.mu.RUnlock()
@@ -78,13 +81,18 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 	}
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	core.ReportWorker(ctx, worker.id)
 	defer r.pool.Release(worker)
 
 	// Execute with context cancellation support
+	stdout, stderr := core.OutputFrom(ctx)
 	resultChan := make(chan result, 1)
 	go func() {
-		res, err := worker.Execute(code, args...)
+		res, err := worker.ExecuteWithInput(code, stdin, stdout, stderr)
 		resultChan <- result{value: res, err: err}
 	}()
 
@@ -105,7 +113,10 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.pool.Release(worker)
 
 	// Call with context cancellation support
@@ -123,6 +134,35 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 }
 
+// poolSize returns the number of workers for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 4
+	}
+	return config.MaxConcurrency
+}
+
+// Reconfigure resizes the worker pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.pool.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
+	}
+	r.config = config
+	return nil
+}
+
 // Shutdown stops the runtime
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.mu.Lock()
@@ -141,6 +181,22 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// PoolStats reports the worker pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.pool == nil {
+		return core.PoolStats{}
+	}
+	return r.pool.Stats()
+}
+
+// Capabilities declares the concurrency model: each execution runs its own JVM process
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyParallel}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "java"
@@ -5,12 +5,16 @@ package java
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Worker represents a Java execution context
@@ -20,6 +24,14 @@ type Worker struct {
 	shutdown bool
 	javaPath string
 	tempDir  string
+	limits   core.ResourceLimits
+	encoding core.OutputEncoding
+	stdout   io.Writer
+	stderr   io.Writer
+
+	// structured returns the delimited JSON result programs print, for
+	// StructuredOutput
+	structured bool
 }
 
 // NewWorker creates a Java worker
@@ -57,6 +69,13 @@ func (w *Worker) Initialize() error {
 
 // Execute runs Java code
 func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error) {
+	return w.ExecuteWithInput(code, nil, nil, nil)
+}
+
+// ExecuteWithInput runs Java code with stdin as the program's standard
+// input, which reads as empty when stdin is nil. What the program prints
+// is also written to stdout and stderr, either of which may be nil.
+func (w *Worker) ExecuteWithInput(code string, stdin io.Reader, stdout, stderr io.Writer) (interface{}, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -93,13 +112,25 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	defer os.Remove(classFile)
 
 	// Execute the compiled Java class
-	var stdout, stderr bytes.Buffer
-	runCmd := exec.Command(w.javaPath, "-cp", w.tempDir, className)
-	runCmd.Stdout = &stdout
-	runCmd.Stderr = &stderr
-
-	if err := runCmd.Run(); err != nil {
-		errMsg := stderr.String()
+	var out, errOut bytes.Buffer
+	args := []string{"-cp", w.tempDir}
+	if w.limits.Memory > 0 {
+		// Bound the heap below the data limit so the JVM reports
+		// OutOfMemoryError instead of failing to allocate natively
+		args = append(args, fmt.Sprintf("-Xmx%dk", w.limits.Memory*3/4/1024))
+	}
+	runCmd := exec.Command(w.javaPath, append(args, className)...)
+	runCmd.Stdin = stdin
+	runCmd.Stdout = core.TeeWriter(&out, core.TeeWriter(w.stdout, stdout))
+	runCmd.Stderr = core.TeeWriter(&errOut, core.TeeWriter(w.stderr, stderr))
+
+	if err := core.RunLimited(runCmd, "java", w.limits); err != nil {
+		var exceeded *core.ResourceExceededError
+		var crashed *core.RuntimeCrashedError
+		if errors.As(err, &exceeded) || errors.As(err, &crashed) {
+			return nil, err
+		}
+		errMsg := errOut.String()
 		if errMsg != "" {
 			return nil, fmt.Errorf("execution failed: %s", errMsg)
 		}
@@ -107,7 +138,15 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	}
 
 	// Extract result from output
-	output := stdout.String()
+	if w.structured {
+		if result, found, err := core.ParseStructuredOutput(out.Bytes()); found {
+			return result, err
+		}
+	}
+	output, raw := core.DecodeOutput(out.Bytes(), w.encoding)
+	if raw != nil {
+		return raw, nil
+	}
 	return extractResult(output), nil
 }
 
@@ -0,0 +1,50 @@
+package javascript
+
+import (
+	"fmt"
+
+	"github.com/griffincancode/polyglot.js/core"
+	"rogchap.com/v8go"
+)
+
+// determinismScript replaces Math.random with a seeded generator and Date
+// with one whose clock is frozen, once per context, and then resets both.
+// Dates built from explicit arguments are unaffected.
+const determinismScript = `(function(seed, now) {
+	const g = globalThis;
+	let state = g.__polyglot_determinism__;
+	if (!state) {
+		state = { seed: 0, now: 0 };
+		Object.defineProperty(g, '__polyglot_determinism__', { value: state });
+
+		Math.random = function() {
+			// mulberry32
+			let t = state.seed = (state.seed + 0x6D2B79F5) >>> 0;
+			t = Math.imul(t ^ (t >>> 15), t | 1);
+			t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+			return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+		};
+
+		const RealDate = Date;
+		const FixedDate = function Date(...args) {
+			if (!new.target) return new RealDate(state.now).toString();
+			return args.length === 0 ? new RealDate(state.now) : new RealDate(...args);
+		};
+		FixedDate.prototype = RealDate.prototype;
+		FixedDate.now = function() { return state.now; };
+		FixedDate.parse = RealDate.parse;
+		FixedDate.UTC = RealDate.UTC;
+		g.Date = FixedDate;
+	}
+	state.seed = seed >>> 0;
+	state.now = now;
+})(%d, %d)`
+
+// determinize seeds Math.random and freezes Date in jsCtx
+func determinize(jsCtx *v8go.Context, d core.Determinism) error {
+	script := fmt.Sprintf(determinismScript, uint32(d.Seed), d.Time.UnixMilli())
+	if _, err := jsCtx.RunScript(script, "determinism.js"); err != nil {
+		return fmt.Errorf("failed to apply deterministic mode: %w", err)
+	}
+	return nil
+}
@@ -1,26 +1,34 @@
 package javascript
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
+	"github.com/griffincancode/polyglot.js/core"
 	"rogchap.com/v8go"
 )
 
 // ContextPool manages V8 contexts
 type ContextPool struct {
-	contexts chan *v8go.Context
+	contexts *core.WorkerPool[*v8go.Context]
+	opts     core.WorkerPoolOptions
 	isolate  *v8go.Isolate
-	size     int
 	mu       sync.Mutex
+	closed   bool
+
+	// inUse holds the acquired contexts. A context the pool destroys while
+	// it is in use is marked true and closed when released instead.
+	inUse   map[*v8go.Context]bool
+	inUseMu sync.Mutex
 }
 
 // NewContextPool creates a context pool
-func NewContextPool(size int, isolate *v8go.Isolate) *ContextPool {
+func NewContextPool(opts core.WorkerPoolOptions, isolate *v8go.Isolate) *ContextPool {
 	return &ContextPool{
-		contexts: make(chan *v8go.Context, size),
-		isolate:  isolate,
-		size:     size,
+		opts:    opts,
+		isolate: isolate,
+		inUse:   make(map[*v8go.Context]bool),
 	}
 }
 
@@ -29,25 +37,79 @@ func (p *ContextPool) Initialize() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	for i := 0; i < p.size; i++ {
-		ctx := v8go.NewContext(p.isolate)
-		if ctx == nil {
-			return fmt.Errorf("failed to create context %d", i)
-		}
-		p.contexts <- ctx
+	if p.closed {
+		return fmt.Errorf("pool is closed")
 	}
 
+	contexts, err := core.NewWorkerPoolWith(p.opts, p.newContext, p.closeContext)
+	if err != nil {
+		return err
+	}
+	p.contexts = contexts
 	return nil
 }
 
-// Acquire gets a context from the pool
-func (p *ContextPool) Acquire() *v8go.Context {
-	return <-p.contexts
+// newContext creates a context in the pool's isolate
+func (p *ContextPool) newContext(id int) (*v8go.Context, error) {
+	ctx := v8go.NewContext(p.isolate)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to create context %d", id)
+	}
+	return ctx, nil
+}
+
+// closeContext closes a context the pool destroys, deferring it to
+// Release while the context is in use
+func (p *ContextPool) closeContext(ctx *v8go.Context) {
+	p.inUseMu.Lock()
+	if _, ok := p.inUse[ctx]; ok {
+		p.inUse[ctx] = true
+		p.inUseMu.Unlock()
+		return
+	}
+	p.inUseMu.Unlock()
+	ctx.Close()
 }
 
-// Release returns a context to the pool
+// Acquire gets a context from the pool, waiting until ctx ends
+func (p *ContextPool) Acquire(ctx context.Context) (*v8go.Context, error) {
+	jsCtx, err := p.contexts.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire context: %w", err)
+	}
+
+	p.inUseMu.Lock()
+	p.inUse[jsCtx] = false
+	p.inUseMu.Unlock()
+	return jsCtx, nil
+}
+
+// Release returns a context to the pool, or closes it if the pool
+// destroyed it while it was in use
 func (p *ContextPool) Release(ctx *v8go.Context) {
-	p.contexts <- ctx
+	p.inUseMu.Lock()
+	destroyed := p.inUse[ctx]
+	delete(p.inUse, ctx)
+	p.inUseMu.Unlock()
+
+	if destroyed {
+		ctx.Close()
+		return
+	}
+	p.contexts.Release(ctx)
+}
+
+// Resize changes the pool's maximum and minimum contexts
+func (p *ContextPool) Resize(size, min int) error {
+	return p.contexts.Resize(size, min)
+}
+
+// Stats reports the pool's contexts
+func (p *ContextPool) Stats() core.PoolStats {
+	if p.contexts == nil {
+		return core.PoolStats{}
+	}
+	return p.contexts.Stats()
 }
 
 // Close shuts down the pool
@@ -55,8 +117,12 @@ func (p *ContextPool) Close() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	close(p.contexts)
-	for ctx := range p.contexts {
-		ctx.Close()
+	if p.closed {
+		return
+	}
+
+	p.closed = true
+	if p.contexts != nil {
+		p.contexts.Close()
 	}
 }
@@ -42,7 +42,7 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 	r.isolate = isolate
 
 	// Initialize context pool
-	r.contexts = NewContextPool(config.MaxConcurrency, isolate)
+	r.contexts = NewContextPool(core.WorkerPoolOptionsFor("javascript", config, poolSize(config)), isolate)
 	if err := r.contexts.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize context pool: %w", err)
 	}
@@ -63,9 +63,18 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 		return nil, fmt.Errorf("JavaScript runtime not initialized")
 	}
 
-	jsCtx := r.contexts.Acquire()
+	jsCtx, err := r.contexts.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.contexts.Release(jsCtx)
 
+	if d, ok := core.DeterminismFor(r.config); ok {
+		if err := determinize(jsCtx, d); err != nil {
+			return nil, err
+		}
+	}
+
 	// Execute code
 	val, err := jsCtx.RunScript(code, "execute.js")
 	if err != nil {
@@ -88,9 +97,18 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 		return nil, fmt.Errorf("JavaScript runtime not initialized")
 	}
 
-	jsCtx := r.contexts.Acquire()
+	jsCtx, err := r.contexts.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.contexts.Release(jsCtx)
 
+	if d, ok := core.DeterminismFor(r.config); ok {
+		if err := determinize(jsCtx, d); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get function
 	global := jsCtx.Global()
 	fnVal, err := global.Get(fn)
@@ -102,10 +120,11 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 		return nil, fmt.Errorf("%s is not a function", fn)
 	}
 
-	// Convert arguments to Valuers
+	// Convert arguments to Valuers, times following the time policy
 	v8Args := make([]v8go.Valuer, len(args))
 	for i, arg := range args {
-		v8Args[i] = convertToV8(jsCtx, arg)
+		arg = core.EncodeTimes(arg, core.TimePolicyFor(r.config))
+		v8Args[i] = convertToV8(jsCtx, arg, core.NilPolicyFor(r.config))
 	}
 
 	// Call function
@@ -118,6 +137,34 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	return convertFromV8(result), nil
 }
 
+// poolSize returns the number of contexts for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 4
+	}
+	return config.MaxConcurrency
+}
+
+// Reconfigure resizes the context pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.contexts == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.contexts.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize context pool: %w", err)
+	}
+	r.config = config
+	return nil
+}
+
 // Shutdown stops the runtime
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.mu.Lock()
@@ -140,6 +187,22 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// PoolStats reports the context pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.contexts == nil {
+		return core.PoolStats{}
+	}
+	return r.contexts.Stats()
+}
+
+// Capabilities declares the concurrency model: workers share one V8 isolate
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyGlobalLock}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "javascript"
@@ -150,9 +213,13 @@ func (r *Runtime) Version() string {
 	return v8go.Version()
 }
 
-// convertToV8 converts Go value to V8 value
-func convertToV8(ctx *v8go.Context, val interface{}) *v8go.Value {
-	if val == nil {
+// convertToV8 converts Go value to V8 value. nils become null or
+// undefined as the nil policy selects.
+func convertToV8(ctx *v8go.Context, val interface{}, nils core.NilPolicy) *v8go.Value {
+	if core.IsNil(val) {
+		if nils == core.NilUndefined {
+			return v8go.Undefined(ctx.Isolate())
+		}
 		return v8go.Null(ctx.Isolate())
 	}
 
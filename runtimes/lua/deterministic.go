@@ -0,0 +1,59 @@
+//go:build runtime_lua
+// +build runtime_lua
+
+package lua
+
+/*
+#include "luawrap.h"
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// determinismScript wraps os.time, os.date and os.clock to read a frozen
+// clock, once per state, then resets the clock and seeds math.random
+const determinismScript = `
+local seed, now = %d, %d
+local state = package.loaded.__polyglot_determinism
+if not state then
+	state = {}
+	package.loaded.__polyglot_determinism = state
+	local time, date = os.time, os.date
+	os.time = function(t)
+		if t then return time(t) end
+		return state.now
+	end
+	os.date = function(format, t)
+		return date(format, t or state.now)
+	end
+	os.clock = function() return 0 end
+end
+state.now = now
+math.randomseed(seed)`
+
+// Determinize seeds math.random and freezes the clock for the next
+// execution
+func (w *Worker) Determinize(d core.Determinism) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return fmt.Errorf("worker is shutdown")
+	}
+
+	cScript := C.CString(fmt.Sprintf(determinismScript, d.Seed, d.Time.Unix()))
+	defer C.free(unsafe.Pointer(cScript))
+
+	if C.luaL_loadstring(w.state, cScript) != 0 || C.luawrap_pcall(w.state, 0, 0, 0) != 0 {
+		err := C.GoString(C.luawrap_tostring(w.state, -1))
+		C.luawrap_pop(w.state, 1)
+		return fmt.Errorf("failed to apply deterministic mode: %s", err)
+	}
+	return nil
+}
@@ -27,4 +27,53 @@ static inline const char* luawrap_tostring(lua_State *L, int idx) {
     return lua_tostring(L, idx);
 }
 
+// luawrap_interrupt_hook raises an error at the next hook event, unwinding
+// the running chunk back to its lua_pcall
+static void luawrap_interrupt_hook(lua_State *L, lua_Debug *ar) {
+    (void)ar;
+    lua_sethook(L, NULL, 0, 0);
+    luaL_error(L, "execution interrupted");
+}
+
+// luawrap_interrupt stops the code running in L. lua_sethook is the one
+// Lua API call that is safe from another thread.
+static inline void luawrap_interrupt(lua_State *L) {
+    lua_sethook(L, luawrap_interrupt_hook, LUA_MASKCALL | LUA_MASKRET | LUA_MASKLINE | LUA_MASKCOUNT, 1);
+}
+
+// luawrap_depth_limit is the stack depth limit of L, kept in its extra
+// space so coroutines created from L share it. Zero means no limit.
+static inline int *luawrap_depth_limit(lua_State *L) {
+    return (int *)lua_getextraspace(L);
+}
+
+// luawrap_depth_hook raises an error when a call nests deeper than the
+// limit. Level 0 is the function being called.
+static void luawrap_depth_hook(lua_State *L, lua_Debug *ar) {
+    lua_Debug frame;
+    (void)ar;
+    if (lua_getstack(L, *luawrap_depth_limit(L), &frame)) {
+        luaL_error(L, "stack depth limit exceeded");
+    }
+}
+
+// luawrap_restore_hooks installs the hooks L runs outside interrupts
+static inline void luawrap_restore_hooks(lua_State *L) {
+    if (*luawrap_depth_limit(L) > 0) {
+        lua_sethook(L, luawrap_depth_hook, LUA_MASKCALL, 0);
+    } else {
+        lua_sethook(L, NULL, 0, 0);
+    }
+}
+
+// luawrap_limit_depth caps how deeply calls in L may nest
+static inline void luawrap_limit_depth(lua_State *L, int depth) {
+    *luawrap_depth_limit(L) = depth;
+    luawrap_restore_hooks(L);
+}
+
+static inline void luawrap_clear_interrupt(lua_State *L) {
+    luawrap_restore_hooks(L);
+}
+
 #endif // LUAWRAP_H
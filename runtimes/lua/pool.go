@@ -11,24 +11,46 @@ package lua
 import "C"
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Pool manages Lua state workers
 type Pool struct {
-	workers chan *Worker
+	workers *core.WorkerPool[*Worker]
+	opts    core.WorkerPoolOptions
 	size    int
-	mu      sync.Mutex
-	closed  bool
+
+	// initCode runs on each worker as it starts
+	initCode string
+
+	// maxStackDepth caps how deeply calls nest on each worker
+	maxStackDepth int
+
+	// shutdownCode runs on each worker before it shuts down, stopped when
+	// closeCtx ends. closeMu is separate from mu because the worker pool
+	// tears workers down while Close holds mu.
+	shutdownCode string
+	closeMu      sync.Mutex
+	closeCtx     context.Context
+
+	// snapshot is the state chunk workers restore after initCode, and
+	// stateGen counts the snapshots set, so workers restore a newer one
+	// before their next use
+	stateMu  sync.Mutex
+	snapshot string
+	stateGen uint64
+
+	mu     sync.Mutex
+	closed bool
 }
 
 // NewPool creates a worker pool
 func NewPool(size int) *Pool {
-	return &Pool{
-		workers: make(chan *Worker, size),
-		size:    size,
-	}
+	return &Pool{size: size}
 }
 
 // Initialize creates workers
@@ -40,37 +62,88 @@ func (p *Pool) Initialize(size int) error {
 		return fmt.Errorf("pool is closed")
 	}
 
+	if size <= 0 {
+		size = p.size
+	}
+
+	opts := p.opts
+	opts.Max = size
+	workers, err := core.NewWorkerPoolWith(opts, p.newWorker, p.teardown)
+	if err != nil {
+		return err
+	}
 	p.size = size
-	p.workers = make(chan *Worker, size)
+	p.workers = workers
+	return nil
+}
 
-	for i := 0; i < size; i++ {
-		worker := NewWorker(i)
-		if err := worker.Initialize(); err != nil {
-			return fmt.Errorf("failed to initialize worker %d: %w", i, err)
+// newWorker creates and initializes a worker, runs the init code on it and
+// restores the snapshot
+func (p *Pool) newWorker(id int) (*Worker, error) {
+	worker := NewWorker(id)
+	if err := worker.Initialize(); err != nil {
+		return nil, err
+	}
+	if p.maxStackDepth > 0 {
+		worker.LimitStackDepth(p.maxStackDepth)
+	}
+	if p.initCode != "" {
+		if _, err := worker.Execute(p.initCode); err != nil {
+			worker.Shutdown()
+			return nil, fmt.Errorf("init code: %w", err)
 		}
-		p.workers <- worker
 	}
-
-	return nil
+	if err := p.restoreSnapshot(worker); err != nil {
+		worker.Shutdown()
+		return nil, err
+	}
+	return worker, nil
 }
 
-// Acquire gets a worker from the pool
-func (p *Pool) Acquire() *Worker {
-	return <-p.workers
+// Acquire gets a worker from the pool, waiting until ctx ends, with the
+// latest snapshot restored
+func (p *Pool) Acquire(ctx context.Context) (*Worker, error) {
+	worker, err := p.workers.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire worker: %w", err)
+	}
+	if err := p.restoreSnapshot(worker); err != nil {
+		p.workers.Release(worker)
+		return nil, err
+	}
+	return worker, nil
 }
 
 // Release returns a worker to the pool
 func (p *Pool) Release(worker *Worker) {
-	select {
-	case p.workers <- worker:
-	default:
-		// Pool is closed, just cleanup the worker
-		worker.Shutdown()
+	p.workers.Release(worker)
+}
+
+// Resize changes the pool's maximum and minimum workers
+func (p *Pool) Resize(size, min int) error {
+	if p.workers == nil {
+		return fmt.Errorf("pool is not initialized")
 	}
+	return p.workers.Resize(size, min)
+}
+
+// Stats reports the pool's workers
+func (p *Pool) Stats() core.PoolStats {
+	if p.workers == nil {
+		return core.PoolStats{}
+	}
+	return p.workers.Stats()
 }
 
 // Close shuts down the pool
-func (p *Pool) Close() {
+func (p *Pool) Close(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.closeMu.Lock()
+	p.closeCtx = ctx
+	p.closeMu.Unlock()
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -79,10 +152,20 @@ func (p *Pool) Close() {
 	}
 
 	p.closed = true
-	close(p.workers)
+	if p.workers != nil {
+		p.workers.Close()
+	}
+}
 
-	// Drain and shutdown all workers
-	for worker := range p.workers {
-		worker.Shutdown()
+// teardown runs the shutdown code on a worker and shuts it down. Workers
+// torn down after Close stop the code when the context given to Close
+// ends; workers retired while idle run it to the end.
+func (p *Pool) teardown(worker *Worker) {
+	p.closeMu.Lock()
+	ctx := p.closeCtx
+	p.closeMu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	worker.Teardown(ctx, p.shutdownCode)
 }
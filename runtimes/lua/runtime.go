@@ -14,6 +14,7 @@ import "C"
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"unsafe"
 
@@ -22,10 +23,11 @@ import (
 
 // Runtime implements Lua runtime integration
 type Runtime struct {
-	config   core.RuntimeConfig
-	pool     *Pool
-	mu       sync.RWMutex
-	shutdown bool
+	config     core.RuntimeConfig
+	pool       *Pool
+	executions core.Executions
+	mu         sync.RWMutex
+	shutdown   bool
 }
 
 // NewRuntime creates a Lua runtime instance
@@ -47,7 +49,17 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 	r.config = config
 
 	// Initialize the pool
-	if err := r.pool.Initialize(config.MaxConcurrency); err != nil {
+	r.pool.opts = core.WorkerPoolOptionsFor("lua", config, poolSize(config))
+	r.pool.initCode = config.InitCode
+	r.pool.maxStackDepth = config.MaxStackDepth
+	r.pool.shutdownCode = config.ShutdownCode
+	if len(config.InitState) > 0 {
+		if !strings.HasPrefix(string(config.InitState), snapshotHeader) {
+			return core.Errorf(core.CodeInvalidArgument, "InitState is not a Lua state snapshot")
+		}
+		r.pool.setSnapshot(string(config.InitState))
+	}
+	if err := r.pool.Initialize(poolSize(config)); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
 
@@ -61,51 +73,243 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 		r.mu.RUnlock()
 		return nil, fmt.Errorf("runtime is shutdown")
 	}
+	capture := r.config.CaptureLastExpr
+	determinism, deterministic := core.DeterminismFor(r.config)
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	core.ReportWorker(ctx, worker.id)
 	defer r.pool.Release(worker)
+	defer worker.arm()()
+	defer r.executions.Track(ctx, worker.Interrupt)()
+	if deterministic {
+		if err := worker.Determinize(determinism); err != nil {
+			return nil, err
+		}
+	}
 
 	// Execute with context cancellation support
 	resultChan := make(chan result, 1)
 	go func() {
+		if capture {
+			code = worker.captureLastExpr(code)
+		}
 		res, err := worker.Execute(code, args...)
 		resultChan <- result{value: res, err: err}
 	}()
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resultChan:
-		return res.value, res.err
+	res, err := core.AwaitResult(ctx, resultChan, worker.Interrupt)
+	if err != nil {
+		return nil, err
 	}
+	return res.value, res.err
 }
 
 // Call invokes a Lua function
 func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (interface{}, error) {
+	return r.call(ctx, func(worker *Worker) (interface{}, error) {
+		return worker.Call(fn, args...)
+	})
+}
+
+// CallMulti invokes a Lua function and returns all of its results
+func (r *Runtime) CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error) {
+	results, err := r.call(ctx, func(worker *Worker) (interface{}, error) {
+		return worker.CallMulti(fn, args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results.([]interface{}), nil
+}
+
+// ExecuteMapped runs code with inputs set as globals and returns the named
+// output globals
+func (r *Runtime) ExecuteMapped(ctx context.Context, code string, inputs map[string]interface{}, outputs []string) (map[string]interface{}, error) {
+	values, err := r.call(ctx, func(worker *Worker) (interface{}, error) {
+		return worker.ExecuteMapped(code, inputs, outputs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values.(map[string]interface{}), nil
+}
+
+// call runs invoke on a pooled worker
+func (r *Runtime) call(ctx context.Context, invoke func(worker *Worker) (interface{}, error)) (interface{}, error) {
 	r.mu.RLock()
 	if r.shutdown {
 		r.mu.RUnlock()
 		return nil, fmt.Errorf("runtime is shutdown")
 	}
+	determinism, deterministic := core.DeterminismFor(r.config)
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.pool.Release(worker)
+	defer worker.arm()()
+	defer r.executions.Track(ctx, worker.Interrupt)()
+	if deterministic {
+		if err := worker.Determinize(determinism); err != nil {
+			return nil, err
+		}
+	}
 
 	// Call with context cancellation support
 	resultChan := make(chan result, 1)
 	go func() {
-		res, err := worker.Call(fn, args...)
+		res, err := invoke(worker)
 		resultChan <- result{value: res, err: err}
 	}()
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resultChan:
-		return res.value, res.err
+	res, err := core.AwaitResult(ctx, resultChan, worker.Interrupt)
+	if err != nil {
+		return nil, err
+	}
+	return res.value, res.err
+}
+
+// definedFunctionsScript lists global Lua functions (not C builtins) as
+// "name:arity" lines; varargs make arity -1
+const definedFunctionsScript = `
+local out = {}
+for name, fn in pairs(_G) do
+	if type(fn) == "function" then
+		local info = debug.getinfo(fn, "Su")
+		if info.what == "Lua" then
+			local arity = (info.isvararg or not info.nparams) and -1 or info.nparams
+			out[#out + 1] = name .. ":" .. arity
+		end
+	end
+end
+return table.concat(out, "\n")`
+
+// DefinedFunctions lists the global Lua functions defined in the session
+func (r *Runtime) DefinedFunctions(ctx context.Context) ([]core.FunctionInfo, error) {
+	listing, err := r.Execute(ctx, definedFunctionsScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
 	}
+	return core.ParseFunctionListing(listing)
+}
+
+// scopeScript lists the globals with their types, and the modules loaded
+// in package, for ParseScopeChanges
+const scopeScript = `
+local out = {}
+for name, value in pairs(_G) do
+	if type(name) == "string" then
+		out[#out + 1] = name .. "\t" .. type(value)
+	end
+end
+if package then
+	for name in pairs(package.loaded) do
+		if name ~= "_G" then out[#out + 1] = "@" .. name end
+	end
+end
+return table.concat(out, "\n")`
+
+// scopeResult is the outcome of ExecuteWithChanges on a worker
+type scopeResult struct {
+	value   interface{}
+	changes core.ScopeChanges
+}
+
+// ExecuteWithChanges runs code like Execute and reports the globals it
+// defined, with their types, and the modules it required
+func (r *Runtime) ExecuteWithChanges(ctx context.Context, code string) (interface{}, core.ScopeChanges, error) {
+	r.mu.RLock()
+	capture := r.config.CaptureLastExpr
+	r.mu.RUnlock()
+
+	res, err := r.call(ctx, func(worker *Worker) (interface{}, error) {
+		before, err := worker.Execute(scopeScript)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scope: %w", err)
+		}
+		if capture {
+			code = worker.captureLastExpr(code)
+		}
+		value, execErr := worker.Execute(code)
+		after, err := worker.Execute(scopeScript)
+		if err == nil {
+			var changes core.ScopeChanges
+			if changes, err = core.ParseScopeChanges(before, after); err == nil {
+				return scopeResult{value: value, changes: changes}, execErr
+			}
+		}
+		if execErr != nil {
+			return nil, execErr
+		}
+		return nil, fmt.Errorf("failed to list scope: %w", err)
+	})
+	scope, _ := res.(scopeResult)
+	return scope.value, scope.changes, err
+}
+
+// availableModulesScript lists the modules loaded or preloaded in
+// package, one per line
+const availableModulesScript = `
+local out = {}
+if package then
+	for name in pairs(package.loaded) do
+		if name ~= "_G" then out[#out + 1] = name end
+	end
+	for name in pairs(package.preload) do
+		out[#out + 1] = name
+	end
+end
+return table.concat(out, "\n")`
+
+// AvailableModules lists the modules Lua code can require without
+// searching package.path: the standard libraries opened in the state and
+// preloaded modules
+func (r *Runtime) AvailableModules(ctx context.Context) ([]string, error) {
+	listing, err := r.Execute(ctx, availableModulesScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list modules: %w", err)
+	}
+	return core.ParseModuleListing(listing)
+}
+
+// Interrupt stops the execution started with a context from
+// core.WithExecutionID; it fails with core.ErrInterrupted
+func (r *Runtime) Interrupt(executionID uint64) error {
+	return r.executions.Interrupt(executionID)
+}
+
+// poolSize returns the number of workers for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 10
+	}
+	return config.MaxConcurrency
+}
+
+// Reconfigure resizes the worker pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.pool.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
+	}
+	r.config = config
+	return nil
 }
 
 // Shutdown stops the runtime
@@ -120,12 +324,27 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.shutdown = true
 
 	if r.pool != nil {
-		r.pool.Close()
+		r.pool.Close(ctx)
 	}
 
 	return nil
 }
 
+// PoolStats reports the worker pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool == nil {
+		return core.PoolStats{}
+	}
+	return r.pool.Stats()
+}
+
+// Capabilities declares the concurrency model: each worker owns a Lua state
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyPerState}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "lua"
@@ -143,7 +362,7 @@ type result struct {
 
 // pushToLua pushes a Go value onto the Lua stack
 func pushToLua(L *C.lua_State, val interface{}) {
-	if val == nil {
+	if core.IsNil(val) {
 		C.lua_pushnil(L)
 		return
 	}
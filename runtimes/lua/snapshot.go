@@ -0,0 +1,178 @@
+//go:build runtime_lua
+// +build runtime_lua
+
+package lua
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// snapshotHeader starts every Lua state snapshot. The rest of a snapshot
+// is a chunk that sets each global it holds.
+const snapshotHeader = "-- polyglot lua snapshot\n"
+
+// snapshotScript serializes the globals code has defined as a chunk that
+// recreates them: data as literals and Lua functions as their bytecode.
+// The standard library, C functions, userdata and threads are left out.
+// Binary bytes are escaped so the chunk survives as a C string.
+const snapshotScript = `
+local skip = {
+	_G = true, _VERSION = true, arg = true,
+	coroutine = true, debug = true, io = true, math = true, os = true,
+	package = true, string = true, table = true, utf8 = true,
+}
+
+local function quote(s)
+	return '"' .. (s:gsub('[%c"\\\128-\255]', function(c)
+		return string.format("\\%03d", c:byte())
+	end)) .. '"'
+end
+
+local function serialize(value, path, seen)
+	local kind = type(value)
+	if kind == "string" then
+		return quote(value)
+	elseif kind == "boolean" then
+		return tostring(value)
+	elseif kind == "number" then
+		if value == math.mininteger then
+			return "math.mininteger"
+		elseif math.type(value) == "integer" then
+			return string.format("%d", value)
+		elseif value ~= value then
+			return "(0/0)"
+		elseif value == math.huge then
+			return "math.huge"
+		elseif value == -math.huge then
+			return "-math.huge"
+		end
+		return string.format("%.17g", value)
+	elseif kind == "function" then
+		local ok, code = pcall(string.dump, value)
+		if not ok then
+			return nil
+		end
+		for i = 1, math.huge do
+			local name = debug.getupvalue(value, i)
+			if name == nil then
+				break
+			elseif name ~= "_ENV" then
+				error("cannot snapshot " .. path .. ": closures are not supported", 0)
+			end
+		end
+		return "load(" .. quote(code) .. ", " .. quote("=" .. path) .. ", 'b')"
+	elseif kind == "table" then
+		if seen[value] then
+			error("cannot snapshot " .. path .. ": tables that contain themselves are not supported", 0)
+		end
+		seen[value] = true
+		local fields = {}
+		for key, item in pairs(value) do
+			local k = serialize(key, path, seen)
+			local v = serialize(item, path .. "." .. tostring(key), seen)
+			if k and v then
+				fields[#fields + 1] = "[" .. k .. "]=" .. v
+			end
+		end
+		seen[value] = nil
+		table.sort(fields)
+		return "{" .. table.concat(fields, ",") .. "}"
+	end
+	return nil
+end
+
+local names = {}
+for name in pairs(_G) do
+	if type(name) == "string" and not skip[name] then
+		names[#names + 1] = name
+	end
+end
+table.sort(names)
+
+local out = {"local load, math = load, math"}
+for _, name in ipairs(names) do
+	local value = serialize(_G[name], name, {})
+	if value then
+		out[#out + 1] = "_G[" .. quote(name) .. "]=" .. value
+	end
+end
+return table.concat(out, "\n")`
+
+// SnapshotState serializes the globals defined in a worker's session:
+// strings, numbers, booleans, tables of them and Lua functions. Functions
+// that close over local variables make it fail; C functions, userdata and
+// threads are left out.
+func (r *Runtime) SnapshotState(ctx context.Context) ([]byte, error) {
+	result, err := r.call(ctx, func(worker *Worker) (interface{}, error) {
+		return worker.Execute(snapshotScript)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot state: %w", err)
+	}
+	chunk, ok := result.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected snapshot of type %T", result)
+	}
+	return []byte(snapshotHeader + chunk), nil
+}
+
+// RestoreState restores a snapshot into one worker now, so a snapshot
+// that fails to load fails here, and into every other worker before its
+// next use, including workers started later
+func (r *Runtime) RestoreState(ctx context.Context, snapshot []byte) error {
+	chunk := string(snapshot)
+	if !strings.HasPrefix(chunk, snapshotHeader) {
+		return core.Errorf(core.CodeInvalidArgument, "not a Lua state snapshot")
+	}
+
+	r.mu.RLock()
+	if r.shutdown {
+		r.mu.RUnlock()
+		return fmt.Errorf("runtime is shutdown")
+	}
+	r.mu.RUnlock()
+
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.pool.Release(worker)
+
+	if _, err := worker.Execute(chunk); err != nil {
+		return fmt.Errorf("failed to restore state: %w", err)
+	}
+	worker.stateGen = r.pool.setSnapshot(chunk)
+	return nil
+}
+
+// setSnapshot makes chunk the state every worker restores, returning its
+// generation
+func (p *Pool) setSnapshot(chunk string) uint64 {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.snapshot = chunk
+	p.stateGen++
+	return p.stateGen
+}
+
+// restoreSnapshot brings worker up to the pool's latest snapshot
+func (p *Pool) restoreSnapshot(worker *Worker) error {
+	p.stateMu.Lock()
+	chunk, gen := p.snapshot, p.stateGen
+	p.stateMu.Unlock()
+
+	if worker.stateGen == gen {
+		return nil
+	}
+	if chunk != "" {
+		if _, err := worker.Execute(chunk); err != nil {
+			return fmt.Errorf("restore state: %w", err)
+		}
+	}
+	worker.stateGen = gen
+	return nil
+}
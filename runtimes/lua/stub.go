@@ -35,11 +35,56 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	return nil, fmt.Errorf("Lua runtime not enabled")
 }
 
+// ExecuteMapped returns an error
+func (r *Runtime) ExecuteMapped(ctx context.Context, code string, inputs map[string]interface{}, outputs []string) (map[string]interface{}, error) {
+	return nil, fmt.Errorf("Lua runtime not enabled")
+}
+
+// CallMulti returns an error
+func (r *Runtime) CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error) {
+	return nil, fmt.Errorf("Lua runtime not enabled")
+}
+
+// DefinedFunctions returns an error
+func (r *Runtime) DefinedFunctions(ctx context.Context) ([]core.FunctionInfo, error) {
+	return nil, fmt.Errorf("Lua runtime not enabled")
+}
+
+// ExecuteWithChanges returns an error
+func (r *Runtime) ExecuteWithChanges(ctx context.Context, code string) (interface{}, core.ScopeChanges, error) {
+	return nil, core.ScopeChanges{}, fmt.Errorf("Lua runtime not enabled")
+}
+
+// SnapshotState returns an error
+func (r *Runtime) SnapshotState(ctx context.Context) ([]byte, error) {
+	return nil, fmt.Errorf("Lua runtime not enabled")
+}
+
+// RestoreState returns an error
+func (r *Runtime) RestoreState(ctx context.Context, snapshot []byte) error {
+	return fmt.Errorf("Lua runtime not enabled")
+}
+
+// AvailableModules returns an error
+func (r *Runtime) AvailableModules(ctx context.Context) ([]string, error) {
+	return nil, fmt.Errorf("Lua runtime not enabled")
+}
+
+// Interrupt returns an error
+func (r *Runtime) Interrupt(executionID uint64) error {
+	return fmt.Errorf("Lua runtime not enabled")
+}
+
 // Shutdown does nothing
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities declares the concurrency model: each worker owns a Lua state
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyPerState}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "lua"
@@ -49,3 +94,8 @@ func (r *Runtime) Name() string {
 func (r *Runtime) Version() string {
 	return "stub (not enabled)"
 }
+
+// Stubbed reports that this runtime is a placeholder
+func (r *Runtime) Stubbed() bool {
+	return true
+}
@@ -12,9 +12,13 @@ package lua
 import "C"
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Worker represents a Lua state
@@ -23,6 +27,20 @@ type Worker struct {
 	state    *C.lua_State
 	mu       sync.Mutex
 	shutdown bool
+
+	// stateGen is the generation of the pool snapshot restored on the
+	// worker
+	stateGen uint64
+
+	// interruptMu guards the interrupt flags, and keeps Interrupt from
+	// touching the state outside an execution. armed is set while an
+	// execution holds the worker, and pending keeps an Interrupt that
+	// comes before its code runs for begin.
+	interruptMu sync.Mutex
+	armed       bool
+	running     bool
+	pending     bool
+	interrupted bool
 }
 
 // NewWorker creates a Lua worker
@@ -49,6 +67,7 @@ func (w *Worker) Initialize() error {
 
 	// Open standard libraries
 	C.luaL_openlibs(w.state)
+	C.luawrap_limit_depth(w.state, 0)
 
 	return nil
 }
@@ -65,6 +84,9 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	cCode := C.CString(code)
 	defer C.free(unsafe.Pointer(cCode))
 
+	w.begin()
+	defer w.end()
+
 	// Load and execute the code
 	if C.luaL_loadstring(w.state, cCode) != 0 {
 		err := C.GoString(C.luawrap_tostring(w.state, -1))
@@ -75,7 +97,7 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	if C.luawrap_pcall(w.state, 0, 1, 0) != 0 {
 		err := C.GoString(C.luawrap_tostring(w.state, -1))
 		C.luawrap_pop(w.state, 1)
-		return nil, fmt.Errorf("lua execution error: %s", err)
+		return nil, w.failure("execution", err)
 	}
 
 	// Get result from stack
@@ -85,8 +107,106 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	return result, nil
 }
 
+// ExecuteMapped sets inputs as globals, runs code and returns the named
+// output globals. Lua cannot tell an unset global from nil, so outputs
+// the code did not set are nil.
+func (w *Worker) ExecuteMapped(code string, inputs map[string]interface{}, outputs []string) (map[string]interface{}, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return nil, fmt.Errorf("worker is shutdown")
+	}
+
+	for name, value := range inputs {
+		cName := C.CString(name)
+		pushToLua(w.state, value)
+		C.lua_setglobal(w.state, cName)
+		C.free(unsafe.Pointer(cName))
+	}
+
+	cCode := C.CString(code)
+	defer C.free(unsafe.Pointer(cCode))
+
+	w.begin()
+	defer w.end()
+
+	if C.luaL_loadstring(w.state, cCode) != 0 {
+		err := C.GoString(C.luawrap_tostring(w.state, -1))
+		C.luawrap_pop(w.state, 1)
+		return nil, fmt.Errorf("lua load error: %s", err)
+	}
+
+	if C.luawrap_pcall(w.state, 0, 0, 0) != 0 {
+		err := C.GoString(C.luawrap_tostring(w.state, -1))
+		C.luawrap_pop(w.state, 1)
+		return nil, w.failure("execution", err)
+	}
+
+	values := make(map[string]interface{}, len(outputs))
+	for _, name := range outputs {
+		cName := C.CString(name)
+		C.lua_getglobal(w.state, cName)
+		C.free(unsafe.Pointer(cName))
+		values[name] = popFromLua(w.state, -1)
+		C.luawrap_pop(w.state, 1)
+	}
+	return values, nil
+}
+
+// captureLastExpr rewrites code to return its final expression, trying
+// the whole chunk and then its last line as an expression, much as the lua
+// prompt does. Code that already returns or ends in a statement is
+// unchanged.
+func (w *Worker) captureLastExpr(code string) string {
+	candidates := []string{"return " + code}
+	trimmed := strings.TrimRight(code, " \t\r\n;")
+	if i := strings.LastIndexByte(trimmed, '\n'); i >= 0 {
+		candidates = append(candidates, trimmed[:i+1]+"return "+trimmed[i+1:])
+	}
+	for _, candidate := range candidates {
+		if w.compiles(candidate) {
+			return candidate
+		}
+	}
+	return code
+}
+
+// compiles reports whether code loads as a Lua chunk
+func (w *Worker) compiles(code string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return false
+	}
+
+	cCode := C.CString(code)
+	defer C.free(unsafe.Pointer(cCode))
+
+	// Either the chunk or the error message is pushed
+	ok := C.luaL_loadstring(w.state, cCode) == 0
+	C.luawrap_pop(w.state, 1)
+	return ok
+}
+
 // Call invokes a Lua function
 func (w *Worker) Call(fn string, args ...interface{}) (interface{}, error) {
+	results, err := w.call(fn, 1, args...)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// CallMulti invokes a Lua function and returns all of its results
+func (w *Worker) CallMulti(fn string, args ...interface{}) ([]interface{}, error) {
+	return w.call(fn, C.LUA_MULTRET, args...)
+}
+
+// call invokes fn keeping nResults results, or all of them for
+// LUA_MULTRET
+func (w *Worker) call(fn string, nResults C.int, args ...interface{}) ([]interface{}, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -97,6 +217,8 @@ func (w *Worker) Call(fn string, args ...interface{}) (interface{}, error) {
 	cFn := C.CString(fn)
 	defer C.free(unsafe.Pointer(cFn))
 
+	base := C.lua_gettop(w.state)
+
 	// Get the function
 	C.lua_getglobal(w.state, cFn)
 
@@ -111,18 +233,119 @@ func (w *Worker) Call(fn string, args ...interface{}) (interface{}, error) {
 	}
 
 	// Call the function
+	w.begin()
+	defer w.end()
 	nArgs := C.int(len(args))
-	if C.luawrap_pcall(w.state, nArgs, 1, 0) != 0 {
+	if C.luawrap_pcall(w.state, nArgs, nResults, 0) != 0 {
 		err := C.GoString(C.luawrap_tostring(w.state, -1))
 		C.luawrap_pop(w.state, 1)
-		return nil, fmt.Errorf("lua call error: %s", err)
+		return nil, w.failure("call", err)
 	}
 
-	// Get result
-	result := popFromLua(w.state, -1)
-	C.luawrap_pop(w.state, 1)
+	// Get results, which sit above the stack's previous top
+	n := C.lua_gettop(w.state) - base
+	results := make([]interface{}, int(n))
+	for i := range results {
+		results[i] = popFromLua(w.state, base+1+C.int(i))
+	}
+	C.luawrap_pop(w.state, n)
 
-	return result, nil
+	return results, nil
+}
+
+// failure builds the error of a failed pcall from its message, marking
+// stack overflows, from the depth limit or Lua's own, as
+// core.ErrStackDepth
+func (w *Worker) failure(kind, msg string) error {
+	if w.wasInterrupted() {
+		return core.ErrInterrupted
+	}
+	err := fmt.Errorf("lua %s error: %s", kind, msg)
+	if strings.Contains(msg, "stack depth limit exceeded") || strings.Contains(msg, "stack overflow") {
+		return core.StackDepthError(err)
+	}
+	return err
+}
+
+// LimitStackDepth caps how deeply calls may nest; zero removes the cap
+func (w *Worker) LimitStackDepth(depth int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.shutdown && w.state != nil {
+		C.luawrap_limit_depth(w.state, C.int(depth))
+	}
+}
+
+// arm marks the worker as held by an execution that Interrupt may stop,
+// until the returned function is called
+func (w *Worker) arm() func() {
+	w.interruptMu.Lock()
+	w.armed, w.pending = true, false
+	w.interruptMu.Unlock()
+
+	return func() {
+		w.interruptMu.Lock()
+		w.armed, w.pending = false, false
+		w.interruptMu.Unlock()
+	}
+}
+
+// begin marks the start of code that Interrupt may stop, applying an
+// interrupt that came before it
+func (w *Worker) begin() {
+	w.interruptMu.Lock()
+	defer w.interruptMu.Unlock()
+	w.running = true
+	w.interrupted = w.pending
+	w.pending = false
+	if w.interrupted {
+		C.luawrap_interrupt(w.state)
+	}
+}
+
+// end marks the end of an execution, removing an interrupt hook that did
+// not fire before the code finished
+func (w *Worker) end() {
+	w.interruptMu.Lock()
+	defer w.interruptMu.Unlock()
+	w.running = false
+	C.luawrap_clear_interrupt(w.state)
+}
+
+func (w *Worker) wasInterrupted() bool {
+	w.interruptMu.Lock()
+	defer w.interruptMu.Unlock()
+	return w.interrupted
+}
+
+// Interrupt stops the running execution with a debug hook that raises an
+// error at the next instruction. Code blocked in a C function stops once
+// the function returns, and code that has not started yet stops as it
+// starts.
+func (w *Worker) Interrupt() {
+	w.interruptMu.Lock()
+	defer w.interruptMu.Unlock()
+
+	if w.running {
+		w.interrupted = true
+		C.luawrap_interrupt(w.state)
+	} else if w.armed {
+		w.pending = true
+	}
+}
+
+// Teardown runs code on the worker, unless ctx has ended, and shuts the
+// worker down. Code still running when ctx ends is interrupted.
+func (w *Worker) Teardown(ctx context.Context, code string) {
+	if code != "" && ctx.Err() == nil {
+		disarm := w.arm()
+		stop := context.AfterFunc(ctx, w.Interrupt)
+		w.Execute(code)
+		stop()
+		disarm()
+	}
+	w.Shutdown()
 }
 
 // Shutdown stops the worker
@@ -5,7 +5,9 @@ package php
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 	"sync"
@@ -45,14 +47,13 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 
 	r.config = config
 
-	// Determine pool size
-	poolSize := config.MaxConcurrency
-	if poolSize <= 0 {
-		poolSize = 4
-	}
+	size := poolSize(config)
 
 	// Initialize the pool
-	r.pool = NewPool(poolSize)
+	r.pool = NewPool(size, core.LimitsFor(config), config.OutputEncoding)
+	r.pool.opts = core.WorkerPoolOptionsFor("php", config, size)
+	r.pool.stdout, r.pool.stderr = core.OutputWriters(config)
+	r.pool.structured = config.StructuredOutput
 	if err := r.pool.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
@@ -71,20 +72,46 @@ func (r *Runtime) checkPHPAvailable() error {
 
 // Execute runs PHP code
 func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{}) (interface{}, error) {
+	return r.ExecuteWithInput(ctx, code, nil)
+}
+
+// ExecuteWithInput runs PHP code with stdin piped to the program, which
+// sees EOF once stdin is exhausted
+func (r *Runtime) ExecuteWithInput(ctx context.Context, code string, stdin io.Reader) (interface{}, error) {
 	r.mu.RLock()
 	if r.shutdown {
 		r.mu.RUnlock()
 		return nil, fmt.Errorf("runtime is shutdown")
 	}
+	capture := r.config.CaptureLastExpr
+	determinism, deterministic := core.DeterminismFor(r.config)
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	core.ReportWorker(ctx, worker.id)
 	defer r.pool.Release(worker)
 
+	captured := false
+	if capture {
+		code, captured = captureLastExpr(prepareCode(code))
+	}
+	if deterministic {
+		// PHP's clock cannot be replaced from userland, so only rand and
+		// mt_rand are made deterministic
+		code = fmt.Sprintf("mt_srand(%d); ", determinism.Seed) + prepareCode(code)
+	}
+
 	// Execute with context cancellation support
+	stdout, stderr := core.OutputFrom(ctx)
 	resultChan := make(chan result, 1)
 	go func() {
-		res, err := worker.Execute(code, args...)
+		res, err := worker.ExecuteWithInput(code, stdin, stdout, stderr)
+		if captured && err == nil {
+			res = capturedResult(res)
+		}
 		resultChan <- result{value: res, err: err}
 	}()
 
@@ -105,7 +132,10 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.pool.Release(worker)
 
 	// Call with context cancellation support
@@ -123,6 +153,34 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 }
 
+// poolSize returns the number of workers for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 4
+	}
+	return config.MaxConcurrency
+}
+
+// Reconfigure resizes the worker pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.pool.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
+	}
+	r.config = config
+	return nil
+}
+
 // Shutdown stops the runtime
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.mu.Lock()
@@ -141,6 +199,21 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// PoolStats reports the worker pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool == nil {
+		return core.PoolStats{}
+	}
+	return r.pool.Stats()
+}
+
+// Capabilities declares the concurrency model: each execution runs its own PHP process
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyParallel}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "php"
@@ -199,3 +272,138 @@ func extractResult(output string) interface{} {
 	// For now, return as string
 	return output
 }
+
+// phpStatementKeywords start statements that have no value
+var phpStatementKeywords = map[string]bool{
+	"abstract": true, "break": true, "class": true, "const": true, "continue": true,
+	"declare": true, "do": true, "echo": true, "enum": true, "final": true,
+	"for": true, "foreach": true, "function": true, "global": true, "goto": true,
+	"if": true, "interface": true, "namespace": true, "return": true, "static": true,
+	"switch": true, "throw": true, "trait": true, "try": true, "unset": true,
+	"use": true, "while": true,
+}
+
+// captureLastExpr rewrites code so its final statement, when it is an
+// expression, is echoed as JSON on a line of its own. It reports whether
+// the code was rewritten.
+func captureLastExpr(code string) (string, bool) {
+	trimmed := strings.TrimRight(code, " \t\r\n;")
+	body, last := splitLastStatement(trimmed)
+	last = trimLeadingComments(last)
+	if last == "" {
+		return code, false
+	}
+
+	word := strings.ToLower(last)
+	if end := strings.IndexFunc(word, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}); end >= 0 {
+		word = word[:end]
+	}
+	if phpStatementKeywords[word] {
+		return code, false
+	}
+
+	return fmt.Sprintf("%s\necho \"\\n\", json_encode(%s);", body, last), true
+}
+
+// splitLastStatement splits code after its last top-level semicolon or
+// closing brace, skipping strings and comments
+func splitLastStatement(code string) (string, string) {
+	split, depth := 0, 0
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '\'' || c == '"':
+			for i++; i < len(code) && code[i] != c; i++ {
+				if code[i] == '\\' {
+					i++
+				}
+			}
+		case c == '#' || c == '/' && i+1 < len(code) && code[i+1] == '/':
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(code) && code[i+1] == '*':
+			end := strings.Index(code[i+2:], "*/")
+			if end < 0 {
+				return code[:split], code[split:]
+			}
+			i += end + 3
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == '}':
+			depth--
+			if depth == 0 {
+				split = i + 1
+			}
+		case c == ';' && depth == 0:
+			split = i + 1
+		}
+	}
+	return code[:split], code[split:]
+}
+
+// trimLeadingComments removes whitespace and comments before a statement
+func trimLeadingComments(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		switch {
+		case strings.HasPrefix(stmt, "#"), strings.HasPrefix(stmt, "//"):
+			end := strings.IndexByte(stmt, '\n')
+			if end < 0 {
+				return ""
+			}
+			stmt = stmt[end+1:]
+		case strings.HasPrefix(stmt, "/*"):
+			end := strings.Index(stmt, "*/")
+			if end < 0 {
+				return ""
+			}
+			stmt = stmt[end+2:]
+		default:
+			return stmt
+		}
+	}
+}
+
+// capturedResult decodes the JSON value echoed by captured code from the
+// last line of its output
+func capturedResult(output interface{}) interface{} {
+	text, ok := output.(string)
+	if !ok {
+		return output
+	}
+	line := text[strings.LastIndexByte(text, '\n')+1:]
+
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return output
+	}
+	return jsonNumbers(value)
+}
+
+// jsonNumbers replaces json.Number values with int64 when integral and
+// float64 otherwise
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = jsonNumbers(elem)
+		}
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = jsonNumbers(elem)
+		}
+	}
+	return v
+}
@@ -6,6 +6,7 @@ package php
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/griffincancode/polyglot.js/core"
 )
@@ -30,6 +31,11 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 	return nil, fmt.Errorf("PHP runtime not enabled")
 }
 
+// ExecuteWithInput is not available
+func (r *Runtime) ExecuteWithInput(ctx context.Context, code string, stdin io.Reader) (interface{}, error) {
+	return nil, fmt.Errorf("PHP runtime not enabled")
+}
+
 // Call returns an error
 func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (interface{}, error) {
 	return nil, fmt.Errorf("PHP runtime not enabled")
@@ -40,6 +46,11 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities declares the concurrency model: each execution runs its own PHP process
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyParallel}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "php"
@@ -49,3 +60,8 @@ func (r *Runtime) Name() string {
 func (r *Runtime) Version() string {
 	return "stub (not enabled)"
 }
+
+// Stubbed reports that this runtime is a placeholder
+func (r *Runtime) Stubbed() bool {
+	return true
+}
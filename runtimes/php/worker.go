@@ -5,10 +5,16 @@ package php
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Worker represents a PHP execution context
@@ -17,6 +23,14 @@ type Worker struct {
 	mu       sync.Mutex
 	shutdown bool
 	phpPath  string
+	limits   core.ResourceLimits
+	encoding core.OutputEncoding
+	stdout   io.Writer
+	stderr   io.Writer
+
+	// structured returns the delimited JSON result programs print, for
+	// StructuredOutput
+	structured bool
 }
 
 // NewWorker creates a PHP worker
@@ -47,6 +61,13 @@ func (w *Worker) Initialize() error {
 
 // Execute runs PHP code
 func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error) {
+	return w.ExecuteWithInput(code, nil, nil, nil)
+}
+
+// ExecuteWithInput runs PHP code with stdin as the program's standard
+// input, which reads as empty when stdin is nil. What the program prints
+// is also written to stdout and stderr, either of which may be nil.
+func (w *Worker) ExecuteWithInput(code string, stdin io.Reader, stdout, stderr io.Writer) (interface{}, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -58,13 +79,19 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	code = prepareCode(code)
 
 	// Execute PHP code using -r flag
-	var stdout, stderr bytes.Buffer
+	var out, errOut bytes.Buffer
 	cmd := exec.Command(w.phpPath, "-r", code)
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		errMsg := stderr.String()
+	cmd.Stdin = stdin
+	cmd.Stdout = core.TeeWriter(&out, core.TeeWriter(w.stdout, stdout))
+	cmd.Stderr = core.TeeWriter(&errOut, core.TeeWriter(w.stderr, stderr))
+
+	if err := core.RunLimited(cmd, "php", w.limits); err != nil {
+		var exceeded *core.ResourceExceededError
+		var crashed *core.RuntimeCrashedError
+		if errors.As(err, &exceeded) || errors.As(err, &crashed) {
+			return nil, err
+		}
+		errMsg := errOut.String()
 		if errMsg != "" {
 			return nil, fmt.Errorf("execution failed: %s", errMsg)
 		}
@@ -72,7 +99,15 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	}
 
 	// Extract result from output
-	output := stdout.String()
+	if w.structured {
+		if result, found, err := core.ParseStructuredOutput(out.Bytes()); found {
+			return result, err
+		}
+	}
+	output, raw := core.DecodeOutput(out.Bytes(), w.encoding)
+	if raw != nil {
+		return raw, nil
+	}
 	return extractResult(output), nil
 }
 
@@ -88,7 +123,11 @@ func (w *Worker) Call(fn string, args ...interface{}) (interface{}, error) {
 	// Build function call string
 	var argStrs []string
 	for _, arg := range args {
-		argStrs = append(argStrs, fmt.Sprintf("%v", arg))
+		literal, err := phpLiteral(arg)
+		if err != nil {
+			return nil, err
+		}
+		argStrs = append(argStrs, literal)
 	}
 
 	code := fmt.Sprintf("echo %s(%s);", fn, strings.Join(argStrs, ", "))
@@ -103,3 +142,31 @@ func (w *Worker) Shutdown() {
 	defer w.mu.Unlock()
 	w.shutdown = true
 }
+
+// phpLiteral formats a Go value as a PHP expression. nil becomes null, and
+// values without a literal form are passed through json_decode.
+func phpLiteral(arg interface{}) (string, error) {
+	if core.IsNil(arg) {
+		return "null", nil
+	}
+	switch v := arg.(type) {
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return phpString(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), nil
+	case float32, float64:
+		return fmt.Sprintf("%v", v), nil
+	}
+	encoded, err := json.Marshal(arg)
+	if err != nil {
+		return "", fmt.Errorf("failed to pass %T to PHP: %w", arg, err)
+	}
+	return "json_decode(" + phpString(string(encoded)) + ", true)", nil
+}
+
+// phpString quotes s as a single-quoted PHP string
+func phpString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
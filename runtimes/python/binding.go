@@ -0,0 +1,65 @@
+//go:build runtime_python
+// +build runtime_python
+
+package python
+
+// #include <Python.h>
+import "C"
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// binding is what Python code calling back into Go can reach during one
+// execution
+type binding struct {
+	ctx     context.Context
+	stdout  io.Writer
+	stderr  io.Writer
+	handles *core.Handles
+}
+
+// bindings holds the binding of each state running an execution that
+// streams output, uses handles or reports progress
+var bindings = struct {
+	mu     sync.Mutex
+	states map[*State]*binding
+}{states: make(map[*State]*binding)}
+
+// bind makes the output writers, handles and progress function on ctx
+// reachable from code
+// running on s, returning a function that removes them
+func (s *State) bind(ctx context.Context) func() {
+	stdout, stderr := core.OutputFrom(ctx)
+	handles := core.HandlesFrom(ctx)
+	if stdout == nil && stderr == nil && handles == nil && core.ProgressFrom(ctx) == nil {
+		return func() {}
+	}
+
+	bindings.mu.Lock()
+	bindings.states[s] = &binding{ctx: ctx, stdout: stdout, stderr: stderr, handles: handles}
+	bindings.mu.Unlock()
+
+	return func() {
+		bindings.mu.Lock()
+		delete(bindings.states, s)
+		bindings.mu.Unlock()
+	}
+}
+
+// boundTo returns the binding of the state running code on thread tid
+func boundTo(tid C.ulong) *binding {
+	bindings.mu.Lock()
+	defer bindings.mu.Unlock()
+
+	for s, b := range bindings.states {
+		if s.runningOn(tid) {
+			return b
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@
 package python
 
 // #include <Python.h>
+// #include <datetime.h>
 // #include <stdlib.h>
 //
 // // Helper functions for type checking
@@ -31,13 +32,39 @@ package python
 // static int py_is_tuple(PyObject *obj) {
 //     return PyTuple_Check(obj);
 // }
+// static int py_is_bytes(PyObject *obj) {
+//     return PyBytes_Check(obj);
+// }
+//
+// // py_datetime_type returns the borrowed datetime.datetime type, importing
+// // the datetime C API on first use. Checking against it rather than the
+// // module attribute also matches datetimes when determinism has replaced
+// // datetime.datetime with a subclass.
+// static PyObject *py_datetime_type(void) {
+//     if (PyDateTimeAPI == NULL) {
+//         PyDateTime_IMPORT;
+//         if (PyDateTimeAPI == NULL) {
+//             PyErr_Clear();
+//             return NULL;
+//         }
+//     }
+//     return (PyObject *)PyDateTimeAPI->DateTimeType;
+// }
+// static int py_is_datetime(PyObject *obj) {
+//     return py_datetime_type() != NULL && PyDateTime_Check(obj);
+// }
 import "C"
 
-import "unsafe"
+import (
+	"time"
+	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
 
 // ToPython converts Go value to Python object (caller must hold GIL)
 func ToPython(val interface{}) *C.PyObject {
-	if val == nil {
+	if core.IsNil(val) {
 		C.Py_IncRef(C.Py_None)
 		return C.Py_None
 	}
@@ -62,6 +89,12 @@ func ToPython(val interface{}) *C.PyObject {
 		return sliceToPy(v)
 	case map[string]interface{}:
 		return mapToPy(v)
+	case []byte:
+		return bytesToPy(v)
+	case time.Time:
+		return timeToPy(v)
+	case *time.Time:
+		return timeToPy(*v)
 	default:
 		C.Py_IncRef(C.Py_None)
 		return C.Py_None
@@ -108,6 +141,16 @@ func FromPython(obj *C.PyObject) interface{} {
 		return pyToSlice(obj)
 	}
 
+	// Check bytes, as returned for images
+	if C.py_is_bytes(obj) != 0 {
+		return pyToBytes(obj)
+	}
+
+	// Check datetime
+	if t, ok := pyToTime(obj); ok {
+		return t
+	}
+
 	// Fallback: try to convert to string representation
 	return nil
 }
@@ -131,6 +174,93 @@ func pyToString(pyStr *C.PyObject) string {
 	return C.GoString(cStr)
 }
 
+// bytesToPy converts Go byte slice to Python bytes
+func bytesToPy(b []byte) *C.PyObject {
+	if len(b) == 0 {
+		return C.PyBytes_FromStringAndSize(nil, 0)
+	}
+	return C.PyBytes_FromStringAndSize((*C.char)(unsafe.Pointer(&b[0])), C.Py_ssize_t(len(b)))
+}
+
+// pyToBytes converts Python bytes to a Go byte slice
+func pyToBytes(pyBytes *C.PyObject) []byte {
+	var data *C.char
+	var size C.Py_ssize_t
+	if C.PyBytes_AsStringAndSize(pyBytes, &data, &size) != 0 {
+		C.PyErr_Clear()
+		return nil
+	}
+	return C.GoBytes(unsafe.Pointer(data), C.int(size))
+}
+
+// pyTimeLayout is the ISO 8601 form datetime.fromisoformat accepts on
+// every supported Python version; datetime keeps microseconds only
+const pyTimeLayout = "2006-01-02T15:04:05.000000-07:00"
+
+// getAttr returns a new reference to obj.name, or nil
+func getAttr(obj *C.PyObject, name string) *C.PyObject {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	attr := C.PyObject_GetAttrString(obj, cName)
+	if attr == nil {
+		C.PyErr_Clear()
+	}
+	return attr
+}
+
+// timeToPy converts a Go time to a timezone-aware Python datetime
+func timeToPy(t time.Time) *C.PyObject {
+	class := C.py_datetime_type()
+	if class == nil {
+		return stringToPy(t.Format(time.RFC3339Nano))
+	}
+
+	fromISO := getAttr(class, "fromisoformat")
+	if fromISO == nil {
+		return stringToPy(t.Format(time.RFC3339Nano))
+	}
+	defer C.Py_DecRef(fromISO)
+
+	args := C.PyTuple_New(1)
+	C.PyTuple_SetItem(args, 0, stringToPy(t.Format(pyTimeLayout)))
+	defer C.Py_DecRef(args)
+
+	result := C.PyObject_CallObject(fromISO, args)
+	if result == nil {
+		C.PyErr_Clear()
+		return stringToPy(t.Format(time.RFC3339Nano))
+	}
+	return result
+}
+
+// pyToTime converts a Python datetime to a Go time. Naive datetimes,
+// which carry no timezone, are read as UTC.
+func pyToTime(obj *C.PyObject) (time.Time, bool) {
+	if C.py_is_datetime(obj) == 0 {
+		return time.Time{}, false
+	}
+
+	isoformat := getAttr(obj, "isoformat")
+	if isoformat == nil {
+		return time.Time{}, false
+	}
+	defer C.Py_DecRef(isoformat)
+
+	formatted := C.PyObject_CallObject(isoformat, nil)
+	if formatted == nil {
+		C.PyErr_Clear()
+		return time.Time{}, false
+	}
+	defer C.Py_DecRef(formatted)
+
+	text := pyToString(formatted)
+	if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+		return t, true
+	}
+	t, err := time.Parse("2006-01-02T15:04:05.999999999", text)
+	return t, err == nil
+}
+
 // sliceToPy converts Go slice to Python list
 func sliceToPy(slice []interface{}) *C.PyObject {
 	pyList := C.PyList_New(C.Py_ssize_t(len(slice)))
@@ -146,6 +276,11 @@ func sliceToPy(slice []interface{}) *C.PyObject {
 	return pyList
 }
 
+// isTuple reports whether obj is a tuple (caller must hold GIL)
+func isTuple(obj *C.PyObject) bool {
+	return C.py_is_tuple(obj) != 0
+}
+
 // pyToSlice converts Python list or tuple to Go slice
 func pyToSlice(pyObj *C.PyObject) []interface{} {
 	var size C.Py_ssize_t
@@ -0,0 +1,80 @@
+//go:build runtime_python
+// +build runtime_python
+
+package python
+
+// #include <Python.h>
+// #include <stdlib.h>
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// determinismScript patches time.time, time.time_ns and datetime's now and
+// today to read a frozen clock, once per interpreter, then resets the
+// clock and seeds random. The patches are shared by every state since the
+// modules are.
+const determinismScript = `
+def __polyglot_determinize(seed, now):
+    import builtins, datetime, random, time
+    state = getattr(builtins, "__polyglot_determinism__", None)
+    if state is None:
+        state = builtins.__polyglot_determinism__ = {"now": now}
+        time.time = lambda: state["now"]
+        time.time_ns = lambda: int(state["now"] * 1e9)
+
+        class FixedDateTime(datetime.datetime):
+            @classmethod
+            def now(cls, tz=None):
+                return cls.fromtimestamp(state["now"], tz)
+
+            @classmethod
+            def utcnow(cls):
+                return cls.utcfromtimestamp(state["now"])
+
+            @classmethod
+            def today(cls):
+                return cls.fromtimestamp(state["now"])
+
+        class FixedDate(datetime.date):
+            @classmethod
+            def today(cls):
+                return cls.fromtimestamp(state["now"])
+
+        datetime.datetime = FixedDateTime
+        datetime.date = FixedDate
+    state["now"] = now
+    random.seed(seed)
+
+__polyglot_determinize(%d, %v)
+del __polyglot_determinize
+`
+
+// hashSeedEnv disables hash randomization, so set and dict-of-set
+// ordering is stable, when set before the interpreter starts
+const hashSeedEnv = "PYTHONHASHSEED"
+
+// Determinize seeds random and freezes the clock for the next execution
+func (s *State) Determinize(d core.Determinism) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return ErrShutdown
+	}
+
+	gil := AcquireGIL()
+	defer gil.Release()
+
+	cScript := C.CString(fmt.Sprintf(determinismScript, d.Seed, d.UnixSeconds()))
+	defer C.free(unsafe.Pointer(cScript))
+
+	if C.PyRun_SimpleString(cScript) != 0 {
+		return fmt.Errorf("%w: failed to apply deterministic mode", ErrExecFailed)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import "C"
 
 import (
 	"errors"
+	"runtime"
 	"unsafe"
 )
 
@@ -16,8 +17,11 @@ type GILGuard struct {
 	state C.PyGILState_STATE
 }
 
-// AcquireGIL acquires the GIL for the current thread
+// AcquireGIL acquires the GIL for the current thread. The goroutine stays
+// on that thread until Release, since the GIL state belongs to the thread
+// and cgo calls may otherwise run on different ones.
 func AcquireGIL() *GILGuard {
+	runtime.LockOSThread()
 	state := C.PyGILState_Ensure()
 	return &GILGuard{state: state}
 }
@@ -25,6 +29,7 @@ func AcquireGIL() *GILGuard {
 // Release releases the GIL
 func (g *GILGuard) Release() {
 	C.PyGILState_Release(g.state)
+	runtime.UnlockOSThread()
 }
 
 // SafeDecRef safely decrements Python object reference count
@@ -0,0 +1,61 @@
+//go:build runtime_python
+// +build runtime_python
+
+package python
+
+// #include <Python.h>
+import "C"
+
+import (
+	"encoding/json"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// polyglotInvokeHandle is called by polyglot.Handle methods. It invokes
+// the method on the Go object behind handle using the handles of the
+// execution running on thread tid. The GIL is released while Go runs.
+//
+//export polyglotInvokeHandle
+func polyglotInvokeHandle(tid C.ulong, handle *C.char, method *C.char, args *C.PyObject) *C.PyObject {
+	b := boundTo(tid)
+	if b == nil || b.handles == nil {
+		raise("no Go handles are available to this execution")
+		return nil
+	}
+
+	goArgs := pyToSlice(args)
+	name := C.GoString(method)
+
+	save := C.PyEval_SaveThread()
+	result, err := b.handles.Invoke(b.ctx, core.Handle(C.GoString(handle)), name, goArgs...)
+	C.PyEval_RestoreThread(save)
+
+	if err == nil {
+		result, err = pythonValue(result)
+	}
+	if err != nil {
+		raise(err.Error())
+		return nil
+	}
+	return ToPython(result)
+}
+
+// pythonValue converts a method result ToPython cannot represent, such as
+// a struct or typed slice, to its generic JSON form
+func pythonValue(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, string, int, int64, float64, bool, []interface{}, map[string]interface{}:
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, core.Errorf(core.CodeInternal, "cannot convert %T result: %w", v, err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, core.Errorf(core.CodeInternal, "cannot convert %T result: %w", v, err)
+	}
+	return generic, nil
+}
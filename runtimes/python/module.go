@@ -0,0 +1,167 @@
+//go:build runtime_python
+// +build runtime_python
+
+package python
+
+/*
+#define PY_SSIZE_T_CLEAN
+#include <Python.h>
+#include <stdlib.h>
+
+extern int polyglotWriteOutput(unsigned long tid, int stream, char *data, int n);
+extern PyObject* polyglotInvokeHandle(unsigned long tid, char *handle, char *method, PyObject *args);
+extern void polyglotReportProgress(unsigned long tid, double fraction, char *message, int n);
+
+static PyObject* polyglot_module_write(PyObject *self, PyObject *args) {
+	int stream;
+	const char *data;
+	Py_ssize_t n;
+	if (!PyArg_ParseTuple(args, "is#", &stream, &data, &n)) {
+		return NULL;
+	}
+	int consumed = polyglotWriteOutput(PyThread_get_thread_ident(), stream, (char *)data, (int)n);
+	return PyBool_FromLong(consumed);
+}
+
+static PyObject* polyglot_module_invoke(PyObject *self, PyObject *args) {
+	const char *handle;
+	const char *method;
+	PyObject *callArgs;
+	if (!PyArg_ParseTuple(args, "ssO!", &handle, &method, &PyTuple_Type, &callArgs)) {
+		return NULL;
+	}
+	return polyglotInvokeHandle(PyThread_get_thread_ident(), (char *)handle, (char *)method, callArgs);
+}
+
+static PyObject* polyglot_module_progress(PyObject *self, PyObject *args) {
+	double fraction;
+	const char *message;
+	Py_ssize_t n;
+	if (!PyArg_ParseTuple(args, "ds#", &fraction, &message, &n)) {
+		return NULL;
+	}
+	polyglotReportProgress(PyThread_get_thread_ident(), fraction, (char *)message, (int)n);
+	Py_RETURN_NONE;
+}
+
+static PyMethodDef polyglot_module_methods[] = {
+	{"write", polyglot_module_write, METH_VARARGS, "Write to the streamed output of the running execution"},
+	{"invoke", polyglot_module_invoke, METH_VARARGS, "Invoke a method of a Go object by handle"},
+	{"progress", polyglot_module_progress, METH_VARARGS, "Report progress of the running execution"},
+	{NULL, NULL, 0, NULL}
+};
+
+static struct PyModuleDef polyglot_module = {
+	PyModuleDef_HEAD_INIT, "_polyglot", NULL, -1, polyglot_module_methods
+};
+
+static int polyglot_register_module(void) {
+	PyObject *module = PyModule_Create(&polyglot_module);
+	if (module == NULL) {
+		return -1;
+	}
+	int rc = PyDict_SetItemString(PyImport_GetModuleDict(), "_polyglot", module);
+	Py_DECREF(module);
+	return rc;
+}
+
+static int polyglot_running_on(unsigned long *tid, unsigned long thread) {
+	return __atomic_load_n(tid, __ATOMIC_SEQ_CST) == thread;
+}
+
+static void polyglot_raise(const char *message) {
+	PyErr_SetString(PyExc_RuntimeError, message);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// moduleScript wraps sys.stdout and sys.stderr so writes from executions
+// with streamed output reach their writers, and defines the polyglot
+// module through which code uses Go objects by handle, reports progress
+// and returns images
+const moduleScript = `
+import sys, types, _polyglot
+
+class _PolyglotOutput:
+    def __init__(self, stream, fallback):
+        self._stream = stream
+        self._fallback = fallback
+
+    def write(self, s):
+        if not _polyglot.write(self._stream, str(s)) and self._fallback is not None:
+            self._fallback.write(s)
+        return len(s)
+
+    def flush(self):
+        if self._fallback is not None:
+            self._fallback.flush()
+
+    def __getattr__(self, name):
+        return getattr(self._fallback, name)
+
+sys.stdout = _PolyglotOutput(1, sys.stdout)
+sys.stderr = _PolyglotOutput(2, sys.stderr)
+
+class Handle:
+    """A Go object passed in by handle; calling a method invokes it in Go"""
+
+    def __init__(self, handle):
+        self._handle = str(handle)
+
+    def __getattr__(self, name):
+        if name.startswith('_'):
+            raise AttributeError(name)
+        handle = self._handle
+        return lambda *args: _polyglot.invoke(handle, name, args)
+
+    def __repr__(self):
+        return 'Handle(%r)' % self._handle
+
+def progress(fraction, message=''):
+    """Report the fraction of work done, from 0 to 1, and the current step"""
+    _polyglot.progress(float(fraction), str(message))
+
+def image(data, mime_type='image/png'):
+    """Return image bytes, such as a chart saved with savefig, for the
+    frontend to show inline"""
+    return {'__polyglot_image__': str(mime_type), 'data': bytes(data)}
+
+polyglot = types.ModuleType('polyglot')
+polyglot.Handle = Handle
+polyglot.progress = progress
+polyglot.image = image
+sys.modules['polyglot'] = polyglot
+del polyglot
+`
+
+// installModule sets up output streaming and the polyglot module. The
+// caller must hold the GIL.
+func installModule() error {
+	if C.polyglot_register_module() != 0 {
+		return fmt.Errorf("failed to register polyglot module: %s", GetError())
+	}
+
+	cScript := C.CString(moduleScript)
+	defer C.free(unsafe.Pointer(cScript))
+	if C.PyRun_SimpleStringFlags(cScript, nil) != 0 {
+		return fmt.Errorf("failed to install polyglot module")
+	}
+	return nil
+}
+
+// runningOn reports whether s is running code on thread tid
+func (s *State) runningOn(tid C.ulong) bool {
+	return C.polyglot_running_on(&s.threadID, tid) != 0
+}
+
+// raise sets a Python RuntimeError. The caller must hold the GIL.
+func raise(message string) {
+	cMessage := C.CString(message)
+	defer C.free(unsafe.Pointer(cMessage))
+	C.polyglot_raise(cMessage)
+}
@@ -0,0 +1,37 @@
+//go:build runtime_python
+// +build runtime_python
+
+package python
+
+// #include <Python.h>
+import "C"
+
+import (
+	"io"
+	"unsafe"
+)
+
+// polyglotWriteOutput is called by sys.stdout and sys.stderr writes. It
+// sends data to the writer of the execution running on thread tid and
+// reports whether there was one; otherwise Python writes it as usual.
+//
+//export polyglotWriteOutput
+func polyglotWriteOutput(tid C.ulong, stream C.int, data *C.char, n C.int) C.int {
+	b := boundTo(tid)
+	if b == nil {
+		return 0
+	}
+
+	var w io.Writer
+	switch stream {
+	case 1:
+		w = b.stdout
+	case 2:
+		w = b.stderr
+	}
+	if w == nil {
+		return 0
+	}
+	w.Write(C.GoBytes(unsafe.Pointer(data), n))
+	return 1
+}
@@ -4,119 +4,217 @@
 package python
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Pool manages Python execution states
 type Pool struct {
-	states chan *State
-	all    []*State
-	size   int
-	mu     sync.RWMutex
+	states *core.WorkerPool[*State]
+	mu     sync.Mutex
 	closed bool
+
+	// preimports, initCode and snapshot set up each new state
+	preimports []string
+	initCode   string
+	snapshot   []byte
+
+	// shutdownCode runs on each state before it shuts down, stopped when
+	// closeCtx ends
+	shutdownCode string
+	closeCtx     context.Context
+
+	// thread runs the states' native work when the runtime is single
+	// threaded; nil runs it on the caller's goroutine
+	thread *core.ThreadDispatcher
 }
 
 // NewPool creates a state pool
-func NewPool(size int) *Pool {
-	if size <= 0 {
-		size = 4
-	}
-	return &Pool{
-		states: make(chan *State, size),
-		all:    make([]*State, 0, size),
-		size:   size,
-		closed: false,
-	}
+func NewPool() *Pool {
+	return &Pool{}
 }
 
-// Initialize creates states
-func (p *Pool) Initialize(size int) error {
+// Initialize creates states, importing preimports into each, running
+// initCode and then restoring snapshot
+func (p *Pool) Initialize(opts core.WorkerPoolOptions, initCode string, snapshot []byte, preimports ...string) error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	if p.closed {
+		p.mu.Unlock()
 		return fmt.Errorf("pool is closed")
 	}
+	p.preimports = preimports
+	p.initCode = initCode
+	p.snapshot = snapshot
+	p.mu.Unlock()
+
+	states, err := core.NewWorkerPoolWith(opts, p.newState, p.teardown)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.states = states
+	p.mu.Unlock()
+	return nil
+}
 
-	if size <= 0 {
-		size = 4
+// newState creates a state and sets it up
+func (p *Pool) newState(id int) (*State, error) {
+	p.mu.Lock()
+	preimports, initCode, snapshot := p.preimports, p.initCode, p.snapshot
+	p.mu.Unlock()
+
+	state := NewState(id)
+	err := p.thread.Do(func() error {
+		err := state.Initialize()
+		if err == nil {
+			err = state.Preimport(preimports)
+		}
+		if err == nil {
+			err = state.Setup(initCode)
+		}
+		if err == nil && len(snapshot) > 0 {
+			err = state.Restore(snapshot)
+		}
+		if err != nil {
+			state.Shutdown()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return state, nil
+}
 
-	p.size = size
-	p.states = make(chan *State, size)
-	p.all = make([]*State, 0, size)
+// Restore restores snapshot into every state, waiting until ctx ends for
+// those in use to be released. States started later restore it too. It
+// stops at the first state that fails.
+func (p *Pool) Restore(ctx context.Context, snapshot []byte) error {
+	p.mu.Lock()
+	p.snapshot = snapshot
+	p.mu.Unlock()
 
-	for i := 0; i < size; i++ {
-		state := NewState(i)
-		if err := state.Initialize(); err != nil {
-			// Clean up already created states
-			for _, s := range p.all {
-				s.Shutdown()
-			}
-			return fmt.Errorf("failed to initialize state %d: %w", i, err)
+	var acquired []*State
+	defer func() {
+		for _, state := range acquired {
+			p.Release(state)
+		}
+	}()
+
+	for len(acquired) < p.states.Size() {
+		state, err := p.Acquire(ctx)
+		if err != nil {
+			return err
 		}
-		p.all = append(p.all, state)
-		p.states <- state
+		acquired = append(acquired, state)
 	}
 
-	return nil
+	return p.thread.Do(func() error {
+		for _, state := range acquired {
+			if err := state.Restore(snapshot); err != nil {
+				return fmt.Errorf("failed to restore state %d: %w", state.ID(), err)
+			}
+		}
+		return nil
+	})
 }
 
-// Acquire gets a state from the pool (blocks until available)
-func (p *Pool) Acquire() *State {
-	p.mu.RLock()
-	if p.closed {
-		p.mu.RUnlock()
-		return nil
+// Acquire gets a state from the pool, waiting until ctx ends
+func (p *Pool) Acquire(ctx context.Context) (*State, error) {
+	state, err := p.states.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire state: %w", err)
 	}
-	p.mu.RUnlock()
-
-	return <-p.states
+	return state, nil
 }
 
 // Release returns a state to the pool
 func (p *Pool) Release(state *State) {
-	if state == nil {
-		return
-	}
+	p.states.Release(state)
+}
+
+// Resize changes the pool's maximum and minimum states
+func (p *Pool) Resize(size, min int) error {
+	p.mu.Lock()
+	states := p.states
+	p.mu.Unlock()
 
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+	if states == nil {
+		return fmt.Errorf("pool is not initialized")
+	}
+	return states.Resize(size, min)
+}
 
-	if !p.closed {
-		p.states <- state
+// Replace retires state, whose code outlived its interrupt and so could
+// not be reset, and releases a fresh state with the same setup in its
+// place. If the fresh state cannot be created the pool shrinks by one.
+func (p *Pool) Replace(state *State) error {
+	p.thread.Do(func() error {
+		state.Retire()
+		return nil
+	})
+	if err := p.states.Replace(state); err != nil {
+		return fmt.Errorf("failed to replace state %d: %w", state.ID(), err)
 	}
+	return nil
 }
 
-// Size returns the pool size
+// Size returns the number of live states
 func (p *Pool) Size() int {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	return p.size
+	return p.states.Size()
 }
 
-// Close shuts down the pool
-func (p *Pool) Close() {
+// Stats reports the pool's states and how callers have used them
+func (p *Pool) Stats() core.PoolStats {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	states := p.states
+	p.mu.Unlock()
+
+	if states == nil {
+		return core.PoolStats{}
+	}
+	return states.Stats()
+}
+
+// Close shuts down the pool. Idle states run the shutdown code and shut
+// down now, and states in use once they are released; the shutdown code
+// is interrupted when ctx ends.
+func (p *Pool) Close(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
+	p.mu.Lock()
 	if p.closed {
+		p.mu.Unlock()
 		return
 	}
-
 	p.closed = true
+	p.closeCtx = ctx
+	states := p.states
+	p.mu.Unlock()
 
-	// Don't close the channel - just mark as closed
-	// Drain all states from channel
-	for len(p.states) > 0 {
-		<-p.states
+	if states != nil {
+		states.Close()
 	}
+}
 
-	// Shutdown all states
-	for _, state := range p.all {
-		state.Shutdown()
+// teardown runs the shutdown code on state and shuts it down, stopping
+// the code when the context given to Close ends
+func (p *Pool) teardown(state *State) {
+	p.mu.Lock()
+	ctx := p.closeCtx
+	p.mu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	p.all = nil
+	p.thread.Do(func() error {
+		state.Teardown(ctx, p.shutdownCode)
+		return nil
+	})
 }
@@ -0,0 +1,34 @@
+//go:build runtime_python
+// +build runtime_python
+
+package python
+
+// #include <Python.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// polyglotReportProgress is called by polyglot.progress. It sends the
+// report to the progress function of the execution running on thread tid,
+// if any. The GIL is released while Go runs.
+//
+//export polyglotReportProgress
+func polyglotReportProgress(tid C.ulong, fraction C.double, message *C.char, n C.int) {
+	b := boundTo(tid)
+	if b == nil {
+		return
+	}
+
+	report := core.Progress{
+		Runtime:  "python",
+		Fraction: float64(fraction),
+		Message:  string(C.GoBytes(unsafe.Pointer(message), n)),
+	}
+	save := C.PyEval_SaveThread()
+	core.ReportProgress(b.ctx, report)
+	C.PyEval_RestoreThread(save)
+}
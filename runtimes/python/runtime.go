@@ -10,7 +10,11 @@ import "C"
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"sync"
 
 	"github.com/griffincancode/polyglot.js/core"
@@ -25,20 +29,36 @@ var (
 
 // Runtime implements Python runtime integration with proper GIL management
 type Runtime struct {
-	config   core.RuntimeConfig
-	pool     *Pool
-	mu       sync.RWMutex
-	shutdown bool
+	config     core.RuntimeConfig
+	pool       *Pool
+	executions core.Executions
+	preimports []string
+	stdout     io.Writer
+	stderr     io.Writer
+	thread     *core.ThreadDispatcher
+	mu         sync.RWMutex
+	shutdown   bool
 }
 
 // NewRuntime creates a Python runtime instance
 func NewRuntime() *Runtime {
 	return &Runtime{
-		pool:     NewPool(4),
+		pool:     NewPool(),
 		shutdown: false,
 	}
 }
 
+// Preimport sets modules imported into every worker when the runtime
+// initializes. Executions can then use them without an import statement,
+// and the first call on each worker does not pay the import. Dotted names
+// bind their top-level package, as an import statement would. It takes
+// effect at the next Initialize.
+func (r *Runtime) Preimport(modules []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preimports = append([]string(nil), modules...)
+}
+
 // Initialize prepares the Python runtime with proper threading support
 func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) error {
 	r.mu.Lock()
@@ -54,12 +74,19 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 	// Initialize Python interpreter once
 	if !initialized {
 		if C.Py_IsInitialized() == 0 {
+			if config.Deterministic {
+				os.Setenv(hashSeedEnv, "0")
+			}
 			C.Py_Initialize()
 
 			// Note: In Python 3.7+, threading is automatically initialized
 			// PyEval_InitThreads() is deprecated and removed in Python 3.9+
 			// The GIL is created automatically when Py_Initialize() is called
 
+			if err := installModule(); err != nil {
+				return err
+			}
+
 			// Save main thread state and release GIL
 			// This allows other threads to acquire it
 			mainThreadState := C.PyEval_SaveThread()
@@ -71,79 +98,393 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 	}
 
 	r.config = config
+	r.stdout, r.stderr = core.OutputWriters(config)
+
+	// The recursion limit belongs to the interpreter, so the last runtime
+	// initialized sets it for all
+	if config.MaxStackDepth > 0 {
+		gil := AcquireGIL()
+		C.Py_SetRecursionLimit(C.int(config.MaxStackDepth))
+		gil.Release()
+	}
 
-	// Determine pool size
-	poolSize := config.MaxConcurrency
-	if poolSize <= 0 {
-		poolSize = 4
+	// A single-threaded runtime has one state, run on its own thread
+	opts := poolOptions(config)
+	if config.SingleThreaded {
+		if r.thread == nil {
+			r.thread = core.NewThreadDispatcher()
+		}
 	}
 
 	// Initialize the state pool
-	if err := r.pool.Initialize(poolSize); err != nil {
+	r.pool.shutdownCode = config.ShutdownCode
+	r.pool.thread = r.thread
+	if err := r.pool.Initialize(opts, r.config.InitCode, r.config.InitState, r.preimports...); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
 
 	return nil
 }
 
+// poolSize returns the number of states for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 4
+	}
+	return config.MaxConcurrency
+}
+
+// poolOptions returns the state pool options for config, limited to one
+// state when the runtime is single threaded
+func poolOptions(config core.RuntimeConfig) core.WorkerPoolOptions {
+	opts := core.WorkerPoolOptionsFor("python", config, poolSize(config))
+	if config.SingleThreaded {
+		opts.Max = 1
+		if opts.Min > 1 {
+			opts.Min = 1
+		}
+	}
+	return opts
+}
+
 // Execute runs Python code with proper GIL management
 func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{}) (interface{}, error) {
+	value, _, err := r.execute(ctx, code, false, args)
+	return value, err
+}
+
+// scopeScript lists the names in the session's globals and locals with
+// their types, and the top-level modules imported, for ParseScopeChanges
+const scopeScript = `(lambda scopes, sys: "\n".join(
+	["%s\t%s" % (name, type(value).__name__) for scope in scopes for name, value in list(scope.items())
+		if not name.startswith("_")] +
+	["@" + name for name in list(sys.modules) if "." not in name and not name.startswith("_")]))((globals(), locals()), __import__("sys"))`
+
+// ExecuteWithChanges runs code like Execute and reports the names it
+// defined in the session, with their types, and the modules it imported
+func (r *Runtime) ExecuteWithChanges(ctx context.Context, code string) (interface{}, core.ScopeChanges, error) {
+	return r.execute(ctx, code, true, nil)
+}
+
+// execute runs code on a pooled state, listing its scope before and after
+// when track is set
+func (r *Runtime) execute(ctx context.Context, code string, track bool, args []interface{}) (interface{}, core.ScopeChanges, error) {
 	r.mu.RLock()
 	if r.shutdown {
 		r.mu.RUnlock()
-		return nil, ErrShutdown
+		return nil, core.ScopeChanges{}, ErrShutdown
 	}
+	reset := r.config.ResetBetweenCalls
+	capture := r.config.CaptureLastExpr
+	pretty := r.prettyErrors()
+	determinism, deterministic := core.DeterminismFor(r.config)
+	ctx = core.AddOutput(ctx, r.stdout, r.stderr)
+	thread := r.thread
 	r.mu.RUnlock()
 
-	state := r.pool.Acquire()
-	if state == nil {
-		return nil, fmt.Errorf("failed to acquire state")
+	state, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, core.ScopeChanges{}, err
+	}
+	core.ReportWorker(ctx, state.id)
+	defer r.release(state, reset)
+	defer state.arm()()
+	defer r.executions.Track(ctx, state.Interrupt)()
+	defer state.bind(ctx)()
+	if deterministic {
+		if err := thread.Do(func() error { return state.Determinize(determinism) }); err != nil {
+			return nil, core.ScopeChanges{}, err
+		}
+	}
+
+	type tracked struct {
+		value   interface{}
+		changes core.ScopeChanges
+		err     error
 	}
-	defer r.pool.Release(state)
 
 	// Execute with context cancellation support
-	resultChan := make(chan Result, 1)
-	go func() {
-		result, err := state.Execute(code, args...)
-		resultChan <- Result{Value: result, Err: err}
-	}()
+	resultChan := make(chan tracked, 1)
+	thread.Go(func() {
+		var before interface{}
+		if track {
+			var err error
+			if before, err = state.Execute(scopeScript); err != nil {
+				resultChan <- tracked{err: fmt.Errorf("failed to list scope: %w", err)}
+				return
+			}
+		}
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resultChan:
-		return res.Value, res.Err
+		value, err := execute(state, code, capture, args...)
+		if pretty {
+			err = scriptError(err)
+		}
+		res := tracked{value: value, err: err}
+		if track {
+			after, listErr := state.Execute(scopeScript)
+			if listErr == nil {
+				res.changes, listErr = core.ParseScopeChanges(before, after)
+			}
+			if listErr != nil && err == nil {
+				res.err = fmt.Errorf("failed to list scope: %w", listErr)
+			}
+		}
+		resultChan <- res
+	})
+
+	res, err := core.AwaitResult(ctx, resultChan, state.Interrupt)
+	if err != nil {
+		return nil, core.ScopeChanges{}, err
+	}
+	return res.value, res.changes, res.err
+}
+
+// release returns state to the pool, resetting it first when reset is
+// set. A state that cannot be reset, because its code outlived the
+// interrupt grace period, still holds this caller's globals, so it is
+// replaced instead of being handed to the next caller.
+func (r *Runtime) release(state *State, reset bool) {
+	if reset {
+		if err := r.thread.Do(state.Reset); err != nil {
+			if err := r.pool.Replace(state); err != nil {
+				core.DefaultLogger().Log(core.LogWarn, err.Error())
+			}
+			return
+		}
 	}
+	r.pool.Release(state)
+}
+
+// ExecuteMapped runs code with inputs bound as variables and returns the
+// named output variables
+func (r *Runtime) ExecuteMapped(ctx context.Context, code string, inputs map[string]interface{}, outputs []string) (map[string]interface{}, error) {
+	r.mu.RLock()
+	if r.shutdown {
+		r.mu.RUnlock()
+		return nil, ErrShutdown
+	}
+	reset := r.config.ResetBetweenCalls
+	pretty := r.prettyErrors()
+	determinism, deterministic := core.DeterminismFor(r.config)
+	ctx = core.AddOutput(ctx, r.stdout, r.stderr)
+	thread := r.thread
+	r.mu.RUnlock()
+
+	state, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	core.ReportWorker(ctx, state.id)
+	defer r.release(state, reset)
+	defer state.arm()()
+	defer r.executions.Track(ctx, state.Interrupt)()
+	defer state.bind(ctx)()
+	if deterministic {
+		if err := thread.Do(func() error { return state.Determinize(determinism) }); err != nil {
+			return nil, err
+		}
+	}
+
+	type mappedResult struct {
+		values map[string]interface{}
+		err    error
+	}
+	resultChan := make(chan mappedResult, 1)
+	thread.Go(func() {
+		values, err := state.ExecuteMapped(code, inputs, outputs)
+		if pretty {
+			err = scriptError(err)
+		}
+		resultChan <- mappedResult{values: values, err: err}
+	})
+
+	res, err := core.AwaitResult(ctx, resultChan, state.Interrupt)
+	if err != nil {
+		return nil, err
+	}
+	return res.values, res.err
 }
 
 // Call invokes a Python function with proper GIL management
 func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (interface{}, error) {
+	return r.call(ctx, fn, false, args)
+}
+
+// CallMulti invokes a Python function, returning the items of a tuple
+// result as separate values
+func (r *Runtime) CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error) {
+	result, err := r.call(ctx, fn, true, args)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]interface{}), nil
+}
+
+func (r *Runtime) call(ctx context.Context, fn string, multi bool, args []interface{}) (interface{}, error) {
 	r.mu.RLock()
 	if r.shutdown {
 		r.mu.RUnlock()
 		return nil, ErrShutdown
 	}
+	pretty := r.prettyErrors()
+	determinism, deterministic := core.DeterminismFor(r.config)
+	ctx = core.AddOutput(ctx, r.stdout, r.stderr)
+	thread := r.thread
 	r.mu.RUnlock()
 
-	state := r.pool.Acquire()
-	if state == nil {
-		return nil, fmt.Errorf("failed to acquire state")
+	state, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
 	}
 	defer r.pool.Release(state)
+	defer state.arm()()
+	defer r.executions.Track(ctx, state.Interrupt)()
+	defer state.bind(ctx)()
+	if deterministic {
+		if err := thread.Do(func() error { return state.Determinize(determinism) }); err != nil {
+			return nil, err
+		}
+	}
 
 	// Call with context cancellation support
 	resultChan := make(chan Result, 1)
-	go func() {
-		result, err := state.Call(fn, args...)
+	thread.Go(func() {
+		result, err := state.call(fn, multi, args...)
+		if pretty {
+			err = scriptError(err)
+		}
 		resultChan <- Result{Value: result, Err: err}
-	}()
+	})
+
+	res, err := core.AwaitResult(ctx, resultChan, state.Interrupt)
+	if err != nil {
+		return nil, err
+	}
+	return res.Value, res.Err
+}
+
+// prettyErrors reports whether the "pretty_errors" option is set, which
+// returns exceptions as a *core.ScriptError with the exception type,
+// message and innermost user frame alongside the full traceback. Callers
+// hold r.mu.
+func (r *Runtime) prettyErrors() bool {
+	pretty, _ := r.config.Options["pretty_errors"].(bool)
+	return pretty
+}
+
+// scriptError condenses the traceback of a failed compile, execution or
+// call, leaving other errors unchanged
+func scriptError(err error) error {
+	for _, sentinel := range []error{ErrCompileFailed, ErrExecFailed, ErrCallFailed} {
+		if !errors.Is(err, sentinel) {
+			continue
+		}
+		text := strings.TrimPrefix(err.Error(), sentinel.Error()+": ")
+		if script, ok := core.ParsePythonTraceback(text); ok {
+			script.Err = err
+			return script
+		}
+	}
+	return err
+}
+
+// definedFunctionsScript lists functions defined in the session's globals
+// and locals as "name:arity" lines; defaults and *args make arity -1
+const definedFunctionsScript = `(lambda scopes: "\n".join(
+	"%s:%d" % (name, -1 if fn.__defaults__ or fn.__code__.co_flags & 0x04 else fn.__code__.co_argcount)
+	for scope in scopes for name, fn in list(scope.items())
+	if type(fn).__name__ == "function" and not name.startswith("_")))((globals(), locals()))`
+
+// DefinedFunctions lists the Python functions defined in the session
+func (r *Runtime) DefinedFunctions(ctx context.Context) ([]core.FunctionInfo, error) {
+	listing, err := r.Execute(ctx, definedFunctionsScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	return core.ParseFunctionListing(listing)
+}
+
+// availableModulesScript lists the public top-level modules on sys.path
+// and those compiled into the interpreter, one per line
+const availableModulesScript = `(lambda sys, pkgutil: "\n".join(
+	name for name in set(sys.builtin_module_names) | {m.name for m in pkgutil.iter_modules()}
+	if not name.startswith("_")))(__import__("sys"), __import__("pkgutil"))`
+
+// AvailableModules lists the top-level modules Python code can import,
+// including installed packages
+func (r *Runtime) AvailableModules(ctx context.Context) ([]string, error) {
+	listing, err := r.Execute(ctx, availableModulesScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list modules: %w", err)
+	}
+	return core.ParseModuleListing(listing)
+}
+
+// SnapshotState serializes the modules, functions and picklable data
+// defined in a worker's session. Snapshots restore only into the same
+// Python minor version.
+func (r *Runtime) SnapshotState(ctx context.Context) ([]byte, error) {
+	r.mu.RLock()
+	if r.shutdown {
+		r.mu.RUnlock()
+		return nil, ErrShutdown
+	}
+	thread := r.thread
+	r.mu.RUnlock()
+
+	state, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.pool.Release(state)
+
+	var snapshot []byte
+	err = thread.Do(func() (err error) {
+		snapshot, err = state.Snapshot()
+		return err
+	})
+	return snapshot, err
+}
+
+// RestoreState restores a snapshot into every worker's globals, waiting
+// for running executions to finish. With ResetBetweenCalls it is restored
+// again after each reset.
+func (r *Runtime) RestoreState(ctx context.Context, snapshot []byte) error {
+	r.mu.RLock()
+	if r.shutdown {
+		r.mu.RUnlock()
+		return ErrShutdown
+	}
+	r.mu.RUnlock()
+
+	return r.pool.Restore(ctx, snapshot)
+}
+
+// Interrupt stops the execution started with a context from
+// core.WithExecutionID; it fails with core.ErrInterrupted
+func (r *Runtime) Interrupt(executionID uint64) error {
+	return r.executions.Interrupt(executionID)
+}
+
+// Reconfigure resizes the state pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resultChan:
-		return res.Value, res.Err
+	opts := poolOptions(config)
+	if err := r.pool.Resize(opts.Max, opts.Min); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
 	}
+	r.config = config
+	return nil
 }
 
 // Shutdown stops the runtime and cleans up resources
@@ -158,7 +499,9 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.shutdown = true
 
 	// Close pool and cleanup all states
-	r.pool.Close()
+	r.pool.Close(ctx)
+	r.thread.Close()
+	r.thread = nil
 
 	initMu.Lock()
 	defer initMu.Unlock()
@@ -177,11 +520,21 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities declares the concurrency model: workers share the interpreter's GIL
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyGlobalLock}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "python"
 }
 
+// PoolStats reports the state pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	return r.pool.Stats()
+}
+
 // Version returns the Python version
 func (r *Runtime) Version() string {
 	if C.Py_IsInitialized() == 0 {
@@ -194,3 +547,40 @@ func (r *Runtime) Version() string {
 	cVersion := C.Py_GetVersion()
 	return C.GoString(cVersion)
 }
+
+// execute runs code on state. With capture set, multi-line code ending in
+// an expression returns that expression's value.
+func execute(state *State, code string, capture bool, args ...interface{}) (interface{}, error) {
+	if capture {
+		if wrapped, ok := captureLastExpr(code); ok {
+			result, err := state.Execute(wrapped, args...)
+			// A compile error means the last line is not an expression,
+			// so the code runs as written
+			if !errors.Is(err, ErrCompileFailed) {
+				return result, err
+			}
+		}
+	}
+	return state.Execute(code, args...)
+}
+
+// pyStringEscaper escapes code for a double-quoted Python string literal
+var pyStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\x00", `\x00`)
+
+// captureLastExpr rewrites code into a single expression that runs all but
+// the last line with exec and evaluates the last line, so Execute returns
+// its value. It reports false for single-line code, which is already
+// evaluated as an expression when it is one, and for code ending inside an
+// indented block or a line continuation.
+func captureLastExpr(code string) (string, bool) {
+	trimmed := strings.TrimRight(code, " \t\r\n")
+	i := strings.LastIndexByte(trimmed, '\n')
+	if i < 0 {
+		return "", false
+	}
+	body, last := strings.TrimRight(trimmed[:i], "\r"), trimmed[i+1:]
+	if last == "" || last[0] == ' ' || last[0] == '\t' || strings.HasSuffix(body, `\`) {
+		return "", false
+	}
+	return fmt.Sprintf(`(exec("%s", globals(), locals()), (%s))[1]`, pyStringEscaper.Replace(body), last), true
+}
@@ -3,13 +3,73 @@
 
 package python
 
-// #include <Python.h>
-// #include <stdlib.h>
+/*
+#include <Python.h>
+#include <stdlib.h>
+
+// The running thread is recorded in the same C call as the evaluation,
+// since the goroutine may move between OS threads across cgo calls. An
+// interrupt requested before the thread was recorded is raised as the
+// code starts; one that lands as the code finishes is discarded.
+
+static void polyglot_start(unsigned long *tid, int *interrupted) {
+	unsigned long self = PyThread_get_thread_ident();
+	__atomic_store_n(tid, self, __ATOMIC_SEQ_CST);
+	if (__atomic_load_n(interrupted, __ATOMIC_SEQ_CST)) {
+		PyThreadState_SetAsyncExc(self, PyExc_KeyboardInterrupt);
+	}
+}
+
+static void polyglot_finish(unsigned long *tid) {
+	__atomic_store_n(tid, 0, __ATOMIC_SEQ_CST);
+	PyThreadState_SetAsyncExc(PyThread_get_thread_ident(), NULL);
+}
+
+static PyObject* polyglot_eval(PyObject *code, PyObject *globals, PyObject *locals, unsigned long *tid, int *interrupted) {
+	polyglot_start(tid, interrupted);
+	PyObject *result = PyEval_EvalCode(code, globals, locals);
+	polyglot_finish(tid);
+	return result;
+}
+
+static PyObject* polyglot_call(PyObject *fn, PyObject *args, unsigned long *tid, int *interrupted) {
+	polyglot_start(tid, interrupted);
+	PyObject *result = PyObject_CallObject(fn, args);
+	polyglot_finish(tid);
+	return result;
+}
+
+// polyglot_interrupt flags the execution as interrupted and raises
+// KeyboardInterrupt in the running thread, if any. The GIL is taken here
+// rather than by the Go caller, whose goroutine may change OS threads
+// between cgo calls.
+static void polyglot_interrupt(unsigned long *tid, int *interrupted) {
+	__atomic_store_n(interrupted, 1, __ATOMIC_SEQ_CST);
+	unsigned long id = __atomic_load_n(tid, __ATOMIC_SEQ_CST);
+	if (id != 0) {
+		PyGILState_STATE gil = PyGILState_Ensure();
+		PyThreadState_SetAsyncExc(id, PyExc_KeyboardInterrupt);
+		PyGILState_Release(gil);
+	}
+}
+
+static void polyglot_set_interrupted(int *interrupted, int value) {
+	__atomic_store_n(interrupted, value, __ATOMIC_SEQ_CST);
+}
+
+static int polyglot_interrupted(int *interrupted) {
+	return __atomic_load_n(interrupted, __ATOMIC_SEQ_CST);
+}
+*/
 import "C"
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // NewState creates a new Python execution state
@@ -41,14 +101,333 @@ func (s *State) Initialize() error {
 		return fmt.Errorf("failed to create dictionaries")
 	}
 
-	// Add builtins to globals
+	s.addBuiltins()
+	return nil
+}
+
+// addBuiltins adds builtins to globals. The caller must hold the GIL.
+func (s *State) addBuiltins() {
 	builtins := C.PyEval_GetBuiltins()
 	if builtins != nil {
 		cKey := C.CString("__builtins__")
 		C.PyDict_SetItemString(s.globals, cKey, builtins)
 		C.free(unsafe.Pointer(cKey))
 	}
+}
+
+// Reset clears the state's globals and locals, leaving only builtins,
+// preimported modules and what the init code and restored snapshot
+// define, so no names from earlier executions are visible. Modules are
+// shared by the interpreter: those imported stay in sys.modules, and
+// changes code made to a module persist across a reset. It fails with
+// ErrWorkerBusy while code still runs on the state.
+func (s *State) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return ErrShutdown
+	}
+	if s.busy {
+		return ErrWorkerBusy
+	}
+
+	gil := AcquireGIL()
+	defer gil.Release()
 
+	C.PyDict_Clear(s.globals)
+	C.PyDict_Clear(s.locals)
+	s.addBuiltins()
+
+	for _, name := range s.modules {
+		if err := s.importModule(name); err != nil {
+			return err
+		}
+	}
+	if err := s.runInitCode(); err != nil {
+		return err
+	}
+	if s.snapshot != nil {
+		return s.restore(s.snapshot)
+	}
+	return nil
+}
+
+// Setup runs code in the state's globals, once now and again after each
+// Reset. Empty code does nothing.
+func (s *State) Setup(code string) error {
+	if code == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return ErrShutdown
+	}
+
+	gil := AcquireGIL()
+	defer gil.Release()
+
+	s.initCode = code
+	return s.runInitCode()
+}
+
+// runInitCode runs the init code with globals as its locals too, so the
+// functions it defines can call each other. The caller must hold the GIL.
+func (s *State) runInitCode() error {
+	if s.initCode == "" {
+		return nil
+	}
+	ClearError()
+
+	cCode := C.CString(s.initCode)
+	defer C.free(unsafe.Pointer(cCode))
+	cFilename := C.CString("<init>")
+	defer C.free(unsafe.Pointer(cFilename))
+
+	compiled := C.Py_CompileString(cCode, cFilename, C.Py_file_input)
+	if compiled == nil {
+		return fmt.Errorf("%w: init code: %s", ErrCompileFailed, GetError())
+	}
+	defer C.Py_DecRef(compiled)
+
+	result := C.PyEval_EvalCode(compiled, s.globals, s.globals)
+	if result == nil {
+		return fmt.Errorf("%w: init code: %s", ErrExecFailed, GetError())
+	}
+	C.Py_DecRef(result)
+	return nil
+}
+
+// stateHelpers serializes a session as a pickled list of entries:
+// modules by name, functions the session defined by their marshalled code,
+// and other values pickled. Marshalled code only loads in the Python
+// version that wrote it, so snapshots record the version.
+const stateHelpers = `
+import importlib, marshal, pickle, sys, types
+
+MAGIC = 'polyglot-python-snapshot'
+
+def snapshot(globals_, locals_):
+    scope = dict(globals_)
+    scope.update(locals_)
+    entries = []
+    for name in sorted(scope):
+        value = scope[name]
+        if name.startswith('__'):
+            continue
+        if isinstance(value, types.ModuleType):
+            entries.append(('module', name, value.__name__))
+        elif isinstance(value, types.FunctionType) and value.__globals__ is globals_:
+            if value.__closure__:
+                raise ValueError('cannot snapshot %s: closures are not supported' % name)
+            defaults = pickle.dumps((value.__defaults__, value.__kwdefaults__))
+            entries.append(('function', name, marshal.dumps(value.__code__), defaults))
+        else:
+            try:
+                entries.append(('value', name, pickle.dumps(value)))
+            except Exception as e:
+                raise ValueError('cannot snapshot %s: %s' % (name, e))
+    return pickle.dumps((MAGIC, tuple(sys.version_info[:2]), entries))
+
+def restore(globals_, data):
+    snapshot = pickle.loads(data)
+    if not isinstance(snapshot, tuple) or len(snapshot) != 3 or snapshot[0] != MAGIC:
+        raise ValueError('not a Python state snapshot')
+    _, version, entries = snapshot
+    if version != tuple(sys.version_info[:2]):
+        raise ValueError('snapshot from Python %d.%d cannot be restored in Python %d.%d' % (version + tuple(sys.version_info[:2])))
+    for entry in entries:
+        kind, name = entry[0], entry[1]
+        if kind == 'module':
+            globals_[name] = importlib.import_module(entry[2])
+        elif kind == 'function':
+            fn = types.FunctionType(marshal.loads(entry[2]), globals_)
+            fn.__defaults__, fn.__kwdefaults__ = pickle.loads(entry[3])
+            globals_[name] = fn
+        else:
+            globals_[name] = pickle.loads(entry[2])
+`
+
+// Snapshot serializes the modules, functions and data the state's code
+// has defined. Functions that close over variables and values pickle
+// cannot serialize make it fail.
+func (s *State) Snapshot() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return nil, ErrShutdown
+	}
+
+	gil := AcquireGIL()
+	defer gil.Release()
+
+	result, err := s.callStateHelper("snapshot", s.globals, s.locals)
+	if err != nil {
+		return nil, err
+	}
+	defer C.Py_DecRef(result)
+
+	var data *C.char
+	var size C.Py_ssize_t
+	if C.PyBytes_AsStringAndSize(result, &data, &size) != 0 {
+		return nil, fmt.Errorf("%w: snapshot: %s", ErrTypeConversion, GetError())
+	}
+	return C.GoBytes(unsafe.Pointer(data), C.int(size)), nil
+}
+
+// Restore defines what snapshot holds in the state's globals, now and
+// again after each Reset
+func (s *State) Restore(snapshot []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return ErrShutdown
+	}
+	if s.busy {
+		return ErrWorkerBusy
+	}
+
+	gil := AcquireGIL()
+	defer gil.Release()
+
+	if err := s.restore(snapshot); err != nil {
+		return err
+	}
+	s.snapshot = snapshot
+	return nil
+}
+
+// restore loads snapshot into globals. The caller must hold the GIL.
+func (s *State) restore(snapshot []byte) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	data := C.PyBytes_FromStringAndSize((*C.char)(unsafe.Pointer(&snapshot[0])), C.Py_ssize_t(len(snapshot)))
+	if data == nil {
+		return fmt.Errorf("%w: snapshot: %s", ErrTypeConversion, GetError())
+	}
+	defer C.Py_DecRef(data)
+
+	result, err := s.callStateHelper("restore", s.globals, data)
+	if err != nil {
+		return err
+	}
+	C.Py_DecRef(result)
+	return nil
+}
+
+// callStateHelper calls a function of stateHelpers, which run in a scope of
+// their own. The caller must hold the GIL.
+func (s *State) callStateHelper(name string, args ...*C.PyObject) (*C.PyObject, error) {
+	ClearError()
+
+	scope := C.PyDict_New()
+	if scope == nil {
+		return nil, fmt.Errorf("failed to create dictionary")
+	}
+	defer C.Py_DecRef(scope)
+	if builtins := C.PyEval_GetBuiltins(); builtins != nil {
+		cKey := C.CString("__builtins__")
+		C.PyDict_SetItemString(scope, cKey, builtins)
+		C.free(unsafe.Pointer(cKey))
+	}
+
+	cCode := C.CString(stateHelpers)
+	defer C.free(unsafe.Pointer(cCode))
+	cFilename := C.CString("<snapshot>")
+	defer C.free(unsafe.Pointer(cFilename))
+
+	compiled := C.Py_CompileString(cCode, cFilename, C.Py_file_input)
+	if compiled == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCompileFailed, GetError())
+	}
+	defer C.Py_DecRef(compiled)
+
+	loaded := C.PyEval_EvalCode(compiled, scope, scope)
+	if loaded == nil {
+		return nil, fmt.Errorf("%w: %s", ErrExecFailed, GetError())
+	}
+	C.Py_DecRef(loaded)
+
+	// Borrowed reference
+	cName := C.CString(name)
+	fn := C.PyDict_GetItemString(scope, cName)
+	C.free(unsafe.Pointer(cName))
+	if fn == nil {
+		return nil, fmt.Errorf("%w: function '%s'", ErrNotFound, name)
+	}
+
+	pyArgs := C.PyTuple_New(C.Py_ssize_t(len(args)))
+	defer C.Py_DecRef(pyArgs)
+	for i, arg := range args {
+		// PyTuple_SetItem steals the reference
+		C.Py_IncRef(arg)
+		C.PyTuple_SetItem(pyArgs, C.Py_ssize_t(i), arg)
+	}
+
+	result := C.PyObject_CallObject(fn, pyArgs)
+	if result == nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrExecFailed, name, GetError())
+	}
+	return result, nil
+}
+
+// Preimport imports modules and binds their top-level names in the
+// state's globals
+func (s *State) Preimport(modules []string) error {
+	if len(modules) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return ErrShutdown
+	}
+
+	gil := AcquireGIL()
+	defer gil.Release()
+
+	for _, name := range modules {
+		if err := s.importModule(name); err != nil {
+			return err
+		}
+	}
+	s.modules = append([]string(nil), modules...)
+	return nil
+}
+
+// importModule imports name into globals. The caller must hold the GIL.
+func (s *State) importModule(name string) error {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	module := C.PyImport_ImportModule(cName)
+	if module == nil {
+		return fmt.Errorf("%w: %s: %s", ErrImportFailed, name, GetError())
+	}
+	C.Py_DecRef(module)
+
+	// The submodule is loaded; bind its package like "import a.b" does
+	top := strings.SplitN(name, ".", 2)[0]
+	cTop := C.CString(top)
+	defer C.free(unsafe.Pointer(cTop))
+
+	module = C.PyImport_ImportModule(cTop)
+	if module == nil {
+		return fmt.Errorf("%w: %s: %s", ErrImportFailed, top, GetError())
+	}
+	defer C.Py_DecRef(module)
+
+	if C.PyDict_SetItemString(s.globals, cTop, module) != 0 {
+		return fmt.Errorf("failed to bind %s: %s", top, GetError())
+	}
 	return nil
 }
 
@@ -66,11 +445,7 @@ func (s *State) Execute(code string, args ...interface{}) (interface{}, error) {
 	s.busy = true
 	s.mu.Unlock()
 
-	defer func() {
-		s.mu.Lock()
-		s.busy = false
-		s.mu.Unlock()
-	}()
+	defer s.idle()
 
 	gil := AcquireGIL()
 	defer gil.Release()
@@ -112,9 +487,13 @@ func (s *State) Execute(code string, args ...interface{}) (interface{}, error) {
 	defer C.Py_DecRef(compiled)
 
 	// Execute compiled code
-	result := C.PyEval_EvalCode(compiled, s.globals, s.locals)
+	result := C.polyglot_eval(compiled, s.globals, s.locals, &s.threadID, &s.interrupted)
 	if result == nil {
-		return nil, fmt.Errorf("%w: %s", ErrExecFailed, GetError())
+		if s.wasInterrupted() {
+			ClearError()
+			return nil, core.ErrInterrupted
+		}
+		return nil, pythonError(ErrExecFailed)
 	}
 	defer C.Py_DecRef(result)
 
@@ -127,8 +506,93 @@ func (s *State) Execute(code string, args ...interface{}) (interface{}, error) {
 	return FromPython(result), nil
 }
 
+// ExecuteMapped sets inputs as variables, runs code as statements and
+// returns the named output variables, failing with ErrNotFound for an
+// output the code did not set
+func (s *State) ExecuteMapped(code string, inputs map[string]interface{}, outputs []string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	if s.shutdown {
+		s.mu.Unlock()
+		return nil, ErrShutdown
+	}
+	if s.busy {
+		s.mu.Unlock()
+		return nil, ErrWorkerBusy
+	}
+	s.busy = true
+	s.mu.Unlock()
+
+	defer s.idle()
+
+	gil := AcquireGIL()
+	defer gil.Release()
+
+	ClearError()
+
+	for name, value := range inputs {
+		cName := C.CString(name)
+		pyValue := ToPython(value)
+		C.PyDict_SetItemString(s.locals, cName, pyValue)
+		C.Py_DecRef(pyValue)
+		C.free(unsafe.Pointer(cName))
+	}
+
+	cCode := C.CString(code)
+	cFilename := C.CString("<string>")
+	compiled := C.Py_CompileString(cCode, cFilename, C.Py_file_input)
+	C.free(unsafe.Pointer(cCode))
+	C.free(unsafe.Pointer(cFilename))
+
+	if compiled == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCompileFailed, GetError())
+	}
+	defer C.Py_DecRef(compiled)
+
+	result := C.polyglot_eval(compiled, s.globals, s.locals, &s.threadID, &s.interrupted)
+	if result == nil {
+		if s.wasInterrupted() {
+			ClearError()
+			return nil, core.ErrInterrupted
+		}
+		return nil, pythonError(ErrExecFailed)
+	}
+	C.Py_DecRef(result)
+
+	values := make(map[string]interface{}, len(outputs))
+	for _, name := range outputs {
+		// Borrowed references, so they are not released
+		cName := C.CString(name)
+		value := C.PyDict_GetItemString(s.locals, cName)
+		if value == nil {
+			value = C.PyDict_GetItemString(s.globals, cName)
+		}
+		C.free(unsafe.Pointer(cName))
+
+		if value == nil {
+			return nil, fmt.Errorf("%w: variable '%s'", ErrNotFound, name)
+		}
+		values[name] = FromPython(value)
+	}
+	return values, nil
+}
+
 // Call invokes a Python function by name
 func (s *State) Call(fn string, args ...interface{}) (interface{}, error) {
+	return s.call(fn, false, args...)
+}
+
+// CallMulti is Call, returning the items of a tuple result as separate
+// values
+func (s *State) CallMulti(fn string, args ...interface{}) ([]interface{}, error) {
+	result, err := s.call(fn, true, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]interface{}), nil
+}
+
+// call invokes fn; with multi set the result is always a []interface{}
+func (s *State) call(fn string, multi bool, args ...interface{}) (interface{}, error) {
 	s.mu.Lock()
 	if s.shutdown {
 		s.mu.Unlock()
@@ -141,11 +605,7 @@ func (s *State) Call(fn string, args ...interface{}) (interface{}, error) {
 	s.busy = true
 	s.mu.Unlock()
 
-	defer func() {
-		s.mu.Lock()
-		s.busy = false
-		s.mu.Unlock()
-	}()
+	defer s.idle()
 
 	gil := AcquireGIL()
 	defer gil.Release()
@@ -153,9 +613,13 @@ func (s *State) Call(fn string, args ...interface{}) (interface{}, error) {
 	// Clear any previous errors
 	ClearError()
 
-	// Get function object
+	// Get function object; top-level definitions from Execute live in
+	// locals
 	cFn := C.CString(fn)
-	fnObj := C.PyDict_GetItemString(s.globals, cFn)
+	fnObj := C.PyDict_GetItemString(s.locals, cFn)
+	if fnObj == nil {
+		fnObj = C.PyDict_GetItemString(s.globals, cFn)
+	}
 	C.free(unsafe.Pointer(cFn))
 
 	if fnObj == nil {
@@ -177,15 +641,57 @@ func (s *State) Call(fn string, args ...interface{}) (interface{}, error) {
 	}
 
 	// Call function
-	result := C.PyObject_CallObject(fnObj, pyArgs)
+	result := C.polyglot_call(fnObj, pyArgs, &s.threadID, &s.interrupted)
 	if result == nil {
-		return nil, fmt.Errorf("%w: %s", ErrCallFailed, GetError())
+		if s.wasInterrupted() {
+			ClearError()
+			return nil, core.ErrInterrupted
+		}
+		return nil, pythonError(ErrCallFailed)
 	}
 	defer C.Py_DecRef(result)
 
+	if multi {
+		if isTuple(result) {
+			return pyToSlice(result), nil
+		}
+		return []interface{}{FromPython(result)}, nil
+	}
 	return FromPython(result), nil
 }
 
+// arm marks the state as held by an execution that Interrupt may stop,
+// until the returned function is called
+func (s *State) arm() func() {
+	s.interruptMu.Lock()
+	s.armed = true
+	C.polyglot_set_interrupted(&s.interrupted, 0)
+	s.interruptMu.Unlock()
+
+	return func() {
+		s.interruptMu.Lock()
+		s.armed = false
+		C.polyglot_set_interrupted(&s.interrupted, 0)
+		s.interruptMu.Unlock()
+	}
+}
+
+// Interrupt raises KeyboardInterrupt in the code running on the state.
+// Python checks for it between bytecodes, so code blocked in a C call
+// stops once the call returns, and code that has not started yet stops
+// as it starts.
+func (s *State) Interrupt() {
+	s.interruptMu.Lock()
+	defer s.interruptMu.Unlock()
+	if s.armed {
+		C.polyglot_interrupt(&s.threadID, &s.interrupted)
+	}
+}
+
+func (s *State) wasInterrupted() bool {
+	return C.polyglot_interrupted(&s.interrupted) != 0
+}
+
 // Shutdown cleans up the state
 func (s *State) Shutdown() {
 	s.mu.Lock()
@@ -211,6 +717,45 @@ func (s *State) Shutdown() {
 	}
 }
 
+// Teardown runs code on the state, unless ctx has ended, and shuts the
+// state down. Code still running when ctx ends is interrupted.
+func (s *State) Teardown(ctx context.Context, code string) {
+	if code != "" && ctx.Err() == nil {
+		disarm := s.arm()
+		stop := context.AfterFunc(ctx, s.Interrupt)
+		s.Execute(code)
+		stop()
+		disarm()
+	}
+	s.Shutdown()
+}
+
+// idle marks the state no longer busy, shutting it down if it was retired
+// while its code ran
+func (s *State) idle() {
+	s.mu.Lock()
+	s.busy = false
+	retired := s.retired
+	s.mu.Unlock()
+
+	if retired {
+		s.Shutdown()
+	}
+}
+
+// Retire shuts the state down now if it is idle, or once the code running
+// on it finishes
+func (s *State) Retire() {
+	s.mu.Lock()
+	if s.busy {
+		s.retired = true
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	s.Shutdown()
+}
+
 // ID returns the state identifier
 func (s *State) ID() int {
 	return s.id
@@ -222,3 +767,15 @@ func (s *State) IsBusy() bool {
 	defer s.mu.Unlock()
 	return s.busy
 }
+
+// pythonError builds the error of a failed execution or call from the
+// pending exception, marking a RecursionError as core.ErrStackDepth. The
+// caller must hold the GIL.
+func pythonError(sentinel error) error {
+	recursion := C.PyErr_ExceptionMatches(C.PyExc_RecursionError) != 0
+	err := fmt.Errorf("%w: %s", sentinel, GetError())
+	if recursion {
+		return core.StackDepthError(err)
+	}
+	return err
+}
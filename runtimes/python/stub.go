@@ -22,6 +22,9 @@ func NewRuntime() *Runtime {
 	return &Runtime{}
 }
 
+// Preimport does nothing
+func (r *Runtime) Preimport(modules []string) {}
+
 // Initialize returns an error indicating Python is not enabled
 func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) error {
 	_ = config // Explicitly mark as used
@@ -38,11 +41,56 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	return nil, errNotEnabled
 }
 
+// ExecuteMapped returns an error
+func (r *Runtime) ExecuteMapped(ctx context.Context, code string, inputs map[string]interface{}, outputs []string) (map[string]interface{}, error) {
+	return nil, errNotEnabled
+}
+
+// CallMulti returns an error
+func (r *Runtime) CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error) {
+	return nil, errNotEnabled
+}
+
+// DefinedFunctions returns an error
+func (r *Runtime) DefinedFunctions(ctx context.Context) ([]core.FunctionInfo, error) {
+	return nil, errNotEnabled
+}
+
+// ExecuteWithChanges returns an error
+func (r *Runtime) ExecuteWithChanges(ctx context.Context, code string) (interface{}, core.ScopeChanges, error) {
+	return nil, core.ScopeChanges{}, errNotEnabled
+}
+
+// SnapshotState returns an error
+func (r *Runtime) SnapshotState(ctx context.Context) ([]byte, error) {
+	return nil, errNotEnabled
+}
+
+// RestoreState returns an error
+func (r *Runtime) RestoreState(ctx context.Context, snapshot []byte) error {
+	return errNotEnabled
+}
+
+// AvailableModules returns an error
+func (r *Runtime) AvailableModules(ctx context.Context) ([]string, error) {
+	return nil, errNotEnabled
+}
+
+// Interrupt returns an error
+func (r *Runtime) Interrupt(executionID uint64) error {
+	return errNotEnabled
+}
+
 // Shutdown does nothing
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities declares the concurrency model: workers share the interpreter's GIL
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyGlobalLock}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "python"
@@ -52,3 +100,8 @@ func (r *Runtime) Name() string {
 func (r *Runtime) Version() string {
 	return "stub (not enabled)"
 }
+
+// Stubbed reports that this runtime is a placeholder
+func (r *Runtime) Stubbed() bool {
+	return true
+}
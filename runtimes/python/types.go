@@ -56,9 +56,21 @@ type State struct {
 	gil      *C.PyGILState_STATE
 	globals  *C.PyObject
 	locals   *C.PyObject
+	modules  []string
+	initCode string
+	snapshot []byte
 	busy     bool
 	shutdown bool
+	retired  bool
 	mu       sync.Mutex
+
+	// threadID identifies the thread running Python code on the state,
+	// or 0 when idle. interrupted is set by Interrupt while armed, and
+	// checked as code starts so an interrupt that comes first is kept.
+	threadID    C.ulong
+	interrupted C.int
+	armed       bool
+	interruptMu sync.Mutex
 }
 
 // Result represents execution result
@@ -0,0 +1,59 @@
+//go:build runtime_ruby
+// +build runtime_ruby
+
+package ruby
+
+/*
+#include <ruby.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// determinismScript makes Time.now read a frozen clock, once per process,
+// then resets the clock and seeds rand
+const determinismScript = `
+unless defined?(::PolyglotDeterminism)
+  module ::PolyglotDeterminism
+    class << self
+      attr_accessor :now
+    end
+  end
+
+  class << ::Time
+    def now(*)
+      at(::PolyglotDeterminism.now)
+    end
+  end
+end
+::PolyglotDeterminism.now = Rational(%d, 1_000_000_000)
+srand(%d)
+nil`
+
+// Determinize seeds rand and freezes the clock for the next execution
+func (w *Worker) Determinize(d core.Determinism) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return fmt.Errorf("worker is shutdown")
+	}
+
+	cScript := C.CString(fmt.Sprintf(determinismScript, d.Time.UnixNano(), d.Seed))
+	defer C.free(unsafe.Pointer(cScript))
+
+	var state C.int
+	C.rb_eval_string_protect(cScript, &state)
+	if state != 0 {
+		errMsg := C.rb_obj_as_string(C.rb_errinfo())
+		C.rb_set_errinfo(C.Qnil)
+		return fmt.Errorf("failed to apply deterministic mode: %s", rubyStringToGo(errMsg))
+	}
+	return nil
+}
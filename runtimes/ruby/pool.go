@@ -4,23 +4,26 @@
 package ruby
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
-// Pool manages Ruby interpreter workers
+// Pool manages Ruby interpreter workers. Workers can be acquired by any
+// caller or pinned to an affinity session, so state set in a session's
+// globals stays visible to that session.
 type Pool struct {
-	workers chan *Worker
+	workers *core.WorkerPool[*Worker]
+	opts    core.WorkerPoolOptions
 	size    int
 	mu      sync.Mutex
 }
 
 // NewPool creates a worker pool
 func NewPool(size int) *Pool {
-	return &Pool{
-		workers: make(chan *Worker, size),
-		size:    size,
-	}
+	return &Pool{size: size}
 }
 
 // Initialize creates workers
@@ -28,28 +31,59 @@ func (p *Pool) Initialize(size int) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.size = size
-	p.workers = make(chan *Worker, size)
-
-	for i := 0; i < size; i++ {
-		worker := NewWorker(i)
-		if err := worker.Initialize(); err != nil {
-			return fmt.Errorf("failed to initialize worker %d: %w", i, err)
-		}
-		p.workers <- worker
+	if size <= 0 {
+		size = p.size
 	}
 
+	opts := p.opts
+	opts.Max = size
+	workers, err := core.NewWorkerPoolWith(opts, newWorker, (*Worker).Shutdown)
+	if err != nil {
+		return err
+	}
+	p.size = size
+	p.workers = workers
 	return nil
 }
 
-// Acquire gets a worker from the pool
-func (p *Pool) Acquire() *Worker {
-	return <-p.workers
+// newWorker creates and initializes a worker
+func newWorker(id int) (*Worker, error) {
+	worker := NewWorker(id)
+	if err := worker.Initialize(); err != nil {
+		return nil, err
+	}
+	return worker, nil
+}
+
+// Acquire gets the worker pinned to the context's affinity session, or
+// any idle worker without a session, waiting until ctx ends
+func (p *Pool) Acquire(ctx context.Context) (*Worker, error) {
+	worker, err := p.workers.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire worker: %w", err)
+	}
+	return worker, nil
 }
 
 // Release returns a worker to the pool
 func (p *Pool) Release(worker *Worker) {
-	p.workers <- worker
+	p.workers.Release(worker)
+}
+
+// Resize changes the pool's maximum and minimum workers
+func (p *Pool) Resize(size, min int) error {
+	if p.workers == nil {
+		return fmt.Errorf("pool is not initialized")
+	}
+	return p.workers.Resize(size, min)
+}
+
+// Stats reports the pool's workers
+func (p *Pool) Stats() core.PoolStats {
+	if p.workers == nil {
+		return core.PoolStats{}
+	}
+	return p.workers.Stats()
 }
 
 // Close shuts down the pool
@@ -57,8 +91,7 @@ func (p *Pool) Close() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	close(p.workers)
-	for worker := range p.workers {
-		worker.Shutdown()
+	if p.workers != nil {
+		p.workers.Close()
 	}
 }
@@ -23,10 +23,11 @@ import (
 
 // Runtime implements Ruby runtime integration
 type Runtime struct {
-	config   core.RuntimeConfig
-	pool     *Pool
-	mu       sync.RWMutex
-	shutdown bool
+	config     core.RuntimeConfig
+	pool       *Pool
+	executions core.Executions
+	mu         sync.RWMutex
+	shutdown   bool
 }
 
 // NewRuntime creates a Ruby runtime instance
@@ -52,7 +53,8 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 	C.ruby_init_loadpath()
 
 	// Initialize the pool
-	if err := r.pool.Initialize(config.MaxConcurrency); err != nil {
+	r.pool.opts = core.WorkerPoolOptionsFor("ruby", config, poolSize(config))
+	if err := r.pool.Initialize(poolSize(config)); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
 
@@ -66,10 +68,22 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 		r.mu.RUnlock()
 		return nil, fmt.Errorf("runtime is shutdown")
 	}
+	determinism, deterministic := core.DeterminismFor(r.config)
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	core.ReportWorker(ctx, worker.id)
 	defer r.pool.Release(worker)
+	defer worker.arm()()
+	defer r.executions.Track(ctx, worker.Interrupt)()
+	if deterministic {
+		if err := worker.Determinize(determinism); err != nil {
+			return nil, err
+		}
+	}
 
 	// Execute with context cancellation support
 	resultChan := make(chan result, 1)
@@ -78,12 +92,11 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 		resultChan <- result{value: res, err: err}
 	}()
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resultChan:
-		return res.value, res.err
+	res, err := core.AwaitResult(ctx, resultChan, worker.Interrupt)
+	if err != nil {
+		return nil, err
 	}
+	return res.value, res.err
 }
 
 // Call invokes a Ruby method
@@ -93,10 +106,21 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 		r.mu.RUnlock()
 		return nil, fmt.Errorf("runtime is shutdown")
 	}
+	determinism, deterministic := core.DeterminismFor(r.config)
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.pool.Release(worker)
+	defer worker.arm()()
+	defer r.executions.Track(ctx, worker.Interrupt)()
+	if deterministic {
+		if err := worker.Determinize(determinism); err != nil {
+			return nil, err
+		}
+	}
 
 	// Call with context cancellation support
 	resultChan := make(chan result, 1)
@@ -105,12 +129,165 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 		resultChan <- result{value: res, err: err}
 	}()
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resultChan:
-		return res.value, res.err
+	res, err := core.AwaitResult(ctx, resultChan, worker.Interrupt)
+	if err != nil {
+		return nil, err
+	}
+	return res.value, res.err
+}
+
+// CallMulti invokes a Ruby method and returns each element of an Array
+// result, which is how "return a, b" returns several values
+func (r *Runtime) CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error) {
+	result, err := r.Call(ctx, fn, args...)
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := result.([]interface{}); ok {
+		return values, nil
+	}
+	return []interface{}{result}, nil
+}
+
+// definedFunctionsScript lists top-level methods as "name:arity" lines;
+// optional and splat parameters make arity negative
+const definedFunctionsScript = `Object.private_instance_methods(false).map { |m|
+	"#{m}:#{Object.instance_method(m).arity}"
+}.join("\n")`
+
+// DefinedFunctions lists the top-level Ruby methods defined in the session
+func (r *Runtime) DefinedFunctions(ctx context.Context) ([]core.FunctionInfo, error) {
+	listing, err := r.Execute(ctx, definedFunctionsScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions: %w", err)
+	}
+	return core.ParseFunctionListing(listing)
+}
+
+// scopeScript lists the global variables and constants with their
+// classes, the top-level methods, and the libraries required, for
+// ParseScopeChanges. Warnings from reading deprecated globals are muted.
+const scopeScript = `begin
+	verbose, $VERBOSE = $VERBOSE, nil
+	(global_variables.map { |name| "#{name}\t#{(eval(name.to_s) rescue nil).class}" } +
+		Object.constants.reject { |name| Object.autoload?(name) }.map { |name| "#{name}\t#{Object.const_get(name).class}" } +
+		Object.private_instance_methods(false).map { |name| "#{name}\tMethod" } +
+		$LOADED_FEATURES.map { |path| "@#{File.basename(path, ".*")}" }).join("\n")
+ensure
+	$VERBOSE = verbose
+end`
+
+// scopeResult is the outcome of ExecuteWithChanges on a worker
+type scopeResult struct {
+	value   interface{}
+	changes core.ScopeChanges
+	err     error
+}
+
+// ExecuteWithChanges runs code like Execute and reports the global
+// variables, constants and top-level methods it defined, and the
+// libraries it required. Local variables do not outlive an execution in
+// Ruby, so they are never reported.
+func (r *Runtime) ExecuteWithChanges(ctx context.Context, code string) (interface{}, core.ScopeChanges, error) {
+	r.mu.RLock()
+	if r.shutdown {
+		r.mu.RUnlock()
+		return nil, core.ScopeChanges{}, fmt.Errorf("runtime is shutdown")
+	}
+	determinism, deterministic := core.DeterminismFor(r.config)
+	r.mu.RUnlock()
+
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, core.ScopeChanges{}, err
+	}
+	core.ReportWorker(ctx, worker.id)
+	defer r.pool.Release(worker)
+	defer worker.arm()()
+	defer r.executions.Track(ctx, worker.Interrupt)()
+	if deterministic {
+		if err := worker.Determinize(determinism); err != nil {
+			return nil, core.ScopeChanges{}, err
+		}
+	}
+
+	resultChan := make(chan scopeResult, 1)
+	go func() {
+		before, err := worker.Execute(scopeScript)
+		if err != nil {
+			resultChan <- scopeResult{err: fmt.Errorf("failed to list scope: %w", err)}
+			return
+		}
+		value, execErr := worker.Execute(code)
+		after, err := worker.Execute(scopeScript)
+		if err == nil {
+			var changes core.ScopeChanges
+			if changes, err = core.ParseScopeChanges(before, after); err == nil {
+				resultChan <- scopeResult{value: value, changes: changes, err: execErr}
+				return
+			}
+		}
+		if execErr == nil {
+			execErr = fmt.Errorf("failed to list scope: %w", err)
+		}
+		resultChan <- scopeResult{err: execErr}
+	}()
+
+	res, err := core.AwaitResult(ctx, resultChan, worker.Interrupt)
+	if err != nil {
+		return nil, core.ScopeChanges{}, err
+	}
+	return res.value, res.changes, res.err
+}
+
+// availableModulesScript lists the libraries found on $LOAD_PATH, one per
+// line
+const availableModulesScript = `$LOAD_PATH.flat_map { |dir|
+	Dir.glob(File.join(dir.to_s, "*.{rb,so,bundle}")).map { |f| File.basename(f, ".*") }
+}.uniq.join("\n")`
+
+// AvailableModules lists the top-level libraries Ruby code can require
+// from $LOAD_PATH
+func (r *Runtime) AvailableModules(ctx context.Context) ([]string, error) {
+	listing, err := r.Execute(ctx, availableModulesScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list modules: %w", err)
+	}
+	return core.ParseModuleListing(listing)
+}
+
+// Interrupt stops the execution started with a context from
+// core.WithExecutionID; it fails with core.ErrInterrupted
+func (r *Runtime) Interrupt(executionID uint64) error {
+	return r.executions.Interrupt(executionID)
+}
+
+// poolSize returns the number of workers for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 10
+	}
+	return config.MaxConcurrency
+}
+
+// Reconfigure resizes the worker pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
 	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.pool.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
+	}
+	r.config = config
+	return nil
 }
 
 // Shutdown stops the runtime
@@ -139,6 +316,21 @@ type result struct {
 	err   error
 }
 
+// PoolStats reports the worker pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool == nil {
+		return core.PoolStats{}
+	}
+	return r.pool.Stats()
+}
+
+// Capabilities declares the concurrency model: workers share the interpreter's GVL
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyGlobalLock}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "ruby"
@@ -165,7 +357,7 @@ func goStringToRuby(s string) C.VALUE {
 
 // convertToRuby converts Go value to Ruby VALUE
 func convertToRuby(val interface{}) C.VALUE {
-	if val == nil {
+	if core.IsNil(val) {
 		return C.Qnil
 	}
 
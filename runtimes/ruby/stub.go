@@ -35,11 +35,41 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	return nil, fmt.Errorf("Ruby runtime not enabled")
 }
 
+// CallMulti returns an error
+func (r *Runtime) CallMulti(ctx context.Context, fn string, args ...interface{}) ([]interface{}, error) {
+	return nil, fmt.Errorf("Ruby runtime not enabled")
+}
+
+// DefinedFunctions returns an error
+func (r *Runtime) DefinedFunctions(ctx context.Context) ([]core.FunctionInfo, error) {
+	return nil, fmt.Errorf("Ruby runtime not enabled")
+}
+
+// ExecuteWithChanges returns an error
+func (r *Runtime) ExecuteWithChanges(ctx context.Context, code string) (interface{}, core.ScopeChanges, error) {
+	return nil, core.ScopeChanges{}, fmt.Errorf("Ruby runtime not enabled")
+}
+
+// AvailableModules returns an error
+func (r *Runtime) AvailableModules(ctx context.Context) ([]string, error) {
+	return nil, fmt.Errorf("Ruby runtime not enabled")
+}
+
+// Interrupt returns an error
+func (r *Runtime) Interrupt(executionID uint64) error {
+	return fmt.Errorf("Ruby runtime not enabled")
+}
+
 // Shutdown does nothing
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities declares the concurrency model: workers share the interpreter's GVL
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyGlobalLock}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "ruby"
@@ -49,3 +79,8 @@ func (r *Runtime) Name() string {
 func (r *Runtime) Version() string {
 	return "stub (not enabled)"
 }
+
+// Stubbed reports that this runtime is a placeholder
+func (r *Runtime) Stubbed() bool {
+	return true
+}
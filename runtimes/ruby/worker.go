@@ -5,12 +5,34 @@ package ruby
 
 /*
 #include <ruby.h>
+#include <ruby/debug.h>
 #include <stdlib.h>
 
 // Helper function to protect rb_eval_string
 static VALUE protected_eval(VALUE code_str) {
     return rb_eval_string(StringValueCStr(code_str));
 }
+
+// Interrupts are delivered as a postponed job, which the VM runs at its
+// next interrupt check on the Ruby thread; the job raises Interrupt there,
+// as Thread#raise would.
+static int polyglot_interrupt_pending = 0;
+
+static void polyglot_raise_interrupt(void *data) {
+    (void)data;
+    if (__atomic_exchange_n(&polyglot_interrupt_pending, 0, __ATOMIC_SEQ_CST)) {
+        rb_raise(rb_eInterrupt, "execution interrupted");
+    }
+}
+
+static void polyglot_interrupt(void) {
+    __atomic_store_n(&polyglot_interrupt_pending, 1, __ATOMIC_SEQ_CST);
+    rb_postponed_job_register_one(0, polyglot_raise_interrupt, NULL);
+}
+
+static void polyglot_clear_interrupt(void) {
+    __atomic_store_n(&polyglot_interrupt_pending, 0, __ATOMIC_SEQ_CST);
+}
 */
 import "C"
 
@@ -18,6 +40,8 @@ import (
 	"fmt"
 	"sync"
 	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Worker represents a Ruby execution context
@@ -25,6 +49,15 @@ type Worker struct {
 	id       int
 	mu       sync.Mutex
 	shutdown bool
+
+	// interruptMu guards the interrupt flags. armed is set while an
+	// execution holds the worker, and pending keeps an Interrupt that
+	// comes before its code runs for begin.
+	interruptMu sync.Mutex
+	armed       bool
+	running     bool
+	pending     bool
+	interrupted bool
 }
 
 // NewWorker creates a Ruby worker
@@ -60,9 +93,15 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	defer C.free(unsafe.Pointer(cCode))
 
 	var state C.int
+	w.begin()
 	result := C.rb_eval_string_protect(cCode, &state)
+	interrupted := w.end()
 
 	if state != 0 {
+		if interrupted {
+			C.rb_set_errinfo(C.Qnil)
+			return nil, core.ErrInterrupted
+		}
 		// Exception occurred
 		errVal := C.rb_errinfo()
 		errMsg := C.rb_obj_as_string(errVal)
@@ -105,9 +144,15 @@ func (w *Worker) Call(fn string, args ...interface{}) (interface{}, error) {
 	defer C.free(unsafe.Pointer(cCode))
 
 	var state C.int
+	w.begin()
 	result := C.rb_eval_string_protect(cCode, &state)
+	interrupted := w.end()
 
 	if state != 0 {
+		if interrupted {
+			C.rb_set_errinfo(C.Qnil)
+			return nil, core.ErrInterrupted
+		}
 		// Exception occurred
 		errVal := C.rb_errinfo()
 		errMsg := C.rb_obj_as_string(errVal)
@@ -117,6 +162,60 @@ func (w *Worker) Call(fn string, args ...interface{}) (interface{}, error) {
 	return convertFromRuby(result), nil
 }
 
+// arm marks the worker as held by an execution that Interrupt may stop,
+// until the returned function is called
+func (w *Worker) arm() func() {
+	w.interruptMu.Lock()
+	w.armed, w.pending = true, false
+	w.interruptMu.Unlock()
+
+	return func() {
+		w.interruptMu.Lock()
+		w.armed, w.pending = false, false
+		w.interruptMu.Unlock()
+	}
+}
+
+// begin marks the start of code that Interrupt may stop, applying an
+// interrupt that came before it
+func (w *Worker) begin() {
+	w.interruptMu.Lock()
+	defer w.interruptMu.Unlock()
+	w.running = true
+	w.interrupted = w.pending
+	w.pending = false
+	C.polyglot_clear_interrupt()
+	if w.interrupted {
+		C.polyglot_interrupt()
+	}
+}
+
+// end marks the end of an execution, dropping an interrupt that did not
+// land before the code finished, and reports whether it was interrupted
+func (w *Worker) end() bool {
+	w.interruptMu.Lock()
+	defer w.interruptMu.Unlock()
+	w.running = false
+	C.polyglot_clear_interrupt()
+	return w.interrupted
+}
+
+// Interrupt raises Interrupt in the running execution. Ruby checks for it
+// between instructions, so code blocked in a C extension stops once the
+// extension returns, and code that has not started yet stops as it
+// starts.
+func (w *Worker) Interrupt() {
+	w.interruptMu.Lock()
+	defer w.interruptMu.Unlock()
+
+	if w.running {
+		w.interrupted = true
+		C.polyglot_interrupt()
+	} else if w.armed {
+		w.pending = true
+	}
+}
+
 // formatRubyArgument converts a Go value to Ruby literal syntax
 func formatRubyArgument(arg interface{}) string {
 	if arg == nil {
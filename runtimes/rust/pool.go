@@ -4,29 +4,45 @@
 package rust
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Pool manages Rust worker instances
 type Pool struct {
-	workers chan *Worker
-	all     []*Worker
-	size    int
-	mu      sync.RWMutex
-	closed  bool
+	workers  *core.WorkerPool[*Worker]
+	opts     core.WorkerPoolOptions
+	size     int
+	mu       sync.RWMutex
+	closed   bool
+	limits   core.ResourceLimits
+	encoding core.OutputEncoding
+	stdout   io.Writer
+	stderr   io.Writer
+	cache    *core.CompileCache
+
+	// structured returns the delimited JSON result programs print, for
+	// StructuredOutput
+	structured bool
+
+	// library is the shared library loaded into each worker as it starts
+	library string
 }
 
 // NewPool creates a worker pool
-func NewPool(size int) *Pool {
+func NewPool(size int, limits core.ResourceLimits, encoding core.OutputEncoding) *Pool {
 	if size <= 0 {
 		size = 4
 	}
 	return &Pool{
-		workers: make(chan *Worker, size),
-		all:     make([]*Worker, 0, size),
-		size:    size,
-		closed:  false,
+		size:     size,
+		closed:   false,
+		limits:   limits,
+		encoding: encoding,
 	}
 }
 
@@ -43,36 +59,44 @@ func (p *Pool) Initialize(size int) error {
 		size = 4
 	}
 
-	p.size = size
-	p.workers = make(chan *Worker, size)
-	p.all = make([]*Worker, 0, size)
-
-	for i := 0; i < size; i++ {
-		worker := NewWorker(i)
-		if err := worker.Initialize(); err != nil {
-			// Clean up already created workers
-			for _, w := range p.all {
-				w.Shutdown()
-			}
-			return fmt.Errorf("failed to initialize worker %d: %w", i, err)
-		}
-		p.all = append(p.all, worker)
-		p.workers <- worker
+	opts := p.opts
+	opts.Max = size
+	workers, err := core.NewWorkerPoolWith(opts, p.newWorker, (*Worker).Shutdown)
+	if err != nil {
+		return err
 	}
-
+	p.size = size
+	p.workers = workers
 	return nil
 }
 
-// Acquire gets a worker from the pool (blocks until available)
-func (p *Pool) Acquire() *Worker {
-	p.mu.RLock()
-	if p.closed {
-		p.mu.RUnlock()
-		return nil
+// newWorker creates and initializes a worker
+func (p *Pool) newWorker(id int) (*Worker, error) {
+	worker := NewWorker(id)
+	worker.limits = p.limits
+	worker.encoding = p.encoding
+	worker.stdout, worker.stderr = p.stdout, p.stderr
+	worker.structured = p.structured
+	worker.cache = p.cache
+	if err := worker.Initialize(); err != nil {
+		return nil, err
 	}
-	p.mu.RUnlock()
+	if p.library != "" {
+		if err := worker.LoadLibrary(p.library); err != nil {
+			worker.Shutdown()
+			return nil, fmt.Errorf("failed to load library: %w", err)
+		}
+	}
+	return worker, nil
+}
 
-	return <-p.workers
+// Acquire gets a worker from the pool, waiting until ctx ends
+func (p *Pool) Acquire(ctx context.Context) (*Worker, error) {
+	worker, err := p.workers.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire worker: %w", err)
+	}
+	return worker, nil
 }
 
 // Release returns a worker to the pool
@@ -80,13 +104,45 @@ func (p *Pool) Release(worker *Worker) {
 	if worker == nil {
 		return
 	}
+	p.workers.Release(worker)
+}
+
+// Resize changes the pool's maximum and minimum workers
+func (p *Pool) Resize(size, min int) error {
+	if err := p.workers.Resize(size, min); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.size = size
+	p.mu.Unlock()
+	return nil
+}
+
+// Stats reports the pool's workers
+func (p *Pool) Stats() core.PoolStats {
+	if p.workers == nil {
+		return core.PoolStats{}
+	}
+	return p.workers.Stats()
+}
 
+// SetCompileCache shares cache between the pool's workers. It must be
+// called before Initialize.
+func (p *Pool) SetCompileCache(cache *core.CompileCache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache = cache
+}
+
+// CompileCacheStats reports compile cache use, which is zero when the
+// cache is disabled
+func (p *Pool) CompileCacheStats() core.CompileCacheStats {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-
-	if !p.closed {
-		p.workers <- worker
+	if p.cache == nil {
+		return core.CompileCacheStats{}
 	}
+	return p.cache.Stats()
 }
 
 // Size returns the pool size
@@ -106,16 +162,11 @@ func (p *Pool) Close() {
 	}
 
 	p.closed = true
-
-	// Drain all workers from channel
-	for len(p.workers) > 0 {
-		<-p.workers
+	if p.workers != nil {
+		p.workers.Close()
 	}
 
-	// Shutdown all workers
-	for _, worker := range p.all {
-		worker.Shutdown()
+	if p.cache != nil {
+		p.cache.Close()
 	}
-
-	p.all = nil
 }
@@ -13,6 +13,7 @@ import "C"
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 
 	"github.com/griffincancode/polyglot.js/core"
@@ -42,35 +43,34 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 
 	r.config = config
 
-	// Initialize worker pool
-	poolSize := config.MaxConcurrency
-	if poolSize <= 0 {
-		poolSize = 4
+	// Initialize worker pool, loading the shared library, if specified,
+	// into each worker
+	size := poolSize(config)
+	r.pool = NewPool(size, core.LimitsFor(config), config.OutputEncoding)
+	r.pool.opts = core.WorkerPoolOptionsFor("rust", config, size)
+	r.pool.stdout, r.pool.stderr = core.OutputWriters(config)
+	r.pool.structured = config.StructuredOutput
+	r.pool.library, _ = config.Options["library_path"].(string)
+	cache, err := core.CompileCacheFor("rust", config)
+	if err != nil {
+		return err
 	}
-
-	r.pool = NewPool(poolSize)
-	if err := r.pool.Initialize(poolSize); err != nil {
+	r.pool.SetCompileCache(cache)
+	if err := r.pool.Initialize(size); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
 
-	// Load Rust shared library if specified
-	if libPath, ok := config.Options["library_path"].(string); ok {
-		// Load library in all workers
-		for i := 0; i < r.pool.Size(); i++ {
-			worker := r.pool.Acquire()
-			if worker != nil {
-				if err := worker.LoadLibrary(libPath); err != nil {
-					r.pool.Release(worker)
-					return fmt.Errorf("failed to load library in worker %d: %w", i, err)
-				}
-				r.pool.Release(worker)
-			}
-		}
-	}
-
 	return nil
 }
 
+// poolSize returns the number of workers for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 4
+	}
+	return config.MaxConcurrency
+}
+
 // Execute runs Rust code (via pre-compiled functions or compilation)
 func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{}) (interface{}, error) {
 	r.mu.RLock()
@@ -85,14 +85,43 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 	}
 
 	// Acquire a worker from the pool
-	worker := r.pool.Acquire()
-	if worker == nil {
-		return nil, fmt.Errorf("failed to acquire worker")
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
 	}
+	core.ReportWorker(ctx, worker.id)
 	defer r.pool.Release(worker)
 
 	// Execute the code
-	return worker.Execute(code, args...)
+	stdout, stderr := core.OutputFrom(ctx)
+	return worker.Execute(code, stdout, stderr, args...)
+}
+
+// ExecuteWithInput compiles and runs Rust code with stdin piped to the
+// program, which sees EOF once stdin is exhausted
+func (r *Runtime) ExecuteWithInput(ctx context.Context, code string, stdin io.Reader) (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.shutdown {
+		return nil, fmt.Errorf("runtime is shutdown")
+	}
+
+	if r.pool == nil {
+		return nil, fmt.Errorf("runtime not initialized")
+	}
+
+	// Acquire a worker from the pool
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	core.ReportWorker(ctx, worker.id)
+	defer r.pool.Release(worker)
+
+	// Execute the code
+	stdout, stderr := core.OutputFrom(ctx)
+	return worker.ExecuteWithInput(code, stdin, stdout, stderr)
 }
 
 // Call invokes a Rust function by symbol name
@@ -109,9 +138,9 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 
 	// Acquire a worker from the pool
-	worker := r.pool.Acquire()
-	if worker == nil {
-		return nil, fmt.Errorf("failed to acquire worker")
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
 	}
 	defer r.pool.Release(worker)
 
@@ -119,6 +148,26 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	return worker.Call(fn, args...)
 }
 
+// Reconfigure resizes the worker pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.pool.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
+	}
+	r.config = config
+	return nil
+}
+
 // Shutdown stops the runtime
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.mu.Lock()
@@ -137,6 +186,62 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Precompile compiles snippets into the compile cache without running
+// them, so executing them later skips rustc
+func (r *Runtime) Precompile(ctx context.Context, snippets []string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.shutdown {
+		return fmt.Errorf("runtime is shutdown")
+	}
+
+	if r.pool == nil {
+		return fmt.Errorf("runtime not initialized")
+	}
+
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.pool.Release(worker)
+
+	for i, code := range snippets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := worker.Precompile(code); err != nil {
+			return fmt.Errorf("snippet %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// CompileCacheStats reports how often compiled Rust binaries were reused
+func (r *Runtime) CompileCacheStats() core.CompileCacheStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool == nil {
+		return core.CompileCacheStats{}
+	}
+	return r.pool.CompileCacheStats()
+}
+
+// PoolStats reports the worker pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool == nil {
+		return core.PoolStats{}
+	}
+	return r.pool.Stats()
+}
+
+// Capabilities declares the concurrency model: each execution runs its own compiled binary
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyParallel}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "rust"
@@ -152,8 +257,8 @@ func (r *Runtime) Version() string {
 	}
 
 	// Try to get rustc version
-	worker := r.pool.Acquire()
-	if worker == nil {
+	worker, err := r.pool.Acquire(context.Background())
+	if err != nil {
 		return "unknown"
 	}
 	defer r.pool.Release(worker)
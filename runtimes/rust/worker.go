@@ -5,12 +5,16 @@ package rust
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Worker represents a Rust execution context
@@ -21,6 +25,15 @@ type Worker struct {
 	loader    *Loader
 	tempDir   string
 	rustcPath string
+	limits    core.ResourceLimits
+	encoding  core.OutputEncoding
+	stdout    io.Writer
+	stderr    io.Writer
+	cache     *core.CompileCache
+
+	// structured returns the delimited JSON result programs print, for
+	// StructuredOutput
+	structured bool
 }
 
 // NewWorker creates a Rust worker
@@ -58,8 +71,10 @@ func (w *Worker) Initialize() error {
 	return nil
 }
 
-// Execute runs Rust code (compiles and executes, or calls pre-loaded function)
-func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error) {
+// Execute runs Rust code (compiles and executes, or calls pre-loaded function).
+// A compiled program's output is also written to stdout and stderr, either
+// of which may be nil.
+func (w *Worker) Execute(code string, stdout, stderr io.Writer, args ...interface{}) (interface{}, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -73,7 +88,21 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	}
 
 	// Otherwise, compile and run the code
-	return w.compileAndRun(code, args...)
+	return w.compileAndRun(code, nil, stdout, stderr)
+}
+
+// ExecuteWithInput compiles and runs Rust code with stdin as the
+// program's standard input, also writing what it prints to stdout and
+// stderr, either of which may be nil
+func (w *Worker) ExecuteWithInput(code string, stdin io.Reader, stdout, stderr io.Writer) (interface{}, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return nil, fmt.Errorf("worker is shutdown")
+	}
+
+	return w.compileAndRun(code, stdin, stdout, stderr)
 }
 
 // Call invokes a Rust function by name
@@ -147,8 +176,10 @@ func (w *Worker) callFunction(fn string, args ...interface{}) (interface{}, erro
 	return nil, fmt.Errorf("complex argument handling not yet implemented")
 }
 
-// compileAndRun compiles Rust code and executes it
-func (w *Worker) compileAndRun(code string, args ...interface{}) (interface{}, error) {
+// compileAndRun compiles Rust code and executes it, reading stdin when
+// it is not nil and writing what it prints to stdout and stderr when they
+// are not nil
+func (w *Worker) compileAndRun(code string, stdin io.Reader, stdout, stderr io.Writer) (interface{}, error) {
 	if w.rustcPath == "" {
 		return nil, fmt.Errorf("rustc not available for compilation")
 	}
@@ -156,38 +187,27 @@ func (w *Worker) compileAndRun(code string, args ...interface{}) (interface{}, e
 	// Prepare the code (wrap in a main function if needed)
 	fullCode := w.prepareCode(code)
 
-	// Write to a temporary Rust file
-	rustFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d.rs", w.id))
-	if err := os.WriteFile(rustFile, []byte(fullCode), 0644); err != nil {
-		return nil, fmt.Errorf("failed to write Rust file: %w", err)
-	}
-	defer os.Remove(rustFile)
-
-	// Compile the Rust code
-	binaryFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d", w.id))
-	var compileStderr bytes.Buffer
-	compileCmd := exec.Command(w.rustcPath, "-o", binaryFile, rustFile)
-	compileCmd.Stderr = &compileStderr
-
-	if err := compileCmd.Run(); err != nil {
-		errMsg := compileStderr.String()
-		if errMsg != "" {
-			return nil, fmt.Errorf("compilation failed: %s", errMsg)
-		}
-		return nil, fmt.Errorf("compilation failed: %w", err)
+	// Compile the Rust code, or reuse the binary of an equivalent program
+	binaryFile, release, err := w.build(fullCode)
+	if err != nil {
+		return nil, err
 	}
-
-	// Clean up compiled binary after execution
-	defer os.Remove(binaryFile)
+	defer release()
 
 	// Execute the compiled binary
-	var stdout, stderr bytes.Buffer
+	var out, errOut bytes.Buffer
 	runCmd := exec.Command(binaryFile)
-	runCmd.Stdout = &stdout
-	runCmd.Stderr = &stderr
-
-	if err := runCmd.Run(); err != nil {
-		errMsg := stderr.String()
+	runCmd.Stdin = stdin
+	runCmd.Stdout = core.TeeWriter(&out, core.TeeWriter(w.stdout, stdout))
+	runCmd.Stderr = core.TeeWriter(&errOut, core.TeeWriter(w.stderr, stderr))
+
+	if err := core.RunLimited(runCmd, "rust", w.limits); err != nil {
+		var exceeded *core.ResourceExceededError
+		var crashed *core.RuntimeCrashedError
+		if errors.As(err, &exceeded) || errors.As(err, &crashed) {
+			return nil, err
+		}
+		errMsg := errOut.String()
 		if errMsg != "" {
 			return nil, fmt.Errorf("execution failed: %s", errMsg)
 		}
@@ -195,7 +215,15 @@ func (w *Worker) compileAndRun(code string, args ...interface{}) (interface{}, e
 	}
 
 	// Extract result from output
-	output := stdout.String()
+	if w.structured {
+		if result, found, err := core.ParseStructuredOutput(out.Bytes()); found {
+			return result, err
+		}
+	}
+	output, raw := core.DecodeOutput(out.Bytes(), w.encoding)
+	if raw != nil {
+		return raw, nil
+	}
 	return extractResult(output), nil
 }
 
@@ -266,3 +294,66 @@ func extractResult(output string) interface{} {
 	// Could parse into specific types based on format
 	return output
 }
+
+// Precompile builds code into the compile cache without running it
+func (w *Worker) Precompile(code string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return fmt.Errorf("worker is shutdown")
+	}
+	if w.cache == nil {
+		return core.NewError(core.CodeUnavailable, "compile cache is disabled")
+	}
+	if w.rustcPath == "" {
+		return fmt.Errorf("rustc not available for compilation")
+	}
+
+	_, release, err := w.build(w.prepareCode(code))
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+// build compiles fullCode, returning the binary and a func to call once it
+// has run. Binaries are shared through the compile cache when enabled.
+func (w *Worker) build(fullCode string) (string, func(), error) {
+	if w.cache == nil {
+		binaryFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d", w.id))
+		if err := w.compile(fullCode, binaryFile); err != nil {
+			return "", nil, err
+		}
+		return binaryFile, func() { os.Remove(binaryFile) }, nil
+	}
+	key := core.SourceKey(fullCode, "rustc", w.rustcPath, core.ToolchainVersion(w.rustcPath, "--version"))
+	return w.cache.Acquire(key, func(path string) error {
+		return w.compile(fullCode, path)
+	})
+}
+
+// compile compiles fullCode to binaryFile
+func (w *Worker) compile(fullCode, binaryFile string) error {
+	// Write to a temporary Rust file
+	rustFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d.rs", w.id))
+	if err := os.WriteFile(rustFile, []byte(fullCode), 0644); err != nil {
+		return fmt.Errorf("failed to write Rust file: %w", err)
+	}
+	defer os.Remove(rustFile)
+
+	// Compile the Rust code
+	var compileStderr bytes.Buffer
+	compileCmd := exec.Command(w.rustcPath, "-o", binaryFile, rustFile)
+	compileCmd.Stderr = &compileStderr
+
+	if err := compileCmd.Run(); err != nil {
+		errMsg := compileStderr.String()
+		if errMsg != "" {
+			return fmt.Errorf("compilation failed: %s", errMsg)
+		}
+		return fmt.Errorf("compilation failed: %w", err)
+	}
+	return nil
+}
@@ -5,22 +5,28 @@ package wasm
 
 import (
 	"fmt"
+	"math"
 	"sync"
 )
 
 // Module represents a compiled WASM module
 type Module struct {
 	bytecode  []byte
+	functions []*Function
 	exports   map[string]*Function
 	instances []*Instance
 	mu        sync.RWMutex
 }
 
-// Function represents an exported WASM function
+// Function represents a WASM function. Functions without a body are
+// placeholders that return 0.
 type Function struct {
-	name   string
-	params []ValueType
-	result ValueType
+	name    string
+	params  []ValueType
+	results []ValueType
+	locals  []ValueType
+	body    []byte
+	blocks  map[int]block
 }
 
 // Instance represents a WASM module instance
@@ -43,6 +49,7 @@ const (
 // Engine manages WASM module execution
 type Engine struct {
 	modules map[string]*Module
+	fuel    uint64
 	mu      sync.RWMutex
 }
 
@@ -130,26 +137,299 @@ func (e *Engine) Shutdown() error {
 	return nil
 }
 
-// parseModule parses WASM module sections
+// maxLocals bounds the locals a function may declare
+const maxLocals = 50000
+
+// signature is a function type from the type section
+type signature struct {
+	params  []ValueType
+	results []ValueType
+}
+
+// parseModule prepares a module for execution. Without fuel the module
+// is not interpreted and gets a placeholder _start, as before metering.
+// With fuel it is parsed and validated against the interpreted subset:
+// the type, function, export and code sections, with i32 and i64 locals,
+// constants, arithmetic and comparisons, and block, loop, if, br, br_if,
+// return and direct calls. Modules with imports, or whose code uses
+// memory, globals, tables, br_table, call_indirect or floats, are
+// rejected, so compiler output such as rustc, clang or TinyGo modules
+// only loads without fuel.
 func (e *Engine) parseModule(module *Module) error {
-	// Simplified parsing - actual implementation would parse all sections
-	// Type, Import, Function, Table, Memory, Global, Export, Start, etc.
+	if e.fuel == 0 {
+		module.exports["_start"] = &Function{
+			name:    "_start",
+			params:  []ValueType{},
+			results: []ValueType{ValueTypeI32},
+		}
+		return nil
+	}
+
+	var types []signature
+	var typeIndices []uint32
+	exports := make(map[string]uint32)
+
+	r := &reader{data: module.bytecode, pos: 8}
+	for r.pos < len(r.data) {
+		id, _ := r.byte()
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		payload, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+
+		s := &reader{data: payload}
+		switch id {
+		case 1: // type
+			types, err = parseTypes(s)
+		case 2: // import
+			if n, _ := s.u32(); n > 0 {
+				err = fmt.Errorf("modules with imports are not supported")
+			}
+		case 3: // function
+			typeIndices, err = parseIndices(s)
+		case 7: // export
+			err = parseExports(s, exports)
+		case 10: // code
+			err = parseCode(s, module, types, typeIndices)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(module.functions) != len(typeIndices) {
+		return fmt.Errorf("module declares %d functions but has %d bodies", len(typeIndices), len(module.functions))
+	}
+	for name, index := range exports {
+		if int(index) >= len(module.functions) {
+			return fmt.Errorf("export %s refers to unknown function %d", name, index)
+		}
+		module.functions[index].name = name
+		module.exports[name] = module.functions[index]
+	}
 
-	// For now, just create a placeholder export
-	module.exports["_start"] = &Function{
-		name:   "_start",
-		params: []ValueType{},
-		result: ValueTypeI32,
+	if len(module.functions) == 0 {
+		module.exports["_start"] = &Function{
+			name:    "_start",
+			params:  []ValueType{},
+			results: []ValueType{ValueTypeI32},
+		}
 	}
 
 	return nil
 }
 
-// callFunction executes a WASM function
+func parseTypes(r *reader) ([]signature, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	var types []signature
+	for i := uint32(0); i < n; i++ {
+		if form, err := r.byte(); err != nil || form != 0x60 {
+			return nil, fmt.Errorf("invalid function type")
+		}
+		params, err := r.valueTypes()
+		if err != nil {
+			return nil, err
+		}
+		results, err := r.valueTypes()
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, signature{params: params, results: results})
+	}
+	return types, nil
+}
+
+func parseIndices(r *reader) ([]uint32, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	var indices []uint32
+	for i := uint32(0); i < n; i++ {
+		index, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// parseExports records exported functions by name
+func parseExports(r *reader, exports map[string]uint32) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		length, err := r.u32()
+		if err != nil {
+			return err
+		}
+		name, err := r.bytes(int(length))
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		index, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if kind == 0 {
+			exports[string(name)] = index
+		}
+	}
+	return nil
+}
+
+// parseCode adds a function to module for each body in the code section
+func parseCode(r *reader, module *Module, types []signature, typeIndices []uint32) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(typeIndices) {
+		return fmt.Errorf("module declares %d functions but has %d bodies", len(typeIndices), n)
+	}
+
+	for i := uint32(0); i < n; i++ {
+		if int(typeIndices[i]) >= len(types) {
+			return fmt.Errorf("function %d has unknown type %d", i, typeIndices[i])
+		}
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		code, err := r.bytes(int(size))
+		if err != nil {
+			return err
+		}
+
+		body := &reader{data: code}
+		entries, err := body.u32()
+		if err != nil {
+			return err
+		}
+		var locals []ValueType
+		for j := uint32(0); j < entries; j++ {
+			count, err := body.u32()
+			if err != nil {
+				return err
+			}
+			if len(locals)+int(count) > maxLocals {
+				return fmt.Errorf("function %d declares more than %d locals", i, maxLocals)
+			}
+			t, err := body.valueType()
+			if err != nil {
+				return err
+			}
+			for k := uint32(0); k < count; k++ {
+				locals = append(locals, t)
+			}
+		}
+
+		fn := &Function{
+			name:    fmt.Sprintf("function %d", i),
+			params:  types[typeIndices[i]].params,
+			results: types[typeIndices[i]].results,
+			locals:  locals,
+			body:    code[body.pos:],
+		}
+		if fn.blocks, err = scanBody(fn.body); err != nil {
+			return fmt.Errorf("%s: %w", fn.name, err)
+		}
+		module.functions = append(module.functions, fn)
+	}
+	return nil
+}
+
+// callFunction executes a WASM function, metering instructions against
+// the engine's fuel
 func (e *Engine) callFunction(instance *Instance, fn *Function, args ...interface{}) (interface{}, error) {
-	// Simplified execution - actual implementation would interpret bytecode
-	// or use a JIT compiler
+	if fn.body == nil {
+		return int32(0), nil
+	}
+
+	if len(args) != len(fn.params) {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", fn.name, len(fn.params), len(args))
+	}
+	values := make([]uint64, len(args))
+	for i, arg := range args {
+		v, err := toValue(arg, fn.params[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s argument %d: %w", fn.name, i, err)
+		}
+		values[i] = v
+	}
+
+	m := &machine{module: instance.module, fuel: e.fuel}
+	results, err := m.invoke(fn, values)
+	if err != nil {
+		return nil, err
+	}
 
-	// For now, return a placeholder result
-	return int32(0), nil
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return fromValue(results[0], fn.results[0]), nil
+	}
+	out := make([]interface{}, len(results))
+	for i, v := range results {
+		out[i] = fromValue(v, fn.results[i])
+	}
+	return out, nil
+}
+
+// toValue converts a Go argument to a WASM value of type t
+func toValue(arg interface{}, t ValueType) (uint64, error) {
+	switch t {
+	case ValueTypeI32, ValueTypeI64:
+		switch v := arg.(type) {
+		case int:
+			return uint64(v), nil
+		case int32:
+			return uint64(v), nil
+		case int64:
+			return uint64(v), nil
+		}
+	case ValueTypeF32:
+		switch v := arg.(type) {
+		case float32:
+			return uint64(math.Float32bits(v)), nil
+		case float64:
+			return uint64(math.Float32bits(float32(v))), nil
+		}
+	case ValueTypeF64:
+		switch v := arg.(type) {
+		case float32:
+			return math.Float64bits(float64(v)), nil
+		case float64:
+			return math.Float64bits(v), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot convert %T", arg)
+}
+
+// fromValue converts a WASM value of type t to Go
+func fromValue(v uint64, t ValueType) interface{} {
+	switch t {
+	case ValueTypeI64:
+		return int64(v)
+	case ValueTypeF32:
+		return math.Float32frombits(uint32(v))
+	case ValueTypeF64:
+		return math.Float64frombits(v)
+	}
+	return int32(uint32(v))
 }
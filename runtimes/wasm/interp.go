@@ -0,0 +1,548 @@
+//go:build runtime_wasm
+// +build runtime_wasm
+
+package wasm
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// ErrFuelExhausted is returned by calls that execute more instructions
+// than RuntimeConfig.WASMInterpreterFuel allows
+var ErrFuelExhausted = core.NewError(core.CodeResourceExhausted, "WASM interpreter fuel exhausted")
+
+// maxCallDepth bounds recursion between WASM functions
+const maxCallDepth = 1024
+
+// Opcodes with immediates or control flow. Numeric opcodes without
+// immediates are listed in unaryOps and binaryOps.
+const (
+	opUnreachable = 0x00
+	opNop         = 0x01
+	opBlock       = 0x02
+	opLoop        = 0x03
+	opIf          = 0x04
+	opElse        = 0x05
+	opEnd         = 0x0B
+	opBr          = 0x0C
+	opBrIf        = 0x0D
+	opReturn      = 0x0F
+	opCall        = 0x10
+	opDrop        = 0x1A
+	opSelect      = 0x1B
+	opLocalGet    = 0x20
+	opLocalSet    = 0x21
+	opLocalTee    = 0x22
+	opI32Const    = 0x41
+	opI64Const    = 0x42
+)
+
+// unaryOps pop one operand and push the result
+var unaryOps = map[byte]func(a uint64) uint64{
+	0x45: func(a uint64) uint64 { return b2u(uint32(a) == 0) }, // i32.eqz
+	0x50: func(a uint64) uint64 { return b2u(a == 0) },         // i64.eqz
+}
+
+// binaryOps pop two operands and push the result; they fail on traps such
+// as division by zero
+var binaryOps = map[byte]func(a, b uint64) (uint64, error){
+	0x46: i32Cmp(func(a, b int32) bool { return a == b }),
+	0x47: i32Cmp(func(a, b int32) bool { return a != b }),
+	0x48: i32Cmp(func(a, b int32) bool { return a < b }),
+	0x49: u32Cmp(func(a, b uint32) bool { return a < b }),
+	0x4A: i32Cmp(func(a, b int32) bool { return a > b }),
+	0x4B: u32Cmp(func(a, b uint32) bool { return a > b }),
+	0x4C: i32Cmp(func(a, b int32) bool { return a <= b }),
+	0x4D: u32Cmp(func(a, b uint32) bool { return a <= b }),
+	0x4E: i32Cmp(func(a, b int32) bool { return a >= b }),
+	0x4F: u32Cmp(func(a, b uint32) bool { return a >= b }),
+	0x51: i64Cmp(func(a, b int64) bool { return a == b }),
+	0x52: i64Cmp(func(a, b int64) bool { return a != b }),
+	0x53: i64Cmp(func(a, b int64) bool { return a < b }),
+	0x55: i64Cmp(func(a, b int64) bool { return a > b }),
+	0x57: i64Cmp(func(a, b int64) bool { return a <= b }),
+	0x59: i64Cmp(func(a, b int64) bool { return a >= b }),
+	0x6A: u32Op(func(a, b uint32) uint32 { return a + b }),
+	0x6B: u32Op(func(a, b uint32) uint32 { return a - b }),
+	0x6C: u32Op(func(a, b uint32) uint32 { return a * b }),
+	0x6D: i32DivS,
+	0x6E: i32DivU,
+	0x6F: i32RemS,
+	0x70: i32RemU,
+	0x71: u32Op(func(a, b uint32) uint32 { return a & b }),
+	0x72: u32Op(func(a, b uint32) uint32 { return a | b }),
+	0x73: u32Op(func(a, b uint32) uint32 { return a ^ b }),
+	0x74: u32Op(func(a, b uint32) uint32 { return a << (b % 32) }),
+	0x75: u32Op(func(a, b uint32) uint32 { return uint32(int32(a) >> (b % 32)) }),
+	0x76: u32Op(func(a, b uint32) uint32 { return a >> (b % 32) }),
+	0x7C: u64Op(func(a, b uint64) uint64 { return a + b }),
+	0x7D: u64Op(func(a, b uint64) uint64 { return a - b }),
+	0x7E: u64Op(func(a, b uint64) uint64 { return a * b }),
+}
+
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func i32Cmp(cmp func(a, b int32) bool) func(a, b uint64) (uint64, error) {
+	return func(a, b uint64) (uint64, error) { return b2u(cmp(int32(a), int32(b))), nil }
+}
+
+func u32Cmp(cmp func(a, b uint32) bool) func(a, b uint64) (uint64, error) {
+	return func(a, b uint64) (uint64, error) { return b2u(cmp(uint32(a), uint32(b))), nil }
+}
+
+func i64Cmp(cmp func(a, b int64) bool) func(a, b uint64) (uint64, error) {
+	return func(a, b uint64) (uint64, error) { return b2u(cmp(int64(a), int64(b))), nil }
+}
+
+func u32Op(op func(a, b uint32) uint32) func(a, b uint64) (uint64, error) {
+	return func(a, b uint64) (uint64, error) { return uint64(op(uint32(a), uint32(b))), nil }
+}
+
+func u64Op(op func(a, b uint64) uint64) func(a, b uint64) (uint64, error) {
+	return func(a, b uint64) (uint64, error) { return op(a, b), nil }
+}
+
+var (
+	errDivideByZero    = fmt.Errorf("integer divide by zero")
+	errIntegerOverflow = fmt.Errorf("integer overflow")
+)
+
+func i32DivS(a, b uint64) (uint64, error) {
+	x, y := int32(a), int32(b)
+	if y == 0 {
+		return 0, errDivideByZero
+	}
+	if x == math.MinInt32 && y == -1 {
+		return 0, errIntegerOverflow
+	}
+	return uint64(uint32(x / y)), nil
+}
+
+func i32DivU(a, b uint64) (uint64, error) {
+	if uint32(b) == 0 {
+		return 0, errDivideByZero
+	}
+	return uint64(uint32(a) / uint32(b)), nil
+}
+
+func i32RemS(a, b uint64) (uint64, error) {
+	x, y := int32(a), int32(b)
+	if y == 0 {
+		return 0, errDivideByZero
+	}
+	if y == -1 {
+		return 0, nil
+	}
+	return uint64(uint32(x % y)), nil
+}
+
+func i32RemU(a, b uint64) (uint64, error) {
+	if uint32(b) == 0 {
+		return 0, errDivideByZero
+	}
+	return uint64(uint32(a) % uint32(b)), nil
+}
+
+// reader decodes the WASM binary format
+type reader struct {
+	data []byte
+	pos  int
+}
+
+var errTruncated = fmt.Errorf("unexpected end of module")
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errTruncated
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// u32 reads an unsigned LEB128 integer
+func (r *reader) u32() (uint32, error) {
+	var result uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+	return 0, fmt.Errorf("integer too long")
+}
+
+// signed reads a signed LEB128 integer of up to bits bits
+func (r *reader) signed(bits uint) (int64, error) {
+	var result int64
+	for shift := uint(0); shift < bits+7; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= int64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			if shift+7 < 64 && b&0x40 != 0 {
+				result |= -1 << (shift + 7)
+			}
+			return result, nil
+		}
+	}
+	return 0, fmt.Errorf("integer too long")
+}
+
+func (r *reader) valueType() (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case 0x7F:
+		return ValueTypeI32, nil
+	case 0x7E:
+		return ValueTypeI64, nil
+	case 0x7D:
+		return ValueTypeF32, nil
+	case 0x7C:
+		return ValueTypeF64, nil
+	}
+	return 0, fmt.Errorf("unsupported value type 0x%02x", b)
+}
+
+func (r *reader) valueTypes() ([]ValueType, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	var types []ValueType
+	for i := uint32(0); i < n; i++ {
+		t, err := r.valueType()
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// blockArity reads a block type, returning how many values the block leaves
+func (r *reader) blockArity() (int, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case 0x40:
+		return 0, nil
+	case 0x7F, 0x7E, 0x7D, 0x7C:
+		return 1, nil
+	}
+	return 0, fmt.Errorf("unsupported block type 0x%02x", b)
+}
+
+// block records where a block, loop or if ends, and where an if's else is.
+// An else is recorded as well, pointing at the end of its if.
+type block struct {
+	arity  int
+	elsePC int
+	endPC  int
+}
+
+// scanBody checks that every instruction in body is supported and matches
+// each block with its else and end
+func scanBody(body []byte) (map[int]block, error) {
+	blocks := make(map[int]block)
+	var open []int
+	r := &reader{data: body}
+	for r.pos < len(body) {
+		pc := r.pos
+		op, _ := r.byte()
+		switch op {
+		case opBlock, opLoop, opIf:
+			arity, err := r.blockArity()
+			if err != nil {
+				return nil, err
+			}
+			blocks[pc] = block{arity: arity, elsePC: -1}
+			open = append(open, pc)
+		case opElse:
+			if len(open) == 0 || body[open[len(open)-1]] != opIf {
+				return nil, fmt.Errorf("else outside if")
+			}
+			b := blocks[open[len(open)-1]]
+			b.elsePC = pc
+			blocks[open[len(open)-1]] = b
+		case opEnd:
+			if len(open) == 0 {
+				if r.pos != len(body) {
+					return nil, fmt.Errorf("instructions after function end")
+				}
+				return blocks, nil
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			b := blocks[start]
+			b.endPC = pc
+			blocks[start] = b
+			if b.elsePC >= 0 {
+				blocks[b.elsePC] = block{elsePC: -1, endPC: pc}
+			}
+		case opBr, opBrIf, opCall, opLocalGet, opLocalSet, opLocalTee:
+			if _, err := r.u32(); err != nil {
+				return nil, err
+			}
+		case opI32Const:
+			if _, err := r.signed(32); err != nil {
+				return nil, err
+			}
+		case opI64Const:
+			if _, err := r.signed(64); err != nil {
+				return nil, err
+			}
+		case opUnreachable, opNop, opReturn, opDrop, opSelect:
+		default:
+			_, unary := unaryOps[op]
+			_, binary := binaryOps[op]
+			if !unary && !binary {
+				return nil, fmt.Errorf("unsupported opcode 0x%02x", op)
+			}
+		}
+	}
+	return nil, fmt.Errorf("function body is missing end")
+}
+
+// label is a branch target; loops branch back to their start, other
+// blocks past their end
+type label struct {
+	cont   int
+	loop   bool
+	arity  int
+	height int
+}
+
+// machine runs one call, sharing its fuel with the calls it makes
+type machine struct {
+	module *Module
+	fuel   uint64
+	depth  int
+}
+
+// invoke runs fn with args, returning its results
+func (m *machine) invoke(fn *Function, args []uint64) ([]uint64, error) {
+	if m.depth >= maxCallDepth {
+		return nil, fmt.Errorf("call stack exhausted")
+	}
+	m.depth++
+	defer func() { m.depth-- }()
+
+	locals := make([]uint64, len(fn.params)+len(fn.locals))
+	copy(locals, args)
+
+	var stack []uint64
+	need := func(n int) error {
+		if len(stack) < n {
+			return fmt.Errorf("operand stack underflow in %s", fn.name)
+		}
+		return nil
+	}
+	pop := func() uint64 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+
+	// The function body is the outermost label; branching to it returns
+	labels := []label{{cont: len(fn.body), arity: len(fn.results)}}
+	branch := func(depth uint32) (int, error) {
+		if int(depth) >= len(labels) {
+			return 0, fmt.Errorf("branch depth %d out of range", depth)
+		}
+		target := labels[len(labels)-1-int(depth)]
+		arity := target.arity
+		if target.loop {
+			arity = 0
+		}
+		if err := need(target.height + arity); err != nil {
+			return 0, err
+		}
+		n := copy(stack[target.height:], stack[len(stack)-arity:])
+		stack = stack[:target.height+n]
+		if target.loop {
+			labels = labels[:len(labels)-int(depth)]
+		} else {
+			labels = labels[:len(labels)-1-int(depth)]
+		}
+		return target.cont, nil
+	}
+	results := func() ([]uint64, error) {
+		if err := need(len(fn.results)); err != nil {
+			return nil, err
+		}
+		return append([]uint64(nil), stack[len(stack)-len(fn.results):]...), nil
+	}
+
+	r := &reader{data: fn.body}
+	for {
+		if m.fuel == 0 {
+			return nil, ErrFuelExhausted
+		}
+		m.fuel--
+
+		pc := r.pos
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case opUnreachable:
+			return nil, fmt.Errorf("unreachable executed in %s", fn.name)
+		case opNop:
+		case opBlock, opLoop:
+			b := fn.blocks[pc]
+			r.pos++
+			if op == opLoop {
+				labels = append(labels, label{cont: r.pos, loop: true, height: len(stack)})
+			} else {
+				labels = append(labels, label{cont: b.endPC + 1, arity: b.arity, height: len(stack)})
+			}
+		case opIf:
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			b := fn.blocks[pc]
+			r.pos++
+			cond := pop()
+			labels = append(labels, label{cont: b.endPC + 1, arity: b.arity, height: len(stack)})
+			if uint32(cond) == 0 {
+				if b.elsePC >= 0 {
+					r.pos = b.elsePC + 1
+				} else {
+					r.pos = b.endPC
+				}
+			}
+		case opElse:
+			// Reached at the end of the then branch
+			r.pos = fn.blocks[pc].endPC
+		case opEnd:
+			labels = labels[:len(labels)-1]
+			if len(labels) == 0 {
+				return results()
+			}
+		case opBr, opBrIf:
+			depth, _ := r.u32()
+			if op == opBrIf {
+				if err := need(1); err != nil {
+					return nil, err
+				}
+				if uint32(pop()) == 0 {
+					continue
+				}
+			}
+			cont, err := branch(depth)
+			if err != nil {
+				return nil, err
+			}
+			if len(labels) == 0 {
+				return results()
+			}
+			r.pos = cont
+		case opReturn:
+			return results()
+		case opCall:
+			index, _ := r.u32()
+			if int(index) >= len(m.module.functions) {
+				return nil, fmt.Errorf("call to unknown function %d", index)
+			}
+			callee := m.module.functions[index]
+			if err := need(len(callee.params)); err != nil {
+				return nil, err
+			}
+			args := append([]uint64(nil), stack[len(stack)-len(callee.params):]...)
+			stack = stack[:len(stack)-len(callee.params)]
+			values, err := m.invoke(callee, args)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, values...)
+		case opDrop:
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			pop()
+		case opSelect:
+			if err := need(3); err != nil {
+				return nil, err
+			}
+			cond, b, a := pop(), pop(), pop()
+			if uint32(cond) != 0 {
+				stack = append(stack, a)
+			} else {
+				stack = append(stack, b)
+			}
+		case opLocalGet, opLocalSet, opLocalTee:
+			index, _ := r.u32()
+			if int(index) >= len(locals) {
+				return nil, fmt.Errorf("local %d out of range in %s", index, fn.name)
+			}
+			switch op {
+			case opLocalGet:
+				stack = append(stack, locals[index])
+			case opLocalSet:
+				if err := need(1); err != nil {
+					return nil, err
+				}
+				locals[index] = pop()
+			case opLocalTee:
+				if err := need(1); err != nil {
+					return nil, err
+				}
+				locals[index] = stack[len(stack)-1]
+			}
+		case opI32Const:
+			v, _ := r.signed(32)
+			stack = append(stack, uint64(uint32(v)))
+		case opI64Const:
+			v, _ := r.signed(64)
+			stack = append(stack, uint64(v))
+		default:
+			if unary, ok := unaryOps[op]; ok {
+				if err := need(1); err != nil {
+					return nil, err
+				}
+				stack = append(stack, unary(pop()))
+				continue
+			}
+			if err := need(2); err != nil {
+				return nil, err
+			}
+			b, a := pop(), pop()
+			v, err := binaryOps[op](a, b)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, v)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+//go:build runtime_wasm
+// +build runtime_wasm
+
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// maxModuleSize bounds modules read from a file or URL
+const maxModuleSize = 256 << 20
+
+// wasmMagic starts every binary WASM module
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6D}
+
+// LoadModuleFromFile loads a binary WASM module from a .wasm file
+func (r *Runtime) LoadModuleFromFile(ctx context.Context, path string) error {
+	bytecode, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read module: %w", err)
+	}
+	return r.loadVerified(ctx, bytecode)
+}
+
+// LoadModuleFromURL fetches and loads a binary WASM module. The fetch is
+// bounded by ctx, so give it a deadline to time out slow servers.
+func (r *Runtime) LoadModuleFromURL(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid module URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/wasm")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch module: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("module URL returned %s", resp.Status)
+	}
+
+	bytecode, err := io.ReadAll(io.LimitReader(resp.Body, maxModuleSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read module: %w", err)
+	}
+	if len(bytecode) > maxModuleSize {
+		return fmt.Errorf("module exceeds %d bytes", maxModuleSize)
+	}
+	return r.loadVerified(ctx, bytecode)
+}
+
+// ErrModuleCorrupted is returned by Execute and Call when
+// RuntimeConfig.WASMVerifyModules is set and a loaded module's bytes no
+// longer match the checksum they had when it was loaded
+var ErrModuleCorrupted = core.NewError(core.CodeInternal, "WASM module corrupted")
+
+// checksumOf identifies a module by the SHA-256 of its bytes
+func checksumOf(bytecode []byte) string {
+	sum := sha256.Sum256(bytecode)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadVerified checks the magic bytes of bytecode and loads it, unless a
+// module with the same checksum is already loaded
+func (r *Runtime) loadVerified(ctx context.Context, bytecode []byte) error {
+	if len(bytecode) < 8 || !bytes.Equal(bytecode[:4], wasmMagic) {
+		return fmt.Errorf("not a binary WASM module")
+	}
+
+	r.mu.RLock()
+	_, cached := r.modules[checksumOf(bytecode)]
+	r.mu.RUnlock()
+	if cached {
+		return nil
+	}
+	return r.LoadModule(ctx, bytecode)
+}
+
+// cacheModule records a loaded module by its checksum. The module keeps
+// the caller's bytes, as the engine does.
+func (r *Runtime) cacheModule(bytecode []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.modules == nil {
+		r.modules = make(map[string][]byte)
+	}
+	r.modules[checksumOf(bytecode)] = bytecode
+}
+
+// verifyModules checks the loaded modules against their checksums when
+// config.WASMVerifyModules is set. A corrupted module is dropped, so
+// loading its original bytes again restores it.
+func (r *Runtime) verifyModules() error {
+	r.mu.RLock()
+	verify := r.config.WASMVerifyModules
+	r.mu.RUnlock()
+	if !verify {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for checksum, bytecode := range r.modules {
+		if checksumOf(bytecode) != checksum {
+			delete(r.modules, checksum)
+			return fmt.Errorf("module %s: %w", checksum[:12], ErrModuleCorrupted)
+		}
+	}
+	return nil
+}
@@ -4,14 +4,19 @@
 package wasm
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Pool manages WASM execution workers
 type Pool struct {
-	workers chan *Worker
+	workers *core.WorkerPool[*Worker]
+	opts    core.WorkerPoolOptions
 	size    int
+	fuel    uint64
 	mu      sync.Mutex
 	closed  bool
 }
@@ -32,32 +37,60 @@ func (p *Pool) Initialize() error {
 		return fmt.Errorf("pool is closed")
 	}
 
-	p.workers = make(chan *Worker, p.size)
-
-	for i := 0; i < p.size; i++ {
-		worker := NewWorker(i)
-		if err := worker.Initialize(); err != nil {
-			return fmt.Errorf("failed to initialize worker %d: %w", i, err)
-		}
-		p.workers <- worker
+	opts := p.opts
+	opts.Max = p.size
+	workers, err := core.NewWorkerPoolWith(opts, p.newWorker, (*Worker).Shutdown)
+	if err != nil {
+		return err
 	}
-
+	p.workers = workers
 	return nil
 }
 
-// Acquire gets a worker from the pool
-func (p *Pool) Acquire() *Worker {
-	return <-p.workers
+// newWorker creates and initializes a worker
+func (p *Pool) newWorker(id int) (*Worker, error) {
+	worker := NewWorker(id)
+	worker.engine.fuel = p.fuel
+	if err := worker.Initialize(); err != nil {
+		return nil, err
+	}
+	return worker, nil
+}
+
+// Acquire gets a worker from the pool, waiting until ctx ends
+func (p *Pool) Acquire(ctx context.Context) (*Worker, error) {
+	worker, err := p.workers.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire worker: %w", err)
+	}
+	return worker, nil
 }
 
 // Release returns a worker to the pool
 func (p *Pool) Release(worker *Worker) {
-	select {
-	case p.workers <- worker:
-	default:
-		// Pool is closed, just cleanup the worker
-		worker.Shutdown()
+	p.workers.Release(worker)
+}
+
+// Resize changes the pool's maximum and minimum workers
+func (p *Pool) Resize(size, min int) error {
+	if p.workers == nil {
+		return fmt.Errorf("pool is not initialized")
+	}
+	if err := p.workers.Resize(size, min); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.size = size
+	p.mu.Unlock()
+	return nil
+}
+
+// Stats reports the pool's workers
+func (p *Pool) Stats() core.PoolStats {
+	if p.workers == nil {
+		return core.PoolStats{}
 	}
+	return p.workers.Stats()
 }
 
 // Close shuts down the pool
@@ -70,10 +103,7 @@ func (p *Pool) Close() {
 	}
 
 	p.closed = true
-	close(p.workers)
-
-	// Drain and shutdown all workers
-	for worker := range p.workers {
-		worker.Shutdown()
+	if p.workers != nil {
+		p.workers.Close()
 	}
 }
@@ -15,6 +15,7 @@ import (
 type Runtime struct {
 	config   core.RuntimeConfig
 	pool     *Pool
+	modules  map[string][]byte
 	mu       sync.RWMutex
 	shutdown bool
 }
@@ -36,8 +37,11 @@ func (r *Runtime) Initialize(ctx context.Context, config core.RuntimeConfig) err
 	}
 
 	r.config = config
+	r.pool.fuel = config.WASMInterpreterFuel
 
 	// Initialize the pool
+	r.pool.size = poolSize(config)
+	r.pool.opts = core.WorkerPoolOptionsFor("wasm", config, r.pool.size)
 	if err := r.pool.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize pool: %w", err)
 	}
@@ -54,7 +58,15 @@ func (r *Runtime) Execute(ctx context.Context, code string, args ...interface{})
 	}
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	if err := r.verifyModules(); err != nil {
+		return nil, err
+	}
+
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	core.ReportWorker(ctx, worker.id)
 	defer r.pool.Release(worker)
 
 	// Execute with context cancellation support
@@ -81,7 +93,14 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	if err := r.verifyModules(); err != nil {
+		return nil, err
+	}
+
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer r.pool.Release(worker)
 
 	// Call with context cancellation support
@@ -99,6 +118,34 @@ func (r *Runtime) Call(ctx context.Context, fn string, args ...interface{}) (int
 	}
 }
 
+// Reconfigure resizes the worker pool for a changed MaxConcurrency or
+// MinWorkers; other settings are fixed at initialization
+func (r *Runtime) Reconfigure(ctx context.Context, config core.RuntimeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shutdown || r.pool == nil {
+		return fmt.Errorf("runtime is not running")
+	}
+	if err := core.PoolSettingsOnly(r.config, config); err != nil {
+		return err
+	}
+
+	if err := r.pool.Resize(poolSize(config), config.MinWorkers); err != nil {
+		return fmt.Errorf("failed to resize pool: %w", err)
+	}
+	r.config = config
+	return nil
+}
+
+// poolSize returns the number of workers for config
+func poolSize(config core.RuntimeConfig) int {
+	if config.MaxConcurrency <= 0 {
+		return 10
+	}
+	return config.MaxConcurrency
+}
+
 // Shutdown stops the runtime
 func (r *Runtime) Shutdown(ctx context.Context) error {
 	r.mu.Lock()
@@ -117,6 +164,21 @@ func (r *Runtime) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// PoolStats reports the worker pool
+func (r *Runtime) PoolStats() core.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pool == nil {
+		return core.PoolStats{}
+	}
+	return r.pool.Stats()
+}
+
+// Capabilities declares the concurrency model: each worker owns a module instance
+func (r *Runtime) Capabilities() core.RuntimeCapabilities {
+	return core.RuntimeCapabilities{Concurrency: core.ConcurrencyPerState}
+}
+
 // Name returns the runtime identifier
 func (r *Runtime) Name() string {
 	return "wasm"
@@ -136,10 +198,17 @@ func (r *Runtime) LoadModule(ctx context.Context, bytecode []byte) error {
 	}
 	r.mu.RUnlock()
 
-	worker := r.pool.Acquire()
+	worker, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
 	defer r.pool.Release(worker)
 
-	return worker.LoadModule(bytecode)
+	if err := worker.LoadModule(bytecode); err != nil {
+		return err
+	}
+	r.cacheModule(bytecode)
+	return nil
 }
 
 type result struct {
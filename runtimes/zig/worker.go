@@ -5,13 +5,17 @@ package zig
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 	"unsafe"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // Worker represents a Zig execution context
@@ -22,6 +26,15 @@ type Worker struct {
 	loader   *Loader
 	tempDir  string
 	zigPath  string
+	limits   core.ResourceLimits
+	encoding core.OutputEncoding
+	stdout   io.Writer
+	stderr   io.Writer
+	cache    *core.CompileCache
+
+	// structured returns the delimited JSON result programs print, for
+	// StructuredOutput
+	structured bool
 }
 
 // NewWorker creates a Zig worker
@@ -59,8 +72,10 @@ func (w *Worker) Initialize() error {
 	return nil
 }
 
-// Execute runs Zig code (compiles and executes, or calls pre-loaded function)
-func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error) {
+// Execute runs Zig code (compiles and executes, or calls pre-loaded function).
+// A compiled program's output is also written to stdout and stderr, either
+// of which may be nil.
+func (w *Worker) Execute(code string, stdout, stderr io.Writer, args ...interface{}) (interface{}, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -74,7 +89,21 @@ func (w *Worker) Execute(code string, args ...interface{}) (interface{}, error)
 	}
 
 	// Otherwise, compile and run the code
-	return w.compileAndRun(code, args...)
+	return w.compileAndRun(code, nil, stdout, stderr)
+}
+
+// ExecuteWithInput compiles and runs Zig code with stdin as the
+// program's standard input, also writing what it prints to stdout and
+// stderr, either of which may be nil
+func (w *Worker) ExecuteWithInput(code string, stdin io.Reader, stdout, stderr io.Writer) (interface{}, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return nil, fmt.Errorf("worker is shutdown")
+	}
+
+	return w.compileAndRun(code, stdin, stdout, stderr)
 }
 
 // Call invokes a Zig function by name
@@ -167,8 +196,10 @@ func (w *Worker) callFunction(fn string, args ...interface{}) (interface{}, erro
 	return nil, fmt.Errorf("complex argument handling not yet implemented")
 }
 
-// compileAndRun compiles Zig code and executes it
-func (w *Worker) compileAndRun(code string, args ...interface{}) (interface{}, error) {
+// compileAndRun compiles Zig code and executes it, reading stdin when
+// it is not nil and writing what it prints to stdout and stderr when they
+// are not nil
+func (w *Worker) compileAndRun(code string, stdin io.Reader, stdout, stderr io.Writer) (interface{}, error) {
 	if w.zigPath == "" {
 		return nil, fmt.Errorf("zig not available for compilation")
 	}
@@ -176,38 +207,27 @@ func (w *Worker) compileAndRun(code string, args ...interface{}) (interface{}, e
 	// Prepare the code (wrap in a main function if needed)
 	fullCode := w.prepareCode(code)
 
-	// Write to a temporary Zig file
-	zigFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d.zig", w.id))
-	if err := os.WriteFile(zigFile, []byte(fullCode), 0644); err != nil {
-		return nil, fmt.Errorf("failed to write Zig file: %w", err)
-	}
-	defer os.Remove(zigFile)
-
-	// Compile and run the Zig code
-	binaryFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d", w.id))
-	var compileStderr bytes.Buffer
-	compileCmd := exec.Command(w.zigPath, "build-exe", zigFile, "-femit-bin="+binaryFile)
-	compileCmd.Stderr = &compileStderr
-
-	if err := compileCmd.Run(); err != nil {
-		errMsg := compileStderr.String()
-		if errMsg != "" {
-			return nil, fmt.Errorf("compilation failed: %s", errMsg)
-		}
-		return nil, fmt.Errorf("compilation failed: %w", err)
+	// Compile the Zig code, or reuse the binary of an equivalent program
+	binaryFile, release, err := w.build(fullCode)
+	if err != nil {
+		return nil, err
 	}
-
-	// Clean up compiled binary after execution
-	defer os.Remove(binaryFile)
+	defer release()
 
 	// Execute the compiled binary
-	var stdout, stderr bytes.Buffer
+	var out, errOut bytes.Buffer
 	runCmd := exec.Command(binaryFile)
-	runCmd.Stdout = &stdout
-	runCmd.Stderr = &stderr
-
-	if err := runCmd.Run(); err != nil {
-		errMsg := stderr.String()
+	runCmd.Stdin = stdin
+	runCmd.Stdout = core.TeeWriter(&out, core.TeeWriter(w.stdout, stdout))
+	runCmd.Stderr = core.TeeWriter(&errOut, core.TeeWriter(w.stderr, stderr))
+
+	if err := core.RunLimited(runCmd, "zig", w.limits); err != nil {
+		var exceeded *core.ResourceExceededError
+		var crashed *core.RuntimeCrashedError
+		if errors.As(err, &exceeded) || errors.As(err, &crashed) {
+			return nil, err
+		}
+		errMsg := errOut.String()
 		if errMsg != "" {
 			return nil, fmt.Errorf("execution failed: %s", errMsg)
 		}
@@ -215,9 +235,18 @@ func (w *Worker) compileAndRun(code string, args ...interface{}) (interface{}, e
 	}
 
 	// Extract result from output (check both stdout and stderr since std.debug.print goes to stderr)
-	output := stdout.String()
-	if output == "" {
-		output = stderr.String() // std.debug.print outputs to stderr
+	data := out.Bytes()
+	if len(data) == 0 {
+		data = errOut.Bytes() // std.debug.print outputs to stderr
+	}
+	if w.structured {
+		if result, found, err := core.ParseStructuredOutput(data); found {
+			return result, err
+		}
+	}
+	output, raw := core.DecodeOutput(data, w.encoding)
+	if raw != nil {
+		return raw, nil
 	}
 	return extractResult(output), nil
 }
@@ -294,3 +323,66 @@ func extractResult(output string) interface{} {
 	// Could parse into specific types based on format
 	return output
 }
+
+// Precompile builds code into the compile cache without running it
+func (w *Worker) Precompile(code string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shutdown {
+		return fmt.Errorf("worker is shutdown")
+	}
+	if w.cache == nil {
+		return core.NewError(core.CodeUnavailable, "compile cache is disabled")
+	}
+	if w.zigPath == "" {
+		return fmt.Errorf("zig not available for compilation")
+	}
+
+	_, release, err := w.build(w.prepareCode(code))
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+// build compiles fullCode, returning the binary and a func to call once it
+// has run. Binaries are shared through the compile cache when enabled.
+func (w *Worker) build(fullCode string) (string, func(), error) {
+	if w.cache == nil {
+		binaryFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d", w.id))
+		if err := w.compile(fullCode, binaryFile); err != nil {
+			return "", nil, err
+		}
+		return binaryFile, func() { os.Remove(binaryFile) }, nil
+	}
+	key := core.SourceKey(fullCode, w.zigPath, "build-exe", core.ToolchainVersion(w.zigPath, "version"))
+	return w.cache.Acquire(key, func(path string) error {
+		return w.compile(fullCode, path)
+	})
+}
+
+// compile compiles fullCode to binaryFile
+func (w *Worker) compile(fullCode, binaryFile string) error {
+	// Write to a temporary Zig file
+	zigFile := filepath.Join(w.tempDir, fmt.Sprintf("main_%d.zig", w.id))
+	if err := os.WriteFile(zigFile, []byte(fullCode), 0644); err != nil {
+		return fmt.Errorf("failed to write Zig file: %w", err)
+	}
+	defer os.Remove(zigFile)
+
+	// Compile and run the Zig code
+	var compileStderr bytes.Buffer
+	compileCmd := exec.Command(w.zigPath, "build-exe", zigFile, "-femit-bin="+binaryFile)
+	compileCmd.Stderr = &compileStderr
+
+	if err := compileCmd.Run(); err != nil {
+		errMsg := compileStderr.String()
+		if errMsg != "" {
+			return fmt.Errorf("compilation failed: %s", errMsg)
+		}
+		return fmt.Errorf("compilation failed: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 // DefaultSigner implements the main signing orchestrator
@@ -11,6 +13,7 @@ type DefaultSigner struct {
 	mu      sync.RWMutex
 	certs   map[string]*Certificate
 	signers map[string]PlatformSigner
+	secrets core.SecretStore
 }
 
 // NewSigner creates a new signer
@@ -35,7 +38,31 @@ func (s *DefaultSigner) RegisterPlatformSigner(ps PlatformSigner) {
 	s.signers[ps.Platform()] = ps
 }
 
-// Sign signs a binary
+// SetSecretStore sets the store that certificate PrivateKeyRefs name keys in
+func (s *DefaultSigner) SetSecretStore(store core.SecretStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets = store
+}
+
+// privateKey reads the private key named by ref from the secret store
+func (s *DefaultSigner) privateKey(ref string) ([]byte, error) {
+	s.mu.RLock()
+	store := s.secrets
+	s.mu.RUnlock()
+
+	if store == nil {
+		return nil, fmt.Errorf("private key %s requires a secret store", ref)
+	}
+	key, err := store.Get(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	return key, nil
+}
+
+// Sign signs a binary. A certificate with a PrivateKeyRef is signed with
+// the key read from the secret store.
 func (s *DefaultSigner) Sign(ctx context.Context, req *SignRequest) (*SignResult, error) {
 	if len(req.Binary) == 0 {
 		return nil, fmt.Errorf("binary is required")
@@ -48,6 +75,18 @@ func (s *DefaultSigner) Sign(ctx context.Context, req *SignRequest) (*SignResult
 	signer, ok := s.signers[req.Platform]
 	s.mu.RUnlock()
 
+	if cert := req.Certificate; len(cert.PrivateKey) == 0 && cert.PrivateKeyRef != "" {
+		key, err := s.privateKey(cert.PrivateKeyRef)
+		if err != nil {
+			return nil, err
+		}
+		withKey := *cert
+		withKey.PrivateKey = key
+		resolved := *req
+		resolved.Certificate = &withKey
+		req = &resolved
+	}
+
 	if !ok {
 		return nil, fmt.Errorf("unsupported platform: %s", req.Platform)
 	}
@@ -104,7 +143,8 @@ func (s *DefaultSigner) ListCertificates(ctx context.Context, certType string) (
 	return certs, nil
 }
 
-// ImportCertificate imports a certificate
+// ImportCertificate imports a certificate. A PrivateKeyRef must name a key
+// in the secret store.
 func (s *DefaultSigner) ImportCertificate(ctx context.Context, cert *Certificate) error {
 	if cert.ID == "" {
 		return fmt.Errorf("certificate ID is required")
@@ -112,6 +152,11 @@ func (s *DefaultSigner) ImportCertificate(ctx context.Context, cert *Certificate
 	if cert.Type == "" {
 		return fmt.Errorf("certificate type is required")
 	}
+	if cert.PrivateKeyRef != "" {
+		if _, err := s.privateKey(cert.PrivateKeyRef); err != nil {
+			return err
+		}
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -7,18 +7,19 @@ import (
 
 // Certificate represents a code signing certificate
 type Certificate struct {
-	ID          string            `json:"id"`
-	Type        string            `json:"type"` // "apple", "windows", "linux"
-	Subject     string            `json:"subject"`
-	Issuer      string            `json:"issuer"`
-	Serial      string            `json:"serial"`
-	Fingerprint string            `json:"fingerprint"`
-	NotBefore   time.Time         `json:"not_before"`
-	NotAfter    time.Time         `json:"not_after"`
-	KeyUsage    []string          `json:"key_usage"`
-	Data        []byte            `json:"data"`
-	PrivateKey  []byte            `json:"private_key"`
-	Metadata    map[string]string `json:"metadata"`
+	ID            string            `json:"id"`
+	Type          string            `json:"type"` // "apple", "windows", "linux"
+	Subject       string            `json:"subject"`
+	Issuer        string            `json:"issuer"`
+	Serial        string            `json:"serial"`
+	Fingerprint   string            `json:"fingerprint"`
+	NotBefore     time.Time         `json:"not_before"`
+	NotAfter      time.Time         `json:"not_after"`
+	KeyUsage      []string          `json:"key_usage"`
+	Data          []byte            `json:"data"`
+	PrivateKey    []byte            `json:"private_key,omitempty"`
+	PrivateKeyRef string            `json:"private_key_ref,omitempty"` // secret store key used in place of PrivateKey
+	Metadata      map[string]string `json:"metadata"`
 }
 
 // SignRequest represents a signing request
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -103,6 +104,149 @@ func TestAllRuntimes(t *testing.T) {
 	}
 }
 
+// TestCaptureLastExpr checks that with CaptureLastExpr set, the final
+// expression is returned by every scripting runtime without an explicit
+// return or echo
+func TestCaptureLastExpr(t *testing.T) {
+	ctx := context.Background()
+
+	runtimes := []struct {
+		runtime   core.Runtime
+		multiLine string
+	}{
+		{python.NewRuntime(), "x = 2\nx + 2"},
+		{javascript.NewRuntime(), "var x = 2;\nx + 2"},
+		{ruby.NewRuntime(), "x = 2\nx + 2"},
+		{lua.NewRuntime(), "x = 2\nx + 2"},
+		{php.NewRuntime(), "$x = 2;\n$x + 2;"},
+	}
+
+	for _, rt := range runtimes {
+		rt := rt
+		t.Run(rt.runtime.Name(), func(t *testing.T) {
+			config := core.RuntimeConfig{
+				Name:            rt.runtime.Name(),
+				Enabled:         true,
+				MaxConcurrency:  1,
+				Timeout:         5 * time.Second,
+				CaptureLastExpr: true,
+			}
+			if err := rt.runtime.Initialize(ctx, config); err != nil {
+				t.Skipf("%s not available: %v", rt.runtime.Name(), err)
+			}
+			defer rt.runtime.Shutdown(ctx)
+
+			for _, code := range []string{"2 + 2", rt.multiLine} {
+				result, err := rt.runtime.Execute(ctx, code)
+				if err != nil {
+					t.Fatalf("Execute(%q) failed: %v", code, err)
+				}
+				if fmt.Sprint(result) != "4" {
+					t.Errorf("Execute(%q) = %v (%T), want 4", code, result, result)
+				}
+			}
+		})
+	}
+}
+
+// TestNilRoundTrip checks that each language's nil arrives in Go as nil,
+// and that Go nils, typed or not, arrive as the language's nil
+func TestNilRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	runtimes := []struct {
+		runtime core.Runtime
+		define  string   // defines ident(x), returning x
+		nils    []string // expressions evaluating to nil
+	}{
+		{python.NewRuntime(), "def ident(x):\n    return x", []string{"None"}},
+		{javascript.NewRuntime(), "function ident(x) { return x; }", []string{"null", "undefined"}},
+		{ruby.NewRuntime(), "def ident(x)\n  x\nend", []string{"nil"}},
+		{lua.NewRuntime(), "function ident(x) return x end", []string{"return nil"}},
+		{php.NewRuntime(), "", []string{"echo null;"}},
+	}
+
+	for _, rt := range runtimes {
+		rt := rt
+		t.Run(rt.runtime.Name(), func(t *testing.T) {
+			config := core.RuntimeConfig{
+				Name:           rt.runtime.Name(),
+				Enabled:        true,
+				MaxConcurrency: 1,
+				Timeout:        5 * time.Second,
+			}
+			if err := rt.runtime.Initialize(ctx, config); err != nil {
+				t.Skipf("%s not available: %v", rt.runtime.Name(), err)
+			}
+			defer rt.runtime.Shutdown(ctx)
+
+			for _, code := range rt.nils {
+				result, err := rt.runtime.Execute(ctx, code)
+				if err != nil {
+					t.Fatalf("Execute(%q) failed: %v", code, err)
+				}
+				if result != nil {
+					t.Errorf("Execute(%q) = %#v, want nil", code, result)
+				}
+			}
+
+			if rt.define == "" {
+				return
+			}
+			if _, err := rt.runtime.Execute(ctx, rt.define); err != nil {
+				t.Fatalf("defining ident failed: %v", err)
+			}
+			for _, arg := range []interface{}{nil, []interface{}(nil), map[string]interface{}(nil), (*int)(nil)} {
+				result, err := rt.runtime.Call(ctx, "ident", arg)
+				if err != nil {
+					t.Fatalf("ident(%#v) failed: %v", arg, err)
+				}
+				if result != nil {
+					t.Errorf("ident(%#v) = %#v, want nil", arg, result)
+				}
+			}
+		})
+	}
+}
+
+// TestJavaScriptNilPolicy checks that the nil policy picks between null
+// and undefined for nil arguments
+func TestJavaScriptNilPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	for _, policy := range []core.NilPolicy{core.NilNull, core.NilUndefined} {
+		t.Run(string(policy), func(t *testing.T) {
+			runtime := javascript.NewRuntime()
+			config := core.RuntimeConfig{
+				Name:           "javascript",
+				Enabled:        true,
+				MaxConcurrency: 1,
+				Timeout:        5 * time.Second,
+				Options:        map[string]interface{}{"nil_policy": string(policy)},
+			}
+			if err := runtime.Initialize(ctx, config); err != nil {
+				t.Skipf("javascript not available: %v", err)
+			}
+			defer runtime.Shutdown(ctx)
+
+			if _, err := runtime.Execute(ctx, "function kind(x = 'default') { return x === null ? 'null' : x; }"); err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			result, err := runtime.Call(ctx, "kind", nil)
+			if err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			want := "null"
+			if policy == core.NilUndefined {
+				want = "default"
+			}
+			if result != want {
+				t.Errorf("kind(nil) = %v, want %s", result, want)
+			}
+		})
+	}
+}
+
 // TestAllRuntimesConcurrency tests all runtimes can be created and shut down concurrently
 func TestAllRuntimesConcurrency(t *testing.T) {
 	runtimes := []core.Runtime{
@@ -202,3 +346,24 @@ func TestRuntimeNames(t *testing.T) {
 		t.Errorf("expected 11 unique runtime names, got %d", len(names))
 	}
 }
+
+// TestRuntimeConcurrencyModels ensures each runtime declares how its workers run
+func TestRuntimeConcurrencyModels(t *testing.T) {
+	expected := map[core.ConcurrencyModel][]core.Runtime{
+		core.ConcurrencyParallel:   {cpp.NewRuntime(), rust.NewRuntime(), zig.NewRuntime(), java.NewRuntime(), php.NewRuntime()},
+		core.ConcurrencyPerState:   {goruntime.NewRuntime(), lua.NewRuntime(), wasm.NewRuntime()},
+		core.ConcurrencyGlobalLock: {python.NewRuntime(), ruby.NewRuntime(), javascript.NewRuntime()},
+	}
+	for model, runtimes := range expected {
+		for _, runtime := range runtimes {
+			capabilities, ok := core.CapabilitiesOf(runtime)
+			if !ok {
+				t.Errorf("%s runtime declares no capabilities", runtime.Name())
+				continue
+			}
+			if capabilities.Concurrency != model {
+				t.Errorf("expected %s to declare %q, got %q", runtime.Name(), model, capabilities.Concurrency)
+			}
+		}
+	}
+}
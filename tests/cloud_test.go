@@ -1,11 +1,18 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/griffincancode/polyglot.js/cloud"
+	"github.com/griffincancode/polyglot.js/core"
 )
 
 func TestCloudBuilder(t *testing.T) {
@@ -92,12 +99,12 @@ func TestCloudStorage(t *testing.T) {
 	}
 
 	// Test listing objects
-	objects, err := storage.List(ctx, "artifacts/", 10)
+	listing, err := storage.List(ctx, "artifacts/", cloud.ListOptions{Limit: 10})
 	if err != nil {
 		t.Fatalf("failed to list objects: %v", err)
 	}
 
-	if len(objects) == 0 {
+	if len(listing.Objects) == 0 {
 		t.Error("expected at least one object")
 	}
 
@@ -113,6 +120,83 @@ func TestCloudStorage(t *testing.T) {
 	}
 }
 
+func TestCloudStorageList(t *testing.T) {
+	testStorageList(t, cloud.NewMemoryStorage())
+}
+
+// testStorageList checks prefix filtering, page boundaries, and metadata
+// against any Storage backend
+func testStorageList(t *testing.T, storage cloud.Storage) {
+	ctx := context.Background()
+	for _, key := range []string{"builds/a", "builds/b", "builds/c", "builds/d", "logs/a"} {
+		if err := storage.Put(ctx, key, []byte(key), map[string]string{"name": key}); err != nil {
+			t.Fatalf("failed to put %s: %v", key, err)
+		}
+	}
+
+	listAll := func(limit int) ([]string, int) {
+		var keys []string
+		pages := 0
+		opts := cloud.ListOptions{Limit: limit}
+		for {
+			result, err := storage.List(ctx, "builds/", opts)
+			if err != nil {
+				t.Fatalf("list failed: %v", err)
+			}
+			pages++
+			for _, obj := range result.Objects {
+				keys = append(keys, obj.Key)
+			}
+			if result.NextCursor == "" {
+				return keys, pages
+			}
+			opts.Cursor = result.NextCursor
+		}
+	}
+
+	want := []string{"builds/a", "builds/b", "builds/c", "builds/d"}
+	for _, tc := range []struct {
+		limit int
+		pages int
+	}{
+		{limit: 0, pages: 1},
+		{limit: 1, pages: 4},
+		{limit: 2, pages: 2},
+		{limit: 3, pages: 2},
+		{limit: 4, pages: 1},
+		{limit: 10, pages: 1},
+	} {
+		keys, pages := listAll(tc.limit)
+		if !reflect.DeepEqual(keys, want) {
+			t.Errorf("limit %d: expected %v, got %v", tc.limit, want, keys)
+		}
+		if pages != tc.pages {
+			t.Errorf("limit %d: expected %d pages, got %d", tc.limit, tc.pages, pages)
+		}
+	}
+
+	result, err := storage.List(ctx, "builds/", cloud.ListOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if obj := result.Objects[0]; obj.Metadata["name"] != "" || obj.Size != int64(len(obj.Key)) {
+		t.Errorf("expected size without metadata, got %+v", obj)
+	}
+
+	result, err = storage.List(ctx, "builds/", cloud.ListOptions{Limit: 1, Metadata: true})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if obj := result.Objects[0]; obj.Metadata["name"] != "builds/a" {
+		t.Errorf("expected metadata, got %+v", obj.Metadata)
+	}
+
+	result, err = storage.List(ctx, "missing/", cloud.ListOptions{Limit: 2})
+	if err != nil || len(result.Objects) != 0 || result.NextCursor != "" {
+		t.Errorf("expected empty listing, got %+v, %v", result, err)
+	}
+}
+
 func TestCloudAuth(t *testing.T) {
 	ctx := context.Background()
 	auth := cloud.NewMemoryAuth()
@@ -229,3 +313,248 @@ func TestBuildTimeout(t *testing.T) {
 		t.Logf("build completed or timed out: %v", err)
 	}
 }
+
+// slowBuilder records the peak number of concurrent builds
+type slowBuilder struct {
+	*cloud.MemoryBuilder
+	running int32
+	peak    int32
+}
+
+func (b *slowBuilder) Build(ctx context.Context, req *cloud.BuildRequest) (*cloud.BuildResult, error) {
+	n := atomic.AddInt32(&b.running, 1)
+	defer atomic.AddInt32(&b.running, -1)
+	for {
+		peak := atomic.LoadInt32(&b.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&b.peak, peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	return b.MemoryBuilder.Build(ctx, req)
+}
+
+func TestCrossCompileConcurrencyLimit(t *testing.T) {
+	ctx := context.Background()
+	builder := &slowBuilder{MemoryBuilder: cloud.NewMemoryBuilder()}
+	client := cloud.NewClient(builder, cloud.NewMemoryStorage(), cloud.NewMemoryAuth())
+	if err := client.Authenticate(ctx, "test-key", "test-secret"); err != nil {
+		t.Fatalf("authentication failed: %v", err)
+	}
+	client.SetMaxConcurrentBuilds(3)
+
+	var platforms []cloud.Platform
+	for _, goos := range []string{"linux", "darwin", "windows", "freebsd"} {
+		for _, arch := range []string{"amd64", "arm64", "386"} {
+			platforms = append(platforms, cloud.Platform{OS: goos, Arch: arch})
+		}
+	}
+
+	progress := make(chan *cloud.BuildProgress)
+	statuses := make(map[cloud.BuildStatus]int)
+	var last *cloud.BuildProgress
+	done := make(chan struct{})
+	go func() {
+		for p := range progress {
+			statuses[p.Status]++
+			last = p
+		}
+		close(done)
+	}()
+
+	results, err := client.CrossCompileWithProgress(ctx, []byte("source"), platforms, progress)
+	if err != nil {
+		t.Fatalf("cross-compilation failed: %v", err)
+	}
+	<-done
+
+	if len(results) != len(platforms) {
+		t.Errorf("expected %d results, got %d", len(platforms), len(results))
+	}
+	if peak := atomic.LoadInt32(&builder.peak); peak > 3 || peak < 1 {
+		t.Errorf("expected at most 3 concurrent builds, peaked at %d", peak)
+	}
+
+	n := len(platforms)
+	if statuses[cloud.BuildQueued] != n || statuses[cloud.BuildRunning] != n || statuses[cloud.BuildCompleted] != n {
+		t.Errorf("expected %d of each status, got %v", n, statuses)
+	}
+	if last == nil || last.Finished != n || last.Total != n {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+}
+
+func TestBuildProvenance(t *testing.T) {
+	ctx := context.Background()
+	builder := cloud.NewMemoryBuilder()
+	storage := cloud.NewMemoryStorage()
+
+	result, err := builder.Build(ctx, &cloud.BuildRequest{
+		ProjectID:    "test-project",
+		Platform:     cloud.Platform{OS: "linux", Arch: "amd64"},
+		Source:       []byte("source"),
+		Languages:    []string{"python", "javascript"},
+		Dependencies: map[string]string{"numpy": "1.26.0", "lodash": "4.17.21"},
+		SBOM:         true,
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	provenance := result.Provenance
+	if provenance == nil {
+		t.Fatal("expected provenance on the build result")
+	}
+	if err := provenance.Verify(result.Binary); err != nil {
+		t.Errorf("checksum does not match binary: %v", err)
+	}
+	if !strings.HasPrefix(provenance.Checksum, "sha256:") || provenance.Toolchains["go"] != runtime.Version() {
+		t.Errorf("unexpected provenance %+v", provenance)
+	}
+
+	want := []cloud.SBOMEntry{
+		{Name: "python", Type: "runtime"},
+		{Name: "javascript", Type: "runtime"},
+		{Name: "lodash", Version: "4.17.21", Type: "package"},
+		{Name: "numpy", Version: "1.26.0", Type: "package"},
+	}
+	if !reflect.DeepEqual(provenance.SBOM, want) {
+		t.Errorf("SBOM = %+v, want %+v", provenance.SBOM, want)
+	}
+
+	// Provenance travels with the artifact in storage
+	key := "builds/test-project/linux-amd64"
+	if err := cloud.StoreArtifact(ctx, storage, key, result); err != nil {
+		t.Fatalf("failed to store artifact: %v", err)
+	}
+
+	binary, stored, err := cloud.LoadArtifact(ctx, storage, key)
+	if err != nil {
+		t.Fatalf("failed to load artifact: %v", err)
+	}
+	if !bytes.Equal(binary, result.Binary) || !reflect.DeepEqual(stored, provenance) {
+		t.Errorf("stored provenance %+v does not match %+v", stored, provenance)
+	}
+
+	// Tampered artifacts fail verification
+	storage.Put(ctx, "builds/tampered", []byte("tampered"), map[string]string{"checksum": provenance.Checksum})
+	if _, _, err := cloud.LoadArtifact(ctx, storage, "builds/tampered"); err == nil {
+		t.Error("expected checksum mismatch for a tampered artifact")
+	}
+}
+
+func TestBuildProvenanceWithoutSBOM(t *testing.T) {
+	result, err := cloud.NewMemoryBuilder().Build(context.Background(), &cloud.BuildRequest{
+		ProjectID: "test-project",
+		Source:    []byte("source"),
+		Languages: []string{"python"},
+	})
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if result.Provenance.SBOM != nil {
+		t.Errorf("expected no SBOM unless requested, got %+v", result.Provenance.SBOM)
+	}
+}
+
+func TestCloudAuthenticateFromStore(t *testing.T) {
+	ctx := context.Background()
+	auth := cloud.NewMemoryAuth()
+	client := cloud.NewClient(cloud.NewMemoryBuilder(), cloud.NewMemoryStorage(), auth)
+
+	secrets := core.NewMemorySecretStore()
+	secrets.Set(cloud.SecretAPIKey, []byte("stored-api-key"))
+
+	var coreErr *core.Error
+	if err := client.AuthenticateFromStore(ctx, secrets); !errors.As(err, &coreErr) || coreErr.Code != core.CodeNotFound {
+		t.Fatalf("expected NOT_FOUND for missing secret key, got %v", err)
+	}
+
+	secrets.Set(cloud.SecretSecretKey, []byte("stored-secret"))
+	if err := client.AuthenticateFromStore(ctx, secrets); err != nil {
+		t.Fatalf("authentication failed: %v", err)
+	}
+
+	creds, err := auth.Authenticate(ctx, "stored-api-key", "stored-secret")
+	if err != nil {
+		t.Fatalf("failed to look up credentials: %v", err)
+	}
+	if creds.APIKey != "stored-api-key" {
+		t.Errorf("expected stored API key, got %s", creds.APIKey)
+	}
+}
+
+func TestEstimateBuild(t *testing.T) {
+	ctx := context.Background()
+	builder := cloud.NewMemoryBuilder()
+	client := cloud.NewClient(builder, cloud.NewMemoryStorage(), cloud.NewMemoryAuth())
+
+	linux := cloud.Platform{OS: "linux", Arch: "amd64"}
+	darwin := cloud.Platform{OS: "darwin", Arch: "arm64"}
+	windows := cloud.Platform{OS: "windows", Arch: "amd64", CGOEnabled: true}
+
+	small, err := client.EstimateBuild(ctx, make([]byte, 1<<20), []cloud.Platform{linux})
+	if err != nil {
+		t.Fatalf("EstimateBuild failed: %v", err)
+	}
+	large, _ := client.EstimateBuild(ctx, make([]byte, 10<<20), []cloud.Platform{linux})
+	if small.Platforms[0].Basis != cloud.EstimateFromHeuristic {
+		t.Errorf("expected heuristic estimate without history, got %s", small.Platforms[0].Basis)
+	}
+	if large.Duration <= small.Duration {
+		t.Errorf("expected larger source to take longer: %v <= %v", large.Duration, small.Duration)
+	}
+	if small.Cost != 0 || small.Currency != "" {
+		t.Errorf("expected no cost without pricing, got %v %s", small.Cost, small.Currency)
+	}
+
+	plain, _ := client.EstimateBuild(ctx, []byte("source"), []cloud.Platform{{OS: "windows", Arch: "amd64"}})
+	cgo, _ := client.EstimateBuild(ctx, []byte("source"), []cloud.Platform{windows})
+	if cgo.Duration <= plain.Duration {
+		t.Errorf("expected cgo build to take longer: %v <= %v", cgo.Duration, plain.Duration)
+	}
+
+	// Unlimited builds run in parallel; one at a time they add up
+	platforms := []cloud.Platform{linux, darwin, windows}
+	parallel, _ := client.EstimateBuild(ctx, []byte("source"), platforms)
+	if len(parallel.Platforms) != 3 {
+		t.Fatalf("expected 3 platform estimates, got %d", len(parallel.Platforms))
+	}
+	var sum, longest time.Duration
+	for _, p := range parallel.Platforms {
+		sum += p.Duration
+		if p.Duration > longest {
+			longest = p.Duration
+		}
+	}
+	if parallel.BuildTime != sum || parallel.Duration != longest {
+		t.Errorf("expected build time %v and duration %v, got %v and %v", sum, longest, parallel.BuildTime, parallel.Duration)
+	}
+	client.SetMaxConcurrentBuilds(1)
+	serial, _ := client.EstimateBuild(ctx, []byte("source"), platforms)
+	if serial.Duration != sum {
+		t.Errorf("expected serial duration %v, got %v", sum, serial.Duration)
+	}
+
+	// Past builds of a platform replace the heuristic
+	builder.Build(ctx, &cloud.BuildRequest{ProjectID: "p", Platform: linux, Source: []byte("source")})
+	client.SetPricing(cloud.BuildPricing{PerMinute: 0.5, Currency: "USD"})
+	priced, _ := client.EstimateBuild(ctx, []byte("source"), []cloud.Platform{linux, darwin})
+	if priced.Platforms[0].Basis != cloud.EstimateFromHistory || priced.Platforms[0].Duration != 30*time.Second {
+		t.Errorf("expected 30s estimate from history, got %+v", priced.Platforms[0])
+	}
+	if priced.Platforms[1].Basis != cloud.EstimateFromHeuristic {
+		t.Errorf("expected heuristic estimate for darwin, got %s", priced.Platforms[1].Basis)
+	}
+	if want := priced.BuildTime.Minutes() * 0.5; priced.Cost != want || priced.Currency != "USD" {
+		t.Errorf("expected cost %v USD, got %v %s", want, priced.Cost, priced.Currency)
+	}
+
+	if _, err := client.EstimateBuild(ctx, nil, platforms); err == nil {
+		t.Error("expected error for empty source")
+	}
+	if _, err := client.EstimateBuild(ctx, []byte("source"), nil); err == nil {
+		t.Error("expected error without platforms")
+	}
+}
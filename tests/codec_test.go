@@ -0,0 +1,460 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+type codecTask struct {
+	ID       int      `json:"id"`
+	Title    string   `json:"title"`
+	Done     bool     `json:"done"`
+	Priority float64  `json:"priority"`
+	Tags     []string `json:"tags"`
+}
+
+// largeResult builds a task/statistics payload similar to the demo apps
+func largeResult(n int) map[string]interface{} {
+	tasks := make([]codecTask, n)
+	for i := range tasks {
+		tasks[i] = codecTask{
+			ID:       i,
+			Title:    fmt.Sprintf("Task number %d", i),
+			Done:     i%3 == 0,
+			Priority: float64(i%5) + 0.5,
+			Tags:     []string{"work", "polyglot"},
+		}
+	}
+
+	return map[string]interface{}{
+		"tasks": tasks,
+		"statistics": map[string]interface{}{
+			"total":     n,
+			"completed": n / 3,
+			"ratio":     0.333,
+			"negative":  -1234567,
+			"empty":     nil,
+		},
+	}
+}
+
+func TestCodec_RoundTripIdentical(t *testing.T) {
+	value := largeResult(200)
+
+	var decoded []interface{}
+	for _, codec := range []core.Codec{core.JSONCodec{}, core.MsgpackCodec{}} {
+		data, err := codec.Marshal(value)
+		if err != nil {
+			t.Fatalf("%s marshal failed: %v", codec.Name(), err)
+		}
+
+		result, err := codec.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s unmarshal failed: %v", codec.Name(), err)
+		}
+		decoded = append(decoded, result)
+	}
+
+	if !reflect.DeepEqual(decoded[0], decoded[1]) {
+		t.Error("JSON and msgpack round-trips differ")
+	}
+}
+
+func TestCodec_MsgpackScalars(t *testing.T) {
+	codec := core.MsgpackCodec{}
+	values := []interface{}{
+		nil, true, false, 0.0, 127.0, -32.0, -33.0, 65536.0, -2147483649.0,
+		1.5, "", "short", string(make([]byte, 300)),
+		[]interface{}{1.0, "two", nil},
+	}
+
+	for _, value := range values {
+		data, err := codec.Marshal(value)
+		if err != nil {
+			t.Fatalf("Marshal(%v) failed: %v", value, err)
+		}
+
+		result, err := codec.Unmarshal(data)
+		if err != nil {
+			t.Fatalf("Unmarshal(%v) failed: %v", value, err)
+		}
+
+		if !reflect.DeepEqual(result, value) {
+			t.Errorf("Round-trip mismatch: got %v, want %v", result, value)
+		}
+	}
+
+	if _, err := codec.Unmarshal([]byte{0x92, 0x01}); err == nil {
+		t.Error("Expected error for truncated data")
+	}
+}
+
+func TestCodec_FormatSelection(t *testing.T) {
+	if core.CodecFor("msgpack").Name() != core.FormatMsgpack {
+		t.Error("Expected msgpack codec")
+	}
+	if core.CodecFor("").Name() != core.FormatJSON {
+		t.Error("Expected JSON fallback for empty format")
+	}
+}
+
+func TestCodec_IntegerPolicyRoundTrip(t *testing.T) {
+	// A Python int beyond 2^53 arrives in Go as int64 from the runtime
+	const big int64 = 9007199254740993
+	pythonResult := map[string]interface{}{"id": big, "count": int64(42), "ratio": 0.5}
+
+	for _, format := range []string{core.FormatJSON, core.FormatMsgpack} {
+		t.Run(format, func(t *testing.T) {
+			// Go -> JS: the unsafe integer is sent as exact decimal digits
+			encoded, err := core.CodecWithPolicy(format, core.NumbersInteger).Marshal(pythonResult)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			// The frontend reads every number as a JavaScript number
+			decoded, err := core.CodecFor(format).Unmarshal(encoded)
+			if err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			result := decoded.(map[string]interface{})
+			if result["id"] != "9007199254740993" {
+				t.Errorf("Expected exact digits for id, got %#v", result["id"])
+			}
+			if result["count"] != float64(42) {
+				t.Errorf("Expected count 42, got %#v", result["count"])
+			}
+			if result["ratio"] != 0.5 {
+				t.Errorf("Expected ratio 0.5, got %#v", result["ratio"])
+			}
+		})
+	}
+}
+
+func TestCodec_FloatPolicyDefault(t *testing.T) {
+	if core.ParseNumberPolicy("") != core.NumbersFloat {
+		t.Error("Expected float policy by default")
+	}
+
+	encoded, err := core.JSONCodec{}.Marshal([]interface{}{int64(9007199254740993)})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(encoded) != "[9007199254740993]" {
+		t.Errorf("Expected the integer unchanged under the default policy, got %s", encoded)
+	}
+
+	decoded, err := core.JSONCodec{}.Unmarshal([]byte(`[9007199254740993]`))
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if _, ok := decoded.([]interface{})[0].(float64); !ok {
+		t.Errorf("Expected float64 under the default policy, got %T", decoded.([]interface{})[0])
+	}
+}
+
+func TestCodec_IntegerPolicyArguments(t *testing.T) {
+	for _, format := range []string{core.FormatJSON, core.FormatMsgpack} {
+		t.Run(format, func(t *testing.T) {
+			// JS -> Go: integral arguments arrive as int64 without precision
+			// loss, and fractional ones as float64
+			args, err := core.CodecFor(format).Marshal([]interface{}{int64(1<<53 - 1), 3, 0.5})
+			if err != nil {
+				t.Fatalf("Marshal args failed: %v", err)
+			}
+			values, err := core.CodecWithPolicy(format, core.NumbersInteger).Unmarshal(args)
+			if err != nil {
+				t.Fatalf("Unmarshal args failed: %v", err)
+			}
+			got := values.([]interface{})
+			if got[0] != int64(1<<53-1) || got[1] != int64(3) || got[2] != 0.5 {
+				t.Errorf("Expected int64, int64, float64 args, got %#v", got)
+			}
+		})
+	}
+
+	if _, err := (core.JSONCodec{Numbers: core.NumbersInteger}).Unmarshal([]byte(`1 2`)); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
+
+func TestCodec_TimePolicyRoundTrip(t *testing.T) {
+	due := time.Date(2024, 3, 9, 14, 30, 15, 250_000_000, time.UTC)
+
+	for _, format := range []string{core.FormatJSON, core.FormatMsgpack} {
+		for _, policy := range []core.TimePolicy{core.TimesRFC3339, core.TimesMillis} {
+			t.Run(format+"/"+string(policy), func(t *testing.T) {
+				codec := core.CodecWithPolicies(format, core.NumbersInteger, policy)
+
+				// Go -> JS: the time travels in the policy's format
+				encoded, err := codec.Marshal(map[string]interface{}{"due": due, "history": []interface{}{&due}})
+				if err != nil {
+					t.Fatalf("Marshal failed: %v", err)
+				}
+				decoded, err := codec.Unmarshal(encoded)
+				if err != nil {
+					t.Fatalf("Unmarshal failed: %v", err)
+				}
+				result := decoded.(map[string]interface{})
+
+				want := core.EncodeTime(due, policy)
+				if result["due"] != want {
+					t.Errorf("Expected due as %#v, got %#v", want, result["due"])
+				}
+				if history := result["history"].([]interface{}); history[0] != want {
+					t.Errorf("Expected nested time as %#v, got %#v", want, history[0])
+				}
+
+				// JS -> Go: handlers read the time back without loss
+				parsed, err := core.ParseTime(result["due"])
+				if err != nil {
+					t.Fatalf("ParseTime failed: %v", err)
+				}
+				if !parsed.Equal(due) {
+					t.Errorf("Expected %v after round trip, got %v", due, parsed)
+				}
+			})
+		}
+	}
+}
+
+func TestCodec_TimePolicyParsing(t *testing.T) {
+	if core.ParseTimePolicy("") != core.TimesRFC3339 || core.ParseTimePolicy("millis") != core.TimesMillis {
+		t.Error("Expected rfc3339 by default and millis when selected")
+	}
+	config := core.RuntimeConfig{Options: map[string]interface{}{"time_policy": "millis"}}
+	if core.TimePolicyFor(config) != core.TimesMillis {
+		t.Error("Expected time_policy option to select millis")
+	}
+
+	// Float milliseconds from JavaScript's default number policy parse too
+	parsed, err := core.ParseTime(float64(1710000000123))
+	if err != nil || parsed.UnixMilli() != 1710000000123 || parsed.Location() != time.UTC {
+		t.Errorf("Expected UTC time from float millis, got %v, %v", parsed, err)
+	}
+	for _, bad := range []interface{}{"yesterday", true, nil} {
+		if _, err := core.ParseTime(bad); err == nil || core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+			t.Errorf("Expected invalid argument for %#v, got %v", bad, err)
+		}
+	}
+}
+
+func BenchmarkCodec_JSON(b *testing.B) {
+	benchmarkCodec(b, core.JSONCodec{})
+}
+
+func BenchmarkCodec_Msgpack(b *testing.B) {
+	benchmarkCodec(b, core.MsgpackCodec{})
+}
+
+func benchmarkCodec(b *testing.B, codec core.Codec) {
+	value := largeResult(1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := codec.Marshal(value)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := codec.Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+		b.SetBytes(int64(len(data)))
+	}
+}
+
+// arenaCodecs covers both wire formats and number policies
+var arenaCodecs = []core.Codec{
+	core.JSONCodec{},
+	core.JSONCodec{Numbers: core.NumbersInteger},
+	core.MsgpackCodec{},
+	core.MsgpackCodec{Numbers: core.NumbersInteger},
+}
+
+func TestCallArena_MatchesCodec(t *testing.T) {
+	arena := core.NewCallArena()
+	args := []interface{}{"task", 42, 1.5, true, nil, []interface{}{"a", "b"}, map[string]interface{}{"id": 7}}
+
+	for _, codec := range arenaCodecs {
+		data, err := codec.Marshal(args)
+		if err != nil {
+			t.Fatalf("%s marshal failed: %v", codec.Name(), err)
+		}
+		want, _ := codec.Unmarshal(data)
+
+		buffers := arena.Acquire()
+		got, err := buffers.DecodeArgs(codec, data)
+		if err != nil {
+			t.Fatalf("%s DecodeArgs failed: %v", codec.Name(), err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: DecodeArgs = %#v, want %#v", codec.Name(), got, want)
+		}
+
+		encoded, err := buffers.Encode(codec, largeResult(3))
+		if err != nil {
+			t.Fatalf("%s Encode failed: %v", codec.Name(), err)
+		}
+		expected, _ := codec.Marshal(largeResult(3))
+		if string(encoded) != string(expected) {
+			t.Errorf("%s: Encode differs from Marshal", codec.Name())
+		}
+		buffers.Release()
+	}
+}
+
+func TestCallArena_Errors(t *testing.T) {
+	buffers := core.NewCallArena().Acquire()
+	defer buffers.Release()
+
+	for _, codec := range []core.Codec{core.JSONCodec{}, core.MsgpackCodec{}} {
+		object, _ := codec.Marshal(map[string]interface{}{"a": 1})
+		if _, err := buffers.DecodeArgs(codec, object); err == nil {
+			t.Errorf("%s: expected error for non-array arguments", codec.Name())
+		}
+		null, _ := codec.Marshal(nil)
+		if args, err := buffers.DecodeArgs(codec, null); err != nil || args != nil {
+			t.Errorf("%s: expected no arguments for null, got %v, %v", codec.Name(), args, err)
+		}
+	}
+
+	packed, _ := core.MsgpackCodec{}.Marshal([]interface{}{1})
+	if _, err := buffers.DecodeArgs(core.MsgpackCodec{}, append(packed, 0x01)); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
+
+// TestCodec_MsgpackLimits checks MessagePack decoding rejects data past
+// the codec's depth and token limits, with or without the call arena
+func TestCodec_MsgpackLimits(t *testing.T) {
+	nested := interface{}(1)
+	for i := 0; i < 10; i++ {
+		nested = []interface{}{map[string]interface{}{"a": nested}}
+	}
+	numerous := make([]interface{}, 200)
+	for i := range numerous {
+		numerous[i] = i
+	}
+
+	codec := core.MsgpackCodec{MaxDepth: 8, MaxTokens: 100}
+	buffers := core.NewCallArena().Acquire()
+	defer buffers.Release()
+
+	normal, _ := codec.Marshal([]interface{}{map[string]interface{}{"tags": []interface{}{"x", "y"}, "n": 1.5}})
+	if _, err := codec.Unmarshal(normal); err != nil {
+		t.Errorf("Expected normal data to decode, got %v", err)
+	}
+	if _, err := buffers.DecodeArgs(codec, normal); err != nil {
+		t.Errorf("Expected normal arguments to decode, got %v", err)
+	}
+
+	for name, args := range map[string][]interface{}{
+		"deep":     {nested},
+		"numerous": {numerous},
+	} {
+		data, _ := codec.Marshal(args)
+		if _, err := codec.Unmarshal(data); core.ErrorInfoFor(err).Code != core.CodeTooLarge {
+			t.Errorf("Unmarshal: expected %s for %s data, got %v", core.CodeTooLarge, name, err)
+		}
+		if _, err := buffers.DecodeArgs(codec, data); core.ErrorInfoFor(err).Code != core.CodeTooLarge {
+			t.Errorf("DecodeArgs: expected %s for %s data, got %v", core.CodeTooLarge, name, err)
+		}
+		if _, err := (core.MsgpackCodec{}).Unmarshal(data); err != nil {
+			t.Errorf("Expected %s data to decode without limits, got %v", name, err)
+		}
+	}
+}
+
+// TestCallArena_Concurrent checks that pooled buffers are never shared by
+// calls in flight; run with -race
+func TestCallArena_Concurrent(t *testing.T) {
+	arena := core.NewCallArena()
+	codec := core.JSONCodec{Numbers: core.NumbersInteger}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				id := int64(g*1000 + i)
+				data, _ := codec.Marshal([]interface{}{id, fmt.Sprintf("call-%d", id)})
+
+				buffers := arena.Acquire()
+				args, err := buffers.DecodeArgs(codec, data)
+				if err != nil {
+					errs <- err
+					return
+				}
+				encoded, _ := buffers.Encode(codec, map[string]interface{}{"id": args[0], "name": args[1]})
+				result := string(encoded)
+				if args[0] != id || args[1] != fmt.Sprintf("call-%d", id) {
+					errs <- fmt.Errorf("args of call %d were overwritten: %v", id, args)
+				}
+				buffers.Release()
+
+				if want := fmt.Sprintf(`{"id":%d,"name":"call-%d"}`, id, id); result != want {
+					errs <- fmt.Errorf("result %s, want %s", result, want)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func BenchmarkBridgeCall_Standard(b *testing.B) {
+	for _, codec := range []core.Codec{core.JSONCodec{}, core.MsgpackCodec{}} {
+		b.Run(codec.Name(), func(b *testing.B) {
+			data, result := bridgeCallPayload(codec)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				decoded, err := codec.Unmarshal(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = decoded.([]interface{})
+				if _, err := codec.Marshal(result); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBridgeCall_Arena(b *testing.B) {
+	arena := core.NewCallArena()
+	for _, codec := range []core.Codec{core.JSONCodec{}, core.MsgpackCodec{}} {
+		b.Run(codec.Name(), func(b *testing.B) {
+			data, result := bridgeCallPayload(codec)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buffers := arena.Acquire()
+				if _, err := buffers.DecodeArgs(codec, data); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := buffers.Encode(codec, result); err != nil {
+					b.Fatal(err)
+				}
+				buffers.Release()
+			}
+		})
+	}
+}
+
+// bridgeCallPayload returns the encoded arguments and the result of a
+// typical small bridge call
+func bridgeCallPayload(codec core.Codec) ([]byte, interface{}) {
+	data, _ := codec.Marshal([]interface{}{"tasks", 1, 25, true})
+	result := []interface{}{"ok", 3, []interface{}{"a", "b", "c"}}
+	return data, result
+}
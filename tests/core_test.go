@@ -1,7 +1,21 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -165,6 +179,306 @@ func TestMemoryReadWrite(t *testing.T) {
 	mem.Free("test")
 }
 
+func TestMemoryRegionBounds(t *testing.T) {
+	mem := core.NewMemoryCoordinator(core.MemoryConfig{MaxSharedMemory: 1024})
+
+	region, err := mem.Allocate("bounds", 16, core.TypeBytes)
+	if err != nil {
+		t.Fatalf("Failed to allocate memory: %v", err)
+	}
+
+	// Writes that end exactly at the boundary succeed
+	if n, err := region.Write(12, []byte("tail")); err != nil || n != 4 {
+		t.Errorf("Expected 4 bytes written at the boundary, got %d (%v)", n, err)
+	}
+	if n, err := region.Write(0, make([]byte, 16)); err != nil || n != 16 {
+		t.Errorf("Expected a full-region write, got %d (%v)", n, err)
+	}
+	if n, err := region.Write(16, nil); err != nil || n != 0 {
+		t.Errorf("Expected an empty write at the end to succeed, got %d (%v)", n, err)
+	}
+
+	region.Write(4, []byte("data"))
+	if data, err := region.Read(4, 4); err != nil || string(data) != "data" {
+		t.Errorf("Expected data, got %q (%v)", data, err)
+	}
+
+	// Overflowing writes fail without writing anything
+	for _, offset := range []int{13, 16, 100, -1} {
+		n, err := region.Write(offset, []byte("over"))
+		if !errors.Is(err, core.ErrOutOfBounds) || n != 0 {
+			t.Errorf("Expected out of bounds write at %d, got %d (%v)", offset, n, err)
+		}
+	}
+	if data, _ := region.Read(12, 4); string(data) != "\x00\x00\x00\x00" {
+		t.Errorf("Expected overflowing write to leave the region unchanged, got %q", data)
+	}
+
+	// Reads beyond the region fail
+	for _, c := range []struct{ offset, length int }{{12, 5}, {17, 0}, {-1, 2}, {0, -1}} {
+		if _, err := region.Read(c.offset, c.length); !errors.Is(err, core.ErrOutOfBounds) {
+			t.Errorf("Expected out of bounds read at %d+%d, got %v", c.offset, c.length, err)
+		}
+	}
+
+	// Reads return a copy
+	data, _ := region.Read(4, 4)
+	data[0] = 'X'
+	if again, _ := region.Read(4, 4); string(again) != "data" {
+		t.Errorf("Expected Read to copy, region now holds %q", again)
+	}
+}
+
+func TestMemoryRegionAtomics(t *testing.T) {
+	mem := core.NewMemoryCoordinator(core.MemoryConfig{MaxSharedMemory: 1024})
+
+	region, err := mem.Allocate("counters", 24, core.TypeInt64)
+	if err != nil {
+		t.Fatalf("Failed to allocate memory: %v", err)
+	}
+
+	// Many goroutines increment a shared counter without locks
+	const goroutines = 50
+	const increments = 1000
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				if _, err := region.AddInt64(8, 1); err != nil {
+					t.Errorf("AddInt64 failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if total, err := region.LoadInt64(8); err != nil || total != goroutines*increments {
+		t.Errorf("Expected counter %d, got %d (%v)", goroutines*increments, total, err)
+	}
+
+	// Compare-and-swap claims a slot exactly once
+	var claimed int64
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			if ok, _ := region.CompareAndSwapInt64(16, 0, id); ok {
+				atomic.AddInt64(&claimed, 1)
+			}
+		}(int64(i + 1))
+	}
+	wg.Wait()
+	if claimed != 1 {
+		t.Errorf("Expected one successful compare-and-swap, got %d", claimed)
+	}
+
+	if err := region.StoreInt64(0, -5); err != nil {
+		t.Fatalf("StoreInt64 failed: %v", err)
+	}
+	if value, _ := region.LoadInt64(0); value != -5 {
+		t.Errorf("Expected -5, got %d", value)
+	}
+
+	if _, err := region.AddInt64(4, 1); !errors.Is(err, core.ErrUnaligned) {
+		t.Errorf("Expected unaligned access error, got %v", err)
+	}
+	if _, err := region.AddInt64(24, 1); !errors.Is(err, core.ErrOutOfBounds) {
+		t.Errorf("Expected out of bounds error, got %v", err)
+	}
+	if _, err := region.CompareAndSwapInt64(20, 0, 1); !errors.Is(err, core.ErrOutOfBounds) {
+		t.Errorf("Expected out of bounds error, got %v", err)
+	}
+}
+
+func TestMemoryNotifyWait(t *testing.T) {
+	mem := core.NewMemoryCoordinator(core.MemoryConfig{MaxSharedMemory: 1024})
+
+	region, err := mem.Allocate("pipeline", 8, core.TypeInt64)
+	if err != nil {
+		t.Fatalf("Failed to allocate memory: %v", err)
+	}
+
+	// Several waiters are all woken by a single notify
+	const waiters = 3
+	ready := make(chan struct{}, waiters)
+	woken := make(chan int64, waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			ready <- struct{}{}
+			if err := mem.Wait(context.Background(), "pipeline"); err != nil {
+				t.Errorf("Wait failed: %v", err)
+			}
+			value, _ := region.LoadInt64(0)
+			woken <- value
+		}()
+	}
+	for i := 0; i < waiters; i++ {
+		<-ready
+	}
+
+	select {
+	case <-woken:
+		t.Fatal("Waiter woke before notify")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	region.StoreInt64(0, 42)
+	if err := mem.Notify("pipeline"); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	for i := 0; i < waiters; i++ {
+		select {
+		case value := <-woken:
+			if value != 42 {
+				t.Errorf("Expected waiter to see 42, got %d", value)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Waiter was not woken by notify")
+		}
+	}
+
+	// Waits return when their context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := mem.Wait(ctx, "pipeline"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	// Freeing the region wakes waiters with an error
+	done := make(chan error, 1)
+	go func() { done <- mem.Wait(context.Background(), "pipeline") }()
+	time.Sleep(10 * time.Millisecond)
+	if err := mem.Free("pipeline"); err != nil {
+		t.Fatalf("Free failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected error waiting on a freed region")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiter was not woken when the region was freed")
+	}
+
+	if err := mem.Notify("pipeline"); err == nil {
+		t.Error("Expected error notifying a missing region")
+	}
+	if err := mem.Wait(context.Background(), "pipeline"); err == nil {
+		t.Error("Expected error waiting on a missing region")
+	}
+}
+
+func TestMemoryRegionTrace(t *testing.T) {
+	mem := core.NewMemoryCoordinator(core.MemoryConfig{MaxSharedMemory: 1024})
+
+	// Untraced coordinators publish nothing
+	bus := core.NewEventBus()
+	events, unsubscribe := bus.Subscribe(core.TopicMemoryRegion, 16)
+	defer unsubscribe()
+	if _, err := mem.Allocate("untraced", 8, core.TypeBytes); err != nil {
+		t.Fatalf("Failed to allocate memory: %v", err)
+	}
+	if err := mem.Free("untraced"); err != nil {
+		t.Fatalf("Failed to free memory: %v", err)
+	}
+
+	logger := &testLogger{}
+	mem.Trace(bus, logger)
+
+	// A buffer handed from Python to JavaScript is traced end to end
+	python, javascript := mem.As("python"), mem.As("javascript")
+	if _, err := python.Allocate("frame", 16, core.TypeBytes); err != nil {
+		t.Fatalf("Failed to allocate memory: %v", err)
+	}
+	if _, err := python.Write("frame", 4, []byte("pixels")); err != nil {
+		t.Fatalf("Failed to write memory: %v", err)
+	}
+	data, err := javascript.Read("frame", 4, 6)
+	if err != nil || string(data) != "pixels" {
+		t.Fatalf("Expected to read pixels, got %q, %v", data, err)
+	}
+	if err := javascript.Free("frame"); err != nil {
+		t.Fatalf("Failed to free memory: %v", err)
+	}
+
+	// Failed operations are not traced
+	if _, err := python.Read("frame", 0, 1); err == nil {
+		t.Error("Expected reading a freed region to fail")
+	}
+
+	expected := []core.RegionEvent{
+		{Op: core.RegionAllocate, Region: "frame", Runtime: "python", Size: 16},
+		{Op: core.RegionWrite, Region: "frame", Runtime: "python", Size: 16, Offset: 4, Length: 6},
+		{Op: core.RegionRead, Region: "frame", Runtime: "javascript", Size: 16, Offset: 4, Length: 6},
+		{Op: core.RegionFree, Region: "frame", Runtime: "javascript", Size: 16},
+	}
+	for i, want := range expected {
+		select {
+		case event := <-events:
+			if got, ok := event.Data.(core.RegionEvent); !ok || got != want {
+				t.Errorf("Event %d: expected %+v, got %+v", i, want, event.Data)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Missing event %d: %+v", i, want)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected event %+v", event.Data)
+	default:
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.entries) != len(expected) {
+		t.Fatalf("Expected %d log entries, got %+v", len(expected), logger.entries)
+	}
+	for _, entry := range logger.entries {
+		if entry.level != core.LogDebug || !strings.Contains(entry.msg, "memory region frame") {
+			t.Errorf("Unexpected log entry %+v", entry)
+		}
+	}
+	if msg := logger.entries[2].msg; !strings.Contains(msg, "read by javascript of 6 bytes at offset 4") {
+		t.Errorf("Expected read to name javascript, got %q", msg)
+	}
+}
+
+func TestMemoryTraceConfig(t *testing.T) {
+	config := core.DefaultConfig()
+	config.Memory.Trace = true
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("NewOrchestrator failed: %v", err)
+	}
+	defer orch.Shutdown(context.Background())
+
+	events, unsubscribe := orch.Events().Subscribe(core.TopicMemoryRegion, 4)
+	defer unsubscribe()
+
+	region, err := orch.Memory().Allocate("shared", 8, core.TypeBytes)
+	if err != nil {
+		t.Fatalf("Failed to allocate memory: %v", err)
+	}
+	if _, err := region.Write(0, []byte{1}); err != nil {
+		t.Fatalf("Failed to write memory: %v", err)
+	}
+
+	for _, op := range []core.RegionOp{core.RegionAllocate, core.RegionWrite} {
+		select {
+		case event := <-events:
+			got := event.Data.(core.RegionEvent)
+			if got.Op != op || got.Region != "shared" || got.Runtime != "" {
+				t.Errorf("Expected untagged %s of shared, got %+v", op, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Missing %s event", op)
+		}
+	}
+}
+
 func TestBridge(t *testing.T) {
 	bridge := core.NewBridge()
 
@@ -222,3 +536,2765 @@ func TestBridgeWithArgs(t *testing.T) {
 		t.Errorf("Expected 8.0, got %v", result)
 	}
 }
+
+// counterAPI is a bridge API registered with RegisterStruct
+type counterAPI struct {
+	count float64
+}
+
+func (c *counterAPI) Increment(ctx context.Context, args ...interface{}) (interface{}, error) {
+	c.count++
+	return c.count, nil
+}
+
+func (c *counterAPI) GetCount(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return c.count, nil
+}
+
+func (c *counterAPI) URLFor(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return fmt.Sprintf("/counter/%v", args[0]), nil
+}
+
+// Reset does not have the handler signature and is skipped
+func (c *counterAPI) Reset() {
+	c.count = 0
+}
+
+// namedAPI chooses its function names
+type namedAPI struct{ counterAPI }
+
+func (n *namedAPI) BridgeNames() map[string]string {
+	return map[string]string{"Increment": "counter.increment", "URLFor": "-"}
+}
+
+// Test RegisterStruct registers a struct's handler methods by name
+func TestBridgeRegisterStruct(t *testing.T) {
+	bridge := core.NewBridge()
+	logger := &testLogger{}
+	bridge.SetLogger(logger)
+
+	api := &counterAPI{}
+	if err := bridge.RegisterStruct(api); err != nil {
+		t.Fatalf("RegisterStruct failed: %v", err)
+	}
+	if got := sortedFunctions(bridge); !reflect.DeepEqual(got, []string{"getCount", "increment", "urlFor"}) {
+		t.Errorf("Expected getCount, increment and urlFor, got %v", got)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := bridge.Call(ctx, "increment"); err != nil {
+			t.Fatalf("Failed to call increment: %v", err)
+		}
+	}
+	if result, err := bridge.Call(ctx, "getCount"); err != nil || result != 2.0 {
+		t.Errorf("Expected count 2, got %v, %v", result, err)
+	}
+	if result, err := bridge.Call(ctx, "urlFor", 7); err != nil || result != "/counter/7" {
+		t.Errorf("Expected /counter/7, got %v, %v", result, err)
+	}
+
+	logger.mu.Lock()
+	if len(logger.entries) != 1 || logger.entries[0].level != core.LogWarn || !strings.Contains(logger.entries[0].msg, "Reset") {
+		t.Errorf("Expected a warning about Reset, got %+v", logger.entries)
+	}
+	logger.mu.Unlock()
+
+	// Registering again fails without leaving any function behind
+	other := core.NewBridge()
+	other.SetLogger(&testLogger{})
+	if err := other.Register("urlFor", func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatalf("Failed to register urlFor: %v", err)
+	}
+	if err := other.RegisterStruct(&counterAPI{}); err == nil {
+		t.Error("Expected a name collision to fail")
+	}
+	if got := other.Functions(); !reflect.DeepEqual(got, []string{"urlFor"}) {
+		t.Errorf("Expected only urlFor after a failed RegisterStruct, got %v", got)
+	}
+}
+
+// sortedFunctions lists the bridge's functions in order
+func sortedFunctions(bridge *core.SimpleBridge) []string {
+	names := bridge.Functions()
+	sort.Strings(names)
+	return names
+}
+
+// Test receivers implementing BridgeNamer choose their function names
+func TestBridgeRegisterStructNames(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.SetLogger(&testLogger{})
+
+	if err := bridge.RegisterStruct(&namedAPI{}); err != nil {
+		t.Fatalf("RegisterStruct failed: %v", err)
+	}
+	if got := sortedFunctions(bridge); !reflect.DeepEqual(got, []string{"counter.increment", "getCount"}) {
+		t.Errorf("Expected counter.increment and getCount, got %v", got)
+	}
+	if result, err := bridge.Call(context.Background(), "counter.increment"); err != nil || result != 1.0 {
+		t.Errorf("Expected count 1, got %v, %v", result, err)
+	}
+
+	for _, receiver := range []interface{}{nil, (*counterAPI)(nil), struct{}{}} {
+		err := bridge.RegisterStruct(receiver)
+		if info := core.ErrorInfoFor(err); err == nil || info.Code != core.CodeInvalidArgument {
+			t.Errorf("Expected registering %T to fail with %s, got %v", receiver, core.CodeInvalidArgument, err)
+		}
+	}
+}
+
+// Test names of the public window.polyglot API cannot be registered
+func TestBridgeReservedNames(t *testing.T) {
+	bridge := core.NewBridge()
+	fn := func(ctx context.Context, args ...interface{}) (interface{}, error) { return "ok", nil }
+
+	for _, name := range []string{"call", "on", "store", "capabilities"} {
+		err := bridge.Register(name, fn)
+		if info := core.ErrorInfoFor(err); err == nil || info.Code != core.CodeInvalidArgument {
+			t.Errorf("Expected registering %q to fail with %s, got %v", name, core.CodeInvalidArgument, err)
+		}
+		if err := bridge.RegisterWithTimeout(name, time.Second, fn); err == nil {
+			t.Errorf("Expected registering %q with a timeout to fail", name)
+		}
+	}
+	if len(bridge.Functions()) != 0 {
+		t.Errorf("Expected no functions registered, got %v", bridge.Functions())
+	}
+
+	// Names merely containing a reserved name are fine
+	for _, name := range []string{"callback", "getStore", "tasks.call"} {
+		if err := bridge.Register(name, fn); err != nil {
+			t.Errorf("Expected %q to register: %v", name, err)
+		}
+	}
+	if result, err := bridge.Call(context.Background(), "callback"); err != nil || result != "ok" {
+		t.Errorf("Expected callback to be callable, got %v (%v)", result, err)
+	}
+
+	// Internal helpers of window.polyglot do not reserve their names
+	for _, name := range []string{"format", "health", "send", "stream"} {
+		if err := bridge.Register(name, fn); err != nil {
+			t.Errorf("Expected %q to register: %v", name, err)
+		}
+	}
+	if result, err := bridge.Call(context.Background(), "format"); err != nil || result != "ok" {
+		t.Errorf("Expected format to be callable, got %v (%v)", result, err)
+	}
+}
+
+func TestResultStreamCollect(t *testing.T) {
+	stream := core.StreamSlice([]string{"a", "b", "c", "d", "e"}, 2)
+	result, err := stream.Collect()
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if !reflect.DeepEqual(result, []interface{}{"a", "b", "c", "d", "e"}) {
+		t.Errorf("Expected chunks to be concatenated, got %v", result)
+	}
+
+	// Chunks that are not lists are returned as a list of chunks
+	ch := make(chan interface{}, 3)
+	ch <- map[string]interface{}{"page": 1}
+	ch <- map[string]interface{}{"page": 2}
+	close(ch)
+	result, err = core.StreamChannel(ch).Collect()
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if pages, ok := result.([]interface{}); !ok || len(pages) != 2 {
+		t.Errorf("Expected two chunks, got %v", result)
+	}
+
+	// An error sent on the channel fails the stream and closes it
+	ch = make(chan interface{}, 2)
+	ch <- []interface{}{1}
+	ch <- errors.New("source failed")
+	stream = core.StreamChannel(ch)
+	if _, err := stream.Collect(); err == nil || err.Error() != "source failed" {
+		t.Errorf("Expected the source error, got %v", err)
+	}
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("Expected a failed stream to be closed, got %v", err)
+	}
+}
+
+func TestSerializedBridgeIncrement(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.SetSerialized(true)
+
+	// Unsynchronized state relies on bridge serialization
+	counter := 0
+	bridge.Register("increment", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		counter++
+		return counter, nil
+	})
+
+	const workers, calls = 50, 100
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				if _, err := bridge.Call(context.Background(), "increment"); err != nil {
+					t.Errorf("Call failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter != workers*calls {
+		t.Errorf("Expected counter %d, got %d", workers*calls, counter)
+	}
+}
+
+// callConcurrently calls each function workers*calls times from workers
+// goroutines
+func callConcurrently(t *testing.T, bridge core.Bridge, workers, calls int, names ...string) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				name := names[(i+j)%len(names)]
+				if _, err := bridge.Call(context.Background(), name); err != nil {
+					t.Errorf("Call %s failed: %v", name, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Run with -race: unsynchronized state is safe under serialized isolation
+func TestBridgeIsolationSerialized(t *testing.T) {
+	bridge := core.NewBridge()
+
+	counter := 0
+	err := bridge.RegisterWithIsolation("increment", core.Serialized, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		counter++
+		return counter, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterWithIsolation failed: %v", err)
+	}
+
+	callConcurrently(t, bridge, 50, 100, "increment")
+	if counter != 50*100 {
+		t.Errorf("Expected counter %d, got %d", 50*100, counter)
+	}
+	if isolation, _ := bridge.Isolation("increment"); isolation != core.Serialized {
+		t.Errorf("Expected serialized isolation, got %+v", isolation)
+	}
+}
+
+// Run with -race: functions of a group share a lock, and other groups do
+// not wait for it
+func TestBridgeIsolationSerializedGroup(t *testing.T) {
+	bridge := core.NewBridge()
+
+	var tasks []int
+	add := func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		tasks = append(tasks, len(tasks))
+		return len(tasks), nil
+	}
+	if err := bridge.RegisterWithIsolation("tasks.add", core.SerializedGroup("tasks"), add); err != nil {
+		t.Fatalf("RegisterWithIsolation failed: %v", err)
+	}
+	// Isolation can be set after registering, e.g. for typed functions
+	if err := bridge.RegisterTyped("tasks.count", func() int { return len(tasks) }); err != nil {
+		t.Fatalf("RegisterTyped failed: %v", err)
+	}
+	if err := bridge.SetIsolation("tasks.count", core.SerializedGroup("tasks")); err != nil {
+		t.Fatalf("SetIsolation failed: %v", err)
+	}
+
+	callConcurrently(t, bridge, 20, 100, "tasks.add", "tasks.count")
+	if len(tasks) != 20*100/2 {
+		t.Errorf("Expected %d tasks, got %d", 20*100/2, len(tasks))
+	}
+
+	// A call in another group runs while the tasks group is held
+	release := make(chan struct{})
+	held := make(chan struct{})
+	bridge.RegisterWithIsolation("tasks.block", core.SerializedGroup("tasks"), func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		close(held)
+		<-release
+		return nil, nil
+	})
+	bridge.RegisterWithIsolation("stats.get", core.SerializedGroup("stats"), func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "stats", nil
+	})
+
+	go bridge.Call(context.Background(), "tasks.block")
+	<-held
+	done := make(chan struct{})
+	go func() {
+		bridge.Call(context.Background(), "stats.get")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("Expected another group not to wait for the tasks group")
+	}
+	close(release)
+}
+
+// Run with -race: concurrent functions run in parallel even on a
+// serialized bridge
+func TestBridgeIsolationConcurrent(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.SetSerialized(true)
+
+	var running, peak int32
+	arrived := make(chan struct{})
+	var once sync.Once
+	err := bridge.RegisterWithIsolation("fibonacci", core.Concurrent, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		if n >= 2 {
+			once.Do(func() { close(arrived) })
+		}
+		select {
+		case <-arrived:
+		case <-time.After(2 * time.Second):
+		}
+		return n, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterWithIsolation failed: %v", err)
+	}
+
+	callConcurrently(t, bridge, 4, 5, "fibonacci")
+	if atomic.LoadInt32(&peak) < 2 {
+		t.Error("Expected concurrent calls to overlap on a serialized bridge")
+	}
+
+	// Functions without an isolation follow the bridge
+	bridge.Register("plain", func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil })
+	if isolation, ok := bridge.Isolation("plain"); !ok || isolation != core.Serialized {
+		t.Errorf("Expected plain to be serialized, got %+v", isolation)
+	}
+}
+
+func TestBridgeIsolationValidation(t *testing.T) {
+	bridge := core.NewBridge()
+	fn := func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil }
+
+	for _, isolation := range []core.Isolation{core.SerializedGroup(""), {Level: "exclusive"}} {
+		err := bridge.RegisterWithIsolation("f", isolation, fn)
+		if info := core.ErrorInfoFor(err); err == nil || info.Code != core.CodeInvalidArgument {
+			t.Errorf("Expected %+v to be rejected, got %v", isolation, err)
+		}
+	}
+	if err := bridge.SetIsolation("missing", core.Serialized); core.ErrorInfoFor(err).Code != core.CodeNotFound {
+		t.Errorf("Expected NOT_FOUND for an unregistered function, got %v", err)
+	}
+	if _, ok := bridge.Isolation("missing"); ok {
+		t.Error("Expected no isolation for an unregistered function")
+	}
+}
+
+func TestFunctionAccess(t *testing.T) {
+	access := core.FunctionAccess{Allow: []string{"greet", "tasks.*"}, Deny: []string{"tasks.delete"}}
+	for name, want := range map[string]bool{
+		"greet":        true,
+		"tasks.list":   true,
+		"tasks.batch":  true,
+		"tasks.delete": false,
+		"settings.get": false,
+		"greeting":     false,
+	} {
+		if got := access.Allows(name); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	bridge := core.NewBridge()
+	if core.RestrictBridge(bridge, &core.FunctionAccess{}) != core.Bridge(bridge) || core.RestrictBridge(bridge, nil) != core.Bridge(bridge) {
+		t.Error("Expected an unrestricted access to return the bridge itself")
+	}
+	// Deny alone allows everything else
+	deny := core.FunctionAccess{Deny: []string{"admin.*"}}
+	if !deny.Allows("greet") || deny.Allows("admin.reset") {
+		t.Error("Expected a deny list to only block matching functions")
+	}
+}
+
+func TestBridgeExtend(t *testing.T) {
+	global := core.NewBridge()
+	global.Register("version", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "1.0", nil
+	})
+	global.Register("title", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "global", nil
+	})
+
+	window := core.NewBridge()
+	if err := window.Extend(global); err != nil {
+		t.Fatalf("Extend failed: %v", err)
+	}
+	window.Register("title", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "window", nil
+	})
+
+	// Inherited functions resolve through the parent
+	if result, err := window.Call(context.Background(), "version"); err != nil || result != "1.0" {
+		t.Errorf("Expected inherited '1.0', got %v (%v)", result, err)
+	}
+
+	// The child wins on name collision without affecting the parent
+	if result, _ := window.Call(context.Background(), "title"); result != "window" {
+		t.Errorf("Expected child override 'window', got %v", result)
+	}
+	if result, _ := global.Call(context.Background(), "title"); result != "global" {
+		t.Errorf("Expected parent 'global', got %v", result)
+	}
+
+	names := window.Functions()
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"title", "version"}) {
+		t.Errorf("Expected merged function list, got %v", names)
+	}
+
+	// Removing the override exposes the parent's function again
+	if err := window.Unregister("title"); err != nil {
+		t.Fatalf("Unregister failed: %v", err)
+	}
+	if result, _ := window.Call(context.Background(), "title"); result != "global" {
+		t.Errorf("Expected parent 'global' after unregister, got %v", result)
+	}
+	if err := window.Unregister("version"); err == nil {
+		t.Error("Should not be able to unregister an inherited function")
+	}
+
+	// Functions registered on the parent later are visible to the child
+	global.Register("late", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "late", nil
+	})
+	if result, _ := window.Call(context.Background(), "late"); result != "late" {
+		t.Errorf("Expected late parent registration, got %v", result)
+	}
+
+	if _, err := window.Call(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for missing function")
+	} else if info := core.ErrorInfoFor(err); info.Code != core.CodeNotFound {
+		t.Errorf("Expected %s, got %s", core.CodeNotFound, info.Code)
+	}
+
+	if err := global.Extend(window); err == nil {
+		t.Error("Expected error for cyclic extension")
+	}
+}
+
+// Test calls to unregistered functions fail with ErrFunctionNotFound
+// naming the function, unless a fallback handles them
+func TestBridgeFallbackHandler(t *testing.T) {
+	bridge := core.NewBridge()
+	ctx := context.Background()
+
+	_, err := bridge.Call(ctx, "missing")
+	if !errors.Is(err, core.ErrFunctionNotFound) {
+		t.Fatalf("Expected ErrFunctionNotFound, got %v", err)
+	}
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeNotFound || info.Details["function"] != "missing" {
+		t.Errorf("Expected NOT_FOUND naming missing, got %+v", info)
+	}
+
+	bridge.Register("known", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "known", nil
+	})
+	bridge.SetFallbackHandler(func(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+		return fmt.Sprintf("proxied %s%v", name, args), nil
+	})
+
+	if result, err := bridge.Call(ctx, "known"); err != nil || result != "known" {
+		t.Errorf("Expected registered function to take precedence, got %v, %v", result, err)
+	}
+	if result, err := bridge.Call(ctx, "remote.sum", 1, 2); err != nil || result != "proxied remote.sum[1 2]" {
+		t.Errorf("Expected fallback to handle remote.sum, got %v, %v", result, err)
+	}
+
+	// Functions of a parent bridge are found before the fallback
+	parent := core.NewBridge()
+	parent.Register("shared", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "shared", nil
+	})
+	parent.Register("broken", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return parent.Call(ctx, "gone")
+	})
+	if err := bridge.Extend(parent); err != nil {
+		t.Fatalf("Extend failed: %v", err)
+	}
+	if result, err := bridge.Call(ctx, "shared"); err != nil || result != "shared" {
+		t.Errorf("Expected parent function, got %v, %v", result, err)
+	}
+	if result, err := bridge.Call(ctx, "elsewhere"); err != nil || result != "proxied elsewhere[]" {
+		t.Errorf("Expected fallback after parent, got %v, %v", result, err)
+	}
+
+	// A parent handler failing to find another function is not rerouted
+	_, err = bridge.Call(ctx, "broken")
+	if info := core.ErrorInfoFor(err); !errors.Is(err, core.ErrFunctionNotFound) || info.Details["function"] != "gone" {
+		t.Errorf("Expected broken to report gone not found, got %v", err)
+	}
+
+	bridge.SetFallbackHandler(nil)
+	if _, err := bridge.Call(ctx, "elsewhere"); !errors.Is(err, core.ErrFunctionNotFound) {
+		t.Errorf("Expected ErrFunctionNotFound without a fallback, got %v", err)
+	}
+}
+
+type manifestUser struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email,omitempty"`
+	Tags  []string `json:"tags"`
+	Boss  *manifestUser
+	notes string
+}
+
+func TestBridgeManifest(t *testing.T) {
+	bridge := core.NewBridge()
+	if err := bridge.RegisterTyped("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatalf("RegisterTyped failed: %v", err)
+	}
+	bridge.RegisterTyped("greet", func(ctx context.Context, u manifestUser) (string, error) {
+		return "hello " + u.Name, nil
+	})
+	bridge.RegisterTyped("join", func(sep string, parts ...string) string { return strings.Join(parts, sep) })
+	bridge.RegisterTyped("reset", func() error { return nil })
+	bridge.Register("legacy", func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil })
+
+	// Typed functions convert decoded JSON arguments
+	result, err := bridge.Call(context.Background(), "add", 2.0, 3.0)
+	if err != nil || result != 5 {
+		t.Errorf("Expected 5, got %v (%v)", result, err)
+	}
+	result, err = bridge.Call(context.Background(), "greet", map[string]interface{}{"name": "Ada"})
+	if err != nil || result != "hello Ada" {
+		t.Errorf("Expected 'hello Ada', got %v (%v)", result, err)
+	}
+	if _, err := bridge.Call(context.Background(), "add", "2", 3.0); core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+		t.Errorf("Expected %s for bad argument, got %v", core.CodeInvalidArgument, err)
+	}
+
+	if err := bridge.RegisterTyped("pair", func() (int, int) { return 1, 2 }); err == nil {
+		t.Error("Expected error for multiple results")
+	}
+	if err := bridge.RegisterTyped("notfunc", 42); err == nil {
+		t.Error("Expected error for non-function")
+	}
+
+	number := core.TypeManifest{Kind: core.KindNumber}
+	str := core.TypeManifest{Kind: core.KindString}
+	any := core.TypeManifest{Kind: core.KindAny}
+	user := core.TypeManifest{Kind: core.KindObject, Fields: []core.FieldManifest{
+		{Name: "name", Type: str},
+		{Name: "email", Type: str, Optional: true},
+		{Name: "tags", Type: core.TypeManifest{Kind: core.KindArray, Elem: &str}},
+		{Name: "Boss", Type: core.TypeManifest{Kind: core.KindAny, Nullable: true}},
+	}}
+	expected := []core.FunctionManifest{
+		{Name: "add", Args: []core.TypeManifest{number, number}, Returns: number},
+		{Name: "greet", Args: []core.TypeManifest{user}, Returns: str},
+		{Name: "join", Args: []core.TypeManifest{str, str}, Variadic: true, Returns: str},
+		{Name: "legacy", Args: []core.TypeManifest{any}, Variadic: true, Returns: any},
+		{Name: "reset", Args: []core.TypeManifest{}, Returns: core.TypeManifest{Kind: core.KindVoid}},
+	}
+	if got := bridge.Manifest(); !reflect.DeepEqual(got, expected) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		t.Errorf("Unexpected manifest:\n%s", gotJSON)
+	}
+
+	// Child bridges report inherited functions unless they override them
+	window := core.NewBridge()
+	window.Extend(bridge)
+	window.RegisterTyped("add", func(a, b string) string { return a + b })
+	if got := window.Manifest(); len(got) != 5 || got[0].Returns.Kind != core.KindString || got[1].Name != "greet" {
+		t.Errorf("Unexpected child manifest: %+v", got)
+	}
+}
+
+func TestAPISpec(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.RegisterTyped("greet", func(ctx context.Context, u manifestUser, times int) (string, error) {
+		return strings.Repeat("hello "+u.Name, times), nil
+	})
+	bridge.RegisterTyped("join", func(sep string, parts ...string) string { return strings.Join(parts, sep) })
+	bridge.RegisterTyped("reset", func() error { return nil })
+	bridge.Register("legacy", func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil })
+
+	if err := bridge.Document("greet", "Greets a user", "user", "times"); err != nil {
+		t.Fatalf("Document failed: %v", err)
+	}
+	if err := bridge.Document("reset", "Resets", "extra"); core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+		t.Errorf("Expected more names than arguments rejected, got %v", err)
+	}
+	if err := bridge.Document("missing", "Nothing"); core.ErrorInfoFor(err).Code != core.CodeNotFound {
+		t.Errorf("Expected an unknown function rejected, got %v", err)
+	}
+
+	spec := core.NewAPISpec(core.APIInfo{Title: "Test", Version: "1.0.0"}, bridge.Manifest())
+	if spec.OpenAPI != core.APISpecVersion || len(spec.Paths) != 4 {
+		t.Fatalf("Expected 4 operations, got %+v", spec.Paths)
+	}
+
+	greet := spec.Paths["/greet"].Post
+	if greet.OperationID != "greet" || greet.Description != "Greets a user" {
+		t.Errorf("Unexpected greet operation: %+v", greet)
+	}
+	args := greet.RequestBody.Content["application/json"].Schema
+	if args.Type != "array" || len(args.PrefixItems) != 2 || args.MinItems != 2 || args.MaxItems == nil || *args.MaxItems != 2 {
+		t.Fatalf("Unexpected greet arguments: %+v", args)
+	}
+	user, times := args.PrefixItems[0], args.PrefixItems[1]
+	if user.Title != "user" || user.Type != "object" || !reflect.DeepEqual(user.Required, []string{"name", "tags", "Boss"}) {
+		t.Errorf("Unexpected user schema: %+v", user)
+	}
+	if tags := user.Properties["tags"]; tags.Type != "array" || tags.Items.Type != "string" {
+		t.Errorf("Unexpected tags schema: %+v", tags)
+	}
+	if boss := user.Properties["Boss"]; boss.Type != nil {
+		t.Errorf("Expected the recursive field to accept any value, got %+v", boss)
+	}
+	if times.Title != "times" || times.Type != "number" {
+		t.Errorf("Unexpected times schema: %+v", times)
+	}
+	if result := greet.Responses["200"].Content["application/json"].Schema; result.Type != "string" {
+		t.Errorf("Unexpected greet result: %+v", result)
+	}
+
+	join := spec.Paths["/join"].Post.RequestBody.Content["application/json"].Schema
+	if len(join.PrefixItems) != 1 || join.Items == nil || join.Items.Type != "string" || join.MaxItems != nil {
+		t.Errorf("Expected variadic arguments as repeated items, got %+v", join)
+	}
+	if reset := spec.Paths["/reset"].Post.Responses["200"]; reset.Content != nil {
+		t.Errorf("Expected no result content for a void function, got %+v", reset)
+	}
+
+	// The spec is served as JSON, and restricted bridges only describe
+	// what they allow
+	restricted := core.RestrictBridge(bridge, &core.FunctionAccess{Allow: []string{"greet", "join"}})
+	server := httptest.NewServer(core.APISpecHandler(restricted, core.APIInfo{Title: "Test", Version: "1.0.0"}))
+	defer server.Close()
+	resp, err := http.Get(server.URL + core.APISpecPath)
+	if err != nil {
+		t.Fatalf("GET spec failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var served struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&served); err != nil {
+		t.Fatalf("Decode spec failed: %v", err)
+	}
+	if len(served.Paths) != 2 || served.Paths["/greet"] == nil || served.Paths["/legacy"] != nil {
+		t.Errorf("Expected only allowed functions served, got %v", served.Paths)
+	}
+}
+
+type enumStatus string
+
+func (enumStatus) EnumValues() []string { return []string{"todo", "doing", "done"} }
+
+type enumTask struct {
+	Title    string       `json:"title"`
+	Status   enumStatus   `json:"status"`
+	Previous enumStatus   `json:"previous,omitempty"`
+	History  []enumStatus `json:"history,omitempty"`
+}
+
+func TestEnumValidation(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.RegisterTyped("setStatus", func(id int, status enumStatus) string { return string(status) })
+	bridge.RegisterTyped("updateTask", func(task enumTask) string { return string(task.Status) })
+
+	ctx := context.Background()
+	if result, err := bridge.Call(ctx, "setStatus", 1.0, "doing"); err != nil || result != "doing" {
+		t.Errorf("Expected valid status to be accepted, got %v (%v)", result, err)
+	}
+	valid := map[string]interface{}{"title": "Ship", "status": "done", "history": []interface{}{"todo", "doing"}}
+	if result, err := bridge.Call(ctx, "updateTask", valid); err != nil || result != "done" {
+		t.Errorf("Expected valid task to be accepted, got %v (%v)", result, err)
+	}
+
+	invalid := []struct {
+		fn    string
+		args  []interface{}
+		field string
+	}{
+		{"setStatus", []interface{}{1.0, "urgent"}, ""},
+		{"updateTask", []interface{}{map[string]interface{}{"title": "Ship", "status": "urgent"}}, "status"},
+		{"updateTask", []interface{}{map[string]interface{}{"title": "Ship"}}, "status"},
+		{"updateTask", []interface{}{map[string]interface{}{"status": "todo", "history": []interface{}{"todo", "later"}}}, "history[1]"},
+	}
+	for _, tc := range invalid {
+		_, err := bridge.Call(ctx, tc.fn, tc.args...)
+		info := core.ErrorInfoFor(err)
+		if info.Code != core.CodeInvalidArgument {
+			t.Errorf("%s%v: expected %s, got %v", tc.fn, tc.args, core.CodeInvalidArgument, err)
+			continue
+		}
+		if tc.field != "" && info.Details["field"] != tc.field {
+			t.Errorf("%s%v: expected field %q, got %v", tc.fn, tc.args, tc.field, info.Details["field"])
+		}
+		if !reflect.DeepEqual(info.Details["allowed"], []string{"todo", "doing", "done"}) {
+			t.Errorf("%s%v: expected allowed values in details, got %v", tc.fn, tc.args, info.Details)
+		}
+	}
+
+	manifest := bridge.Manifest()
+	if got := manifest[0].Args[1]; got.Kind != core.KindString || !reflect.DeepEqual(got.Enum, []string{"todo", "doing", "done"}) {
+		t.Errorf("Expected enum in manifest, got %+v", got)
+	}
+
+	// Untyped handlers validate through an Enum
+	priority := core.NewEnum("priority", "low", "medium", "high")
+	if value, err := priority.Parse("high"); err != nil || value != "high" {
+		t.Errorf("Expected high to be accepted, got %q (%v)", value, err)
+	}
+	for _, arg := range []interface{}{"urgent", 3.0, nil} {
+		_, err := priority.Parse(arg)
+		if info := core.ErrorInfoFor(err); info.Code != core.CodeInvalidArgument || info.Details["field"] != "priority" {
+			t.Errorf("Expected %v to be rejected, got %v", arg, err)
+		}
+	}
+}
+
+func TestParsePythonTraceback(t *testing.T) {
+	// As the Python runtime reports an exception raised in a helper
+	traceback := `ValueError: invalid literal for int() with base 10: 'abc'
+
+Traceback:
+Traceback (most recent call last):
+  File "<string>", line 6, in <module>
+  File "<string>", line 3, in parse
+    return int(value)
+           ^^^^^^^^^^
+  File "/usr/lib/python3.11/json/decoder.py", line 337, in decode
+    obj, end = self.raw_decode(s, idx=_w(s, 0).end())
+ValueError: invalid literal for int() with base 10: 'abc'
+`
+	script, ok := core.ParsePythonTraceback(traceback)
+	if !ok {
+		t.Fatal("Expected traceback to parse")
+	}
+	if script.Type != "ValueError" || script.Message != "invalid literal for int() with base 10: 'abc'" {
+		t.Errorf("Unexpected exception %q: %q", script.Type, script.Message)
+	}
+	if len(script.Frames) != 3 || script.Frames[1].Source != "return int(value)" {
+		t.Errorf("Unexpected frames %+v", script.Frames)
+	}
+	expected := core.TraceFrame{File: "<string>", Line: 3, Function: "parse", Source: "return int(value)"}
+	if script.Frame == nil || *script.Frame != expected {
+		t.Errorf("Expected top user frame %+v, got %+v", expected, script.Frame)
+	}
+	if script.Traceback != traceback {
+		t.Error("Expected the full traceback to be kept")
+	}
+
+	info := core.ErrorInfoFor(fmt.Errorf("execution failed: %w", script))
+	if info.Code != core.CodeScriptError {
+		t.Errorf("Expected %s, got %s", core.CodeScriptError, info.Code)
+	}
+	if info.Message != "ValueError: invalid literal for int() with base 10: 'abc' (line 3)" {
+		t.Errorf("Unexpected summary %q", info.Message)
+	}
+	if info.Details["type"] != "ValueError" || info.Details["frame"] != expected || info.Details["traceback"] != traceback {
+		t.Errorf("Unexpected details %+v", info.Details)
+	}
+
+	// Syntax errors have no traceback header
+	script, ok = core.ParsePythonTraceback(`  File "<string>", line 1
+    x = = 1
+        ^
+SyntaxError: invalid syntax`)
+	if !ok || script.Type != "SyntaxError" || script.Message != "invalid syntax" || script.Frame == nil || script.Frame.Line != 1 {
+		t.Errorf("Unexpected syntax error %+v", script)
+	}
+
+	// Only the last of chained exceptions is kept
+	script, ok = core.ParsePythonTraceback(`Traceback (most recent call last):
+  File "<string>", line 2, in <module>
+KeyError: 'name'
+
+During handling of the above exception, another exception occurred:
+
+Traceback (most recent call last):
+  File "<string>", line 4, in <module>
+RuntimeError: lookup failed`)
+	if !ok || script.Type != "RuntimeError" || script.Message != "lookup failed" || len(script.Frames) != 1 || script.Frame.Line != 4 {
+		t.Errorf("Unexpected chained error %+v", script)
+	}
+
+	if _, ok := core.ParsePythonTraceback("  File \"<string>\", line 1\n"); ok {
+		t.Error("Expected text without an exception line not to parse")
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	invalid := []byte("caf\xe9 \xff\x00")
+
+	text, raw := core.DecodeOutput([]byte("café"), core.OutputRaw)
+	if text != "café" || raw != nil {
+		t.Errorf("Expected valid output as text, got %q, %x", text, raw)
+	}
+
+	text, raw = core.DecodeOutput(invalid, core.OutputRaw)
+	if text != "" || !bytes.Equal(raw, invalid) {
+		t.Errorf("Expected invalid output byte for byte, got %q, %x", text, raw)
+	}
+	invalid[0] = 'C'
+	if raw[0] != 'c' {
+		t.Error("Expected raw output to be a copy")
+	}
+
+	for _, encoding := range []core.OutputEncoding{"", core.OutputReplace} {
+		text, raw = core.DecodeOutput([]byte("caf\xe9 \xff\x00"), encoding)
+		if raw != nil || text != "caf\uFFFD \uFFFD\x00" {
+			t.Errorf("%q: expected invalid bytes replaced, got %q, %x", encoding, text, raw)
+		}
+	}
+}
+
+func TestParseStructuredOutput(t *testing.T) {
+	output := []byte("loading data\n" + core.ResultStart + `{"name": "widget", "count": 3, "ratio": 0.5, "tags": ["a", "b"]}` + core.ResultEnd + "\ndone\n")
+	result, found, err := core.ParseStructuredOutput(output)
+	if err != nil || !found {
+		t.Fatalf("Expected a result, got found=%v, %v", found, err)
+	}
+	expected := map[string]interface{}{"name": "widget", "count": int64(3), "ratio": 0.5, "tags": []interface{}{"a", "b"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %#v", expected, result)
+	}
+
+	// The last result wins, and may span lines
+	output = []byte(core.ResultStart + "1" + core.ResultEnd + "\n" + core.ResultStart + "[\n  2\n]\n" + core.ResultEnd)
+	if result, _, err := core.ParseStructuredOutput(output); err != nil || !reflect.DeepEqual(result, []interface{}{int64(2)}) {
+		t.Errorf("Expected the last result [2], got %v, %v", result, err)
+	}
+
+	if _, found, err := core.ParseStructuredOutput([]byte("plain output\n")); found || err != nil {
+		t.Errorf("Expected no result in plain output, got found=%v, %v", found, err)
+	}
+	for _, bad := range []string{
+		core.ResultStart + `{"unterminated": ` + core.ResultEnd,
+		core.ResultStart + `{} {}` + core.ResultEnd,
+		core.ResultStart + `{}`,
+	} {
+		if _, found, err := core.ParseStructuredOutput([]byte(bad)); !found || err == nil {
+			t.Errorf("Expected %q to fail, got found=%v, %v", bad, found, err)
+		}
+	}
+}
+
+func TestSafeState(t *testing.T) {
+	var counter core.SafeCounter
+	tasks := core.NewSafeSlice()
+	seen := core.NewSafeMap()
+
+	const workers, calls = 50, 100
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				counter.Increment()
+				tasks.Append(j)
+				seen.Update("total", func(value interface{}, exists bool) interface{} {
+					if !exists {
+						return 1
+					}
+					return value.(int) + 1
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if counter.Get() != workers*calls {
+		t.Errorf("Expected counter %d, got %d", workers*calls, counter.Get())
+	}
+	if tasks.Len() != workers*calls {
+		t.Errorf("Expected %d tasks, got %d", workers*calls, tasks.Len())
+	}
+	if total, _ := seen.Get("total"); total != workers*calls {
+		t.Errorf("Expected map total %d, got %v", workers*calls, total)
+	}
+}
+
+func TestSafeSliceRemoveFunc(t *testing.T) {
+	type task struct{ name string }
+	tasks := core.NewSafeSlice()
+	tasks.Append(&task{"a"}, &task{"b"}, &task{"c"}, &task{"d"})
+
+	if !tasks.RemoveFunc(func(item interface{}) bool { return item.(*task).name == "b" }) {
+		t.Fatal("Expected b to be removed")
+	}
+	if tasks.RemoveFunc(func(item interface{}) bool { return item.(*task).name == "b" }) {
+		t.Error("Expected nothing left to remove")
+	}
+
+	var names []string
+	for _, item := range tasks.Snapshot() {
+		names = append(names, item.(*task).name)
+	}
+	if strings.Join(names, ",") != "a,c,d" {
+		t.Errorf("Expected a,c,d to remain in order, got %v", names)
+	}
+
+	// Removed items are not kept alive by the slots left behind, while the
+	// slice itself still is
+	defer runtime.KeepAlive(tasks)
+	collected := make(chan struct{})
+	if item, _ := tasks.Get(2); item != nil {
+		runtime.SetFinalizer(item.(*task), func(*task) { close(collected) })
+	}
+	if !tasks.RemoveFunc(func(item interface{}) bool { return item.(*task).name == "d" }) {
+		t.Fatal("Expected d to be removed")
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-collected:
+			return
+		case <-deadline:
+			t.Fatal("Expected the removed item to be garbage collected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// newTaskGroup builds a handler group over a task list for batch tests
+func newTaskGroup(t *testing.T) (*core.HandlerGroup, *core.SafeSlice, core.Bridge) {
+	tasks := core.NewSafeSlice()
+	group := core.NewHandlerGroup("tasks",
+		func() interface{} { return tasks.Snapshot() },
+		func(snapshot interface{}) { tasks.Restore(snapshot.([]interface{})) })
+
+	group.Handle("add", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		title, ok := args[0].(string)
+		if !ok || title == "" {
+			return nil, core.NewError(core.CodeInvalidArgument, "title is required")
+		}
+		tasks.Append(title)
+		return tasks.Len(), nil
+	})
+	group.Handle("delete", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		title := args[0].(string)
+		if !tasks.RemoveFunc(func(item interface{}) bool { return item == title }) {
+			return nil, core.Errorf(core.CodeNotFound, "task %s not found", title)
+		}
+		return true, nil
+	})
+
+	bridge := core.NewBridge()
+	if err := group.Register(bridge); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	return group, tasks, bridge
+}
+
+func TestHandlerGroupBatch(t *testing.T) {
+	ctx := context.Background()
+	_, tasks, bridge := newTaskGroup(t)
+
+	if _, err := bridge.Call(ctx, "tasks.add", "existing"); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	// Operations arrive from the frontend as decoded JSON
+	ops := []interface{}{
+		map[string]interface{}{"method": "add", "args": []interface{}{"write docs"}},
+		map[string]interface{}{"method": "delete", "args": []interface{}{"existing"}},
+		map[string]interface{}{"method": "add", "args": []interface{}{"ship"}},
+	}
+	result, err := bridge.Call(ctx, "tasks.batch", ops)
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	if results := result.([]interface{}); len(results) != 3 || results[0] != 2 || results[1] != true {
+		t.Errorf("Unexpected batch results %v", results)
+	}
+	if got := tasks.Snapshot(); len(got) != 2 || got[0] != "write docs" || got[1] != "ship" {
+		t.Errorf("Unexpected tasks after batch: %v", got)
+	}
+}
+
+func TestHandlerGroupBatchRollback(t *testing.T) {
+	ctx := context.Background()
+	group, tasks, bridge := newTaskGroup(t)
+	group.Call(ctx, "add", "existing")
+
+	_, err := group.Batch(ctx, []core.BatchOp{
+		{Method: "add", Args: []interface{}{"first"}},
+		{Method: "delete", Args: []interface{}{"existing"}},
+		{Method: "delete", Args: []interface{}{"missing"}},
+		{Method: "add", Args: []interface{}{"never"}},
+	})
+	info := core.ErrorInfoFor(err)
+	if info.Code != core.CodeNotFound || info.Details["index"] != 2 {
+		t.Errorf("Expected NOT_FOUND at operation 2, got %v (%+v)", err, info)
+	}
+	if got := tasks.Snapshot(); len(got) != 1 || got[0] != "existing" {
+		t.Errorf("Expected prior operations rolled back, got %v", got)
+	}
+
+	// Unknown methods and malformed operations also reject the batch
+	_, err = bridge.Call(ctx, "tasks.batch", []interface{}{
+		map[string]interface{}{"method": "add", "args": []interface{}{"first"}},
+		map[string]interface{}{"method": "rename"},
+	})
+	if core.ErrorInfoFor(err).Code != core.CodeNotFound || tasks.Len() != 1 {
+		t.Errorf("Expected unknown method to roll back, got %v with %d tasks", err, tasks.Len())
+	}
+	if _, err := bridge.Call(ctx, "tasks.batch", "add"); core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+		t.Errorf("Expected INVALID_ARGUMENT for malformed batch, got %v", err)
+	}
+}
+
+func TestErrorInfoFor(t *testing.T) {
+	policyErr := &core.PolicyViolationError{Runtime: "python", Rule: "max-length", Detail: "too long"}
+
+	tests := []struct {
+		err  error
+		code core.ErrorCode
+	}{
+		{core.NewError(core.CodeForbidden, "no"), core.CodeForbidden},
+		{fmt.Errorf("wrapped: %w", core.NewError(core.CodeUnavailable, "down")), core.CodeUnavailable},
+		{policyErr, core.CodePolicyViolation},
+		{context.DeadlineExceeded, core.CodeTimeout},
+		{fmt.Errorf("call: %w", context.Canceled), core.CodeCanceled},
+		{fmt.Errorf("boom"), core.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		if info := core.ErrorInfoFor(tt.err); info.Code != tt.code {
+			t.Errorf("ErrorInfoFor(%v) code = %s, want %s", tt.err, info.Code, tt.code)
+		}
+	}
+
+	if info := core.ErrorInfoFor(policyErr); info.Details["rule"] != "max-length" {
+		t.Errorf("Expected policy rule detail, got %v", info.Details)
+	}
+
+	cause := fmt.Errorf("io failure")
+	err := core.Errorf(core.CodeUnavailable, "storage: %w", cause)
+	if !errors.Is(err, cause) || err.Error() != "storage: io failure" {
+		t.Errorf("Expected Errorf to wrap cause, got %v", err)
+	}
+}
+
+// poolWorker is a worker for WorkerPool tests
+type poolWorker struct {
+	id        int
+	destroyed atomic.Bool
+}
+
+func newTestWorkerPool(t *testing.T, size int) (*core.WorkerPool[*poolWorker], []*poolWorker) {
+	t.Helper()
+	var created []*poolWorker
+	pool, err := core.NewWorkerPool(size, func(id int) (*poolWorker, error) {
+		w := &poolWorker{id: id}
+		created = append(created, w)
+		return w, nil
+	}, func(w *poolWorker) { w.destroyed.Store(true) })
+	if err != nil {
+		t.Fatalf("NewWorkerPool failed: %v", err)
+	}
+	return pool, created
+}
+
+func TestWorkerPoolReuse(t *testing.T) {
+	pool, _ := newTestWorkerPool(t, 2)
+	defer pool.Close()
+
+	ctx := context.Background()
+	seen := make(map[*poolWorker]bool)
+	for i := 0; i < 10; i++ {
+		w, err := pool.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		seen[w] = true
+		pool.Release(w)
+	}
+	if len(seen) > 2 {
+		t.Errorf("Expected workers to be reused, saw %d", len(seen))
+	}
+
+	// Releasing twice must not hand a worker to two callers
+	w, _ := pool.Acquire(ctx)
+	pool.Release(w)
+	pool.Release(w)
+	a, _ := pool.Acquire(ctx)
+	b, _ := pool.Acquire(ctx)
+	if a == b {
+		t.Error("Expected distinct workers after a double release")
+	}
+	if stats := pool.Stats(); stats.Workers != 2 || stats.Idle != 0 || stats.Acquired != 13 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	pool.Release(a)
+	pool.Release(b)
+}
+
+func TestWorkerPoolExhaustion(t *testing.T) {
+	pool, _ := newTestWorkerPool(t, 1)
+	defer pool.Close()
+
+	w, err := pool.TryAcquire()
+	if err != nil {
+		t.Fatalf("TryAcquire failed: %v", err)
+	}
+	if _, err := pool.TryAcquire(); !errors.Is(err, core.ErrPoolExhausted) || core.ErrorInfoFor(err).Code != core.CodeResourceExhausted {
+		t.Errorf("Expected ErrPoolExhausted, got %v", err)
+	}
+
+	// A waiting caller gets the worker once it is released
+	got := make(chan *poolWorker, 1)
+	go func() {
+		w, _ := pool.Acquire(context.Background())
+		got <- w
+	}()
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Waiting != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	pool.Release(w)
+	select {
+	case next := <-got:
+		if next != w {
+			t.Error("Expected the waiting caller to get the released worker")
+		}
+		pool.Release(next)
+	case <-time.After(time.Second):
+		t.Fatal("Waiting caller was not served")
+	}
+
+	stats := pool.Stats()
+	if stats.Waiting != 0 || stats.Waits != 1 || stats.Exhausted != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestWorkerPoolCancellation(t *testing.T) {
+	pool, _ := newTestWorkerPool(t, 1)
+	defer pool.Close()
+
+	w, _ := pool.Acquire(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Waiting != 0 {
+		t.Errorf("Expected the timed out caller to stop waiting, got %+v", stats)
+	}
+
+	// The abandoned wait does not keep the worker from later callers
+	pool.Release(w)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); err != nil {
+		t.Errorf("Expected Acquire after release to succeed, got %v", err)
+	}
+}
+
+func TestWorkerPoolShutdown(t *testing.T) {
+	pool, workers := newTestWorkerPool(t, 2)
+
+	busy, _ := pool.Acquire(context.Background())
+	waitErr := make(chan error, 1)
+	go func() {
+		held, _ := pool.Acquire(context.Background())
+		_, err := pool.Acquire(context.Background())
+		pool.Release(held)
+		waitErr <- err
+	}()
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Waiting != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	pool.Close()
+	pool.Close()
+	select {
+	case err := <-waitErr:
+		if !errors.Is(err, core.ErrPoolClosed) {
+			t.Errorf("Expected waiting caller to get ErrPoolClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake the waiting caller")
+	}
+
+	// Busy workers are destroyed when released, not out from under callers
+	if busy.destroyed.Load() {
+		t.Error("Expected busy worker to survive Close")
+	}
+	pool.Release(busy)
+	for _, w := range workers {
+		if !w.destroyed.Load() {
+			t.Errorf("Expected worker %d destroyed", w.id)
+		}
+	}
+	if _, err := pool.Acquire(context.Background()); !errors.Is(err, core.ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Workers != 0 {
+		t.Errorf("Expected no live workers after close, got %+v", stats)
+	}
+}
+
+func TestWorkerPoolAffinity(t *testing.T) {
+	pool, _ := newTestWorkerPool(t, 4)
+	defer pool.Close()
+
+	ctx := core.WithAffinity(context.Background(), "session")
+	first, _ := pool.Acquire(ctx)
+	pool.Release(first)
+	for i := 0; i < 5; i++ {
+		w, err := pool.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		if w != first {
+			t.Fatalf("Expected session to keep worker %d, got %d", first.id, w.id)
+		}
+		pool.Release(w)
+	}
+
+	// A session waits for its own worker even while others are idle
+	held, _ := pool.Acquire(ctx)
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected session to wait for its worker, got %v", err)
+	}
+	pool.Release(held)
+}
+
+func TestWorkerPoolAutoscale(t *testing.T) {
+	bus := core.NewEventBus()
+	events, unsubscribe := bus.Subscribe(core.TopicPoolScale, 16)
+	defer unsubscribe()
+
+	var started, destroyed atomic.Int32
+	pool, err := core.NewWorkerPoolWith(core.WorkerPoolOptions{
+		Max:         4,
+		Min:         1,
+		IdleTimeout: 30 * time.Millisecond,
+		Name:        "test",
+		Events:      bus,
+	}, func(id int) (*poolWorker, error) {
+		started.Add(1)
+		return &poolWorker{id: id}, nil
+	}, func(w *poolWorker) {
+		destroyed.Add(1)
+		w.destroyed.Store(true)
+	})
+	if err != nil {
+		t.Fatalf("NewWorkerPoolWith failed: %v", err)
+	}
+	defer pool.Close()
+
+	if stats := pool.Stats(); stats.Workers != 1 {
+		t.Fatalf("Expected only the minimum worker up front, got %+v", stats)
+	}
+
+	// Sustained load queues callers, growing the pool to its maximum
+	ctx := context.Background()
+	var held []*poolWorker
+	for i := 0; i < 4; i++ {
+		w, err := pool.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		held = append(held, w)
+	}
+	if stats := pool.Stats(); stats.Workers != 4 || stats.Idle != 0 {
+		t.Fatalf("Expected 4 busy workers under load, got %+v", stats)
+	}
+	if _, err := pool.TryAcquire(); !errors.Is(err, core.ErrPoolExhausted) {
+		t.Errorf("Expected the pool to stop growing at its maximum, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		event := <-events
+		scale := event.Data.(core.PoolScaleEvent)
+		if scale.Pool != "test" || scale.Delta != 1 || scale.Workers != i+2 {
+			t.Errorf("Unexpected scale up event: %+v", scale)
+		}
+	}
+
+	// Idleness retires workers down to the minimum
+	for _, w := range held {
+		pool.Release(w)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Size() > 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := pool.Stats(); stats.Workers != 1 || stats.Idle != 1 {
+		t.Fatalf("Expected the pool to shrink to its minimum, got %+v", stats)
+	}
+	if started.Load() != 4 || destroyed.Load() != 3 {
+		t.Errorf("Expected 4 started and 3 retired workers, got %d and %d", started.Load(), destroyed.Load())
+	}
+
+	retired := 0
+	for retired < 3 {
+		select {
+		case event := <-events:
+			scale := event.Data.(core.PoolScaleEvent)
+			if scale.Delta >= 0 {
+				t.Fatalf("Unexpected scale event while idle: %+v", scale)
+			}
+			retired -= scale.Delta
+		case <-time.After(time.Second):
+			t.Fatalf("Expected scale down events for 3 workers, got %d", retired)
+		}
+	}
+
+	// The minimum survives further timeouts and the pool grows again
+	time.Sleep(80 * time.Millisecond)
+	if pool.Size() != 1 {
+		t.Errorf("Expected the minimum to be kept, got %d workers", pool.Size())
+	}
+	a, _ := pool.Acquire(ctx)
+	b, _ := pool.Acquire(ctx)
+	if pool.Size() != 2 {
+		t.Errorf("Expected the pool to grow again on demand, got %d workers", pool.Size())
+	}
+	pool.Release(a)
+	pool.Release(b)
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	pool, _ := newTestWorkerPool(t, 4)
+	defer pool.Close()
+
+	ctx := context.Background()
+	a, _ := pool.Acquire(ctx)
+	b, _ := pool.Acquire(ctx)
+	c, _ := pool.Acquire(ctx)
+
+	// Shrinking retires the idle worker at once and busy ones on release
+	if err := pool.Resize(2, 0); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	if stats := pool.Stats(); stats.Workers != 3 || stats.Idle != 0 {
+		t.Errorf("Expected 3 busy workers after shrinking, got %+v", stats)
+	}
+	pool.Release(a)
+	pool.Release(b)
+	pool.Release(c)
+	if stats := pool.Stats(); stats.Workers != 2 || stats.Idle != 2 {
+		t.Errorf("Expected 2 idle workers after release, got %+v", stats)
+	}
+	if !c.destroyed.Load() || a.destroyed.Load() || b.destroyed.Load() {
+		t.Error("Expected only the worker beyond the new size to be destroyed")
+	}
+
+	// Growing starts workers up to the new size and wakes waiting callers
+	held := []*poolWorker{}
+	for i := 0; i < 2; i++ {
+		w, _ := pool.Acquire(ctx)
+		held = append(held, w)
+	}
+	acquired := make(chan *poolWorker, 1)
+	go func() {
+		w, _ := pool.Acquire(ctx)
+		acquired <- w
+	}()
+	if err := pool.Resize(5, 0); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	select {
+	case w := <-acquired:
+		held = append(held, w)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a waiting caller to get a new worker")
+	}
+	for _, w := range held {
+		pool.Release(w)
+	}
+	if stats := pool.Stats(); stats.Workers != 5 || stats.Idle != 5 {
+		t.Errorf("Expected 5 idle workers after growing, got %+v", stats)
+	}
+
+	if err := pool.Resize(2, 3); err == nil {
+		t.Error("Expected error when Min exceeds Max")
+	}
+	pool.Close()
+	if err := pool.Resize(2, 0); !errors.Is(err, core.ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed resizing a closed pool, got %v", err)
+	}
+}
+
+func TestWorkerPoolResizeAffinity(t *testing.T) {
+	pool, _ := newTestWorkerPool(t, 8)
+	defer pool.Close()
+
+	key := ""
+	for i := 0; core.AffinitySlot(key, 8) != 5; i++ {
+		key = fmt.Sprintf("session-%d", i)
+	}
+	ctx := core.WithAffinity(context.Background(), key)
+	held, _ := pool.Acquire(ctx)
+	if held.id != 5 {
+		t.Fatalf("Expected the session on worker 5, got %d", held.id)
+	}
+
+	// A caller waiting on the session's slot when the pool shrinks below
+	// it moves to the key's slot in the smaller pool
+	acquired := make(chan *poolWorker, 1)
+	go func() {
+		w, err := pool.Acquire(ctx)
+		if err != nil {
+			t.Errorf("Acquire failed: %v", err)
+		}
+		acquired <- w
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := pool.Resize(4, 0); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	pool.Release(held)
+
+	select {
+	case w := <-acquired:
+		if w == nil || w.id != core.AffinitySlot(key, 4) {
+			t.Errorf("Expected the session's worker in the smaller pool, got %+v", w)
+		}
+		pool.Release(w)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the waiting session to be served after shrinking")
+	}
+	if !held.destroyed.Load() {
+		t.Error("Expected the worker beyond the new size to be destroyed")
+	}
+}
+
+func TestWorkerPoolReplace(t *testing.T) {
+	pool, _ := newTestWorkerPool(t, 1)
+	defer pool.Close()
+
+	ctx := context.Background()
+	stuck, _ := pool.Acquire(ctx)
+	if err := pool.Replace(stuck); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	// The dropped worker is left to its owner and a new one takes its slot
+	fresh, err := pool.TryAcquire()
+	if err != nil {
+		t.Fatalf("Expected the new worker to be idle, got %v", err)
+	}
+	if fresh == stuck || stuck.destroyed.Load() {
+		t.Error("Expected a new worker without destroying the dropped one")
+	}
+	if stats := pool.Stats(); stats.Workers != 1 {
+		t.Errorf("Expected 1 worker after replacing, got %+v", stats)
+	}
+
+	// Releasing the dropped worker does nothing, and only workers in use
+	// can be replaced
+	pool.Release(stuck)
+	if _, err := pool.TryAcquire(); !errors.Is(err, core.ErrPoolExhausted) {
+		t.Errorf("Expected the dropped worker not to return to the pool, got %v", err)
+	}
+	pool.Release(fresh)
+	if err := pool.Replace(fresh); err == nil {
+		t.Error("Expected error replacing an idle worker")
+	}
+}
+
+func TestWorkerPoolAffinityRefill(t *testing.T) {
+	var failing atomic.Bool
+	pool, err := core.NewWorkerPool(2, func(id int) (*poolWorker, error) {
+		if failing.Load() {
+			return nil, errors.New("factory down")
+		}
+		return &poolWorker{id: id}, nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewWorkerPool failed: %v", err)
+	}
+	defer pool.Close()
+
+	// A failed Replace leaves the session's slot empty, and the session's
+	// next call starts a worker there even though the pool does not scale
+	ctx := core.WithAffinity(context.Background(), "session")
+	stuck, _ := pool.Acquire(ctx)
+	failing.Store(true)
+	if err := pool.Replace(stuck); err == nil {
+		t.Fatal("Expected Replace to fail while the factory is down")
+	}
+	failing.Store(false)
+
+	short, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	w, err := pool.Acquire(short)
+	if err != nil {
+		t.Fatalf("Expected the session's slot to be refilled, got %v", err)
+	}
+	if w == stuck || w.id != stuck.id {
+		t.Errorf("Expected a new worker in slot %d, got %+v", stuck.id, w)
+	}
+	pool.Release(w)
+}
+
+func TestWorkerPoolRetireKeepsSessions(t *testing.T) {
+	pool, err := core.NewWorkerPoolWith(core.WorkerPoolOptions{
+		Max:         2,
+		IdleTimeout: 20 * time.Millisecond,
+	}, func(id int) (*poolWorker, error) {
+		return &poolWorker{id: id}, nil
+	}, func(w *poolWorker) { w.destroyed.Store(true) })
+	if err != nil {
+		t.Fatalf("NewWorkerPoolWith failed: %v", err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	session, _ := pool.Acquire(core.WithAffinity(ctx, "session"))
+	other, _ := pool.Acquire(ctx)
+	pool.Release(session)
+	pool.Release(other)
+
+	// Only the worker without a session is retired
+	deadline := time.Now().Add(time.Second)
+	for !other.destroyed.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !other.destroyed.Load() || session.destroyed.Load() {
+		t.Errorf("Expected only the idle worker without a session to retire")
+	}
+	if pool.Size() != 1 {
+		t.Errorf("Expected the session's worker to be kept, got %d workers", pool.Size())
+	}
+}
+
+func TestWorkerPoolCloseWhileRetiring(t *testing.T) {
+	ctx := context.Background()
+	for round := 0; round < 20; round++ {
+		var closed atomic.Bool
+		var late atomic.Int32
+		pool, err := core.NewWorkerPoolWith(core.WorkerPoolOptions{
+			Max:         4,
+			IdleTimeout: time.Millisecond,
+		}, func(id int) (*poolWorker, error) {
+			return &poolWorker{id: id}, nil
+		}, func(w *poolWorker) {
+			// A slow teardown keeps the reaper busy while Close runs
+			time.Sleep(time.Millisecond)
+			if closed.Load() {
+				late.Add(1)
+			}
+			w.destroyed.Store(true)
+		})
+		if err != nil {
+			t.Fatalf("NewWorkerPoolWith failed: %v", err)
+		}
+
+		var workers []*poolWorker
+		for i := 0; i < 4; i++ {
+			w, err := pool.Acquire(ctx)
+			if err != nil {
+				t.Fatalf("Acquire failed: %v", err)
+			}
+			workers = append(workers, w)
+		}
+		for _, w := range workers {
+			pool.Release(w)
+		}
+
+		time.Sleep(time.Duration(round%4) * time.Millisecond)
+		pool.Close()
+		closed.Store(true)
+
+		for _, w := range workers {
+			if !w.destroyed.Load() {
+				t.Fatalf("Round %d: worker %d not destroyed when Close returned", round, w.id)
+			}
+		}
+		if n := late.Load(); n > 0 {
+			t.Fatalf("Round %d: %d workers destroyed after Close returned", round, n)
+		}
+	}
+}
+
+func TestWorkerPoolOptionsFor(t *testing.T) {
+	bus := core.NewEventBus()
+	config := core.RuntimeConfig{MaxConcurrency: 8, MinWorkers: 2, IdleTimeout: time.Second, Events: bus}
+	opts := core.WorkerPoolOptionsFor("cpp", config, 8)
+	if opts.Max != 8 || opts.Min != 2 || opts.IdleTimeout != time.Second || opts.Name != "cpp" || opts.Events != bus {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	if _, err := core.NewWorkerPoolWith(core.WorkerPoolOptions{Max: 2, Min: 3}, func(int) (*poolWorker, error) { return &poolWorker{}, nil }, nil); err == nil {
+		t.Error("Expected error when Min exceeds Max")
+	}
+}
+
+func TestWorkerPoolFactoryFailure(t *testing.T) {
+	var created []*poolWorker
+	_, err := core.NewWorkerPool(3, func(id int) (*poolWorker, error) {
+		if id == 2 {
+			return nil, fmt.Errorf("boom")
+		}
+		w := &poolWorker{id: id}
+		created = append(created, w)
+		return w, nil
+	}, func(w *poolWorker) { w.destroyed.Store(true) })
+	if err == nil || !strings.Contains(err.Error(), "worker 2") {
+		t.Fatalf("Expected worker 2 to fail, got %v", err)
+	}
+	for _, w := range created {
+		if !w.destroyed.Load() {
+			t.Errorf("Expected worker %d destroyed after a failed start", w.id)
+		}
+	}
+
+	if _, err := core.NewWorkerPool(0, func(int) (*poolWorker, error) { return &poolWorker{}, nil }, nil); err == nil {
+		t.Error("Expected error for an empty pool")
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	bus := core.NewEventBus()
+
+	events, unsubscribe := bus.Subscribe("topic", 1)
+	bus.Publish("topic", "first")
+	bus.Publish("topic", "second") // buffer full, dropped
+	bus.Publish("other", "ignored")
+
+	if event := <-events; event.Data != "first" || event.Topic != "topic" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if bus.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", bus.Dropped())
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("Expected channel to be closed after unsubscribe")
+	}
+	bus.Publish("topic", "after")
+}
+
+// collectEvents reads the integers published on sub until it is closed
+func collectEvents(sub *core.Subscription, delay time.Duration) <-chan []int {
+	out := make(chan []int, 1)
+	go func() {
+		var got []int
+		for event := range sub.C {
+			got = append(got, event.Data.(int))
+			time.Sleep(delay)
+		}
+		out <- got
+	}()
+	return out
+}
+
+func TestEventBusOverflow(t *testing.T) {
+	// Without a reader, each drop policy keeps the expected end of the
+	// stream and counts the rest as dropped
+	for _, tt := range []struct {
+		overflow core.EventOverflow
+		want     []int
+	}{
+		{"", []int{0, 1, 2, 3}},
+		{core.EventDropNewest, []int{0, 1, 2, 3}},
+		{core.EventDropOldest, []int{6, 7, 8, 9}},
+	} {
+		bus := core.NewEventBus()
+		sub := bus.SubscribeWith("topic", core.SubscribeOptions{Buffer: 4, Overflow: tt.overflow})
+		for i := 0; i < 10; i++ {
+			bus.Publish("topic", i)
+		}
+		sub.Cancel()
+
+		var got []int
+		for event := range sub.C {
+			got = append(got, event.Data.(int))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.overflow, tt.want, got)
+		}
+		if sub.Dropped() != 6 || bus.Dropped() != 6 {
+			t.Errorf("%q: expected 6 dropped, got %d (bus %d)", tt.overflow, sub.Dropped(), bus.Dropped())
+		}
+	}
+
+	// A fast producer never waits on a slow consumer under drop policies,
+	// and every event is either delivered or counted
+	for _, overflow := range []core.EventOverflow{core.EventDropNewest, core.EventDropOldest} {
+		bus := core.NewEventBus()
+		sub := bus.SubscribeWith("topic", core.SubscribeOptions{Buffer: 8, Overflow: overflow})
+		received := collectEvents(sub, time.Millisecond)
+
+		published := make(chan struct{})
+		go func() {
+			for i := 0; i < 1000; i++ {
+				bus.Publish("topic", i)
+			}
+			close(published)
+		}()
+		select {
+		case <-published:
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("%q: Publish blocked on a slow subscriber", overflow)
+		}
+		sub.Cancel()
+
+		got := <-received
+		if int64(len(got))+sub.Dropped() != 1000 || sub.Dropped() == 0 {
+			t.Errorf("%q: expected received and dropped to total 1000 with drops, got %d and %d", overflow, len(got), sub.Dropped())
+		}
+		if !sort.IntsAreSorted(got) {
+			t.Errorf("%q: expected events in order, got %v", overflow, got)
+		}
+	}
+
+	// Blocking delivers every event to a slow consumer, in order
+	bus := core.NewEventBus()
+	sub := bus.SubscribeWith("topic", core.SubscribeOptions{Buffer: 2, Overflow: core.EventBlock})
+	received := collectEvents(sub, time.Millisecond)
+	for i := 0; i < 20; i++ {
+		bus.Publish("topic", i)
+	}
+	sub.Cancel()
+	if got := <-received; len(got) != 20 || !sort.IntsAreSorted(got) || sub.Dropped() != 0 {
+		t.Errorf("Expected all 20 events in order under block, got %v with %d dropped", got, sub.Dropped())
+	}
+
+	// BlockTimeout bounds the wait on a subscriber that never reads
+	bus = core.NewEventBus()
+	sub = bus.SubscribeWith("topic", core.SubscribeOptions{Buffer: 1, Overflow: core.EventBlock, BlockTimeout: 10 * time.Millisecond})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		bus.Publish("topic", i)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the block timeout to bound Publish, took %v", elapsed)
+	}
+	if sub.Dropped() != 2 || bus.Dropped() != 2 {
+		t.Errorf("Expected 2 events dropped after timing out, got %d (bus %d)", sub.Dropped(), bus.Dropped())
+	}
+
+	// Canceling wakes a Publish blocked without a timeout
+	sub = bus.SubscribeWith("blocked", core.SubscribeOptions{Buffer: 1, Overflow: core.EventBlock})
+	bus.Publish("blocked", 0)
+	published := make(chan struct{})
+	go func() {
+		bus.Publish("blocked", 1)
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sub.Cancel()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Cancel to release a blocked Publish")
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := core.RetryPolicy{MaxAttempts: 5, Backoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond}
+	for i, d := range want {
+		if got := policy.Delay(i + 1); got != d {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, d)
+		}
+	}
+
+	if !policy.Retriable(core.NewError(core.CodeUnavailable, "busy")) || policy.Retriable(fmt.Errorf("boom")) {
+		t.Error("Expected only default retriable codes to be retried")
+	}
+	if policy.Retriable(core.NewError(core.CodeTimeout, "slow")) {
+		t.Error("Expected timeouts not to be retried by default")
+	}
+	timeouts := core.RetryPolicy{RetryOn: []core.ErrorCode{core.CodeTimeout}}
+	if !timeouts.Retriable(core.NewError(core.CodeTimeout, "slow")) {
+		t.Error("Expected RetryOn to opt in to retrying timeouts")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := policy.Do(ctx, func() error { return core.NewError(core.CodeUnavailable, "busy") })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context error, got %v", err)
+	}
+}
+
+func TestIDGeneratorUniqueAfterDelete(t *testing.T) {
+	var ids core.IDGenerator
+	ids.Observe(2) // seeded entities 1 and 2
+
+	type item struct{ id int64 }
+	items := []item{{1}, {2}}
+	seen := map[int64]bool{1: true, 2: true}
+
+	for round := 0; round < 50; round++ {
+		id := ids.Next()
+		if seen[id] {
+			t.Fatalf("ID %d reused in round %d", id, round)
+		}
+		seen[id] = true
+		items = append(items, item{id})
+
+		// Delete the newest item every other round, which made
+		// len(items)+1 reissue its ID
+		if round%2 == 0 {
+			items = items[:len(items)-1]
+		}
+	}
+
+	ids.Observe(10)
+	if ids.Last() < 52 {
+		t.Errorf("Observe must not move the sequence backwards, last is %d", ids.Last())
+	}
+}
+
+func TestIDGeneratorConcurrent(t *testing.T) {
+	var ids core.IDGenerator
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := ids.Next()
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("Duplicate ID %d", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	bridge := core.NewBridge()
+	if err := ids.Register(bridge, "nextId"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	id, err := bridge.Call(context.Background(), "nextId")
+	if err != nil || id != int64(1001) {
+		t.Errorf("Expected bridge to issue 1001, got %v, %v", id, err)
+	}
+
+	a, err := core.NewUUID()
+	if err != nil {
+		t.Fatalf("NewUUID failed: %v", err)
+	}
+	b, _ := core.NewUUID()
+	if a == b || len(a) != 36 || a[14] != '4' {
+		t.Errorf("Unexpected UUIDs %s, %s", a, b)
+	}
+}
+
+func TestParseFunctionListing(t *testing.T) {
+	functions, err := core.ParseFunctionListing("greet:-2\nadd:2\n\nadd:2\nns:fn:1")
+	if err != nil {
+		t.Fatalf("ParseFunctionListing failed: %v", err)
+	}
+	expected := []core.FunctionInfo{{Name: "add", Arity: 2}, {Name: "greet", Arity: -1}, {Name: "ns:fn", Arity: 1}}
+	if len(functions) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, functions)
+	}
+	for i := range expected {
+		if functions[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], functions[i])
+		}
+	}
+
+	if functions, err := core.ParseFunctionListing(nil); err != nil || len(functions) != 0 {
+		t.Errorf("Expected empty listing, got %v, %v", functions, err)
+	}
+	if _, err := core.ParseFunctionListing("broken"); err == nil {
+		t.Error("Expected error for malformed entry")
+	}
+	if _, err := core.DefinedFunctions(context.Background(), NewMockRuntime("mock", "1.0")); core.ErrorInfoFor(err).Code != core.CodeUnavailable {
+		t.Errorf("Expected %s for runtime without introspection, got %v", core.CodeUnavailable, err)
+	}
+}
+
+func TestParseScopeChanges(t *testing.T) {
+	before := "answer\tint\nname\tstr\n@sys\n@os"
+	after := "answer\tint\nname\tfunction\ntotal\tfloat\nns::Deep\tClass\n@sys\n@os\n@json\n"
+	changes, err := core.ParseScopeChanges(before, after)
+	if err != nil {
+		t.Fatalf("ParseScopeChanges failed: %v", err)
+	}
+	expected := []core.ScopedName{{Name: "name", Type: "function"}, {Name: "ns::Deep", Type: "Class"}, {Name: "total", Type: "float"}}
+	if !reflect.DeepEqual(changes.Defined, expected) {
+		t.Errorf("Expected defined %v, got %v", expected, changes.Defined)
+	}
+	if !reflect.DeepEqual(changes.Imported, []string{"json"}) {
+		t.Errorf("Expected imported [json], got %v", changes.Imported)
+	}
+
+	if changes, err := core.ParseScopeChanges(nil, nil); err != nil || len(changes.Defined) != 0 || len(changes.Imported) != 0 {
+		t.Errorf("Expected no changes, got %v, %v", changes, err)
+	}
+	if _, err := core.ParseScopeChanges("", "broken"); err == nil {
+		t.Error("Expected error for malformed entry")
+	}
+	if _, _, err := core.ExecuteWithChanges(context.Background(), NewMockRuntime("mock", "1.0"), "x = 1"); core.ErrorInfoFor(err).Code != core.CodeUnavailable {
+		t.Errorf("Expected %s for runtime without change tracking, got %v", core.CodeUnavailable, err)
+	}
+}
+
+func TestDescribePrimitives(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		kind  string
+		text  string
+	}{
+		{nil, core.DisplayNull, "null"},
+		{true, core.DisplayBoolean, "true"},
+		{42, core.DisplayNumber, "42"},
+		{int64(-7), core.DisplayNumber, "-7"},
+		{uint8(200), core.DisplayNumber, "200"},
+		{2.5, core.DisplayNumber, "2.5"},
+		{float32(0.1), core.DisplayNumber, "0.1"},
+		{"héllo", core.DisplayString, "héllo"},
+		{[]byte{1, 2, 3}, core.DisplayBytes, "3 bytes"},
+		{(*int)(nil), core.DisplayNull, "null"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), core.DisplayString, "2024-01-02T03:04:05Z"},
+	}
+	for _, c := range cases {
+		d := core.Describe(c.value)
+		if d.Type != c.kind || d.Text != c.text || len(d.Entries) != 0 {
+			t.Errorf("Describe(%#v) = %+v, want %s %q", c.value, d, c.kind, c.text)
+		}
+	}
+	if d := core.Describe("héllo"); d.Length != 5 {
+		t.Errorf("Expected string length in characters, got %d", d.Length)
+	}
+}
+
+func TestDescribeNested(t *testing.T) {
+	type point struct {
+		X    int    `json:"x"`
+		Y    int    `json:"y"`
+		Name string `json:"name,omitempty"`
+	}
+	value := map[string]interface{}{
+		"scores": []float64{1.5, 2},
+		"meta":   map[string]interface{}{"ok": true, "tags": []string{"a"}},
+		"origin": point{X: 1, Y: 2},
+		"empty":  []interface{}{},
+	}
+
+	d := core.Describe(value)
+	if d.Type != core.DisplayMap || d.Length != 4 || d.Text != "map (4 items)" {
+		t.Fatalf("Unexpected top level %+v", d)
+	}
+	var keys []string
+	for _, entry := range d.Entries {
+		keys = append(keys, entry.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"empty", "meta", "origin", "scores"}) {
+		t.Errorf("Expected entries sorted by key, got %v", keys)
+	}
+
+	empty, meta, origin, scores := d.Entries[0].Value, d.Entries[1].Value, d.Entries[2].Value, d.Entries[3].Value
+	if empty.Type != core.DisplayList || empty.Text != "list (0 items)" || len(empty.Entries) != 0 {
+		t.Errorf("Unexpected empty list %+v", empty)
+	}
+	if scores.Type != core.DisplayList || scores.Text != "list (2 items)" ||
+		scores.Entries[0].Key != "0" || scores.Entries[0].Value.Text != "1.5" || scores.Entries[1].Value.Text != "2" {
+		t.Errorf("Unexpected list %+v", scores)
+	}
+	tags := meta.Entries[1].Value
+	if meta.Entries[0].Key != "ok" || meta.Entries[0].Value.Type != core.DisplayBoolean ||
+		tags.Type != core.DisplayList || tags.Text != "list (1 item)" || tags.Entries[0].Value.Text != "a" {
+		t.Errorf("Unexpected nested map %+v", meta)
+	}
+	// Structs are described as they serialize
+	if origin.Type != core.DisplayMap || origin.Length != 2 || origin.Entries[0].Key != "x" || origin.Entries[1].Value.Text != "2" {
+		t.Errorf("Unexpected struct %+v", origin)
+	}
+
+	// The display serializes alongside the value
+	encoded, err := json.Marshal(core.Displayed{Value: value, Display: d})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded struct {
+		Display core.Display `json:"display"`
+	}
+	if err := json.Unmarshal(encoded, &decoded); err != nil || !reflect.DeepEqual(decoded.Display, d) {
+		t.Errorf("Display did not round-trip through JSON: %v", err)
+	}
+}
+
+func TestDescribeTruncates(t *testing.T) {
+	long := make([]int, core.DisplayMaxEntries+50)
+	d := core.Describe(long)
+	if !d.Truncated || len(d.Entries) != core.DisplayMaxEntries || d.Length != len(long) {
+		t.Errorf("Expected %d of %d entries, got %d (truncated %v)", core.DisplayMaxEntries, len(long), len(d.Entries), d.Truncated)
+	}
+
+	var nested interface{} = []interface{}{"bottom"}
+	for i := 0; i < core.DisplayMaxDepth+2; i++ {
+		nested = []interface{}{nested}
+	}
+	d = core.Describe(nested)
+	for depth := 0; depth < core.DisplayMaxDepth; depth++ {
+		if len(d.Entries) != 1 {
+			t.Fatalf("Expected depth %d to be expanded", depth)
+		}
+		d = d.Entries[0].Value
+	}
+	if !d.Truncated || len(d.Entries) != 0 || d.Text != "list (1 item)" {
+		t.Errorf("Expected list past the depth limit to be summarized, got %+v", d)
+	}
+}
+
+func TestWithDisplay(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.Register("stats", core.WithDisplay(func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{"mean": 2.5}, nil
+	}))
+	bridge.Register("fails", core.WithDisplay(func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("boom")
+	}))
+
+	result, err := bridge.Call(context.Background(), "stats")
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	displayed, ok := result.(core.Displayed)
+	if !ok || displayed.Display.Type != core.DisplayMap || displayed.Display.Entries[0].Value.Text != "2.5" {
+		t.Errorf("Expected a displayed map, got %#v", result)
+	}
+	if _, err := bridge.Call(context.Background(), "fails"); err == nil || err.Error() != "boom" {
+		t.Errorf("Expected the handler error unchanged, got %v", err)
+	}
+}
+
+func TestWithDisplayOptionsHoldsBackLargeResults(t *testing.T) {
+	results := core.NewResultCache(0)
+	bridge := core.NewBridge()
+	bridge.Register("fullResult", results.Fetch)
+	bridge.Register("numbers", core.WithDisplayOptions(func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		numbers := make([]int, int(args[0].(float64)))
+		for i := range numbers {
+			numbers[i] = i
+		}
+		return numbers, nil
+	}, core.DisplayOptions{MaxEntries: 100, Results: results}))
+
+	result, err := bridge.Call(context.Background(), "numbers", float64(10000))
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	displayed := result.(core.Displayed)
+	if displayed.Display.Text != "list (10000 items, showing 100)" || len(displayed.Display.Entries) != 100 || !displayed.Display.Truncated {
+		t.Errorf("Expected a truncated display, got %q with %d entries", displayed.Display.Text, len(displayed.Display.Entries))
+	}
+	if displayed.Value != nil || displayed.Ref == "" {
+		t.Fatalf("Expected the full value held back behind a ref, got value %T ref %q", displayed.Value, displayed.Ref)
+	}
+
+	full, err := bridge.Call(context.Background(), "fullResult", displayed.Ref)
+	if err != nil {
+		t.Fatalf("fullResult failed: %v", err)
+	}
+	if numbers, ok := full.([]int); !ok || len(numbers) != 10000 || numbers[9999] != 9999 {
+		t.Errorf("Expected the full list, got %T", full)
+	}
+
+	// Small results are sent whole
+	result, err = bridge.Call(context.Background(), "numbers", float64(3))
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if displayed := result.(core.Displayed); displayed.Ref != "" || !reflect.DeepEqual(displayed.Value, []int{0, 1, 2}) {
+		t.Errorf("Expected a small result sent whole, got %+v", displayed)
+	}
+}
+
+func TestResultCacheEvictsOldest(t *testing.T) {
+	results := core.NewResultCache(2)
+	first := results.Put("a")
+	results.Put("b")
+	last := results.Put("c")
+
+	if _, err := results.Get(first); core.ErrorInfoFor(err).Code != core.CodeNotFound {
+		t.Errorf("Expected the oldest result forgotten, got %v", err)
+	}
+	if value, err := results.Get(last); err != nil || value != "c" {
+		t.Errorf("Expected the latest result kept, got %v, %v", value, err)
+	}
+	if _, err := results.Fetch(context.Background(), 42); core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+		t.Errorf("Expected a non-string ref rejected, got %v", err)
+	}
+}
+
+func TestParseModuleListing(t *testing.T) {
+	modules, err := core.ParseModuleListing("math\njson\n\n  os  \nmath")
+	if err != nil {
+		t.Fatalf("ParseModuleListing failed: %v", err)
+	}
+	if !reflect.DeepEqual(modules, []string{"json", "math", "os"}) {
+		t.Errorf("Expected sorted unique modules, got %v", modules)
+	}
+
+	if modules, err := core.ParseModuleListing(nil); err != nil || len(modules) != 0 {
+		t.Errorf("Expected empty listing, got %v, %v", modules, err)
+	}
+	if _, err := core.ParseModuleListing(42); err == nil {
+		t.Error("Expected error for a listing that is not a string")
+	}
+	if _, err := core.AvailableModules(context.Background(), NewMockRuntime("mock", "1.0")); core.ErrorInfoFor(err).Code != core.CodeUnavailable {
+		t.Errorf("Expected %s for runtime without introspection, got %v", core.CodeUnavailable, err)
+	}
+}
+
+// snapshottingRuntime keeps its state as bytes it can snapshot and restore
+type snapshottingRuntime struct {
+	*MockRuntime
+	state []byte
+}
+
+func (r *snapshottingRuntime) SnapshotState(ctx context.Context) ([]byte, error) {
+	return append([]byte(nil), r.state...), nil
+}
+
+func (r *snapshottingRuntime) RestoreState(ctx context.Context, snapshot []byte) error {
+	r.state = append([]byte(nil), snapshot...)
+	return nil
+}
+
+func TestStateSnapshot(t *testing.T) {
+	ctx := context.Background()
+	source := &snapshottingRuntime{MockRuntime: NewMockRuntime("mock", "1.0"), state: []byte("prepared")}
+	target := &snapshottingRuntime{MockRuntime: NewMockRuntime("mock", "1.0")}
+
+	snapshot, err := core.SnapshotState(ctx, source)
+	if err != nil {
+		t.Fatalf("SnapshotState failed: %v", err)
+	}
+	if err := core.RestoreState(ctx, target, snapshot); err != nil {
+		t.Fatalf("RestoreState failed: %v", err)
+	}
+	if string(target.state) != "prepared" {
+		t.Errorf("Expected the snapshot restored, got %q", target.state)
+	}
+
+	if err := core.RestoreState(ctx, target, nil); core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+		t.Errorf("Expected %s for an empty snapshot, got %v", core.CodeInvalidArgument, err)
+	}
+	if _, err := core.SnapshotState(ctx, NewMockRuntime("mock", "1.0")); core.ErrorInfoFor(err).Code != core.CodeUnavailable {
+		t.Errorf("Expected %s for runtime without snapshots, got %v", core.CodeUnavailable, err)
+	}
+	if err := core.RestoreState(ctx, NewMockRuntime("mock", "1.0"), snapshot); core.ErrorInfoFor(err).Code != core.CodeUnavailable {
+		t.Errorf("Expected %s for runtime without snapshots, got %v", core.CodeUnavailable, err)
+	}
+}
+
+func TestExecutionsInterrupt(t *testing.T) {
+	var executions core.Executions
+
+	ctx, id := core.WithExecutionID(context.Background())
+	if core.ExecutionIDFrom(ctx) != id {
+		t.Fatalf("Expected execution ID %d, got %d", id, core.ExecutionIDFrom(ctx))
+	}
+	if _, other := core.WithExecutionID(context.Background()); other == id {
+		t.Error("Expected unique execution IDs")
+	}
+
+	interrupted := 0
+	done := executions.Track(ctx, func() { interrupted++ })
+	if err := executions.Interrupt(id); err != nil {
+		t.Fatalf("Interrupt failed: %v", err)
+	}
+	if interrupted != 1 {
+		t.Errorf("Expected interrupt to be called once, got %d", interrupted)
+	}
+
+	done()
+	var coreErr *core.Error
+	if err := executions.Interrupt(id); !errors.As(err, &coreErr) || coreErr.Code != core.CodeNotFound {
+		t.Errorf("Expected NOT_FOUND for finished execution, got %v", err)
+	}
+
+	// Executions without an ID are not tracked
+	executions.Track(context.Background(), func() { interrupted++ })()
+	if interrupted != 1 {
+		t.Errorf("Expected untracked execution not to be interrupted")
+	}
+}
+
+func TestAwaitResult(t *testing.T) {
+	results := make(chan int, 1)
+	results <- 42
+	if res, err := core.AwaitResult(context.Background(), results, func() {
+		t.Error("Expected no interrupt for a finished execution")
+	}); err != nil || res != 42 {
+		t.Errorf("Expected 42, got %v, %v", res, err)
+	}
+
+	// A cancelled execution is interrupted and waited for
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stopped := make(chan int, 1)
+	interrupted := false
+	res, err := core.AwaitResult(ctx, stopped, func() {
+		interrupted = true
+		stopped <- 7
+	})
+	if err != context.Canceled || res != 0 {
+		t.Errorf("Expected context.Canceled and no result, got %v, %v", res, err)
+	}
+	if !interrupted {
+		t.Error("Expected the execution to be interrupted")
+	}
+	if len(stopped) != 0 {
+		t.Error("Expected the interrupted result to be drained")
+	}
+}
+
+func TestMemorySecretStore(t *testing.T) {
+	var store core.SecretStore = core.NewMemorySecretStore()
+
+	var coreErr *core.Error
+	if _, err := store.Get("missing"); !errors.As(err, &coreErr) || coreErr.Code != core.CodeNotFound {
+		t.Errorf("Expected NOT_FOUND for missing secret, got %v", err)
+	}
+
+	value := []byte("s3cr3t")
+	if err := store.Set("api", value); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	value[0] = 'X'
+
+	got, err := store.Get("api")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if string(got) != "s3cr3t" {
+		t.Errorf("Expected stored copy s3cr3t, got %q", got)
+	}
+	got[0] = 'X'
+	if again, _ := store.Get("api"); string(again) != "s3cr3t" {
+		t.Errorf("Expected Get to return a copy, got %q", again)
+	}
+
+	if err := store.Set("", value); !errors.As(err, &coreErr) || coreErr.Code != core.CodeInvalidArgument {
+		t.Errorf("Expected INVALID_ARGUMENT for empty key, got %v", err)
+	}
+
+	if err := store.Delete("api"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get("api"); err == nil {
+		t.Error("Expected error after delete")
+	}
+	if err := store.Delete("api"); !errors.As(err, &coreErr) || coreErr.Code != core.CodeNotFound {
+		t.Errorf("Expected NOT_FOUND deleting missing secret, got %v", err)
+	}
+}
+
+// handleStore is an object with state that only makes sense in Go
+type handleStore struct {
+	mu    sync.Mutex
+	items map[string]int
+}
+
+func (s *handleStore) Put(ctx context.Context, key string, value int) error {
+	if ctx == nil {
+		return errors.New("missing context")
+	}
+	if value < 0 {
+		return core.NewError(core.CodeInvalidArgument, "value must not be negative")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = value
+	return nil
+}
+
+func (s *handleStore) Get(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.items[key]
+}
+
+func (s *handleStore) Keys(prefix string, keys ...string) int {
+	return len(keys)
+}
+
+func (s *handleStore) Crash() {
+	panic("store corrupted")
+}
+
+// Watch returns a value JSON cannot encode
+func (s *handleStore) Watch() chan int {
+	return make(chan int)
+}
+
+func TestHandlesInvoke(t *testing.T) {
+	handles := core.NewHandles()
+	store := &handleStore{items: make(map[string]int)}
+	handle := handles.Register(store)
+	ctx := context.Background()
+
+	// Runtime numbers arrive as float64
+	if _, err := handles.Invoke(ctx, handle, "Put", "answer", float64(42)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	got, err := handles.Invoke(ctx, handle, "Get", "answer")
+	if err != nil || got != 42 {
+		t.Errorf("Expected 42, got %v, %v", got, err)
+	}
+	if got, err := handles.Invoke(ctx, handle, "Keys", "k", "a", "b"); err != nil || got != 2 {
+		t.Errorf("Expected variadic call to see 2 keys, got %v, %v", got, err)
+	}
+
+	// Errors returned by the method pass through
+	_, err = handles.Invoke(ctx, handle, "Put", "answer", float64(-1))
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeInvalidArgument {
+		t.Errorf("Expected method error, got %v", err)
+	}
+
+	for name, args := range map[string][]interface{}{
+		"wrong count":    {"answer"},
+		"fractional int": {"answer", 1.5},
+		"wrong type":     {42, float64(1)},
+	} {
+		_, err := handles.Invoke(ctx, handle, "Put", args...)
+		if info := core.ErrorInfoFor(err); info.Code != core.CodeInvalidArgument {
+			t.Errorf("%s: expected %s, got %v", name, core.CodeInvalidArgument, err)
+		}
+	}
+
+	if _, err := handles.Invoke(ctx, handle, "Delete", "answer"); core.ErrorInfoFor(err).Code != core.CodeNotFound {
+		t.Errorf("Expected unknown method to be NOT_FOUND, got %v", err)
+	}
+
+	// A panicking method fails the call instead of the process
+	_, err = handles.Invoke(ctx, handle, "Crash")
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeInternal || !strings.Contains(info.Message, "store corrupted") {
+		t.Errorf("Expected the panic as %s, got %v", core.CodeInternal, err)
+	}
+
+	if err := handles.Release(handle); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if _, err := handles.Invoke(ctx, handle, "Get", "answer"); core.ErrorInfoFor(err).Code != core.CodeNotFound {
+		t.Errorf("Expected released handle to be NOT_FOUND, got %v", err)
+	}
+
+	if core.HandlesFrom(core.WithHandles(ctx, handles)) != handles {
+		t.Error("Expected handles from context")
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	cases := []struct {
+		filename string
+		content  string
+		want     string
+	}{
+		{"script.py", "", "python"},
+		{"app.rb", "", "ruby"},
+		{"init.lua", "", "lua"},
+		{"lib.rs", "", "rust"},
+		{"Main.PY", "", "python"},
+		{"snippet", "def greet(name):\n    return f\"hi {name}\"\n", "python"},
+		{"snippet", "require 'json'\n[1, 2].each do |n|\n  puts n\nend\n", "ruby"},
+		{"snippet", "local total = 0\nif total ~= 1 then\n  total = 1\nend\n", "lua"},
+		{"snippet.txt", "const xs = [1, 2];\nconsole.log(xs.map(x => x * 2));\n", "javascript"},
+		{"snippet", "fn main() {\n    let mut n = 1;\n    println!(\"{}\", n);\n}\n", "rust"},
+		{"tool", "#!/usr/bin/env python3\nprint('hi')\n", "python"},
+		{"tool", "#!/usr/local/bin/node\n", "javascript"},
+		{"index", "<?php\necho 'hi';\n", "php"},
+		{"module", "\x00asm\x01\x00\x00\x00", "wasm"},
+	}
+	for _, c := range cases {
+		got, err := core.DetectLanguage(c.filename, []byte(c.content))
+		if err != nil {
+			t.Errorf("DetectLanguage(%q) failed: %v", c.filename, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("DetectLanguage(%q, %q) = %s, want %s", c.filename, c.content, got, c.want)
+		}
+	}
+
+	// A lone end is valid Ruby and Lua
+	_, err := core.DetectLanguage("snippet", []byte("end\n"))
+	info := core.ErrorInfoFor(err)
+	if err == nil || info.Code != core.CodeInvalidArgument {
+		t.Fatalf("Expected ambiguity error, got %v", err)
+	}
+	if candidates, _ := info.Details["candidates"].([]string); strings.Join(candidates, ",") != "lua,ruby" {
+		t.Errorf("Expected lua and ruby candidates, got %v", info.Details["candidates"])
+	}
+
+	if _, err := core.DetectLanguage("notes.txt", []byte("just some words")); err == nil {
+		t.Error("Expected error for undetectable content")
+	}
+}
+
+func TestSourceKey(t *testing.T) {
+	base := "fn main() {\n    let s = \"a  // b\";\n    println!(\"{}\", s);\n}\n"
+
+	same := map[string]string{
+		"comment":       strings.Replace(base, ";\n", "; // the value\n", 1),
+		"block comment": strings.Replace(base, "let s", "/* the value */ let s", 1),
+		"block lines":   strings.Replace(base, "{\n    let", "{ /* the\n */ let", 1),
+		"indentation":   strings.ReplaceAll(base, "    ", "\t\t"),
+		"trailing":      strings.ReplaceAll(base, ";\n", ";   \n"),
+	}
+	for name, code := range same {
+		if core.SourceKey(code, "rustc") != core.SourceKey(base, "rustc") {
+			t.Errorf("%s: expected cache hit", name)
+		}
+	}
+
+	// Lines are kept so line numbers hold for every program sharing a key
+	different := map[string]string{
+		"comment line":    "// entry point\n" + base,
+		"blank lines":     strings.Replace(base, "{\n", "{\n\n\n", 1),
+		"block line":      strings.Replace(base, "let s", "/* the\n value */ let s", 1),
+		"value":           strings.Replace(base, "let s", "let t = 1; let s", 1),
+		"string spaces":   strings.Replace(base, "a  // b", "a // b", 1),
+		"string comment":  strings.Replace(base, "a  // b", "a  // c", 1),
+		"split token":     strings.Replace(base, "main", "ma in", 1),
+		"char literal":    base + "const C: char = ' ';\n",
+		"raw string":      strings.Replace(base, "\"a  // b\"", "r#\"a // b\"#", 1),
+		"raw string edit": strings.Replace(base, "\"a  // b\"", "r#\"a  // b\"#", 1),
+	}
+	keys := map[string]string{core.SourceKey(base, "rustc"): "base"}
+	for name, code := range different {
+		key := core.SourceKey(code, "rustc")
+		if other, ok := keys[key]; ok {
+			t.Errorf("%s: expected cache miss, shares key with %s", name, other)
+		}
+		keys[key] = name
+	}
+
+	if core.SourceKey(base, "rustc") == core.SourceKey(base, "rustc", "-O") {
+		t.Error("expected flags to change the key")
+	}
+
+	// Line numbers compiled into the program make the exact text matter
+	lined := "fn main() { println!(\"{}\", line!()); }"
+	if core.SourceKey(lined) == core.SourceKey("\n"+lined) {
+		t.Error("expected cache miss for programs using line!")
+	}
+
+	// Lifetimes are not character literals
+	lifetime := "fn f<'a>(s: &'a str) -> &'a str { s } // \"\n"
+	if core.SourceKey(lifetime) != core.SourceKey(strings.Replace(lifetime, "// \"", "// changed", 1)) {
+		t.Error("expected comments after lifetimes to be ignored")
+	}
+}
+
+func TestCompileCache(t *testing.T) {
+	cache, err := core.NewCompileCache("test", 2)
+	if err != nil {
+		t.Fatalf("NewCompileCache failed: %v", err)
+	}
+	defer cache.Close()
+
+	builds := 0
+	build := func(path string) error {
+		builds++
+		return os.WriteFile(path, []byte("binary"), 0755)
+	}
+
+	first, release, err := cache.Acquire("a", build)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	again, releaseAgain, _ := cache.Acquire("a", build)
+	if again != first || builds != 1 {
+		t.Errorf("expected cached artifact, got %s after %d builds", again, builds)
+	}
+
+	// Evicting an artifact in use keeps it until released
+	cache.Acquire("b", build)
+	cache.Acquire("c", build)
+	if _, err := os.Stat(first); err != nil {
+		t.Errorf("artifact removed while in use: %v", err)
+	}
+	release()
+	releaseAgain()
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("expected evicted artifact removed after release, got %v", err)
+	}
+
+	if _, _, err := cache.Acquire("d", func(string) error { return errors.New("compile error") }); err == nil {
+		t.Error("expected build error")
+	}
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 4 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCompileCachePersistent(t *testing.T) {
+	dir := t.TempDir()
+	builds := 0
+	build := func(path string) error {
+		builds++
+		return os.WriteFile(path, []byte("binary"), 0755)
+	}
+
+	first, err := core.OpenCompileCache(dir, "test", 0, 0)
+	if err != nil {
+		t.Fatalf("OpenCompileCache failed: %v", err)
+	}
+	path, release, err := first.Acquire("a", build)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	release()
+
+	// A second cache on the same directory reuses the artifact, whether
+	// opened before or after the first closes
+	second, err := core.OpenCompileCache(dir, "test", 0, 0)
+	if err != nil {
+		t.Fatalf("OpenCompileCache failed: %v", err)
+	}
+	first.Close()
+	again, release, err := second.Acquire("a", build)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	release()
+	if again != path || builds != 1 {
+		t.Errorf("expected persisted artifact, got %s after %d builds", again, builds)
+	}
+	if stats := second.Stats(); stats.Hits != 1 || stats.Bytes != int64(len("binary")) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	second.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("persistent artifact removed on close: %v", err)
+	}
+
+	// The byte limit evicts the least recently used artifacts
+	limited, err := core.OpenCompileCache(dir, "test", 0, int64(2*len("binary")))
+	if err != nil {
+		t.Fatalf("OpenCompileCache failed: %v", err)
+	}
+	for _, key := range []string{"b", "c"} {
+		_, release, err := limited.Acquire(key, build)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected artifact over the byte limit evicted, got %v", err)
+	}
+	if stats := limited.Stats(); stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	limited.Close()
+
+	files, _, err := core.CleanCompileCache(dir)
+	if err != nil || files != 2 {
+		t.Errorf("CleanCompileCache removed %d files: %v", files, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected cache directory removed, got %v", err)
+	}
+}
+
+func TestSummarizeTimings(t *testing.T) {
+	// 1ms..100ms, shuffled
+	timings := make([]time.Duration, 0, 100)
+	for i := 0; i < 100; i++ {
+		timings = append(timings, time.Duration((i*37)%100+1)*time.Millisecond)
+	}
+
+	result := core.SummarizeTimings(timings)
+	checks := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"min", result.Min, time.Millisecond},
+		{"max", result.Max, 100 * time.Millisecond},
+		{"mean", result.Mean, 50500 * time.Microsecond},
+		{"p50", result.P50, 50 * time.Millisecond},
+		{"p90", result.P90, 90 * time.Millisecond},
+		{"p99", result.P99, 99 * time.Millisecond},
+		{"total", result.Total, 5050 * time.Millisecond},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if result.Iterations != 100 || timings[0] != time.Millisecond {
+		t.Errorf("unexpected iterations %d or reordered timings", result.Iterations)
+	}
+
+	if single := core.SummarizeTimings([]time.Duration{7 * time.Millisecond}); single.P50 != 7*time.Millisecond || single.P99 != 7*time.Millisecond {
+		t.Errorf("single sample percentiles = %v/%v, want 7ms", single.P50, single.P99)
+	}
+	if empty := core.SummarizeTimings(nil); empty != (core.TimingResult{}) {
+		t.Errorf("empty timings gave %+v", empty)
+	}
+}
+
+func TestAsResultOrError(t *testing.T) {
+	// Expected failures become values
+	result, err := core.AsResultOrError(map[string]interface{}{
+		core.ErrorValueKey: map[string]interface{}{
+			"code":    "INVALID_EMAIL",
+			"message": "email is invalid",
+			"details": map[string]interface{}{"field": "email"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected an expected failure as a value, got %v", err)
+	}
+	outcome := result.(core.Outcome)
+	if outcome.OK || outcome.Error == nil || outcome.Error.Code != "INVALID_EMAIL" ||
+		outcome.Error.Message != "email is invalid" || outcome.Error.Details["field"] != "email" {
+		t.Errorf("Unexpected outcome %+v", outcome)
+	}
+
+	result, _ = core.AsResultOrError(map[string]interface{}{core.ErrorValueKey: "too short"}, nil)
+	if outcome := result.(core.Outcome); outcome.Error.Code != core.CodeInvalidArgument || outcome.Error.Message != "too short" {
+		t.Errorf("Expected a message marker to default to INVALID_ARGUMENT, got %+v", outcome.Error)
+	}
+
+	// Other results succeed
+	value := map[string]interface{}{"error": "not a marker"}
+	result, err = core.AsResultOrError(value, nil)
+	if outcome := result.(core.Outcome); err != nil || !outcome.OK || !reflect.DeepEqual(outcome.Value, value) {
+		t.Errorf("Expected a successful outcome, got %+v (%v)", result, err)
+	}
+
+	// Execution errors stay errors
+	failure := &core.ScriptError{Runtime: "python", Type: "ZeroDivisionError", Message: "division by zero"}
+	if result, err := core.AsResultOrError(nil, failure); err != failure || result != nil {
+		t.Errorf("Expected the execution error returned as is, got %v (%v)", result, err)
+	}
+	if _, err := core.AsResultOrError(map[string]interface{}{core.ErrorValueKey: 42}, nil); err == nil {
+		t.Error("Expected a malformed marker to fail")
+	}
+}
+
+func TestIsNil(t *testing.T) {
+	var nilMap map[string]int
+	var nilErr error
+	nils := []interface{}{nil, []interface{}(nil), nilMap, (*int)(nil), (func())(nil), nilErr}
+	for _, v := range nils {
+		if !core.IsNil(v) {
+			t.Errorf("IsNil(%#v) = false", v)
+		}
+	}
+	for _, v := range []interface{}{0, "", false, []interface{}{}, map[string]int{}, new(int)} {
+		if core.IsNil(v) {
+			t.Errorf("IsNil(%#v) = true", v)
+		}
+	}
+
+	if core.ParseNilPolicy("undefined") != core.NilUndefined || core.ParseNilPolicy("") != core.NilNull {
+		t.Error("unexpected nil policy parsing")
+	}
+	config := core.RuntimeConfig{Options: map[string]interface{}{"nil_policy": "undefined"}}
+	if core.NilPolicyFor(config) != core.NilUndefined {
+		t.Error("expected nil_policy option to select undefined")
+	}
+}
+
+func TestStackDepthError(t *testing.T) {
+	cause := fmt.Errorf("code execution failed: RecursionError")
+	err := core.StackDepthError(cause)
+
+	if err.Error() != cause.Error() {
+		t.Errorf("Expected the cause's message, got %q", err.Error())
+	}
+	if !errors.Is(err, core.ErrStackDepth) || !errors.Is(err, cause) {
+		t.Errorf("Expected the error to match ErrStackDepth and its cause")
+	}
+	if code := core.ErrorInfoFor(err).Code; code != core.CodeResourceExhausted {
+		t.Errorf("Expected %s, got %s", core.CodeResourceExhausted, code)
+	}
+}
+
+// Test a thread dispatcher runs functions one at a time in submission
+// order, and inline once closed
+func TestThreadDispatcher(t *testing.T) {
+	dispatcher := core.NewThreadDispatcher()
+
+	var running, overlaps int32
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(1)
+		dispatcher.Go(func() {
+			defer wg.Done()
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+			order = append(order, i)
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+
+	failure := errors.New("failed")
+	if err := dispatcher.Do(func() error { return failure }); err != failure {
+		t.Errorf("Expected Do to return the function's error, got %v", err)
+	}
+	wg.Wait()
+	if overlaps != 0 {
+		t.Errorf("Expected functions to run one at a time, %d overlapped", overlaps)
+	}
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("Expected functions in submission order, got %v", order)
+		}
+	}
+
+	dispatcher.Close()
+	ran := false
+	dispatcher.Do(func() error { ran = true; return nil })
+	if !ran {
+		t.Error("Expected Do to run inline on a closed dispatcher")
+	}
+
+	var none *core.ThreadDispatcher
+	if err := none.Do(func() error { return failure }); err != failure {
+		t.Errorf("Expected a nil dispatcher to run Do inline, got %v", err)
+	}
+}
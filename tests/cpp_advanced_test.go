@@ -4,8 +4,11 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -348,6 +351,33 @@ func TestCppPoolReuse(t *testing.T) {
 	}
 }
 
+// TestCppPersistentCompileCache tests that runtimes sharing a cache
+// directory reuse each other's binaries
+func TestCppPersistentCompileCache(t *testing.T) {
+	ctx := context.Background()
+	config := core.RuntimeConfig{
+		Name:           "cpp",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        20 * time.Second,
+		Options:        map[string]interface{}{"compile_cache_dir": t.TempDir()},
+	}
+
+	for i, want := range []uint64{0, 1} {
+		runtime := cpp.NewRuntime()
+		if err := runtime.Initialize(ctx, config); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		if _, err := runtime.Execute(ctx, "cout << 42;"); err != nil {
+			t.Fatalf("Execute %d failed: %v", i, err)
+		}
+		if stats := runtime.CompileCacheStats(); stats.Hits != want {
+			t.Errorf("runtime %d: expected %d cache hits, got %+v", i, want, stats)
+		}
+		runtime.Shutdown(ctx)
+	}
+}
+
 // TestCppShutdown tests proper shutdown
 func TestCppShutdown(t *testing.T) {
 	runtime := cpp.NewRuntime()
@@ -377,3 +407,264 @@ func TestCppShutdown(t *testing.T) {
 		t.Logf("Got expected error after shutdown: %v", err)
 	}
 }
+
+// TestCppInvalidUTF8Output tests that raw output keeps bytes that are not
+// valid UTF-8
+func TestCppInvalidUTF8Output(t *testing.T) {
+	runtime := cpp.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "cpp",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        30 * time.Second,
+		OutputEncoding: core.OutputRaw,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	result, err := runtime.Execute(ctx, "#include <iostream>\nint main() { std::cout << \"caf\\xe9\"; return 0; }")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	raw, ok := result.(core.RawOutput)
+	if !ok {
+		t.Fatalf("Expected core.RawOutput, got %T: %v", result, result)
+	}
+	if string(raw) != "caf\xe9" {
+		t.Errorf("Expected Latin-1 bytes to be preserved, got %x", []byte(raw))
+	}
+	if raw.String() != "caf�" {
+		t.Errorf("Expected String to replace invalid bytes, got %q", raw.String())
+	}
+
+	// Valid output is unaffected
+	result, err = runtime.Execute(ctx, `std::cout << "café";`)
+	if err != nil || result != "café" {
+		t.Errorf("Expected café, got %v (%v)", result, err)
+	}
+}
+
+// TestCppOutputWriters tests that output is also written to the writers
+// set on the runtime config
+func TestCppOutputWriters(t *testing.T) {
+	runtime := cpp.NewRuntime()
+	ctx := context.Background()
+
+	var stdout, stderr bytes.Buffer
+	config := core.RuntimeConfig{
+		Name:           "cpp",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        30 * time.Second,
+		Stdout:         &stdout,
+		Stderr:         &stderr,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	result, err := runtime.Execute(ctx, `std::cout << "hello"; std::cerr << "warn";`)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != "hello" {
+		t.Errorf("Expected stdout to still be returned, got %v", result)
+	}
+	if got := stdout.String(); got != "hello" {
+		t.Errorf("Unexpected stdout %q", got)
+	}
+	if got := stderr.String(); got != "warn" {
+		t.Errorf("Unexpected stderr %q", got)
+	}
+
+	// Writers set with WithOutput receive the execution's output too
+	var ctxOut, ctxErr bytes.Buffer
+	outCtx := core.WithOutput(ctx, &ctxOut, &ctxErr)
+	if _, err := runtime.Execute(outCtx, `std::cout << "live" << std::endl; std::cerr << "oops";`); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := ctxOut.String(); got != "live\n" {
+		t.Errorf("Unexpected context stdout %q", got)
+	}
+	if got := ctxErr.String(); got != "oops" {
+		t.Errorf("Unexpected context stderr %q", got)
+	}
+	if got := stdout.String(); got != "hellolive\n" {
+		t.Errorf("Expected config writers to keep receiving output, got %q", got)
+	}
+}
+
+// TestCppPoolAutoscale tests that the orchestrator's event bus reports
+// the C++ pool growing under load and shrinking when idle
+func TestCppPoolAutoscale(t *testing.T) {
+	config := core.DefaultConfig()
+	config.Languages["cpp"] = &core.RuntimeConfig{
+		Name:           "cpp",
+		Enabled:        true,
+		MaxConcurrency: 3,
+		MinWorkers:     1,
+		IdleTimeout:    100 * time.Millisecond,
+		Timeout:        30 * time.Second,
+	}
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	runtime := cpp.NewRuntime()
+	orch.RegisterRuntime(runtime)
+	ctx := context.Background()
+	if err := orch.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	events, unsubscribe := orch.Events().Subscribe(core.TopicPoolScale, 16)
+	defer unsubscribe()
+
+	if stats := runtime.PoolStats(); stats.Workers != 1 {
+		t.Fatalf("Expected 1 worker before load, got %+v", stats)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := orch.Execute(ctx, "cpp", `std::cout << 1;`); err != nil {
+				t.Errorf("Execute failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	grown := 0
+	deadline := time.After(5 * time.Second)
+	for grown == 0 || runtime.PoolStats().Workers > 1 {
+		select {
+		case event := <-events:
+			scale := event.Data.(core.PoolScaleEvent)
+			if scale.Pool != "cpp" {
+				t.Errorf("Unexpected pool in event: %+v", scale)
+			}
+			if scale.Delta > 0 {
+				grown += scale.Delta
+			}
+		case <-deadline:
+			t.Fatalf("Expected the pool to grow and shrink back, grew %d, now %+v", grown, runtime.PoolStats())
+		}
+	}
+}
+
+// TestCppCrash tests that a program killed by a signal returns a
+// RuntimeCrashedError carrying the signal
+func TestCppCrash(t *testing.T) {
+	runtime := cpp.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "cpp",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        30 * time.Second,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	_, err := runtime.Execute(ctx, "#include <cstdlib>\n#include <iostream>\nint main() { std::cerr << \"giving up\"; std::abort(); }")
+	var crashed *core.RuntimeCrashedError
+	if !errors.As(err, &crashed) {
+		t.Fatalf("Expected RuntimeCrashedError, got %v", err)
+	}
+	if crashed.Runtime != "cpp" || crashed.Signal != syscall.SIGABRT || crashed.OutOfMemory {
+		t.Errorf("Unexpected crash: %+v", crashed)
+	}
+	if crashed.Stderr != "giving up" {
+		t.Errorf("Expected captured stderr, got %q", crashed.Stderr)
+	}
+}
+
+// TestCppExecuteWithInput tests that a program reads piped input to EOF
+func TestCppExecuteWithInput(t *testing.T) {
+	runtime := cpp.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "cpp",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        10 * time.Second,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `#include <iostream>
+#include <string>
+
+int main() {
+    std::string line;
+    int lines = 0;
+    while (std::getline(std::cin, line)) {
+        std::cout << line << "|";
+        lines++;
+    }
+    std::cout << lines;
+    return 0;
+}`
+	result, err := runtime.ExecuteWithInput(ctx, code, bytes.NewBufferString("alpha\nbeta\ngamma\n"))
+	if err != nil {
+		t.Fatalf("ExecuteWithInput failed: %v", err)
+	}
+	if result != "alpha|beta|gamma|3" {
+		t.Errorf("expected each line echoed back, got %v", result)
+	}
+}
+
+// TestCppDescriptorLimit tests the orchestrator's file descriptor cap
+// applies to the programs the runtime runs
+func TestCppDescriptorLimit(t *testing.T) {
+	ctx := context.Background()
+	config := core.DefaultConfig()
+	config.EnableRuntime("cpp", "")
+	config.Languages["cpp"].Timeout = 30 * time.Second
+	config.MaxFileDescriptors = 64
+
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	orch.RegisterRuntime(cpp.NewRuntime())
+	if err := orch.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	if _, err := orch.Execute(ctx, "cpp", "cout << 42;"); err != nil {
+		t.Fatalf("Execute within the cap failed: %v", err)
+	}
+	if stats := orch.Descriptors().Stats(); stats.Peak == 0 || stats.Open != 0 || stats.Limit != 64 {
+		t.Errorf("Expected the program's descriptors counted and released, got %+v", stats)
+	}
+
+	// Hold all but one descriptor, leaving too few to start a program
+	release, err := orch.Descriptors().Acquire("other", 63)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer release()
+	_, err = orch.Execute(ctx, "cpp", "cout << 42;")
+	if !errors.Is(err, core.ErrDescriptorLimit) {
+		t.Fatalf("Expected ErrDescriptorLimit, got %v", err)
+	}
+	if stats := orch.Descriptors().Stats(); stats.Refused != 1 {
+		t.Errorf("Expected one refused program, got %+v", stats)
+	}
+}
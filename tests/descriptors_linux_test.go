@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// Test descriptors held for a running subprocess are counted until it exits
+func TestDescriptorTracking(t *testing.T) {
+	tracker := core.NewDescriptorTracker(0)
+	limits := core.ResourceLimits{Descriptors: tracker}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- core.RunLimited(exec.Command("sh", "-c", "sleep 0.3"), "shell", limits)
+	}()
+
+	var stats core.DescriptorStats
+	deadline := time.Now().Add(time.Second)
+	for stats = tracker.Stats(); stats.Open == 0 && time.Now().Before(deadline); stats = tracker.Stats() {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats.Open == 0 || stats.ByRuntime["shell"] != stats.Open {
+		t.Errorf("Expected descriptors held for the shell while it runs, got %+v", stats)
+	}
+	if stats.Process < stats.Open || stats.ProcessLimit == 0 {
+		t.Errorf("Expected process-wide descriptor counts, got %+v", stats)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("RunLimited failed: %v", err)
+	}
+	stats = tracker.Stats()
+	if stats.Open != 0 || len(stats.ByRuntime) != 0 || stats.Peak == 0 {
+		t.Errorf("Expected descriptors released after exit with a peak recorded, got %+v", stats)
+	}
+}
+
+// Test subprocesses that would exceed the cap are not started
+func TestDescriptorLimit(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "started")
+	tracker := core.NewDescriptorTracker(2)
+
+	err := core.RunLimited(exec.Command("sh", "-c", "touch "+marker), "shell", core.ResourceLimits{Descriptors: tracker})
+	if !errors.Is(err, core.ErrDescriptorLimit) {
+		t.Fatalf("Expected ErrDescriptorLimit, got %v", err)
+	}
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeResourceExhausted || info.Details["runtime"] != "shell" {
+		t.Errorf("Expected %s naming the runtime, got %+v", core.CodeResourceExhausted, info)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("Expected the refused process not to run")
+	}
+	if stats := tracker.Stats(); stats.Refused != 1 || stats.Open != 0 || stats.Limit != 2 {
+		t.Errorf("Expected one refusal and nothing held, got %+v", stats)
+	}
+
+	// Reservations made directly count against the same cap
+	release, err := tracker.Acquire("shell", 2)
+	if err != nil {
+		t.Fatalf("Acquire within the cap failed: %v", err)
+	}
+	if _, err := tracker.Acquire("shell", 1); !errors.Is(err, core.ErrDescriptorLimit) {
+		t.Errorf("Expected Acquire past the cap to fail, got %v", err)
+	}
+	release()
+	release()
+	if stats := tracker.Stats(); stats.Open != 0 {
+		t.Errorf("Expected release to be idempotent, got %+v", stats)
+	}
+
+	roomy := core.NewDescriptorTracker(64)
+	if err := core.RunLimited(exec.Command("sh", "-c", "touch "+marker), "shell", core.ResourceLimits{Descriptors: roomy}); err != nil {
+		t.Fatalf("Expected process within the cap to run: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("Expected the process to run: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// formatOrSkip formats code, skipping the test when the formatter is absent
+func formatOrSkip(t *testing.T, language, code string) string {
+	t.Helper()
+	formatted, err := core.FormatCode(context.Background(), language, code)
+	var typed *core.Error
+	if errors.As(err, &typed) && typed.Code == core.CodeUnavailable {
+		t.Skipf("formatter for %s unavailable: %v", language, err)
+	}
+	if err != nil {
+		t.Fatalf("format %s failed: %v", language, err)
+	}
+	return formatted
+}
+
+func TestFormatCode(t *testing.T) {
+	tests := []struct {
+		language  string
+		messy     string
+		formatted string
+	}{
+		{"go", "package main\nfunc main(){x:=1\n_=x}\n", "package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n"},
+		{"python", "def add( a,b ):\n  return a+b\n", "def add(a, b):\n    return a + b\n"},
+		{"rust", "fn add(a:i32,b:i32)->i32{a+b}\n", "fn add(a: i32, b: i32) -> i32 {\n    a + b\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			if got := formatOrSkip(t, tt.language, tt.messy); got != tt.formatted {
+				t.Errorf("messy code formatted as %q, want %q", got, tt.formatted)
+			}
+			if got := formatOrSkip(t, tt.language, tt.formatted); got != tt.formatted {
+				t.Errorf("well-formatted code changed to %q", got)
+			}
+		})
+	}
+}
+
+func TestFormatCodeErrors(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := core.FormatCode(ctx, "cobol", "+++")
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeUnavailable || info.Message != "formatter unavailable: no formatter for cobol" {
+		t.Errorf("expected formatter unavailable, got %v", err)
+	}
+
+	_, err = core.FormatCode(ctx, "go", "package main\nfunc {")
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeInvalidArgument {
+		t.Errorf("expected invalid argument for bad code, got %v", err)
+	}
+}
+
+// formattingRuntime formats code itself
+type formattingRuntime struct {
+	core.Runtime
+}
+
+func (formattingRuntime) Format(ctx context.Context, code string) (string, error) {
+	return "formatted:" + code, nil
+}
+
+func TestFormatPrefersRuntimeFormatter(t *testing.T) {
+	got, err := core.Format(context.Background(), formattingRuntime{}, "x")
+	if err != nil || got != "formatted:x" {
+		t.Errorf("expected runtime formatter to be used, got %q, %v", got, err)
+	}
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -288,3 +289,150 @@ func TestJavaScriptShutdownAndReuse(t *testing.T) {
 		t.Logf("Got expected error after shutdown: %v", err)
 	}
 }
+
+// TestJavaScriptDeterministic tests that deterministic mode makes random
+// numbers and the clock repeat across executions
+func TestJavaScriptDeterministic(t *testing.T) {
+	runtime := javascript.NewRuntime()
+	ctx := context.Background()
+
+	frozen := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	config := core.RuntimeConfig{
+		Name:              "javascript",
+		Enabled:           true,
+		MaxConcurrency:    2,
+		Timeout:           5 * time.Second,
+		Deterministic:     true,
+		DeterministicSeed: 7,
+		DeterministicTime: frozen,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `JSON.stringify([Math.random(), Math.random(), Date.now(), new Date().toISOString(), Object.keys({b: 1, a: 2})])`
+	first, err := runtime.Execute(ctx, code)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		again, err := runtime.Execute(ctx, code)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if again != first {
+			t.Errorf("Expected identical output across runs, got %v then %v", first, again)
+		}
+	}
+
+	now, err := runtime.Execute(ctx, "Date.now()")
+	if err != nil || now != float64(frozen.UnixMilli()) {
+		t.Errorf("Expected Date.now() to be %d, got %v (%v)", frozen.UnixMilli(), now, err)
+	}
+
+	// Explicit dates and Date methods still work
+	result, err := runtime.Execute(ctx, `new Date(0).getTime() === 0 && new Date() instanceof Date && typeof Date() === 'string'`)
+	if err != nil || result != true {
+		t.Errorf("Expected Date to behave normally otherwise, got %v (%v)", result, err)
+	}
+}
+
+// TestJavaScriptPoolAutoscale tests that the context pool grows under load
+// and shrinks back to MinWorkers when idle, as the other pools do
+func TestJavaScriptPoolAutoscale(t *testing.T) {
+	runtime := javascript.NewRuntime()
+	ctx := context.Background()
+
+	bus := core.NewEventBus()
+	events, unsubscribe := bus.Subscribe(core.TopicPoolScale, 16)
+	defer unsubscribe()
+
+	config := core.RuntimeConfig{
+		Name:           "javascript",
+		Enabled:        true,
+		MaxConcurrency: 3,
+		MinWorkers:     1,
+		IdleTimeout:    50 * time.Millisecond,
+		Events:         bus,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	if stats := runtime.PoolStats(); stats.Workers != 1 {
+		t.Fatalf("Expected 1 context before load, got %+v", stats)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := runtime.Execute(ctx, `const start = Date.now(); while (Date.now() - start < 50) {}`); err != nil {
+				t.Errorf("Execute failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	grown := 0
+	deadline := time.After(5 * time.Second)
+	for grown == 0 || runtime.PoolStats().Workers > 1 {
+		select {
+		case event := <-events:
+			scale := event.Data.(core.PoolScaleEvent)
+			if scale.Pool != "javascript" {
+				t.Errorf("Unexpected pool in event: %+v", scale)
+			}
+			if scale.Delta > 0 {
+				grown += scale.Delta
+			}
+		case <-deadline:
+			t.Fatalf("Expected the pool to grow and shrink back, grew %d, now %+v", grown, runtime.PoolStats())
+		}
+	}
+}
+
+// TestJavaScriptReconfigure tests resizing the context pool while running
+func TestJavaScriptReconfigure(t *testing.T) {
+	runtime := javascript.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "javascript",
+		Enabled:        true,
+		MaxConcurrency: 2,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	config.MaxConcurrency = 4
+	if err := runtime.Reconfigure(ctx, config); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if stats := runtime.PoolStats(); stats.Workers != 4 {
+		t.Errorf("Expected 4 contexts after growing, got %+v", stats)
+	}
+
+	config.MaxConcurrency = 1
+	if err := runtime.Reconfigure(ctx, config); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if stats := runtime.PoolStats(); stats.Workers != 1 {
+		t.Errorf("Expected 1 context after shrinking, got %+v", stats)
+	}
+
+	changed := config
+	changed.IdleTimeout = time.Minute
+	if err := runtime.Reconfigure(ctx, changed); err == nil || core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+		t.Errorf("Expected an idle timeout change to fail with %s, got %v", core.CodeInvalidArgument, err)
+	}
+
+	if _, err := runtime.Execute(ctx, "1 + 1"); err != nil {
+		t.Errorf("Execute after resizing failed: %v", err)
+	}
+}
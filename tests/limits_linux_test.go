@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// Test a memory-hungry script is stopped at the memory limit
+func TestResourceLimitMemory(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+
+	limits := core.ResourceLimits{Memory: 256 * 1024 * 1024}
+	if err := core.RunLimited(exec.Command(python, "-c", "x = bytearray(16 * 1024 * 1024)"), "python", limits); err != nil {
+		t.Fatalf("Expected script within limit to succeed: %v", err)
+	}
+
+	err = core.RunLimited(exec.Command(python, "-c", "x = bytearray(1024 * 1024 * 1024)"), "python", limits)
+	var exceeded *core.ResourceExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("Expected ResourceExceededError, got %v", err)
+	}
+	if exceeded.Resource != "memory" || exceeded.Runtime != "python" {
+		t.Errorf("Unexpected error fields: %+v", exceeded)
+	}
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeResourceExhausted {
+		t.Errorf("Expected %s, got %s", core.CodeResourceExhausted, info.Code)
+	}
+}
+
+// Test a busy loop is stopped at the CPU limit
+func TestResourceLimitCPU(t *testing.T) {
+	start := time.Now()
+	err := core.RunLimited(exec.Command("sh", "-c", "while :; do :; done"), "shell", core.ResourceLimits{CPU: time.Second})
+
+	var exceeded *core.ResourceExceededError
+	if !errors.As(err, &exceeded) || exceeded.Resource != "cpu" {
+		t.Fatalf("Expected cpu ResourceExceededError, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected process to be stopped near the limit, ran %v", elapsed)
+	}
+}
+
+// Test a process killed by a signal is reported as a crash carrying the
+// signal and its output
+func TestRunLimitedSignalCrash(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "echo partial; echo dying >&2; kill -TERM $$")
+	err := core.RunLimited(cmd, "shell", core.ResourceLimits{})
+
+	var crashed *core.RuntimeCrashedError
+	if !errors.As(err, &crashed) {
+		t.Fatalf("Expected RuntimeCrashedError, got %v", err)
+	}
+	if crashed.Signal != syscall.SIGTERM || crashed.ExitCode != -1 {
+		t.Errorf("Expected SIGTERM with exit code -1, got %+v", crashed)
+	}
+	if crashed.OutOfMemory {
+		t.Error("Expected SIGTERM not to be reported as out of memory")
+	}
+	if strings.TrimSpace(crashed.Stdout) != "partial" || strings.TrimSpace(crashed.Stderr) != "dying" {
+		t.Errorf("Expected captured output, got stdout %q stderr %q", crashed.Stdout, crashed.Stderr)
+	}
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeInternal || info.Details["signal"] != "terminated" {
+		t.Errorf("Unexpected error info: %+v", info)
+	}
+}
+
+// Test a crash under a memory limit without any sign of running out of
+// memory keeps its signal rather than being reported as a memory error
+func TestRunLimitedCrashUnderMemoryLimit(t *testing.T) {
+	limits := core.ResourceLimits{Memory: 256 * 1024 * 1024}
+	err := core.RunLimited(exec.Command("sh", "-c", "kill -SEGV $$"), "shell", limits)
+
+	var exceeded *core.ResourceExceededError
+	if errors.As(err, &exceeded) {
+		t.Fatalf("Expected a crash, got %v", err)
+	}
+	var crashed *core.RuntimeCrashedError
+	if !errors.As(err, &crashed) || crashed.Signal != syscall.SIGSEGV {
+		t.Fatalf("Expected SIGSEGV RuntimeCrashedError, got %v", err)
+	}
+}
+
+// Test a SIGKILL without an OOM kill, such as a manual kill, is a plain
+// crash rather than out of memory
+func TestRunLimitedKilledIsNotOutOfMemory(t *testing.T) {
+	err := core.RunLimited(exec.Command("sh", "-c", "kill -KILL $$"), "shell", core.ResourceLimits{})
+
+	var crashed *core.RuntimeCrashedError
+	if !errors.As(err, &crashed) {
+		t.Fatalf("Expected RuntimeCrashedError, got %v", err)
+	}
+	if crashed.Signal != syscall.SIGKILL || crashed.OutOfMemory {
+		t.Errorf("Expected SIGKILL not out of memory, got %+v", crashed)
+	}
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeInternal {
+		t.Errorf("Expected %s, got %s", core.CodeInternal, info.Code)
+	}
+}
+
+// Test a process reporting an allocation failure is out of memory
+func TestRunLimitedReportsOutOfMemory(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "echo 'MemoryError' >&2; kill -ABRT $$")
+	err := core.RunLimited(cmd, "shell", core.ResourceLimits{})
+
+	var crashed *core.RuntimeCrashedError
+	if !errors.As(err, &crashed) || !crashed.OutOfMemory {
+		t.Fatalf("Expected out of memory RuntimeCrashedError, got %v", err)
+	}
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeResourceExhausted {
+		t.Errorf("Expected %s, got %s", core.CodeResourceExhausted, info.Code)
+	}
+}
+
+// Test an ordinary failing exit is not a crash
+func TestRunLimitedExitIsNotCrash(t *testing.T) {
+	err := core.RunLimited(exec.Command("sh", "-c", "exit 3"), "shell", core.ResourceLimits{})
+
+	var crashed *core.RuntimeCrashedError
+	if err == nil || errors.As(err, &crashed) {
+		t.Fatalf("Expected a plain exit error, got %v", err)
+	}
+}
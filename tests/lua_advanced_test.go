@@ -5,6 +5,12 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -430,3 +436,544 @@ func TestLuaName(t *testing.T) {
 		t.Errorf("Expected name 'lua', got '%s'", name)
 	}
 }
+
+// TestLuaAvailableModules tests that the standard libraries are listed
+func TestLuaAvailableModules(t *testing.T) {
+	runtime := lua.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "lua",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	modules, err := runtime.AvailableModules(ctx)
+	if err != nil {
+		t.Fatalf("AvailableModules failed: %v", err)
+	}
+
+	listed := strings.Join(modules, ",")
+	for _, name := range []string{"math", "string", "table"} {
+		found := false
+		for _, module := range modules {
+			found = found || module == name
+		}
+		if !found {
+			t.Errorf("Expected %s among available modules, got %s", name, listed)
+		}
+	}
+}
+
+// TestLuaDefinedFunctions tests listing user-defined global functions
+func TestLuaDefinedFunctions(t *testing.T) {
+	runtime := lua.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "lua",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `
+function add(a, b) return a + b end
+function sum(...) return select("#", ...) end
+local function hidden() end
+`
+	if _, err := runtime.Execute(ctx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	functions, err := runtime.DefinedFunctions(ctx)
+	if err != nil {
+		t.Fatalf("DefinedFunctions failed: %v", err)
+	}
+
+	expected := []core.FunctionInfo{{Name: "add", Arity: 2}, {Name: "sum", Arity: -1}}
+	if len(functions) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, functions)
+	}
+	for i := range expected {
+		if functions[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], functions[i])
+		}
+	}
+}
+
+// TestLuaInterrupt tests that Interrupt stops a running busy loop
+func TestLuaInterrupt(t *testing.T) {
+	runtime := lua.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "lua",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	execCtx, id := core.WithExecutionID(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := runtime.Execute(execCtx, `while true do end`)
+		done <- err
+	}()
+
+	// The execution is registered once it has a worker
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.Interrupt(id) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("execution never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-done:
+		if err != core.ErrInterrupted {
+			t.Errorf("Expected ErrInterrupted, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Execution was not interrupted")
+	}
+
+	if err := runtime.Interrupt(id); err == nil {
+		t.Error("Expected error interrupting a finished execution")
+	}
+
+	// A timed out execution is stopped, freeing the only worker
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := runtime.Execute(timeoutCtx, `while true do end`); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	resultCtx, cancelResult := context.WithTimeout(ctx, 2*time.Second)
+	defer cancelResult()
+	result, err := runtime.Execute(resultCtx, `return 42`)
+	if err != nil {
+		t.Fatalf("Execute after timeout failed: %v", err)
+	}
+	if result != float64(42) {
+		t.Errorf("Expected 42, got %v (%T)", result, result)
+	}
+}
+
+// TestLuaExecuteWithChanges tests that the globals and modules code
+// defines are reported with its result
+func TestLuaExecuteWithChanges(t *testing.T) {
+	runtime := lua.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "lua",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `
+answer = 42
+function greet(name) return "Hello " .. name end
+local hidden = true
+package.preload.tracked = function() return {} end
+require("tracked")
+return answer
+`
+	value, changes, err := runtime.ExecuteWithChanges(ctx, code)
+	if err != nil {
+		t.Fatalf("ExecuteWithChanges failed: %v", err)
+	}
+	if value != float64(42) {
+		t.Errorf("Expected 42, got %v", value)
+	}
+
+	expected := []core.ScopedName{{Name: "answer", Type: "number"}, {Name: "greet", Type: "function"}}
+	if !reflect.DeepEqual(changes.Defined, expected) {
+		t.Errorf("Expected defined %v, got %v", expected, changes.Defined)
+	}
+	if !reflect.DeepEqual(changes.Imported, []string{"tracked"}) {
+		t.Errorf("Expected imported [tracked], got %v", changes.Imported)
+	}
+}
+
+func TestLuaCallMulti(t *testing.T) {
+	runtime := lua.NewRuntime()
+	ctx := context.Background()
+
+	// One worker so the functions are defined where they are called
+	config := core.RuntimeConfig{Name: "lua", Enabled: true, MaxConcurrency: 1}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := "function divmod(a, b) return math.floor(a / b), a % b end\nfunction nothing() end"
+	if _, err := runtime.Execute(ctx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	values, err := runtime.CallMulti(ctx, "divmod", 17, 5)
+	if err != nil {
+		t.Fatalf("CallMulti failed: %v", err)
+	}
+	if len(values) != 2 || values[0] != float64(3) || values[1] != float64(2) {
+		t.Errorf("Expected both results, got %#v", values)
+	}
+
+	// Call keeps only the first result
+	if result, err := runtime.Call(ctx, "divmod", 17, 5); err != nil || result != float64(3) {
+		t.Errorf("Expected 3 from Call, got %v (%v)", result, err)
+	}
+
+	values, err = runtime.CallMulti(ctx, "nothing")
+	if err != nil || len(values) != 0 {
+		t.Errorf("Expected no results, got %#v (%v)", values, err)
+	}
+}
+
+func TestLuaExecuteMapped(t *testing.T) {
+	config := core.DefaultConfig()
+	config.Languages["lua"] = &core.RuntimeConfig{Name: "lua", Enabled: true, MaxConcurrency: 1}
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	orch.RegisterRuntime(lua.NewRuntime())
+	ctx := context.Background()
+	if err := orch.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	inputs := map[string]interface{}{"x": 6, "name": "world"}
+	code := "product = x * 7\ngreeting = 'hello ' .. name"
+	outputs, err := orch.ExecuteMapped(ctx, "lua", code, inputs, []string{"product", "greeting", "unset"})
+	if err != nil {
+		t.Fatalf("ExecuteMapped failed: %v", err)
+	}
+	if outputs["product"] != float64(42) || outputs["greeting"] != "hello world" {
+		t.Errorf("Unexpected outputs: %#v", outputs)
+	}
+	if value, ok := outputs["unset"]; !ok || value != nil {
+		t.Errorf("Expected an unset output to be nil, got %#v", value)
+	}
+}
+
+// TestLuaDeterministic tests that deterministic mode makes random numbers
+// and the clock repeat across executions
+func TestLuaDeterministic(t *testing.T) {
+	config := core.DefaultConfig()
+	config.Languages["lua"] = &core.RuntimeConfig{
+		Name:              "lua",
+		Enabled:           true,
+		MaxConcurrency:    1,
+		Deterministic:     true,
+		DeterministicTime: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	orch.RegisterRuntime(lua.NewRuntime())
+	ctx := context.Background()
+	if err := orch.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	code := `return math.random(1, 1000000) .. " " .. math.random() .. " " .. os.time() .. " " .. os.date("!%Y-%m-%d %H:%M")`
+	first, err := orch.Execute(ctx, "lua", code)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		again, err := orch.Execute(ctx, "lua", code)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if again != first {
+			t.Errorf("Expected identical output across runs, got %v then %v", first, again)
+		}
+	}
+	if !strings.HasSuffix(first.(string), " 1709294400 2024-03-01 12:00") {
+		t.Errorf("Expected the clock to be frozen, got %v", first)
+	}
+}
+
+// TestLuaInitCode tests init code runs once on each worker before its
+// executions
+func TestLuaInitCode(t *testing.T) {
+	runtime := lua.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "lua",
+		Enabled:        true,
+		MaxConcurrency: 3,
+		InitCode: `
+init_runs = (init_runs or 0) + 1
+function square(x) return x * x end
+`,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	for i := 0; i < 6; i++ {
+		result, err := runtime.Execute(ctx, "return init_runs * 100 + square(3)")
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result != float64(109) {
+			t.Errorf("Expected init code to run once with square defined, got %v", result)
+		}
+	}
+
+	failing := lua.NewRuntime()
+	config.InitCode = "error('bad setup')"
+	err := failing.Initialize(ctx, config)
+	defer failing.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "bad setup") {
+		t.Errorf("Expected the init code error, got %v", err)
+	}
+}
+
+// Test state snapshotted from one runtime restores into every worker of
+// another with the same functions and data
+func TestLuaStateSnapshot(t *testing.T) {
+	ctx := context.Background()
+	source := lua.NewRuntime()
+	if err := source.Initialize(ctx, core.RuntimeConfig{Name: "lua", Enabled: true, MaxConcurrency: 1}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer source.Shutdown(ctx)
+
+	_, err := source.Execute(ctx, `
+rates = {standard = 0.2, reduced = 0.05, labels = {"a", "b\0c"}}
+function tax(amount, kind)
+	return amount * rates[kind or "standard"]
+end
+function label(i) return rates.labels[i] end
+`)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	snapshot, err := core.SnapshotState(ctx, source)
+	if err != nil {
+		t.Fatalf("SnapshotState failed: %v", err)
+	}
+
+	target := lua.NewRuntime()
+	if err := target.Initialize(ctx, core.RuntimeConfig{Name: "lua", Enabled: true, MaxConcurrency: 3}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer target.Shutdown(ctx)
+	if err := core.RestoreState(ctx, target, snapshot); err != nil {
+		t.Fatalf("RestoreState failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := target.Call(ctx, "tax", 100, "reduced")
+			if err != nil {
+				t.Errorf("Call failed: %v", err)
+			} else if result != float64(5) {
+				t.Errorf("Expected 5, got %v", result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	result, err := target.Execute(ctx, "return #label(2)")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != float64(3) {
+		t.Errorf("Expected binary strings to survive, got length %v", result)
+	}
+
+	if err := target.RestoreState(ctx, []byte("x = 1")); core.ErrorInfoFor(err).Code != core.CodeInvalidArgument {
+		t.Errorf("Expected INVALID_ARGUMENT for a non-snapshot, got %v", err)
+	}
+}
+
+// Test InitState prepares workers started later, and closures cannot be
+// snapshotted
+func TestLuaInitState(t *testing.T) {
+	ctx := context.Background()
+	source := lua.NewRuntime()
+	if err := source.Initialize(ctx, core.RuntimeConfig{Name: "lua", Enabled: true, MaxConcurrency: 1}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer source.Shutdown(ctx)
+
+	if _, err := source.Execute(ctx, `greeting = "hello" function greet(name) return greeting .. ", " .. name end`); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	snapshot, err := source.SnapshotState(ctx)
+	if err != nil {
+		t.Fatalf("SnapshotState failed: %v", err)
+	}
+
+	target := lua.NewRuntime()
+	config := core.RuntimeConfig{
+		Name:           "lua",
+		Enabled:        true,
+		MaxConcurrency: 2,
+		IdleTimeout:    time.Minute,
+		InitState:      snapshot,
+	}
+	if err := target.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer target.Shutdown(ctx)
+
+	result, err := target.Call(ctx, "greet", "Ada")
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if result != "hello, Ada" {
+		t.Errorf("Expected the snapshot's greeting, got %v", result)
+	}
+
+	if _, err := source.Execute(ctx, `local count = 0 function counter() count = count + 1 return count end`); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, err := source.SnapshotState(ctx); err == nil || !strings.Contains(err.Error(), "closures") {
+		t.Errorf("Expected closures to fail the snapshot, got %v", err)
+	}
+}
+
+// Test MaxStackDepth stops runaway recursion with an error code can catch
+// and that surfaces as ErrStackDepth
+func TestLuaMaxStackDepth(t *testing.T) {
+	ctx := context.Background()
+	runtime := lua.NewRuntime()
+	config := core.RuntimeConfig{Name: "lua", Enabled: true, MaxConcurrency: 1, MaxStackDepth: 200}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	if _, err := runtime.Execute(ctx, `function depth(n) if n == 0 then return 0 end return depth(n - 1) + 1 end`); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	result, err := runtime.Call(ctx, "depth", 100)
+	if err != nil {
+		t.Fatalf("Recursion within the limit failed: %v", err)
+	}
+	if result != float64(100) {
+		t.Errorf("Expected 100, got %v (%T)", result, result)
+	}
+
+	_, err = runtime.Call(ctx, "depth", 10000)
+	if !errors.Is(err, core.ErrStackDepth) {
+		t.Fatalf("Expected ErrStackDepth, got %v", err)
+	}
+	if code := core.ErrorInfoFor(err).Code; code != core.CodeResourceExhausted {
+		t.Errorf("Expected %s, got %s", core.CodeResourceExhausted, code)
+	}
+	if _, err := runtime.Execute(ctx, "return depth(10000)"); !errors.Is(err, core.ErrStackDepth) {
+		t.Errorf("Expected ErrStackDepth from Execute, got %v", err)
+	}
+
+	caught, err := runtime.Execute(ctx, `local ok, msg = pcall(depth, 10000) return not ok and msg:find("stack depth") ~= nil`)
+	if err != nil {
+		t.Fatalf("Caught error escaped: %v", err)
+	}
+	if caught != true {
+		t.Errorf("Expected code to catch the depth error, got %v", caught)
+	}
+}
+
+// Test ShutdownCode runs once on every worker before it is torn down,
+// including a worker busy when Shutdown starts, and can use what InitCode
+// opened
+func TestLuaShutdownCode(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "shutdown.log")
+	runtime := lua.NewRuntime()
+	config := core.RuntimeConfig{
+		Name:           "lua",
+		Enabled:        true,
+		MaxConcurrency: 3,
+		InitCode:       fmt.Sprintf("log = io.open(%q, 'a')", path),
+		ShutdownCode:   `log:write("closed\n") log:close()`,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	started := make(chan struct{})
+	finished := make(chan error, 1)
+	go func() {
+		close(started)
+		_, err := runtime.Execute(ctx, `local deadline = os.clock() + 0.3 while os.clock() < deadline do end`)
+		finished <- err
+	}()
+	<-started
+	time.Sleep(50 * time.Millisecond)
+
+	if err := runtime.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := <-finished; err != nil {
+		t.Fatalf("Busy execution failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if closed := strings.Count(string(data), "closed\n"); closed != 3 {
+		t.Errorf("Expected the shutdown code to run once per worker, got %d runs", closed)
+	}
+}
+
+// Test shutdown code still running when the Shutdown context ends is
+// interrupted
+func TestLuaShutdownCodeGrace(t *testing.T) {
+	runtime := lua.NewRuntime()
+	config := core.RuntimeConfig{Name: "lua", Enabled: true, MaxConcurrency: 1, ShutdownCode: "while true do end"}
+	if err := runtime.Initialize(context.Background(), config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := runtime.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Shutdown took %v; the shutdown code should stop with its context", elapsed)
+	}
+}
@@ -1,10 +1,24 @@
 package tests
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/griffincancode/polyglot.js/core"
 	"github.com/griffincancode/polyglot.js/marketplace"
 )
 
@@ -234,7 +248,7 @@ func TestMarketplaceTemplate(t *testing.T) {
 	}
 
 	// Test initializing from template
-	err = client.InitFromTemplate(ctx, "test-template", "/tmp/test-project", tmpl.Variables)
+	err = client.InitFromTemplate(ctx, "test-template", t.TempDir(), tmpl.Variables)
 	if err != nil {
 		t.Fatalf("failed to init from template: %v", err)
 	}
@@ -249,3 +263,360 @@ func TestMarketplaceTemplate(t *testing.T) {
 		t.Errorf("expected name %s, got %s", tmpl.Name, retrieved.Name)
 	}
 }
+
+func TestMarketplaceBinaryTemplateFiles(t *testing.T) {
+	ctx := context.Background()
+	registry := marketplace.NewMemoryRegistry()
+	client := marketplace.NewClient(registry, marketplace.NewMemoryCache(), marketplace.NewValidator())
+
+	icon := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0xff, 0xfe, '{', '{', 0x80}
+	tmpl := &marketplace.Template{
+		ID:     "binary-template",
+		Name:   "Binary Template",
+		Author: "Test Author",
+		Files: []marketplace.TemplateFile{
+			{Path: "README.md", Content: "# {{.project_name}}\n", Templated: true},
+			{Path: "assets/icon.png", Data: icon, ContentType: "image/png"},
+			{Path: "run.sh", Content: "#!/bin/sh\n", Executable: true},
+		},
+		Variables: map[string]string{"project_name": "default"},
+	}
+
+	// Binary content survives the JSON encoding registries use
+	encoded, err := json.Marshal(tmpl)
+	if err != nil {
+		t.Fatalf("failed to encode template: %v", err)
+	}
+	var decoded marketplace.Template
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("failed to decode template: %v", err)
+	}
+	if !bytes.Equal(decoded.Files[1].Data, icon) || decoded.Files[1].ContentType != "image/png" {
+		t.Errorf("binary file changed in JSON: %+v", decoded.Files[1])
+	}
+
+	if err := registry.PublishTemplate(ctx, &decoded); err != nil {
+		t.Fatalf("failed to publish template: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := client.InitFromTemplate(ctx, "binary-template", dir, map[string]string{"project_name": "myapp"}); err != nil {
+		t.Fatalf("failed to init from template: %v", err)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "assets", "icon.png"))
+	if err != nil {
+		t.Fatalf("failed to read icon: %v", err)
+	}
+	if !bytes.Equal(written, icon) {
+		t.Errorf("expected icon bytes %x, got %x", icon, written)
+	}
+
+	readme, _ := os.ReadFile(filepath.Join(dir, "README.md"))
+	if string(readme) != "# myapp\n" {
+		t.Errorf("expected expanded README, got %q", readme)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "run.sh")); err != nil || info.Mode()&0100 == 0 {
+		t.Errorf("expected run.sh to be executable: %v", err)
+	}
+}
+
+func TestMarketplaceTemplateFileValidation(t *testing.T) {
+	ctx := context.Background()
+	registry := marketplace.NewMemoryRegistry()
+	client := marketplace.NewClient(registry, marketplace.NewMemoryCache(), marketplace.NewValidator())
+
+	templated := &marketplace.Template{
+		ID:     "templated-binary",
+		Name:   "Templated Binary",
+		Author: "Test Author",
+		Files: []marketplace.TemplateFile{
+			{Path: "font.woff2", Data: []byte{0x77, 0x4f, 0x46, 0x32}, Templated: true},
+		},
+	}
+	escaping := &marketplace.Template{
+		ID:     "escaping",
+		Name:   "Escaping",
+		Author: "Test Author",
+		Files: []marketplace.TemplateFile{
+			{Path: "../outside.txt", Content: "x"},
+		},
+	}
+	registry.PublishTemplate(ctx, templated)
+	registry.PublishTemplate(ctx, escaping)
+
+	dir := t.TempDir()
+	if err := client.InitFromTemplate(ctx, "templated-binary", dir, nil); err == nil {
+		t.Error("expected error for a templated binary file")
+	}
+	if err := client.InitFromTemplate(ctx, "escaping", filepath.Join(dir, "project"), nil); err == nil {
+		t.Error("expected error for a path outside the target directory")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside.txt")); err == nil {
+		t.Error("expected no file written outside the target directory")
+	}
+}
+
+// flakyRegistryServer serves one package after failing the first failures
+// requests with 503
+func flakyRegistryServer(failures int32, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= failures {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/packages/http-test/1.0.0":
+			json.NewEncoder(w).Encode(marketplace.Package{ID: "http-test", Name: "HTTP Test", Version: "1.0.0", Author: "Test", Checksum: "c"})
+		case "/v1/packages/slow/1.0.0":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestHTTPRegistryRetry(t *testing.T) {
+	ctx := context.Background()
+	var requests int32
+	server := flakyRegistryServer(2, &requests)
+	defer server.Close()
+
+	registry, err := marketplace.NewHTTPRegistry(marketplace.HTTPConfig{
+		BaseURL: server.URL + "/v1",
+		Timeout: time.Second,
+		Retry:   core.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	pkg, err := registry.GetPackage(ctx, "http-test", "1.0.0")
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if pkg.Name != "HTTP Test" {
+		t.Errorf("unexpected package: %+v", pkg)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	// Missing packages are not retried
+	atomic.StoreInt32(&requests, 2)
+	var coreErr *core.Error
+	_, err = registry.GetPackage(ctx, "missing", "1.0.0")
+	if !errors.As(err, &coreErr) || coreErr.Code != core.CodeNotFound {
+		t.Errorf("expected NOT_FOUND, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected a single request for a missing package, got %d", requests-2)
+	}
+
+	// The client installs through the registry
+	atomic.StoreInt32(&requests, 0)
+	client := marketplace.NewClient(registry, marketplace.NewMemoryCache(), marketplace.NewValidator())
+	if err := client.Install(ctx, "http-test", "1.0.0"); err != nil {
+		t.Errorf("failed to install package: %v", err)
+	}
+}
+
+func TestHTTPRegistryPersistentFailure(t *testing.T) {
+	ctx := context.Background()
+	var requests int32
+	server := flakyRegistryServer(100, &requests)
+	defer server.Close()
+
+	registry, _ := marketplace.NewHTTPRegistry(marketplace.HTTPConfig{
+		BaseURL: server.URL + "/v1",
+		Retry:   core.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+
+	var coreErr *core.Error
+	_, err := registry.GetPackage(ctx, "http-test", "1.0.0")
+	if !errors.As(err, &coreErr) || coreErr.Code != core.CodeUnavailable {
+		t.Errorf("expected UNAVAILABLE, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 attempts, got %d", requests)
+	}
+
+	client := marketplace.NewClient(registry, marketplace.NewMemoryCache(), marketplace.NewValidator())
+	err = client.Install(ctx, "http-test", "1.0.0")
+	if !errors.As(err, &coreErr) || coreErr.Code != core.CodeUnavailable {
+		t.Errorf("expected install to fail with UNAVAILABLE, got %v", err)
+	}
+}
+
+func TestHTTPRegistryTimeout(t *testing.T) {
+	ctx := context.Background()
+	var requests int32
+	server := flakyRegistryServer(0, &requests)
+	defer server.Close()
+
+	// Each attempt times out and is retried until the deadline passes
+	registry, _ := marketplace.NewHTTPRegistry(marketplace.HTTPConfig{
+		BaseURL:  server.URL + "/v1",
+		Timeout:  20 * time.Millisecond,
+		Retry:    core.RetryPolicy{MaxAttempts: 10, Backoff: time.Millisecond},
+		Deadline: 50 * time.Millisecond,
+	})
+
+	start := time.Now()
+	var coreErr *core.Error
+	_, err := registry.GetPackage(ctx, "slow", "1.0.0")
+	if !errors.As(err, &coreErr) || coreErr.Code != core.CodeTimeout {
+		t.Errorf("expected TIMEOUT, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n < 2 {
+		t.Errorf("expected timed out attempts to be retried, got %d requests", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the deadline to bound retries, took %v", elapsed)
+	}
+}
+
+// packageTarball packs files as a gzipped tarball and returns it with its
+// sha256 checksum
+func packageTarball(t *testing.T, files map[string]string) ([]byte, string) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// drainInstall reads install progress until the channel closes, returning
+// the phases in the order they started and the final report
+func drainInstall(t *testing.T, progress <-chan marketplace.InstallProgress) ([]marketplace.InstallPhase, marketplace.InstallProgress) {
+	var phases []marketplace.InstallPhase
+	var last marketplace.InstallProgress
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case p, ok := <-progress:
+			if !ok {
+				return phases, last
+			}
+			if len(phases) == 0 || phases[len(phases)-1] != p.Phase {
+				phases = append(phases, p.Phase)
+			}
+			last = p
+		case <-timeout:
+			t.Fatal("install did not finish")
+		}
+	}
+}
+
+func TestMarketplaceInstallStream(t *testing.T) {
+	ctx := context.Background()
+	registry := marketplace.NewMemoryRegistry()
+	client := marketplace.NewClient(registry, marketplace.NewMemoryCache(), marketplace.NewValidator())
+	dir := t.TempDir()
+	client.SetInstallDir(dir)
+
+	data, checksum := packageTarball(t, map[string]string{
+		"plugin.py":     "def run(): pass\n",
+		"lib/helper.js": "export const x = 1;\n",
+	})
+	registry.Publish(ctx, &marketplace.Package{ID: "streamed", Name: "Streamed", Version: "1.0.0", Author: "Test", Checksum: checksum}, data)
+
+	progress, err := client.InstallStream(ctx, "streamed", "1.0.0")
+	if err != nil {
+		t.Fatalf("InstallStream failed: %v", err)
+	}
+	phases, last := drainInstall(t, progress)
+	want := []marketplace.InstallPhase{marketplace.PhaseDownload, marketplace.PhaseVerify, marketplace.PhaseExtract, marketplace.PhaseDone}
+	if fmt.Sprint(phases) != fmt.Sprint(want) {
+		t.Errorf("expected phases %v, got %v", want, phases)
+	}
+	if last.Err != nil {
+		t.Fatalf("install failed: %v", last.Err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "streamed", "1.0.0", "lib", "helper.js"))
+	if err != nil || string(content) != "export const x = 1;\n" {
+		t.Errorf("expected the package unpacked, got %q, %v", content, err)
+	}
+	if installed, _ := client.List(ctx); len(installed) != 1 {
+		t.Errorf("expected 1 installed package, got %d", len(installed))
+	}
+
+	// A package failing verification is never unpacked
+	registry.Publish(ctx, &marketplace.Package{ID: "tampered", Name: "Tampered", Version: "1.0.0", Author: "Test", Checksum: checksum}, append(data, 0))
+	progress, err = client.InstallStream(ctx, "tampered", "1.0.0")
+	if err != nil {
+		t.Fatalf("InstallStream failed: %v", err)
+	}
+	if _, last := drainInstall(t, progress); last.Phase != marketplace.PhaseVerify || last.Err == nil {
+		t.Errorf("expected verification to fail, got %+v", last)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tampered")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing installed for a tampered package, got %v", err)
+	}
+}
+
+func TestMarketplaceInstallStreamCancel(t *testing.T) {
+	// The registry sends part of the package, then stalls until the
+	// request is abandoned
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/packages/big/1.0.0":
+			json.NewEncoder(w).Encode(marketplace.Package{ID: "big", Name: "Big", Version: "1.0.0", Author: "Test", Checksum: "c"})
+		case "/v1/packages/big/1.0.0/data":
+			w.Header().Set("Content-Length", "1048576")
+			w.Write(make([]byte, 64*1024))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	registry, _ := marketplace.NewHTTPRegistry(marketplace.HTTPConfig{BaseURL: server.URL + "/v1"})
+	client := marketplace.NewClient(registry, marketplace.NewMemoryCache(), marketplace.NewValidator())
+	dir := t.TempDir()
+	client.SetInstallDir(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	progress, err := client.InstallStream(ctx, "big", "1.0.0")
+	if err != nil {
+		t.Fatalf("InstallStream failed: %v", err)
+	}
+
+	var last marketplace.InstallProgress
+	for p := range progress {
+		if p.Phase == marketplace.PhaseDownload && p.Bytes > 0 {
+			if p.Total != 1048576 {
+				t.Errorf("expected the package size as total, got %d", p.Total)
+			}
+			cancel()
+		}
+		last = p
+	}
+	if !errors.Is(last.Err, context.Canceled) || last.Phase != marketplace.PhaseDownload {
+		t.Errorf("expected the download canceled, got %+v", last)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected no files left behind, got %d entries", len(entries))
+	}
+	if installed, _ := client.List(context.Background()); len(installed) != 0 {
+		t.Errorf("expected nothing installed, got %d packages", len(installed))
+	}
+	if client.Cache().Has(context.Background(), "big", "1.0.0") {
+		t.Error("expected nothing cached")
+	}
+}
@@ -4,7 +4,14 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -374,3 +381,1191 @@ add(10, 20)
 		t.Logf("Function result: %v (type: %T)", result, result)
 	}
 }
+
+// TestPythonDefinedFunctions tests listing user-defined functions
+func TestPythonDefinedFunctions(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `
+def add(a, b):
+    return a + b
+
+def greet(name="world"):
+    return "Hello " + name
+
+def total(*values):
+    return sum(values)
+
+def _helper():
+    pass
+
+answer = 42
+`
+	if _, err := runtime.Execute(ctx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	functions, err := runtime.DefinedFunctions(ctx)
+	if err != nil {
+		t.Fatalf("DefinedFunctions failed: %v", err)
+	}
+
+	expected := []core.FunctionInfo{{Name: "add", Arity: 2}, {Name: "greet", Arity: -1}, {Name: "total", Arity: -1}}
+	if len(functions) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, functions)
+	}
+	for i := range expected {
+		if functions[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], functions[i])
+		}
+	}
+}
+
+// TestPythonExecuteWithChanges tests that the names and modules code
+// defines are reported with its result
+func TestPythonExecuteWithChanges(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	if _, err := runtime.Execute(ctx, "existing = 1"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	code := `
+import colorsys
+existing = 1
+answer = 42
+
+def greet(name):
+    return "Hello " + name
+`
+	_, changes, err := runtime.ExecuteWithChanges(ctx, code)
+	if err != nil {
+		t.Fatalf("ExecuteWithChanges failed: %v", err)
+	}
+
+	expected := []core.ScopedName{{Name: "answer", Type: "int"}, {Name: "colorsys", Type: "module"}, {Name: "greet", Type: "function"}}
+	if !reflect.DeepEqual(changes.Defined, expected) {
+		t.Errorf("Expected defined %v, got %v", expected, changes.Defined)
+	}
+	if !reflect.DeepEqual(changes.Imported, []string{"colorsys"}) {
+		t.Errorf("Expected imported [colorsys], got %v", changes.Imported)
+	}
+
+	// Rebinding a name to another type reports it again
+	value, changes, err := runtime.ExecuteWithChanges(ctx, "answer = 'forty-two'")
+	if err != nil {
+		t.Fatalf("ExecuteWithChanges failed: %v", err)
+	}
+	if value != nil || !reflect.DeepEqual(changes.Defined, []core.ScopedName{{Name: "answer", Type: "str"}}) {
+		t.Errorf("Expected answer rebound to str, got %v, %v", value, changes)
+	}
+
+	// Code that fails partway reports what it defined before failing
+	_, changes, err = runtime.ExecuteWithChanges(ctx, "partial = 1\nraise ValueError('boom')")
+	if err == nil {
+		t.Fatal("Expected the exception to be returned")
+	}
+	if !reflect.DeepEqual(changes.Defined, []core.ScopedName{{Name: "partial", Type: "int"}}) {
+		t.Errorf("Expected partial to be reported, got %v", changes)
+	}
+}
+
+// TestPythonInterrupt tests that Interrupt stops a running busy loop
+func TestPythonInterrupt(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	execCtx, id := core.WithExecutionID(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := runtime.Execute(execCtx, `while True: pass`)
+		done <- err
+	}()
+
+	// The execution is registered once it has a worker
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.Interrupt(id) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("execution never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-done:
+		if err != core.ErrInterrupted {
+			t.Errorf("Expected ErrInterrupted, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Execution was not interrupted")
+	}
+
+	if err := runtime.Interrupt(id); err == nil {
+		t.Error("Expected error interrupting a finished execution")
+	}
+
+	// A timed out execution is stopped, freeing the only worker
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := runtime.Execute(timeoutCtx, `while True: pass`); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	resultCtx, cancelResult := context.WithTimeout(ctx, 2*time.Second)
+	defer cancelResult()
+	result, err := runtime.Execute(resultCtx, `42`)
+	if err != nil {
+		t.Fatalf("Execute after timeout failed: %v", err)
+	}
+	if result != int64(42) {
+		t.Errorf("Expected 42, got %v (%T)", result, result)
+	}
+}
+
+// TestPythonAvailableModules tests that common stdlib modules are listed
+// as importable
+func TestPythonAvailableModules(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        10 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	modules, err := core.AvailableModules(ctx, runtime)
+	if err != nil {
+		t.Fatalf("AvailableModules failed: %v", err)
+	}
+
+	available := make(map[string]bool, len(modules))
+	for _, name := range modules {
+		available[name] = true
+		if strings.HasPrefix(name, "_") {
+			t.Errorf("Expected private module %s to be omitted", name)
+		}
+	}
+	for _, name := range []string{"math", "json", "re", "datetime", "collections", "itertools", "statistics", "sys"} {
+		if !available[name] {
+			t.Errorf("Expected %s among available modules", name)
+		}
+	}
+	if available["polyglot_no_such_module"] {
+		t.Error("Expected a missing module not to be listed")
+	}
+}
+
+// Test print output is streamed to the writers set on the context
+func TestPythonOutputStreaming(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	var stdout, stderr bytes.Buffer
+	outCtx := core.WithOutput(ctx, &stdout, &stderr)
+	code := "import sys\nfor i in range(3):\n    print('step', i)\nprint('oops', file=sys.stderr)"
+	if _, err := runtime.Execute(outCtx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if got := stdout.String(); got != "step 0\nstep 1\nstep 2\n" {
+		t.Errorf("Unexpected stdout %q", got)
+	}
+	if got := stderr.String(); got != "oops\n" {
+		t.Errorf("Unexpected stderr %q", got)
+	}
+
+	// Executions without writers print as usual
+	stdout.Reset()
+	if _, err := runtime.Execute(ctx, "print('not captured')"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output captured, got %q", stdout.String())
+	}
+}
+
+// Test print output is also written to the writers set on the runtime config
+func TestPythonConfigOutput(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	var stdout, stderr bytes.Buffer
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 2,
+		Timeout:        5 * time.Second,
+		Stdout:         &stdout,
+		Stderr:         &stderr,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := "import sys\nprint('hello')\nprint('oops', file=sys.stderr)"
+	if _, err := runtime.Execute(ctx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := stdout.String(); got != "hello\n" {
+		t.Errorf("Unexpected stdout %q", got)
+	}
+	if got := stderr.String(); got != "oops\n" {
+		t.Errorf("Unexpected stderr %q", got)
+	}
+
+	// Writers set on the context receive the output as well
+	var ctxOut bytes.Buffer
+	stdout.Reset()
+	if _, err := runtime.Execute(core.WithOutput(ctx, &ctxOut, nil), "print('both')"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if stdout.String() != "both\n" || ctxOut.String() != "both\n" {
+		t.Errorf("Expected output in both writers, got %q and %q", stdout.String(), ctxOut.String())
+	}
+}
+
+// Test preimported modules are available to executions on every worker
+func TestPythonPreimport(t *testing.T) {
+	runtime := python.NewRuntime()
+	runtime.Preimport([]string{"math", "os.path"})
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 2,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	for i := 0; i < 4; i++ {
+		result, err := runtime.Execute(ctx, "math.sqrt(16)")
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result != 4.0 {
+			t.Errorf("Expected 4, got %v", result)
+		}
+	}
+
+	result, err := runtime.Execute(ctx, "os.path.join('a', 'b')")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != "a/b" {
+		t.Errorf("Expected a/b, got %v", result)
+	}
+}
+
+// Test init code runs once on each worker and its definitions are
+// available to later executions and calls
+func TestPythonInitCode(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	// Workers share the interpreter's sys module, so it counts the runs
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 3,
+		InitCode: `
+import sys
+sys.polyglot_init_runs = getattr(sys, "polyglot_init_runs", 0) + 1
+
+def helper(x):
+    return x * 2
+
+def double_plus_one(x):
+    return helper(x) + 1
+`,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	for i := 0; i < 6; i++ {
+		result, err := runtime.Execute(ctx, "double_plus_one(20)")
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result != int64(41) {
+			t.Errorf("Expected 41, got %v (%T)", result, result)
+		}
+	}
+
+	result, err := runtime.Call(ctx, "double_plus_one", 4)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if result != int64(9) {
+		t.Errorf("Expected 9, got %v (%T)", result, result)
+	}
+
+	runs, err := runtime.Execute(ctx, "sys.polyglot_init_runs")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if runs != int64(3) {
+		t.Errorf("Expected init code to run once per worker, ran %v times", runs)
+	}
+}
+
+// Test init code definitions survive ResetBetweenCalls and a failing init
+// code fails initialization
+func TestPythonInitCodeReset(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:              "python",
+		Enabled:           true,
+		MaxConcurrency:    1,
+		ResetBetweenCalls: true,
+		InitCode:          "greeting = 'hello'",
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	for i := 0; i < 2; i++ {
+		if _, err := runtime.Execute(ctx, "greeting = 'changed'"); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		result, err := runtime.Execute(ctx, "greeting")
+		if err != nil {
+			t.Fatalf("Init code definitions lost after reset: %v", err)
+		}
+		if result != "hello" {
+			t.Errorf("Expected the init code value, got %v", result)
+		}
+	}
+
+	failing := python.NewRuntime()
+	config.InitCode = "raise ValueError('bad setup')"
+	err := failing.Initialize(ctx, config)
+	defer failing.Shutdown(ctx)
+	if !errors.Is(err, python.ErrExecFailed) {
+		t.Errorf("Expected ErrExecFailed, got %v", err)
+	}
+}
+
+// Test a missing preimport fails initialization
+func TestPythonPreimportMissing(t *testing.T) {
+	runtime := python.NewRuntime()
+	runtime.Preimport([]string{"polyglot_no_such_module"})
+	ctx := context.Background()
+
+	err := runtime.Initialize(ctx, core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1})
+	defer runtime.Shutdown(ctx)
+	if !errors.Is(err, python.ErrImportFailed) {
+		t.Errorf("Expected ErrImportFailed, got %v", err)
+	}
+}
+
+// Test Python code invokes methods of a Go object passed in by handle
+func TestPythonHandles(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	handles := core.NewHandles()
+	store := &handleStore{items: make(map[string]int)}
+	handle := handles.Register(store)
+	handleCtx := core.WithHandles(ctx, handles)
+
+	// Statements return nil, so results are read back with expressions on
+	// the same worker
+	code := `
+import polyglot
+store = polyglot.Handle(arg0)
+store.Put("answer", 40)
+`
+	if _, err := runtime.Execute(handleCtx, code, string(handle)); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if store.Get("answer") != 40 {
+		t.Errorf("Expected Put to reach the Go object, got %d", store.Get("answer"))
+	}
+	result, err := runtime.Execute(handleCtx, `store.Get("answer") + 2`)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != int64(42) {
+		t.Errorf("Expected 42, got %v (%T)", result, result)
+	}
+
+	// Go errors surface as Python exceptions
+	code = `
+try:
+    store.Put("answer", -1)
+    outcome = "no error"
+except RuntimeError as e:
+    outcome = str(e)
+`
+	if _, err := runtime.Execute(handleCtx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	result, err = runtime.Execute(handleCtx, "outcome")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if s, _ := result.(string); !strings.Contains(s, "must not be negative") {
+		t.Errorf("Expected the Go error in Python, got %v", result)
+	}
+
+	// Panics and results Python cannot receive surface as exceptions too
+	for method, want := range map[string]string{"Crash": "store corrupted", "Watch": "cannot convert"} {
+		code = fmt.Sprintf(`
+try:
+    store.%s()
+    outcome = "no error"
+except RuntimeError as e:
+    outcome = str(e)
+`, method)
+		if _, err := runtime.Execute(handleCtx, code); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		result, err = runtime.Execute(handleCtx, "outcome")
+		if s, _ := result.(string); err != nil || !strings.Contains(s, want) {
+			t.Errorf("%s: expected %q in Python, got %v, %v", method, want, result, err)
+		}
+	}
+
+	// Without handles on the context, invoking fails
+	if _, err := runtime.Execute(ctx, `store.Get("answer")`); err == nil {
+		t.Error("Expected error invoking a handle without handles")
+	}
+}
+
+// Test progress reported from a Python loop arrives in order
+func TestPythonProgress(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	var reports []core.Progress
+	progressCtx := core.WithProgress(ctx, func(p core.Progress) {
+		reports = append(reports, p)
+	})
+
+	code := `
+import polyglot
+total = 0
+for i in range(5):
+    total += i
+    polyglot.progress((i + 1) / 5, "item %d" % i)
+`
+	if _, err := runtime.Execute(progressCtx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if len(reports) != 5 {
+		t.Fatalf("Expected 5 reports, got %+v", reports)
+	}
+	for i, report := range reports {
+		if report.Runtime != "python" || report.Fraction != float64(i+1)/5 || report.Message != fmt.Sprintf("item %d", i) {
+			t.Errorf("Report %d: unexpected %+v", i, report)
+		}
+	}
+
+	// Without a progress function on the context, reporting does nothing
+	if _, err := runtime.Execute(ctx, "polyglot.progress(0.5)"); err != nil {
+		t.Errorf("Expected progress without a listener to be ignored, got %v", err)
+	}
+}
+
+// Test ResetBetweenCalls isolates globals and imports between executions
+func TestPythonResetBetweenCalls(t *testing.T) {
+	for _, reset := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reset=%t", reset), func(t *testing.T) {
+			runtime := python.NewRuntime()
+			runtime.Preimport([]string{"math"})
+			ctx := context.Background()
+
+			// One worker so both calls share an interpreter scope
+			config := core.RuntimeConfig{
+				Name:              "python",
+				Enabled:           true,
+				MaxConcurrency:    1,
+				ResetBetweenCalls: reset,
+			}
+			if err := runtime.Initialize(ctx, config); err != nil {
+				t.Fatalf("Initialize failed: %v", err)
+			}
+			defer runtime.Shutdown(ctx)
+
+			if _, err := runtime.Execute(ctx, "secret = 42\nimport json"); err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+
+			for _, code := range []string{"secret", "json.dumps(42)"} {
+				result, err := runtime.Execute(ctx, code)
+				if reset && err == nil {
+					t.Errorf("%s: expected NameError after reset, got %v", code, result)
+				}
+				if !reset && err != nil {
+					t.Errorf("%s: expected state to persist, got %v", code, err)
+				}
+			}
+
+			// Preimports survive a reset
+			result, err := runtime.Execute(ctx, "math.sqrt(9)")
+			if err != nil {
+				t.Fatalf("Preimport lost: %v", err)
+			}
+			if result != 3.0 {
+				t.Errorf("Expected 3, got %v", result)
+			}
+		})
+	}
+}
+
+// Test a state whose code outlives its interrupt is replaced rather than
+// released with the caller's globals
+func TestPythonResetAfterStuckInterrupt(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:              "python",
+		Enabled:           true,
+		MaxConcurrency:    1,
+		ResetBetweenCalls: true,
+		InitCode:          "greeting = 'hello'",
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	// Swallows interrupts for longer than core.InterruptGrace
+	stuck := `secret = 42
+import time
+end = time.time() + core_grace + 1
+while time.time() < end:
+    try:
+        time.sleep(0.01)
+    except KeyboardInterrupt:
+        pass
+`
+	stuck = strings.Replace(stuck, "core_grace", fmt.Sprint(core.InterruptGrace.Seconds()), 1)
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := runtime.Execute(timeout, stuck); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	result, err := runtime.Execute(ctx, "'secret' in globals()")
+	if err != nil {
+		t.Fatalf("Execute after stuck interrupt failed: %v", err)
+	}
+	if result != false {
+		t.Errorf("Expected the stuck caller's globals to be gone, got %v", result)
+	}
+	if result, err := runtime.Execute(ctx, "greeting"); err != nil || result != "hello" {
+		t.Errorf("Expected the replacement to run init code, got %v, %v", result, err)
+	}
+}
+
+func TestPythonPrettyErrors(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Options:        map[string]interface{}{"pretty_errors": true},
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	_, err := runtime.Execute(ctx, "def parse(value):\n    return int(value)\n\nparse('abc')")
+	var script *core.ScriptError
+	if !errors.As(err, &script) {
+		t.Fatalf("Expected *core.ScriptError, got %T: %v", err, err)
+	}
+	if script.Type != "ValueError" || !strings.Contains(script.Message, "'abc'") {
+		t.Errorf("Unexpected exception %q: %q", script.Type, script.Message)
+	}
+	if script.Frame == nil || script.Frame.Function != "parse" || script.Frame.Line != 2 {
+		t.Errorf("Expected parse at line 2 as top user frame, got %+v", script.Frame)
+	}
+	if !errors.Is(err, python.ErrExecFailed) {
+		t.Error("Expected the runtime error to stay wrapped")
+	}
+
+	_, err = runtime.Execute(ctx, "x = = 1")
+	if !errors.As(err, &script) || script.Type != "SyntaxError" {
+		t.Errorf("Expected SyntaxError, got %v", err)
+	}
+}
+
+func TestPythonCallMulti(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	// One worker so the functions are defined where they are called
+	config := core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := "def stats(values):\n    return min(values), max(values), len(values)\n\ndef single():\n    return [1, 2]"
+	if _, err := runtime.Execute(ctx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	values, err := runtime.CallMulti(ctx, "stats", []interface{}{3, 1, 2})
+	if err != nil {
+		t.Fatalf("CallMulti failed: %v", err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{int64(1), int64(3), int64(3)}) {
+		t.Errorf("Expected all three tuple items, got %#v", values)
+	}
+
+	// A list is one value, not several
+	values, err = runtime.CallMulti(ctx, "single")
+	if err != nil || len(values) != 1 {
+		t.Errorf("Expected a single list result, got %#v (%v)", values, err)
+	}
+}
+
+func TestPythonExecuteMapped(t *testing.T) {
+	config := core.DefaultConfig()
+	config.Languages["python"] = &core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1}
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	orch.RegisterRuntime(python.NewRuntime())
+	ctx := context.Background()
+	if err := orch.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	inputs := map[string]interface{}{"x": 6, "name": "world"}
+	code := "product = x * 7\ngreeting = 'hello ' + name\nx + 1"
+	outputs, err := orch.ExecuteMapped(ctx, "python", code, inputs, []string{"product", "greeting"})
+	if err != nil {
+		t.Fatalf("ExecuteMapped failed: %v", err)
+	}
+	want := map[string]interface{}{"product": int64(42), "greeting": "hello world"}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Errorf("Expected %v, got %#v", want, outputs)
+	}
+
+	_, err = orch.ExecuteMapped(ctx, "python", "y = 1", nil, []string{"missing"})
+	if !errors.Is(err, python.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unset output, got %v", err)
+	}
+}
+
+// TestPythonDeterministic tests that deterministic mode makes random
+// numbers and the clock repeat across executions
+func TestPythonDeterministic(t *testing.T) {
+	runtime := python.NewRuntime()
+	ctx := context.Background()
+
+	frozen := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	config := core.RuntimeConfig{
+		Name:              "python",
+		Enabled:           true,
+		MaxConcurrency:    1,
+		Deterministic:     true,
+		DeterministicSeed: 7,
+		DeterministicTime: frozen,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `(__import__("random").random(), __import__("random").randint(0, 1000), __import__("time").time(), ` +
+		`__import__("datetime").datetime.now(__import__("datetime").timezone.utc).isoformat())`
+	first, err := runtime.Execute(ctx, code)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		again, err := runtime.Execute(ctx, code)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if !reflect.DeepEqual(again, first) {
+			t.Errorf("Expected identical output across runs, got %v then %v", first, again)
+		}
+	}
+
+	values, ok := first.([]interface{})
+	if !ok || len(values) != 4 {
+		t.Fatalf("Expected a 4-tuple, got %#v", first)
+	}
+	if values[2] != float64(frozen.Unix()) {
+		t.Errorf("Expected time.time() to be %d, got %v", frozen.Unix(), values[2])
+	}
+	if values[3] != "2024-03-01T12:00:00+00:00" {
+		t.Errorf("Expected datetime.now() to be frozen, got %v", values[3])
+	}
+}
+
+// Test state snapshotted from one runtime restores into every worker of
+// another with the same functions and data
+func TestPythonStateSnapshot(t *testing.T) {
+	ctx := context.Background()
+	source := python.NewRuntime()
+	if err := source.Initialize(ctx, core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer source.Shutdown(ctx)
+
+	_, err := source.Execute(ctx, `
+import math
+rates = {"standard": 0.2, "reduced": 0.05}
+
+def tax(amount, kind="standard"):
+    return round(amount * rates[kind], 2)
+
+def hypotenuse(a, b):
+    return math.sqrt(a * a + b * b)
+`)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	snapshot, err := core.SnapshotState(ctx, source)
+	if err != nil {
+		t.Fatalf("SnapshotState failed: %v", err)
+	}
+
+	target := python.NewRuntime()
+	if err := target.Initialize(ctx, core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 2}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer target.Shutdown(ctx)
+	if err := core.RestoreState(ctx, target, snapshot); err != nil {
+		t.Fatalf("RestoreState failed: %v", err)
+	}
+
+	// Concurrent calls reach both workers
+	results := make(chan interface{}, 4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			result, err := target.Call(ctx, "tax", 100, "reduced")
+			if err != nil {
+				t.Errorf("Call failed: %v", err)
+			}
+			results <- result
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if result := <-results; result != float64(5) {
+			t.Errorf("Expected 5, got %v (%T)", result, result)
+		}
+	}
+
+	result, err := target.Execute(ctx, "hypotenuse(3, 4) + tax(10)")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != float64(7) {
+		t.Errorf("Expected 7, got %v (%T)", result, result)
+	}
+
+	if err := target.RestoreState(ctx, []byte("not a snapshot")); err == nil {
+		t.Error("Expected an invalid snapshot to fail")
+	}
+}
+
+// Test InitState prepares each worker and survives ResetBetweenCalls, and
+// closures cannot be snapshotted
+func TestPythonInitState(t *testing.T) {
+	ctx := context.Background()
+	source := python.NewRuntime()
+	if err := source.Initialize(ctx, core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer source.Shutdown(ctx)
+
+	if _, err := source.Execute(ctx, "greeting = 'hello'\ndef greet(name):\n    return greeting + ', ' + name"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	snapshot, err := source.SnapshotState(ctx)
+	if err != nil {
+		t.Fatalf("SnapshotState failed: %v", err)
+	}
+
+	target := python.NewRuntime()
+	config := core.RuntimeConfig{
+		Name:              "python",
+		Enabled:           true,
+		MaxConcurrency:    1,
+		ResetBetweenCalls: true,
+		InitState:         snapshot,
+	}
+	if err := target.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer target.Shutdown(ctx)
+
+	for i := 0; i < 2; i++ {
+		if _, err := target.Execute(ctx, "greeting = 'bye'"); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		result, err := target.Execute(ctx, "greet('Ada')")
+		if err != nil {
+			t.Fatalf("Restored state lost after reset: %v", err)
+		}
+		if result != "hello, Ada" {
+			t.Errorf("Expected the snapshot's greeting, got %v", result)
+		}
+	}
+
+	if _, err := source.Execute(ctx, "def outer():\n    x = 1\n    def inner():\n        return x\n    return inner\nclosure = outer()"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, err := source.SnapshotState(ctx); err == nil || !strings.Contains(err.Error(), "closure") {
+		t.Errorf("Expected closures to fail the snapshot, got %v", err)
+	}
+}
+
+// Test MaxStackDepth turns runaway recursion into a catchable RecursionError
+// that surfaces as ErrStackDepth
+func TestPythonMaxStackDepth(t *testing.T) {
+	ctx := context.Background()
+	runtime := python.NewRuntime()
+	config := core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1, MaxStackDepth: 200}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+	// The limit is interpreter-wide, so put back the default for later tests
+	defer runtime.Execute(ctx, "__import__('sys').setrecursionlimit(1000)")
+
+	if _, err := runtime.Execute(ctx, "global depth\ndef depth(n):\n    return n if n == 0 else depth(n - 1) + 1"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	result, err := runtime.Execute(ctx, "depth(100)")
+	if err != nil {
+		t.Fatalf("Recursion within the limit failed: %v", err)
+	}
+	if result != int64(100) {
+		t.Errorf("Expected 100, got %v (%T)", result, result)
+	}
+
+	_, err = runtime.Execute(ctx, "depth(10000)")
+	if !errors.Is(err, core.ErrStackDepth) {
+		t.Fatalf("Expected ErrStackDepth, got %v", err)
+	}
+	if code := core.ErrorInfoFor(err).Code; code != core.CodeResourceExhausted {
+		t.Errorf("Expected %s, got %s", core.CodeResourceExhausted, code)
+	}
+	if !strings.Contains(err.Error(), "RecursionError") {
+		t.Errorf("Expected the RecursionError traceback, got %v", err)
+	}
+
+	if _, err := runtime.Call(ctx, "depth", 10000); !errors.Is(err, core.ErrStackDepth) {
+		t.Errorf("Expected ErrStackDepth from Call, got %v", err)
+	}
+
+	if _, err := runtime.Execute(ctx, "try:\n    depth(10000)\n    caught = False\nexcept RecursionError:\n    caught = True"); err != nil {
+		t.Fatalf("Caught RecursionError escaped: %v", err)
+	}
+	result, err = runtime.Execute(ctx, "caught")
+	if err != nil || result != true {
+		t.Errorf("Expected code to catch RecursionError, got %v, %v", result, err)
+	}
+}
+
+// Test ShutdownCode runs once on every worker before it is torn down,
+// including a worker busy when Shutdown starts, and can use what InitCode
+// opened
+func TestPythonShutdownCode(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "shutdown.log")
+	runtime := python.NewRuntime()
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 3,
+		InitCode:       fmt.Sprintf("log = open(%q, 'a')", path),
+		ShutdownCode:   "log.write('closed\\n')\nlog.close()",
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	started := make(chan struct{})
+	finished := make(chan error, 1)
+	go func() {
+		close(started)
+		_, err := runtime.Execute(ctx, "import time\ntime.sleep(0.3)")
+		finished <- err
+	}()
+	<-started
+	time.Sleep(50 * time.Millisecond)
+
+	if err := runtime.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := <-finished; err != nil {
+		t.Fatalf("Busy execution failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if closed := strings.Count(string(data), "closed\n"); closed != 3 {
+		t.Errorf("Expected the shutdown code to run once per worker, got %d runs", closed)
+	}
+}
+
+// Test shutdown code still running when the Shutdown context ends is
+// interrupted
+func TestPythonShutdownCodeGrace(t *testing.T) {
+	runtime := python.NewRuntime()
+	config := core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1, ShutdownCode: "while True:\n    pass"}
+	if err := runtime.Initialize(context.Background(), config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := runtime.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Shutdown took %v; the shutdown code should stop with its context", elapsed)
+	}
+}
+
+// Test a single-threaded runtime runs every call on the same OS thread
+// and still returns each caller's result under concurrent submission
+func TestPythonSingleThreaded(t *testing.T) {
+	ctx := context.Background()
+	runtime := python.NewRuntime()
+	config := core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 4, SingleThreaded: true}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	if _, err := runtime.Execute(ctx, "def square(n):\n    return (__import__('threading').get_native_id(), n * n)"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	const calls = 20
+	type outcome struct {
+		n      int
+		result interface{}
+		err    error
+	}
+	outcomes := make(chan outcome, calls)
+	for i := 0; i < calls; i++ {
+		go func(n int) {
+			result, err := runtime.Call(ctx, "square", n)
+			outcomes <- outcome{n, result, err}
+		}(i)
+	}
+
+	threads := map[interface{}]bool{}
+	for i := 0; i < calls; i++ {
+		o := <-outcomes
+		if o.err != nil {
+			t.Fatalf("Call %d failed: %v", o.n, o.err)
+		}
+		pair, ok := o.result.([]interface{})
+		if !ok || len(pair) != 2 {
+			t.Fatalf("Call %d returned %#v", o.n, o.result)
+		}
+		if fmt.Sprint(pair[1]) != fmt.Sprint(o.n*o.n) {
+			t.Errorf("Call %d returned %v, want %d", o.n, pair[1], o.n*o.n)
+		}
+		threads[fmt.Sprint(pair[0])] = true
+	}
+
+	id, err := runtime.Execute(ctx, "__import__('threading').get_native_id()")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	threads[fmt.Sprint(id)] = true
+	if len(threads) != 1 {
+		t.Errorf("Expected every call on one thread, got %d threads", len(threads))
+	}
+	if stats := runtime.PoolStats(); stats.Workers != 1 {
+		t.Errorf("Expected one worker, got %d", stats.Workers)
+	}
+}
+
+// Test polyglot.image returns image bytes tagged with their MIME type,
+// which the bridge sends to the frontend as an image
+func TestPythonImageResult(t *testing.T) {
+	ctx := context.Background()
+	runtime := python.NewRuntime()
+	if err := runtime.Initialize(ctx, core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	if _, err := runtime.Execute(ctx, "import polyglot, io\nbuf = io.BytesIO(b'\\x89PNG\\r\\n\\x1a\\n\\x00chart')"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	result, err := runtime.Execute(ctx, "polyglot.image(buf.getvalue())")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	image, ok := core.ImageFrom(result)
+	if !ok {
+		t.Fatalf("Expected an image, got %#v", result)
+	}
+	if image.MimeType != "image/png" || !bytes.Equal(image.Data, []byte("\x89PNG\r\n\x1a\n\x00chart")) {
+		t.Errorf("Unexpected image %s %q", image.MimeType, image.Data)
+	}
+
+	svg, err := runtime.Execute(ctx, "polyglot.image('<svg/>'.encode(), 'image/svg+xml')")
+	if image, ok := core.ImageFrom(svg); err != nil || !ok || image.MimeType != "image/svg+xml" || string(image.Data) != "<svg/>" {
+		t.Errorf("Expected an SVG image, got %#v, %v", svg, err)
+	}
+}
+
+// Test times passed into Python arrive as timezone-aware datetimes and
+// datetimes returned come back as Go times
+func TestPythonDatetime(t *testing.T) {
+	ctx := context.Background()
+	runtime := python.NewRuntime()
+	if err := runtime.Initialize(ctx, core.RuntimeConfig{Name: "python", Enabled: true, MaxConcurrency: 1}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `
+def describe(when):
+    return [when.isoformat(), when.microsecond, when.utcoffset().total_seconds()]
+
+def next_day(when):
+    import datetime
+    return when + datetime.timedelta(days=1)
+`
+	if _, err := runtime.Execute(ctx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	due := time.Date(2024, 3, 9, 14, 30, 15, 250_000_000, time.FixedZone("CET", 3600))
+	described, err := runtime.Call(ctx, "describe", due)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if !reflect.DeepEqual(described, []interface{}{"2024-03-09T14:30:15.250000+01:00", int64(250000), 3600.0}) {
+		t.Errorf("Expected an aware datetime, got %#v", described)
+	}
+
+	result, err := runtime.Call(ctx, "next_day", due)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	next, ok := result.(time.Time)
+	if !ok || !next.Equal(due.Add(24*time.Hour)) {
+		t.Errorf("Expected %v, got %#v", due.Add(24*time.Hour), result)
+	}
+	if _, offset := next.Zone(); offset != 3600 {
+		t.Errorf("Expected the datetime's offset kept, got %d", offset)
+	}
+
+	// The time a handler returns for the bridge follows the time policy
+	encoded, err := core.CodecWithPolicies(core.FormatJSON, core.NumbersFloat, core.TimesMillis).Marshal(result)
+	if err != nil || string(encoded) != fmt.Sprint(due.Add(24*time.Hour).UnixMilli()) {
+		t.Errorf("Expected epoch millis, got %s, %v", encoded, err)
+	}
+}
@@ -496,3 +496,41 @@ func BenchmarkPythonExecution(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkPythonPreimport compares a snippet using a preimported module
+// with one importing it inline
+func BenchmarkPythonPreimport(b *testing.B) {
+	runtime := python.NewRuntime()
+	runtime.Preimport([]string{"statistics"})
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "python",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        30 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		b.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	benchmarks := []struct {
+		name string
+		code string
+	}{
+		{"Inline import", "import statistics\nstatistics.mean([1, 2, 3, 4])"},
+		{"Preimported", "statistics.mean([1, 2, 3, 4])"},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := runtime.Execute(ctx, bm.code); err != nil {
+					b.Errorf("Execute failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -779,6 +779,39 @@ func TestRubyWorkerPoolReuse(t *testing.T) {
 	}
 }
 
+// TestRubyAffinitySession tests that an affinity session keeps globals coherent
+func TestRubyAffinitySession(t *testing.T) {
+	runtime := ruby.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "ruby",
+		Enabled:        true,
+		MaxConcurrency: 4,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	session := core.WithAffinity(ctx, "counter-session")
+	if _, err := runtime.Execute(session, "$affinity_counter = 0"); err != nil {
+		t.Fatalf("Failed to set variable: %v", err)
+	}
+
+	for i := 1; i <= 10; i++ {
+		result, err := runtime.Execute(session, "$affinity_counter += 1")
+		if err != nil {
+			t.Fatalf("Iteration %d failed: %v", i, err)
+		}
+		if fmt.Sprint(result) != fmt.Sprint(i) {
+			t.Fatalf("Expected counter %d, got %v", i, result)
+		}
+	}
+}
+
 // TestRubyClassesAndObjects tests Ruby class definition and object creation
 func TestRubyClassesAndObjects(t *testing.T) {
 	runtime := ruby.NewRuntime()
@@ -1058,3 +1091,154 @@ func TestRubyStringEscaping(t *testing.T) {
 		})
 	}
 }
+
+// TestRubyDefinedFunctions tests listing user-defined methods
+func TestRubyDefinedFunctions(t *testing.T) {
+	runtime := ruby.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "ruby",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `
+def introspect_add(a, b)
+  a + b
+end
+
+def introspect_greet(name = "world")
+  "Hello #{name}"
+end
+`
+	if _, err := runtime.Execute(ctx, code); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	functions, err := runtime.DefinedFunctions(ctx)
+	if err != nil {
+		t.Fatalf("DefinedFunctions failed: %v", err)
+	}
+
+	arities := make(map[string]int)
+	for _, fn := range functions {
+		arities[fn.Name] = fn.Arity
+	}
+	if arity, ok := arities["introspect_add"]; !ok || arity != 2 {
+		t.Errorf("Expected introspect_add with arity 2, got %v", functions)
+	}
+	if arity, ok := arities["introspect_greet"]; !ok || arity != -1 {
+		t.Errorf("Expected introspect_greet with arity -1, got %v", functions)
+	}
+}
+
+// TestRubyInterrupt tests that Interrupt stops a running busy loop
+func TestRubyInterrupt(t *testing.T) {
+	runtime := ruby.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "ruby",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	execCtx, id := core.WithExecutionID(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := runtime.Execute(execCtx, `loop { }`)
+		done <- err
+	}()
+
+	// The execution is registered once it has a worker
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.Interrupt(id) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("execution never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-done:
+		if err != core.ErrInterrupted {
+			t.Errorf("Expected ErrInterrupted, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Execution was not interrupted")
+	}
+
+	if err := runtime.Interrupt(id); err == nil {
+		t.Error("Expected error interrupting a finished execution")
+	}
+
+	// A timed out execution is stopped, freeing the only worker
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := runtime.Execute(timeoutCtx, `loop { }`); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+
+	resultCtx, cancelResult := context.WithTimeout(ctx, 2*time.Second)
+	defer cancelResult()
+	result, err := runtime.Execute(resultCtx, `42`)
+	if err != nil {
+		t.Fatalf("Execute after timeout failed: %v", err)
+	}
+	if result != int64(42) {
+		t.Errorf("Expected 42, got %v (%T)", result, result)
+	}
+}
+
+// TestRubyExecuteWithChanges tests that the globals, constants and
+// methods code defines are reported with its result
+func TestRubyExecuteWithChanges(t *testing.T) {
+	runtime := ruby.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "ruby",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `
+$tracked_answer = 42
+TRACKED_LIMIT = 10
+
+def tracked_greet(name)
+  "Hello #{name}"
+end
+`
+	_, changes, err := runtime.ExecuteWithChanges(ctx, code)
+	if err != nil {
+		t.Fatalf("ExecuteWithChanges failed: %v", err)
+	}
+
+	types := make(map[string]string)
+	for _, name := range changes.Defined {
+		types[name.Name] = name.Type
+	}
+	if types["$tracked_answer"] != "Integer" || types["TRACKED_LIMIT"] != "Integer" || types["tracked_greet"] != "Method" {
+		t.Errorf("Expected the global, constant and method to be reported, got %v", changes.Defined)
+	}
+}
@@ -1,9 +1,13 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/griffincancode/polyglot.js/core"
 	"github.com/griffincancode/polyglot.js/runtimes/rust"
@@ -682,3 +686,227 @@ func TestRustPatternMatching(t *testing.T) {
 		t.Logf("Result: %v", result)
 	}
 }
+
+// TestRustInvalidUTF8Output tests output that is not valid UTF-8
+func TestRustInvalidUTF8Output(t *testing.T) {
+	code := `use std::io::Write;
+fn main() {
+    std::io::stdout().write_all(&[0x6f, 0x6b, 0xff, 0xfe, 0x00, 0x21]).unwrap();
+}`
+	expected := []byte{0x6f, 0x6b, 0xff, 0xfe, 0x00, 0x21}
+
+	for _, encoding := range []core.OutputEncoding{core.OutputRaw, core.OutputReplace} {
+		t.Run(string(encoding), func(t *testing.T) {
+			runtime := rust.NewRuntime()
+			ctx := context.Background()
+
+			config := core.RuntimeConfig{
+				Name:           "rust",
+				Enabled:        true,
+				MaxConcurrency: 1,
+				Timeout:        30 * time.Second,
+				OutputEncoding: encoding,
+			}
+			if err := runtime.Initialize(ctx, config); err != nil {
+				t.Skipf("Rust runtime not available: %v", err)
+			}
+			defer runtime.Shutdown(ctx)
+
+			result, err := runtime.Execute(ctx, code)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+
+			if encoding == core.OutputRaw {
+				raw, ok := result.(core.RawOutput)
+				if !ok {
+					t.Fatalf("Expected core.RawOutput, got %T", result)
+				}
+				if !bytes.Equal(raw, expected) {
+					t.Errorf("Expected bytes %x, got %x", expected, []byte(raw))
+				}
+				return
+			}
+
+			text, ok := result.(string)
+			if !ok || !utf8.ValidString(text) || text != "ok�\x00!" {
+				t.Errorf("Expected replaced text, got %q (%T)", result, result)
+			}
+		})
+	}
+}
+
+// TestRustCompileCache tests reuse of binaries across cosmetic edits
+func TestRustCompileCache(t *testing.T) {
+	runtime := rust.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "rust",
+		Enabled:        true,
+		MaxConcurrency: 2,
+		Timeout:        30 * time.Second,
+	}
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Skipf("Rust runtime not available: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	programs := []struct {
+		code   string
+		output string
+		hit    bool
+	}{
+		{"fn main() {\n    println!(\"{}\", 6 * 7);\n}", "42", false},
+		{"fn main() { // answer\n        println!(\"{}\", 6 * 7); /* inline */\n}\n", "42", true},
+		{"fn main() {\n    println!(\"{}\", 6 * 8);\n}", "48", false},
+		{"fn main() {\n    println!(\"{}  \", 6 * 8);\n}", "48", false},
+	}
+	for i, p := range programs {
+		before := runtime.CompileCacheStats()
+		result, err := runtime.Execute(ctx, p.code)
+		if err != nil {
+			t.Fatalf("program %d failed: %v", i, err)
+		}
+		if result != p.output {
+			t.Errorf("program %d: expected %q, got %v", i, p.output, result)
+		}
+		hit := runtime.CompileCacheStats().Hits > before.Hits
+		if hit != p.hit {
+			t.Errorf("program %d: expected cache hit %v, got %v", i, p.hit, hit)
+		}
+	}
+	if stats := runtime.CompileCacheStats(); stats.Entries != 3 {
+		t.Errorf("expected 3 cached binaries, got %+v", stats)
+	}
+}
+
+// TestRustPrecompile tests snippets precompiled through the orchestrator
+// run from the compile cache without compiling again
+func TestRustPrecompile(t *testing.T) {
+	ctx := context.Background()
+	config := core.DefaultConfig()
+	config.EnableRuntime("rust", "")
+	config.Languages["rust"].MaxConcurrency = 2
+	config.Languages["rust"].Timeout = 30 * time.Second
+
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	runtime := rust.NewRuntime()
+	orch.RegisterRuntime(runtime)
+	if err := orch.Initialize(ctx); err != nil {
+		t.Skipf("Rust runtime not available: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	snippets := []string{"6 * 7", "fn main() {\n    println!(\"{}\", 6 * 8);\n}"}
+	if err := orch.Precompile(ctx, "rust", snippets); err != nil {
+		t.Fatalf("Precompile failed: %v", err)
+	}
+	precompiled := runtime.CompileCacheStats()
+	if precompiled.Entries != 2 {
+		t.Fatalf("expected 2 cached binaries after precompiling, got %+v", precompiled)
+	}
+
+	for i, want := range []string{"42", "48"} {
+		result, err := orch.Execute(ctx, "rust", snippets[i])
+		if err != nil {
+			t.Fatalf("snippet %d failed: %v", i, err)
+		}
+		if result != want {
+			t.Errorf("snippet %d: expected %q, got %v", i, want, result)
+		}
+	}
+	stats := runtime.CompileCacheStats()
+	if stats.Hits != precompiled.Hits+2 || stats.Misses != precompiled.Misses {
+		t.Errorf("expected only cache hits after precompiling, went from %+v to %+v", precompiled, stats)
+	}
+
+	if err := orch.Precompile(ctx, "rust", []string{"fn main() { let x: i32 = \"no\"; }"}); err == nil {
+		t.Error("expected a snippet that fails to compile to fail Precompile")
+	}
+}
+
+// TestRustExecuteWithInput tests that a program reads piped input to EOF
+func TestRustExecuteWithInput(t *testing.T) {
+	ctx := context.Background()
+	config := core.DefaultConfig()
+	config.EnableRuntime("rust", "")
+	config.Languages["rust"].Timeout = 30 * time.Second
+
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	orch.RegisterRuntime(rust.NewRuntime())
+	if err := orch.Initialize(ctx); err != nil {
+		t.Skipf("Rust runtime not available: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	code := `use std::io::Read;
+
+fn main() {
+    let mut input = String::new();
+    std::io::stdin().read_to_string(&mut input).unwrap();
+    print!("{}", input);
+}`
+	input := "first line\nsecond line"
+	result, err := orch.ExecuteWithInput(ctx, "rust", code, bytes.NewBufferString(input))
+	if err != nil {
+		t.Fatalf("ExecuteWithInput failed: %v", err)
+	}
+	if result != input {
+		t.Errorf("expected input echoed back as %q, got %v", input, result)
+	}
+
+	result, err = orch.ExecuteWithInput(ctx, "rust", code, nil)
+	if err != nil {
+		t.Fatalf("ExecuteWithInput without input failed: %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected no output without input, got %v", result)
+	}
+}
+
+// TestRustStructuredOutput tests delimited JSON output is returned decoded
+func TestRustStructuredOutput(t *testing.T) {
+	ctx := context.Background()
+	config := core.DefaultConfig()
+	config.EnableRuntime("rust", "")
+	config.Languages["rust"].Timeout = 30 * time.Second
+	config.Languages["rust"].StructuredOutput = true
+
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	orch.RegisterRuntime(rust.NewRuntime())
+	if err := orch.Initialize(ctx); err != nil {
+		t.Skipf("Rust runtime not available: %v", err)
+	}
+	defer orch.Shutdown(ctx)
+
+	code := fmt.Sprintf(`fn main() {
+    println!("computing totals");
+    let total: i64 = (1..=10).sum();
+    println!("%s{{\"total\": {}, \"mean\": {}, \"label\": \"sum\"}}%s", total, total as f64 / 10.0);
+    println!("finished");
+}`, core.ResultStart, core.ResultEnd)
+	result, err := orch.Execute(ctx, "rust", code)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	expected := map[string]interface{}{"total": int64(55), "mean": 5.5, "label": "sum"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %#v", expected, result)
+	}
+
+	// Programs that print no result return their output as usual
+	result, err = orch.Execute(ctx, "rust", `println!("plain");`)
+	if err != nil || result != "plain" {
+		t.Errorf("expected plain output, got %v, %v", result, err)
+	}
+}
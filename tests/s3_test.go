@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/cloud"
+)
+
+// mockS3 is a minimal path-style S3 server holding one bucket in memory
+type mockS3 struct {
+	t       *testing.T
+	bucket  string
+	mu      sync.Mutex
+	objects map[string]mockObject
+}
+
+type mockObject struct {
+	data   []byte
+	header http.Header
+}
+
+func newMockS3(t *testing.T, bucket string) *httptest.Server {
+	m := &mockS3{t: t, bucket: bucket, objects: make(map[string]mockObject)}
+	return httptest.NewServer(m)
+}
+
+func (m *mockS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-access/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>SignatureDoesNotMatch</Code><Message>bad signature</Message></Error>`)
+		return
+	}
+	for name := range r.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") && !strings.Contains(auth, lower) {
+			m.t.Errorf("header %s is not signed", lower)
+		}
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == m.bucket && r.Method == http.MethodGet {
+		m.list(w, r.URL.Query())
+		return
+	}
+	key := strings.TrimPrefix(path, m.bucket+"/")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obj, ok := m.objects[key]
+	switch r.Method {
+	case http.MethodPut:
+		header := http.Header{}
+		for name, values := range r.Header {
+			if name == "Content-Type" || strings.HasPrefix(name, "X-Amz-Meta-") {
+				header[name] = values
+			}
+		}
+		m.objects[key] = mockObject{data: body, header: header}
+	case http.MethodGet, http.MethodHead:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for name, values := range obj.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.data)))
+		w.Header().Set("ETag", `"etag-`+key+`"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(m.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// list serves ListObjectsV2, using the last key returned as the
+// continuation token
+func (m *mockS3) list(w http.ResponseWriter, query url.Values) {
+	type content struct {
+		Key          string
+		Size         int
+		ETag         string
+		LastModified time.Time
+	}
+	var result struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+		Contents              []content
+	}
+
+	prefix, token := query.Get("prefix"), query.Get("continuation-token")
+	m.mu.Lock()
+	for key, obj := range m.objects {
+		if strings.HasPrefix(key, prefix) && key > token {
+			result.Contents = append(result.Contents, content{Key: key, Size: len(obj.data), ETag: `"etag"`, LastModified: time.Now().UTC()})
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	if limit, err := strconv.Atoi(query.Get("max-keys")); err == nil && limit < len(result.Contents) {
+		result.Contents = result.Contents[:limit]
+		result.IsTruncated = true
+		result.NextContinuationToken = result.Contents[limit-1].Key
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+func newTestS3Storage(t *testing.T) *cloud.S3Storage {
+	t.Helper()
+	server := newMockS3(t, "artifacts")
+	t.Cleanup(server.Close)
+
+	storage, err := cloud.NewS3Storage(cloud.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "artifacts",
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	return storage
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestS3Storage(t)
+
+	data := []byte("release binary")
+	metadata := map[string]string{
+		cloud.ContentTypeKey: "application/x-executable",
+		"version":            "1.2.0",
+		"toolchain.go":       "go1.21",
+	}
+	if err := storage.Put(ctx, "releases/app 1.2.0.bin", data, metadata); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	storage.Put(ctx, "releases/app-1.1.0.bin", []byte("old"), nil)
+	storage.Put(ctx, "logs/build.log", []byte("log"), nil)
+
+	got, err := storage.Get(ctx, "releases/app 1.2.0.bin")
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("get returned %q, %v", got, err)
+	}
+
+	obj, err := storage.GetMetadata(ctx, "releases/app 1.2.0.bin")
+	if err != nil {
+		t.Fatalf("get metadata failed: %v", err)
+	}
+	if obj.ContentType != "application/x-executable" || obj.Size != int64(len(data)) {
+		t.Errorf("unexpected object %+v", obj)
+	}
+	if obj.Metadata["version"] != "1.2.0" || obj.Metadata["toolchain.go"] != "go1.21" || len(obj.Metadata) != 2 {
+		t.Errorf("metadata did not round-trip: %v", obj.Metadata)
+	}
+
+	listing, err := storage.List(ctx, "releases/", cloud.ListOptions{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if objects := listing.Objects; len(objects) != 2 || objects[0].Key != "releases/app 1.2.0.bin" || objects[1].Key != "releases/app-1.1.0.bin" {
+		t.Errorf("unexpected listing %+v", listing.Objects)
+	}
+
+	if err := storage.Delete(ctx, "releases/app 1.2.0.bin"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := storage.Get(ctx, "releases/app 1.2.0.bin"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
+
+func TestS3StorageList(t *testing.T) {
+	testStorageList(t, newTestS3Storage(t))
+}
+
+func TestS3StorageProvenance(t *testing.T) {
+	ctx := context.Background()
+	storage := newTestS3Storage(t)
+
+	result, err := cloud.NewMemoryBuilder().Build(ctx, &cloud.BuildRequest{
+		ProjectID: "test-project",
+		Source:    []byte("source"),
+		Languages: []string{"python"},
+		SBOM:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cloud.StoreArtifact(ctx, storage, "builds/app", result); err != nil {
+		t.Fatalf("failed to store artifact: %v", err)
+	}
+	_, provenance, err := cloud.LoadArtifact(ctx, storage, "builds/app")
+	if err != nil {
+		t.Fatalf("failed to load artifact: %v", err)
+	}
+	if provenance.Checksum != result.Provenance.Checksum || len(provenance.SBOM) != 1 {
+		t.Errorf("provenance did not round-trip: %+v", provenance)
+	}
+}
+
+func TestS3StorageConfig(t *testing.T) {
+	if _, err := cloud.NewS3Storage(cloud.S3Config{Endpoint: "localhost:9000", Bucket: "b", AccessKey: "a", SecretKey: "s"}); err == nil {
+		t.Error("expected error for endpoint without scheme")
+	}
+	if _, err := cloud.NewS3Storage(cloud.S3Config{Endpoint: "http://localhost:9000", AccessKey: "a", SecretKey: "s"}); err == nil {
+		t.Error("expected error without bucket")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/griffincancode/polyglot.js/core"
 	"github.com/griffincancode/polyglot.js/signing"
 )
 
@@ -266,3 +267,59 @@ func TestCertificateExpiry(t *testing.T) {
 		t.Error("certificate should be expired")
 	}
 }
+
+// keyRecordingSigner records the private key it is asked to sign with
+type keyRecordingSigner struct {
+	key []byte
+}
+
+func (s *keyRecordingSigner) Platform() string { return "test" }
+func (s *keyRecordingSigner) Supported() bool  { return true }
+
+func (s *keyRecordingSigner) Sign(ctx context.Context, req *signing.SignRequest) (*signing.SignResult, error) {
+	s.key = req.Certificate.PrivateKey
+	return &signing.SignResult{SignedBinary: req.Binary, Certificate: req.Certificate}, nil
+}
+
+func (s *keyRecordingSigner) Verify(ctx context.Context, req *signing.VerifyRequest) (*signing.VerifyResult, error) {
+	return &signing.VerifyResult{Valid: true}, nil
+}
+
+func TestSignerPrivateKeyFromSecretStore(t *testing.T) {
+	ctx := context.Background()
+	signer := signing.NewSigner()
+	platform := &keyRecordingSigner{}
+	signer.RegisterPlatformSigner(platform)
+
+	cert := &signing.Certificate{
+		ID:            "stored-key-cert",
+		Type:          "linux",
+		PrivateKeyRef: "signing.release",
+	}
+
+	// The referenced key must be available
+	if err := signer.ImportCertificate(ctx, cert); err == nil {
+		t.Fatal("expected error importing certificate without a secret store")
+	}
+
+	secrets := core.NewMemorySecretStore()
+	signer.SetSecretStore(secrets)
+	if err := signer.ImportCertificate(ctx, cert); err == nil {
+		t.Fatal("expected error importing certificate with a missing key")
+	}
+
+	secrets.Set("signing.release", []byte("private-key"))
+	if err := signer.ImportCertificate(ctx, cert); err != nil {
+		t.Fatalf("failed to import certificate: %v", err)
+	}
+
+	if _, err := signer.Sign(ctx, &signing.SignRequest{Binary: []byte("binary"), Platform: "test", Certificate: cert}); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if string(platform.key) != "private-key" {
+		t.Errorf("expected signing with the stored key, got %q", platform.key)
+	}
+	if len(cert.PrivateKey) != 0 {
+		t.Error("expected the stored key not to be copied into the certificate")
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// tokenKinds strips positions so tokens compare by type and value
+func tokenKinds(tokens []core.Token) []core.Token {
+	kinds := make([]core.Token, len(tokens))
+	for i, tok := range tokens {
+		kinds[i] = core.Token{Type: tok.Type, Value: tok.Value}
+	}
+	return kinds
+}
+
+func tok(typ core.TokenType, value string) core.Token {
+	return core.Token{Type: typ, Value: value}
+}
+
+func TestTokenizePython(t *testing.T) {
+	code := "def greet(name):  # say hi\n    return f\"hi {name}\" * 2.5e-1\n\"\"\"doc\nstring\"\"\""
+
+	tokens, err := core.TokenizeCode("python", code)
+	if err != nil {
+		t.Fatalf("tokenize failed: %v", err)
+	}
+
+	want := []core.Token{
+		tok(core.TokenKeyword, "def"),
+		tok(core.TokenIdentifier, "greet"),
+		tok(core.TokenOperator, "("),
+		tok(core.TokenIdentifier, "name"),
+		tok(core.TokenOperator, ")"),
+		tok(core.TokenOperator, ":"),
+		tok(core.TokenComment, "# say hi"),
+		tok(core.TokenKeyword, "return"),
+		tok(core.TokenString, `f"hi {name}"`),
+		tok(core.TokenOperator, "*"),
+		tok(core.TokenNumber, "2.5e-1"),
+		tok(core.TokenString, "\"\"\"doc\nstring\"\"\""),
+	}
+	if got := tokenKinds(tokens); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected tokens:\n got %v\nwant %v", got, want)
+	}
+
+	// Positions: "return" starts line 2, column 5; the docstring line 3
+	if ret := tokens[7]; ret.Line != 2 || ret.Column != 5 || code[ret.Offset:ret.Offset+len(ret.Value)] != ret.Value {
+		t.Errorf("unexpected position for %+v", ret)
+	}
+	if doc := tokens[11]; doc.Line != 3 || doc.Column != 1 {
+		t.Errorf("unexpected position for %+v", doc)
+	}
+}
+
+func TestTokenizeLua(t *testing.T) {
+	code := "local s = 'a\\'b' .. [[long]]\n--[[ block\ncomment ]] for i = 1, 0x1F do end -- tail"
+
+	tokens, err := core.TokenizeCode("lua", code)
+	if err != nil {
+		t.Fatalf("tokenize failed: %v", err)
+	}
+
+	want := []core.Token{
+		tok(core.TokenKeyword, "local"),
+		tok(core.TokenIdentifier, "s"),
+		tok(core.TokenOperator, "="),
+		tok(core.TokenString, `'a\'b'`),
+		tok(core.TokenOperator, "."),
+		tok(core.TokenOperator, "."),
+		tok(core.TokenString, "[[long]]"),
+		tok(core.TokenComment, "--[[ block\ncomment ]]"),
+		tok(core.TokenKeyword, "for"),
+		tok(core.TokenIdentifier, "i"),
+		tok(core.TokenOperator, "="),
+		tok(core.TokenNumber, "1"),
+		tok(core.TokenOperator, ","),
+		tok(core.TokenNumber, "0x1F"),
+		tok(core.TokenKeyword, "do"),
+		tok(core.TokenKeyword, "end"),
+		tok(core.TokenComment, "-- tail"),
+	}
+	if got := tokenKinds(tokens); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected tokens:\n got %v\nwant %v", got, want)
+	}
+
+	if forTok := tokens[8]; forTok.Line != 3 || forTok.Column != 12 {
+		t.Errorf("unexpected position for %+v", forTok)
+	}
+}
+
+func TestTokenizeUnsupportedLanguage(t *testing.T) {
+	if _, err := core.TokenizeCode("cobol", "DISPLAY 'HI'."); err == nil {
+		t.Error("expected error for unsupported language")
+	}
+}
+
+// tokenizingRuntime tokenizes code itself
+type tokenizingRuntime struct {
+	core.Runtime
+}
+
+func (tokenizingRuntime) Tokenize(code string) ([]core.Token, error) {
+	return []core.Token{tok(core.TokenIdentifier, code)}, nil
+}
+
+func TestTokenizePrefersRuntimeTokenizer(t *testing.T) {
+	tokens, err := core.Tokenize(tokenizingRuntime{}, "if")
+	if err != nil || len(tokens) != 1 || tokens[0].Type != core.TokenIdentifier {
+		t.Errorf("expected runtime tokenizer to be used, got %v, %v", tokens, err)
+	}
+}
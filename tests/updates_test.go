@@ -1,8 +1,17 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -289,3 +298,243 @@ func TestVersionComparison(t *testing.T) {
 		}
 	}
 }
+
+const feedManifest = `{"releases": [
+	{"version": {"major": 1, "minor": 5, "patch": 0}, "channel": "stable", "platform": "linux", "arch": "amd64", "url": "https://example.com/linux-1.5.0"},
+	{"version": {"major": 2, "minor": 0, "patch": 0}, "channel": "stable", "platform": "darwin", "arch": "arm64", "url": "https://example.com/darwin-2.0.0"},
+	{"version": {"major": 2, "minor": 1, "patch": 0}, "channel": "beta", "platform": "linux", "url": "https://example.com/linux-2.1.0"},
+	{"version": {"major": 9, "minor": 0, "patch": 0}, "channel": "stable", "platform": "linux"}
+]}`
+
+func TestHTTPFeed(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, feedManifest)
+	}))
+	defer server.Close()
+
+	feed := updates.NewHTTPFeed(server.URL)
+	feed.Platform, feed.Arch = "linux", "amd64"
+
+	releases, err := feed.Releases(context.Background())
+	if err != nil {
+		t.Fatalf("failed to fetch releases: %v", err)
+	}
+
+	// The darwin release is filtered out and the release without a URL dropped
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 linux releases, got %d", len(releases))
+	}
+	for _, release := range releases {
+		if release.Platform != "linux" {
+			t.Errorf("unexpected platform %s", release.Platform)
+		}
+	}
+
+	// A second fetch revalidates with the ETag and reuses the cached manifest
+	releases, err = feed.Releases(context.Background())
+	if err != nil || len(releases) != 2 {
+		t.Fatalf("expected cached releases, got %d: %v", len(releases), err)
+	}
+	if notModified != 1 {
+		t.Errorf("expected a conditional request, got %d not-modified responses", notModified)
+	}
+
+	// Within MaxAge the cache is used without a request
+	feed.MaxAge = time.Minute
+	feed.Releases(context.Background())
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestHTTPFeedMalformedManifest(t *testing.T) {
+	for _, body := range []string{`not json`, `{"releases": "nope"}`, `{}`} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		}))
+
+		if _, err := updates.NewHTTPFeed(server.URL).Releases(context.Background()); err == nil {
+			t.Errorf("expected error for manifest %q", body)
+		}
+		server.Close()
+	}
+}
+
+func TestUpdateManagerFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, feedManifest)
+	}))
+	defer server.Close()
+
+	feed := updates.NewHTTPFeed(server.URL)
+	feed.Platform, feed.Arch = "linux", "amd64"
+
+	manager := updates.NewManager(updates.NewDiffer(), updates.NewDownloader(), updates.NewVerifier())
+	manager.SetFeed(feed)
+
+	update, err := manager.Check(context.Background(), updates.Version{Major: 1}, "stable")
+	if err != nil {
+		t.Fatalf("failed to check for updates: %v", err)
+	}
+	if update == nil || update.Available.Minor != 5 || update.Release.URL != "https://example.com/linux-1.5.0" {
+		t.Fatalf("expected linux 1.5.0, got %+v", update)
+	}
+
+	update, err = manager.Check(context.Background(), updates.Version{Major: 2}, "beta")
+	if err != nil || update == nil || update.Available.Minor != 1 {
+		t.Fatalf("expected beta 2.1.0, got %+v: %v", update, err)
+	}
+}
+
+// interruptingServer serves payload with range support, aborting the first
+// response halfway through
+func interruptingServer(t *testing.T, payload []byte, ranges *[]string) *httptest.Server {
+	t.Helper()
+	first := true
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		if first {
+			first = false
+			w.Header().Set("Content-Length", fmt.Sprint(len(payload)))
+			w.Write(payload[:len(payload)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "app.bin", time.Time{}, bytes.NewReader(payload))
+	}))
+}
+
+func TestResumableDownload(t *testing.T) {
+	payload := make([]byte, 256*1024)
+	rand.New(rand.NewSource(1)).Read(payload)
+	sum := sha256.Sum256(payload)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	var ranges []string
+	server := interruptingServer(t, payload, &ranges)
+	defer server.Close()
+
+	ctx := context.Background()
+	downloader := updates.NewResumableDownloader(t.TempDir())
+
+	if _, err := downloader.Download(ctx, server.URL, nil); err == nil {
+		t.Fatal("expected the first download to be interrupted")
+	}
+
+	progress := make(chan *updates.DownloadProgress, 100)
+	data, err := downloader.Download(ctx, server.URL, progress)
+	if err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != fmt.Sprintf("bytes=%d-", len(payload)/2) {
+		t.Errorf("expected the second request to resume at the midpoint, got ranges %q", ranges)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Fatalf("resumed download does not match payload (%d bytes)", len(data))
+	}
+	if err := downloader.Verify(ctx, data, checksum); err != nil {
+		t.Errorf("checksum of the combined download failed: %v", err)
+	}
+
+	var last *updates.DownloadProgress
+	for p := range progress {
+		last = p
+	}
+	if last == nil || last.BytesDownloaded != int64(len(payload)) || last.Percentage != 100 {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+}
+
+func TestResumableDownloadThroughManager(t *testing.T) {
+	payload := bytes.Repeat([]byte("polyglot"), 8192)
+	sum := sha256.Sum256(payload)
+
+	var ranges []string
+	server := interruptingServer(t, payload, &ranges)
+	defer server.Close()
+
+	manager := updates.NewManager(updates.NewDiffer(), updates.NewResumableDownloader(t.TempDir()), updates.NewVerifier())
+	update := &updates.Update{Release: &updates.Release{
+		URL:       server.URL,
+		Checksum:  "sha256:" + hex.EncodeToString(sum[:]),
+		Signature: []byte("signature"),
+	}}
+
+	if _, err := manager.Download(context.Background(), update, nil); err == nil {
+		t.Fatal("expected the first download to be interrupted")
+	}
+	data, err := manager.Download(context.Background(), update, nil)
+	if err != nil {
+		t.Fatalf("resumed download failed verification: %v", err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Error("resumed download does not match payload")
+	}
+
+	// A wrong digest is rejected
+	update.Release.Checksum = "sha256:" + hex.EncodeToString(make([]byte, 32))
+	if _, err := manager.Download(context.Background(), update, nil); err == nil {
+		t.Error("expected checksum mismatch")
+	}
+}
+
+// selfTestBinary is a script whose selftest exits with the given code
+func selfTestBinary(version string, exitCode int) []byte {
+	return []byte(fmt.Sprintf("#!/bin/sh\n# %s\n[ \"$1\" = \"--selftest\" ] || exit 2\nexit %d\n", version, exitCode))
+}
+
+func TestRollbackSelfTest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("selftest fixtures are shell scripts")
+	}
+
+	tests := []struct {
+		name     string
+		exitCode int
+		wantErr  bool
+		want     string
+	}{
+		{"passes", 0, false, "1.0.0"},
+		{"fails", 1, true, "2.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "app")
+			if err := os.WriteFile(path, selfTestBinary("2.0.0", 0), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			manager := updates.NewManager(updates.NewDiffer(), updates.NewDownloader(), updates.NewVerifier())
+			manager.SetRollbackOptions(updates.RollbackOptions{BinaryPath: path, SelfTest: true})
+
+			checkpoint, err := manager.CreateCheckpoint(ctx, updates.Version{Major: 1}, selfTestBinary("1.0.0", tt.exitCode))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = manager.Rollback(ctx, checkpoint.ID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rollback error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			installed, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(installed, []byte("# "+tt.want)) {
+				t.Errorf("expected version %s installed, got %q", tt.want, installed)
+			}
+		})
+	}
+}
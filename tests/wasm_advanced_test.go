@@ -6,6 +6,12 @@ package tests
 import (
 	"context"
 	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -286,6 +292,36 @@ func TestWASMPoolBehavior(t *testing.T) {
 	t.Logf("Errors: %d/%d", errorCount, numWorkers)
 }
 
+// TestWASMReconfigure tests that the pool follows MaxConcurrency
+func TestWASMReconfigure(t *testing.T) {
+	runtime := wasm.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "wasm",
+		Enabled:        true,
+		MaxConcurrency: 3,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	if stats := runtime.PoolStats(); stats.Workers != 3 {
+		t.Fatalf("Expected 3 workers, got %+v", stats)
+	}
+
+	config.MaxConcurrency = 6
+	if err := runtime.Reconfigure(ctx, config); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	if stats := runtime.PoolStats(); stats.Workers != 6 {
+		t.Errorf("Expected 6 workers after reconfiguring, got %+v", stats)
+	}
+}
+
 // TestWASMModuleLoading tests loading various WASM modules
 func TestWASMModuleLoading(t *testing.T) {
 	runtime := wasm.NewRuntime()
@@ -347,6 +383,268 @@ func TestWASMModuleLoading(t *testing.T) {
 	}
 }
 
+// TestWASMModuleFromFileAndURL tests loading modules from a .wasm file and
+// from an HTTP server
+func TestWASMModuleFromFileAndURL(t *testing.T) {
+	runtime := wasm.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "wasm",
+		Enabled:        true,
+		MaxConcurrency: 2,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	module := []byte{
+		0x00, 0x61, 0x73, 0x6D, // magic
+		0x01, 0x00, 0x00, 0x00, // version
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "module.wasm")
+	if err := os.WriteFile(path, module, 0644); err != nil {
+		t.Fatalf("Failed to write module: %v", err)
+	}
+	if err := runtime.LoadModuleFromFile(ctx, path); err != nil {
+		t.Errorf("LoadModuleFromFile failed: %v", err)
+	}
+	if err := runtime.LoadModuleFromFile(ctx, path); err != nil {
+		t.Errorf("Reloading a cached module failed: %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.wasm")
+	os.WriteFile(invalid, []byte("not a wasm module"), 0644)
+	if err := runtime.LoadModuleFromFile(ctx, invalid); err == nil {
+		t.Error("Expected error for a file without the WASM magic bytes")
+	}
+	if err := runtime.LoadModuleFromFile(ctx, filepath.Join(dir, "missing.wasm")); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/module.wasm":
+			w.Header().Set("Content-Type", "application/wasm")
+			w.Write(module)
+		case "/slow.wasm":
+			time.Sleep(200 * time.Millisecond)
+			w.Write(module)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	if err := runtime.LoadModuleFromURL(ctx, server.URL+"/module.wasm"); err != nil {
+		t.Errorf("LoadModuleFromURL failed: %v", err)
+	}
+	if err := runtime.LoadModuleFromURL(ctx, server.URL+"/missing.wasm"); err == nil {
+		t.Error("Expected error for a missing module")
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := runtime.LoadModuleFromURL(timeoutCtx, server.URL+"/slow.wasm"); err == nil {
+		t.Error("Expected error when the fetch times out")
+	}
+}
+
+// countingModule exports _start(n i32) i32, which counts up to n in a loop
+func countingModule() []byte {
+	body := []byte{
+		0x01, 0x01, 0x7F, // one i32 local, the counter
+		0x02, 0x40, // block
+		0x03, 0x40, // loop
+		0x20, 0x01, 0x20, 0x00, 0x4E, // counter >= n
+		0x0D, 0x01, // br_if 1
+		0x20, 0x01, 0x41, 0x01, 0x6A, 0x21, 0x01, // counter++
+		0x0C, 0x00, // br 0
+		0x0B,       // end loop
+		0x0B,       // end block
+		0x20, 0x01, // counter
+		0x0B, // end
+	}
+
+	module := []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
+	section := func(id byte, payload ...byte) {
+		module = append(module, id, byte(len(payload)))
+		module = append(module, payload...)
+	}
+	section(0x01, 0x01, 0x60, 0x01, 0x7F, 0x01, 0x7F)                   // type (i32) -> i32
+	section(0x03, 0x01, 0x00)                                           // one function of type 0
+	section(0x07, 0x01, 0x06, '_', 's', 't', 'a', 'r', 't', 0x00, 0x00) // export _start
+	section(0x0A, append([]byte{0x01, byte(len(body))}, body...)...)    // code
+	return module
+}
+
+// TestWASMInterpreterFuel tests that fuel bounds the instructions a call executes
+func TestWASMInterpreterFuel(t *testing.T) {
+	runtime := wasm.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:                "wasm",
+		Enabled:             true,
+		MaxConcurrency:      1,
+		Timeout:             5 * time.Second,
+		WASMInterpreterFuel: 10000,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	module := string(countingModule())
+
+	result, err := runtime.Execute(ctx, module, 100)
+	if err != nil {
+		t.Fatalf("Execute within budget failed: %v", err)
+	}
+	if result != int32(100) {
+		t.Errorf("Expected 100, got %v (%T)", result, result)
+	}
+
+	// Fuel is per call, so a second call gets a full budget
+	if _, err := runtime.Execute(ctx, module, 100); err != nil {
+		t.Errorf("Second execution failed: %v", err)
+	}
+
+	_, err = runtime.Execute(ctx, module, 1<<30)
+	if !errors.Is(err, wasm.ErrFuelExhausted) {
+		t.Errorf("Expected ErrFuelExhausted, got %v", err)
+	}
+	var coreErr *core.Error
+	if !errors.As(err, &coreErr) || coreErr.Code != core.CodeResourceExhausted {
+		t.Errorf("Expected RESOURCE_EXHAUSTED, got %v", err)
+	}
+}
+
+// importingModule is countingModule with a function import, which is
+// outside the interpreted subset
+func importingModule() []byte {
+	module := countingModule()
+	imports := []byte{0x02, 0x09, 0x01, 0x03, 'e', 'n', 'v', 0x01, 'f', 0x00, 0x00}
+	// The import section follows the type section, which ends at byte 16
+	return append(append(append([]byte{}, module[:16]...), imports...), module[16:]...)
+}
+
+// TestWASMUnmetered tests that modules outside the interpreted subset
+// load without fuel and are rejected with it
+func TestWASMUnmetered(t *testing.T) {
+	ctx := context.Background()
+	module := string(importingModule())
+
+	for _, fuel := range []uint64{0, 10000} {
+		runtime := wasm.NewRuntime()
+		config := core.RuntimeConfig{
+			Name:                "wasm",
+			Enabled:             true,
+			MaxConcurrency:      1,
+			Timeout:             5 * time.Second,
+			WASMInterpreterFuel: fuel,
+		}
+		if err := runtime.Initialize(ctx, config); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+
+		_, err := runtime.Execute(ctx, module)
+		if fuel == 0 && err != nil {
+			t.Errorf("Expected unmetered module to load, got %v", err)
+		}
+		if fuel > 0 && (err == nil || !strings.Contains(err.Error(), "imports")) {
+			t.Errorf("Expected metered module with imports to be rejected, got %v", err)
+		}
+		runtime.Shutdown(ctx)
+	}
+}
+
+// TestWASMVerifyModules tests that a loaded module whose bytes change
+// afterwards is detected and rejected
+func TestWASMVerifyModules(t *testing.T) {
+	runtime := wasm.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:              "wasm",
+		Enabled:           true,
+		MaxConcurrency:    1,
+		Timeout:           5 * time.Second,
+		WASMVerifyModules: true,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	module := countingModule()
+	code := string(module)
+	if err := runtime.LoadModule(ctx, module); err != nil {
+		t.Fatalf("LoadModule failed: %v", err)
+	}
+	if _, err := runtime.Execute(ctx, code, 10); err != nil {
+		t.Fatalf("Execute with an intact module failed: %v", err)
+	}
+
+	// Corrupt the loaded module's bytes in place
+	module[len(module)-3] ^= 0xFF
+
+	_, err := runtime.Execute(ctx, code, 10)
+	if !errors.Is(err, wasm.ErrModuleCorrupted) {
+		t.Fatalf("Expected ErrModuleCorrupted, got %v", err)
+	}
+	if code := core.ErrorInfoFor(err).Code; code != core.CodeInternal {
+		t.Errorf("Expected INTERNAL, got %s", code)
+	}
+
+	// The corrupted module is dropped, and loading it again restores it
+	if _, err := runtime.Execute(ctx, code, 10); err != nil {
+		t.Errorf("Execute after the corrupted module was dropped failed: %v", err)
+	}
+	if err := runtime.LoadModule(ctx, countingModule()); err != nil {
+		t.Fatalf("Reloading the module failed: %v", err)
+	}
+	if _, err := runtime.Call(ctx, "_start", 10); errors.Is(err, wasm.ErrModuleCorrupted) {
+		t.Errorf("Call after reloading the module failed: %v", err)
+	}
+}
+
+// TestWASMUnverifiedModules tests that modules are not checked by default
+func TestWASMUnverifiedModules(t *testing.T) {
+	runtime := wasm.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:           "wasm",
+		Enabled:        true,
+		MaxConcurrency: 1,
+		Timeout:        5 * time.Second,
+	}
+
+	if err := runtime.Initialize(ctx, config); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer runtime.Shutdown(ctx)
+
+	module := countingModule()
+	code := string(module)
+	if err := runtime.LoadModule(ctx, module); err != nil {
+		t.Fatalf("LoadModule failed: %v", err)
+	}
+	module[len(module)-3] ^= 0xFF
+
+	if _, err := runtime.Execute(ctx, code, 10); err != nil {
+		t.Errorf("Execute without verification failed: %v", err)
+	}
+}
+
 // TestWASMShutdownBehavior tests shutdown behavior
 func TestWASMShutdownBehavior(t *testing.T) {
 	runtime := wasm.NewRuntime()
@@ -0,0 +1,31 @@
+//go:build stub
+// +build stub
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+	"github.com/griffincancode/polyglot.js/webview"
+)
+
+// Test the stub backend reports its page loaded when run
+func TestWebview_StubReady(t *testing.T) {
+	wv := webview.New(core.WebviewConfig{Title: "Stub", Width: 800, Height: 600}, core.NewBridge())
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	if err := wv.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := wv.WaitReady(ctx); err != nil {
+		t.Errorf("Expected the stub page to be ready after Run, got %v", err)
+	}
+}
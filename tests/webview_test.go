@@ -1,12 +1,30 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/griffincancode/polyglot.js/core"
@@ -379,6 +397,286 @@ func TestWebview_DataURL(t *testing.T) {
 	defer wv.Terminate()
 }
 
+// Test window appearance options round-trip through the config
+func TestWebview_WindowOptions(t *testing.T) {
+	config := core.WebviewConfig{
+		Title:       "Window Options",
+		Width:       800,
+		Height:      600,
+		URL:         "data:text/html,<html><body>Test</body></html>",
+		Transparent: true,
+		Frameless:   true,
+		AlwaysOnTop: true,
+		Fullscreen:  true,
+	}
+
+	wv := webview.New(config, nil)
+	if got := wv.Config(); got != config {
+		t.Errorf("Config did not round-trip: got %+v, want %+v", got, config)
+	}
+
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize with window options failed: %v", err)
+	}
+	defer wv.Terminate()
+}
+
+// refusingBackend is a recording backend that cannot honor window
+// options or states listed in refuse
+type refusingBackend struct {
+	*recordingBackend
+	refuse  map[string]bool
+	options webview.WindowOptions
+}
+
+func (b *refusingBackend) SetWindowOptions(opts webview.WindowOptions) error {
+	b.options = opts
+	if opts.Frameless && b.refuse["frameless"] {
+		return fmt.Errorf("unsupported window options: frameless")
+	}
+	return nil
+}
+
+func (b *refusingBackend) SetWindowState(state webview.WindowState) error {
+	if b.refuse[string(state)] {
+		return fmt.Errorf("window state %s failed", state)
+	}
+	return nil
+}
+
+func (b *refusingBackend) SetUserAgent(ua string) error {
+	if ua != "" && b.refuse["useragent"] {
+		return fmt.Errorf("user agent override is not supported")
+	}
+	return nil
+}
+
+func (b *refusingBackend) SetRequestHeaders(headers map[string]string) error {
+	if len(headers) > 0 && b.refuse["headers"] {
+		return fmt.Errorf("request headers are not supported")
+	}
+	return nil
+}
+
+// useRefusingBackend installs a backend refusing the named features
+func useRefusingBackend(t *testing.T, refuse ...string) *refusingBackend {
+	backend := &refusingBackend{recordingBackend: &recordingBackend{bindings: make(map[string]interface{})}, refuse: map[string]bool{}}
+	for _, name := range refuse {
+		backend.refuse[name] = true
+	}
+	previous := webview.NewBackend
+	webview.ConfigureBackend(func(debug bool) webview.WebviewBackend { return backend })
+	t.Cleanup(func() { webview.ConfigureBackend(previous) })
+	return backend
+}
+
+// Test window options the backend cannot honor are logged, and a window
+// that fails to enter fullscreen is not reported as fullscreen
+func TestWebview_WindowOptionsRefused(t *testing.T) {
+	backend := useRefusingBackend(t, "frameless", "fullscreen")
+	logger := &testLogger{}
+
+	wv := webview.New(core.WebviewConfig{Title: "Refused", Frameless: true, AlwaysOnTop: true, Fullscreen: true}, nil)
+	wv.SetLogger(logger)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	if !backend.options.Frameless || !backend.options.AlwaysOnTop {
+		t.Errorf("Expected the options to reach the backend, got %+v", backend.options)
+	}
+	if wv.WindowState() != webview.StateNormal {
+		t.Errorf("Expected a window that did not enter fullscreen to stay normal, got %s", wv.WindowState())
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	var warnings []string
+	for _, entry := range logger.entries {
+		if entry.level == core.LogWarn {
+			warnings = append(warnings, entry.msg)
+		}
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "frameless") || !strings.Contains(warnings[1], "fullscreen") {
+		t.Errorf("Expected warnings for frameless and fullscreen, got %q", warnings)
+	}
+}
+
+// Test a native window that cannot set the user agent fails to initialize,
+// while request headers the backend refuses are skipped and not kept
+func TestWebview_OverridesRefused(t *testing.T) {
+	useRefusingBackend(t, "useragent", "headers")
+
+	wv := webview.New(core.WebviewConfig{Title: "Refused", UserAgent: "PolyglotTest/1.0"}, nil)
+	if err := wv.Initialize(); err == nil || !strings.Contains(err.Error(), "user agent") {
+		t.Fatalf("Expected Initialize to fail on the user agent, got %v", err)
+	}
+	if err := wv.Eval("1"); err == nil {
+		t.Error("Expected no window after a failed Initialize")
+	}
+
+	// Headers the backend cannot send are skipped with a warning
+	logger := &testLogger{}
+	wv = webview.New(core.WebviewConfig{Title: "Refused"}, nil)
+	wv.SetLogger(logger)
+	if err := wv.SetRequestHeaders(map[string]string{"X-Test": "1"}); err != nil {
+		t.Fatalf("SetRequestHeaders before Initialize failed: %v", err)
+	}
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Expected Initialize to skip refused request headers, got %v", err)
+	}
+	defer wv.Terminate()
+
+	logger.mu.Lock()
+	var warned bool
+	for _, entry := range logger.entries {
+		if entry.level == core.LogWarn && strings.Contains(entry.msg, "request headers") {
+			warned = true
+		}
+	}
+	logger.mu.Unlock()
+	if !warned {
+		t.Error("Expected a warning for the skipped request headers")
+	}
+
+	if err := wv.SetRequestHeaders(map[string]string{"X-Test": "1"}); err == nil {
+		t.Error("Expected refused headers to fail after Initialize")
+	}
+}
+
+// Test window state control lifecycle
+func TestWebview_WindowStateLifecycle(t *testing.T) {
+	config := core.WebviewConfig{
+		Title:  "Window State",
+		Width:  800,
+		Height: 600,
+		URL:    "data:text/html,<html><body>Test</body></html>",
+	}
+
+	wv := webview.New(config, nil)
+
+	// State changes before initialize should fail
+	if err := wv.Minimize(); err == nil {
+		t.Error("Expected error before initialize, got nil")
+	}
+
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if wv.WindowState() != webview.StateNormal {
+		t.Errorf("Expected initial state normal, got %s", wv.WindowState())
+	}
+
+	wv.Terminate()
+
+	// State changes after terminate should fail
+	if err := wv.Maximize(); err == nil {
+		t.Error("Expected error after terminate, got nil")
+	}
+}
+
+// Test window state transitions through the stub backend
+func TestWebview_WindowStateTransitions(t *testing.T) {
+	config := core.WebviewConfig{
+		Title:  "Window Transitions",
+		Width:  800,
+		Height: 600,
+		URL:    "data:text/html,<html><body>Test</body></html>",
+	}
+
+	wv := webview.New(config, nil)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	steps := []struct {
+		action func() error
+		want   webview.WindowState
+	}{
+		{wv.Minimize, webview.StateMinimized},
+		{wv.Restore, webview.StateNormal},
+		{wv.Maximize, webview.StateMaximized},
+		{func() error { return wv.Fullscreen(true) }, webview.StateFullscreen},
+		{func() error { return wv.Fullscreen(false) }, webview.StateNormal},
+	}
+
+	for i, step := range steps {
+		if err := step.action(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		if got := wv.WindowState(); got != step.want {
+			t.Errorf("Step %d: expected state %s, got %s", i, step.want, got)
+		}
+	}
+}
+
+// Test the window state is only recorded once the backend has applied it
+func TestWebview_WindowStateRefused(t *testing.T) {
+	useRefusingBackend(t, "minimized")
+
+	wv := webview.New(core.WebviewConfig{Title: "Refused"}, nil)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	if err := wv.Maximize(); err != nil {
+		t.Fatalf("Maximize failed: %v", err)
+	}
+	if err := wv.Minimize(); err == nil {
+		t.Error("Expected Minimize to report the backend's failure")
+	}
+	if wv.WindowState() != webview.StateMaximized {
+		t.Errorf("Expected the state to stay maximized, got %s", wv.WindowState())
+	}
+}
+
+// Test user agent and request header configuration
+func TestWebview_UserAgentAndHeaders(t *testing.T) {
+	config := core.WebviewConfig{
+		Title:     "User Agent",
+		Width:     800,
+		Height:    600,
+		URL:       "data:text/html,<html><body>Test</body></html>",
+		UserAgent: "PolyglotTest/1.0",
+	}
+
+	wv := webview.New(config, nil)
+
+	err := wv.SetRequestHeaders(map[string]string{"Authorization": "Bearer token"})
+	if err != nil {
+		t.Fatalf("SetRequestHeaders failed: %v", err)
+	}
+
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	if wv.UserAgent() != "PolyglotTest/1.0" {
+		t.Errorf("Expected user agent 'PolyglotTest/1.0', got '%s'", wv.UserAgent())
+	}
+
+	if got := wv.RequestHeaders()["Authorization"]; got != "Bearer token" {
+		t.Errorf("Expected Authorization header to be retained, got '%s'", got)
+	}
+
+	invalid := []map[string]string{
+		{"": "value"},
+		{"Bad Header": "value"},
+		{"X-Inject": "value\r\nX-Other: evil"},
+	}
+
+	for _, headers := range invalid {
+		if err := wv.SetRequestHeaders(headers); err == nil {
+			t.Errorf("Expected error for headers %q, got nil", headers)
+		}
+	}
+}
+
 // Test JSON serialization in bridge
 func TestWebview_JSONSerialization(t *testing.T) {
 	bridge := core.NewBridge()
@@ -464,6 +762,47 @@ func BenchmarkWebview_BridgeCalls(b *testing.B) {
 	}
 }
 
+// BenchmarkWebview_BridgeCallsParallel measures call dispatch from many
+// goroutines, which reads the bridge's dispatch table without locking
+func BenchmarkWebview_BridgeCallsParallel(b *testing.B) {
+	bridge := core.NewBridge()
+	for i := 0; i < 64; i++ {
+		bridge.Register(fmt.Sprintf("fn%d", i), func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			return "result", nil
+		})
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = bridge.Call(nil, "fn7")
+		}
+	})
+}
+
+// BenchmarkWebview_MutexMapCallsParallel is the baseline for
+// BenchmarkWebview_BridgeCallsParallel: dispatch through a map guarded by
+// a sync.RWMutex
+func BenchmarkWebview_MutexMapCallsParallel(b *testing.B) {
+	var mu sync.RWMutex
+	handlers := make(map[string]core.BridgeFunc)
+	for i := 0; i < 64; i++ {
+		handlers[fmt.Sprintf("fn%d", i)] = func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			return "result", nil
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.RLock()
+			fn := handlers["fn7"]
+			mu.RUnlock()
+			_, _ = fn(nil)
+		}
+	})
+}
+
 // Example test for documentation
 func ExampleWebview() {
 	config := core.WebviewConfig{
@@ -527,3 +866,3209 @@ func TestWebview_Goroutine(t *testing.T) {
 
 	wv.Terminate()
 }
+
+// recordingBackend is a headless backend that keeps bound functions so tests
+// can invoke them the way page JavaScript would
+type recordingBackend struct {
+	bindings  map[string]interface{}
+	scripts   []string
+	zoom      float64
+	navigated string
+}
+
+func (b *recordingBackend) SetTitle(title string)                             {}
+func (b *recordingBackend) SetSize(width, height int, hint webview.Hint)      {}
+func (b *recordingBackend) Navigate(url string)                               { b.navigated = url }
+func (b *recordingBackend) Run()                                              {}
+func (b *recordingBackend) Eval(script string)                                {}
+func (b *recordingBackend) Init(script string)                                { b.scripts = append(b.scripts, script) }
+func (b *recordingBackend) SetWindowOptions(opts webview.WindowOptions) error { return nil }
+func (b *recordingBackend) SetWindowState(state webview.WindowState) error    { return nil }
+func (b *recordingBackend) SetUserAgent(ua string) error                      { return nil }
+func (b *recordingBackend) SetRequestHeaders(headers map[string]string) error { return nil }
+func (b *recordingBackend) SetZoom(factor float64) error                      { b.zoom = factor; return nil }
+func (b *recordingBackend) Terminate()                                        {}
+func (b *recordingBackend) Destroy()                                          {}
+
+func (b *recordingBackend) Bind(name string, fn interface{}) error {
+	b.bindings[name] = fn
+	return nil
+}
+
+// useRecordingBackend installs a recording backend for the duration of a test
+func useRecordingBackend(t *testing.T) *recordingBackend {
+	backend := &recordingBackend{bindings: make(map[string]interface{})}
+	previous := webview.NewBackend
+	webview.ConfigureBackend(func(debug bool) webview.WebviewBackend { return backend })
+	t.Cleanup(func() { webview.ConfigureBackend(previous) })
+	return backend
+}
+
+type logEntry struct {
+	level core.LogLevel
+	msg   string
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *testLogger) Log(level core.LogLevel, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level, msg})
+}
+
+// Test the public members the injected scripts give window.polyglot are
+// reserved bridge names, while its internal helpers are not
+func TestWebview_ReservedBridgeNames(t *testing.T) {
+	backend := useRecordingBackend(t)
+	wv := webview.New(core.WebviewConfig{Title: "Reserved", Width: 400, Height: 300, Serialization: core.FormatMsgpack}, core.NewBridge())
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+	if err := wv.ReportHealth(nil, webview.HealthOptions{}); err != nil {
+		t.Fatalf("ReportHealth failed: %v", err)
+	}
+
+	members := polyglotMembers(backend.scripts)
+	if len(members) < 10 {
+		t.Fatalf("Expected to find the window.polyglot members, got %v", members)
+	}
+	public := map[string]bool{"call": true, "on": true, "capabilities": true}
+	for _, name := range members {
+		if core.IsReservedBridgeName(name) != public[name] {
+			t.Errorf("window.polyglot.%s: expected reserved %v", name, public[name])
+		}
+	}
+}
+
+// polyglotMembers lists the members scripts assign to window.polyglot or
+// define at the top level of its object literal
+func polyglotMembers(scripts []string) []string {
+	assign := regexp.MustCompile(`window\.polyglot\.(\w+) =`)
+	member := regexp.MustCompile(`^(\s*)(\w+):`)
+
+	var names []string
+	for _, script := range scripts {
+		for _, m := range assign.FindAllStringSubmatch(script, -1) {
+			names = append(names, m[1])
+		}
+		start := strings.Index(script, "window.polyglot = {\n")
+		if start < 0 {
+			continue
+		}
+		// Top-level members share the indentation of the first one
+		indent := ""
+		for _, line := range strings.Split(script[start:], "\n")[1:] {
+			m := member.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			if indent == "" {
+				indent = m[1]
+			}
+			if m[1] == indent {
+				names = append(names, m[2])
+			}
+		}
+	}
+	return names
+}
+
+// Test console output captured in the page reaches the Go logger
+func TestWebview_CaptureConsole(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	wv := webview.New(core.WebviewConfig{Title: "Console", Width: 400, Height: 300, CaptureConsole: true}, nil)
+	logger := &testLogger{}
+	wv.SetLogger(logger)
+
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	fn, ok := backend.bindings["__polyglot_console__"].(func(string, string))
+	if !ok {
+		t.Fatal("Expected __polyglot_console__ binding")
+	}
+
+	fn("error", "boom")
+	fn("log", "hello")
+
+	if len(logger.entries) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(logger.entries))
+	}
+	if logger.entries[0].level != core.LogError || logger.entries[0].msg != "[console] boom" {
+		t.Errorf("Unexpected entry: %+v", logger.entries[0])
+	}
+	if logger.entries[1].level != core.LogInfo {
+		t.Errorf("Expected console.log at info level, got %s", logger.entries[1].level)
+	}
+
+	// A nil logger discards output instead of panicking
+	wv.SetLogger(nil)
+	fn("warn", "dropped")
+	if len(logger.entries) != 2 {
+		t.Errorf("Expected output after SetLogger(nil) to be discarded, got %d entries", len(logger.entries))
+	}
+}
+
+// Test console capture is off unless configured
+func TestWebview_CaptureConsoleDisabled(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	wv := webview.New(core.WebviewConfig{Title: "Console", Width: 400, Height: 300}, nil)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	if _, ok := backend.bindings["__polyglot_console__"]; ok {
+		t.Error("Expected no console binding when CaptureConsole is false")
+	}
+}
+
+// Test typed Go errors reach JavaScript as structured error objects
+func TestWebview_BridgeErrorCodes(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	bridge := core.NewBridge()
+	bridge.Register("secret", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, core.NewError(core.CodeUnauthorized, "login required").WithDetail("realm", "admin")
+	})
+	bridge.Register("broken", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("disk full")
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Errors", Width: 400, Height: 300}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call, ok := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	if !ok {
+		t.Fatal("Expected __polyglot_call__ binding")
+	}
+
+	tests := []struct {
+		name    string
+		fn      string
+		args    string
+		code    core.ErrorCode
+		message string
+		details map[string]interface{}
+	}{
+		{"typed error", "secret", "[]", core.CodeUnauthorized, "login required", map[string]interface{}{"realm": "admin"}},
+		{"plain error", "broken", "[]", core.CodeInternal, "disk full", nil},
+		{"missing function", "nope", "[]", core.CodeNotFound, "function nope not found", map[string]interface{}{"function": "nope"}},
+		{"bad arguments", "secret", "{", core.CodeInvalidArgument, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := call(tt.fn, tt.args)
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+
+			// The rejection message is the JSON object the injected script parses
+			var info struct {
+				Code    core.ErrorCode         `json:"code"`
+				Message string                 `json:"message"`
+				Details map[string]interface{} `json:"details"`
+			}
+			if err := json.Unmarshal([]byte(err.Error()), &info); err != nil {
+				t.Fatalf("Expected JSON error object, got %q", err.Error())
+			}
+			if info.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, info.Code)
+			}
+			if tt.message != "" && info.Message != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, info.Message)
+			}
+			if tt.details != nil && fmt.Sprint(info.Details) != fmt.Sprint(tt.details) {
+				t.Errorf("Expected details %v, got %v", tt.details, info.Details)
+			}
+		})
+	}
+}
+
+// Test oversized bridge messages are rejected before deserialization
+func TestWebview_MaxMessageBytes(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	calls := 0
+	bridge := core.NewBridge()
+	bridge.Register("echo", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		calls++
+		return args[0], nil
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Limits", Width: 400, Height: 300, MaxMessageBytes: 64}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+
+	// Under the cap proceeds
+	result, err := call("echo", `["small"]`)
+	if err != nil || result != `"small"` {
+		t.Fatalf("Expected small message to succeed, got %q, %v", result, err)
+	}
+
+	// Over the cap is rejected with a typed error, even if malformed
+	oversized := `["` + strings.Repeat("x", 100)
+	_, err = call("echo", oversized)
+	if err == nil {
+		t.Fatal("Expected oversized message to be rejected")
+	}
+
+	var info core.ErrorInfo
+	if err := json.Unmarshal([]byte(err.Error()), &info); err != nil {
+		t.Fatalf("Expected structured error, got %q", err.Error())
+	}
+	if info.Code != core.CodeTooLarge {
+		t.Errorf("Expected %s, got %s", core.CodeTooLarge, info.Code)
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to run only for the small message, ran %d times", calls)
+	}
+}
+
+// Test deeply nested and oversized JSON arguments are rejected before
+// deserialization while normal payloads pass
+func TestWebview_MaxMessageShape(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	calls := 0
+	bridge := core.NewBridge()
+	bridge.Register("echo", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		calls++
+		return args[0], nil
+	})
+
+	config := core.WebviewConfig{Title: "Limits", Width: 400, Height: 300, MaxMessageDepth: 8, MaxMessageTokens: 100}
+	wv := webview.New(config, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+
+	normal := `[{"name": "a [nested] \"string\" {", "tags": ["x", "y"], "n": -1.5e3, "ok": true, "none": null}]`
+	if _, err := call("echo", normal); err != nil {
+		t.Fatalf("Expected a normal payload to pass, got %v", err)
+	}
+
+	for name, payload := range map[string]string{
+		"deep":     "[" + strings.Repeat(`{"a":[`, 10) + "1" + strings.Repeat("]}", 10) + "]",
+		"numerous": "[[" + strings.Repeat("1,", 200) + "1]]",
+		"keys":     `[{` + strings.Repeat(`"k":0,`, 60) + `"k":0}]`,
+	} {
+		_, err := call("echo", payload)
+		if err == nil {
+			t.Errorf("Expected the %s payload to be rejected", name)
+			continue
+		}
+		var info core.ErrorInfo
+		if err := json.Unmarshal([]byte(err.Error()), &info); err != nil {
+			t.Fatalf("Expected structured error, got %q", err.Error())
+		}
+		if info.Code != core.CodeTooLarge {
+			t.Errorf("Expected %s for the %s payload, got %s", core.CodeTooLarge, name, info.Code)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to run only for the normal payload, ran %d times", calls)
+	}
+
+	// Strings that only look deep or numerous are single values
+	brackets := `["` + strings.Repeat("[{", 50) + `"]`
+	if _, err := call("echo", brackets); err != nil {
+		t.Errorf("Expected brackets inside a string to pass, got %v", err)
+	}
+}
+
+// Test MessagePack arguments, which skip the JSON scan, are held to the
+// same depth and token limits with and without the call arena
+func TestWebview_MaxMessageShapePacked(t *testing.T) {
+	nested := interface{}(1)
+	for i := 0; i < 10; i++ {
+		nested = []interface{}{map[string]interface{}{"a": nested}}
+	}
+	numerous := make([]interface{}, 200)
+
+	for _, arena := range []bool{false, true} {
+		backend := useRecordingBackend(t)
+		bridge := core.NewBridge()
+		bridge.Register("echo", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			return len(args), nil
+		})
+
+		config := core.WebviewConfig{Title: "Limits", Width: 400, Height: 300, Serialization: core.FormatMsgpack,
+			MaxMessageDepth: 8, MaxMessageTokens: 100, Arena: arena}
+		wv := webview.New(config, bridge)
+		if err := wv.Initialize(); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+
+		call := backend.bindings["__polyglot_call_packed__"].(func(string, string) (string, error))
+		packed := func(args ...interface{}) string {
+			data, err := core.MsgpackCodec{}.Marshal(args)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			return base64.StdEncoding.EncodeToString(data)
+		}
+
+		if _, err := call("echo", packed(map[string]interface{}{"tags": []interface{}{"x", "y"}})); err != nil {
+			t.Errorf("arena %t: expected a normal payload to pass, got %v", arena, err)
+		}
+		for name, payload := range map[string]string{"deep": packed(nested), "numerous": packed(numerous)} {
+			_, err := call("echo", payload)
+			var info core.ErrorInfo
+			if err == nil || json.Unmarshal([]byte(err.Error()), &info) != nil || info.Code != core.CodeTooLarge {
+				t.Errorf("arena %t: expected %s for the %s payload, got %v", arena, core.CodeTooLarge, name, err)
+			}
+		}
+		wv.Terminate()
+	}
+}
+
+// Test the default nesting limit applies when unset and a negative limit
+// disables it
+func TestWebview_MaxMessageDepthDefault(t *testing.T) {
+	deep := strings.Repeat("[", core.DefaultMaxMessageDepth+2) + strings.Repeat("]", core.DefaultMaxMessageDepth+2)
+
+	for _, tc := range []struct {
+		depth  int
+		reject bool
+	}{{0, true}, {-1, false}} {
+		backend := useRecordingBackend(t)
+		bridge := core.NewBridge()
+		bridge.Register("echo", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			return len(args), nil
+		})
+		wv := webview.New(core.WebviewConfig{Title: "Limits", Width: 400, Height: 300, MaxMessageDepth: tc.depth}, bridge)
+		if err := wv.Initialize(); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+
+		call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+		_, err := call("echo", deep)
+		if rejected := err != nil; rejected != tc.reject {
+			t.Errorf("MaxMessageDepth %d: expected rejection %t, got %v", tc.depth, tc.reject, err)
+		}
+		wv.Terminate()
+	}
+}
+
+// closeRecorder tracks whether a file reader was closed
+type closeRecorder struct {
+	*strings.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+// Test FileResponse metadata and content are streamed through the bridge
+func TestWebview_FileResponse(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	// Larger than one chunk so the content is streamed in several reads
+	content := "id,title\n" + strings.Repeat("1,export tasks as CSV\n", 20000)
+	reader := &closeRecorder{Reader: strings.NewReader(content)}
+
+	bridge := core.NewBridge()
+	bridge.Register("exportTasks", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return core.NewFileResponse("tasks.csv", "text/csv", reader), nil
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Files", Width: 400, Height: 300}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	read := backend.bindings["__polyglot_read__"].(func(string) (string, error))
+
+	result, err := call("exportTasks", "[]")
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	var descriptor struct {
+		File struct {
+			ID       string `json:"id"`
+			Name     string `json:"name"`
+			MimeType string `json:"mimeType"`
+		} `json:"__polyglot_file__"`
+	}
+	if err := json.Unmarshal([]byte(result), &descriptor); err != nil {
+		t.Fatalf("Invalid descriptor %q: %v", result, err)
+	}
+	if descriptor.File.Name != "tasks.csv" || descriptor.File.MimeType != "text/csv" {
+		t.Errorf("Unexpected metadata: %+v", descriptor.File)
+	}
+
+	var received []byte
+	chunks := 0
+	for {
+		chunk, err := read(descriptor.File.ID)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if chunk == "" {
+			break
+		}
+		data, err := base64.StdEncoding.DecodeString(chunk)
+		if err != nil {
+			t.Fatalf("Invalid chunk: %v", err)
+		}
+		received = append(received, data...)
+		chunks++
+	}
+
+	if string(received) != content {
+		t.Errorf("Content mismatch: got %d bytes, want %d", len(received), len(content))
+	}
+	if chunks < 2 {
+		t.Errorf("Expected content to be streamed in multiple chunks, got %d", chunks)
+	}
+	if !reader.closed {
+		t.Error("Expected reader to be closed after streaming")
+	}
+
+	// Drained streams are released
+	if _, err := read(descriptor.File.ID); err == nil {
+		t.Error("Expected error reading a finished stream")
+	}
+}
+
+// failingReader fails after its first chunk
+type failingReader struct {
+	closeRecorder
+	reads int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.reads > 1 {
+		return 0, errors.New("disk unplugged")
+	}
+	return len(p), nil
+}
+
+// Test file streams are closed when the frontend stops reading early and
+// when their reader fails
+func TestWebview_FileResponseClosed(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	abandoned := &closeRecorder{Reader: strings.NewReader(strings.Repeat("x", 1<<20))}
+	failing := &failingReader{}
+	bridge := core.NewBridge()
+	bridge.Register("abandoned", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return core.NewFileResponse("big.bin", "application/octet-stream", abandoned), nil
+	})
+	bridge.Register("failing", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return core.NewFileResponse("broken.bin", "application/octet-stream", failing), nil
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Files", Width: 400, Height: 300}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	read := backend.bindings["__polyglot_read__"].(func(string) (string, error))
+	closeFile := backend.bindings["__polyglot_close_file__"].(func(string))
+
+	fileID := func(name string) string {
+		result, err := call(name, "[]")
+		if err != nil {
+			t.Fatalf("Call %s failed: %v", name, err)
+		}
+		var descriptor struct {
+			File struct {
+				ID string `json:"id"`
+			} `json:"__polyglot_file__"`
+		}
+		if err := json.Unmarshal([]byte(result), &descriptor); err != nil {
+			t.Fatalf("Invalid descriptor %q: %v", result, err)
+		}
+		return descriptor.File.ID
+	}
+
+	id := fileID("abandoned")
+	if _, err := read(id); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	closeFile(id)
+	if !abandoned.closed {
+		t.Error("Expected an abandoned stream to be closed")
+	}
+	if _, err := read(id); err == nil {
+		t.Error("Expected error reading a closed stream")
+	}
+
+	id = fileID("failing")
+	if _, err := read(id); err != nil {
+		t.Fatalf("First read failed: %v", err)
+	}
+	if _, err := read(id); err == nil {
+		t.Error("Expected the reader's error")
+	}
+	if !failing.closed {
+		t.Error("Expected a failed stream to be closed")
+	}
+}
+
+// Test a ResultStream is delivered chunk by chunk, in order, and can be
+// reassembled
+func TestWebview_ResultStream(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	items := make([]int, 10000)
+	for i := range items {
+		items[i] = i
+	}
+	closed := 0
+	bridge := core.NewBridge()
+	bridge.Register("bigList", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		stream := core.StreamSlice(items, 128)
+		return core.NewResultStream(stream.Next, func() error {
+			closed++
+			return nil
+		}), nil
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Streams", Width: 400, Height: 300}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	if !wv.Capabilities().Streams {
+		t.Error("Expected streams capability")
+	}
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	next := backend.bindings["__polyglot_next__"].(func(string) (string, error))
+	closeStream := backend.bindings["__polyglot_close__"].(func(string))
+
+	open := func() string {
+		result, err := call("bigList", "[]")
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		var descriptor struct {
+			Stream struct {
+				ID string `json:"id"`
+			} `json:"__polyglot_stream__"`
+		}
+		if err := json.Unmarshal([]byte(result), &descriptor); err != nil || descriptor.Stream.ID == "" {
+			t.Fatalf("Invalid descriptor %q: %v", result, err)
+		}
+		return descriptor.Stream.ID
+	}
+
+	id := open()
+	var received []int
+	chunks := 0
+	for {
+		encoded, err := next(id)
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		var chunk struct {
+			Done  bool  `json:"done"`
+			Value []int `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(encoded), &chunk); err != nil {
+			t.Fatalf("Invalid chunk %q: %v", encoded, err)
+		}
+		if chunk.Done {
+			break
+		}
+		if len(chunk.Value) > 128 {
+			t.Fatalf("Chunk of %d items exceeds the chunk size", len(chunk.Value))
+		}
+		received = append(received, chunk.Value...)
+		chunks++
+	}
+
+	if chunks != (len(items)+127)/128 {
+		t.Errorf("Expected %d chunks, got %d", (len(items)+127)/128, chunks)
+	}
+	if len(received) != len(items) {
+		t.Fatalf("Expected %d items, got %d", len(items), len(received))
+	}
+	for i, v := range received {
+		if v != i {
+			t.Fatalf("Item %d out of order: got %d", i, v)
+		}
+	}
+	if closed != 1 {
+		t.Errorf("Expected the drained stream to be closed once, got %d", closed)
+	}
+	if _, err := next(id); err == nil {
+		t.Error("Expected error reading a finished stream")
+	}
+
+	// A stream the page abandons is closed without being drained
+	id = open()
+	if _, err := next(id); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	closeStream(id)
+	if closed != 2 {
+		t.Errorf("Expected the abandoned stream to be closed, got %d closes", closed)
+	}
+}
+
+// Test a restricted window can only call allowed functions while the main
+// window sharing its bridge calls everything
+func TestWebview_FunctionAccess(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	bridge := core.NewBridge()
+	called := map[string]int{}
+	for _, name := range []string{"greet", "tasks.list", "tasks.delete", "settings.reset"} {
+		name := name
+		bridge.Register(name, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			called[name]++
+			return name, nil
+		})
+	}
+
+	primary := webview.New(core.WebviewConfig{Title: "Main", Width: 400, Height: 300}, bridge)
+	if err := primary.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer primary.Terminate()
+	mainCall := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+
+	restricted := webview.New(core.WebviewConfig{
+		Title:  "Remote",
+		Width:  400,
+		Height: 300,
+		Access: &core.FunctionAccess{
+			Allow: []string{"greet", "tasks.*"},
+			Deny:  []string{"tasks.delete"},
+		},
+	}, bridge)
+	if err := restricted.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer restricted.Terminate()
+	restrictedCall := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+
+	for _, name := range []string{"greet", "tasks.list"} {
+		if result, err := restrictedCall(name, "[]"); err != nil || result != fmt.Sprintf("%q", name) {
+			t.Errorf("Expected restricted window to call %s, got %q (%v)", name, result, err)
+		}
+	}
+	for _, name := range []string{"tasks.delete", "settings.reset"} {
+		_, err := restrictedCall(name, "[]")
+		var info core.ErrorInfo
+		if err == nil || json.Unmarshal([]byte(err.Error()), &info) != nil || info.Code != core.CodeForbidden {
+			t.Errorf("Expected %s to be forbidden in the restricted window, got %v", name, err)
+		}
+		if called[name] != 0 {
+			t.Errorf("Expected %s handler not to run for the restricted window", name)
+		}
+	}
+
+	for _, name := range []string{"tasks.delete", "settings.reset"} {
+		if _, err := mainCall(name, "[]"); err != nil {
+			t.Errorf("Expected primary window to call %s: %v", name, err)
+		}
+	}
+
+	// The restricted page only learns about functions it may call
+	if functions := restricted.Capabilities().Functions; !reflect.DeepEqual(functions, []string{"greet", "tasks.list"}) {
+		t.Errorf("Expected restricted capabilities to list allowed functions, got %v", functions)
+	}
+	if functions := primary.Capabilities().Functions; len(functions) != 4 {
+		t.Errorf("Expected primary capabilities to list every function, got %v", functions)
+	}
+}
+
+// Test the window falls back to a browser tab only when the native webview
+// is unavailable and the config allows it
+func TestWebview_BrowserFallbackDecision(t *testing.T) {
+	useRecordingBackend(t)
+	native := webview.New(core.WebviewConfig{Title: "Native", BrowserFallback: true}, core.NewBridge())
+	if err := native.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer native.Terminate()
+	if native.Mode() != webview.ModeNative || native.Capabilities().Mode != webview.ModeNative {
+		t.Errorf("Expected native mode with a working backend, got %q", native.Mode())
+	}
+
+	previous := webview.NewBackend
+	webview.ConfigureBackend(func(debug bool) webview.WebviewBackend { return nil })
+	defer webview.ConfigureBackend(previous)
+
+	strict := webview.New(core.WebviewConfig{Title: "Strict"}, core.NewBridge())
+	if err := strict.Initialize(); err == nil {
+		t.Error("Expected Initialize to fail without a native webview or fallback")
+	}
+	if strict.Mode() != "" {
+		t.Errorf("Expected no mode after a failed Initialize, got %q", strict.Mode())
+	}
+
+	fallback := webview.New(core.WebviewConfig{Title: "Fallback", BrowserFallback: true}, core.NewBridge())
+	if err := fallback.Initialize(); err != nil {
+		t.Fatalf("Expected browser fallback, got %v", err)
+	}
+	defer fallback.Terminate()
+	if fallback.Mode() != webview.ModeBrowser || fallback.Capabilities().Mode != webview.ModeBrowser {
+		t.Errorf("Expected browser mode, got %q", fallback.Mode())
+	}
+	if err := fallback.Maximize(); err == nil {
+		t.Error("Expected window controls to be unavailable in a browser tab")
+	}
+}
+
+// Test the browser fallback serves the page with the bridge and dispatches
+// calls over HTTP
+func TestWebview_BrowserFallbackBridge(t *testing.T) {
+	previous := webview.NewBackend
+	webview.ConfigureBackend(func(debug bool) webview.WebviewBackend { return nil })
+	defer webview.ConfigureBackend(previous)
+
+	opened := make(chan string, 1)
+	previousOpen := webview.OpenBrowser
+	webview.OpenBrowser = func(url string) error {
+		opened <- url
+		return nil
+	}
+	defer func() { webview.OpenBrowser = previousOpen }()
+
+	bridge := core.NewBridge()
+	bridge.Register("add", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return args[0].(float64) + args[1].(float64), nil
+	})
+	wv := webview.New(core.WebviewConfig{
+		Title:           "Fallback",
+		URL:             "data:text/html,<html><head><title>App</title></head><body>100%</body></html>",
+		BrowserFallback: true,
+	}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- wv.Run() }()
+	defer func() {
+		wv.Terminate()
+		if err := <-done; err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+	}()
+
+	var address string
+	select {
+	case address = <-opened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the page to be opened in the browser")
+	}
+	base := strings.SplitN(address, "?", 2)[0]
+
+	// Other clients are turned away without the session token
+	if response, err := http.Get(base); err != nil || response.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected a request without the token to be forbidden, got %v (%v)", response, err)
+	}
+
+	// The opened URL sets the session cookie and serves the page with the
+	// bridge script
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	response, err := client.Get(address)
+	if err != nil {
+		t.Fatalf("Failed to load page: %v", err)
+	}
+	page, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if !strings.Contains(string(page), `<head><script src="/__polyglot/init.js"></script><title>App</title>`) || !strings.Contains(string(page), "100%") {
+		t.Fatalf("Expected the page with the injected script, got %s", page)
+	}
+	response, err = client.Get(base + "__polyglot/init.js")
+	if err != nil {
+		t.Fatalf("Failed to load script: %v", err)
+	}
+	script, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if !strings.Contains(string(script), "__polyglot_call__") || !strings.Contains(string(script), "window.polyglot") {
+		t.Errorf("Expected the script to bind the bridge, got %s", script)
+	}
+
+	// Bridge calls dispatch through the binding endpoint
+	response, err = client.Post(base+"__polyglot/bind/__polyglot_call__", "application/json", strings.NewReader(`["add", "[2, 3]"]`))
+	if err != nil {
+		t.Fatalf("Bridge call failed: %v", err)
+	}
+	var reply struct {
+		Result string `json:"result"`
+		Error  string `json:"error"`
+	}
+	json.NewDecoder(response.Body).Decode(&reply)
+	response.Body.Close()
+	if reply.Result != "5" || reply.Error != "" {
+		t.Errorf("Expected add to return 5, got %+v", reply)
+	}
+
+	response, err = client.Post(base+"__polyglot/bind/__polyglot_call__", "application/json", strings.NewReader(`["missing", "[]"]`))
+	if err != nil {
+		t.Fatalf("Bridge call failed: %v", err)
+	}
+	reply.Result, reply.Error = "", ""
+	json.NewDecoder(response.Body).Decode(&reply)
+	response.Body.Close()
+	var info core.ErrorInfo
+	if json.Unmarshal([]byte(reply.Error), &info) != nil || info.Code != core.CodeNotFound {
+		t.Errorf("Expected a NOT_FOUND error for a missing function, got %+v", reply)
+	}
+
+	// Scripts passed to Eval reach the tab as server-sent events
+	wv.Eval("document.title = 'A';\nconsole.log(1)")
+	events, err := client.Get(base + "__polyglot/events")
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer events.Body.Close()
+	buffered := make([]byte, 0, 128)
+	chunk := make([]byte, 128)
+	for !strings.Contains(string(buffered), "\n\n") {
+		n, err := events.Body.Read(chunk)
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		buffered = append(buffered, chunk[:n]...)
+	}
+	if string(buffered) != "data: document.title = 'A';\ndata: console.log(1)\n\n" {
+		t.Errorf("Unexpected event %q", buffered)
+	}
+}
+
+// Test the browser backend starts serving when created, reports startup
+// failures, and refuses binding bodies over the message limit
+func TestWebview_BrowserBackendServer(t *testing.T) {
+	if _, err := webview.NewBrowserBackend(webview.BrowserConfig{Server: webview.AssetServerConfig{Addr: "0.0.0.0:0"}}); err == nil {
+		t.Error("Expected a non-loopback address to be rejected")
+	}
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer taken.Close()
+	if _, err := webview.NewBrowserBackend(webview.BrowserConfig{Server: webview.AssetServerConfig{Addr: taken.Addr().String()}}); err == nil {
+		t.Error("Expected a server that cannot listen to fail")
+	}
+
+	backend, err := webview.NewBrowserBackend(webview.BrowserConfig{MaxMessageBytes: 64, Logger: core.NopLogger{}})
+	if err != nil {
+		t.Fatalf("NewBrowserBackend failed: %v", err)
+	}
+	defer backend.Destroy()
+	backend.Bind("echo", func(s string) string { return s })
+
+	// The server is up before Navigate opens the tab
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	response, err := client.Get(backend.URL())
+	if err != nil {
+		t.Fatalf("Failed to load page: %v", err)
+	}
+	response.Body.Close()
+	base := strings.SplitN(backend.URL(), "?", 2)[0]
+
+	response, err = client.Post(base+"__polyglot/bind/echo", "application/json", strings.NewReader(`["hi"]`))
+	if err != nil {
+		t.Fatalf("Binding call failed: %v", err)
+	}
+	var reply struct {
+		Result string `json:"result"`
+	}
+	json.NewDecoder(response.Body).Decode(&reply)
+	response.Body.Close()
+	if reply.Result != "hi" {
+		t.Errorf("Expected echo to return hi, got %+v", reply)
+	}
+
+	large := `["` + strings.Repeat("x", 100) + `"]`
+	response, err = client.Post(base+"__polyglot/bind/echo", "application/json", strings.NewReader(large))
+	if err != nil {
+		t.Fatalf("Binding call failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a body over the limit, got %d", response.StatusCode)
+	}
+}
+
+// Test retriable bridge errors are retried per the configured policy
+func TestWebview_RetryPolicy(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	failures := map[string]int{"flaky": 2, "invalid": 5, "down": 5}
+	attempts := map[string]int{}
+	codes := map[string]core.ErrorCode{"flaky": core.CodeUnavailable, "invalid": core.CodeInvalidArgument, "down": core.CodeUnavailable}
+
+	bridge := core.NewBridge()
+	for name := range failures {
+		name := name
+		bridge.Register(name, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			attempts[name]++
+			if attempts[name] <= failures[name] {
+				return nil, core.NewError(codes[name], "worker restarting")
+			}
+			return "ok", nil
+		})
+	}
+
+	policy := &core.RetryPolicy{MaxAttempts: 4, Backoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+	wv := webview.New(core.WebviewConfig{Title: "Retry", Width: 400, Height: 300, Retry: policy}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	// The policy is handed to the injected script
+	found := false
+	for _, script := range backend.scripts {
+		if strings.Contains(script, `"maxAttempts":4`) && strings.Contains(script, `"retryOn":["UNAVAILABLE"]`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected init script to carry the retry policy")
+	}
+
+	// dispatch calls the binding and rebuilds the typed error as the page does
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	dispatch := func(name string) func() error {
+		return func() error {
+			_, err := call(name, "[]")
+			if err == nil {
+				return nil
+			}
+			var info core.ErrorInfo
+			if jsonErr := json.Unmarshal([]byte(err.Error()), &info); jsonErr != nil {
+				return err
+			}
+			return core.NewError(info.Code, info.Message)
+		}
+	}
+
+	if err := policy.Do(context.Background(), dispatch("flaky")); err != nil {
+		t.Errorf("Expected transient failure to be retried, got %v", err)
+	}
+	if attempts["flaky"] != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts["flaky"])
+	}
+
+	if err := policy.Do(context.Background(), dispatch("invalid")); err == nil || attempts["invalid"] != 1 {
+		t.Errorf("Expected non-retriable error without retry, got %v after %d attempts", err, attempts["invalid"])
+	}
+
+	if err := policy.Do(context.Background(), dispatch("down")); err == nil || attempts["down"] != 4 {
+		t.Errorf("Expected failure after 4 attempts, got %v after %d attempts", err, attempts["down"])
+	}
+}
+
+// Test the injected script retries call, and not callOnce, when run in a
+// JavaScript engine against the errors the bindings return
+func TestWebview_RetryScript(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not available")
+	}
+	backend := useRecordingBackend(t)
+
+	bridge := core.NewBridge()
+	for _, code := range []core.ErrorCode{core.CodeUnavailable, core.CodeTimeout, core.CodeInvalidArgument} {
+		code := code
+		bridge.Register(string(code), func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			return nil, core.NewError(code, "failed")
+		})
+	}
+
+	policy := &core.RetryPolicy{MaxAttempts: 4, Backoff: time.Millisecond}
+	wv := webview.New(core.WebviewConfig{Title: "Retry", Width: 400, Height: 300, Retry: policy}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	var script string
+	for _, s := range backend.scripts {
+		if strings.Contains(s, "window.polyglot = {") {
+			script = s
+		}
+	}
+	if script == "" {
+		t.Fatal("Expected the bridge script to be injected")
+	}
+
+	// The page sees the errors exactly as the binding reports them
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	rejections := map[string]string{}
+	for _, code := range []string{"UNAVAILABLE", "TIMEOUT", "INVALID_ARGUMENT"} {
+		_, err := call(code, "[]")
+		if err == nil {
+			t.Fatalf("Expected %s binding to fail", code)
+		}
+		rejections[code] = err.Error()
+	}
+	encoded, _ := json.Marshal(rejections)
+
+	// Each case fails with a code a number of times before succeeding
+	harness := `globalThis.window = globalThis;
+const rejections = ` + string(encoded) + `;
+const cases = {
+	flaky: { code: 'UNAVAILABLE', failures: 2 },
+	once: { code: 'UNAVAILABLE', failures: 1 },
+	slow: { code: 'TIMEOUT', failures: 1 },
+	invalid: { code: 'INVALID_ARGUMENT', failures: 1 },
+	down: { code: 'UNAVAILABLE', failures: 10 }
+};
+const attempts = {};
+window.__polyglot_call__ = async function(name, args) {
+	attempts[name] = (attempts[name] || 0) + 1;
+	if (attempts[name] <= cases[name].failures) throw rejections[cases[name].code];
+	return JSON.stringify('ok');
+};
+` + script + `
+(async function() {
+	const results = {};
+	for (const name of Object.keys(cases)) {
+		const method = name === 'once' ? 'callOnce' : 'call';
+		try {
+			results[name] = { value: await window.polyglot[method](name) };
+		} catch (e) {
+			results[name] = { code: e.code };
+		}
+		results[name].attempts = attempts[name];
+	}
+	console.log(JSON.stringify(results));
+})();
+`
+
+	out, err := exec.Command(node, "-e", harness).CombinedOutput()
+	if err != nil {
+		t.Fatalf("node failed: %v\n%s", err, out)
+	}
+	var results map[string]struct {
+		Value    string `json:"value"`
+		Code     string `json:"code"`
+		Attempts int    `json:"attempts"`
+	}
+	if err := json.Unmarshal(out, &results); err != nil {
+		t.Fatalf("Unexpected output %q: %v", out, err)
+	}
+
+	if r := results["flaky"]; r.Value != "ok" || r.Attempts != 3 {
+		t.Errorf("Expected call to retry UNAVAILABLE until it succeeds, got %+v", r)
+	}
+	if r := results["once"]; r.Code != "UNAVAILABLE" || r.Attempts != 1 {
+		t.Errorf("Expected callOnce not to retry, got %+v", r)
+	}
+	if r := results["slow"]; r.Code != "TIMEOUT" || r.Attempts != 1 {
+		t.Errorf("Expected TIMEOUT not to be retried by default, got %+v", r)
+	}
+	if r := results["invalid"]; r.Code != "INVALID_ARGUMENT" || r.Attempts != 1 {
+		t.Errorf("Expected INVALID_ARGUMENT not to be retried, got %+v", r)
+	}
+	if r := results["down"]; r.Code != "UNAVAILABLE" || r.Attempts != 4 {
+		t.Errorf("Expected call to give up after 4 attempts, got %+v", r)
+	}
+}
+
+// Test deep link parsing and scheme validation
+func TestWebview_ParseDeepLink(t *testing.T) {
+	u, err := webview.ParseDeepLink("MyApp", "myapp://open/project?id=42")
+	if err != nil {
+		t.Fatalf("ParseDeepLink failed: %v", err)
+	}
+	if u.Host != "open" || u.Path != "/project" || u.Query().Get("id") != "42" {
+		t.Errorf("Unexpected parse result: %v", u)
+	}
+
+	if _, err := webview.ParseDeepLink("myapp", "other://open"); err == nil {
+		t.Error("Expected error for link with another scheme")
+	}
+
+	for _, scheme := range []string{"", "https", "1app", "my app", "javascript"} {
+		if _, err := webview.ParseDeepLink(scheme, "myapp://open"); err == nil {
+			t.Errorf("Expected scheme %q to be rejected", scheme)
+		}
+	}
+
+	link, ok := webview.DeepLinkFromArgs("myapp", []string{"--debug", "https://example.com", "myapp://settings"})
+	if !ok || link != "myapp://settings" {
+		t.Errorf("Expected launch URL from args, got %q, %v", link, ok)
+	}
+	if _, ok := webview.DeepLinkFromArgs("myapp", []string{"--debug"}); ok {
+		t.Error("Expected no launch URL in args")
+	}
+}
+
+// Test forwarding deep links to a running instance
+func TestWebview_DeepLinkDispatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets not available")
+	}
+
+	scheme := fmt.Sprintf("polyglottest%d", time.Now().UnixNano())
+	links := make(chan string, 4)
+	server, err := webview.ListenDeepLinks(scheme, func(url string) {
+		links <- url
+	})
+	if err != nil {
+		t.Fatalf("ListenDeepLinks failed: %v", err)
+	}
+	defer server.Close()
+
+	if _, err := webview.ListenDeepLinks(scheme, func(string) {}); err == nil {
+		t.Error("Expected second listener for the same scheme to fail")
+	}
+
+	if err := webview.SendDeepLink(scheme, scheme+"://open?id=1"); err != nil {
+		t.Fatalf("SendDeepLink failed: %v", err)
+	}
+	if got := <-links; got != scheme+"://open?id=1" {
+		t.Errorf("Expected forwarded link, got %q", got)
+	}
+
+	if err := webview.SendDeepLink(scheme, "other://open"); err == nil {
+		t.Error("Expected link with another scheme to be rejected")
+	}
+
+	if err := webview.SendDeepLink(scheme, ""); err != nil {
+		t.Fatalf("SendDeepLink activation failed: %v", err)
+	}
+	if got := <-links; got != "" {
+		t.Errorf("Expected empty activation link, got %q", got)
+	}
+
+	server.Close()
+	if err := webview.SendDeepLink(scheme, scheme+"://open"); err == nil {
+		t.Error("Expected send to fail after Close")
+	}
+	if len(links) != 0 {
+		t.Errorf("Expected no further links, got %d", len(links))
+	}
+}
+
+// Test deep links only travel through a socket private to the user
+func TestWebview_DeepLinkSocketPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets not available")
+	}
+	scheme := fmt.Sprintf("polyglottest%d", time.Now().UnixNano())
+
+	// A runtime directory others can access is not used
+	shared := t.TempDir()
+	if err := os.Chmod(shared, 0777); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", shared)
+	server, err := webview.ListenDeepLinks(scheme, func(string) {})
+	if err != nil {
+		t.Fatalf("ListenDeepLinks failed: %v", err)
+	}
+	server.Close()
+	if entries, _ := os.ReadDir(shared); len(entries) != 0 {
+		t.Errorf("Expected no socket in a shared directory, found %v", entries)
+	}
+
+	// Something other than our socket at the path is neither replaced
+	// nor sent links
+	private := t.TempDir()
+	if err := os.Chmod(private, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", private)
+	planted := filepath.Join(private, "polyglot-"+scheme+".sock")
+	if err := os.WriteFile(planted, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := webview.SendDeepLink(scheme, scheme+"://login?token=secret"); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("Expected SendDeepLink to refuse a non-socket, got %v", err)
+	}
+	if _, err := webview.ListenDeepLinks(scheme, func(string) {}); err == nil {
+		t.Error("Expected ListenDeepLinks to leave a file it does not own as a socket")
+	}
+	if _, err := os.Stat(planted); err != nil {
+		t.Errorf("Expected the planted file to be left in place: %v", err)
+	}
+}
+
+// Test asset server binding and CORS headers
+func TestWebview_AssetServer(t *testing.T) {
+	assets := fstest.MapFS{"index.html": {Data: []byte("<h1>app</h1>")}}
+
+	server, err := webview.NewAssetServer(assets, webview.AssetServerConfig{})
+	if err != nil {
+		t.Fatalf("NewAssetServer failed: %v", err)
+	}
+	url, err := server.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Close()
+
+	host, _, _ := net.SplitHostPort(strings.TrimSuffix(strings.TrimPrefix(url, "http://"), "/"))
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		t.Errorf("Expected default bind to loopback, got %s", url)
+	}
+
+	resp, err := http.Get(url + "index.html")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "<h1>app</h1>" {
+		t.Errorf("Unexpected body %q", body)
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Expected no CORS headers by default")
+	}
+
+	for _, addr := range []string{"0.0.0.0:0", ":0", "example.com:80"} {
+		if _, err := webview.NewAssetServer(assets, webview.AssetServerConfig{Addr: addr}); err == nil {
+			t.Errorf("Expected %s to require AllowExternal", addr)
+		}
+	}
+	external, err := webview.NewAssetServer(assets, webview.AssetServerConfig{Addr: "0.0.0.0:0", AllowExternal: true})
+	if err != nil {
+		t.Fatalf("Expected opt-in external bind to be accepted: %v", err)
+	}
+	externalURL, err := external.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer external.Close()
+	if !strings.HasPrefix(externalURL, "http://0.0.0.0:") && !strings.HasPrefix(externalURL, "http://[::]:") {
+		t.Errorf("Expected external bind address, got %s", externalURL)
+	}
+}
+
+// Test ServeAssets serves the page the window loads, accepts its posted
+// frames and closes with the window
+func TestWebview_ServeAssets(t *testing.T) {
+	backend := useRecordingBackend(t)
+	bridge := core.NewBridge()
+	bridge.Register("echo", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return args[0], nil
+	})
+
+	config := core.WebviewConfig{Title: "Assets", Width: 400, Height: 300, URL: "https://example.com",
+		Binary: core.BinaryTransfer}
+	wv := webview.New(config, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if _, err := wv.ServeAssets(fstest.MapFS{}, webview.AssetServerConfig{Addr: "0.0.0.0:0"}); err == nil {
+		t.Error("Expected ServeAssets to keep the loopback requirement")
+	}
+	server, err := wv.ServeAssets(fstest.MapFS{"index.html": {Data: []byte("<h1>app</h1>")}}, webview.AssetServerConfig{})
+	if err != nil {
+		t.Fatalf("ServeAssets failed: %v", err)
+	}
+	url := server.URL()
+
+	if err := wv.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if backend.navigated != url {
+		t.Errorf("Expected the window to load %s, got %s", url, backend.navigated)
+	}
+
+	resp, err := http.Get(url + "index.html")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "<h1>app</h1>" {
+		t.Errorf("Unexpected body %q", body)
+	}
+
+	frame, _ := webview.EncodeFrame([]interface{}{[]byte{1, 2, 3}})
+	req, _ := http.NewRequest(http.MethodPost, strings.TrimSuffix(url, "/")+webview.FramePath+"?name=echo", bytes.NewReader(frame))
+	req.Header.Set("X-Polyglot-Token", frameToken(t, backend.scripts))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected posted frame to be served, got %d", resp.StatusCode)
+	}
+
+	wv.Terminate()
+	if server.URL() != "" {
+		t.Error("Expected the asset server to close with the window")
+	}
+}
+
+// Test CORS headers for configured origins
+func TestWebview_AssetServerCORS(t *testing.T) {
+	assets := fstest.MapFS{"data.json": {Data: []byte("{}")}}
+	server, err := webview.NewAssetServer(assets, webview.AssetServerConfig{
+		CORSOrigins: []string{"http://localhost:3000"},
+	})
+	if err != nil {
+		t.Fatalf("NewAssetServer failed: %v", err)
+	}
+	url, err := server.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Close()
+
+	request := func(method, origin string) *http.Response {
+		req, _ := http.NewRequest(method, url+"data.json", nil)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := request(http.MethodGet, "http://localhost:3000")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Errorf("Expected allowed origin header, got %q", got)
+	}
+
+	resp = request(http.MethodGet, "http://evil.example")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no header for disallowed origin, got %q", got)
+	}
+
+	resp = request(http.MethodOptions, "http://localhost:3000")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Methods") == "" {
+		t.Errorf("Expected preflight response, got %d", resp.StatusCode)
+	}
+}
+
+// Test the headless test webview records emitted events and scripts
+func TestWebview_TestWebviewEmit(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+
+	if err := wv.Emit("task.added", map[string]interface{}{"id": 7, "title": "Write docs"}); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	if err := wv.Eval("document.title = 'Tasks'"); err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+
+	events := wv.Events()
+	if len(events) != 1 || events[0].Name != "task.added" {
+		t.Fatalf("Expected one task.added event, got %+v", events)
+	}
+	data, ok := events[0].Data.(map[string]interface{})
+	if !ok || data["id"] != float64(7) || data["title"] != "Write docs" {
+		t.Errorf("Unexpected event data %v", events[0].Data)
+	}
+
+	scripts := wv.Scripts()
+	if len(scripts) != 2 || !strings.Contains(scripts[0], `"polyglot:task.added"`) || scripts[1] != "document.title = 'Tasks'" {
+		t.Errorf("Unexpected scripts %q", scripts)
+	}
+
+	if err := wv.Emit("bad", func() {}); err == nil {
+		t.Error("Expected error for unencodable event data")
+	}
+
+	wv.Reset()
+	if len(wv.Events()) != 0 || len(wv.Scripts()) != 0 {
+		t.Error("Expected Reset to clear recordings")
+	}
+}
+
+// Test rapid events of each name arrive in emit order with consecutive
+// sequence numbers, while names emit concurrently
+func TestWebview_EmitOrderPerChannel(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+
+	const count = 200
+	channels := []string{"taskUpdated", "runtimeLog", "progress"}
+	var wg sync.WaitGroup
+	for _, name := range channels {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				if err := wv.Emit(name, map[string]interface{}{"n": i}); err != nil {
+					t.Errorf("Emit %s failed: %v", name, err)
+					return
+				}
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	received := make(map[string][]webview.EmittedEvent)
+	for _, event := range wv.Events() {
+		received[event.Name] = append(received[event.Name], event)
+	}
+	for _, name := range channels {
+		events := received[name]
+		if len(events) != count {
+			t.Fatalf("Expected %d %s events, got %d", count, name, len(events))
+		}
+		for i, event := range events {
+			n := event.Data.(map[string]interface{})["n"]
+			if n != float64(i) || event.Seq != uint64(i+1) {
+				t.Fatalf("%s event %d: got n=%v seq=%d", name, i, n, event.Seq)
+			}
+		}
+	}
+
+	// The page sequences by the number carried in each script
+	var seqs []int
+	pattern := regexp.MustCompile(`\("polyglot:taskUpdated", (\d+),`)
+	for _, script := range wv.Scripts() {
+		if m := pattern.FindStringSubmatch(script); m != nil {
+			seq, _ := strconv.Atoi(m[1])
+			seqs = append(seqs, seq)
+		}
+	}
+	if len(seqs) != count {
+		t.Fatalf("Expected %d taskUpdated scripts, got %d", count, len(seqs))
+	}
+	for i, seq := range seqs {
+		if seq != i+1 {
+			t.Fatalf("Script %d carries seq %d", i, seq)
+		}
+	}
+}
+
+// Test a failed emit does not leave a gap in the sequence
+func TestWebview_EmitSequenceSkipsFailures(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+
+	wv.Emit("taskUpdated", 1)
+	if err := wv.Emit("taskUpdated", func() {}); err == nil {
+		t.Fatal("Expected error for unencodable event data")
+	}
+	wv.Emit("taskUpdated", 2)
+
+	events := wv.Events()
+	if len(events) != 2 || events[0].Seq != 1 || events[1].Seq != 2 {
+		t.Errorf("Expected consecutive sequence numbers, got %+v", events)
+	}
+
+	uninitialized := webview.New(core.DefaultConfig().Webview, nil)
+	if err := uninitialized.Emit("taskUpdated", 1); err == nil {
+		t.Error("Expected Emit to fail before Initialize")
+	}
+}
+
+// Test simulated frontend calls route to registered bridge functions
+func TestWebview_TestWebviewCall(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.Register("add", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, core.NewError(core.CodeInvalidArgument, "add requires 2 arguments")
+		}
+		return args[0].(float64) + args[1].(float64), nil
+	})
+
+	wv := webview.NewTestWebview(bridge)
+
+	result, err := wv.Call("add", 2, 3)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if result != float64(5) {
+		t.Errorf("Expected 5, got %v", result)
+	}
+
+	_, err = wv.Call("add", 1)
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeInvalidArgument {
+		t.Errorf("Expected %s, got %v", core.CodeInvalidArgument, err)
+	}
+
+	_, err = wv.Call("missing")
+	if info := core.ErrorInfoFor(err); info.Code != core.CodeNotFound {
+		t.Errorf("Expected %s, got %v", core.CodeNotFound, err)
+	}
+}
+
+// Test the integer number policy delivers JS integers to handlers as int64
+func TestWebview_IntegerNumberPolicy(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	var received []interface{}
+	bridge := core.NewBridge()
+	bridge.Register("deleteTask", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		received = args
+		return args[0], nil
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Numbers", Width: 400, Height: 300, Numbers: string(core.NumbersInteger)}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	result, err := call("deleteTask", `[3, 2.5, {"id": 7}]`)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	if id, ok := received[0].(int64); !ok || id != 3 {
+		t.Errorf("Expected integer argument as int64, got %T %v", received[0], received[0])
+	}
+	if f, ok := received[1].(float64); !ok || f != 2.5 {
+		t.Errorf("Expected fractional argument as float64, got %T %v", received[1], received[1])
+	}
+	if nested := received[2].(map[string]interface{}); nested["id"] != int64(7) {
+		t.Errorf("Expected nested integer as int64, got %T", nested["id"])
+	}
+	if result != "3" {
+		t.Errorf("Expected result 3, got %s", result)
+	}
+
+	// The default policy keeps JavaScript's float64 numbers
+	backend = useRecordingBackend(t)
+	wv = webview.New(core.WebviewConfig{Title: "Numbers", Width: 400, Height: 300}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call = backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	if _, err := call("deleteTask", `[3]`); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if _, ok := received[0].(float64); !ok {
+		t.Errorf("Expected float64 under the default policy, got %T", received[0])
+	}
+}
+
+// Test the time policy sets the format times travel in over the bridge,
+// and that handlers read times sent back in either format
+func TestWebview_TimePolicy(t *testing.T) {
+	due := time.Date(2024, 3, 9, 14, 30, 15, 0, time.UTC)
+
+	bridge := core.NewBridge()
+	bridge.Register("reschedule", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		when, err := core.ParseTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"due": when.Add(24 * time.Hour)}, nil
+	})
+
+	tests := []struct {
+		times string
+		arg   string
+		want  string
+	}{
+		{"", `["2024-03-09T14:30:15Z"]`, `{"due":"2024-03-10T14:30:15Z"}`},
+		{"millis", fmt.Sprintf("[%d]", due.UnixMilli()), fmt.Sprintf(`{"due":%d}`, due.Add(24*time.Hour).UnixMilli())},
+	}
+	for _, tt := range tests {
+		backend := useRecordingBackend(t)
+		wv := webview.New(core.WebviewConfig{Title: "Times", Width: 400, Height: 300, Times: tt.times}, bridge)
+		if err := wv.Initialize(); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		defer wv.Terminate()
+
+		call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+		result, err := call("reschedule", tt.arg)
+		if err != nil {
+			t.Fatalf("Call failed under %q: %v", tt.times, err)
+		}
+		if result != tt.want {
+			t.Errorf("Expected %s under %q, got %s", tt.want, tt.times, result)
+		}
+		if caps := wv.Capabilities(); caps.Times != string(core.ParseTimePolicy(tt.times)) {
+			t.Errorf("Expected capabilities to advertise %q, got %q", core.ParseTimePolicy(tt.times), caps.Times)
+		}
+	}
+}
+
+// Test the health sent to the frontend marks stubbed runtimes unavailable
+// with their configured notice
+func TestWebview_ReportHealth(t *testing.T) {
+	config := core.DefaultConfig()
+	config.EnableRuntime("python", "3.11")
+	config.EnableRuntime("mock", "1.0")
+
+	orch, err := core.NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	orch.RegisterRuntime(&StubbedMockRuntime{NewMockRuntime("python", "stub")})
+	orch.RegisterRuntime(NewMockRuntime("mock", "1.0"))
+	orch.InitializeReport(context.Background())
+
+	wv := webview.NewTestWebview(core.NewBridge())
+	err = wv.ReportHealth(orch.Health(), webview.HealthOptions{
+		Notices: map[string]string{"python": "Python unavailable; calculator disabled."},
+		Banner:  true,
+	})
+	if err != nil {
+		t.Fatalf("ReportHealth failed: %v", err)
+	}
+
+	events := wv.Events()
+	if len(events) != 1 || events[0].Name != webview.RuntimeHealthEvent {
+		t.Fatalf("Expected one runtimeHealth event, got %+v", events)
+	}
+	data := events[0].Data.(map[string]interface{})
+	if data["degraded"] != true {
+		t.Errorf("Expected degraded health, got %v", data)
+	}
+	notices := data["notices"].([]interface{})
+	if len(notices) != 1 || notices[0] != "Python unavailable; calculator disabled." {
+		t.Errorf("Expected the python notice, got %v", notices)
+	}
+
+	runtimes := data["runtimes"].([]interface{})
+	if len(runtimes) != 2 {
+		t.Fatalf("Expected 2 runtimes, got %v", runtimes)
+	}
+	mock := runtimes[0].(map[string]interface{})
+	if mock["runtime"] != "mock" || mock["status"] != webview.StatusReady || mock["notice"] != nil {
+		t.Errorf("Expected mock to be ready, got %v", mock)
+	}
+	python := runtimes[1].(map[string]interface{})
+	if python["runtime"] != "python" || python["status"] != webview.StatusStubbed || python["error"] == nil {
+		t.Errorf("Expected python to be stubbed, got %v", python)
+	}
+
+	scripts := wv.Scripts()
+	if len(scripts) == 0 || !strings.Contains(scripts[0], "polyglot-health-banner") ||
+		!strings.Contains(scripts[0], "calculator disabled") {
+		t.Errorf("Expected the current page to get the health and banner, got %v", scripts)
+	}
+}
+
+// Test a fully working app reports no notices and unnamed runtimes get a
+// default notice
+func TestWebview_ReportHealthReady(t *testing.T) {
+	report := webview.NewHealthReport(map[string]core.RuntimeHealth{
+		"mock": {Runtime: "mock", Version: "1.0", Initialized: true, Ready: true},
+	}, nil)
+	if report.Degraded || len(report.Notices) != 0 {
+		t.Errorf("Expected healthy report, got %+v", report)
+	}
+	if report.Runtimes[0].Status != webview.StatusReady || report.Runtimes[0].Version != "1.0" {
+		t.Errorf("Expected mock 1.0 ready, got %+v", report.Runtimes[0])
+	}
+
+	report = webview.NewHealthReport(map[string]core.RuntimeHealth{
+		"lua":  {Runtime: "lua", Error: "lua runtime not enabled", Stubbed: true},
+		"ruby": {Runtime: "ruby", Error: "ruby not found"},
+		"mock": {Runtime: "mock", Initialized: true},
+	}, nil)
+	want := map[string]string{"lua": webview.StatusStubbed, "mock": webview.StatusStarting, "ruby": webview.StatusFailed}
+	for _, status := range report.Runtimes {
+		if status.Status != want[status.Runtime] {
+			t.Errorf("Expected %s to be %s, got %s", status.Runtime, want[status.Runtime], status.Status)
+		}
+	}
+	if !report.Degraded || !reflect.DeepEqual(report.Notices, []string{"lua unavailable", "ruby unavailable"}) {
+		t.Errorf("Expected default notices for lua and ruby, got %+v", report)
+	}
+
+	wv := webview.New(core.DefaultConfig().Webview, nil)
+	if err := wv.ReportHealth(nil, webview.HealthOptions{}); err == nil {
+		t.Error("Expected ReportHealth to fail before Initialize")
+	}
+}
+
+// Test runtime output arrives as runtimeLog events in order
+func TestWebview_StreamLogs(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+
+	ctx, stop := wv.StreamLogs(context.Background(), "python")
+	stdout, stderr := core.OutputFrom(ctx)
+	io.WriteString(stdout, "step 1\nstep ")
+	io.WriteString(stderr, "warning\r\n")
+	io.WriteString(stdout, "2\n")
+	io.WriteString(stdout, "done")
+	stop()
+
+	want := []struct{ stream, line string }{
+		{"stdout", "step 1"},
+		{"stderr", "warning"},
+		{"stdout", "step 2"},
+		{"stdout", "done"},
+	}
+	events := wv.Events()
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), events)
+	}
+	for i, event := range events {
+		data := event.Data.(map[string]interface{})
+		if event.Name != webview.RuntimeLogEvent || data["runtime"] != "python" ||
+			data["stream"] != want[i].stream || data["line"] != want[i].line {
+			t.Errorf("Event %d: expected %s %q, got %s %v", i, want[i].stream, want[i].line, event.Name, data)
+		}
+	}
+}
+
+// Test a burst of output never blocks the writer and dropped lines are
+// counted
+func TestWebview_StreamLogsBackpressure(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+
+	ctx, stop := wv.StreamLogs(context.Background(), "python")
+	stdout, _ := core.OutputFrom(ctx)
+
+	const total = 20000
+	written := make(chan struct{})
+	go func() {
+		for i := 0; i < total; i++ {
+			fmt.Fprintf(stdout, "%d\n", i)
+		}
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Writes blocked on event delivery")
+	}
+	stop()
+
+	delivered, dropped, last := 0, 0, -1
+	for _, event := range wv.Events() {
+		data := event.Data.(map[string]interface{})
+		if n, ok := data["dropped"].(float64); ok {
+			dropped += int(n)
+		}
+		if data["stream"] == "" || data["stream"] == nil {
+			continue
+		}
+		var n int
+		fmt.Sscan(data["line"].(string), &n)
+		if n <= last {
+			t.Fatalf("Line %d arrived after %d", n, last)
+		}
+		last = n
+		delivered++
+	}
+	if delivered+dropped != total {
+		t.Errorf("Expected %d lines delivered or dropped, got %d + %d", total, delivered, dropped)
+	}
+}
+
+// Test progress reports arrive as runtimeProgress events in order
+func TestWebview_StreamProgress(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+
+	ctx, stop := wv.StreamProgress(context.Background())
+	for i := 0; i <= 4; i++ {
+		core.ReportProgress(ctx, core.Progress{Runtime: "python", Fraction: float64(i) / 4, Message: fmt.Sprintf("step %d", i)})
+	}
+	core.ReportProgress(ctx, core.Progress{Runtime: "python", Fraction: 1.5})
+	stop()
+
+	// Reports after stop are ignored
+	core.ReportProgress(ctx, core.Progress{Runtime: "python", Fraction: 0.5})
+
+	events := wv.Events()
+	if len(events) != 6 {
+		t.Fatalf("Expected 6 events, got %+v", events)
+	}
+	for i, event := range events[:5] {
+		data := event.Data.(map[string]interface{})
+		if event.Name != webview.RuntimeProgressEvent || data["runtime"] != "python" ||
+			data["fraction"] != float64(i)/4 || data["message"] != fmt.Sprintf("step %d", i) {
+			t.Errorf("Event %d: unexpected %s %v", i, event.Name, data)
+		}
+	}
+	if data := events[5].Data.(map[string]interface{}); data["fraction"] != 1.0 {
+		t.Errorf("Expected fractions to be clamped to 1, got %v", data["fraction"])
+	}
+}
+
+// Test expected failures resolve as values while execution errors reject
+func TestWebview_ResultOrError(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.Register("validate", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		email, _ := args[0].(string)
+		if strings.Contains(email, "@") {
+			return core.AsResultOrError(map[string]interface{}{"email": email}, nil)
+		}
+		return core.AsResultOrError(map[string]interface{}{
+			core.ErrorValueKey: map[string]interface{}{"message": "email is invalid"},
+		}, nil)
+	})
+	bridge.Register("crash", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return core.AsResultOrError(nil, core.NewError(core.CodeInternal, "interpreter crashed"))
+	})
+	wv := webview.NewTestWebview(bridge)
+
+	result, err := wv.Call("validate", "ada@example.com")
+	if err != nil || !reflect.DeepEqual(result, map[string]interface{}{"ok": true, "value": map[string]interface{}{"email": "ada@example.com"}}) {
+		t.Errorf("Expected a successful outcome, got %v (%v)", result, err)
+	}
+
+	result, err = wv.Call("validate", "nope")
+	expected := map[string]interface{}{"ok": false, "error": map[string]interface{}{"code": "INVALID_ARGUMENT", "message": "email is invalid"}}
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected a failed outcome as a value, got %v (%v)", result, err)
+	}
+
+	var typed *core.Error
+	if _, err := wv.Call("crash"); !errors.As(err, &typed) || typed.Code != core.CodeInternal {
+		t.Errorf("Expected the execution error to reject the call, got %v", err)
+	}
+}
+
+// Test FlushEvents waits for every queued log line and progress report
+func TestWebview_FlushEvents(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+
+	if err := wv.FlushEvents(context.Background()); err != nil {
+		t.Fatalf("Expected nothing to flush, got %v", err)
+	}
+
+	logs, stopLogs := wv.StreamLogs(context.Background(), "python")
+	defer stopLogs()
+	progress, stopProgress := wv.StreamProgress(context.Background())
+	defer stopProgress()
+	stdout, _ := core.OutputFrom(logs)
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(stdout, "line %d\n", i)
+		core.ReportProgress(progress, core.Progress{Runtime: "python", Fraction: float64(i) / 50})
+	}
+
+	if err := wv.FlushEvents(context.Background()); err != nil {
+		t.Fatalf("FlushEvents failed: %v", err)
+	}
+	lines, reports := 0, 0
+	for _, event := range wv.Events() {
+		switch event.Name {
+		case webview.RuntimeLogEvent:
+			lines++
+		case webview.RuntimeProgressEvent:
+			reports++
+		}
+	}
+	if lines != 50 || reports != 50 {
+		t.Errorf("Expected 50 log lines and 50 reports delivered, got %d and %d", lines, reports)
+	}
+}
+
+// Test FlushEvents gives up when its context ends while events are stuck
+func TestWebview_FlushEventsCancel(t *testing.T) {
+	backend := &blockingBackend{recordingBackend: useRecordingBackend(t), release: make(chan struct{})}
+	webview.ConfigureBackend(func(debug bool) webview.WebviewBackend { return backend })
+
+	wv := webview.New(core.WebviewConfig{Title: "Flush"}, core.NewBridge())
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	ctx, stop := wv.StreamProgress(context.Background())
+	defer stop()
+	core.ReportProgress(ctx, core.Progress{Runtime: "python", Fraction: 0.5})
+
+	timeout, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := wv.FlushEvents(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected FlushEvents to time out, got %v", err)
+	}
+
+	close(backend.release)
+	if err := wv.FlushEvents(context.Background()); err != nil {
+		t.Errorf("Expected FlushEvents to succeed once delivery resumes, got %v", err)
+	}
+}
+
+// blockingBackend holds scripts passed to Eval until release is closed
+type blockingBackend struct {
+	*recordingBackend
+	release chan struct{}
+}
+
+func (b *blockingBackend) Eval(script string) {
+	<-b.release
+}
+
+// Test binary frames round trip nested byte values
+func TestWebview_BinaryFrames(t *testing.T) {
+	image := make([]byte, 4096)
+	for i := range image {
+		image[i] = byte(i)
+	}
+	value := []interface{}{"photo.png", image, map[string]interface{}{"thumb": []byte{1, 2, 3}, "width": float64(64)}}
+
+	frame, err := webview.EncodeFrame(value)
+	if err != nil {
+		t.Fatalf("EncodeFrame failed: %v", err)
+	}
+	decoded, err := webview.DecodeFrame(core.CodecFor(core.FormatJSON), frame)
+	if err != nil {
+		t.Fatalf("DecodeFrame failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, value) {
+		t.Errorf("Frame did not round trip: %v", decoded)
+	}
+
+	for name, bad := range map[string][]byte{
+		"empty":           nil,
+		"wrong magic":     append([]byte("XXXX"), frame[4:]...),
+		"truncated blobs": frame[:len(frame)-10],
+		"trailing data":   append(append([]byte(nil), frame...), 0),
+	} {
+		if _, err := webview.DecodeFrame(core.CodecFor(core.FormatJSON), bad); err == nil {
+			t.Errorf("%s: expected decode error", name)
+		}
+	}
+
+	// Payload objects shaped like blob placeholders stay objects, and a
+	// whole-value blob round trips
+	for _, value := range []interface{}{
+		[]interface{}{map[string]interface{}{"__polyglot_bytes__": float64(0)}, []byte{9}},
+		[]byte{1, 2},
+	} {
+		frame, err := webview.EncodeFrame(value)
+		if err != nil {
+			t.Fatalf("EncodeFrame failed: %v", err)
+		}
+		decoded, err := webview.DecodeFrame(core.CodecFor(core.FormatJSON), frame)
+		if err != nil || !reflect.DeepEqual(decoded, value) {
+			t.Errorf("Expected %v to round trip, got %v, %v", value, decoded, err)
+		}
+	}
+}
+
+// frameToken extracts the frame token from the injected bridge script
+func frameToken(t *testing.T, scripts []string) string {
+	t.Helper()
+	for _, script := range scripts {
+		if m := regexp.MustCompile(`frameToken: "([0-9a-f]+)"`).FindStringSubmatch(script); m != nil {
+			return m[1]
+		}
+	}
+	t.Fatal("No frame token in bridge script")
+	return ""
+}
+
+// Test images returned by bridge functions reach the frontend with their
+// MIME type, as base64 over JSON and as a raw blob in frames
+func TestWebview_ImageResults(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	bridge := core.NewBridge()
+	bridge.Register("chart", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return core.NewImage("", png), nil
+	})
+	bridge.Register("tagged", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		// As a runtime without a bytes type returns an image
+		return map[string]interface{}{core.ImageKey: "image/svg+xml", "data": base64.StdEncoding.EncodeToString([]byte("<svg/>"))}, nil
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Images", Width: 400, Height: 300}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	if !strings.Contains(strings.Join(backend.scripts, "\n"), "result.__polyglot_image__") {
+		t.Error("Expected the bridge script to turn image results into images")
+	}
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	for _, tc := range []struct {
+		name, mimeType string
+		data           []byte
+	}{
+		{"chart", "image/png", png},
+		{"tagged", "image/svg+xml", []byte("<svg/>")},
+	} {
+		resultJSON, err := call(tc.name, "[]")
+		if err != nil {
+			t.Fatalf("%s failed: %v", tc.name, err)
+		}
+		var result struct {
+			Image struct {
+				MimeType string `json:"mimeType"`
+				Data     []byte `json:"data"`
+			} `json:"__polyglot_image__"`
+		}
+		if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+			t.Fatalf("%s returned %s: %v", tc.name, resultJSON, err)
+		}
+		if result.Image.MimeType != tc.mimeType || !bytes.Equal(result.Image.Data, tc.data) {
+			t.Errorf("%s: expected %s image %q, got %s", tc.name, tc.mimeType, tc.data, resultJSON)
+		}
+	}
+
+	// Frames carry the image as a raw blob
+	frame, err := webview.EncodeFrame([]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	callFrame := backend.bindings["__polyglot_call_frame__"].(func(string, string) (string, error))
+	resultB64, err := callFrame("chart", base64.StdEncoding.EncodeToString(frame))
+	if err != nil {
+		t.Fatalf("Frame call failed: %v", err)
+	}
+	encoded, _ := base64.StdEncoding.DecodeString(resultB64)
+	decoded, err := webview.DecodeFrame(core.CodecFor(core.FormatJSON), encoded)
+	if err != nil {
+		t.Fatalf("DecodeFrame failed: %v", err)
+	}
+	descriptor := decoded.(map[string]interface{})[core.ImageKey].(map[string]interface{})
+	if data, _ := descriptor["data"].([]byte); descriptor["mimeType"] != "image/png" || !bytes.Equal(data, png) {
+		t.Errorf("Expected a PNG blob in the frame, got %v", descriptor)
+	}
+}
+
+// Test calls with binary arguments travel as frames over the base64
+// binding and as raw bytes posted to the asset server
+func TestWebview_BinaryFrameCalls(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	bridge := core.NewBridge()
+	bridge.Register("invert", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		data, ok := args[0].([]byte)
+		if !ok {
+			return nil, core.Errorf(core.CodeInvalidArgument, "expected bytes, got %T", args[0])
+		}
+		out := make([]byte, len(data))
+		for i, b := range data {
+			out[i] = ^b
+		}
+		return map[string]interface{}{"data": out, "size": len(out)}, nil
+	})
+
+	wv := webview.New(core.WebviewConfig{Title: "Frames", Width: 400, Height: 300, Binary: core.BinaryTransfer}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	frame, err := webview.EncodeFrame([]interface{}{[]byte{0x00, 0x0f, 0xff}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult := func(encoded []byte) {
+		t.Helper()
+		result, err := webview.DecodeFrame(core.CodecFor(core.FormatJSON), encoded)
+		if err != nil {
+			t.Fatalf("DecodeFrame failed: %v", err)
+		}
+		data := result.(map[string]interface{})["data"]
+		if !bytes.Equal(data.([]byte), []byte{0xff, 0xf0, 0x00}) {
+			t.Errorf("Unexpected result %v", result)
+		}
+	}
+
+	// Base64 fallback over the string binding
+	call := backend.bindings["__polyglot_call_frame__"].(func(string, string) (string, error))
+	resultB64, err := call("invert", base64.StdEncoding.EncodeToString(frame))
+	if err != nil {
+		t.Fatalf("Frame call failed: %v", err)
+	}
+	encoded, _ := base64.StdEncoding.DecodeString(resultB64)
+	checkResult(encoded)
+
+	// Raw bytes posted to the asset server
+	server, err := webview.NewAssetServer(fstest.MapFS{}, webview.AssetServerConfig{})
+	if err != nil {
+		t.Fatalf("NewAssetServer failed: %v", err)
+	}
+	server.ServeFrames(wv)
+	post := func(token string, body []byte) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, webview.FramePath+"?name=invert", bytes.NewReader(body))
+		req.Header.Set("X-Polyglot-Token", token)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	token := frameToken(t, backend.scripts)
+	resp := post(token, frame)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	checkResult(body)
+
+	if resp := post("wrong", frame); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a bad token, got %d", resp.StatusCode)
+	}
+
+	// Bridge errors come back as error info
+	textFrame, _ := webview.EncodeFrame([]interface{}{"not bytes"})
+	resp = post(token, textFrame)
+	body, _ = io.ReadAll(resp.Body)
+	var info core.ErrorInfo
+	if resp.StatusCode != http.StatusUnprocessableEntity || json.Unmarshal(body, &info) != nil || info.Code != core.CodeInvalidArgument {
+		t.Errorf("Expected INVALID_ARGUMENT error info, got %d %s", resp.StatusCode, body)
+	}
+}
+
+// Test MessagePack calls posted as raw bytes to the asset server, held to
+// the message limits and refused when MessagePack is not enabled
+func TestWebview_PackedTransfer(t *testing.T) {
+	for _, serialization := range []string{core.FormatMsgpack, core.FormatJSON} {
+		backend := useRecordingBackend(t)
+		bridge := core.NewBridge()
+		bridge.Register("sum", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+			total := 0.0
+			for _, arg := range args {
+				total += arg.(float64)
+			}
+			return map[string]interface{}{"total": total}, nil
+		})
+
+		config := core.WebviewConfig{Title: "Packed", Width: 400, Height: 300, Serialization: serialization,
+			Binary: core.BinaryTransfer, MaxMessageDepth: 8}
+		wv := webview.New(config, bridge)
+		if err := wv.Initialize(); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		server, err := webview.NewAssetServer(fstest.MapFS{}, webview.AssetServerConfig{})
+		if err != nil {
+			t.Fatalf("NewAssetServer failed: %v", err)
+		}
+		server.ServeFrames(wv)
+
+		token := frameToken(t, backend.scripts)
+		post := func(args ...interface{}) (*http.Response, []byte) {
+			body, err := core.MsgpackCodec{}.Marshal(args)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, webview.FramePath+"?format=msgpack&name=sum", bytes.NewReader(body))
+			req.Header.Set("X-Polyglot-Token", token)
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			resp := rec.Result()
+			data, _ := io.ReadAll(resp.Body)
+			return resp, data
+		}
+		errorCode := func(resp *http.Response, body []byte) core.ErrorCode {
+			var info core.ErrorInfo
+			if resp.StatusCode != http.StatusUnprocessableEntity || json.Unmarshal(body, &info) != nil {
+				return ""
+			}
+			return info.Code
+		}
+
+		resp, body := post(1, 2, 3.5)
+		if serialization == core.FormatJSON {
+			if code := errorCode(resp, body); code != core.CodeInvalidArgument {
+				t.Errorf("Expected %s without MessagePack, got %d %s", core.CodeInvalidArgument, resp.StatusCode, body)
+			}
+			wv.Terminate()
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/msgpack" {
+			t.Fatalf("Expected a MessagePack result, got %d %s", resp.StatusCode, body)
+		}
+		result, err := core.MsgpackCodec{}.Unmarshal(body)
+		if err != nil || result.(map[string]interface{})["total"] != 6.5 {
+			t.Errorf("Unexpected result %v, %v", result, err)
+		}
+
+		nested := interface{}(1.0)
+		for i := 0; i < 10; i++ {
+			nested = []interface{}{nested}
+		}
+		if code := errorCode(post(nested)); code != core.CodeTooLarge {
+			t.Errorf("Expected %s for deep arguments, got %q", core.CodeTooLarge, code)
+		}
+		wv.Terminate()
+	}
+}
+
+// binaryWireSizes returns the bytes on the wire for a buffer sent as a
+// posted frame, a base64 frame, and a JSON array of numbers, which is how
+// the JSON bridge would otherwise carry a typed array
+func binaryWireSizes(t testing.TB, buffer []byte) (transfer, b64, jsonArray int) {
+	frame, err := webview.EncodeFrame([]interface{}{buffer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	numbers := make([]int, len(buffer))
+	for i, b := range buffer {
+		numbers[i] = int(b)
+	}
+	encoded, err := json.Marshal([]interface{}{numbers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(frame), base64.StdEncoding.EncodedLen(len(frame)), len(encoded)
+}
+
+// Test transfer sizes for a large ArrayBuffer
+func TestWebview_BinaryTransferSizes(t *testing.T) {
+	buffer := make([]byte, 1<<20)
+	for i := range buffer {
+		buffer[i] = byte(i * 7)
+	}
+
+	transfer, b64, jsonArray := binaryWireSizes(t, buffer)
+	t.Logf("1 MiB buffer: transfer %d bytes, base64 %d bytes, JSON array %d bytes", transfer, b64, jsonArray)
+
+	if overhead := transfer - len(buffer); overhead > 64 {
+		t.Errorf("Expected a small frame overhead, got %d bytes", overhead)
+	}
+	if b64 > transfer*4/3+4 || b64 <= transfer {
+		t.Errorf("Expected base64 to add about a third, got %d for %d", b64, transfer)
+	}
+	if jsonArray < 3*len(buffer) {
+		t.Errorf("Expected a JSON number array to be much larger, got %d", jsonArray)
+	}
+}
+
+// BenchmarkWebview_BinaryTransfer measures encoding a large ArrayBuffer for
+// each binary path, reporting bytes on the wire
+func BenchmarkWebview_BinaryTransfer(b *testing.B) {
+	buffer := make([]byte, 1<<20)
+	for i := range buffer {
+		buffer[i] = byte(i * 7)
+	}
+	transfer, b64, jsonArray := binaryWireSizes(b, buffer)
+
+	b.Run("transfer", func(b *testing.B) {
+		b.SetBytes(int64(len(buffer)))
+		for i := 0; i < b.N; i++ {
+			webview.EncodeFrame([]interface{}{buffer})
+		}
+		b.ReportMetric(float64(transfer), "wire-bytes")
+	})
+	b.Run("base64", func(b *testing.B) {
+		b.SetBytes(int64(len(buffer)))
+		for i := 0; i < b.N; i++ {
+			frame, _ := webview.EncodeFrame([]interface{}{buffer})
+			base64.StdEncoding.EncodeToString(frame)
+		}
+		b.ReportMetric(float64(b64), "wire-bytes")
+	})
+	b.Run("json", func(b *testing.B) {
+		numbers := make([]int, len(buffer))
+		for i, v := range buffer {
+			numbers[i] = int(v)
+		}
+		b.SetBytes(int64(len(buffer)))
+		for i := 0; i < b.N; i++ {
+			json.Marshal([]interface{}{numbers})
+		}
+		b.ReportMetric(float64(jsonArray), "wire-bytes")
+	})
+}
+
+func TestWebview_Capabilities(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	bridge := core.NewBridge()
+	bridge.Register("greet", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "hi", nil
+	})
+	tasks := core.NewHandlerGroup("tasks", func() interface{} { return nil }, func(interface{}) {})
+	tasks.Handle("add", func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil })
+	tasks.Register(bridge)
+
+	config := core.WebviewConfig{
+		Title: "Caps", Width: 400, Height: 300,
+		Serialization:  core.FormatMsgpack,
+		Numbers:        "integer",
+		CaptureConsole: true,
+		Retry:          &core.RetryPolicy{MaxAttempts: 3},
+	}
+	wv := webview.New(config, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	caps := wv.Capabilities()
+	if caps.Protocol != webview.ProtocolVersion {
+		t.Errorf("Expected protocol %d, got %d", webview.ProtocolVersion, caps.Protocol)
+	}
+	if strings.Join(caps.Serialization, ",") != "json,msgpack" || caps.Numbers != "integer" || caps.Binary != "base64" {
+		t.Errorf("Expected configured wire options, got %+v", caps)
+	}
+	if !caps.Events || !caps.Files || !caps.Console || !caps.Retry {
+		t.Errorf("Expected events, files, console and retry, got %+v", caps)
+	}
+	if strings.Join(caps.Batch, ",") != "tasks" {
+		t.Errorf("Expected tasks batch group, got %v", caps.Batch)
+	}
+	if strings.Join(caps.Functions, ",") != "greet,tasks.add,tasks.batch" {
+		t.Errorf("Expected bridge functions, got %v", caps.Functions)
+	}
+
+	// The handshake payload is injected with the bridge script
+	encoded, _ := json.Marshal(caps)
+	found := false
+	for _, script := range backend.scripts {
+		if strings.Contains(script, "capabilities: "+string(encoded)) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected init script to advertise capabilities")
+	}
+
+	// Functions registered later show up when the page refreshes
+	bridge.Register("late", func(ctx context.Context, args ...interface{}) (interface{}, error) { return nil, nil })
+	refresh := backend.bindings["__polyglot_capabilities__"].(func() (string, error))
+	payload, err := refresh()
+	if err != nil {
+		t.Fatalf("Capabilities binding failed: %v", err)
+	}
+	var refreshed webview.Capabilities
+	if err := json.Unmarshal([]byte(payload), &refreshed); err != nil {
+		t.Fatalf("Invalid capabilities payload: %v", err)
+	}
+	if strings.Join(refreshed.Functions, ",") != "greet,late,tasks.add,tasks.batch" {
+		t.Errorf("Expected refreshed functions, got %v", refreshed.Functions)
+	}
+
+	// Disabled features are reported as such
+	plain := webview.New(core.WebviewConfig{Title: "Plain", Width: 400, Height: 300}, core.NewBridge())
+	if caps := plain.Capabilities(); caps.Console || caps.Retry || len(caps.Serialization) != 1 || caps.Numbers != "float" || len(caps.Batch) != 0 {
+		t.Errorf("Expected defaults without optional features, got %+v", caps)
+	}
+}
+
+func TestIPCFraming(t *testing.T) {
+	var buf bytes.Buffer
+	sent := []webview.IPCMessage{
+		{Type: webview.IPCCall, ID: 1, Name: "greet", Data: json.RawMessage(`["bob"]`)},
+		{Type: webview.IPCResult, ID: 1, Error: &core.ErrorInfo{Code: core.CodeNotFound, Message: "missing"}},
+		{Type: webview.IPCTerminate},
+	}
+	for _, msg := range sent {
+		if err := webview.WriteIPCMessage(&buf, msg); err != nil {
+			t.Fatalf("write %s: %v", msg.Type, err)
+		}
+	}
+
+	for _, want := range sent {
+		got, err := webview.ReadIPCMessage(&buf)
+		if err != nil {
+			t.Fatalf("read %s: %v", want.Type, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	}
+	if _, err := webview.ReadIPCMessage(&buf); err != io.EOF {
+		t.Errorf("Expected io.EOF at end of stream, got %v", err)
+	}
+
+	// A frame cut off mid-body
+	buf.Reset()
+	webview.WriteIPCMessage(&buf, webview.IPCMessage{Type: webview.IPCEval, Name: "1 + 1"})
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-3])
+	if _, err := webview.ReadIPCMessage(truncated); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF for truncated frame, got %v", err)
+	}
+
+	// A length prefix over the limit is rejected before reading the body
+	oversized := bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff})
+	_, err := webview.ReadIPCMessage(oversized)
+	if core.ErrorInfoFor(err).Code != core.CodeTooLarge {
+		t.Errorf("Expected CodeTooLarge for oversized frame, got %v", err)
+	}
+}
+
+// childBackend stands in for the native window in the helper process
+type childBackend struct {
+	recordingBackend
+	done   chan struct{}
+	once   sync.Once
+	stdout *os.File
+}
+
+func (b *childBackend) Run() { <-b.done }
+
+func (b *childBackend) Terminate() { b.once.Do(func() { close(b.done) }) }
+
+// Eval scripts drive the helper: "selftest" writes to fd 1, as native code
+// might, then calls greet through the bridge and reports the result back;
+// "crash" exits abruptly
+func (b *childBackend) Eval(script string) {
+	call := b.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	switch script {
+	case "selftest":
+		b.stdout.WriteString("native output\n")
+		result, err := call("greet", `["bob"]`)
+		if err != nil {
+			result = err.Error()
+		}
+		arg, _ := json.Marshal([]string{result})
+		call("report", string(arg))
+	case "crash":
+		os.Exit(3)
+	}
+}
+
+// TestWebviewChildHelper is the child process of the out-of-process tests
+func TestWebviewChildHelper(t *testing.T) {
+	if !webview.IsChildProcess() {
+		t.Skip("helper process for out-of-process tests")
+	}
+	backend := &childBackend{
+		recordingBackend: recordingBackend{bindings: make(map[string]interface{})},
+		done:             make(chan struct{}),
+		stdout:           os.Stdout,
+	}
+	webview.ConfigureBackend(func(debug bool) webview.WebviewBackend { return backend })
+
+	if err := webview.RunChild(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// startOutOfProcess runs a webview in the helper process
+func startOutOfProcess(t *testing.T, bridge core.Bridge) *webview.OutOfProcess {
+	t.Helper()
+	view := webview.NewOutOfProcess(core.WebviewConfig{Title: "Child", URL: "about:blank"}, bridge)
+	view.SetCommand(func() *exec.Cmd {
+		return exec.Command(os.Args[0], "-test.run=^TestWebviewChildHelper$")
+	})
+	if err := view.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { view.Terminate() })
+	return view
+}
+
+func TestOutOfProcess_BridgeCall(t *testing.T) {
+	bridge := core.NewBridge()
+	bridge.Register("greet", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return fmt.Sprintf("hello %v", args[0]), nil
+	})
+	reports := make(chan string, 1)
+	bridge.Register("report", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		reports <- args[0].(string)
+		return nil, nil
+	})
+
+	view := startOutOfProcess(t, bridge)
+	if err := view.Eval("selftest"); err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+
+	select {
+	case got := <-reports:
+		if got != `"hello bob"` {
+			t.Errorf("Expected greet result to cross the pipe, got %s", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the child's bridge call")
+	}
+
+	if err := view.Terminate(); err != nil {
+		t.Fatalf("Terminate failed: %v", err)
+	}
+	if err := view.Err(); err != nil {
+		t.Errorf("Expected no error after Terminate, got %v", err)
+	}
+}
+
+func TestOutOfProcess_Crash(t *testing.T) {
+	view := startOutOfProcess(t, core.NewBridge())
+	if err := view.Eval("crash"); err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+
+	select {
+	case <-view.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the crash to be detected")
+	}
+
+	err := view.Err()
+	if !errors.Is(err, webview.ErrWebviewCrashed) {
+		t.Fatalf("Expected ErrWebviewCrashed, got %v", err)
+	}
+	if core.ErrorInfoFor(err).Code != core.CodeUnavailable {
+		t.Errorf("Expected CodeUnavailable, got %v", core.ErrorInfoFor(err).Code)
+	}
+	if !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Expected the exit status in %q", err)
+	}
+	if err := view.Eval("1 + 1"); err == nil {
+		t.Error("Expected Eval to fail after the crash")
+	}
+}
+
+// loopBackend blocks in Run until Terminate, like a native event loop, and
+// records whether Destroy came while the loop still ran
+type loopBackend struct {
+	recordingBackend
+	started chan struct{}
+	stop    chan struct{}
+	once    sync.Once
+
+	mu       sync.Mutex
+	looping  bool
+	destroys int
+	early    bool
+}
+
+func (b *loopBackend) Run() {
+	b.mu.Lock()
+	b.looping = true
+	b.mu.Unlock()
+	close(b.started)
+	<-b.stop
+	// Leave time for a Destroy racing the loop's exit to be seen
+	time.Sleep(10 * time.Millisecond)
+	b.mu.Lock()
+	b.looping = false
+	b.mu.Unlock()
+}
+
+func (b *loopBackend) Terminate() { b.once.Do(func() { close(b.stop) }) }
+
+func (b *loopBackend) Destroy() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.destroys++
+	b.early = b.early || b.looping
+}
+
+func TestWebview_TerminateWhileRunning(t *testing.T) {
+	backend := &loopBackend{
+		recordingBackend: recordingBackend{bindings: make(map[string]interface{})},
+		started:          make(chan struct{}),
+		stop:             make(chan struct{}),
+	}
+	previous := webview.NewBackend
+	webview.ConfigureBackend(func(debug bool) webview.WebviewBackend { return backend })
+	t.Cleanup(func() { webview.ConfigureBackend(previous) })
+
+	wv := webview.New(core.WebviewConfig{Title: "Loop", URL: "about:blank"}, nil)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- wv.Run() }()
+	<-backend.started
+
+	if err := wv.Terminate(); err != nil {
+		t.Fatalf("Terminate failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for Run to return")
+	}
+
+	// Terminate after Run returned has nothing left to destroy
+	wv.Terminate()
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if backend.destroys != 1 || backend.early {
+		t.Errorf("Expected one Destroy after the loop ended, got %d (during loop: %v)", backend.destroys, backend.early)
+	}
+}
+
+func TestWebview_BridgeTimeout(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	bridge := core.NewBridge()
+	bridge.RegisterWithTimeout("stuck", 50*time.Millisecond, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		// Ignores ctx, like a handler blocked in a slow runtime
+		<-release
+		return "late", nil
+	})
+	bridge.RegisterWithTimeout("quick", time.Second, func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, errors.New("expected a deadline")
+		}
+		return "done", nil
+	})
+	if err := bridge.RegisterWithTimeout("zero", 0, nil); err == nil {
+		t.Error("Expected error for zero timeout")
+	}
+
+	result, err := bridge.Call(nil, "quick")
+	if err != nil || result != "done" {
+		t.Errorf("Expected done, got %v, %v", result, err)
+	}
+
+	start := time.Now()
+	_, err = bridge.Call(context.Background(), "stuck")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected prompt return, took %v", elapsed)
+	}
+	var typed *core.Error
+	if !errors.As(err, &typed) || typed.Code != core.CodeTimeout {
+		t.Fatalf("Expected TIMEOUT error, got %v", err)
+	}
+	if typed.Details["timeout"] != "50ms" {
+		t.Errorf("Expected timeout detail, got %v", typed.Details)
+	}
+
+	// A caller cancelling first gets its own error back
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := bridge.Call(ctx, "stuck"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	// The frontend receives the TIMEOUT code
+	wv := webview.New(core.WebviewConfig{Title: "Timeout", Width: 400, Height: 300}, bridge)
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+
+	call := backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+	_, err = call("stuck", "[]")
+	var info struct {
+		Code core.ErrorCode `json:"code"`
+	}
+	if err == nil || json.Unmarshal([]byte(err.Error()), &info) != nil || info.Code != core.CodeTimeout {
+		t.Errorf("Expected TIMEOUT for frontend, got %v", err)
+	}
+}
+
+func TestWebview_NilPolicy(t *testing.T) {
+	for _, policy := range []string{"", "undefined"} {
+		backend := useRecordingBackend(t)
+
+		wv := webview.New(core.WebviewConfig{Title: "Nil", Width: 400, Height: 300, Nil: policy}, core.NewBridge())
+		if err := wv.Initialize(); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+
+		want := "nilUndefined: " + strconv.FormatBool(policy == "undefined")
+		found := false
+		for _, script := range backend.scripts {
+			found = found || strings.Contains(script, want)
+		}
+		if !found {
+			t.Errorf("Nil %q: expected bridge script with %q", policy, want)
+		}
+		wv.Terminate()
+	}
+}
+
+// Test a slow bridge call does not hold up a fast one when calls run on a
+// handler pool
+func TestWebview_HandlerPool(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	bridge := core.NewBridge()
+	bridge.Register("slow", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		return "slow", nil
+	})
+	bridge.Register("fast", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "fast", nil
+	})
+	bridge.Register("fail", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return nil, core.NewError(core.CodeNotFound, "no such task")
+	})
+
+	config := core.DefaultConfig().Webview
+	config.HandlerPool = 2
+	wv := webview.NewTestWebviewWith(config, bridge)
+
+	slow := make(chan interface{}, 1)
+	go func() {
+		result, err := wv.Call("slow")
+		if err != nil {
+			t.Errorf("slow call failed: %v", err)
+		}
+		slow <- result
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() {
+		result, err := wv.Call("fast")
+		if err == nil && result != "fast" {
+			err = fmt.Errorf("unexpected result %v", result)
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("fast call failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fast call blocked behind the slow one")
+	}
+
+	_, err := wv.Call("fail")
+	var bridgeErr *core.Error
+	if !errors.As(err, &bridgeErr) || bridgeErr.Code != core.CodeNotFound {
+		t.Errorf("Expected %s from a pooled call, got %v", core.CodeNotFound, err)
+	}
+
+	close(release)
+	if result := <-slow; result != "slow" {
+		t.Errorf("Expected the slow result, got %v", result)
+	}
+
+	var settled int
+	for _, script := range wv.Scripts() {
+		if strings.Contains(script, "window.polyglot.settle(") {
+			settled++
+		}
+	}
+	if settled != 3 {
+		t.Errorf("Expected 3 calls settled in the page, got %d", settled)
+	}
+}
+
+// Test function ordering runs calls to one function one at a time in the
+// order made, while other functions run alongside
+func TestWebview_HandlerOrderFunction(t *testing.T) {
+	started := make(chan float64, 2)
+	release := make(chan struct{})
+	bridge := core.NewBridge()
+	bridge.Register("save", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		started <- args[0].(float64)
+		<-release
+		return args[0], nil
+	})
+	bridge.Register("ping", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "pong", nil
+	})
+
+	config := core.DefaultConfig().Webview
+	config.HandlerPool = 4
+	config.HandlerOrder = core.HandlerOrderFunction
+	wv := webview.NewTestWebviewWith(config, bridge)
+
+	var wg sync.WaitGroup
+	save := func(n int) {
+		defer wg.Done()
+		if _, err := wv.Call("save", n); err != nil {
+			t.Errorf("save %d failed: %v", n, err)
+		}
+	}
+	wg.Add(2)
+	go save(1)
+	if n := <-started; n != 1 {
+		t.Fatalf("Expected save 1 to start first, got %v", n)
+	}
+	go save(2)
+
+	if result, err := wv.Call("ping"); err != nil || result != "pong" {
+		t.Errorf("Expected ping to run while save is busy, got %v, %v", result, err)
+	}
+	select {
+	case n := <-started:
+		t.Fatalf("save %v started before save 1 finished", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if n := <-started; n != 2 {
+		t.Errorf("Expected save 2 to start next, got %v", n)
+	}
+	wg.Wait()
+
+	invalid := core.DefaultConfig()
+	invalid.Webview.HandlerOrder = "random"
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected an unknown handler order to fail validation")
+	}
+}
+
+// Test MaxConcurrentCalls queues calls beyond the limit under a burst
+func TestWebview_MaxConcurrentCallsQueue(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	release := make(chan struct{})
+	bridge := core.NewBridge()
+	bridge.Register("work", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return "done", nil
+	})
+
+	config := core.DefaultConfig().Webview
+	config.HandlerPool = 8
+	config.MaxConcurrentCalls = 2
+	wv := webview.NewTestWebviewWith(config, bridge)
+
+	const calls = 20
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		go func() {
+			result, err := wv.Call("work")
+			if err == nil && result != "done" {
+				err = fmt.Errorf("unexpected result %v", result)
+			}
+			errs <- err
+		}()
+	}
+
+	// Give the burst time to pile up behind the limit
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	if running != 2 {
+		t.Errorf("Expected 2 calls running at the limit, got %d", running)
+	}
+	mu.Unlock()
+
+	close(release)
+	for i := 0; i < calls; i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("Queued call failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Queued calls never ran")
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 2 {
+		t.Errorf("Expected at most 2 calls at once, got %d", peak)
+	}
+}
+
+// Test the reject policy fails calls beyond MaxConcurrentCalls at once
+func TestWebview_MaxConcurrentCallsReject(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	bridge := core.NewBridge()
+	bridge.Register("slow", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		close(started)
+		<-release
+		return "slow", nil
+	})
+	bridge.Register("fast", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "fast", nil
+	})
+
+	config := core.DefaultConfig().Webview
+	config.MaxConcurrentCalls = 1
+	config.CallOverflow = core.CallOverflowReject
+	wv := webview.NewTestWebviewWith(config, bridge)
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := wv.Call("slow")
+		slow <- err
+	}()
+	<-started
+
+	_, err := wv.Call("fast")
+	var bridgeErr *core.Error
+	if !errors.As(err, &bridgeErr) || bridgeErr.Code != core.CodeResourceExhausted {
+		t.Errorf("Expected %s beyond the limit, got %v", core.CodeResourceExhausted, err)
+	}
+
+	close(release)
+	if err := <-slow; err != nil {
+		t.Errorf("Call within the limit failed: %v", err)
+	}
+	if result, err := wv.Call("fast"); err != nil || result != "fast" {
+		t.Errorf("Expected calls to succeed once the limit frees up, got %v, %v", result, err)
+	}
+
+	config.CallOverflow = "drop"
+	app := core.DefaultConfig()
+	app.Webview = config
+	if err := app.Validate(); err == nil {
+		t.Error("Expected an unknown overflow policy to be rejected")
+	}
+}
+
+// Test DataURL escapes content so it decodes back unchanged
+func TestWebview_DataURLEncoding(t *testing.T) {
+	html := []byte(`<html><body><a href="#top">100% café</a> ☕ 50%20 off?</body></html>`)
+
+	encoded := webview.DataURL("text/html", html)
+	header, data, ok := strings.Cut(encoded, ",")
+	if !ok || header != "data:text/html;charset=utf-8" {
+		t.Fatalf("Unexpected data URL header in %s", encoded)
+	}
+	for _, c := range data {
+		if c == '#' || c == ' ' || c == '"' || c > 0x7E {
+			t.Fatalf("Data URL keeps unescaped %q: %s", c, data)
+		}
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil || decoded != string(html) {
+		t.Errorf("Expected %s to decode to the page, got %q, %v", data, decoded, err)
+	}
+
+	// A charset named by the caller is kept
+	if encoded := webview.DataURL("text/plain; charset=latin1", []byte("a#b")); encoded != "data:text/plain; charset=latin1,a%23b" {
+		t.Errorf("Unexpected data URL %s", encoded)
+	}
+
+	// Binary types are base64-encoded
+	png := []byte{0x89, 'P', 'N', 'G', 0x00, '#', '%'}
+	encoded = webview.DataURL("image/png", png)
+	data, ok = strings.CutPrefix(encoded, "data:image/png;base64,")
+	if !ok {
+		t.Fatalf("Expected a base64 data URL, got %s", encoded)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(data); err != nil || !bytes.Equal(raw, png) {
+		t.Errorf("Expected the image bytes back, got %v, %v", raw, err)
+	}
+
+	if encoded := webview.DataURL("", []byte("hi there")); encoded != "data:text/plain;charset=utf-8,hi%20there" {
+		t.Errorf("Unexpected default data URL %s", encoded)
+	}
+}
+
+// Test zoom factors are clamped, stepped and applied when the window is created
+func TestWebview_ZoomClamp(t *testing.T) {
+	backend := useRecordingBackend(t)
+
+	wv := webview.New(core.WebviewConfig{Title: "Zoom", Width: 400, Height: 300, Zoom: 8}, nil)
+	if zoom := wv.Zoom(); zoom != webview.MaxZoom {
+		t.Errorf("Expected initial zoom clamped to %v, got %v", webview.MaxZoom, zoom)
+	}
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+	if backend.zoom != webview.MaxZoom {
+		t.Errorf("Expected the backend zoomed to %v on creation, got %v", webview.MaxZoom, backend.zoom)
+	}
+
+	for _, tt := range []struct {
+		factor float64
+		want   float64
+	}{
+		{1.5, 1.5},
+		{10, webview.MaxZoom},
+		{0.1, webview.MinZoom},
+		{webview.MinZoom, webview.MinZoom},
+	} {
+		if err := wv.SetZoom(tt.factor); err != nil {
+			t.Fatalf("SetZoom(%v) failed: %v", tt.factor, err)
+		}
+		if zoom := wv.Zoom(); zoom != tt.want || backend.zoom != tt.want {
+			t.Errorf("SetZoom(%v): expected %v, got %v with %v applied", tt.factor, tt.want, zoom, backend.zoom)
+		}
+	}
+	for _, factor := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		err := wv.SetZoom(factor)
+		if code := core.ErrorInfoFor(err).Code; code != core.CodeInvalidArgument {
+			t.Errorf("SetZoom(%v): expected %s, got %v", factor, core.CodeInvalidArgument, err)
+		}
+	}
+	if zoom := wv.Zoom(); zoom != webview.MinZoom {
+		t.Errorf("Expected rejected factors to leave the zoom alone, got %v", zoom)
+	}
+
+	// The page's zoom controls step by whole percents
+	zoom := backend.bindings["__polyglot_zoom__"].(func(string, float64) (float64, error))
+	for _, tt := range []struct {
+		action string
+		want   float64
+	}{
+		{"out", webview.MinZoom},
+		{"reset", 1},
+		{"in", 1.1},
+		{"in", 1.2},
+		{"out", 1.1},
+		{"get", 1.1},
+	} {
+		got, err := zoom(tt.action, 0)
+		if err != nil || got != tt.want {
+			t.Errorf("zoom %s: expected %v, got %v (%v)", tt.action, tt.want, got, err)
+		}
+	}
+	if got, err := zoom("set", 2); err != nil || got != 2 {
+		t.Errorf("zoom set: expected 2, got %v (%v)", got, err)
+	}
+
+	scripts := strings.Join(backend.scripts, "\n")
+	for _, want := range []string{"zoomIn:", "resetZoom:", "addEventListener('keydown'"} {
+		if !strings.Contains(scripts, want) {
+			t.Errorf("Expected the page scripts to contain %q", want)
+		}
+	}
+}
+
+// Test a remembered zoom is saved to the store and restored on the next launch
+func TestWebview_ZoomPersistence(t *testing.T) {
+	backend := useRecordingBackend(t)
+	path := filepath.Join(t.TempDir(), "app", "window-state.json")
+	store := webview.NewFileStateStore(path)
+
+	if state, err := store.Load(); err != nil || state.Zoom != 0 {
+		t.Fatalf("Expected an empty state before saving, got %+v (%v)", state, err)
+	}
+
+	first := webview.New(core.WebviewConfig{Title: "Zoom", Width: 400, Height: 300}, nil)
+	if err := first.SetStateStore(store); err != nil {
+		t.Fatalf("SetStateStore failed: %v", err)
+	}
+	if err := first.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := first.SetZoom(1.75); err != nil {
+		t.Fatalf("SetZoom failed: %v", err)
+	}
+	first.Terminate()
+
+	if state, err := store.Load(); err != nil || state.Zoom != 1.75 {
+		t.Fatalf("Expected zoom 1.75 saved, got %+v (%v)", state, err)
+	}
+
+	second := webview.New(core.WebviewConfig{Title: "Zoom", Width: 400, Height: 300, Zoom: 1.25, RememberState: true, StateFile: path}, nil)
+	if zoom := second.Zoom(); zoom != 1.75 {
+		t.Errorf("Expected the remembered zoom restored over the configured one, got %v", zoom)
+	}
+	if err := second.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer second.Terminate()
+	if backend.zoom != 1.75 {
+		t.Errorf("Expected the remembered zoom applied on creation, got %v", backend.zoom)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.SetStateStore(store); err == nil {
+		t.Error("Expected a corrupt state file to fail SetStateStore")
+	}
+}
+
+// Test WaitReady blocks until the page fires DOMContentLoaded, and
+// OnReady handlers run on every load
+func TestWebview_WaitReady(t *testing.T) {
+	wv := webview.NewTestWebview(core.NewBridge())
+	defer wv.Terminate()
+
+	loads := make(chan struct{}, 4)
+	wv.OnReady(func() { loads <- struct{}{} })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wv.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected WaitReady to block before the page loads, got %v", err)
+	}
+	if wv.Ready() {
+		t.Error("Expected the page not to be ready yet")
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- wv.WaitReady(context.Background()) }()
+	if err := wv.LoadPage(); err != nil {
+		t.Fatalf("LoadPage failed: %v", err)
+	}
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Errorf("Expected WaitReady to succeed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitReady was not released by the page loading")
+	}
+	if !wv.Ready() {
+		t.Error("Expected the page to be ready")
+	}
+	if err := wv.WaitReady(context.Background()); err != nil {
+		t.Errorf("Expected WaitReady to return at once once ready, got %v", err)
+	}
+
+	// Reloads notify the handlers again
+	wv.LoadPage()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-loads:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected OnReady for load %d", i+1)
+		}
+	}
+}
+
+// Test WaitReady fails when the window closes before its page loads
+func TestWebview_WaitReadyClosed(t *testing.T) {
+	backend := useRecordingBackend(t)
+	wv := webview.New(core.WebviewConfig{Title: "Ready", Width: 800, Height: 600}, core.NewBridge())
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if _, ok := backend.bindings["__polyglot_ready__"].(func()); !ok {
+		t.Fatal("Expected __polyglot_ready__ binding")
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- wv.WaitReady(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	wv.Terminate()
+	select {
+	case err := <-waited:
+		if !errors.Is(err, webview.ErrClosedBeforeReady) {
+			t.Errorf("Expected ErrClosedBeforeReady, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitReady was not released by Terminate")
+	}
+
+	// A new window waits for its own page
+	if err := wv.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer wv.Terminate()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wv.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a new window to wait for its page, got %v", err)
+	}
+	backend.bindings["__polyglot_ready__"].(func())()
+	if err := wv.WaitReady(context.Background()); err != nil {
+		t.Errorf("Expected WaitReady to succeed once the page loaded, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -557,3 +558,42 @@ func TestZigPoolExhaustion(t *testing.T) {
 		<-done
 	}
 }
+
+// TestZigStructuredOutput tests delimited JSON output is returned decoded
+func TestZigStructuredOutput(t *testing.T) {
+	runtime := zig.NewRuntime()
+	ctx := context.Background()
+
+	config := core.RuntimeConfig{
+		Name:             "zig",
+		Version:          "0.11",
+		Enabled:          true,
+		Options:          make(map[string]interface{}),
+		MaxConcurrency:   1,
+		Timeout:          30 * time.Second,
+		StructuredOutput: true,
+	}
+
+	err := runtime.Initialize(ctx, config)
+	if err != nil {
+		t.Logf("Initialize returned expected error: %v", err)
+		return
+	}
+	defer runtime.Shutdown(ctx)
+
+	code := `const std = @import("std");
+
+pub fn main() !void {
+    const stdout = std.io.getStdOut().writer();
+    try stdout.print("warming up\n", .{});
+    try stdout.print("` + core.ResultStart + `{{\"primes\": [2, 3, 5], \"count\": {d}}}` + core.ResultEnd + `\n", .{3});
+}`
+	result, err := runtime.Execute(ctx, code)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	expected := map[string]interface{}{"primes": []interface{}{int64(2), int64(3), int64(5)}, "count": int64(3)}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %#v", expected, result)
+	}
+}
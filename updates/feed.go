@@ -0,0 +1,146 @@
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// maxManifestSize bounds the release manifest read from a feed
+const maxManifestSize = 4 << 20
+
+// Manifest is the JSON document served by an update feed
+type Manifest struct {
+	Releases []*Release `json:"releases"`
+}
+
+// HTTPFeed fetches releases from a JSON manifest served over HTTP. The
+// manifest is cached and revalidated with its ETag.
+type HTTPFeed struct {
+	// URL of the manifest
+	URL string
+
+	// Platform and Arch select releases; they default to the running
+	// platform. Releases without a platform or arch match any.
+	Platform string
+	Arch     string
+
+	// MaxAge serves the cached manifest without revalidating for this long
+	// (zero revalidates on every request)
+	MaxAge time.Duration
+
+	// Client performs requests (default http.DefaultClient)
+	Client *http.Client
+
+	mu        sync.Mutex
+	etag      string
+	releases  []*Release
+	fetchedAt time.Time
+}
+
+// NewHTTPFeed creates a feed for the manifest at url
+func NewHTTPFeed(url string) *HTTPFeed {
+	return &HTTPFeed{
+		URL:      url,
+		Platform: runtime.GOOS,
+		Arch:     runtime.GOARCH,
+	}
+}
+
+// Releases returns the feed's releases for the configured platform
+func (f *HTTPFeed) Releases(ctx context.Context) ([]*Release, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.releases != nil && f.MaxAge > 0 && time.Since(f.fetchedAt) < f.MaxAge {
+		return f.filter(f.releases), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if f.etag != "" {
+		req.Header.Set("If-None-Match", f.etag)
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		f.fetchedAt = time.Now()
+		return f.filter(f.releases), nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("feed returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	if len(body) > maxManifestSize {
+		return nil, fmt.Errorf("manifest exceeds %d bytes", maxManifestSize)
+	}
+
+	releases, err := parseManifest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.releases = releases
+	f.etag = resp.Header.Get("ETag")
+	f.fetchedAt = time.Now()
+	return f.filter(releases), nil
+}
+
+// filter returns the releases matching the feed's platform and arch
+func (f *HTTPFeed) filter(releases []*Release) []*Release {
+	var matched []*Release
+	for _, release := range releases {
+		if release.Platform != "" && f.Platform != "" && release.Platform != f.Platform {
+			continue
+		}
+		if release.Arch != "" && f.Arch != "" && release.Arch != f.Arch {
+			continue
+		}
+		matched = append(matched, release)
+	}
+	return matched
+}
+
+// parseManifest decodes a manifest, dropping entries without a channel or
+// download URL
+func parseManifest(data []byte) ([]*Release, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("malformed manifest: %w", err)
+	}
+	if manifest.Releases == nil {
+		return nil, fmt.Errorf("malformed manifest: missing releases")
+	}
+
+	releases := make([]*Release, 0, len(manifest.Releases))
+	for _, release := range manifest.Releases {
+		if release == nil || release.Channel == "" || release.URL == "" {
+			continue
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
@@ -15,6 +15,8 @@ type DefaultManager struct {
 	verifier    Verifier
 	checkpoints map[string]*Checkpoint
 	releases    map[string]*Release
+	feed        Feed
+	rollback    RollbackOptions
 }
 
 // NewManager creates a new update manager
@@ -30,12 +32,14 @@ func NewManager(differ Differ, downloader Downloader, verifier Verifier) *Defaul
 
 // Check checks for available updates
 func (m *DefaultManager) Check(ctx context.Context, current Version, channel string) (*Update, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	releases, err := m.candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// Find latest release for channel
 	var latest *Release
-	for _, release := range m.releases {
+	for _, release := range releases {
 		if release.Channel != channel {
 			continue
 		}
@@ -118,20 +122,22 @@ func (m *DefaultManager) Apply(ctx context.Context, data []byte, update *Update)
 	return result, nil
 }
 
-// Rollback rolls back to a previous version
+// Rollback rolls back to a previous version. With rollback options set, the
+// checkpoint binary replaces the installed one and may be self tested.
 func (m *DefaultManager) Rollback(ctx context.Context, checkpointID string) error {
 	m.mu.RLock()
 	checkpoint, ok := m.checkpoints[checkpointID]
+	opts := m.rollback
 	m.mu.RUnlock()
 
 	if !ok {
 		return fmt.Errorf("checkpoint not found: %s", checkpointID)
 	}
 
-	// Restore from checkpoint (simplified)
-	_ = checkpoint.Binary
-
-	return nil
+	if opts.BinaryPath == "" {
+		return nil
+	}
+	return m.restore(ctx, opts, checkpoint)
 }
 
 // CreateCheckpoint creates a restore point
@@ -172,6 +178,35 @@ func (m *DefaultManager) AddRelease(release *Release) {
 	m.releases[key] = release
 }
 
+// SetFeed sets a feed queried for releases on every Check, in addition to
+// releases added with AddRelease
+func (m *DefaultManager) SetFeed(feed Feed) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.feed = feed
+}
+
+// candidates returns the added releases plus those published by the feed
+func (m *DefaultManager) candidates(ctx context.Context) ([]*Release, error) {
+	m.mu.RLock()
+	feed := m.feed
+	releases := make([]*Release, 0, len(m.releases))
+	for _, release := range m.releases {
+		releases = append(releases, release)
+	}
+	m.mu.RUnlock()
+
+	if feed == nil {
+		return releases, nil
+	}
+
+	published, err := feed.Releases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feed: %w", err)
+	}
+	return append(releases, published...), nil
+}
+
 // Helper function to compare versions
 func compareVersions(a, b Version) int {
 	if a.Major != b.Major {
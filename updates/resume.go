@@ -0,0 +1,208 @@
+package updates
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ResumableDownloader downloads over HTTP into a partial file so an
+// interrupted download resumes with a range request instead of starting
+// over
+type ResumableDownloader struct {
+	// Dir holds partial downloads
+	Dir string
+
+	// Client performs requests (default http.DefaultClient)
+	Client *http.Client
+}
+
+// NewResumableDownloader creates a downloader keeping partial files in dir
+func NewResumableDownloader(dir string) *ResumableDownloader {
+	return &ResumableDownloader{Dir: dir}
+}
+
+// partialPath returns the partial file for a URL
+func (d *ResumableDownloader) partialPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:8])+".part")
+}
+
+// Download downloads data from a URL, resuming any partial download left by
+// an earlier attempt
+func (d *ResumableDownloader) Download(ctx context.Context, url string, progress chan<- *DownloadProgress) ([]byte, error) {
+	if url == "" {
+		return nil, fmt.Errorf("URL is required")
+	}
+
+	var offset int64
+	if info, err := os.Stat(d.partialPath(url)); err == nil {
+		offset = info.Size()
+	}
+	return d.Resume(ctx, url, offset, progress)
+}
+
+// Resume continues a download from offset bytes of the partial file. On
+// failure the bytes received so far are kept for the next attempt.
+func (d *ResumableDownloader) Resume(ctx context.Context, url string, offset int64, progress chan<- *DownloadProgress) ([]byte, error) {
+	if progress != nil {
+		defer close(progress)
+	}
+	if url == "" {
+		return nil, fmt.Errorf("URL is required")
+	}
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	path := d.partialPath(url)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partial download: %w", err)
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err != nil || info.Size() < offset {
+		offset = 0
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		var start int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != offset {
+			return nil, fmt.Errorf("unexpected content range %q", resp.Header.Get("Content-Range"))
+		}
+	case http.StatusOK:
+		// The server ignored the range; start over
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file already holds the whole download
+		if offset == 0 {
+			return nil, fmt.Errorf("download failed: %s", resp.Status)
+		}
+		return d.finish(file, path)
+	default:
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	written, err := copyWithProgress(ctx, file, resp.Body, offset, total, progress)
+	if err != nil {
+		return nil, fmt.Errorf("download interrupted after %d bytes: %w", written, err)
+	}
+	if total >= 0 && written < total {
+		return nil, fmt.Errorf("download interrupted after %d of %d bytes", written, total)
+	}
+
+	return d.finish(file, path)
+}
+
+// finish reads the completed download and removes the partial file
+func (d *ResumableDownloader) finish(file *os.File, path string) ([]byte, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	os.Remove(path)
+	return data, nil
+}
+
+// Verify verifies downloaded data
+func (d *ResumableDownloader) Verify(ctx context.Context, data []byte, checksum string) error {
+	return verifyChecksum(data, checksum)
+}
+
+// copyWithProgress copies src to dst, reporting progress from offset, and
+// returns the total bytes held by dst
+func copyWithProgress(ctx context.Context, dst io.Writer, src io.Reader, offset, total int64, progress chan<- *DownloadProgress) (int64, error) {
+	started := time.Now()
+	written := offset
+	buf := make([]byte, 32*1024)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return written, err
+			}
+			written += int64(n)
+			if progress != nil {
+				select {
+				case progress <- newProgress(written, offset, total, started):
+				default:
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+// newProgress describes a download that has reached written bytes
+func newProgress(written, offset, total int64, started time.Time) *DownloadProgress {
+	p := &DownloadProgress{
+		BytesDownloaded: written,
+		TotalBytes:      total,
+		StartedAt:       started,
+	}
+
+	if elapsed := time.Since(started).Seconds(); elapsed > 0 {
+		p.Speed = int64(float64(written-offset) / elapsed)
+	}
+	if total > 0 {
+		p.Percentage = float64(written) / float64(total) * 100
+		if p.Speed > 0 {
+			p.TimeRemaining = (total - written) / p.Speed
+		}
+	}
+	return p
+}
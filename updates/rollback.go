@@ -0,0 +1,97 @@
+package updates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// SelfTestFlag is passed to a restored binary to run its self test
+const SelfTestFlag = "--selftest"
+
+// RollbackOptions configures how Rollback restores a checkpoint
+type RollbackOptions struct {
+	// BinaryPath is the installed executable replaced by the checkpoint.
+	// When empty, Rollback only validates the checkpoint.
+	BinaryPath string
+
+	// SelfTest runs the restored binary with SelfTestFlag and requires a
+	// successful exit; on failure the newer binary is put back
+	SelfTest bool
+
+	// SelfTestTimeout bounds the self test (default 30s)
+	SelfTestTimeout time.Duration
+}
+
+// SetRollbackOptions configures rollback for the installed binary
+func (m *DefaultManager) SetRollbackOptions(opts RollbackOptions) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollback = opts
+}
+
+// restore installs a checkpoint binary and verifies it, reverting to the
+// binary it replaced if the self test fails
+func (m *DefaultManager) restore(ctx context.Context, opts RollbackOptions, checkpoint *Checkpoint) error {
+	newer, err := os.ReadFile(opts.BinaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read installed binary: %w", err)
+	}
+
+	if err := replaceBinary(opts.BinaryPath, checkpoint.Binary); err != nil {
+		return fmt.Errorf("failed to restore checkpoint %s: %w", checkpoint.ID, err)
+	}
+
+	if !opts.SelfTest {
+		return nil
+	}
+
+	testErr := runSelfTest(ctx, opts.BinaryPath, opts.SelfTestTimeout)
+	if testErr == nil {
+		return nil
+	}
+
+	if err := replaceBinary(opts.BinaryPath, newer); err != nil {
+		return fmt.Errorf("restored binary failed self test (%v) and reverting failed: %w", testErr, err)
+	}
+	return fmt.Errorf("restored binary failed self test, kept the newer version: %w", testErr)
+}
+
+// runSelfTest runs a binary with SelfTestFlag
+func runSelfTest(ctx context.Context, path string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, SelfTestFlag).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+// replaceBinary atomically writes an executable
+func replaceBinary(path string, binary []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rollback-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -101,6 +101,12 @@ type Manager interface {
 	ListCheckpoints(ctx context.Context) ([]*Checkpoint, error)
 }
 
+// Feed supplies releases from a remote source
+type Feed interface {
+	// Releases returns the currently published releases
+	Releases(ctx context.Context) ([]*Release, error)
+}
+
 // Differ generates binary diffs
 type Differ interface {
 	// Generate generates a diff between two binaries
@@ -2,7 +2,10 @@ package updates
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 // DefaultVerifier implements update verification
@@ -35,12 +38,28 @@ func (v *DefaultVerifier) VerifyChecksum(ctx context.Context, data []byte, check
 		return fmt.Errorf("checksum is required")
 	}
 
-	// Simplified checksum verification (in production, compute actual checksum)
-	computed := fmt.Sprintf("sha256-%d", len(data))
+	return verifyChecksum(data, checksum)
+}
+
+// verifyChecksum checks data against a "sha256:<hex>" digest. Checksums in
+// the simplified "sha256-<size>" form only compare the length.
+func verifyChecksum(data []byte, checksum string) error {
+	if checksum == "" {
+		return fmt.Errorf("checksum is required")
+	}
+
+	var computed string
+	if strings.HasPrefix(checksum, "sha256:") {
+		sum := sha256.Sum256(data)
+		computed = "sha256:" + hex.EncodeToString(sum[:])
+		checksum = strings.ToLower(checksum)
+	} else {
+		computed = fmt.Sprintf("sha256-%d", len(data))
+	}
+
 	if computed != checksum {
 		return fmt.Errorf("checksum mismatch: expected %s, got %s", checksum, computed)
 	}
-
 	return nil
 }
 
@@ -0,0 +1,202 @@
+package webview
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultAssetAddr binds the asset server to a free loopback port
+const DefaultAssetAddr = "127.0.0.1:0"
+
+// AssetServerConfig configures the asset server
+type AssetServerConfig struct {
+	// Addr is the host:port to bind (default DefaultAssetAddr)
+	Addr string
+
+	// AllowExternal permits binding to a non-loopback address such as
+	// 0.0.0.0, which exposes the assets to the network
+	AllowExternal bool
+
+	// CORSOrigins lists origins allowed to fetch assets cross-origin; "*"
+	// allows any origin. Empty sends no CORS headers.
+	CORSOrigins []string
+}
+
+// AssetServer serves frontend assets over HTTP for the webview to load
+type AssetServer struct {
+	config   AssetServerConfig
+	files    http.Handler
+	app      http.Handler
+	server   *http.Server
+	listener net.Listener
+	frames   *Webview
+	mu       sync.Mutex
+}
+
+// NewAssetServer creates a server for assets. Only loopback addresses are
+// accepted unless AllowExternal is set.
+func NewAssetServer(assets fs.FS, config AssetServerConfig) (*AssetServer, error) {
+	server, err := newAssetServer(config)
+	if err != nil {
+		return nil, err
+	}
+	server.files = http.FileServer(http.FS(assets))
+	return server, nil
+}
+
+// newAssetServer validates config for a server whose content the caller
+// sets: files for assets, or app to handle every request itself
+func newAssetServer(config AssetServerConfig) (*AssetServer, error) {
+	if config.Addr == "" {
+		config.Addr = DefaultAssetAddr
+	}
+	if err := validateAssetAddr(config.Addr, config.AllowExternal); err != nil {
+		return nil, err
+	}
+	for _, origin := range config.CORSOrigins {
+		if origin == "" || strings.ContainsAny(origin, "\r\n\x00") {
+			return nil, fmt.Errorf("invalid CORS origin %q", origin)
+		}
+	}
+
+	return &AssetServer{config: config}, nil
+}
+
+// validateAssetAddr rejects non-loopback bind addresses without opt-in
+func validateAssetAddr(addr string, allowExternal bool) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid asset server address %q: %w", addr, err)
+	}
+	if allowExternal || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("asset server address %q is not loopback; set AllowExternal to expose assets to the network", addr)
+}
+
+// Start begins serving and returns the base URL of the assets
+func (s *AssetServer) Start() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return "", fmt.Errorf("asset server already running")
+	}
+
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to start asset server: %w", err)
+	}
+	s.listener = listener
+	s.server = &http.Server{Handler: s}
+	go s.server.Serve(listener)
+
+	return s.url(), nil
+}
+
+// URL returns the base URL of the assets, or "" when not running
+func (s *AssetServer) URL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return ""
+	}
+	return s.url()
+}
+
+func (s *AssetServer) url() string {
+	return "http://" + s.listener.Addr().String() + "/"
+}
+
+// ServeHTTP serves an asset, or passes the request to the app of a
+// browser tab's server, adding CORS headers for allowed origins
+func (s *AssetServer) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if len(s.config.CORSOrigins) > 0 {
+		rw.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && s.allowOrigin(origin) {
+			rw.Header().Set("Access-Control-Allow-Origin", origin)
+			if r.Method == http.MethodOptions {
+				rw.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+				if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+					rw.Header().Set("Access-Control-Allow-Headers", headers)
+				}
+				rw.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+
+	s.mu.Lock()
+	frames := s.frames
+	s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == FramePath && frames != nil:
+		s.serveFrame(rw, r, frames)
+	case s.app != nil:
+		s.app.ServeHTTP(rw, r)
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		s.files.ServeHTTP(rw, r)
+	default:
+		rw.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// allowOrigin reports whether origin may fetch assets cross-origin
+func (s *AssetServer) allowOrigin(origin string) bool {
+	for _, allowed := range s.config.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeAssets serves assets for the window from a new AssetServer, which
+// Run loads in place of config.URL. The server also accepts the page's
+// posted frames, and closes with the window. Call it before Run.
+func (w *Webview) ServeAssets(assets fs.FS, config AssetServerConfig) (*AssetServer, error) {
+	server, err := NewAssetServer(assets, config)
+	if err != nil {
+		return nil, err
+	}
+	url, err := server.Start()
+	if err != nil {
+		return nil, err
+	}
+	server.ServeFrames(w)
+
+	w.mu.Lock()
+	previous := w.assets
+	w.assets = server
+	w.config.URL = url
+	w.mu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
+	return server, nil
+}
+
+// Close stops the server
+func (s *AssetServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	s.server = nil
+	s.listener = nil
+	return err
+}
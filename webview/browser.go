@@ -0,0 +1,689 @@
+package webview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// Mode is how a Webview shows its page
+type Mode string
+
+const (
+	// ModeNative shows the page in a native webview window
+	ModeNative Mode = "native"
+
+	// ModeBrowser shows the page in a tab of the user's default browser,
+	// served with the bridge over loopback HTTP
+	ModeBrowser Mode = "browser"
+)
+
+// Browser backend endpoints, served alongside the page
+const (
+	browserScriptPath = "/__polyglot/init.js"
+	browserBindPath   = "/__polyglot/bind/"
+	browserEventsPath = "/__polyglot/events"
+)
+
+// browserTokenParam carries the session token in the URL opened in the
+// browser, which swaps it for a cookie
+const browserTokenParam = "polyglot_token"
+
+// browserCookie holds the session token once the page has loaded
+const browserCookie = "polyglot_session"
+
+// OpenBrowser opens url in the user's default browser. Replace it to open
+// the page some other way, such as printing the URL on a server.
+var OpenBrowser = openBrowser
+
+// openBrowser launches the platform's URL handler
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// BrowserConfig configures a BrowserBackend
+type BrowserConfig struct {
+	// Server configures the loopback server the tab loads from
+	Server AssetServerConfig
+
+	// MaxMessageBytes caps the body of a binding call. Zero uses
+	// core.DefaultMaxMessageBytes; a negative value disables the limit.
+	MaxMessageBytes int
+
+	// Logger receives warnings, such as a page that cannot be served
+	// (default core.DefaultLogger())
+	Logger core.Logger
+}
+
+// BrowserBackend implements WebviewBackend with a browser tab: Navigate
+// serves the page from an AssetServer, injecting a script that exposes
+// bound functions as fetch calls and runs scripts passed to Eval, and
+// opens it with OpenBrowser. Window controls are not available.
+//
+// Only the tab opened by OpenBrowser can reach the bridge: the URL carries
+// a session token that the page exchanges for a same-site cookie.
+type BrowserBackend struct {
+	mu       sync.Mutex
+	title    string
+	bindings map[string]reflect.Value
+	scripts  []string
+	page     http.Handler
+	token    string
+	server   *AssetServer
+	address  string
+	opened   bool
+	limit    int64
+	logger   core.Logger
+	clients  map[chan string]struct{}
+	pending  []string
+	done     chan struct{}
+	closed   bool
+}
+
+// NewBrowserBackend creates a browser tab backend and starts its server,
+// so a backend that is returned is ready to serve the tab
+func NewBrowserBackend(config BrowserConfig) (*BrowserBackend, error) {
+	limit := int64(config.MaxMessageBytes)
+	if limit == 0 {
+		limit = core.DefaultMaxMessageBytes
+	}
+	if config.Logger == nil {
+		config.Logger = core.DefaultLogger()
+	}
+
+	b := &BrowserBackend{
+		bindings: make(map[string]reflect.Value),
+		page:     http.NotFoundHandler(),
+		token:    newFrameToken(),
+		limit:    limit,
+		logger:   config.Logger,
+		clients:  make(map[chan string]struct{}),
+		done:     make(chan struct{}),
+	}
+
+	server, err := newAssetServer(config.Server)
+	if err != nil {
+		return nil, err
+	}
+	server.app = b
+	address, err := server.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start browser server: %w", err)
+	}
+	b.server = server
+	b.address = address + "?" + browserTokenParam + "=" + b.token
+	return b, nil
+}
+
+// SetTitle sets the document title once the page loads
+func (b *BrowserBackend) SetTitle(title string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.title = title
+}
+
+// SetSize is ignored: the browser sizes its tabs
+func (b *BrowserBackend) SetSize(w, h int, hint Hint) {}
+
+// Navigate serves url and opens it in the browser the first time, or
+// reloads open tabs afterwards
+func (b *BrowserBackend) Navigate(target string) {
+	page, err := browserPage(target)
+	if err != nil {
+		b.logger.Log(core.LogWarn, err.Error())
+		page = http.NotFoundHandler()
+	}
+
+	b.mu.Lock()
+	b.page = page
+	opened := b.opened
+	b.opened = true
+	b.mu.Unlock()
+
+	if opened {
+		b.Eval("location.reload()")
+		return
+	}
+	if err := OpenBrowser(b.address); err != nil {
+		b.logger.Log(core.LogWarn, fmt.Sprintf("%v; open %s to use the app", err, b.address))
+	}
+}
+
+// URL returns the address that opens the app in a browser tab, session
+// token included
+func (b *BrowserBackend) URL() string {
+	return b.address
+}
+
+// Run blocks until Terminate
+func (b *BrowserBackend) Run() {
+	<-b.done
+}
+
+// Eval runs script in every open tab, or in the first tab to connect when
+// none is open yet
+func (b *BrowserBackend) Eval(script string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.clients) == 0 {
+		b.pending = append(b.pending, script)
+		return
+	}
+	for client := range b.clients {
+		select {
+		case client <- script:
+		default:
+			// The tab stopped reading; it reconnects and reloads
+		}
+	}
+}
+
+// Bind exposes fn to the page as a function returning a Promise, with the
+// same conversions as the native backend: arguments are decoded from JSON
+// into fn's parameters, and a non-nil error return rejects the Promise
+// with the error's message
+func (b *BrowserBackend) Bind(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fmt.Errorf("binding %s is not a function", name)
+	}
+	if out := v.Type().NumOut(); out > 2 || (out == 2 && v.Type().Out(1) != errorType) {
+		return fmt.Errorf("binding %s must return a value, an error, or both", name)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, exists := b.bindings[name]; exists {
+		return fmt.Errorf("binding %s already exists", name)
+	}
+	b.bindings[name] = v
+	return nil
+}
+
+// Init runs script at the start of every page load
+func (b *BrowserBackend) Init(script string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.scripts = append(b.scripts, script)
+}
+
+// SetWindowOptions reports every requested feature as unsupported
+func (b *BrowserBackend) SetWindowOptions(opts WindowOptions) error {
+	var unsupported []string
+	if opts.Transparent {
+		unsupported = append(unsupported, "transparent")
+	}
+	if opts.Frameless {
+		unsupported = append(unsupported, "frameless")
+	}
+	if opts.AlwaysOnTop {
+		unsupported = append(unsupported, "always-on-top")
+	}
+	if opts.Fullscreen {
+		unsupported = append(unsupported, "fullscreen")
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("unsupported window options in a browser tab: %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// SetWindowState is not supported: pages cannot resize their browser
+func (b *BrowserBackend) SetWindowState(state WindowState) error {
+	return fmt.Errorf("window state %s not supported in a browser tab", state)
+}
+
+// SetUserAgent is not supported: the browser sends its own user agent
+func (b *BrowserBackend) SetUserAgent(ua string) error {
+	if ua == "" {
+		return nil
+	}
+	return fmt.Errorf("user agent override not supported in a browser tab")
+}
+
+// SetRequestHeaders is not supported: the browser makes its own requests
+func (b *BrowserBackend) SetRequestHeaders(headers map[string]string) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	return fmt.Errorf("custom request headers not supported in a browser tab")
+}
+
+// SetZoom is not supported: the browser's own zoom applies to the tab
+func (b *BrowserBackend) SetZoom(factor float64) error {
+	if factor == 1 {
+		return nil
+	}
+	return fmt.Errorf("zoom not supported in a browser tab")
+}
+
+// Terminate stops the server and ends Run. Open tabs stay open but lose
+// the bridge.
+func (b *BrowserBackend) Terminate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.done)
+	b.server.Close()
+	for client := range b.clients {
+		close(client)
+	}
+	b.clients = make(map[chan string]struct{})
+}
+
+// Destroy releases the server
+func (b *BrowserBackend) Destroy() {
+	b.Terminate()
+}
+
+// ServeHTTP serves the page, the injected script, binding calls and the
+// Eval event stream to the session's tab
+func (b *BrowserBackend) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if token := r.URL.Query().Get(browserTokenParam); token != "" && token == b.token {
+		http.SetCookie(rw, &http.Cookie{
+			Name:     browserCookie,
+			Value:    b.token,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		query := r.URL.Query()
+		query.Del(browserTokenParam)
+		clean := *r.URL
+		clean.RawQuery = query.Encode()
+		http.Redirect(rw, r, clean.RequestURI(), http.StatusFound)
+		return
+	}
+	if !b.authorized(r) {
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.URL.Path == browserScriptPath:
+		rw.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		rw.Header().Set("Cache-Control", "no-store")
+		io.WriteString(rw, b.script())
+	case r.URL.Path == browserEventsPath:
+		b.serveEvents(rw, r)
+	case strings.HasPrefix(r.URL.Path, browserBindPath):
+		b.serveBinding(rw, r, strings.TrimPrefix(r.URL.Path, browserBindPath))
+	default:
+		b.mu.Lock()
+		page := b.page
+		b.mu.Unlock()
+		page.ServeHTTP(rw, r)
+	}
+}
+
+// authorized reports whether r comes from the session's tab, by cookie or,
+// for bindings, by the token header
+func (b *BrowserBackend) authorized(r *http.Request) bool {
+	if r.Header.Get(frameTokenHeader) == b.token {
+		return true
+	}
+	cookie, err := r.Cookie(browserCookie)
+	return err == nil && cookie.Value == b.token
+}
+
+// script builds the injected script: binding stubs, an Eval listener and
+// the Init scripts
+func (b *BrowserBackend) script() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	names := make([]string, 0, len(b.bindings))
+	for name := range b.bindings {
+		names = append(names, name)
+	}
+	encodedNames, _ := json.Marshal(names)
+	token, _ := json.Marshal(b.token)
+	title, _ := json.Marshal(b.title)
+
+	var script strings.Builder
+	fmt.Fprintf(&script, `(function() {
+	const token = %s;
+	const title = %s;
+	const call = function(name, args) {
+		return fetch(%q + encodeURIComponent(name), {
+			method: 'POST',
+			headers: { 'Content-Type': 'application/json', %q: token },
+			body: JSON.stringify(args)
+		}).then(function(response) {
+			return response.json();
+		}).then(function(reply) {
+			if ('error' in reply) {
+				throw reply.error;
+			}
+			return reply.result;
+		});
+	};
+	%s.forEach(function(name) {
+		window[name] = function(...args) { return call(name, args); };
+	});
+	const events = new EventSource(%q);
+	events.onmessage = function(event) { (0, eval)(event.data); };
+	if (title) {
+		document.addEventListener('DOMContentLoaded', function() {
+			if (!document.title) document.title = title;
+		});
+	}
+})();
+`, token, title, browserBindPath, frameTokenHeader, encodedNames, browserEventsPath)
+	for _, init := range b.scripts {
+		script.WriteString(init)
+		script.WriteString("\n")
+	}
+	return script.String()
+}
+
+// serveBinding calls a bound function with the JSON array of arguments in
+// the request body, replying {"result": ...} or {"error": "..."}. Bodies
+// over the message limit are refused before they are decoded.
+func (b *BrowserBackend) serveBinding(rw http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", "POST")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	b.mu.Lock()
+	fn, ok := b.bindings[name]
+	b.mu.Unlock()
+	if !ok {
+		http.Error(rw, "binding not found", http.StatusNotFound)
+		return
+	}
+
+	if b.limit > 0 {
+		r.Body = http.MaxBytesReader(rw, r.Body, b.limit)
+	}
+	var args []json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(rw, fmt.Sprintf("message exceeds limit of %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(rw, "invalid arguments", http.StatusBadRequest)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	result, err := callBinding(fn, args)
+	if err != nil {
+		json.NewEncoder(rw).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(map[string]interface{}{"result": result}); err != nil {
+		json.NewEncoder(rw).Encode(map[string]string{"error": err.Error()})
+	}
+}
+
+// errorType is the reflect.Type of error
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// callBinding decodes args into fn's parameters and calls it
+func callBinding(fn reflect.Value, args []json.RawMessage) (interface{}, error) {
+	t := fn.Type()
+	if !t.IsVariadic() && len(args) != t.NumIn() {
+		return nil, fmt.Errorf("expected %d arguments, got %d", t.NumIn(), len(args))
+	}
+	if t.IsVariadic() && len(args) < t.NumIn()-1 {
+		return nil, fmt.Errorf("expected at least %d arguments, got %d", t.NumIn()-1, len(args))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, raw := range args {
+		param := t.In(min(i, t.NumIn()-1))
+		if t.IsVariadic() && i >= t.NumIn()-1 {
+			param = param.Elem()
+		}
+		arg := reflect.New(param)
+		if err := json.Unmarshal(raw, arg.Interface()); err != nil {
+			return nil, fmt.Errorf("invalid argument %d: %w", i, err)
+		}
+		in[i] = arg.Elem()
+	}
+
+	out := fn.Call(in)
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		if t.Out(0) == errorType {
+			err, _ := out[0].Interface().(error)
+			return nil, err
+		}
+		return out[0].Interface(), nil
+	default:
+		err, _ := out[1].Interface().(error)
+		return out[0].Interface(), err
+	}
+}
+
+// serveEvents streams scripts passed to Eval as server-sent events
+func (b *BrowserBackend) serveEvents(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	client := make(chan string, 64)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	for _, script := range b.pending {
+		client <- script
+	}
+	b.pending = nil
+	b.clients[client] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, client)
+		b.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case script, ok := <-client:
+			if !ok {
+				return
+			}
+			for _, line := range strings.Split(script, "\n") {
+				fmt.Fprintf(rw, "data: %s\n", line)
+			}
+			io.WriteString(rw, "\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// browserPage serves the page at target: the document of a data: URL, the
+// files next to a file: URL, or an http(s) site through a reverse proxy.
+// HTML responses get the injected script.
+func browserPage(target string) (http.Handler, error) {
+	switch {
+	case target == "" || target == "about:blank":
+		return htmlPage([]byte("<!DOCTYPE html><html><head></head><body></body></html>")), nil
+	case strings.HasPrefix(target, "data:"):
+		html, err := decodeDataURL(target)
+		if err != nil {
+			return nil, err
+		}
+		return htmlPage(html), nil
+	case strings.HasPrefix(target, "file:"):
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page URL %q: %w", target, err)
+		}
+		return filePage(filepath.FromSlash(parsed.Path)), nil
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page URL %q: %w", target, err)
+		}
+		return proxyPage(parsed), nil
+	}
+	return nil, fmt.Errorf("cannot serve %q in a browser tab", target)
+}
+
+// htmlPage serves html at the root
+func htmlPage(html []byte) http.Handler {
+	page := injectScript(html)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write(page)
+	})
+}
+
+// filePage serves path at the root and the files beside it
+func filePage(path string) http.Handler {
+	dir, index := filepath.Dir(path), "/"+filepath.Base(path)
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path
+		if name == "/" {
+			name = index
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".html" && ext != ".htm" {
+			files.ServeHTTP(rw, r)
+			return
+		}
+		html, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(filepath.Clean(name))))
+		if err != nil {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write(injectScript(html))
+	})
+}
+
+// proxyPage forwards requests to the site at target
+func proxyPage(target *url.URL) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = target.Host
+		// Responses must arrive uncompressed to inject the script
+		r.Header.Del("Accept-Encoding")
+		r.Header.Del("Cookie")
+	}
+	proxy.ModifyResponse = func(response *http.Response) error {
+		if !strings.HasPrefix(response.Header.Get("Content-Type"), "text/html") {
+			return nil
+		}
+		html, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+		html = injectScript(html)
+		response.Body = io.NopCloser(bytes.NewReader(html))
+		response.ContentLength = int64(len(html))
+		response.Header.Set("Content-Length", strconv.Itoa(len(html)))
+		return nil
+	}
+	return proxy
+}
+
+// injectScript loads the browser backend's script before any script of the
+// page
+func injectScript(html []byte) []byte {
+	tag := []byte(`<script src="` + browserScriptPath + `"></script>`)
+	lower := bytes.ToLower(html)
+	for _, anchor := range []string{"<head", "<html"} {
+		if i := bytes.Index(lower, []byte(anchor)); i >= 0 {
+			if end := bytes.IndexByte(html[i:], '>'); end >= 0 {
+				at := i + end + 1
+				return append(append(append([]byte{}, html[:at]...), tag...), html[at:]...)
+			}
+		}
+	}
+	return append(tag, html...)
+}
+
+// decodeDataURL returns the document of a data: URL. Percent signs not
+// starting an escape are kept, as browsers do.
+func decodeDataURL(target string) ([]byte, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(target, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data URL")
+	}
+	decoded := lenientUnescape(data)
+	if strings.HasSuffix(header, ";base64") {
+		raw, err := base64.StdEncoding.DecodeString(string(decoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data URL: %w", err)
+		}
+		return raw, nil
+	}
+	return decoded, nil
+}
+
+// lenientUnescape decodes %XX escapes, leaving other percent signs
+func lenientUnescape(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if b, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				out = append(out, byte(b))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package webview
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// ProtocolVersion is the version of the protocol between the injected
+// bridge script and the Go side. It increases when a change would break
+// pages written against the previous version.
+const ProtocolVersion = 1
+
+// Capabilities advertises the bridge features the backend offers. Pages
+// read it as window.polyglot.capabilities to feature-detect, for example
+// using a batch endpoint only when it exists.
+type Capabilities struct {
+	// Protocol is ProtocolVersion
+	Protocol int `json:"protocol"`
+
+	// Mode is "native", or "browser" when the page runs in a browser tab
+	// without window controls
+	Mode Mode `json:"mode"`
+
+	// Serialization lists the wire formats the backend accepts
+	Serialization []string `json:"serialization"`
+
+	// Numbers is the number policy, "float" or "integer"
+	Numbers string `json:"numbers"`
+
+	// Times is the time policy, "rfc3339" or "millis"
+	Times string `json:"times"`
+
+	// Binary is how binary frames travel, "base64" or "transfer"
+	Binary string `json:"binary"`
+
+	// Batch lists the function groups with a "<group>.batch" endpoint
+	Batch []string `json:"batch"`
+
+	// Events, Files, Streams and Console report support for polyglot.on,
+	// FileResponse downloads, ResultStream results and console forwarding
+	Events  bool `json:"events"`
+	Files   bool `json:"files"`
+	Streams bool `json:"streams"`
+	Console bool `json:"console"`
+
+	// Retry reports whether failed calls are retried automatically
+	Retry bool `json:"retry"`
+
+	// Functions lists the callable bridge functions, when the bridge can
+	// enumerate them
+	Functions []string `json:"functions"`
+}
+
+// Capabilities reports what the bridge currently supports. Functions and
+// batch groups registered after the page loads are picked up by
+// window.polyglot.refreshCapabilities.
+func (w *Webview) Capabilities() Capabilities {
+	caps := Capabilities{
+		Protocol:      ProtocolVersion,
+		Mode:          w.mode,
+		Serialization: []string{core.FormatJSON},
+		Numbers:       string(core.ParseNumberPolicy(w.config.Numbers)),
+		Times:         string(core.ParseTimePolicy(w.config.Times)),
+		Binary:        core.BinaryBase64,
+		Batch:         []string{},
+		Events:        true,
+		Files:         w.bridge != nil,
+		Streams:       w.bridge != nil,
+		Console:       w.config.CaptureConsole,
+		Retry:         w.config.Retry != nil && w.config.Retry.MaxAttempts > 1,
+		Functions:     []string{},
+	}
+	if w.config.Serialization == core.FormatMsgpack {
+		caps.Serialization = append(caps.Serialization, core.FormatMsgpack)
+	}
+	if w.config.Binary == core.BinaryTransfer {
+		caps.Binary = core.BinaryTransfer
+	}
+
+	if lister, ok := w.bridge.(interface{ Functions() []string }); ok {
+		caps.Functions = append(caps.Functions, lister.Functions()...)
+		sort.Strings(caps.Functions)
+		for _, name := range caps.Functions {
+			if group, ok := strings.CutSuffix(name, ".batch"); ok {
+				caps.Batch = append(caps.Batch, group)
+			}
+		}
+	}
+	return caps
+}
+
+// capabilitiesJSON encodes the capabilities for the page
+func (w *Webview) capabilitiesJSON() string {
+	encoded, err := json.Marshal(w.Capabilities())
+	if err != nil {
+		return "null"
+	}
+	return string(encoded)
+}
+
+// bindCapabilities lets the page fetch fresh capabilities
+func (w *Webview) bindCapabilities() {
+	w.instance.Bind("__polyglot_capabilities__", func() (string, error) {
+		return w.capabilitiesJSON(), nil
+	})
+}
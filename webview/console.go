@@ -0,0 +1,65 @@
+package webview
+
+import "github.com/griffincancode/polyglot.js/core"
+
+// consoleScript wraps console methods so their output is forwarded to Go
+// while still reaching DevTools
+const consoleScript = `
+	(function() {
+		const format = function(arg) {
+			if (typeof arg === 'string') return arg;
+			if (arg instanceof Error) return arg.stack || arg.message;
+			try { return JSON.stringify(arg); } catch (e) { return String(arg); }
+		};
+		['debug', 'log', 'info', 'warn', 'error'].forEach(function(method) {
+			const original = console[method];
+			console[method] = function(...args) {
+				original.apply(console, args);
+				try {
+					__polyglot_console__(method, args.map(format).join(' '));
+				} catch (e) {}
+			};
+		});
+	})();
+`
+
+// ConsoleLevel maps a JavaScript console method to a log level
+func ConsoleLevel(method string) core.LogLevel {
+	switch method {
+	case "debug":
+		return core.LogDebug
+	case "warn":
+		return core.LogWarn
+	case "error":
+		return core.LogError
+	default:
+		return core.LogInfo
+	}
+}
+
+// SetLogger sets the logger receiving captured console output and
+// webview warnings. A nil logger discards them.
+func (w *Webview) SetLogger(logger core.Logger) {
+	if logger == nil {
+		logger = core.NopLogger{}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logger = logger
+}
+
+// bindConsole forwards console output to the logger when CaptureConsole is set
+func (w *Webview) bindConsole() {
+	if !w.config.CaptureConsole {
+		return
+	}
+
+	w.instance.Bind("__polyglot_console__", func(method string, message string) {
+		w.mu.Lock()
+		logger := w.logger
+		w.mu.Unlock()
+		logger.Log(ConsoleLevel(method), "[console] "+message)
+	})
+	w.instance.Init(consoleScript)
+}
@@ -0,0 +1,57 @@
+package webview
+
+import (
+	"encoding/base64"
+	"mime"
+	"strings"
+)
+
+// DataURL embeds content in a data: URL the webview can load, such as a
+// page for WebviewConfig.URL. Text types are percent-encoded, keeping the
+// URL readable, and get a UTF-8 charset unless mimeType names one; other
+// types are base64-encoded. An empty mimeType means text/plain.
+func DataURL(mimeType string, content []byte) string {
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	if err != nil || !textual(mediaType) {
+		return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(content)
+	}
+	if _, ok := params["charset"]; !ok {
+		mimeType += ";charset=utf-8"
+	}
+	return "data:" + mimeType + "," + percentEncode(content)
+}
+
+// textual reports whether a media type holds text worth keeping readable
+func textual(mediaType string) bool {
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml", "image/svg+xml":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/")
+}
+
+// dataURLSafe holds the bytes left as they are in percent-encoded data:
+// URLs: unreserved characters and delimiters with no special meaning after
+// the comma. '#' and '%' are escaped, as are spaces, quotes, angle
+// brackets, controls and non-ASCII bytes.
+const dataURLSafe = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~!$&'()*+,;=:@/?"
+
+// percentEncode escapes every byte of content outside dataURLSafe
+func percentEncode(content []byte) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(content))
+	for _, c := range content {
+		if strings.IndexByte(dataURLSafe, c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0F])
+	}
+	return b.String()
+}
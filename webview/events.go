@@ -0,0 +1,126 @@
+package webview
+
+import (
+	"context"
+	"sync"
+)
+
+// pendingEvents counts events queued for the frontend, such as those of
+// StreamLogs and StreamProgress, that have not been passed to Emit yet
+type pendingEvents struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+// add records n newly queued events
+func (p *pendingEvents) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.count == 0 {
+		p.idle = make(chan struct{})
+	}
+	p.count += n
+}
+
+// done records that a queued event was emitted
+func (p *pendingEvents) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count--
+	if p.count == 0 {
+		close(p.idle)
+	}
+}
+
+// wait blocks until no events are queued or ctx is done
+func (p *pendingEvents) wait(ctx context.Context) error {
+	p.mu.Lock()
+	if p.count == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	idle := p.idle
+	p.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FlushEvents blocks until every event queued for the frontend so far has
+// been emitted, or ctx is done. Call it before Terminate so shutdown does
+// not drop the last log lines and progress reports of running
+// executions. Events queued while it waits are waited for too.
+func (w *Webview) FlushEvents(ctx context.Context) error {
+	return w.pending.wait(ctx)
+}
+
+// eventChannels sequences the events of each name
+type eventChannels struct {
+	mu       sync.Mutex
+	channels map[string]*eventChannel
+}
+
+// eventChannel holds the sequence of one event name. Its lock is held
+// while an event is sent, so sends of one name never overlap.
+type eventChannel struct {
+	mu  sync.Mutex
+	seq uint64
+}
+
+// get returns the channel of event, creating it on first use
+func (c *eventChannels) get(event string) *eventChannel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.channels == nil {
+		c.channels = make(map[string]*eventChannel)
+	}
+	channel, ok := c.channels[event]
+	if !ok {
+		channel = &eventChannel{}
+		c.channels[event] = channel
+	}
+	return channel
+}
+
+// emitScript delivers one event through the page's sequencer, or
+// dispatches it directly on pages loaded without one
+const emitScript = `(function(name, seq, detail) {
+	if (window.__polyglot_event__) window.__polyglot_event__(name, seq, detail);
+	else window.dispatchEvent(new CustomEvent(name, { detail: detail }));
+})(%s, %d, %s)`
+
+// bindEvents installs the page's sequencer, which dispatches the events of
+// each name in sequence order. An event arriving ahead of its predecessor
+// is held until the predecessor arrives. A page loaded mid-stream starts
+// from the first event it receives, and dispatches any earlier one late
+// rather than dropping it.
+func (w *Webview) bindEvents() {
+	w.instance.Init(`
+		window.__polyglot_event__ = (function() {
+			const channels = {};
+			const dispatch = function(name, detail) {
+				window.dispatchEvent(new CustomEvent(name, { detail: detail }));
+			};
+			return function(name, seq, detail) {
+				let channel = channels[name];
+				if (!channel) channel = channels[name] = { next: seq, held: new Map() };
+				if (seq < channel.next) {
+					dispatch(name, detail);
+					return;
+				}
+				channel.held.set(seq, detail);
+				while (channel.held.has(channel.next)) {
+					const next = channel.held.get(channel.next);
+					channel.held.delete(channel.next);
+					channel.next++;
+					dispatch(name, next);
+				}
+			};
+		})();
+	`)
+}
@@ -0,0 +1,166 @@
+package webview
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// fileChunkSize is the number of bytes sent per read from the frontend
+const fileChunkSize = 256 * 1024
+
+// fileStreams holds FileResponse readers until the frontend drains them
+type fileStreams struct {
+	mu      sync.Mutex
+	next    int
+	readers map[string]io.Reader
+}
+
+// open registers a reader and returns its stream id
+func (s *fileStreams) open(r io.Reader) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readers == nil {
+		s.readers = make(map[string]io.Reader)
+	}
+	s.next++
+	id := strconv.Itoa(s.next)
+	s.readers[id] = r
+	return id
+}
+
+// read returns the next base64 encoded chunk of a stream, or an empty
+// string once the stream is exhausted. The stream is closed on every path
+// that does not return a chunk, including a panicking reader.
+func (s *fileStreams) read(id string) (string, error) {
+	s.mu.Lock()
+	r, ok := s.readers[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return "", core.Errorf(core.CodeNotFound, "file stream %s not found", id)
+	}
+
+	done := true
+	defer func() {
+		if done {
+			s.close(id)
+		}
+	}()
+
+	buf := make([]byte, fileChunkSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if n == 0 {
+			return "", nil
+		}
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read file stream: %w", err)
+	}
+
+	done = false
+	return base64.StdEncoding.EncodeToString(buf[:n]), nil
+}
+
+// close removes a stream and closes its reader
+func (s *fileStreams) close(id string) {
+	s.mu.Lock()
+	r, ok := s.readers[id]
+	delete(s.readers, id)
+	s.mu.Unlock()
+
+	if closer, isCloser := r.(io.Closer); ok && isCloser {
+		closer.Close()
+	}
+}
+
+// closeAll releases every open stream
+func (s *fileStreams) closeAll() {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.readers))
+	for id := range s.readers {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		s.close(id)
+	}
+}
+
+// fileResponse returns result as a FileResponse with a reader, or nil
+func fileResponse(result interface{}) *core.FileResponse {
+	var file *core.FileResponse
+	switch f := result.(type) {
+	case *core.FileResponse:
+		file = f
+	case core.FileResponse:
+		file = &f
+	}
+
+	if file == nil || file.Reader == nil {
+		return nil
+	}
+	return file
+}
+
+// closeFile closes a FileResponse result's reader if it is a Closer
+func closeFile(result interface{}) {
+	if file := fileResponse(result); file != nil {
+		if closer, ok := file.Reader.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// fileDescriptor replaces a FileResponse result with the metadata the
+// frontend needs to stream it
+func (w *Webview) fileDescriptor(result interface{}) interface{} {
+	file := fileResponse(result)
+	if file == nil {
+		return result
+	}
+
+	return map[string]interface{}{
+		"__polyglot_file__": map[string]interface{}{
+			"id":       w.files.open(file.Reader),
+			"name":     file.Name,
+			"mimeType": file.MimeType,
+		},
+	}
+}
+
+// imageDescriptor sends an image with its MIME type. Data stays []byte so
+// frames carry it as a raw blob; JSON and MessagePack encode it as base64
+// text and binary.
+func imageDescriptor(image *core.Image) interface{} {
+	return map[string]interface{}{
+		core.ImageKey: map[string]interface{}{
+			"mimeType": image.MimeType,
+			"data":     image.Data,
+		},
+	}
+}
+
+// bindFiles lets the frontend read FileResponse streams chunk by chunk,
+// and close them if it stops reading early
+func (w *Webview) bindFiles() {
+	w.instance.Bind("__polyglot_read__", func(id string) (string, error) {
+		chunk, err := w.files.read(id)
+		if err != nil {
+			return "", bridgeError(err)
+		}
+		return chunk, nil
+	})
+
+	w.instance.Bind("__polyglot_close_file__", func(id string) {
+		w.files.close(id)
+	})
+}
@@ -0,0 +1,349 @@
+package webview
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// Binary frames carry bridge calls whose arguments or results contain raw
+// bytes, such as ArrayBuffers and typed arrays from JavaScript or []byte
+// from Go, without encoding each byte as JSON. A frame is:
+//
+//	"PGF2"                 magic
+//	uint32 (big endian)    header length N
+//	N bytes                header JSON
+//	blobs                  the byte values, back to back
+//
+// The header is {"value": v, "sizes": [n0, n1, ...], "paths": [p0, p1,
+// ...]}, where v is the payload with each byte value replaced by null.
+// Blob i, whose length is sizes[i], belongs at path pi: the array indexes
+// and object keys leading to it from v. Placing blobs by path rather than
+// by a marker inside v means no payload value can pass for a blob.
+// Arguments decode as []interface{} with blobs as []byte; JavaScript
+// receives blobs as Uint8Array.
+//
+// Under MessagePack serialization, calls without binary arguments are
+// posted to FramePath with format=msgpack as raw MessagePack instead, and
+// the result comes back the same way.
+const frameMagic = "PGF2"
+
+// FramePath is the asset server endpoint receiving frames and MessagePack
+// calls posted by the page when Binary is "transfer"
+const FramePath = "/__polyglot/frame"
+
+// frameTokenHeader authenticates frames posted to the asset server
+const frameTokenHeader = "X-Polyglot-Token"
+
+type frameHeader struct {
+	Value interface{}     `json:"value"`
+	Sizes []int           `json:"sizes"`
+	Paths [][]interface{} `json:"paths"`
+}
+
+// EncodeFrame encodes v as a binary frame, moving []byte values into blobs
+func EncodeFrame(v interface{}) ([]byte, error) {
+	var blobs [][]byte
+	var sizes []int
+	var paths [][]interface{}
+	value := extractBlobs(v, nil, func(b []byte, path []interface{}) {
+		blobs = append(blobs, b)
+		sizes = append(sizes, len(b))
+		paths = append(paths, path)
+	})
+
+	header, err := json.Marshal(frameHeader{Value: value, Sizes: sizes, Paths: paths})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode frame: %w", err)
+	}
+
+	size := len(frameMagic) + 4 + len(header)
+	for _, b := range blobs {
+		size += len(b)
+	}
+	frame := make([]byte, 0, size)
+	frame = append(frame, frameMagic...)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(header)))
+	frame = append(frame, header...)
+	for _, b := range blobs {
+		frame = append(frame, b...)
+	}
+	return frame, nil
+}
+
+// DecodeFrame decodes a binary frame, decoding the header with codec and
+// restoring blobs as []byte
+func DecodeFrame(codec core.Codec, frame []byte) (interface{}, error) {
+	encoded, data, err := splitFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := codec.Unmarshal(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid frame header: %w", err)
+	}
+	header, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid frame header")
+	}
+
+	sizes, _ := header["sizes"].([]interface{})
+	paths, _ := header["paths"].([]interface{})
+	if len(paths) != len(sizes) {
+		return nil, fmt.Errorf("frame has %d blobs but %d blob paths", len(sizes), len(paths))
+	}
+	blobs := make([][]byte, 0, len(sizes))
+	for _, s := range sizes {
+		n, ok := frameInt(s)
+		if !ok || n < 0 || n > len(data) {
+			return nil, fmt.Errorf("truncated frame blob")
+		}
+		blobs = append(blobs, data[:n:n])
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("unexpected data after frame blobs")
+	}
+
+	value := header["value"]
+	for i, path := range paths {
+		steps, ok := path.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid frame blob path %v", path)
+		}
+		if value, err = placeBlob(value, steps, blobs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// splitFrame splits a binary frame into its encoded header and the blobs
+// that follow it
+func splitFrame(frame []byte) (header, blobs []byte, err error) {
+	if len(frame) < len(frameMagic)+4 || string(frame[:len(frameMagic)]) != frameMagic {
+		return nil, nil, fmt.Errorf("not a binary frame")
+	}
+	headerLen := int(binary.BigEndian.Uint32(frame[len(frameMagic):]))
+	rest := frame[len(frameMagic)+4:]
+	if headerLen > len(rest) {
+		return nil, nil, fmt.Errorf("truncated frame header")
+	}
+	return rest[:headerLen], rest[headerLen:], nil
+}
+
+// extractBlobs replaces []byte values in v with nil, passing each to add
+// with its path from the root of v
+func extractBlobs(v interface{}, path []interface{}, add func([]byte, []interface{})) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		add(val, append([]interface{}{}, path...))
+		return nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = extractBlobs(item, append(path, i), add)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			out[key] = extractBlobs(item, append(path, key), add)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// placeBlob sets the value at path inside v to blob, returning the new
+// root. Each step is an index into an array or a key of an object.
+func placeBlob(v interface{}, path []interface{}, blob []byte) (interface{}, error) {
+	if len(path) == 0 {
+		return blob, nil
+	}
+
+	parent := v
+	for i, step := range path {
+		last := i == len(path)-1
+		switch container := parent.(type) {
+		case []interface{}:
+			index, ok := frameInt(step)
+			if !ok || index < 0 || index >= len(container) {
+				return nil, fmt.Errorf("invalid frame blob path %v", path)
+			}
+			if last {
+				container[index] = blob
+			} else {
+				parent = container[index]
+			}
+		case map[string]interface{}:
+			key, ok := step.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid frame blob path %v", path)
+			}
+			if last {
+				container[key] = blob
+			} else {
+				parent = container[key]
+			}
+		default:
+			return nil, fmt.Errorf("invalid frame blob path %v", path)
+		}
+	}
+	return v, nil
+}
+
+// frameInt reads an integer decoded under either number policy
+func frameInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), n == float64(int(n))
+	case int64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// invokeFrame decodes a frame of arguments, calls the bridge, and encodes
+// the result as a frame
+func (w *Webview) invokeFrame(name string, frame []byte) ([]byte, error) {
+	if err := w.checkMessageSize(len(frame)); err != nil {
+		return nil, err
+	}
+	if header, _, err := splitFrame(frame); err == nil {
+		if err := w.checkMessageShape(header); err != nil {
+			return nil, err
+		}
+	}
+
+	decoded, err := DecodeFrame(bridgeCodec(w.config, core.FormatJSON), frame)
+	if err != nil {
+		return nil, core.Errorf(core.CodeInvalidArgument, "invalid arguments: %w", err)
+	}
+	args, ok := decoded.([]interface{})
+	if decoded != nil && !ok {
+		return nil, core.Errorf(core.CodeInvalidArgument, "invalid arguments: expected array, got %T", decoded)
+	}
+
+	result, err := w.bridge.Call(context.Background(), name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	described := w.describe(result)
+	encoded, err := EncodeFrame(described)
+	if err != nil {
+		w.discard(described)
+		return nil, fmt.Errorf("failed to serialize result: %w", err)
+	}
+	return encoded, nil
+}
+
+// invokePacked decodes MessagePack arguments, calls the bridge, and encodes
+// the result as MessagePack
+func (w *Webview) invokePacked(name string, payload []byte) ([]byte, error) {
+	if w.config.Serialization != core.FormatMsgpack {
+		return nil, core.NewError(core.CodeInvalidArgument, "MessagePack serialization is not enabled")
+	}
+	if err := w.checkMessageSize(len(payload)); err != nil {
+		return nil, err
+	}
+
+	result, err := w.invoke(bridgeCodec(w.config, core.FormatMsgpack), name, payload, bytesToString)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
+}
+
+// bindFrames binds the base64 frame channel, used when frames cannot be
+// posted to the asset server. Callers hold w.mu.
+func (w *Webview) bindFrames() {
+	w.bindCallLocked("__polyglot_call_frame__", func(name string, frameB64 string) (string, error) {
+		if err := w.checkMessageSize(base64.StdEncoding.DecodedLen(len(frameB64))); err != nil {
+			return "", bridgeError(err)
+		}
+
+		frame, err := base64.StdEncoding.DecodeString(frameB64)
+		if err != nil {
+			return "", bridgeError(core.Errorf(core.CodeInvalidArgument, "invalid arguments: %w", err))
+		}
+
+		result, err := w.invokeFrame(name, frame)
+		if err != nil {
+			return "", bridgeError(err)
+		}
+		return base64.StdEncoding.EncodeToString(result), nil
+	})
+}
+
+// newFrameToken returns a random token pages must send with posted frames
+func newFrameToken() string {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		panic(fmt.Sprintf("failed to generate frame token: %v", err))
+	}
+	return hex.EncodeToString(token)
+}
+
+// ServeFrames lets the page served by s post binary frames and MessagePack
+// calls for w's bridge as raw bytes, which avoids base64 encoding them. It
+// takes effect when the webview's Binary setting is "transfer"; posts must
+// carry the token injected into the page.
+func (s *AssetServer) ServeFrames(w *Webview) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = w
+}
+
+// serveFrame handles a frame or MessagePack call posted to FramePath
+func (s *AssetServer) serveFrame(rw http.ResponseWriter, r *http.Request, w *Webview) {
+	if r.Header.Get(frameTokenHeader) != w.frameToken {
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	limit := int64(w.config.MaxMessageBytes)
+	if limit == 0 {
+		limit = core.DefaultMaxMessageBytes
+	}
+	body := io.Reader(r.Body)
+	if limit > 0 {
+		body = io.LimitReader(r.Body, limit+1)
+	}
+	frame, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(rw, "failed to read frame", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	contentType := "application/octet-stream"
+	var result []byte
+	if query.Get("format") == core.FormatMsgpack {
+		contentType = "application/msgpack"
+		result, err = w.invokePacked(query.Get("name"), frame)
+	} else {
+		result, err = w.invokeFrame(query.Get("name"), frame)
+	}
+	if err != nil {
+		encoded, _ := json.Marshal(core.ErrorInfoFor(err))
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+		rw.Write(encoded)
+		return
+	}
+
+	rw.Header().Set("Content-Type", contentType)
+	rw.Write(result)
+}
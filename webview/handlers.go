@@ -0,0 +1,175 @@
+package webview
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// callHandler serves one bridge call binding, taking the function name and
+// its encoded arguments. It is an alias so bindings keep their plain
+// function type.
+type callHandler = func(name string, payload string) (string, error)
+
+// ErrTooManyCalls fails bridge calls made while config.MaxConcurrentCalls
+// calls are running, when config.CallOverflow is "reject"
+var ErrTooManyCalls = core.NewError(core.CodeResourceExhausted, "too many concurrent bridge calls")
+
+// callLimit bounds the bridge calls running at once
+type callLimit struct {
+	slots  chan struct{}
+	reject bool
+}
+
+// newCallLimit creates a limit of max calls, rejecting calls beyond it
+// instead of queueing them when overflow asks to
+func newCallLimit(max int, overflow string) *callLimit {
+	return &callLimit{
+		slots:  make(chan struct{}, max),
+		reject: overflow == core.CallOverflowReject,
+	}
+}
+
+// wrap makes handler hold a slot of the limit while it runs
+func (l *callLimit) wrap(handler callHandler) callHandler {
+	return func(name string, payload string) (string, error) {
+		if l.reject {
+			select {
+			case l.slots <- struct{}{}:
+			default:
+				return "", bridgeError(ErrTooManyCalls)
+			}
+		} else {
+			l.slots <- struct{}{}
+		}
+		defer func() { <-l.slots }()
+		return handler(name, payload)
+	}
+}
+
+// handlerPool runs bridge calls off the message loop on a bounded number of
+// goroutines, keeping the order config.HandlerOrder asks for
+type handlerPool struct {
+	slots chan struct{}
+	order string
+
+	// queues holds the calls waiting behind a running one, by ordering
+	// key. A key is present while its calls are being drained.
+	mu     sync.Mutex
+	queues map[string][]func()
+}
+
+// newHandlerPool creates a pool running up to size calls at once
+func newHandlerPool(size int, order string) *handlerPool {
+	return &handlerPool{
+		slots:  make(chan struct{}, size),
+		order:  order,
+		queues: make(map[string][]func()),
+	}
+}
+
+// submit schedules a call to the function name without waiting for it
+func (p *handlerPool) submit(name string, call func()) {
+	var key string
+	switch p.order {
+	case core.HandlerOrderFunction:
+		key = name
+	case core.HandlerOrderAll:
+	default:
+		go p.run(call)
+		return
+	}
+
+	p.mu.Lock()
+	queue, draining := p.queues[key]
+	p.queues[key] = append(queue, call)
+	p.mu.Unlock()
+	if !draining {
+		go p.drain(key)
+	}
+}
+
+// drain runs the calls queued under key in order until none are left
+func (p *handlerPool) drain(key string) {
+	for {
+		p.mu.Lock()
+		queue := p.queues[key]
+		if len(queue) == 0 {
+			delete(p.queues, key)
+			p.mu.Unlock()
+			return
+		}
+		call := queue[0]
+		p.queues[key] = queue[1:]
+		p.mu.Unlock()
+
+		p.run(call)
+	}
+}
+
+// run runs call once the pool has room
+func (p *handlerPool) run(call func()) {
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+	call()
+}
+
+// bindCall binds a bridge call channel, keeping its handler so pooled
+// calls can be dispatched to it. Every channel shares the call limit. It
+// takes w.mu, since the dispatch binding reads the handlers from the UI
+// thread once the page is running.
+func (w *Webview) bindCall(binding string, handler callHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.bindCallLocked(binding, handler)
+}
+
+// bindCallLocked is bindCall for callers holding w.mu, as Initialize does
+func (w *Webview) bindCallLocked(binding string, handler callHandler) {
+	if w.limit != nil {
+		handler = w.limit.wrap(handler)
+	}
+	w.instance.Bind(binding, handler)
+	if w.calls == nil {
+		w.calls = make(map[string]callHandler)
+	}
+	w.calls[binding] = handler
+}
+
+// bindDispatch binds the channel pages use instead of the call bindings
+// when calls are pooled. It returns as soon as the call is queued; the
+// result settles the page's promise for call id once the handler is done.
+func (w *Webview) bindDispatch() {
+	w.instance.Bind("__polyglot_dispatch__", func(id uint64, binding string, name string, payload string) error {
+		w.mu.Lock()
+		handler, ok := w.calls[binding]
+		w.mu.Unlock()
+		if !ok {
+			return fmt.Errorf("unknown call binding %s", binding)
+		}
+
+		w.handlers.submit(name, func() {
+			result, err := handler(name, payload)
+			if err != nil {
+				w.settle(id, false, err.Error())
+				return
+			}
+			w.settle(id, true, result)
+		})
+		return nil
+	})
+}
+
+// settle resolves or rejects the page's promise for a pooled call. A page
+// that navigated away meanwhile ignores it.
+func (w *Webview) settle(id uint64, ok bool, value string) {
+	encoded, _ := json.Marshal(value)
+	if err := w.Eval(fmt.Sprintf("window.polyglot.settle(%d, %t, %s);", id, ok, encoded)); err != nil {
+		return
+	}
+	if w.settled != nil {
+		w.settled(id, ok, value)
+	}
+}
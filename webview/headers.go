@@ -0,0 +1,42 @@
+package webview
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateHeaders checks header names and values for sanity
+func validateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if err := validateHeaderName(name); err != nil {
+			return err
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("invalid value for header %s: contains control characters", name)
+		}
+	}
+	return nil
+}
+
+// validateHeaderName ensures a header name is a valid HTTP token
+func validateHeaderName(name string) error {
+	if name == "" {
+		return fmt.Errorf("header name cannot be empty")
+	}
+
+	for _, c := range name {
+		if !isTokenChar(c) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+
+	return nil
+}
+
+// isTokenChar reports whether c is allowed in an HTTP token (RFC 7230)
+func isTokenChar(c rune) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+}
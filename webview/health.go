@@ -0,0 +1,160 @@
+package webview
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// RuntimeHealthEvent is the event ReportHealth emits with the health of
+// the app's runtimes
+const RuntimeHealthEvent = "runtimeHealth"
+
+// Runtime statuses, reported in RuntimeStatus.Status
+const (
+	StatusReady    = "ready"
+	StatusStarting = "starting"
+	StatusStubbed  = "stubbed"
+	StatusFailed   = "failed"
+)
+
+// RuntimeStatus is the health of one runtime as the frontend sees it
+type RuntimeStatus struct {
+	Runtime string `json:"runtime"`
+
+	// Status is one of the Status* values. Stubbed and failed runtimes
+	// are unavailable.
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	// Notice is the message shown for an unavailable runtime
+	Notice string `json:"notice,omitempty"`
+}
+
+// HealthReport is the payload of a runtimeHealth event, also available to
+// the page as window.polyglot.health
+type HealthReport struct {
+	// Runtimes are sorted by name
+	Runtimes []RuntimeStatus `json:"runtimes"`
+
+	// Degraded reports that some runtime is unavailable
+	Degraded bool `json:"degraded"`
+
+	// Notices are the notices of the unavailable runtimes, in order
+	Notices []string `json:"notices"`
+}
+
+// HealthOptions configures how ReportHealth presents unavailable runtimes
+type HealthOptions struct {
+	// Notices maps runtimes to the message shown when they are
+	// unavailable, as in "Python unavailable; calculator disabled."
+	// Runtimes without one get "<runtime> unavailable".
+	Notices map[string]string
+
+	// Banner shows the notices in a banner at the top of the page. Pages
+	// with their own banner leave it unset and read the report instead.
+	Banner bool
+}
+
+// NewHealthReport builds the report of health, as returned by
+// Orchestrator.Health, with notices for the unavailable runtimes
+func NewHealthReport(health map[string]core.RuntimeHealth, notices map[string]string) HealthReport {
+	report := HealthReport{Runtimes: []RuntimeStatus{}, Notices: []string{}}
+	for name, h := range health {
+		status := RuntimeStatus{Runtime: name, Status: runtimeStatus(h), Version: h.Version, Error: h.Error}
+		if status.Status == StatusStubbed || status.Status == StatusFailed {
+			status.Notice = notices[name]
+			if status.Notice == "" {
+				status.Notice = name + " unavailable"
+			}
+		}
+		report.Runtimes = append(report.Runtimes, status)
+	}
+	sort.Slice(report.Runtimes, func(i, j int) bool {
+		return report.Runtimes[i].Runtime < report.Runtimes[j].Runtime
+	})
+	for _, status := range report.Runtimes {
+		if status.Notice != "" {
+			report.Degraded = true
+			report.Notices = append(report.Notices, status.Notice)
+		}
+	}
+	return report
+}
+
+// runtimeStatus classifies the health of a runtime
+func runtimeStatus(h core.RuntimeHealth) string {
+	switch {
+	case h.Stubbed:
+		return StatusStubbed
+	case h.Error != "" || !h.Initialized:
+		return StatusFailed
+	case h.Ready:
+		return StatusReady
+	}
+	return StatusStarting
+}
+
+// ReportHealth tells the frontend which runtimes are usable, so it can
+// disable features whose runtime is stubbed or failed to start instead of
+// letting them fail. The report is set as window.polyglot.health on the
+// current page and every page loaded after, and emitted as a
+// runtimeHealth event for pages already listening. Call it after
+// Initialize, and again whenever the health changes:
+//
+//	wv.ReportHealth(orchestrator.Health(), webview.HealthOptions{
+//		Notices: map[string]string{"python": "Python unavailable; calculator disabled."},
+//		Banner:  true,
+//	})
+func (w *Webview) ReportHealth(health map[string]core.RuntimeHealth, opts HealthOptions) error {
+	report := NewHealthReport(health, opts.Notices)
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode health: %w", err)
+	}
+	script := fmt.Sprintf(healthScript, encoded, opts.Banner)
+
+	w.mu.Lock()
+	if w.instance == nil {
+		w.mu.Unlock()
+		return fmt.Errorf("webview not initialized")
+	}
+	w.instance.Init(script)
+	w.mu.Unlock()
+
+	if err := w.Eval(script); err != nil {
+		return err
+	}
+	return w.Emit(RuntimeHealthEvent, report)
+}
+
+// healthScript stores a health report in the page and, when asked, shows
+// its notices in a banner, replacing any shown before
+const healthScript = `
+	(function(health, banner) {
+		window.polyglot = window.polyglot || {};
+		window.polyglot.health = health;
+		if (!banner) return;
+		const render = function() {
+			let el = document.getElementById('polyglot-health-banner');
+			if (!health.degraded) {
+				if (el) el.remove();
+				return;
+			}
+			if (!el) {
+				el = document.createElement('div');
+				el.id = 'polyglot-health-banner';
+				el.setAttribute('role', 'alert');
+				el.style.cssText = 'position:sticky;top:0;z-index:2147483647;padding:8px 16px;' +
+					'background:#fff3cd;color:#664d03;border-bottom:1px solid #ffe69c;font:14px sans-serif';
+				document.body.prepend(el);
+			}
+			el.textContent = health.notices.join(' ');
+		};
+		if (document.body) render();
+		else document.addEventListener('DOMContentLoaded', render);
+	})(%s, %t);
+`
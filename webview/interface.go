@@ -11,6 +11,32 @@ const (
 	HintFixed Hint = 3
 )
 
+// WindowOptions describes optional window appearance features
+type WindowOptions struct {
+	// Transparent makes the window background transparent
+	Transparent bool
+
+	// Frameless removes the native title bar and borders
+	Frameless bool
+
+	// AlwaysOnTop keeps the window above other windows
+	AlwaysOnTop bool
+
+	// Fullscreen fills the entire screen
+	Fullscreen bool
+}
+
+// WindowState represents the current window display state
+type WindowState string
+
+// WindowState constants
+const (
+	StateNormal     WindowState = "normal"
+	StateMinimized  WindowState = "minimized"
+	StateMaximized  WindowState = "maximized"
+	StateFullscreen WindowState = "fullscreen"
+)
+
 // WebviewBackend defines the interface for webview implementations
 type WebviewBackend interface {
 	// SetTitle sets the window title
@@ -34,10 +60,27 @@ type WebviewBackend interface {
 	// Init runs initialization JavaScript
 	Init(script string)
 
-	// Terminate stops the webview
+	// SetWindowOptions applies window appearance features, returning an
+	// error listing any features the backend cannot honor
+	SetWindowOptions(opts WindowOptions) error
+
+	// SetWindowState changes the window display state
+	SetWindowState(state WindowState) error
+
+	// SetUserAgent overrides the user agent used for navigation
+	SetUserAgent(ua string) error
+
+	// SetRequestHeaders sets extra headers sent with navigation requests
+	SetRequestHeaders(headers map[string]string) error
+
+	// SetZoom scales the page by factor, where 1 is unzoomed
+	SetZoom(factor float64) error
+
+	// Terminate stops the event loop; it may be called from any goroutine
 	Terminate()
 
-	// Destroy cleans up resources
+	// Destroy cleans up resources. It is only called when Run is not
+	// looping.
 	Destroy()
 }
 
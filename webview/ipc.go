@@ -0,0 +1,118 @@
+package webview
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// MaxIPCMessageBytes caps the size of one message between an
+// OutOfProcess webview and its child
+const MaxIPCMessageBytes = 64 * 1024 * 1024
+
+// IPC message types
+const (
+	// IPCInit carries the webview config from the parent to the child
+	IPCInit = "init"
+
+	// IPCCall is a bridge call from the page, sent by the child
+	IPCCall = "call"
+
+	// IPCResult answers an IPCCall with the same ID
+	IPCResult = "result"
+
+	// IPCEval and IPCEmit run a script or dispatch an event in the page
+	IPCEval = "eval"
+	IPCEmit = "emit"
+
+	// IPCTerminate asks the child to close its window
+	IPCTerminate = "terminate"
+
+	// IPCClosed reports that the child's window closed normally
+	IPCClosed = "closed"
+)
+
+// IPCMessage is one message between an OutOfProcess webview and its child
+type IPCMessage struct {
+	Type string `json:"type"`
+
+	// ID pairs a call with its result
+	ID uint64 `json:"id,omitempty"`
+
+	// Name is the function of a call, the event of an emit, or the
+	// script of an eval
+	Name string `json:"name,omitempty"`
+
+	// Data holds JSON encoded arguments, results, event payloads or the
+	// init config
+	Data json.RawMessage `json:"data,omitempty"`
+
+	// Error is set on results of failed calls
+	Error *core.ErrorInfo `json:"error,omitempty"`
+}
+
+// WriteIPCMessage writes msg as a 4-byte big-endian length followed by
+// its JSON encoding
+func WriteIPCMessage(w io.Writer, msg IPCMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", msg.Type, err)
+	}
+	if len(body) > MaxIPCMessageBytes {
+		return core.Errorf(core.CodeTooLarge, "%s message of %d bytes exceeds limit of %d bytes", msg.Type, len(body), MaxIPCMessageBytes)
+	}
+
+	frame := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+	copy(frame[4:], body)
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadIPCMessage reads a message written by WriteIPCMessage. It returns
+// io.EOF when the stream ends between messages.
+func ReadIPCMessage(r io.Reader) (IPCMessage, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return IPCMessage{}, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxIPCMessageBytes {
+		return IPCMessage{}, core.Errorf(core.CodeTooLarge, "message of %d bytes exceeds limit of %d bytes", size, MaxIPCMessageBytes)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return IPCMessage{}, err
+	}
+
+	var msg IPCMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return IPCMessage{}, fmt.Errorf("invalid message: %w", err)
+	}
+	return msg, nil
+}
+
+// ipcConn serializes writes to one side of the channel
+type ipcConn struct {
+	r  io.Reader
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func (c *ipcConn) send(msg IPCMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return WriteIPCMessage(c.w, msg)
+}
+
+func (c *ipcConn) receive() (IPCMessage, error) {
+	return ReadIPCMessage(c.r)
+}
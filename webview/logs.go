@@ -0,0 +1,141 @@
+package webview
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// RuntimeLogEvent is the event StreamLogs emits for each line a runtime
+// prints
+const RuntimeLogEvent = "runtimeLog"
+
+// logBuffer is the number of lines held for the frontend before new lines
+// are dropped
+const logBuffer = 1024
+
+// RuntimeLogLine is the payload of a runtimeLog event
+type RuntimeLogLine struct {
+	Runtime string `json:"runtime"`
+	Stream  string `json:"stream"`
+	Line    string `json:"line"`
+
+	// Dropped counts lines discarded before this one because the frontend
+	// fell behind. Lines dropped at the end are reported by a final event
+	// with an empty Stream.
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// StreamLogs returns a context whose executions send each line they print
+// to the frontend as a runtimeLog event, in order. Lines are queued so
+// execution never waits on the webview; when the queue is full, lines are
+// dropped and counted in the next delivered line. Call stop once the
+// execution returns to flush partial lines and wait for delivery.
+func (w *Webview) StreamLogs(ctx context.Context, runtime string) (_ context.Context, stop func()) {
+	s := &logStream{
+		webview: w,
+		runtime: runtime,
+		lines:   make(chan RuntimeLogLine, logBuffer),
+		done:    make(chan struct{}),
+	}
+	go s.deliver()
+
+	stdout := &logWriter{stream: s, name: "stdout"}
+	stderr := &logWriter{stream: s, name: "stderr"}
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			stdout.flush()
+			stderr.flush()
+			s.close()
+			<-s.done
+		})
+	}
+	return core.WithOutput(ctx, stdout, stderr), stop
+}
+
+// logStream queues lines from both streams of one execution
+type logStream struct {
+	webview *Webview
+	runtime string
+	lines   chan RuntimeLogLine
+	done    chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+func (s *logStream) send(stream, line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	s.webview.pending.add(1)
+	select {
+	case s.lines <- RuntimeLogLine{Runtime: s.runtime, Stream: stream, Line: line, Dropped: s.dropped}:
+		s.dropped = 0
+	default:
+		s.webview.pending.done()
+		s.dropped++
+	}
+}
+
+func (s *logStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.dropped > 0 {
+		// deliver never waits on s.mu, so this send cannot deadlock
+		s.webview.pending.add(1)
+		s.lines <- RuntimeLogLine{Runtime: s.runtime, Dropped: s.dropped}
+	}
+	close(s.lines)
+}
+
+func (s *logStream) deliver() {
+	defer close(s.done)
+	for line := range s.lines {
+		s.webview.Emit(RuntimeLogEvent, line)
+		s.webview.pending.done()
+	}
+}
+
+// logWriter splits one stream into lines
+type logWriter struct {
+	stream *logStream
+	name   string
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		l.stream.send(l.name, string(bytes.TrimSuffix(l.partial[:i], []byte("\r"))))
+		l.partial = l.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (l *logWriter) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.partial) > 0 {
+		l.stream.send(l.name, string(l.partial))
+		l.partial = nil
+	}
+}
@@ -3,17 +3,58 @@
 
 package webview
 
-import webview "github.com/webview/webview_go"
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"unsafe"
 
-// NativeBackend implements WebviewBackend using the webview/webview library
+	webview "github.com/webview/webview_go"
+)
+
+// NativeBackend implements WebviewBackend using the webview/webview library.
+// Window features webview/webview does not cover are applied through the
+// platform window it exposes.
 type NativeBackend struct {
 	wv webview.WebView
+
+	// uiThread is the thread that created the window, which runs its loop
+	uiThread uint64
+
+	// closed is closed once the loop can no longer run dispatched calls
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// platform holds what the platform window code keeps between calls
+	platform platformWindow
+
+	// zoom is the CSS zoom reapplied on each page load
+	zoom     float64
+	zoomMu   sync.Mutex
+	zoomOnce sync.Once
 }
 
-// NewNativeBackend creates a native webview instance
+// zoomScript reapplies the zoom level on every page load, since CSS zoom
+// does not survive navigation
+const zoomScript = `document.addEventListener('DOMContentLoaded', function() {
+	__polyglot_zoom_level__().then(function(zoom) {
+		document.documentElement.style.zoom = zoom;
+	});
+});`
+
+// NewNativeBackend creates a native webview instance, or returns nil when
+// there is no display to show it on
 func NewNativeBackend(debug bool) WebviewBackend {
+	if err := displayAvailable(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return nil
+	}
 	return &NativeBackend{
-		wv: webview.New(debug),
+		wv:       webview.New(debug),
+		uiThread: currentThread(),
+		closed:   make(chan struct{}),
 	}
 }
 
@@ -31,6 +72,33 @@ func (n *NativeBackend) Navigate(url string) {
 
 func (n *NativeBackend) Run() {
 	n.wv.Run()
+	n.closeOnce.Do(func() { close(n.closed) })
+}
+
+// onUI runs fn with the native window on the UI thread and returns its
+// error. On the UI thread, as in Initialize and bindings, fn runs
+// directly; elsewhere it is dispatched to the loop, waiting for Run if the
+// loop has not started.
+func (n *NativeBackend) onUI(fn func(window unsafe.Pointer) error) error {
+	run := func() error {
+		window := n.wv.Window()
+		if window == nil {
+			return fmt.Errorf("window closed")
+		}
+		return fn(window)
+	}
+	if currentThread() == n.uiThread {
+		return run()
+	}
+
+	done := make(chan error, 1)
+	n.wv.Dispatch(func() { done <- run() })
+	select {
+	case err := <-done:
+		return err
+	case <-n.closed:
+		return fmt.Errorf("window closed")
+	}
 }
 
 func (n *NativeBackend) Eval(script string) {
@@ -45,14 +113,124 @@ func (n *NativeBackend) Init(script string) {
 	n.wv.Init(script)
 }
 
+// SetWindowOptions applies window features through the platform window.
+// Features the platform cannot provide are left off and listed in the
+// error; see the platform's applyOptions for which those are.
+func (n *NativeBackend) SetWindowOptions(opts WindowOptions) error {
+	var unsupported []string
+	err := n.onUI(func(window unsafe.Pointer) error {
+		unsupported = n.applyOptions(window, opts)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Transparent && !contains(unsupported, "transparent") {
+		// The window shows through only where the page paints nothing
+		n.wv.Init(`document.addEventListener('DOMContentLoaded', function() {
+			document.documentElement.style.background = 'transparent';
+			document.body.style.background = 'transparent';
+		});`)
+	}
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("unsupported window options on %s: %s", runtime.GOOS, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWindowState changes the window display state through the platform
+// window, returning once the platform has taken the change
+func (n *NativeBackend) SetWindowState(state WindowState) error {
+	switch state {
+	case StateNormal, StateMinimized, StateMaximized, StateFullscreen:
+	default:
+		return fmt.Errorf("unknown window state %q", state)
+	}
+	return n.onUI(func(window unsafe.Pointer) error {
+		return n.applyState(window, state)
+	})
+}
+
+// SetUserAgent sets the user agent the platform web view sends with its
+// requests and reports as navigator.userAgent
+func (n *NativeBackend) SetUserAgent(ua string) error {
+	if ua == "" {
+		return nil
+	}
+	return n.onUI(func(window unsafe.Pointer) error {
+		return n.applyUserAgent(window, ua)
+	})
+}
+
+// SetRequestHeaders is not supported: webview/webview exposes no hook for
+// modifying navigation requests, and none of the platform web views can
+// add headers to every request from outside the page
+func (n *NativeBackend) SetRequestHeaders(headers map[string]string) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	return fmt.Errorf("request headers are not supported by the native webview; send them from the page with fetch instead")
+}
+
+// SetZoom scales the page with CSS zoom, as webview/webview exposes no
+// zoom API. The first call, made when the window is created, installs the
+// script keeping the zoom across navigation.
+func (n *NativeBackend) SetZoom(factor float64) error {
+	n.zoomOnce.Do(func() {
+		n.wv.Bind("__polyglot_zoom_level__", func() float64 {
+			n.zoomMu.Lock()
+			defer n.zoomMu.Unlock()
+			return n.zoom
+		})
+		n.wv.Init(zoomScript)
+	})
+
+	n.zoomMu.Lock()
+	n.zoom = factor
+	n.zoomMu.Unlock()
+
+	n.wv.Dispatch(func() {
+		n.wv.Eval(fmt.Sprintf("document.documentElement.style.zoom = %g;", factor))
+	})
+	return nil
+}
+
+// Terminate stops the event loop from the UI thread, so it is safe to call
+// from any goroutine
 func (n *NativeBackend) Terminate() {
-	n.wv.Terminate()
+	n.wv.Dispatch(n.wv.Terminate)
 }
 
 func (n *NativeBackend) Destroy() {
+	n.closeOnce.Do(func() { close(n.closed) })
 	n.wv.Destroy()
 }
 
+// displayAvailable reports an error on systems without a graphical
+// session, where creating a window would fail
+func displayAvailable() error {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return nil
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return fmt.Errorf("no display available (DISPLAY and WAYLAND_DISPLAY are unset)")
+	}
+	return nil
+}
+
 func init() {
 	NewBackend = NewNativeBackend
 }
@@ -0,0 +1,163 @@
+//go:build !stub && darwin
+// +build !stub,darwin
+
+package webview
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa -framework WebKit
+
+#import <Cocoa/Cocoa.h>
+#import <WebKit/WebKit.h>
+#include <pthread.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+static uintptr_t polyglot_thread(void) {
+	return (uintptr_t)pthread_self();
+}
+
+// polyglot_set_frameless hides the title bar and window buttons but keeps
+// the window titled, since borderless windows cannot take keyboard focus
+static void polyglot_set_frameless(void *window) {
+	NSWindow *w = (NSWindow *)window;
+	w.styleMask |= NSWindowStyleMaskFullSizeContentView;
+	w.titlebarAppearsTransparent = YES;
+	w.titleVisibility = NSWindowTitleHidden;
+	[[w standardWindowButton:NSWindowCloseButton] setHidden:YES];
+	[[w standardWindowButton:NSWindowMiniaturizeButton] setHidden:YES];
+	[[w standardWindowButton:NSWindowZoomButton] setHidden:YES];
+}
+
+static void polyglot_set_keep_above(void *window) {
+	[(NSWindow *)window setLevel:NSFloatingWindowLevel];
+}
+
+static int polyglot_set_transparent(void *window) {
+	NSWindow *w = (NSWindow *)window;
+	NSView *view = [w contentView];
+	if (![view isKindOfClass:[WKWebView class]]) {
+		return 0;
+	}
+	@try {
+		[view setValue:@NO forKey:@"drawsBackground"];
+	} @catch (NSException *e) {
+		return 0;
+	}
+	[w setOpaque:NO];
+	[w setBackgroundColor:[NSColor clearColor]];
+	return 1;
+}
+
+static int polyglot_is_fullscreen(NSWindow *w) {
+	return (w.styleMask & NSWindowStyleMaskFullScreen) != 0;
+}
+
+static void polyglot_fullscreen(void *window) {
+	NSWindow *w = (NSWindow *)window;
+	if (!polyglot_is_fullscreen(w)) {
+		[w toggleFullScreen:nil];
+	}
+}
+
+static int polyglot_set_user_agent(void *window, const char *ua) {
+	NSView *view = [(NSWindow *)window contentView];
+	if (![view isKindOfClass:[WKWebView class]]) {
+		return 0;
+	}
+	[(WKWebView *)view setCustomUserAgent:[NSString stringWithUTF8String:ua]];
+	return 1;
+}
+
+static void polyglot_minimize(void *window) {
+	[(NSWindow *)window miniaturize:nil];
+}
+
+static void polyglot_maximize(void *window) {
+	NSWindow *w = (NSWindow *)window;
+	if (polyglot_is_fullscreen(w)) {
+		[w toggleFullScreen:nil];
+	}
+	if ([w isMiniaturized]) {
+		[w deminiaturize:nil];
+	}
+	if (![w isZoomed]) {
+		[w zoom:nil];
+	}
+}
+
+static void polyglot_restore(void *window) {
+	NSWindow *w = (NSWindow *)window;
+	if ([w isMiniaturized]) {
+		[w deminiaturize:nil];
+	}
+	if (polyglot_is_fullscreen(w)) {
+		[w toggleFullScreen:nil];
+	}
+	if ([w isZoomed]) {
+		[w zoom:nil];
+	}
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// platformWindow keeps nothing on macOS, which tracks window state itself
+type platformWindow struct{}
+
+// currentThread identifies the calling OS thread
+func currentThread() uint64 {
+	return uint64(C.polyglot_thread())
+}
+
+// applyOptions applies opts to the NSWindow. A frameless window keeps its
+// rounded corners but has no title bar to drag. Transparency relies on
+// WKWebView's drawsBackground key and is reported unsupported on versions
+// without it.
+func (n *NativeBackend) applyOptions(window unsafe.Pointer, opts WindowOptions) []string {
+	var unsupported []string
+	if opts.Transparent && C.polyglot_set_transparent(window) == 0 {
+		unsupported = append(unsupported, "transparent")
+	}
+	if opts.Frameless {
+		C.polyglot_set_frameless(window)
+	}
+	if opts.AlwaysOnTop {
+		C.polyglot_set_keep_above(window)
+	}
+	if opts.Fullscreen {
+		C.polyglot_fullscreen(window)
+	}
+	return unsupported
+}
+
+// applyState changes the NSWindow's state. AppKit animates the change and
+// finishes it after the call returns; maximizing zooms the window to fill
+// the screen beside the Dock and menu bar.
+func (n *NativeBackend) applyState(window unsafe.Pointer, state WindowState) error {
+	switch state {
+	case StateMinimized:
+		C.polyglot_minimize(window)
+	case StateMaximized:
+		C.polyglot_maximize(window)
+	case StateFullscreen:
+		C.polyglot_fullscreen(window)
+	default:
+		C.polyglot_restore(window)
+	}
+	return nil
+}
+
+// applyUserAgent sets the WKWebView's custom user agent
+func (n *NativeBackend) applyUserAgent(window unsafe.Pointer, ua string) error {
+	cua := C.CString(ua)
+	defer C.free(unsafe.Pointer(cua))
+	if C.polyglot_set_user_agent(window, cua) == 0 {
+		return fmt.Errorf("failed to set user agent: the window holds no web view")
+	}
+	return nil
+}
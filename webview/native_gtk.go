@@ -0,0 +1,148 @@
+//go:build !stub && (linux || freebsd || openbsd || netbsd)
+// +build !stub
+// +build linux freebsd openbsd netbsd
+
+package webview
+
+/*
+#cgo pkg-config: gtk+-3.0 webkit2gtk-4.0
+
+#include <gtk/gtk.h>
+#include <webkit2/webkit2.h>
+#include <pthread.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+static uintptr_t polyglot_thread(void) {
+	return (uintptr_t)pthread_self();
+}
+
+static void polyglot_set_frameless(void *window) {
+	gtk_window_set_decorated(GTK_WINDOW(window), FALSE);
+}
+
+static void polyglot_set_keep_above(void *window) {
+	gtk_window_set_keep_above(GTK_WINDOW(window), TRUE);
+}
+
+static void polyglot_fullscreen(void *window) {
+	gtk_window_fullscreen(GTK_WINDOW(window));
+}
+
+static int polyglot_set_user_agent(void *window, const char *ua) {
+	GtkWidget *view = gtk_bin_get_child(GTK_BIN(window));
+	if (view == NULL || !WEBKIT_IS_WEB_VIEW(view)) {
+		return 0;
+	}
+	WebKitSettings *settings = webkit_web_view_get_settings(WEBKIT_WEB_VIEW(view));
+	webkit_settings_set_user_agent(settings, ua);
+	return 1;
+}
+
+static void polyglot_minimize(void *window) {
+	gtk_window_iconify(GTK_WINDOW(window));
+}
+
+static void polyglot_maximize(void *window) {
+	gtk_window_unfullscreen(GTK_WINDOW(window));
+	gtk_window_maximize(GTK_WINDOW(window));
+}
+
+static void polyglot_restore(void *window) {
+	gtk_window_unfullscreen(GTK_WINDOW(window));
+	gtk_window_unmaximize(GTK_WINDOW(window));
+	gtk_window_deiconify(GTK_WINDOW(window));
+}
+
+// polyglot_set_transparent gives the window an alpha channel and clears
+// the web view's background. The visual can only change while the window
+// is unrealized, so a shown window is hidden and shown again.
+static int polyglot_set_transparent(void *window) {
+	GtkWidget *widget = GTK_WIDGET(window);
+	GdkScreen *screen = gtk_widget_get_screen(widget);
+	GdkVisual *visual = gdk_screen_get_rgba_visual(screen);
+	if (visual == NULL || !gdk_screen_is_composited(screen)) {
+		return 0;
+	}
+
+	gboolean visible = gtk_widget_get_visible(widget);
+	if (gtk_widget_get_realized(widget)) {
+		gtk_widget_hide(widget);
+		gtk_widget_unrealize(widget);
+	}
+	gtk_widget_set_visual(widget, visual);
+	gtk_widget_set_app_paintable(widget, TRUE);
+
+	GtkWidget *view = gtk_bin_get_child(GTK_BIN(window));
+	if (view != NULL && WEBKIT_IS_WEB_VIEW(view)) {
+		GdkRGBA clear = {0, 0, 0, 0};
+		webkit_web_view_set_background_color(WEBKIT_WEB_VIEW(view), &clear);
+	}
+	if (visible) {
+		gtk_widget_show(widget);
+	}
+	return 1;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// platformWindow keeps nothing on GTK, which tracks window state itself
+type platformWindow struct{}
+
+// currentThread identifies the calling OS thread
+func currentThread() uint64 {
+	return uint64(C.polyglot_thread())
+}
+
+// applyOptions applies opts to the GtkWindow. Transparency needs a
+// compositing window manager and is reported unsupported without one.
+// Frameless, always-on-top and fullscreen are requests the window manager
+// may decline.
+func (n *NativeBackend) applyOptions(window unsafe.Pointer, opts WindowOptions) []string {
+	var unsupported []string
+	if opts.Transparent && C.polyglot_set_transparent(window) == 0 {
+		unsupported = append(unsupported, "transparent")
+	}
+	if opts.Frameless {
+		C.polyglot_set_frameless(window)
+	}
+	if opts.AlwaysOnTop {
+		C.polyglot_set_keep_above(window)
+	}
+	if opts.Fullscreen {
+		C.polyglot_fullscreen(window)
+	}
+	return unsupported
+}
+
+// applyState asks the window manager for state. GTK does not report
+// whether the request was granted, so a window manager that ignores it
+// leaves the window as it was.
+func (n *NativeBackend) applyState(window unsafe.Pointer, state WindowState) error {
+	switch state {
+	case StateMinimized:
+		C.polyglot_minimize(window)
+	case StateMaximized:
+		C.polyglot_maximize(window)
+	case StateFullscreen:
+		C.polyglot_fullscreen(window)
+	default:
+		C.polyglot_restore(window)
+	}
+	return nil
+}
+
+// applyUserAgent sets the user agent in the WebKitWebView's settings
+func (n *NativeBackend) applyUserAgent(window unsafe.Pointer, ua string) error {
+	cua := C.CString(ua)
+	defer C.free(unsafe.Pointer(cua))
+	if C.polyglot_set_user_agent(window, cua) == 0 {
+		return fmt.Errorf("failed to set user agent: the window holds no web view")
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+//go:build !stub && windows
+// +build !stub,windows
+
+package webview
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                 = syscall.NewLazyDLL("user32.dll")
+	procGetWindowLongW     = user32.NewProc("GetWindowLongW")
+	procSetWindowLongW     = user32.NewProc("SetWindowLongW")
+	procSetWindowPos       = user32.NewProc("SetWindowPos")
+	procGetWindowRect      = user32.NewProc("GetWindowRect")
+	procMonitorFromWindow  = user32.NewProc("MonitorFromWindow")
+	procGetMonitorInfoW    = user32.NewProc("GetMonitorInfoW")
+	procShowWindow         = user32.NewProc("ShowWindow")
+	procIsIconic           = user32.NewProc("IsIconic")
+	procIsZoomed           = user32.NewProc("IsZoomed")
+	procGetCurrentThreadId = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	gwlStyle       = -16
+	hwndTopmost    = -1
+	wsCaption      = 0x00C00000
+	wsThickFrame   = 0x00040000
+	wsOverlapped   = 0x00CF0000 // WS_OVERLAPPEDWINDOW
+	swpNoSize      = 0x0001
+	swpNoMove      = 0x0002
+	swpNoZOrder    = 0x0004
+	swpNoActivate  = 0x0010
+	swpFrame       = 0x0020 // SWP_FRAMECHANGED
+	swpNoOwnerZ    = 0x0200
+	monitorNearest = 2
+	swMaximize     = 3
+	swMinimize     = 6
+	swRestore      = 9
+)
+
+type winRect struct {
+	Left, Top, Right, Bottom int32
+}
+
+type monitorInfo struct {
+	Size    uint32
+	Monitor winRect
+	Work    winRect
+	Flags   uint32
+}
+
+// platformWindow keeps the frame a fullscreen window returns to
+type platformWindow struct {
+	fullscreen bool
+	style      uint32
+	rect       winRect
+}
+
+// currentThread identifies the calling OS thread
+func currentThread() uint64 {
+	id, _, _ := procGetCurrentThreadId.Call()
+	return uint64(id)
+}
+
+// handleArg passes a negative handle or index, such as GWL_STYLE, as a
+// sign-extended argument
+func handleArg(value int32) uintptr {
+	return uintptr(value)
+}
+
+func windowStyle(hwnd uintptr) uint32 {
+	style, _, _ := procGetWindowLongW.Call(hwnd, handleArg(gwlStyle))
+	return uint32(style)
+}
+
+// setWindowStyle replaces the style and has the frame redrawn to match
+func setWindowStyle(hwnd uintptr, style uint32) error {
+	procSetWindowLongW.Call(hwnd, handleArg(gwlStyle), uintptr(style))
+	if r, _, err := procSetWindowPos.Call(hwnd, 0, 0, 0, 0, 0, swpNoMove|swpNoSize|swpNoZOrder|swpNoActivate|swpFrame); r == 0 {
+		return fmt.Errorf("failed to update window frame: %w", err)
+	}
+	return nil
+}
+
+// applyOptions applies opts to the HWND. The WebView2 control draws its own
+// opaque background, which cannot be reached through the window handle, so
+// transparency is unsupported. Frameless windows lose their resize border.
+func (n *NativeBackend) applyOptions(window unsafe.Pointer, opts WindowOptions) []string {
+	hwnd := uintptr(window)
+	var unsupported []string
+	if opts.Transparent {
+		unsupported = append(unsupported, "transparent")
+	}
+	if opts.Frameless {
+		if setWindowStyle(hwnd, windowStyle(hwnd)&^(wsCaption|wsThickFrame)) != nil {
+			unsupported = append(unsupported, "frameless")
+		}
+	}
+	if opts.AlwaysOnTop {
+		if r, _, _ := procSetWindowPos.Call(hwnd, handleArg(hwndTopmost), 0, 0, 0, 0, swpNoMove|swpNoSize|swpNoActivate); r == 0 {
+			unsupported = append(unsupported, "always-on-top")
+		}
+	}
+	if opts.Fullscreen {
+		if n.enterFullscreen(hwnd) != nil {
+			unsupported = append(unsupported, "fullscreen")
+		}
+	}
+	return unsupported
+}
+
+// enterFullscreen removes the frame and covers the window's monitor,
+// remembering the frame and position to return to
+func (n *NativeBackend) enterFullscreen(hwnd uintptr) error {
+	if n.platform.fullscreen {
+		return nil
+	}
+
+	var rect winRect
+	if r, _, err := procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect))); r == 0 {
+		return fmt.Errorf("failed to read window position: %w", err)
+	}
+	info := monitorInfo{Size: uint32(unsafe.Sizeof(monitorInfo{}))}
+	monitor, _, _ := procMonitorFromWindow.Call(hwnd, monitorNearest)
+	if r, _, err := procGetMonitorInfoW.Call(monitor, uintptr(unsafe.Pointer(&info))); r == 0 {
+		return fmt.Errorf("failed to read monitor: %w", err)
+	}
+
+	style := windowStyle(hwnd)
+	procSetWindowLongW.Call(hwnd, handleArg(gwlStyle), uintptr(style&^wsOverlapped))
+	m := info.Monitor
+	if r, _, err := procSetWindowPos.Call(hwnd, 0, uintptr(m.Left), uintptr(m.Top), uintptr(m.Right-m.Left), uintptr(m.Bottom-m.Top), swpNoOwnerZ|swpFrame); r == 0 {
+		procSetWindowLongW.Call(hwnd, handleArg(gwlStyle), uintptr(style))
+		return fmt.Errorf("failed to cover the monitor: %w", err)
+	}
+	n.platform = platformWindow{fullscreen: true, style: style, rect: rect}
+	return nil
+}
+
+// exitFullscreen restores the frame and position saved by enterFullscreen
+func (n *NativeBackend) exitFullscreen(hwnd uintptr) error {
+	if !n.platform.fullscreen {
+		return nil
+	}
+
+	saved := n.platform
+	procSetWindowLongW.Call(hwnd, handleArg(gwlStyle), uintptr(saved.style))
+	r := saved.rect
+	if ok, _, err := procSetWindowPos.Call(hwnd, 0, uintptr(r.Left), uintptr(r.Top), uintptr(r.Right-r.Left), uintptr(r.Bottom-r.Top), swpNoOwnerZ|swpNoZOrder|swpFrame); ok == 0 {
+		return fmt.Errorf("failed to restore window frame: %w", err)
+	}
+	n.platform = platformWindow{}
+	return nil
+}
+
+// applyState changes the window's state and checks that Windows applied
+// it. ShowWindow completes before it returns.
+func (n *NativeBackend) applyState(window unsafe.Pointer, state WindowState) error {
+	hwnd := uintptr(window)
+	switch state {
+	case StateMinimized:
+		procShowWindow.Call(hwnd, swMinimize)
+		if iconic, _, _ := procIsIconic.Call(hwnd); iconic == 0 {
+			return fmt.Errorf("window could not be minimized")
+		}
+	case StateMaximized:
+		if err := n.exitFullscreen(hwnd); err != nil {
+			return err
+		}
+		procShowWindow.Call(hwnd, swMaximize)
+		if zoomed, _, _ := procIsZoomed.Call(hwnd); zoomed == 0 {
+			return fmt.Errorf("window could not be maximized")
+		}
+	case StateFullscreen:
+		if zoomed, _, _ := procIsZoomed.Call(hwnd); zoomed != 0 {
+			procShowWindow.Call(hwnd, swRestore)
+		}
+		return n.enterFullscreen(hwnd)
+	default:
+		if err := n.exitFullscreen(hwnd); err != nil {
+			return err
+		}
+		procShowWindow.Call(hwnd, swRestore)
+	}
+	return nil
+}
+
+// applyUserAgent is not supported: WebView2 takes the user agent from its
+// settings object, which webview/webview does not expose
+func (n *NativeBackend) applyUserAgent(window unsafe.Pointer, ua string) error {
+	return fmt.Errorf("user agent override is not supported by the native webview on windows")
+}
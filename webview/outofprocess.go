@@ -0,0 +1,468 @@
+package webview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// childEnv marks a process started by OutOfProcess
+const childEnv = "POLYGLOT_WEBVIEW_CHILD"
+
+// terminateGrace is how long the child gets to close its window before it
+// is killed
+const terminateGrace = 3 * time.Second
+
+// ErrWebviewCrashed reports that an OutOfProcess child exited without
+// closing its window or being terminated
+var ErrWebviewCrashed = core.NewError(core.CodeUnavailable, "webview process crashed")
+
+// OutOfProcess runs the native webview in a child process, so a crash in
+// the browser engine does not take down the backend, and the reverse. The
+// page talks to the bridge over a pipe to the child; binary arguments
+// arrive as base64 strings, FileResponse results are not streamed, and
+// ResultStream results are reassembled before crossing the pipe.
+//
+// The child is the app's own executable. Its main must call RunChild,
+// before doing anything else, when IsChildProcess reports true.
+type OutOfProcess struct {
+	config  core.WebviewConfig
+	bridge  core.Bridge
+	codec   core.Codec
+	command func() *exec.Cmd
+
+	mu          sync.Mutex
+	cmd         *exec.Cmd
+	conn        *ipcConn
+	closed      bool
+	terminating bool
+	done        chan struct{}
+	err         error
+
+	// ctx is canceled when the child exits, ending calls in flight
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewOutOfProcess creates a webview that runs in a child process once
+// started
+func NewOutOfProcess(config core.WebviewConfig, bridge core.Bridge) *OutOfProcess {
+	return &OutOfProcess{
+		config:  config,
+		bridge:  core.RestrictBridge(bridge, config.Access),
+		codec:   bridgeCodec(config, core.FormatJSON),
+		command: defaultChildCommand,
+		done:    make(chan struct{}),
+	}
+}
+
+// defaultChildCommand re-runs the current executable with its arguments
+func defaultChildCommand() *exec.Cmd {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = os.Args[0]
+	}
+	return exec.Command(executable, os.Args[1:]...)
+}
+
+// SetCommand changes how the child is started. It must be called before
+// Start; the command's stdin and stdout are used for the IPC channel.
+func (p *OutOfProcess) SetCommand(command func() *exec.Cmd) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.command = command
+}
+
+// Start launches the child and opens its window
+func (p *OutOfProcess) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd != nil {
+		return fmt.Errorf("webview process already started")
+	}
+
+	cmd := p.command()
+	cmd.Env = append(os.Environ(), childEnv+"=1")
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	functions := []string{}
+	if lister, ok := p.bridge.(interface{ Functions() []string }); ok {
+		functions = lister.Functions()
+	}
+	init, err := json.Marshal(childInit{Config: p.config, Functions: functions})
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start webview process: %w", err)
+	}
+	p.cmd = cmd
+	p.conn = &ipcConn{r: stdout, w: stdin}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	go p.serve()
+
+	if err := p.conn.send(IPCMessage{Type: IPCInit, Data: init}); err != nil {
+		// serve reports the exit once the child is gone
+		cmd.Process.Kill()
+		return fmt.Errorf("failed to initialize webview process: %w", err)
+	}
+	return nil
+}
+
+// serve answers the child's bridge calls until the channel closes, then
+// records how the child exited
+func (p *OutOfProcess) serve() {
+	for {
+		msg, err := p.conn.receive()
+		if err != nil {
+			break
+		}
+		switch msg.Type {
+		case IPCCall:
+			go p.call(msg)
+		case IPCClosed:
+			p.mu.Lock()
+			p.closed = true
+			p.mu.Unlock()
+		}
+	}
+
+	waitErr := p.cmd.Wait()
+	p.cancel()
+
+	p.mu.Lock()
+	if !p.closed && !p.terminating {
+		if waitErr == nil {
+			waitErr = errors.New("exited without closing its window")
+		}
+		p.err = core.Errorf(core.CodeUnavailable, "%w: %v", ErrWebviewCrashed, waitErr)
+	}
+	p.mu.Unlock()
+	close(p.done)
+}
+
+// call runs a bridge call from the page and sends back its result
+func (p *OutOfProcess) call(msg IPCMessage) {
+	reply := IPCMessage{Type: IPCResult, ID: msg.ID}
+
+	result, err := p.invoke(msg)
+	if err != nil {
+		info := core.ErrorInfoFor(err)
+		reply.Error = &info
+	} else {
+		reply.Data = result
+	}
+	p.conn.send(reply)
+}
+
+func (p *OutOfProcess) invoke(msg IPCMessage) ([]byte, error) {
+	if p.bridge == nil {
+		return nil, core.Errorf(core.CodeUnavailable, "no bridge")
+	}
+
+	var args []interface{}
+	if len(msg.Data) > 0 {
+		decoded, err := p.codec.Unmarshal(msg.Data)
+		if err != nil {
+			return nil, core.Errorf(core.CodeInvalidArgument, "invalid arguments: %w", err)
+		}
+		args, _ = decoded.([]interface{})
+	}
+
+	result, err := p.bridge.Call(p.ctx, msg.Name, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile(result)
+	if stream, ok := result.(*core.ResultStream); ok && stream != nil {
+		if result, err = stream.Collect(); err != nil {
+			return nil, err
+		}
+	}
+	encoded, err := p.codec.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize result: %w", err)
+	}
+	return encoded, nil
+}
+
+// send delivers msg to a running child
+func (p *OutOfProcess) send(msg IPCMessage) error {
+	p.mu.Lock()
+	conn := p.conn
+	p.mu.Unlock()
+
+	if conn == nil {
+		return fmt.Errorf("webview process not started")
+	}
+	select {
+	case <-p.done:
+		if err := p.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("webview process exited")
+	default:
+	}
+	return conn.send(msg)
+}
+
+// Eval executes JavaScript in the child's page
+func (p *OutOfProcess) Eval(script string) error {
+	return p.send(IPCMessage{Type: IPCEval, Name: script})
+}
+
+// Emit dispatches an event to the child's page, as Webview.Emit does
+func (p *OutOfProcess) Emit(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event, err)
+	}
+	return p.send(IPCMessage{Type: IPCEmit, Name: event, Data: payload})
+}
+
+// Done is closed when the child exits
+func (p *OutOfProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns an error wrapping ErrWebviewCrashed once the child has
+// crashed, and nil while it runs or after it closed normally
+func (p *OutOfProcess) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Wait blocks until the child exits and returns Err
+func (p *OutOfProcess) Wait() error {
+	<-p.done
+	return p.Err()
+}
+
+// Terminate closes the child's window, killing the child if it does not
+// exit within a few seconds
+func (p *OutOfProcess) Terminate() error {
+	p.mu.Lock()
+	if p.cmd == nil {
+		p.mu.Unlock()
+		return nil
+	}
+	p.terminating = true
+	conn := p.conn
+	p.mu.Unlock()
+
+	conn.send(IPCMessage{Type: IPCTerminate})
+	select {
+	case <-p.done:
+	case <-time.After(terminateGrace):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+	return nil
+}
+
+// childInit is the payload of IPCInit
+type childInit struct {
+	Config    core.WebviewConfig `json:"config"`
+	Functions []string           `json:"functions"`
+}
+
+// IsChildProcess reports whether this process was started by
+// OutOfProcess to host its window
+func IsChildProcess() bool {
+	return os.Getenv(childEnv) == "1"
+}
+
+// RunChild hosts the window of the parent OutOfProcess until it closes or
+// the parent goes away. It must run on the main goroutine. The IPC channel
+// moves off stdout to a private descriptor, and output the process writes
+// to stdout, from Go or native code, is sent to stderr.
+func RunChild() error {
+	ipc, err := privateStdout()
+	if err != nil {
+		return fmt.Errorf("failed to redirect stdout: %w", err)
+	}
+	conn := &ipcConn{r: os.Stdin, w: ipc}
+	os.Stdout = os.Stderr
+
+	msg, err := conn.receive()
+	if err != nil {
+		return fmt.Errorf("failed to read init: %w", err)
+	}
+	if msg.Type != IPCInit {
+		return fmt.Errorf("expected %s message, got %s", IPCInit, msg.Type)
+	}
+	var init childInit
+	if err := json.Unmarshal(msg.Data, &init); err != nil {
+		return fmt.Errorf("invalid init: %w", err)
+	}
+
+	bridge := newRemoteBridge(conn, init.Functions, init.Config)
+	w := New(init.Config, bridge)
+	if err := w.Initialize(); err != nil {
+		return err
+	}
+
+	// Commands run in order on their own goroutine, so a page call made
+	// while one runs can still receive its result. Terminate only stops
+	// the loop; Run destroys the window after it returns.
+	commands := make(chan IPCMessage, 64)
+	go func() {
+		for msg := range commands {
+			switch msg.Type {
+			case IPCEval:
+				w.Eval(msg.Name)
+			case IPCEmit:
+				w.Emit(msg.Name, msg.Data)
+			case IPCTerminate:
+				w.Terminate()
+			}
+		}
+	}()
+
+	go func() {
+		defer close(commands)
+		for {
+			msg, err := conn.receive()
+			if err != nil {
+				// The parent is gone
+				bridge.close()
+				commands <- IPCMessage{Type: IPCTerminate}
+				return
+			}
+			if msg.Type == IPCResult {
+				bridge.deliver(msg)
+			} else {
+				commands <- msg
+			}
+		}
+	}()
+
+	err = w.Run()
+	conn.send(IPCMessage{Type: IPCClosed})
+	return err
+}
+
+// remoteBridge forwards the child page's calls to the parent's bridge
+type remoteBridge struct {
+	conn      *ipcConn
+	functions []string
+	codec     core.Codec
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan IPCMessage
+	closed  bool
+}
+
+func newRemoteBridge(conn *ipcConn, functions []string, config core.WebviewConfig) *remoteBridge {
+	return &remoteBridge{
+		conn:      conn,
+		functions: functions,
+		codec:     bridgeCodec(config, core.FormatJSON),
+		pending:   make(map[uint64]chan IPCMessage),
+	}
+}
+
+func (b *remoteBridge) Register(name string, fn core.BridgeFunc) error {
+	return fmt.Errorf("functions must be registered in the parent process")
+}
+
+func (b *remoteBridge) Unregister(name string) error {
+	return fmt.Errorf("functions must be unregistered in the parent process")
+}
+
+// Functions returns the parent's functions as of startup
+func (b *remoteBridge) Functions() []string {
+	return b.functions
+}
+
+// Call sends a call to the parent and waits for its result
+func (b *remoteBridge) Call(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	encoded, err := b.codec.Marshal(args)
+	if err != nil {
+		return nil, core.Errorf(core.CodeInvalidArgument, "invalid arguments: %w", err)
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, core.NewError(core.CodeUnavailable, "backend process is gone")
+	}
+	b.nextID++
+	id := b.nextID
+	reply := make(chan IPCMessage, 1)
+	b.pending[id] = reply
+	b.mu.Unlock()
+
+	if err := b.conn.send(IPCMessage{Type: IPCCall, ID: id, Name: name, Data: encoded}); err != nil {
+		b.mu.Lock()
+		delete(b.pending, id)
+		b.mu.Unlock()
+		return nil, core.Errorf(core.CodeUnavailable, "backend process is gone: %w", err)
+	}
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			return nil, core.NewError(core.CodeUnavailable, "backend process is gone")
+		}
+		if msg.Error != nil {
+			return nil, &core.Error{Code: msg.Error.Code, Message: msg.Error.Message, Details: msg.Error.Details}
+		}
+		if len(msg.Data) == 0 {
+			return nil, nil
+		}
+		return b.codec.Unmarshal(msg.Data)
+	case <-ctx.Done():
+		b.mu.Lock()
+		delete(b.pending, id)
+		b.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// deliver hands a result to the call waiting for it
+func (b *remoteBridge) deliver(msg IPCMessage) {
+	b.mu.Lock()
+	reply, ok := b.pending[msg.ID]
+	delete(b.pending, msg.ID)
+	b.mu.Unlock()
+
+	if ok {
+		reply <- msg
+	}
+}
+
+// close fails calls in flight once the parent is gone
+func (b *remoteBridge) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for id, reply := range b.pending {
+		close(reply)
+		delete(b.pending, id)
+	}
+}
@@ -0,0 +1,61 @@
+package webview
+
+import (
+	"context"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// RuntimeProgressEvent is the event StreamProgress emits for each progress
+// report, with a core.Progress payload
+const RuntimeProgressEvent = "runtimeProgress"
+
+// progressBuffer is the number of reports held for the frontend before
+// new reports are dropped
+const progressBuffer = 256
+
+// StreamProgress returns a context whose executions send each progress
+// report to the frontend as a runtimeProgress event, in order. Reports
+// are queued so execution never waits on the webview; when the queue is
+// full, reports are dropped, which a progress bar does not notice. Call
+// stop once the execution returns to wait for delivery.
+func (w *Webview) StreamProgress(ctx context.Context) (_ context.Context, stop func()) {
+	reports := make(chan core.Progress, progressBuffer)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for report := range reports {
+			w.Emit(RuntimeProgressEvent, report)
+			w.pending.done()
+		}
+	}()
+
+	var mu sync.Mutex
+	closed := false
+	report := func(p core.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		w.pending.add(1)
+		select {
+		case reports <- p:
+		default:
+			w.pending.done()
+		}
+	}
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			mu.Lock()
+			closed = true
+			close(reports)
+			mu.Unlock()
+			<-done
+		})
+	}
+	return core.WithProgress(ctx, report), stop
+}
@@ -0,0 +1,285 @@
+package webview
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// ErrAlreadyRunning is returned by RegisterProtocol when another instance
+// handles the scheme. The launch URL has been forwarded to it and its
+// window focused, so this process should exit.
+var ErrAlreadyRunning = errors.New("another instance is handling deep links")
+
+// maxDeepLinkLength bounds URLs accepted from other instances
+const maxDeepLinkLength = 8 * 1024
+
+// RegisterProtocol registers the app as the handler for scheme:// URLs and
+// calls handler for each deep link: the URL the app was launched with, and
+// URLs from later launches, which are forwarded to this instance and focus
+// its window. Forwarded links are handled on a background goroutine. When
+// an instance is already running, the launch URL is forwarded to it and
+// ErrAlreadyRunning is returned.
+//
+// On macOS the OS delivers URLs to a running app as Apple Events, which
+// the webview does not receive, so links opened from other apps never
+// reach handler there; only forwarding between instances works.
+func (w *Webview) RegisterProtocol(scheme string, handler func(url string)) error {
+	scheme, err := normalizeScheme(scheme)
+	if err != nil {
+		return err
+	}
+
+	launchURL, _ := DeepLinkFromArgs(scheme, os.Args[1:])
+	if err := SendDeepLink(scheme, launchURL); err == nil {
+		return ErrAlreadyRunning
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if err := registerScheme(scheme, exe); err != nil {
+		w.logger.Log(core.LogWarn, fmt.Sprintf("failed to register %s:// handler: %v", scheme, err))
+	}
+
+	server, err := ListenDeepLinks(scheme, func(link string) {
+		w.focus()
+		handler(link)
+	})
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.protocols = append(w.protocols, server)
+	w.mu.Unlock()
+
+	if launchURL != "" {
+		handler(launchURL)
+	}
+	return nil
+}
+
+// focus brings the window to the front for a forwarded deep link
+func (w *Webview) focus() {
+	if w.WindowState() == StateMinimized {
+		w.Restore()
+	}
+	w.Eval("window.focus()")
+}
+
+// ParseDeepLink parses a deep link, checking that it uses scheme
+func ParseDeepLink(scheme, raw string) (*url.URL, error) {
+	scheme, err := normalizeScheme(scheme)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxDeepLinkLength {
+		return nil, fmt.Errorf("deep link exceeds %d bytes", maxDeepLinkLength)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deep link: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, scheme) {
+		return nil, fmt.Errorf("deep link %q does not use the %s scheme", raw, scheme)
+	}
+	return u, nil
+}
+
+// DeepLinkFromArgs finds a scheme URL among command-line arguments, as
+// passed by the OS when launching the registered handler
+func DeepLinkFromArgs(scheme string, args []string) (string, bool) {
+	for _, arg := range args {
+		if _, err := ParseDeepLink(scheme, arg); err == nil {
+			return arg, true
+		}
+	}
+	return "", false
+}
+
+// normalizeScheme validates a URL scheme (RFC 3986) and lowercases it
+func normalizeScheme(scheme string) (string, error) {
+	scheme = strings.ToLower(strings.TrimSuffix(scheme, "://"))
+	if scheme == "" {
+		return "", fmt.Errorf("scheme is required")
+	}
+	for i, c := range scheme {
+		letter := 'a' <= c && c <= 'z'
+		if i == 0 && !letter || !letter && !('0' <= c && c <= '9') && c != '+' && c != '-' && c != '.' {
+			return "", fmt.Errorf("invalid scheme %q", scheme)
+		}
+	}
+	switch scheme {
+	case "http", "https", "file", "about", "javascript", "data":
+		return "", fmt.Errorf("scheme %s is reserved", scheme)
+	}
+	return scheme, nil
+}
+
+// deepLinkSocket is where the running instance listens for forwarded
+// links. Links often carry auth tokens, so the socket lives in a
+// directory only the current user can access.
+func deepLinkSocket(scheme string) (string, error) {
+	dir, err := deepLinkDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("polyglot-%s.sock", scheme)), nil
+}
+
+// deepLinkDir returns $XDG_RUNTIME_DIR when it is private to the current
+// user, and otherwise a per-user directory under the temp directory,
+// created with mode 0700. A directory another user created first, or
+// opened to others, is refused.
+func deepLinkDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && checkPrivateDir(dir) == nil {
+		return dir, nil
+	}
+
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("polyglot-%d", os.Getuid()))
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("failed to create deep link directory: %w", err)
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return "", fmt.Errorf("deep link directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// DeepLinkServer receives deep links forwarded by later launches
+type DeepLinkServer struct {
+	scheme   string
+	listener net.Listener
+	handler  func(url string)
+	once     sync.Once
+}
+
+// ListenDeepLinks accepts deep links for scheme forwarded by other
+// instances, calling handler for each valid one. An empty link only
+// activates the instance and is passed to handler as "".
+func ListenDeepLinks(scheme string, handler func(url string)) (*DeepLinkServer, error) {
+	scheme, err := normalizeScheme(scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := deepLinkSocket(scheme)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		// A socket left by a crashed instance refuses connections. Only
+		// our own sockets are removed.
+		if checkSocketOwner(path) == nil && !socketAlive(path) {
+			os.Remove(path)
+			listener, err = net.Listen("unix", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for deep links: %w", err)
+		}
+	}
+
+	s := &DeepLinkServer{scheme: scheme, listener: listener, handler: handler}
+	go s.serve()
+	return s, nil
+}
+
+func socketAlive(path string) bool {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func (s *DeepLinkServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *DeepLinkServer) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	reader := bufio.NewReader(io.LimitReader(conn, maxDeepLinkLength+1))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	link := strings.TrimSuffix(line, "\n")
+	if link != "" {
+		if _, err := ParseDeepLink(s.scheme, link); err != nil {
+			fmt.Fprintf(conn, "error: %v\n", err)
+			return
+		}
+	}
+
+	s.handler(link)
+	fmt.Fprint(conn, "ok\n")
+}
+
+// Close stops accepting deep links
+func (s *DeepLinkServer) Close() error {
+	var err error
+	s.once.Do(func() { err = s.listener.Close() })
+	return err
+}
+
+// SendDeepLink forwards a deep link to the running instance handling
+// scheme. It fails when no instance is listening or the link is rejected.
+func SendDeepLink(scheme, link string) error {
+	scheme, err := normalizeScheme(scheme)
+	if err != nil {
+		return err
+	}
+	if strings.ContainsAny(link, "\r\n") {
+		return fmt.Errorf("invalid deep link %q", link)
+	}
+
+	path, err := deepLinkSocket(scheme)
+	if err != nil {
+		return err
+	}
+	// Never hand a link to a socket another user planted
+	if err := checkSocketOwner(path); err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := fmt.Fprintf(conn, "%s\n", link); err != nil {
+		return err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no reply from running instance: %w", err)
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return errors.New(strings.TrimPrefix(reply, "error: "))
+	}
+	return nil
+}
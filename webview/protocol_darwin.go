@@ -0,0 +1,14 @@
+//go:build darwin
+// +build darwin
+
+package webview
+
+import "fmt"
+
+// registerScheme reports that deep links are unsupported on macOS. URL
+// schemes are declared with CFBundleURLTypes in the app bundle's
+// Info.plist, but Launch Services delivers the URLs as Apple Events
+// rather than arguments, and the webview does not handle those.
+func registerScheme(scheme, exe string) error {
+	return fmt.Errorf("%s:// links opened from other apps are not delivered on macOS", scheme)
+}
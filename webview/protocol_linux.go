@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package webview
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// registerScheme installs a desktop entry handling x-scheme-handler/<scheme>
+// and makes it the default handler
+func registerScheme(scheme, exe string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	dir := filepath.Join(dataHome, "applications")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("polyglot-%s-handler.desktop", scheme)
+	entry := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=%s
+Exec="%s" %%u
+NoDisplay=true
+MimeType=x-scheme-handler/%s;
+`, filepath.Base(exe), exe, scheme)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(entry), 0644); err != nil {
+		return err
+	}
+
+	if out, err := exec.Command("xdg-mime", "default", name, "x-scheme-handler/"+scheme).CombinedOutput(); err != nil {
+		return fmt.Errorf("xdg-mime failed: %v: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package webview
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// registerScheme is not supported on this platform
+func registerScheme(scheme, exe string) error {
+	return fmt.Errorf("protocol registration not supported on %s", runtime.GOOS)
+}
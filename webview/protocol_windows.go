@@ -0,0 +1,41 @@
+//go:build windows
+// +build windows
+
+package webview
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// registerScheme registers the executable for the scheme under the
+// current user's classes
+func registerScheme(scheme, exe string) error {
+	key := `HKCU\Software\Classes\` + scheme
+	commands := [][]string{
+		{"add", key, "/ve", "/d", "URL:" + scheme + " Protocol", "/f"},
+		{"add", key, "/v", "URL Protocol", "/d", "", "/f"},
+		{"add", key + `\shell\open\command`, "/ve", "/d", fmt.Sprintf(`"%s" "%%1"`, exe), "/f"},
+	}
+	for _, args := range commands {
+		if out, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("reg %s failed: %v: %s", args[1], err, out)
+		}
+	}
+	return nil
+}
+
+// checkPrivateDir accepts any directory: the temp directory is already
+// private to the user on Windows
+func checkPrivateDir(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+// checkSocketOwner checks only that the socket exists, as the directory
+// holding it is private to the user
+func checkSocketOwner(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
@@ -0,0 +1,119 @@
+package webview
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosedBeforeReady is returned by WaitReady when the webview is
+// terminated before its page finished loading
+var ErrClosedBeforeReady = errors.New("webview closed before the page was ready")
+
+// readyScript reports the page ready once its DOM has loaded
+const readyScript = `
+		(function() {
+			var report = function() { __polyglot_ready__(); };
+			if (document.readyState === 'loading') document.addEventListener('DOMContentLoaded', report);
+			else report();
+		})();
+	`
+
+// readiness tracks whether the window's page has loaded
+type readiness struct {
+	mu       sync.Mutex
+	loaded   bool
+	closed   bool
+	done     chan struct{}
+	handlers []func()
+}
+
+// signal returns the channel closed once the page loads or the window
+// closes. Callers hold r.mu.
+func (r *readiness) signal() chan struct{} {
+	if r.done == nil {
+		r.done = make(chan struct{})
+	}
+	return r.done
+}
+
+// reset starts waiting for a new window's page
+func (r *readiness) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loaded || r.closed {
+		r.done = nil
+	}
+	r.loaded, r.closed = false, false
+}
+
+// markLoaded records a page load, returning the handlers to notify
+func (r *readiness) markLoaded() []func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded && !r.closed {
+		close(r.signal())
+	}
+	r.loaded = true
+	return append([]func(){}, r.handlers...)
+}
+
+// close wakes waiters of a window closed before its page loaded
+func (r *readiness) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded && !r.closed {
+		close(r.signal())
+	}
+	r.closed = true
+}
+
+// WaitReady blocks until the page has fired DOMContentLoaded, so Emit and
+// initial data pushes made afterwards reach listeners the page registered
+// while loading. It returns at once if the page is already loaded, and
+// fails with ErrClosedBeforeReady if the webview is terminated first.
+func (w *Webview) WaitReady(ctx context.Context) error {
+	w.ready.mu.Lock()
+	done := w.ready.signal()
+	w.ready.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	w.ready.mu.Lock()
+	defer w.ready.mu.Unlock()
+	if !w.ready.loaded {
+		return ErrClosedBeforeReady
+	}
+	return nil
+}
+
+// Ready reports whether the page has loaded
+func (w *Webview) Ready() bool {
+	w.ready.mu.Lock()
+	defer w.ready.mu.Unlock()
+	return w.ready.loaded
+}
+
+// OnReady calls fn in its own goroutine each time a page finishes
+// loading, including after reloads and navigation, so the backend can
+// push the data a freshly loaded page needs
+func (w *Webview) OnReady(fn func()) {
+	w.ready.mu.Lock()
+	defer w.ready.mu.Unlock()
+	w.ready.handlers = append(w.ready.handlers, fn)
+}
+
+// bindReady reports page loads to WaitReady and OnReady
+func (w *Webview) bindReady() {
+	w.ready.reset()
+	w.instance.Bind("__polyglot_ready__", func() {
+		for _, fn := range w.ready.markLoaded() {
+			go fn()
+		}
+	})
+	w.instance.Init(readyScript)
+}
@@ -0,0 +1,109 @@
+package webview
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stateFileName is the file RememberState keeps window state in
+const stateFileName = "window-state.json"
+
+// SavedState is the window state remembered between launches
+type SavedState struct {
+	// Zoom is the page zoom factor, or zero when none was saved
+	Zoom float64 `json:"zoom,omitempty"`
+}
+
+// StateStore loads and saves remembered window state
+type StateStore interface {
+	// Load returns the saved state, which is empty when nothing has been
+	// saved yet
+	Load() (SavedState, error)
+
+	// Save replaces the saved state
+	Save(state SavedState) error
+}
+
+// FileStateStore keeps window state as JSON in a file
+type FileStateStore struct {
+	path string
+}
+
+// NewFileStateStore creates a store keeping state in the file at path,
+// which is created with its directory on the first save
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+// DefaultStateFile returns where a window titled title remembers its
+// state: window-state.json in a directory named after the title under the
+// user's config directory
+func DefaultStateFile(title string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the config directory: %w", err)
+	}
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if name == "" || name == "." || name == ".." {
+		name = "polyglot"
+	}
+	return filepath.Join(dir, name, stateFileName), nil
+}
+
+// Path returns the file the store keeps state in
+func (s *FileStateStore) Path() string {
+	return s.path
+}
+
+// Load reads the saved state, returning an empty state when the file does
+// not exist
+func (s *FileStateStore) Load() (SavedState, error) {
+	var state SavedState
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return SavedState{}, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return state, nil
+}
+
+// Save writes state to the file, replacing it atomically so a crash never
+// leaves it half written
+func (s *FileStateStore) Save(state SavedState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(s.path), err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(s.path), stateFileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", s.path, err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to save %s: %w", s.path, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to save %s: %w", s.path, err)
+	}
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", s.path, err)
+	}
+	return nil
+}
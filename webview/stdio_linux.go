@@ -0,0 +1,12 @@
+//go:build linux
+// +build linux
+
+package webview
+
+import "syscall"
+
+// dup2 makes to a copy of from; Dup2 is missing on some Linux
+// architectures
+func dup2(from, to int) error {
+	return syscall.Dup3(from, to, 0)
+}
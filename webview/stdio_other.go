@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package webview
+
+import "syscall"
+
+// dup2 makes to a copy of from
+func dup2(from, to int) error {
+	return syscall.Dup2(from, to)
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package webview
+
+import (
+	"os"
+	"syscall"
+)
+
+// privateStdout moves the IPC channel from fd 1 to a private descriptor
+// and points fd 1 at stderr, so output from native code or cgo, which
+// bypasses os.Stdout, cannot corrupt the channel
+func privateStdout() (*os.File, error) {
+	syscall.ForkLock.RLock()
+	fd, err := syscall.Dup(1)
+	if err == nil {
+		syscall.CloseOnExec(fd)
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dup2(2, 1); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "ipc"), nil
+}
@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package webview
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procSetStdHandle = kernel32.NewProc("SetStdHandle")
+)
+
+// privateStdout keeps the IPC channel on the original stdout handle and
+// points the process's standard output at stderr, so native code that
+// looks up the handle writes there. C runtime descriptors opened at
+// startup still hold the original handle.
+func privateStdout() (*os.File, error) {
+	ipc := os.NewFile(uintptr(syscall.Stdout), "ipc")
+	stdout := syscall.STD_OUTPUT_HANDLE
+	if r, _, err := procSetStdHandle.Call(uintptr(stdout), uintptr(syscall.Stderr)); r == 0 {
+		return nil, err
+	}
+	return ipc, nil
+}
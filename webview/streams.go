@@ -0,0 +1,145 @@
+package webview
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// resultStreams holds ResultStreams until the frontend drains or abandons
+// them
+type resultStreams struct {
+	mu      sync.Mutex
+	next    int
+	streams map[string]*core.ResultStream
+}
+
+// open registers a stream and returns its id
+func (s *resultStreams) open(stream *core.ResultStream) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.streams == nil {
+		s.streams = make(map[string]*core.ResultStream)
+	}
+	s.next++
+	id := strconv.Itoa(s.next)
+	s.streams[id] = stream
+	return id
+}
+
+// read returns the next chunk of a stream, with done set once the stream
+// is exhausted and removed
+func (s *resultStreams) read(id string) (chunk interface{}, done bool, err error) {
+	s.mu.Lock()
+	stream, ok := s.streams[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, false, core.Errorf(core.CodeNotFound, "result stream %s not found", id)
+	}
+
+	chunk, err = stream.Next()
+	if err == io.EOF {
+		s.close(id)
+		return nil, true, nil
+	}
+	if err != nil {
+		s.close(id)
+		return nil, false, err
+	}
+	return chunk, false, nil
+}
+
+// close removes a stream and closes it
+func (s *resultStreams) close(id string) {
+	s.mu.Lock()
+	stream, ok := s.streams[id]
+	delete(s.streams, id)
+	s.mu.Unlock()
+
+	if ok {
+		stream.Close()
+	}
+}
+
+// closeAll releases every open stream
+func (s *resultStreams) closeAll() {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.streams))
+	for id := range s.streams {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		s.close(id)
+	}
+}
+
+// describe replaces FileResponse, ResultStream and image results with the
+// descriptors the frontend reads them through
+func (w *Webview) describe(result interface{}) interface{} {
+	if image, ok := core.ImageFrom(result); ok {
+		return imageDescriptor(image)
+	}
+	stream, ok := result.(*core.ResultStream)
+	if !ok {
+		return w.fileDescriptor(result)
+	}
+	if stream == nil {
+		return result
+	}
+
+	return map[string]interface{}{
+		"__polyglot_stream__": map[string]interface{}{
+			"id": w.streams.open(stream),
+		},
+	}
+}
+
+// discard closes the file or result stream a descriptor from describe
+// opened, for results that never reach the frontend
+func (w *Webview) discard(described interface{}) {
+	m, ok := described.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if file, ok := m["__polyglot_file__"].(map[string]interface{}); ok {
+		w.files.close(file["id"].(string))
+	}
+	if stream, ok := m["__polyglot_stream__"].(map[string]interface{}); ok {
+		w.streams.close(stream["id"].(string))
+	}
+}
+
+// bindStreams lets the frontend pull ResultStream chunks one at a time.
+// Each chunk is encoded on its own as {"value": chunk}, and {"done": true}
+// marks the end of the stream.
+func (w *Webview) bindStreams() {
+	codec := bridgeCodec(w.config, core.FormatJSON)
+
+	w.instance.Bind("__polyglot_next__", func(id string) (string, error) {
+		chunk, done, err := w.streams.read(id)
+		if err != nil {
+			return "", bridgeError(err)
+		}
+		if done {
+			return `{"done":true}`, nil
+		}
+
+		encoded, err := codec.Marshal(map[string]interface{}{"value": chunk})
+		if err != nil {
+			w.streams.close(id)
+			return "", bridgeError(fmt.Errorf("failed to serialize stream chunk: %w", err))
+		}
+		return string(encoded), nil
+	})
+
+	w.instance.Bind("__polyglot_close__", func(id string) {
+		w.streams.close(id)
+	})
+}
@@ -7,10 +7,18 @@ import "fmt"
 
 // StubBackend is a no-op implementation for testing or when webview is disabled
 type StubBackend struct {
-	title  string
-	url    string
-	width  int
-	height int
+	title     string
+	url       string
+	width     int
+	height    int
+	options   WindowOptions
+	state     WindowState
+	userAgent string
+	headers   map[string]string
+	zoom      float64
+
+	// ready is the page load binding, called by Run as if the page loaded
+	ready func()
 }
 
 // NewStubBackend creates a stub webview instance
@@ -41,6 +49,9 @@ func (s *StubBackend) Navigate(url string) {
 func (s *StubBackend) Run() {
 	fmt.Println("Stub: Run() - webview would start here")
 	fmt.Printf("Stub: Window: %s - %dx%d - %s\n", s.title, s.width, s.height, s.url)
+	if s.ready != nil {
+		s.ready()
+	}
 }
 
 func (s *StubBackend) Eval(script string) {
@@ -49,6 +60,9 @@ func (s *StubBackend) Eval(script string) {
 
 func (s *StubBackend) Bind(name string, fn interface{}) error {
 	fmt.Printf("Stub: Bind(%s, <func>)\n", name)
+	if ready, ok := fn.(func()); ok && name == "__polyglot_ready__" {
+		s.ready = ready
+	}
 	return nil
 }
 
@@ -56,6 +70,36 @@ func (s *StubBackend) Init(script string) {
 	fmt.Printf("Stub: Init(%s)\n", script)
 }
 
+func (s *StubBackend) SetWindowOptions(opts WindowOptions) error {
+	s.options = opts
+	fmt.Printf("Stub: SetWindowOptions(%+v)\n", opts)
+	return nil
+}
+
+func (s *StubBackend) SetWindowState(state WindowState) error {
+	s.state = state
+	fmt.Printf("Stub: SetWindowState(%s)\n", state)
+	return nil
+}
+
+func (s *StubBackend) SetUserAgent(ua string) error {
+	s.userAgent = ua
+	fmt.Printf("Stub: SetUserAgent(%s)\n", ua)
+	return nil
+}
+
+func (s *StubBackend) SetRequestHeaders(headers map[string]string) error {
+	s.headers = headers
+	fmt.Printf("Stub: SetRequestHeaders(%d headers)\n", len(headers))
+	return nil
+}
+
+func (s *StubBackend) SetZoom(factor float64) error {
+	s.zoom = factor
+	fmt.Printf("Stub: SetZoom(%g)\n", factor)
+	return nil
+}
+
 func (s *StubBackend) Terminate() {
 	fmt.Println("Stub: Terminate()")
 }
@@ -0,0 +1,222 @@
+package webview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// EmittedEvent is an event sent to the frontend with Emit
+type EmittedEvent struct {
+	// Name of the event
+	Name string
+
+	// Data as the frontend receives it, decoded from JSON
+	Data interface{}
+
+	// Seq numbers the events of each name from 1, in the order the page
+	// delivers them
+	Seq uint64
+}
+
+// TestWebview is a headless webview for testing the contract between an
+// app's bridge and its frontend without a display. It records scripts
+// passed to Eval and events passed to Emit, and Call simulates a bridge
+// call made by the frontend.
+type TestWebview struct {
+	*Webview
+	backend *recordingBackend
+}
+
+// NewTestWebview creates an initialized headless webview for bridge
+func NewTestWebview(bridge core.Bridge) *TestWebview {
+	return NewTestWebviewWith(core.DefaultConfig().Webview, bridge)
+}
+
+// NewTestWebviewWith creates an initialized headless webview for bridge
+// with config
+func NewTestWebviewWith(config core.WebviewConfig, bridge core.Bridge) *TestWebview {
+	backend := &recordingBackend{
+		bindings: make(map[string]interface{}),
+		pending:  make(map[uint64]chan settledCall),
+	}
+	w := New(config, bridge)
+	w.emitted = backend.recordEvent
+	w.settled = backend.settle
+	w.initialize(func(debug bool) WebviewBackend { return backend })
+	return &TestWebview{Webview: w, backend: backend}
+}
+
+// Scripts returns the scripts passed to Eval, including those sent by Emit
+func (t *TestWebview) Scripts() []string {
+	t.backend.mu.Lock()
+	defer t.backend.mu.Unlock()
+	return append([]string(nil), t.backend.scripts...)
+}
+
+// Events returns the events passed to Emit
+func (t *TestWebview) Events() []EmittedEvent {
+	t.backend.mu.Lock()
+	defer t.backend.mu.Unlock()
+	return append([]EmittedEvent(nil), t.backend.events...)
+}
+
+// LoadPage simulates the page firing DOMContentLoaded, releasing
+// WaitReady and calling the OnReady handlers
+func (t *TestWebview) LoadPage() error {
+	t.backend.mu.Lock()
+	binding, ok := t.backend.bindings["__polyglot_ready__"].(func())
+	t.backend.mu.Unlock()
+	if !ok {
+		return core.NewError(core.CodeUnavailable, "ready signal not bound")
+	}
+	binding()
+	return nil
+}
+
+// Reset clears recorded scripts and events
+func (t *TestWebview) Reset() {
+	t.backend.mu.Lock()
+	defer t.backend.mu.Unlock()
+	t.backend.scripts = nil
+	t.backend.events = nil
+}
+
+// Call simulates window.polyglot.callOnce from the frontend: arguments and
+// the result travel through the JSON bridge binding, and failures are
+// returned as *core.Error with the code the frontend would see. With a
+// handler pool, the call is dispatched to the pool the way the page
+// dispatches it, and Call waits for it to settle; calls from several
+// goroutines then run concurrently.
+func (t *TestWebview) Call(name string, args ...interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	var resultJSON string
+	if t.handlers != nil {
+		resultJSON, err = t.dispatch(name, string(argsJSON))
+	} else {
+		t.backend.mu.Lock()
+		binding, ok := t.backend.bindings["__polyglot_call__"].(func(string, string) (string, error))
+		t.backend.mu.Unlock()
+		if !ok {
+			return nil, core.NewError(core.CodeUnavailable, "bridge not bound")
+		}
+		resultJSON, err = binding(name, string(argsJSON))
+	}
+	if err != nil {
+		var info core.ErrorInfo
+		if jsonErr := json.Unmarshal([]byte(err.Error()), &info); jsonErr != nil {
+			return nil, err
+		}
+		return nil, &core.Error{Code: info.Code, Message: info.Message, Details: info.Details}
+	}
+
+	var result interface{}
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	return result, nil
+}
+
+// dispatch sends a call through the dispatch binding and waits for it to
+// settle
+func (t *TestWebview) dispatch(name string, argsJSON string) (string, error) {
+	t.backend.mu.Lock()
+	binding, ok := t.backend.bindings["__polyglot_dispatch__"].(func(uint64, string, string, string) error)
+	if !ok {
+		t.backend.mu.Unlock()
+		return "", core.NewError(core.CodeUnavailable, "bridge not bound")
+	}
+	t.backend.lastCall++
+	id := t.backend.lastCall
+	settled := make(chan settledCall, 1)
+	t.backend.pending[id] = settled
+	t.backend.mu.Unlock()
+
+	if err := binding(id, "__polyglot_call__", name, argsJSON); err != nil {
+		t.backend.mu.Lock()
+		delete(t.backend.pending, id)
+		t.backend.mu.Unlock()
+		return "", err
+	}
+	call := <-settled
+	if !call.ok {
+		return "", errors.New(call.value)
+	}
+	return call.value, nil
+}
+
+// settledCall is the outcome of a pooled call
+type settledCall struct {
+	ok    bool
+	value string
+}
+
+// recordingBackend is the headless backend behind a TestWebview
+type recordingBackend struct {
+	mu       sync.Mutex
+	bindings map[string]interface{}
+	scripts  []string
+	events   []EmittedEvent
+
+	// pending holds the pooled calls waiting to settle
+	pending  map[uint64]chan settledCall
+	lastCall uint64
+}
+
+func (b *recordingBackend) settle(id uint64, ok bool, value string) {
+	b.mu.Lock()
+	settled := b.pending[id]
+	delete(b.pending, id)
+	b.mu.Unlock()
+	if settled != nil {
+		settled <- settledCall{ok: ok, value: value}
+	}
+}
+
+func (b *recordingBackend) recordEvent(event string, seq uint64, payload []byte) {
+	var data interface{}
+	json.Unmarshal(payload, &data)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, EmittedEvent{Name: event, Data: data, Seq: seq})
+}
+
+func (b *recordingBackend) SetTitle(title string)                             {}
+func (b *recordingBackend) SetSize(width, height int, hint Hint)              {}
+func (b *recordingBackend) Navigate(url string)                               {}
+func (b *recordingBackend) Run()                                              {}
+func (b *recordingBackend) Init(script string)                                {}
+func (b *recordingBackend) SetWindowOptions(opts WindowOptions) error         { return nil }
+func (b *recordingBackend) SetWindowState(state WindowState) error            { return nil }
+func (b *recordingBackend) SetUserAgent(ua string) error                      { return nil }
+func (b *recordingBackend) SetRequestHeaders(headers map[string]string) error { return nil }
+func (b *recordingBackend) SetZoom(factor float64) error                      { return nil }
+func (b *recordingBackend) Terminate()                                        {}
+func (b *recordingBackend) Destroy()                                          {}
+
+func (b *recordingBackend) Eval(script string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.scripts = append(b.scripts, script)
+}
+
+func (b *recordingBackend) Bind(name string, fn interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, exists := b.bindings[name]; exists {
+		return fmt.Errorf("binding %s already exists", name)
+	}
+	b.bindings[name] = fn
+	return nil
+}
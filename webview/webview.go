@@ -1,7 +1,10 @@
 package webview
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"runtime"
 	"sync"
@@ -11,19 +14,76 @@ import (
 
 // Webview manages the native webview window
 type Webview struct {
-	config   core.WebviewConfig
-	bridge   core.Bridge
-	instance WebviewBackend
-	mu       sync.Mutex
-	running  bool
+	config    core.WebviewConfig
+	bridge    core.Bridge
+	instance  WebviewBackend
+	mode      Mode
+	mu        sync.Mutex
+	running   bool
+	stopping  bool
+	state     WindowState
+	zoom      float64
+	store     StateStore
+	headers   map[string]string
+	logger    core.Logger
+	files     fileStreams
+	streams   resultStreams
+	pending   pendingEvents
+	ready     readiness
+	protocols []*DeepLinkServer
+	channels  eventChannels
+	emitted   func(event string, seq uint64, payload []byte)
+
+	// handlers runs bridge calls off the message loop when
+	// config.HandlerPool is set, dispatching them to the handlers of the
+	// call bindings
+	handlers *handlerPool
+	calls    map[string]callHandler
+	settled  func(id uint64, ok bool, value string)
+
+	// limit bounds the calls running at once when
+	// config.MaxConcurrentCalls is set
+	limit *callLimit
+
+	// arena pools call buffers when config.Arena is set
+	arena *core.CallArena
+
+	// frameToken authenticates frames posted to an asset server
+	frameToken string
+
+	// assets serves the page when ServeAssets is used, and closes with
+	// the window
+	assets *AssetServer
 }
 
-// New creates a new webview instance
+// New creates a new webview instance. The page can only call the bridge
+// functions config.Access permits.
 func New(config core.WebviewConfig, bridge core.Bridge) *Webview {
-	return &Webview{
+	w := &Webview{
 		config: config,
-		bridge: bridge,
+		bridge: core.RestrictBridge(bridge, config.Access),
+		state:  StateNormal,
+		zoom:   1,
+		logger: core.DefaultLogger(),
+
+		frameToken: newFrameToken(),
+	}
+	if config.Zoom > 0 {
+		w.zoom = clampZoom(config.Zoom)
 	}
+	if config.RememberState {
+		w.rememberState()
+	}
+	if config.Arena {
+		w.arena = core.NewCallArena()
+	}
+	if config.HandlerPool > 0 {
+		w.handlers = newHandlerPool(config.HandlerPool, config.HandlerOrder)
+	}
+	if config.MaxConcurrentCalls > 0 {
+		w.limit = newCallLimit(config.MaxConcurrentCalls, config.CallOverflow)
+	}
+	return w
 }
 
 // Initialize creates the webview window
@@ -31,6 +91,11 @@ func (w *Webview) Initialize() error {
 	// Lock to OS thread - required for WebKit on macOS and GTK on Linux
 	runtime.LockOSThread()
 
+	return w.initialize(NewBackend)
+}
+
+// initialize creates the window with the given backend factory
+func (w *Webview) initialize(newBackend func(debug bool) WebviewBackend) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -39,21 +104,161 @@ func (w *Webview) Initialize() error {
 	}
 
 	// Create webview instance using configured backend
-	w.instance = NewBackend(w.config.Debug)
-	if w.instance == nil {
-		return fmt.Errorf("failed to create webview")
+	instance, mode, err := w.createBackend(newBackend)
+	if err != nil {
+		return err
+	}
+	if err := w.applyOverrides(instance, mode); err != nil {
+		instance.Destroy()
+		return err
 	}
+	w.instance = instance
+	w.mode = mode
 
 	// Configure window
 	w.instance.SetTitle(w.config.Title)
 	w.instance.SetSize(w.config.Width, w.config.Height, HintNone)
 
+	// Apply window features; those the backend cannot honor are left off
+	// and reported, and the window works without them
+	if err := w.instance.SetWindowOptions(w.windowOptions()); err != nil {
+		w.logger.Log(core.LogWarn, err.Error())
+	}
+	if w.config.Fullscreen {
+		if err := w.instance.SetWindowState(StateFullscreen); err != nil {
+			w.logger.Log(core.LogWarn, fmt.Sprintf("window cannot start fullscreen: %v", err))
+		} else {
+			w.state = StateFullscreen
+		}
+	}
+
+	if err := w.instance.SetZoom(w.zoom); err != nil {
+		w.logger.Log(core.LogWarn, err.Error())
+	}
+
 	// Bind bridge functions
 	w.bindBridge()
+	w.bindWindowControls()
+	w.bindConsole()
+	w.bindEvents()
+	w.bindReady()
+
+	return nil
+}
+
+// applyOverrides applies the user agent and request headers. A native
+// window that cannot set the user agent fails, since the app would
+// otherwise identify itself wrongly to servers; a browser tab, already a
+// fallback, only warns. Request headers are unsupported by the native
+// backends, so headers a backend refuses are skipped with a warning.
+func (w *Webview) applyOverrides(instance WebviewBackend, mode Mode) error {
+	if err := instance.SetUserAgent(w.config.UserAgent); err != nil {
+		if mode != ModeBrowser {
+			return err
+		}
+		w.logger.Log(core.LogWarn, err.Error())
+	}
+	if err := instance.SetRequestHeaders(w.headers); err != nil {
+		w.logger.Log(core.LogWarn, err.Error())
+	}
+	return nil
+}
+
+// createBackend creates the window with newBackend, falling back to a
+// browser tab when it is unavailable and config.BrowserFallback is set
+func (w *Webview) createBackend(newBackend func(debug bool) WebviewBackend) (WebviewBackend, Mode, error) {
+	if instance := newBackend(w.config.Debug); instance != nil {
+		return instance, ModeNative, nil
+	}
+	if !w.config.BrowserFallback {
+		return nil, "", fmt.Errorf("failed to create webview")
+	}
+	w.logger.Log(core.LogWarn, "native webview unavailable, opening the app in a browser tab")
+	browser, err := NewBrowserBackend(BrowserConfig{
+		MaxMessageBytes: w.config.MaxMessageBytes,
+		Logger:          currentLogger{w},
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("native webview unavailable and browser fallback failed: %w", err)
+	}
+	browser.server.ServeFrames(w)
+	return browser, ModeBrowser, nil
+}
+
+// currentLogger logs through the webview's logger at the time of each
+// message, so SetLogger also reaches a backend created before it. It must
+// not be used while holding w.mu.
+type currentLogger struct {
+	w *Webview
+}
+
+func (l currentLogger) Log(level core.LogLevel, msg string) {
+	l.w.mu.Lock()
+	logger := l.w.logger
+	l.w.mu.Unlock()
+	logger.Log(level, msg)
+}
+
+// Mode reports how the page is shown, once Initialize has succeeded
+func (w *Webview) Mode() Mode {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.mode
+}
+
+// Config returns the webview configuration
+func (w *Webview) Config() core.WebviewConfig {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.config
+}
+
+// UserAgent returns the configured user agent override
+func (w *Webview) UserAgent() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.config.UserAgent
+}
 
+// SetRequestHeaders sets extra headers applied to navigation requests.
+// Headers set before Initialize are applied when the window is created and
+// skipped with a warning if the backend cannot send them; afterwards the
+// backend's error is returned and the previous headers are kept. The native
+// backends do not support request headers.
+func (w *Webview) SetRequestHeaders(headers map[string]string) error {
+	if err := validateHeaders(headers); err != nil {
+		return err
+	}
+
+	copied := make(map[string]string, len(headers))
+	for name, value := range headers {
+		copied[name] = value
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.instance != nil {
+		if err := w.instance.SetRequestHeaders(copied); err != nil {
+			return err
+		}
+	}
+	w.headers = copied
 	return nil
 }
 
+// RequestHeaders returns the configured navigation headers
+func (w *Webview) RequestHeaders() map[string]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	headers := make(map[string]string, len(w.headers))
+	for name, value := range w.headers {
+		headers[name] = value
+	}
+	return headers
+}
+
 // Run starts the webview event loop
 func (w *Webview) Run() error {
 	w.mu.Lock()
@@ -62,17 +267,25 @@ func (w *Webview) Run() error {
 		return fmt.Errorf("webview not initialized")
 	}
 	w.running = true
+	instance := w.instance
+	url := w.config.URL
 	w.mu.Unlock()
 
 	// Navigate to URL
-	w.instance.Navigate(w.config.URL)
+	instance.Navigate(url)
 
 	// Run event loop (blocks)
-	w.instance.Run()
+	instance.Run()
 
 	w.mu.Lock()
+	defer w.mu.Unlock()
 	w.running = false
-	w.mu.Unlock()
+	// A Terminate during the loop left the window for Run to destroy,
+	// since it cannot be freed while the loop still uses it
+	if w.stopping {
+		w.stopping = false
+		w.destroy()
+	}
 
 	return nil
 }
@@ -90,6 +303,38 @@ func (w *Webview) Eval(script string) error {
 	return nil
 }
 
+// Emit dispatches an event to the frontend as a "polyglot:<event>" DOM
+// event whose detail is data encoded as JSON. Pages can listen with
+// window.polyglot.on(event, handler).
+//
+// Events of one name reach the page in the order Emit returns, whatever
+// the transport; events of different names are independent. Emit blocks
+// while another event of the same name is being sent.
+func (w *Webview) Emit(event string, data interface{}) error {
+	name, err := json.Marshal("polyglot:" + event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event name: %w", err)
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", event, err)
+	}
+
+	channel := w.channels.get(event)
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+
+	seq := channel.seq + 1
+	if err := w.Eval(fmt.Sprintf(emitScript, name, seq, payload)); err != nil {
+		return err
+	}
+	channel.seq = seq
+	if w.emitted != nil {
+		w.emitted(event, seq, payload)
+	}
+	return nil
+}
+
 // Bind adds a Go function callable from JavaScript
 func (w *Webview) Bind(name string, fn interface{}) error {
 	w.mu.Lock()
@@ -102,62 +347,713 @@ func (w *Webview) Bind(name string, fn interface{}) error {
 	return w.instance.Bind(name, fn)
 }
 
-// Terminate closes the webview
+// Minimize minimizes the window
+func (w *Webview) Minimize() error {
+	return w.setState(StateMinimized)
+}
+
+// Maximize maximizes the window
+func (w *Webview) Maximize() error {
+	return w.setState(StateMaximized)
+}
+
+// Fullscreen enters or leaves fullscreen mode
+func (w *Webview) Fullscreen(enable bool) error {
+	if enable {
+		return w.setState(StateFullscreen)
+	}
+	return w.setState(StateNormal)
+}
+
+// Restore returns the window to its normal state
+func (w *Webview) Restore() error {
+	return w.setState(StateNormal)
+}
+
+// WindowState returns the current window display state
+func (w *Webview) WindowState() WindowState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state
+}
+
+// setState applies a window state through the backend and records it once
+// the backend has applied it. w.mu is not held meanwhile, since the native
+// backend waits for the UI thread, which may itself be waiting for w.mu.
+func (w *Webview) setState(state WindowState) error {
+	w.mu.Lock()
+	instance := w.instance
+	w.mu.Unlock()
+
+	if instance == nil {
+		return fmt.Errorf("webview not initialized")
+	}
+
+	if err := instance.SetWindowState(state); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.instance == instance {
+		w.state = state
+	}
+	return nil
+}
+
+// Terminate closes the webview. It may be called from any goroutine:
+// while Run is looping, the loop is stopped and Run destroys the window
+// once it returns.
 func (w *Webview) Terminate() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.instance == nil {
+	if w.instance == nil || w.stopping {
 		return nil
 	}
 
 	w.instance.Terminate()
+	if w.running {
+		w.stopping = true
+		return nil
+	}
+	w.destroy()
+	return nil
+}
+
+// destroy frees the window and releases what it held. Callers hold w.mu.
+func (w *Webview) destroy() {
 	w.instance.Destroy()
 	w.instance = nil
+	w.ready.close()
+	w.state = StateNormal
+	w.files.closeAll()
+	w.streams.closeAll()
+	for _, server := range w.protocols {
+		server.Close()
+	}
+	w.protocols = nil
+	if w.assets != nil {
+		w.assets.Close()
+		w.assets = nil
+	}
+}
 
-	return nil
+// windowOptions extracts window features from the configuration.
+// Fullscreen is left to SetWindowState, so the window state is only
+// recorded once the backend has entered it.
+func (w *Webview) windowOptions() WindowOptions {
+	return WindowOptions{
+		Transparent: w.config.Transparent,
+		Frameless:   w.config.Frameless,
+		AlwaysOnTop: w.config.AlwaysOnTop,
+	}
+}
+
+// bridgeCodec returns the codec for a wire format using the number and
+// time policies in config. MessagePack decoding enforces the message depth
+// and token limits itself, since packed payloads skip checkMessageShape.
+func bridgeCodec(config core.WebviewConfig, format string) core.Codec {
+	codec := core.CodecWithPolicies(format, core.ParseNumberPolicy(config.Numbers), core.ParseTimePolicy(config.Times))
+	if packed, ok := codec.(core.MsgpackCodec); ok {
+		packed.MaxDepth = messageLimit(config.MaxMessageDepth, core.DefaultMaxMessageDepth)
+		packed.MaxTokens = messageLimit(config.MaxMessageTokens, core.DefaultMaxMessageTokens)
+		return packed
+	}
+	return codec
 }
 
-// bindBridge sets up the JavaScript bridge
+// bindBridge sets up the JavaScript bridge. Callers hold w.mu.
 func (w *Webview) bindBridge() {
 	if w.bridge == nil {
 		return
 	}
 
+	w.calls = make(map[string]callHandler)
+
 	// Create a unified bridge function
-	w.instance.Bind("__polyglot_call__", func(name string, argsJSON string) (string, error) {
-		// Parse arguments
-		var args []interface{}
-		if argsJSON != "" {
-			if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-				return "", fmt.Errorf("invalid arguments: %w", err)
-			}
+	w.bindCallLocked("__polyglot_call__", func(name string, argsJSON string) (string, error) {
+		if err := w.checkMessageSize(len(argsJSON)); err != nil {
+			return "", bridgeError(err)
 		}
-
-		// Call bridge function (pass nil context for now)
-		result, err := w.bridge.Call(nil, name, args...)
-		if err != nil {
-			return "", err
+		if err := w.checkMessageShape([]byte(argsJSON)); err != nil {
+			return "", bridgeError(err)
 		}
 
-		// Serialize result
-		resultJSON, err := json.Marshal(result)
+		result, err := w.invoke(bridgeCodec(w.config, core.FormatJSON), name, []byte(argsJSON), bytesToString)
 		if err != nil {
-			return "", fmt.Errorf("failed to serialize result: %w", err)
+			return "", bridgeError(err)
 		}
-
-		return string(resultJSON), nil
+		return result, nil
 	})
 
-	// Inject bridge initialization script
-	initScript := `
+	// MessagePack payloads are posted as raw bytes when Binary is
+	// "transfer" and the page is served by an asset server, and travel
+	// base64-encoded over the string binding otherwise
+	packed := w.config.Serialization == core.FormatMsgpack
+	if packed {
+		w.bindCallLocked("__polyglot_call_packed__", func(name string, argsB64 string) (string, error) {
+			if err := w.checkMessageSize(base64.StdEncoding.DecodedLen(len(argsB64))); err != nil {
+				return "", bridgeError(err)
+			}
+
+			payload, err := base64.StdEncoding.DecodeString(argsB64)
+			if err != nil {
+				return "", bridgeError(core.Errorf(core.CodeInvalidArgument, "invalid arguments: %w", err))
+			}
+
+			result, err := w.invoke(bridgeCodec(w.config, core.FormatMsgpack), name, payload, base64.StdEncoding.EncodeToString)
+			if err != nil {
+				return "", bridgeError(err)
+			}
+			return result, nil
+		})
+	}
+	w.bindFrames()
+	w.bindCapabilities()
+	if w.handlers != nil {
+		w.bindDispatch()
+	}
+
+	// Inject the bridge script, which defines window.polyglot: it encodes
+	// calls for the configured transport, retries and rejects them with
+	// structured errors, and decodes results, streams and images
+	initScript := fmt.Sprintf(`
 		window.polyglot = {
+			preferPacked: %t,
+			dispatched: %t,
+			pending: {},
+			lastCall: 0,
+			retry: %s,
+			transfer: %t,
+			frameURL: %q,
+			frameToken: %q,
+			capabilities: %s,
+			nilUndefined: %t,
+			undefine: function(value) {
+				if (value === null) return undefined;
+				if (Array.isArray(value)) return value.map(this.undefine, this);
+				if (typeof value === 'object' && Object.getPrototypeOf(value) === Object.prototype) {
+					for (const key in value) value[key] = this.undefine(value[key]);
+				}
+				return value;
+			},
+			send: function(binding, name, payload) {
+				if (!this.dispatched) return window[binding](name, payload);
+				const id = ++this.lastCall;
+				return new Promise((resolve, reject) => {
+					this.pending[id] = { resolve: resolve, reject: reject };
+					__polyglot_dispatch__(id, binding, name, payload).catch((e) => {
+						delete this.pending[id];
+						reject(e);
+					});
+				});
+			},
+			settle: function(id, ok, value) {
+				const call = this.pending[id];
+				if (!call) return;
+				delete this.pending[id];
+				if (ok) call.resolve(value);
+				else call.reject(value);
+			},
+			refreshCapabilities: async function() {
+				this.capabilities = JSON.parse(await __polyglot_capabilities__());
+				return this.capabilities;
+			},
+			toError: function(e) {
+				let info = null;
+				try {
+					info = JSON.parse(typeof e === 'string' ? e : e.message);
+				} catch (_) {}
+				if (!info || typeof info !== 'object' || !info.code) {
+					info = { code: 'INTERNAL', message: String(e && e.message !== undefined ? e.message : e) };
+				}
+				const err = new Error(info.message);
+				err.code = info.code;
+				err.details = info.details || null;
+				return err;
+			},
+			encodeBase64: function(bytes) {
+				let binary = '';
+				for (let i = 0; i < bytes.length; i += 0x8000) {
+					binary += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+				}
+				return btoa(binary);
+			},
+			isBinary: function(v) {
+				return v instanceof ArrayBuffer || ArrayBuffer.isView(v);
+			},
+			hasBinary: function(v) {
+				if (this.isBinary(v)) return true;
+				if (Array.isArray(v)) return v.some((item) => this.hasBinary(item));
+				if (v && typeof v === 'object') return Object.keys(v).some((k) => this.hasBinary(v[k]));
+				return false;
+			},
+			encodeFrame: function(value) {
+				const blobs = [];
+				const paths = [];
+				const strip = (v, path) => {
+					if (this.isBinary(v)) {
+						blobs.push(v instanceof ArrayBuffer ? new Uint8Array(v) : new Uint8Array(v.buffer, v.byteOffset, v.byteLength));
+						paths.push(path);
+						return null;
+					}
+					if (Array.isArray(v)) return v.map((item, i) => strip(item, path.concat([i])));
+					if (v && typeof v === 'object' && v.constructor === Object) {
+						const out = {};
+						for (const k of Object.keys(v)) out[k] = strip(v[k], path.concat([k]));
+						return out;
+					}
+					return v;
+				};
+				const stripped = strip(value, []);
+				const header = new TextEncoder().encode(JSON.stringify({ value: stripped, sizes: blobs.map((b) => b.length), paths: paths }));
+				let size = 8 + header.length;
+				blobs.forEach((b) => { size += b.length; });
+				const frame = new Uint8Array(size);
+				frame.set([80, 71, 70, 50]);
+				new DataView(frame.buffer).setUint32(4, header.length);
+				frame.set(header, 8);
+				let offset = 8 + header.length;
+				blobs.forEach((b) => { frame.set(b, offset); offset += b.length; });
+				return frame;
+			},
+			decodeFrame: function(frame) {
+				const headerLength = new DataView(frame.buffer, frame.byteOffset, frame.byteLength).getUint32(4);
+				const header = JSON.parse(new TextDecoder().decode(frame.subarray(8, 8 + headerLength)));
+				const blobs = [];
+				let offset = 8 + headerLength;
+				(header.sizes || []).forEach((n) => { blobs.push(frame.subarray(offset, offset + n)); offset += n; });
+				let value = header.value;
+				(header.paths || []).forEach((path, i) => {
+					if (path.length === 0) {
+						value = blobs[i];
+						return;
+					}
+					let parent = value;
+					for (let j = 0; j < path.length - 1; j++) parent = parent[path[j]];
+					parent[path[path.length - 1]] = blobs[i];
+				});
+				return value;
+			},
+			post: async function(name, format, body) {
+				if (!this.transfer || location.protocol.indexOf('http') !== 0) return null;
+				let response = null;
+				try {
+					response = await fetch(this.frameURL + '?name=' + encodeURIComponent(name) + (format ? '&format=' + format : ''), {
+						method: 'POST',
+						headers: { 'Content-Type': 'application/octet-stream', 'X-Polyglot-Token': this.frameToken },
+						body: body
+					});
+				} catch (_) {}
+				if (response && response.ok) return new Uint8Array(await response.arrayBuffer());
+				if (response && response.status === 422) throw await response.text();
+				// The page is not served by an asset server accepting frames
+				this.transfer = false;
+				return null;
+			},
+			invokeFrame: async function(name, args) {
+				const frame = this.encodeFrame(args);
+				const posted = await this.post(name, '', frame);
+				if (posted) return this.decodeFrame(posted);
+				const resultB64 = await this.send('__polyglot_call_frame__', name, this.encodeBase64(frame));
+				return this.decodeFrame(this.decodeBase64(resultB64));
+			},
+			decodeBase64: function(b64) {
+				const raw = atob(b64);
+				const bytes = new Uint8Array(raw.length);
+				for (let i = 0; i < raw.length; i++) bytes[i] = raw.charCodeAt(i);
+				return bytes;
+			},
+			readFile: async function(file) {
+				const parts = [];
+				let done = false;
+				try {
+					for (;;) {
+						const chunk = await __polyglot_read__(file.id);
+						if (!chunk) break;
+						parts.push(this.decodeBase64(chunk));
+					}
+					done = true;
+				} finally {
+					if (!done) __polyglot_close_file__(file.id);
+				}
+				const blob = new Blob(parts, { type: file.mimeType });
+				const url = URL.createObjectURL(blob);
+				return {
+					name: file.name,
+					mimeType: file.mimeType,
+					blob: blob,
+					url: url,
+					download: function() {
+						const link = document.createElement('a');
+						link.href = url;
+						link.download = file.name;
+						document.body.appendChild(link);
+						link.click();
+						link.remove();
+					}
+				};
+			},
+			readImage: function(image) {
+				const bytes = typeof image.data === 'string' ? this.decodeBase64(image.data) : new Uint8Array(image.data || []);
+				const blob = new Blob([bytes], { type: image.mimeType });
+				const url = URL.createObjectURL(blob);
+				return {
+					mimeType: image.mimeType,
+					bytes: bytes,
+					blob: blob,
+					url: url,
+					element: function() {
+						const img = document.createElement('img');
+						img.src = url;
+						return img;
+					}
+				};
+			},
+			readStream: async function*(stream) {
+				let done = false;
+				try {
+					for (;;) {
+						let next;
+						try {
+							next = JSON.parse(await __polyglot_next__(stream.id));
+						} catch (e) {
+							done = true;
+							throw this.toError(e);
+						}
+						if (next.done) {
+							done = true;
+							return;
+						}
+						yield this.nilUndefined ? this.undefine(next.value) : next.value;
+					}
+				} finally {
+					if (!done) __polyglot_close__(stream.id);
+				}
+			},
+			collectStream: async function(stream) {
+				const chunks = [];
+				for await (const chunk of this.readStream(stream)) chunks.push(chunk);
+				if (!chunks.every(Array.isArray)) return chunks;
+				const items = [];
+				for (const chunk of chunks) {
+					for (const item of chunk) items.push(item);
+				}
+				return items;
+			},
+			stream: async function*(name, ...args) {
+				let result;
+				try {
+					result = await this.invoke(name, args);
+				} catch (e) {
+					throw this.toError(e);
+				}
+				if (result && result.__polyglot_stream__) {
+					yield* this.readStream(result.__polyglot_stream__);
+					return;
+				}
+				yield this.nilUndefined ? this.undefine(result) : result;
+			},
+			format: function() {
+				const mp = window.MessagePack;
+				return this.preferPacked && mp && mp.encode && mp.decode ? 'msgpack' : 'json';
+			},
 			call: async function(name, ...args) {
+				return this.callWith(this.retry, name, args);
+			},
+			callOnce: async function(name, ...args) {
+				return this.callWith(null, name, args);
+			},
+			on: function(event, handler) {
+				const listener = function(e) { handler(e.detail); };
+				window.addEventListener('polyglot:' + event, listener);
+				return function() { window.removeEventListener('polyglot:' + event, listener); };
+			},
+			batch: async function(group, ops) {
+				return this.callWith(null, group + '.batch', [ops]);
+			},
+			callWith: async function(retry, name, args) {
+				const attempts = retry && retry.maxAttempts > 1 ? retry.maxAttempts : 1;
+				let delay = retry ? retry.backoffMs : 0;
+				for (let attempt = 1; ; attempt++) {
+					try {
+						const result = await this.invoke(name, args);
+						if (result && result.__polyglot_file__) {
+							return await this.readFile(result.__polyglot_file__);
+						}
+						if (result && result.__polyglot_stream__) {
+							return await this.collectStream(result.__polyglot_stream__);
+						}
+						if (result && result.__polyglot_image__) {
+							return this.readImage(result.__polyglot_image__);
+						}
+						return this.nilUndefined ? this.undefine(result) : result;
+					} catch (e) {
+						const err = this.toError(e);
+						if (attempt >= attempts || retry.retryOn.indexOf(err.code) < 0) throw err;
+						const wait = retry.maxBackoffMs > 0 ? Math.min(delay, retry.maxBackoffMs) : delay;
+						await new Promise(function(resolve) { setTimeout(resolve, wait); });
+						delay *= 2;
+					}
+				}
+			},
+			invoke: async function(name, args) {
+				if (this.hasBinary(args)) {
+					return this.invokeFrame(name, args);
+				}
+				if (this.format() === 'msgpack') {
+					const packed = window.MessagePack.encode(args);
+					const posted = await this.post(name, 'msgpack', packed);
+					if (posted) return window.MessagePack.decode(posted);
+					const resultB64 = await this.send('__polyglot_call_packed__', name, this.encodeBase64(packed));
+					return window.MessagePack.decode(this.decodeBase64(resultB64));
+				}
 				const argsJSON = JSON.stringify(args);
-				const resultJSON = await __polyglot_call__(name, argsJSON);
+				const resultJSON = await this.send('__polyglot_call__', name, argsJSON);
 				return JSON.parse(resultJSON);
 			}
 		};
-	`
+	`, packed, w.handlers != nil, retryScript(w.config.Retry), w.config.Binary == core.BinaryTransfer, FramePath, w.frameToken, w.capabilitiesJSON(),
+		core.ParseNilPolicy(w.config.Nil) == core.NilUndefined)
 	w.instance.Init(initScript)
+	w.bindFiles()
+	w.bindStreams()
+}
+
+// invoke decodes arguments, calls the bridge, and encodes the result,
+// converting it with text before pooled buffers are reused
+func (w *Webview) invoke(codec core.Codec, name string, payload []byte, text func([]byte) string) (string, error) {
+	if w.arena != nil {
+		return w.invokePooled(codec, name, payload, text)
+	}
+
+	// Parse arguments
+	var args []interface{}
+	if len(payload) > 0 {
+		decoded, err := codec.Unmarshal(payload)
+		if err != nil {
+			return "", argumentsError(err)
+		}
+		if decoded != nil {
+			list, ok := decoded.([]interface{})
+			if !ok {
+				return "", core.Errorf(core.CodeInvalidArgument, "invalid arguments: expected array, got %T", decoded)
+			}
+			args = list
+		}
+	}
+
+	// Call bridge function
+	result, err := w.bridge.Call(context.Background(), name, args...)
+	if err != nil {
+		return "", err
+	}
+
+	// Serialize result, replacing files and streams with descriptors
+	described := w.describe(result)
+	encoded, err := codec.Marshal(described)
+	if err != nil {
+		w.discard(described)
+		return "", fmt.Errorf("failed to serialize result: %w", err)
+	}
+
+	return text(encoded), nil
+}
+
+// invokePooled is invoke using buffers from the call arena
+func (w *Webview) invokePooled(codec core.Codec, name string, payload []byte, text func([]byte) string) (string, error) {
+	buffers := w.arena.Acquire()
+	defer buffers.Release()
+
+	var args []interface{}
+	if len(payload) > 0 {
+		decoded, err := buffers.DecodeArgs(codec, payload)
+		if err != nil {
+			return "", argumentsError(err)
+		}
+		args = decoded
+	}
+
+	result, err := w.bridge.Call(context.Background(), name, args...)
+	if err != nil {
+		return "", err
+	}
+
+	described := w.describe(result)
+	encoded, err := buffers.Encode(codec, described)
+	if err != nil {
+		w.discard(described)
+		return "", fmt.Errorf("failed to serialize result: %w", err)
+	}
+	return text(encoded), nil
+}
+
+// argumentsError reports bridge arguments that failed to decode, keeping
+// typed errors such as an exceeded message limit as they are
+func argumentsError(err error) error {
+	var typed *core.Error
+	if errors.As(err, &typed) {
+		return err
+	}
+	return core.Errorf(core.CodeInvalidArgument, "invalid arguments: %w", err)
+}
+
+func bytesToString(b []byte) string {
+	return string(b)
+}
+
+// retryScript encodes a retry policy for the injected script
+func retryScript(policy *core.RetryPolicy) string {
+	if policy == nil || policy.MaxAttempts < 2 {
+		return "null"
+	}
+
+	encoded, err := json.Marshal(map[string]interface{}{
+		"maxAttempts":  policy.MaxAttempts,
+		"backoffMs":    policy.Backoff.Milliseconds(),
+		"maxBackoffMs": policy.MaxBackoff.Milliseconds(),
+		"retryOn":      policy.Codes(),
+	})
+	if err != nil {
+		return "null"
+	}
+	return string(encoded)
+}
+
+// checkMessageSize rejects bridge arguments larger than MaxMessageBytes
+// before they are decoded
+func (w *Webview) checkMessageSize(size int) error {
+	limit := w.config.MaxMessageBytes
+	if limit == 0 {
+		limit = core.DefaultMaxMessageBytes
+	}
+
+	if limit > 0 && size > limit {
+		return core.Errorf(core.CodeTooLarge, "message of %d bytes exceeds limit of %d bytes", size, limit).
+			WithDetail("size", size).
+			WithDetail("limit", limit)
+	}
+	return nil
+}
+
+// checkMessageShape rejects JSON bridge arguments nested deeper than
+// MaxMessageDepth or holding more than MaxMessageTokens values before they
+// are decoded. It only scans the payload; malformed JSON is left for the
+// decoder to report.
+func (w *Webview) checkMessageShape(payload []byte) error {
+	maxDepth := messageLimit(w.config.MaxMessageDepth, core.DefaultMaxMessageDepth)
+	maxTokens := messageLimit(w.config.MaxMessageTokens, core.DefaultMaxMessageTokens)
+	if maxDepth == 0 && maxTokens == 0 {
+		return nil
+	}
+
+	depth, tokens := 0, 0
+	for i := 0; i < len(payload); i++ {
+		switch payload[i] {
+		case ' ', '\t', '\n', '\r', ',', ':':
+			continue
+		case ']', '}':
+			depth--
+			continue
+		case '[', '{':
+			depth++
+			if maxDepth > 0 && depth > maxDepth {
+				return core.Errorf(core.CodeTooLarge, "message nests deeper than %d levels", maxDepth).
+					WithDetail("limit", maxDepth)
+			}
+		case '"':
+			for i++; i < len(payload) && payload[i] != '"'; i++ {
+				if payload[i] == '\\' {
+					i++
+				}
+			}
+		default:
+			for i+1 < len(payload) && !jsonDelimiter(payload[i+1]) {
+				i++
+			}
+		}
+
+		tokens++
+		if maxTokens > 0 && tokens > maxTokens {
+			return core.Errorf(core.CodeTooLarge, "message holds more than %d values", maxTokens).
+				WithDetail("limit", maxTokens)
+		}
+	}
+	return nil
+}
+
+// messageLimit resolves a message limit setting: zero uses def, and a
+// negative value disables the limit, returned as 0
+func messageLimit(setting, def int) int {
+	switch {
+	case setting == 0:
+		return def
+	case setting < 0:
+		return 0
+	}
+	return setting
+}
+
+// jsonDelimiter reports whether c ends a JSON number or literal
+func jsonDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', ',', ':', ']', '}', '[', '{', '"':
+		return true
+	}
+	return false
+}
+
+// bridgeError converts an error into one whose message is the JSON encoded
+// core.ErrorInfo, which the injected script turns into a structured Error
+func bridgeError(err error) error {
+	encoded, marshalErr := json.Marshal(core.ErrorInfoFor(err))
+	if marshalErr != nil {
+		return err
+	}
+	return errors.New(string(encoded))
+}
+
+// bindWindowControls exposes window state control to JavaScript
+func (w *Webview) bindWindowControls() {
+	w.instance.Bind("__polyglot_window__", func(action string, enable bool) (string, error) {
+		var err error
+		switch action {
+		case "minimize":
+			err = w.Minimize()
+		case "maximize":
+			err = w.Maximize()
+		case "fullscreen":
+			err = w.Fullscreen(enable)
+		case "restore":
+			err = w.Restore()
+		case "state":
+		default:
+			err = fmt.Errorf("unknown window action: %s", action)
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(w.WindowState()), nil
+	})
+	w.instance.Bind("__polyglot_zoom__", w.zoomAction)
+
+	w.instance.Init(`
+		window.polyglot = window.polyglot || {};
+		window.polyglot.window = {
+			minimize: function() { return __polyglot_window__('minimize', false); },
+			maximize: function() { return __polyglot_window__('maximize', false); },
+			fullscreen: function(enable) { return __polyglot_window__('fullscreen', enable !== false); },
+			restore: function() { return __polyglot_window__('restore', false); },
+			state: function() { return __polyglot_window__('state', false); },
+			zoom: function(factor) { return factor === undefined ? __polyglot_zoom__('get', 0) : __polyglot_zoom__('set', factor); },
+			zoomIn: function() { return __polyglot_zoom__('in', 0); },
+			zoomOut: function() { return __polyglot_zoom__('out', 0); },
+			resetZoom: function() { return __polyglot_zoom__('reset', 0); }
+		};
+	`)
+	// A browser tab keeps the browser's own zoom shortcuts
+	if w.mode != ModeBrowser {
+		w.instance.Init(zoomShortcutScript)
+	}
 }
@@ -0,0 +1,142 @@
+package webview
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/griffincancode/polyglot.js/core"
+)
+
+// Zoom limits, and the step zoomIn, zoomOut and their shortcuts change
+// the zoom by
+const (
+	MinZoom  = 0.25
+	MaxZoom  = 5.0
+	ZoomStep = 0.1
+)
+
+// zoomShortcutScript zooms the page with Ctrl (Cmd on macOS) and +, - or
+// 0, unless the page handled the key itself
+const zoomShortcutScript = `
+		window.addEventListener('keydown', function(e) {
+			if (e.defaultPrevented || !(e.ctrlKey || e.metaKey) || e.altKey) return;
+			var action = {'+': 'in', '=': 'in', '-': 'out', '_': 'out', '0': 'reset'}[e.key];
+			if (!action) return;
+			e.preventDefault();
+			__polyglot_zoom__(action, 0);
+		});
+	`
+
+// SetZoom scales the page by factor, where 1 is unzoomed, for
+// accessibility and high-DPI screens. Factors outside MinZoom-MaxZoom are
+// clamped. A zoom set before Initialize is applied when the window is
+// created. With RememberState the zoom is saved and restored on the next
+// launch.
+func (w *Webview) SetZoom(factor float64) error {
+	if math.IsNaN(factor) || math.IsInf(factor, 0) || factor <= 0 {
+		return core.Errorf(core.CodeInvalidArgument, "invalid zoom factor %v", factor)
+	}
+	factor = clampZoom(factor)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.instance != nil {
+		if err := w.instance.SetZoom(factor); err != nil {
+			return err
+		}
+	}
+	w.zoom = factor
+
+	if w.store != nil {
+		if err := w.store.Save(SavedState{Zoom: factor}); err != nil {
+			w.logger.Log(core.LogWarn, fmt.Sprintf("failed to remember zoom: %v", err))
+		}
+	}
+	return nil
+}
+
+// Zoom returns the page zoom factor
+func (w *Webview) Zoom() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.zoom
+}
+
+// SetStateStore remembers window state in store instead of the file
+// RememberState uses, and restores the state it holds. A nil store stops
+// remembering state.
+func (w *Webview) SetStateStore(store StateStore) error {
+	var state SavedState
+	if store != nil {
+		var err error
+		if state, err = store.Load(); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.store = store
+	if state.Zoom <= 0 || math.IsNaN(state.Zoom) || math.IsInf(state.Zoom, 0) {
+		return nil
+	}
+	zoom := clampZoom(state.Zoom)
+	if w.instance != nil {
+		if err := w.instance.SetZoom(zoom); err != nil {
+			return err
+		}
+	}
+	w.zoom = zoom
+	return nil
+}
+
+// rememberState restores and saves state in the file config.StateFile
+// names, for RememberState
+func (w *Webview) rememberState() {
+	path := w.config.StateFile
+	if path == "" {
+		var err error
+		if path, err = DefaultStateFile(w.config.Title); err != nil {
+			w.logger.Log(core.LogWarn, fmt.Sprintf("window state will not be remembered: %v", err))
+			return
+		}
+	}
+	if err := w.SetStateStore(NewFileStateStore(path)); err != nil {
+		w.logger.Log(core.LogWarn, fmt.Sprintf("failed to restore window state: %v", err))
+	}
+}
+
+// zoomAction applies a zoom action from the page and returns the new zoom
+func (w *Webview) zoomAction(action string, factor float64) (float64, error) {
+	var err error
+	switch action {
+	case "get":
+	case "set":
+		err = w.SetZoom(factor)
+	case "in":
+		err = w.SetZoom(stepZoom(w.Zoom(), ZoomStep))
+	case "out":
+		err = w.SetZoom(stepZoom(w.Zoom(), -ZoomStep))
+	case "reset":
+		err = w.SetZoom(1)
+	default:
+		err = fmt.Errorf("unknown zoom action: %s", action)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return w.Zoom(), nil
+}
+
+// clampZoom limits factor to MinZoom-MaxZoom
+func clampZoom(factor float64) float64 {
+	return math.Min(math.Max(factor, MinZoom), MaxZoom)
+}
+
+// stepZoom changes zoom by delta, rounded to whole percents so repeated
+// steps do not drift
+func stepZoom(zoom, delta float64) float64 {
+	return clampZoom(math.Round((zoom+delta)*100) / 100)
+}